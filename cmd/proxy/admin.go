@@ -0,0 +1,96 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/hfi/llm-secret-interceptor/internal/adminclient"
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+)
+
+// adminTokenEnv holds the admin API token of commands calling a running proxy
+const adminTokenEnv = "LLM_PROXY_ADMIN_TOKEN"
+
+// adminFlags are the flags of commands calling the admin API
+type adminFlags struct {
+	url    string
+	caCert string
+	cert   string
+	key    string
+}
+
+// register adds the admin API flags to fs
+func (f *adminFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.url, "url", "", "management server URL (default: derived from metrics.listen)")
+	fs.StringVar(&f.caCert, "cacert", "", "CA bundle verifying the management server (default: tls.ca_cert)")
+	fs.StringVar(&f.cert, "cert", "", "client certificate for management servers requiring mTLS")
+	fs.StringVar(&f.key, "key", "", "key of the client certificate")
+}
+
+// client creates an admin API client. The token is read from
+// LLM_PROXY_ADMIN_TOKEN, or from metrics.admin.token of the configuration.
+func (f *adminFlags) client() (*adminclient.Client, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	token := os.Getenv(adminTokenEnv)
+	if token == "" {
+		token = cfg.Metrics.Admin.Token
+	}
+	if token == "" {
+		return nil, fmt.Errorf("no admin token: set %s", adminTokenEnv)
+	}
+
+	baseURL := f.url
+	if baseURL == "" {
+		if baseURL, err = managementURL(cfg); err != nil {
+			return nil, err
+		}
+	}
+	if !strings.HasPrefix(baseURL, "https://") {
+		return adminclient.New(baseURL, token, nil), nil
+	}
+
+	caCerts := []string{f.caCert}
+	if f.caCert == "" {
+		// The management server falls back to a certificate of the interception CA
+		caCerts = nil
+		if _, err := os.Stat(cfg.TLS.CACert); err == nil {
+			caCerts = []string{cfg.TLS.CACert}
+		}
+	}
+	tlsConfig, err := adminclient.TLSConfig(caCerts, f.cert, f.key)
+	if err != nil {
+		return nil, err
+	}
+	return adminclient.New(baseURL, token, tlsConfig), nil
+}
+
+// managementURL derives the URL of the local management server from the
+// configuration
+func managementURL(cfg *config.Config) (string, error) {
+	addr := cfg.Metrics.Listen
+	if addr == "" {
+		addr = fmt.Sprintf(":%d", cfg.Metrics.Port)
+	}
+	if strings.HasPrefix(addr, "systemd:") {
+		return "", errors.New("the management server listens on a systemd socket: set -url")
+	}
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid metrics listen address %q: %w", addr, err)
+	}
+	if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+		host = "127.0.0.1"
+	}
+	scheme := "http"
+	if cfg.Metrics.MTLS.ClientCA != "" {
+		scheme = "https"
+	}
+	return scheme + "://" + net.JoinHostPort(host, port), nil
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/bench"
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/proxy"
+)
+
+// runBench measures the throughput and latency of the configured
+// interceptors on sample corpora
+func runBench(args []string) int {
+	fs := flag.NewFlagSet("bench", flag.ContinueOnError)
+	duration := fs.Duration("duration", 2*time.Second, "how long each interceptor runs per CPU count")
+	cpuList := fs.String("cpus", defaultBenchCPUs(), "comma-separated CPU counts to measure with")
+	jsonOutput := fs.Bool("json", false, "print the report as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s bench [flags] <corpus.jsonl | sample file>...\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+	cpus, err := parseCPUList(*cpuList)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid -cpus: %v\n", err)
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+		return 1
+	}
+	manager, err := proxy.NewInterceptorManager(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize interceptors: %v\n", err)
+		return 1
+	}
+	samples, err := bench.LoadSamples(fs.Args())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load samples: %v\n", err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	report, err := bench.Run(ctx, manager, samples, bench.Options{Duration: *duration, CPUs: cpus})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Benchmark failed: %v\n", err)
+		return 1
+	}
+	if *jsonOutput {
+		err = report.WriteJSON(os.Stdout)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write report: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// defaultBenchCPUs measures with one core and all cores
+func defaultBenchCPUs() string {
+	if n := runtime.NumCPU(); n > 1 {
+		return "1," + strconv.Itoa(n)
+	}
+	return "1"
+}
+
+// parseCPUList parses a comma-separated list of positive CPU counts
+func parseCPUList(list string) ([]int, error) {
+	var cpus []int
+	for field := range strings.SplitSeq(list, ",") {
+		n, err := strconv.Atoi(strings.TrimSpace(field))
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("%q is not a positive CPU count", field)
+		}
+		cpus = append(cpus, n)
+	}
+	return cpus, nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/proxy"
+)
+
+// runExplain reports every rule and candidate the configured interceptors
+// evaluate on a text, why candidates were skipped and what the proxy would
+// do with the secrets found
+func runExplain(args []string) int {
+	fs := flag.NewFlagSet("explain", flag.ContinueOnError)
+	file := fs.String("file", "", "read the text from a file, - for stdin")
+	jsonOutput := fs.Bool("json", false, "print the report as JSON")
+	verbose := fs.Bool("verbose", false, "also list the rules that matched nothing")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s explain [flags] (-file <path> | <text...>)\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if (*file == "") == (fs.NArg() == 0) {
+		fs.Usage()
+		return 2
+	}
+
+	text := strings.Join(fs.Args(), " ")
+	if *file != "" {
+		var data []byte
+		var err error
+		if *file == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(filepath.Clean(*file))
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read text: %v\n", err)
+			return 1
+		}
+		text = string(data)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+		return 1
+	}
+	report, err := proxy.Explain(cfg, text)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to explain text: %v\n", err)
+		return 1
+	}
+
+	if *jsonOutput {
+		err = report.WriteJSON(os.Stdout)
+	} else {
+		err = report.WriteText(os.Stdout, *verbose)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write report: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"crypto/x509"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/proxy"
+)
+
+// runExportCA prints the CA certificate clients trust or installs it into
+// the trust stores of this machine
+func runExportCA(args []string) int {
+	fs := flag.NewFlagSet("export-ca", flag.ContinueOnError)
+	certPath := fs.String("cert", "", "CA certificate bundle (default: tls.ca_cert of the configuration)")
+	format := fs.String("format", "pem", "output format: pem, der or p12")
+	out := fs.String("out", "", "write the certificate to this file instead of stdout")
+	password := fs.String("p12-password", "", "password of the PKCS#12 bundle (default: tls.p12_password)")
+	install := fs.Bool("install", false, "install the certificate into the trust stores")
+	stores := fs.String("stores", "system,nss", "trust stores to install into; the system store needs root, nss the browser user")
+	dryRun := fs.Bool("dry-run", false, "print the install commands without running them")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s export-ca [flags]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 || (*dryRun && !*install) {
+		fs.Usage()
+		return 2
+	}
+
+	if *certPath == "" || *password == "" {
+		cfg, err := config.Load()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+			return 1
+		}
+		if *certPath == "" {
+			*certPath = cfg.TLS.CACert
+		}
+		if *password == "" {
+			*password = cfg.TLS.P12Password
+		}
+	}
+	anchor, err := proxy.LoadCAAnchor(*certPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load CA certificate: %v\n", err)
+		return 1
+	}
+
+	if *install {
+		selected := strings.Split(*stores, ",")
+		for _, store := range selected {
+			if store != proxy.TrustStoreSystem && store != proxy.TrustStoreNSS {
+				fmt.Fprintf(os.Stderr, "Unknown trust store %q, want system or nss\n", store)
+				return 2
+			}
+		}
+		return installCA(anchor, selected, *dryRun)
+	}
+
+	data, err := proxy.EncodeCA(anchor, *format, *password)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to encode CA certificate: %v\n", err)
+		return 1
+	}
+	if *out != "" {
+		err = os.WriteFile(filepath.Clean(*out), data, 0644) //#nosec G306 -- holds the public CA certificate only
+	} else {
+		_, err = os.Stdout.Write(data)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write CA certificate: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// installCA installs the CA certificate into the given trust stores, or
+// only prints the commands in a dry run
+func installCA(anchor *x509.Certificate, stores []string, dryRun bool) int {
+	// The bundle may hold intermediates; only the root is installed
+	pemData, err := proxy.EncodeCA(anchor, "pem", "")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to encode CA certificate: %v\n", err)
+		return 1
+	}
+	tmp, err := os.CreateTemp("", "llm-secret-interceptor-ca-*.crt")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write CA certificate: %v\n", err)
+		return 1
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(pemData); err != nil {
+		_ = tmp.Close()
+		fmt.Fprintf(os.Stderr, "Failed to write CA certificate: %v\n", err)
+		return 1
+	}
+	if err := tmp.Close(); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write CA certificate: %v\n", err)
+		return 1
+	}
+
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = ""
+	}
+	failed := 0
+	for _, step := range proxy.TrustStorePlan(runtime.GOOS, tmp.Name(), home, stores, exec.LookPath) {
+		if step.Skip != "" {
+			fmt.Printf("[%s] skipped: %s\n", step.Store, step.Skip)
+			continue
+		}
+		fmt.Printf("[%s] %s\n", step.Store, strings.Join(step.Command, " "))
+		if dryRun {
+			continue
+		}
+		cmd := exec.Command(step.Command[0], step.Command[1:]...) //#nosec G204 -- commands are built by TrustStorePlan from fixed tools
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			fmt.Fprintf(os.Stderr, "[%s] failed: %v\n", step.Store, err)
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d install steps failed; the system store needs root or administrator rights\n", failed)
+		return 1
+	}
+	if dryRun {
+		fmt.Println("Dry run: no trust store was changed")
+	}
+	return 0
+}
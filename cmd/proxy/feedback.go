@@ -0,0 +1,141 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/hfi/llm-secret-interceptor/internal/proxy"
+)
+
+// runFeedback reports false positives to a running proxy and lists the
+// reports per rule through its admin API
+func runFeedback(args []string) int {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s feedback <report|list> [flags]\n", os.Args[0])
+	}
+	if len(args) == 0 {
+		usage()
+		return 2
+	}
+
+	switch args[0] {
+	case "report":
+		return runFeedbackReport(args[1:])
+	case "list":
+		return runFeedbackList(args[1:])
+	default:
+		usage()
+		return 2
+	}
+}
+
+// runFeedbackReport reports the value behind a placeholder as no secret
+func runFeedbackReport(args []string) int {
+	fs := flag.NewFlagSet("feedback report", flag.ContinueOnError)
+	var admin adminFlags
+	admin.register(fs)
+	namespace := fs.String("namespace", "", "client namespace of the placeholder (e.g. ip:10.0.0.5)")
+	reason := fs.String("reason", "", "why the value is no secret, kept in the allowlist")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s feedback report [flags] <placeholder>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	client, err := admin.client()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	result, err := client.ReportFalsePositive(context.Background(), proxy.FalsePositive{
+		Placeholder: fs.Arg(0),
+		Namespace:   *namespace,
+		Reason:      *reason,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to report false positive: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("Reported %s as false positive of rule %s\n", result.Placeholder, orDash(ruleOrInterceptor(result.Metadata.Rule, result.Metadata.Interceptor)))
+	if result.Allowlisted {
+		fmt.Println("The value is allowlisted and no longer masked")
+	}
+	return 0
+}
+
+// runFeedbackList lists the false positives reported per rule
+func runFeedbackList(args []string) int {
+	fs := flag.NewFlagSet("feedback list", flag.ContinueOnError)
+	var admin adminFlags
+	admin.register(fs)
+	jsonOutput := fs.Bool("json", false, "print the counts as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s feedback list [flags]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	client, err := admin.client()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	rules, err := client.FalsePositives(context.Background())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to list false positives: %v\n", err)
+		return 1
+	}
+
+	if *jsonOutput {
+		err = writeJSON(os.Stdout, rules)
+	} else {
+		err = writeFeedbackTable(os.Stdout, rules)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write false positives: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// writeFeedbackTable writes the false positives per rule as a table
+func writeFeedbackTable(w io.Writer, rules []proxy.RuleFeedback) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "RULE\tINTERCEPTOR\tREPORTS\n")
+	total := 0
+	for _, r := range rules {
+		fmt.Fprintf(tw, "%s\t%s\t%d\n", orDash(r.Rule), orDash(r.Interceptor), r.Reports)
+		total += r.Reports
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%d false positives reported\n", total)
+	return err
+}
+
+// ruleOrInterceptor names a detection by its rule, or by its interceptor
+// when it has no rules
+func ruleOrInterceptor(rule, interceptor string) string {
+	if rule == "" {
+		return interceptor
+	}
+	return rule
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hfi/llm-secret-interceptor/internal/proxy"
+)
+
+// runGenerateCA generates a CA certificate and key, a PKCS#12 bundle of the
+// certificate for trust store tools refusing PEM, and prints how to install it
+func runGenerateCA(args []string) int {
+	fs := flag.NewFlagSet("generate-ca", flag.ContinueOnError)
+	password := fs.String("p12-password", "", "password of the PKCS#12 bundle")
+	noP12 := fs.Bool("no-p12", false, "do not write a PKCS#12 bundle")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s generate-ca [flags] [cert-path] [key-path]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 2 {
+		fs.Usage()
+		return 2
+	}
+
+	certPath := "./certs/ca.crt"
+	keyPath := "./certs/ca.key"
+	if fs.NArg() > 0 {
+		certPath = fs.Arg(0)
+	}
+	if fs.NArg() > 1 {
+		keyPath = fs.Arg(1)
+	}
+	if err := proxy.GenerateCA(certPath, keyPath); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to generate CA: %v\n", err)
+		return 1
+	}
+	fmt.Printf("CA certificate generated:\n  Certificate: %s\n  Key: %s\n", certPath, keyPath)
+
+	p12Path := strings.TrimSuffix(certPath, filepath.Ext(certPath)) + ".p12"
+	if !*noP12 {
+		if err := proxy.WriteCAPKCS12(certPath, p12Path, *password); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write PKCS#12 bundle: %v\n", err)
+			return 1
+		}
+		fmt.Printf("  PKCS#12: %s\n", p12Path)
+	}
+
+	fmt.Printf("\nInstall the certificate on clients:\n\n%s\n", proxy.CAInstallInstructions(certPath, p12Path))
+	return 0
+}
@@ -2,16 +2,19 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"fmt"
-	"net/http"
 	"os"
 	"os/signal"
+	"runtime"
 	"syscall"
 	"time"
 
 	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/metrics"
 	"github.com/hfi/llm-secret-interceptor/internal/proxy"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
+	mgmtserver "github.com/hfi/llm-secret-interceptor/internal/server"
 	"github.com/rs/zerolog"
 )
 
@@ -37,12 +40,15 @@ func main() {
 		Str("version", Version).
 		Str("commit", GitCommit).
 		Msg("Starting LLM Secret Interceptor")
+	metrics.RecordBuildInfo(Version, GitCommit)
 
 	ensureCA(cfg, logger)
 	server := createServer(cfg, logger)
-	startMetricsServer(cfg, logger)
+	startMetricsServer(server, cfg, logger)
+	startGRPCAdminServer(server, cfg, logger)
 	startProxyServer(server, logger, cfg)
 	startMappingStoreUpdater(server)
+	startKeyRotation(server, cfg, logger)
 	waitForShutdown(server, logger)
 }
 
@@ -56,9 +62,28 @@ func handleCommand() bool {
 	case "version":
 		printVersion()
 		return true
+	case "bench":
+		os.Exit(runBench(os.Args[2:]))
+	case "explain":
+		os.Exit(runExplain(os.Args[2:]))
+	case "export-ca":
+		os.Exit(runExportCA(os.Args[2:]))
 	case "generate-ca":
-		generateCA()
-		return true
+		os.Exit(runGenerateCA(os.Args[2:]))
+	case "ruletest":
+		os.Exit(runRuleTest(os.Args[2:]))
+	case "test-rule":
+		os.Exit(runTestRule(os.Args[2:]))
+	case "feedback":
+		os.Exit(runFeedback(os.Args[2:]))
+	case "mappings":
+		os.Exit(runMappings(os.Args[2:]))
+	case "purge":
+		os.Exit(runPurge(os.Args[2:]))
+	case "replay":
+		os.Exit(runReplay(os.Args[2:]))
+	case "selftest":
+		os.Exit(runSelfTest(os.Args[2:]))
 	}
 	return false
 }
@@ -67,22 +92,7 @@ func printVersion() {
 	fmt.Printf("LLM Secret Interceptor %s\n", Version)
 	fmt.Printf("Git Commit: %s\n", GitCommit)
 	fmt.Printf("Build Time: %s\n", BuildTime)
-}
-
-func generateCA() {
-	certPath := "./certs/ca.crt"
-	keyPath := "./certs/ca.key"
-	if len(os.Args) > 2 {
-		certPath = os.Args[2]
-	}
-	if len(os.Args) > 3 {
-		keyPath = os.Args[3]
-	}
-	if err := proxy.GenerateCA(certPath, keyPath); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to generate CA: %v\n", err)
-		os.Exit(1)
-	}
-	fmt.Printf("CA certificate generated:\n  Certificate: %s\n  Key: %s\n", certPath, keyPath)
+	fmt.Printf("Go Version: %s\n", runtime.Version())
 }
 
 func setupLogger() zerolog.Logger {
@@ -111,6 +121,10 @@ func configureLogLevel(cfg *config.Config) {
 }
 
 func ensureCA(cfg *config.Config, logger zerolog.Logger) {
+	if provider := cfg.TLS.CASigner.Provider; provider != "" && provider != "file" {
+		// The key is held by the signer; the certificate must be issued for it
+		return
+	}
 	if _, err := os.Stat(cfg.TLS.CACert); os.IsNotExist(err) {
 		logger.Info().Msg("CA certificate not found, generating...")
 		if err := proxy.GenerateCA(cfg.TLS.CACert, cfg.TLS.CAKey); err != nil {
@@ -131,40 +145,99 @@ func createServer(cfg *config.Config, logger zerolog.Logger) *proxy.Server {
 	return server
 }
 
-func startMetricsServer(cfg *config.Config, logger zerolog.Logger) {
+func startMetricsServer(server *proxy.Server, cfg *config.Config, logger zerolog.Logger) {
 	if !cfg.Metrics.Enabled {
 		return
 	}
+	tlsConfig, err := server.ManagementTLSConfig()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to configure metrics server")
+	}
+	metricsAddr := cfg.Metrics.Listen
+	if metricsAddr == "" {
+		metricsAddr = fmt.Sprintf(":%d", cfg.Metrics.Port)
+	}
+	// Listen before the proxy starts, which closes unclaimed inherited sockets
+	ln, err := server.Listen(metricsAddr)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to listen for metrics server")
+	}
+	if tlsConfig != nil {
+		ln = tls.NewListener(ln, tlsConfig)
+	}
+	mgmt := mgmtserver.New(&mgmtserver.Config{
+		Addr:        metricsAddr,
+		MetricsPath: cfg.Metrics.Endpoint,
+		HealthPath:  "/health",
+		ReadyPath:   "/ready",
+		LivePath:    "/live",
+		VersionPath: "/version",
+		Version:     Version,
+		Commit:      GitCommit,
+		BuildTime:   BuildTime,
+	})
+	mgmt.RegisterHealthCheck("store", server.CheckStore)
+	mgmt.RegisterHealthCheck("ca", server.CheckCA)
+	mgmt.RegisterHealthCheck("upstream", server.CheckUpstreams)
+	mgmt.Handle("/admin/usage", server.RequireAdmin(server.UsageHandler()))
+	mgmt.Handle("/admin/ca", server.RequireAdmin(server.CAAdminHandler()))
+	mgmt.Handle("/admin/mappings", server.RequireAdmin(server.MappingsAdminHandler()))
+	mgmt.Handle("/admin/mappings/", server.RequireAdmin(server.MappingsAdminHandler()))
+	mgmt.Handle("/admin/interceptors", server.RequireAdmin(server.InterceptorsAdminHandler()))
+	mgmt.Handle("/admin/interceptors/", server.RequireAdmin(server.InterceptorsAdminHandler()))
+	mgmt.Handle("/admin/rules", server.RequireAdmin(server.RulesAdminHandler()))
+	mgmt.Handle("/admin/rules/", server.RequireAdmin(server.RulesAdminHandler()))
+	mgmt.Handle("/admin/stats", server.RequireAdmin(server.StatsHandler()))
+	mgmt.Handle("/admin/config", server.RequireAdmin(server.ConfigHandler()))
+	mgmt.Handle("/admin/status", server.RequireAdmin(server.StatusHandler()))
+	mgmt.Handle("/admin/mode", server.RequireAdmin(server.ModeHandler()))
+	mgmt.Handle("/admin/events", server.RequireAdmin(server.EventsHandler()))
+	mgmt.Handle("/admin/explain", server.RequireAdmin(server.ExplainHandler()))
+	mgmt.Handle("/admin/feedback", server.RequireAdmin(server.FeedbackAdminHandler()))
+	mgmt.Handle("/dashboard", server.DashboardHandler())
+	mgmt.Handle("/ca.crt", server.CAHandler())
+	mgmt.Handle("/ca.der", server.CAHandler())
+	mgmt.Handle("/ca.p12", server.CAHandler())
+	mgmt.Handle("/ca/install", server.CAInstallHandler())
+	if cfg.Metrics.PAC.Enabled {
+		mgmt.Handle("/proxy.pac", server.PACHandler())
+	}
 	go func() {
-		metricsAddr := fmt.Sprintf(":%d", cfg.Metrics.Port)
-		mux := http.NewServeMux()
-		mux.Handle(cfg.Metrics.Endpoint, promhttp.Handler())
-		mux.HandleFunc("/health", func(w http.ResponseWriter, _ *http.Request) {
-			w.WriteHeader(http.StatusOK)
-			if _, err := w.Write([]byte("OK")); err != nil {
-				logger.Debug().Err(err).Msg("Failed to write health response")
-			}
-		})
 		logger.Info().Str("addr", metricsAddr).Msg("Starting metrics server")
-		metricsServer := &http.Server{
-			Addr:              metricsAddr,
-			Handler:           mux,
-			ReadHeaderTimeout: 10 * time.Second,
-			ReadTimeout:       30 * time.Second,
-			WriteTimeout:      30 * time.Second,
-			IdleTimeout:       60 * time.Second,
-		}
-		if err := metricsServer.ListenAndServe(); err != nil {
+		if err := mgmt.Serve(ln); err != nil {
 			logger.Error().Err(err).Msg("Metrics server error")
 		}
 	}()
 }
 
+// startGRPCAdminServer serves the admin API over gRPC if it has a listen
+// address
+func startGRPCAdminServer(server *proxy.Server, cfg *config.Config, logger zerolog.Logger) {
+	addr := cfg.Metrics.Admin.GRPC.Listen
+	if addr == "" {
+		return
+	}
+	grpcServer, err := server.NewGRPCAdminServer()
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to configure gRPC admin server")
+	}
+	ln, err := server.Listen(addr)
+	if err != nil {
+		logger.Fatal().Err(err).Msg("Failed to listen for gRPC admin server")
+	}
+	go func() {
+		logger.Info().Str("addr", addr).Msg("Starting gRPC admin server")
+		if err := grpcServer.Serve(ln); err != nil {
+			logger.Error().Err(err).Msg("gRPC admin server error")
+		}
+	}()
+}
+
 func startProxyServer(server *proxy.Server, logger zerolog.Logger, cfg *config.Config) {
 	if err := server.Start(); err != nil {
 		logger.Fatal().Err(err).Msg("Failed to start proxy server")
 	}
-	logger.Info().Str("listen", cfg.Proxy.Listen).Msg("Proxy server started")
+	logger.Info().Msg("Proxy server started")
 }
 
 func startMappingStoreUpdater(server *proxy.Server) {
@@ -177,10 +250,66 @@ func startMappingStoreUpdater(server *proxy.Server) {
 	}()
 }
 
+// startKeyRotation rotates the store data key once it is older than the
+// rotation interval, checking its age every rotation check interval
+func startKeyRotation(server *proxy.Server, cfg *config.Config, logger zerolog.Logger) {
+	encryption := cfg.Storage.Encryption
+	if !encryption.Enabled || encryption.RotationInterval <= 0 || encryption.RotationCheck <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(encryption.RotationCheck)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := server.RotateDataKey(context.Background()); err != nil {
+				logger.Error().Err(err).Msg("Data key rotation failed")
+			}
+		}
+	}()
+}
+
+// reloadConfig re-reads the configuration and rule files and applies them to
+// the running server; a configuration that fails to load or validate leaves
+// the running one in place
+func reloadConfig(server *proxy.Server, logger zerolog.Logger) {
+	logger.Info().Msg("Reloading configuration")
+	cfg, err := config.Load()
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to reload configuration, keeping current settings")
+		return
+	}
+	ignored, err := server.Reload(cfg)
+	if err != nil {
+		logger.Error().Err(err).Msg("Invalid configuration, keeping current settings")
+		return
+	}
+	configureLogLevel(cfg)
+	if len(ignored) > 0 {
+		logger.Warn().Strs("settings", ignored).Msg("Changed settings take effect after a restart")
+	}
+	logger.Info().Msg("Configuration reloaded")
+}
+
 func waitForShutdown(server *proxy.Server, logger zerolog.Logger) {
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	<-sigChan
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGUSR2)
+wait:
+	for sig := range sigChan {
+		switch sig {
+		case syscall.SIGHUP:
+			reloadConfig(server, logger)
+		case syscall.SIGUSR2:
+			// Hand the listeners to a new process, then drain and exit
+			logger.Info().Msg("Upgrading")
+			if err := server.Upgrade(); err != nil {
+				logger.Error().Err(err).Msg("Upgrade failed, continuing to serve")
+				continue
+			}
+			break wait
+		default:
+			break wait
+		}
+	}
 
 	logger.Info().Msg("Shutting down...")
 
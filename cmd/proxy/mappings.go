@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/proxy"
+	"github.com/hfi/llm-secret-interceptor/internal/storage"
+)
+
+// runMappings inspects and deletes the mappings of a running proxy through
+// its admin API
+func runMappings(args []string) int {
+	usage := func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s mappings <list|lookup|purge> [flags]\n", os.Args[0])
+	}
+	if len(args) == 0 {
+		usage()
+		return 2
+	}
+
+	switch args[0] {
+	case "list":
+		return runMappingsList(args[1:])
+	case "lookup":
+		return runMappingsLookup(args[1:])
+	case "purge":
+		return runMappingsPurge(args[1:])
+	default:
+		usage()
+		return 2
+	}
+}
+
+// runMappingsList lists mappings without their secrets
+func runMappingsList(args []string) int {
+	fs := flag.NewFlagSet("mappings list", flag.ContinueOnError)
+	var admin adminFlags
+	admin.register(fs)
+	filter := mappingsFilterFlags(fs)
+	limit := fs.Int("limit", 100, "list at most this many mappings, 0 for all")
+	jsonOutput := fs.Bool("json", false, "print the mappings as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s mappings list [flags]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 || *limit < 0 {
+		fs.Usage()
+		return 2
+	}
+
+	client, err := admin.client()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	mappings, err := client.ListMappings(context.Background(), *filter, *limit)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to list mappings: %v\n", err)
+		return 1
+	}
+
+	if *jsonOutput {
+		err = writeJSON(os.Stdout, mappings)
+	} else {
+		err = writeMappingsTable(os.Stdout, mappings)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write mappings: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runMappingsLookup describes the mapping of a placeholder
+func runMappingsLookup(args []string) int {
+	fs := flag.NewFlagSet("mappings lookup", flag.ContinueOnError)
+	var admin adminFlags
+	admin.register(fs)
+	namespace := fs.String("namespace", "", "client namespace of the placeholder (e.g. ip:10.0.0.5)")
+	jsonOutput := fs.Bool("json", false, "print the mapping as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s mappings lookup [flags] <placeholder>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	client, err := admin.client()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	info, found, err := client.LookupMapping(context.Background(), *namespace, fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to look up mapping: %v\n", err)
+		return 1
+	}
+	if !found {
+		// The usual cause of placeholders reaching clients unrestored
+		fmt.Fprintf(os.Stderr, "No mapping for %s: it expired, was purged or belongs to another namespace\n", fs.Arg(0))
+		return 1
+	}
+
+	if *jsonOutput {
+		err = writeJSON(os.Stdout, info)
+	} else {
+		err = writeMapping(os.Stdout, info)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write mapping: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// runMappingsPurge deletes a mapping or the mappings selected by a filter
+func runMappingsPurge(args []string) int {
+	fs := flag.NewFlagSet("mappings purge", flag.ContinueOnError)
+	var admin adminFlags
+	admin.register(fs)
+	filter := mappingsFilterFlags(fs)
+	all := fs.Bool("all", false, "purge all mappings")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s mappings purge [flags] [placeholder]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	placeholder := fs.Arg(0)
+	switch {
+	case fs.NArg() > 1:
+		fs.Usage()
+		return 2
+	case placeholder != "" && (*all || filter.SecretType != "" || filter.RequestID != "" || filter.OlderThan != 0):
+		// A placeholder is only qualified by its namespace
+		fs.Usage()
+		return 2
+	case placeholder == "" && filter.IsEmpty() == !*all:
+		// Purging everything must be requested explicitly and exclusively
+		fs.Usage()
+		return 2
+	}
+
+	client, err := admin.client()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		return 1
+	}
+	ctx := context.Background()
+	if placeholder != "" {
+		existed, err := client.DeleteMapping(ctx, filter.Namespace, placeholder)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to delete mapping: %v\n", err)
+			return 1
+		}
+		if !existed {
+			fmt.Fprintf(os.Stderr, "No mapping for %s\n", placeholder)
+			return 1
+		}
+		fmt.Printf("Deleted mapping of %s\n", placeholder)
+		return 0
+	}
+
+	deleted, err := client.PurgeMappings(ctx, *filter, *all)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to purge mappings: %v\n", err)
+		return 1
+	}
+	fmt.Printf("Purged %d mappings\n", deleted)
+	return 0
+}
+
+// mappingsFilterFlags adds the mapping filter flags to fs
+func mappingsFilterFlags(fs *flag.FlagSet) *storage.PurgeFilter {
+	var filter storage.PurgeFilter
+	fs.StringVar(&filter.Namespace, "namespace", "", "mappings of a client namespace (e.g. ip:10.0.0.5)")
+	fs.StringVar(&filter.SecretType, "type", "", "mappings of a secret type")
+	fs.StringVar(&filter.RequestID, "request-id", "", "mappings created by a request")
+	fs.DurationVar(&filter.OlderThan, "older-than", 0, "mappings created longer ago than this")
+	return &filter
+}
+
+// writeMappingsTable writes mappings as a table
+func writeMappingsTable(w io.Writer, mappings []proxy.MappingInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "PLACEHOLDER\tNAMESPACE\tTYPE\tRULE\tHOST\tCREATED\tRESTORES\tLAST RESTORED\n")
+	for _, m := range mappings {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%d\t%s\n",
+			m.Placeholder, orDash(m.Namespace), orDash(m.Metadata.SecretType), orDash(m.Metadata.Rule),
+			orDash(m.Metadata.SourceHost), formatTime(m.CreatedAt), m.RestoreCount, formatTime(m.LastRestored))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%d mappings\n", len(mappings))
+	return err
+}
+
+// writeMapping writes the details of a mapping
+func writeMapping(w io.Writer, m proxy.MappingInfo) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, row := range [][2]string{
+		{"Placeholder", m.Placeholder},
+		{"Namespace", orDash(m.Namespace)},
+		{"Type", orDash(m.Metadata.SecretType)},
+		{"Interceptor", orDash(m.Metadata.Interceptor)},
+		{"Rule", orDash(m.Metadata.Rule)},
+		{"Source host", orDash(m.Metadata.SourceHost)},
+		{"Request ID", orDash(m.Metadata.RequestID)},
+		{"Created", formatTime(m.CreatedAt)},
+		{"Last used", formatTime(m.LastUsed)},
+		{"Restores", fmt.Sprint(m.RestoreCount)},
+		{"Last restored", formatTime(m.LastRestored)},
+	} {
+		fmt.Fprintf(tw, "%s:\t%s\n", row[0], row[1])
+	}
+	return tw.Flush()
+}
+
+// writeJSON writes v as indented JSON
+func writeJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// orDash returns s, or "-" if it is empty
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// formatTime formats t in RFC 3339, or "-" if it is unset
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+	return t.Local().Format(time.RFC3339)
+}
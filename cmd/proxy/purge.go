@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/proxy"
+	"github.com/hfi/llm-secret-interceptor/internal/storage"
+	"github.com/rs/zerolog"
+)
+
+// runPurge deletes mappings from the shared mapping store
+func runPurge(args []string) int {
+	fs := flag.NewFlagSet("purge", flag.ContinueOnError)
+	var filter storage.PurgeFilter
+	fs.StringVar(&filter.Namespace, "namespace", "", "purge mappings of a client namespace (e.g. ip:10.0.0.5)")
+	fs.StringVar(&filter.SecretType, "type", "", "purge mappings of a secret type")
+	fs.StringVar(&filter.RequestID, "request-id", "", "purge mappings created by a request")
+	fs.DurationVar(&filter.OlderThan, "older-than", 0, "purge mappings created longer ago than this")
+	all := fs.Bool("all", false, "purge all mappings")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s purge [flags]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 || filter.IsEmpty() == !*all {
+		// Purging everything must be requested explicitly and exclusively
+		fs.Usage()
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+		return 1
+	}
+
+	ctx := context.Background()
+	store, err := proxy.OpenMappingStore(ctx, cfg.Storage)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to open mapping store: %v\n", err)
+		return 1
+	}
+	defer func() {
+		if err := store.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to close mapping store: %v\n", err)
+		}
+	}()
+
+	auditLogger, err := proxy.NewAuditLogger(cfg, zerolog.New(os.Stderr).With().Timestamp().Logger())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize audit logger: %v\n", err)
+		return 1
+	}
+	defer auditLogger.Close()
+
+	deleted, err := storage.Purge(ctx, store, filter)
+	auditLogger.LogMappingsPurged(deleted, filter.Criteria())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Purge failed after %d mappings: %v\n", deleted, err)
+		return 1
+	}
+
+	fmt.Printf("Purged %d mappings\n", deleted)
+	return 0
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/proxy"
+)
+
+// runReplay feeds recorded request and response bodies through the secret
+// pipeline and reports what the current configuration would detect, redact
+// and block
+func runReplay(args []string) int {
+	fs := flag.NewFlagSet("replay", flag.ContinueOnError)
+	jsonOutput := fs.Bool("json", false, "print the report as JSON")
+	verbose := fs.Bool("verbose", false, "list every record and detection")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s replay [flags] <records.jsonl | ->\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), `Each line is {"id": "...", "host": "...", "path": "...", "request": {...}, "response": {...}}; only request is required.`)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+		return 1
+	}
+
+	var r io.Reader = os.Stdin
+	if path := fs.Arg(0); path != "-" {
+		f, err := os.Open(filepath.Clean(path))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to open records: %v\n", err)
+			return 1
+		}
+		defer func() { _ = f.Close() }()
+		r = f
+	}
+	records, err := proxy.ReadReplayRecords(r)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read records: %v\n", err)
+		return 1
+	}
+
+	report, err := proxy.Replay(context.Background(), cfg, records)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to replay records: %v\n", err)
+		return 1
+	}
+	if *jsonOutput {
+		err = report.WriteJSON(os.Stdout)
+	} else {
+		err = report.WriteText(os.Stdout, *verbose)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write report: %v\n", err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/proxy"
+	"github.com/hfi/llm-secret-interceptor/internal/ruletest"
+)
+
+// runRuleTest evaluates the configured interceptors against a labeled corpus
+func runRuleTest(args []string) int {
+	fs := flag.NewFlagSet("ruletest", flag.ContinueOnError)
+	jsonOutput := fs.Bool("json", false, "print the report as JSON")
+	verbose := fs.Bool("verbose", false, "list every false positive and false negative")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s ruletest [flags] <corpus.jsonl>\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+		return 1
+	}
+
+	manager, err := proxy.NewInterceptorManager(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize interceptors: %v\n", err)
+		return 1
+	}
+
+	samples, err := ruletest.LoadCorpus(fs.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load corpus: %v\n", err)
+		return 1
+	}
+
+	report := ruletest.Evaluate(manager, samples)
+	if *jsonOutput {
+		err = report.WriteJSON(os.Stdout)
+	} else {
+		err = report.WriteText(os.Stdout, *verbose)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write report: %v\n", err)
+		return 1
+	}
+
+	return 0
+}
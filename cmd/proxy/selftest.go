@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/proxy"
+	"github.com/rs/zerolog"
+)
+
+// runSelfTest routes a canned request containing a secret through the
+// configured proxy and a mock upstream, verifying masking and restoration
+func runSelfTest(args []string) int {
+	fs := flag.NewFlagSet("selftest", flag.ContinueOnError)
+	jsonOutput := fs.Bool("json", false, "print the report as JSON")
+	verbose := fs.Bool("verbose", false, "show the log of the proxy under test")
+	timeout := fs.Duration("timeout", 30*time.Second, "time limit of the self-test")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s selftest [flags]\n", os.Args[0])
+		fmt.Fprintln(fs.Output(), "Sends a request containing a secret through the proxy with the configured CA and interceptors to a local mock upstream.")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 0 {
+		fs.Usage()
+		return 2
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+		return 1
+	}
+	logger := zerolog.New(os.Stderr).With().Timestamp().Logger().Level(zerolog.WarnLevel)
+	if *verbose {
+		logger = logger.Level(zerolog.DebugLevel)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	report, err := proxy.SelfTest(ctx, cfg, logger)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to run self-test: %v\n", err)
+		return 1
+	}
+
+	if *jsonOutput {
+		err = report.WriteJSON(os.Stdout)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write report: %v\n", err)
+		return 1
+	}
+	if !report.OK() {
+		return 1
+	}
+	return 0
+}
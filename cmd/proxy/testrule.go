@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/hfi/llm-secret-interceptor/internal/proxy"
+	"github.com/hfi/llm-secret-interceptor/internal/ruletest"
+)
+
+// runTestRule checks a pattern or the rules of a rule file against sample
+// strings before they are deployed
+func runTestRule(args []string) int {
+	fs := flag.NewFlagSet("test-rule", flag.ContinueOnError)
+	pattern := fs.String("pattern", "", "regular expression to check")
+	ruleFile := fs.String("rules", "", "YAML rule file to check")
+	samplesFile := fs.String("samples", "", "file with one sample per line, - for stdin")
+	jsonOutput := fs.Bool("json", false, "print the report as JSON")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s test-rule (-pattern <regex> | -rules <file.yaml>) [flags] [sample...]\n", os.Args[0])
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if (*pattern == "") == (*ruleFile == "") {
+		fs.Usage()
+		return 2
+	}
+
+	samples := fs.Args()
+	if *samplesFile != "" {
+		fileSamples, err := readSamples(*samplesFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to read samples: %v\n", err)
+			return 1
+		}
+		samples = append(samples, fileSamples...)
+	}
+
+	var report *ruletest.CheckReport
+	if *pattern != "" {
+		report = &ruletest.CheckReport{
+			Samples: samples,
+			Rules:   []ruletest.RuleCheck{ruletest.CheckPattern(*pattern, samples)},
+		}
+	} else {
+		rules, err := proxy.LoadRuleFile(*ruleFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to load rules: %v\n", err)
+			return 1
+		}
+		report = ruletest.CheckRules(rules, samples)
+	}
+
+	var err error
+	if *jsonOutput {
+		err = report.WriteJSON(os.Stdout)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write report: %v\n", err)
+		return 1
+	}
+
+	if report.Failed() {
+		return 1
+	}
+	return 0
+}
+
+// readSamples reads one sample per line from path, or from stdin for "-"
+func readSamples(path string) ([]string, error) {
+	var r io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(filepath.Clean(path))
+		if err != nil {
+			return nil, err
+		}
+		defer func() { _ = f.Close() }()
+		r = f
+	}
+
+	var samples []string
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		samples = append(samples, scanner.Text())
+	}
+	return samples, scanner.Err()
+}
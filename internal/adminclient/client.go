@@ -0,0 +1,232 @@
+// Package adminclient talks to the admin API of a running proxy.
+package adminclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/proxy"
+	"github.com/hfi/llm-secret-interceptor/internal/storage"
+)
+
+// APIError is an error response of the admin API
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("admin API answered %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// Client calls the admin API with a bearer token
+type Client struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+// New creates a client of the admin API at baseURL, such as
+// "https://127.0.0.1:9090". A nil tlsConfig uses the system roots.
+func New(baseURL, token string, tlsConfig *tls.Config) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+	return &Client{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   token,
+		http:    &http.Client{Transport: transport, Timeout: 30 * time.Second},
+	}
+}
+
+// TLSConfig trusts the CA bundles at caCerts in addition to the system
+// roots and presents the client certificate at cert and key, if given
+func TLSConfig(caCerts []string, cert, key string) (*tls.Config, error) {
+	roots, err := x509.SystemCertPool()
+	if err != nil {
+		roots = x509.NewCertPool()
+	}
+	for _, path := range caCerts {
+		data, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		if !roots.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates found in %s", path)
+		}
+	}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12, RootCAs: roots}
+	if cert != "" || key != "" {
+		pair, err := tls.LoadX509KeyPair(cert, key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{pair}
+	}
+	return tlsConfig, nil
+}
+
+// ListMappings lists up to limit mappings selected by filter, newest first;
+// a limit of 0 lists all of them
+func (c *Client) ListMappings(ctx context.Context, filter storage.PurgeFilter, limit int) ([]proxy.MappingInfo, error) {
+	query := filterQuery(filter)
+	query.Set("limit", strconv.Itoa(limit))
+	var response struct {
+		Mappings []proxy.MappingInfo `json:"mappings"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/admin/mappings", query, nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Mappings, nil
+}
+
+// LookupMapping describes the mapping of placeholder in namespace
+func (c *Client) LookupMapping(ctx context.Context, namespace, placeholder string) (proxy.MappingInfo, bool, error) {
+	var info proxy.MappingInfo
+	err := c.do(ctx, http.MethodGet, mappingPath(placeholder), namespaceQuery(namespace), nil, &info)
+	if isNotFound(err) {
+		return proxy.MappingInfo{}, false, nil
+	}
+	if err != nil {
+		return proxy.MappingInfo{}, false, err
+	}
+	return info, true, nil
+}
+
+// PurgeMappings deletes the mappings selected by filter. Purging all
+// mappings with an empty filter requires all.
+func (c *Client) PurgeMappings(ctx context.Context, filter storage.PurgeFilter, all bool) (int, error) {
+	query := filterQuery(filter)
+	if all {
+		query.Set("all", "true")
+	}
+	var response struct {
+		Deleted int `json:"deleted"`
+	}
+	if err := c.do(ctx, http.MethodDelete, "/admin/mappings", query, nil, &response); err != nil {
+		return 0, err
+	}
+	return response.Deleted, nil
+}
+
+// DeleteMapping deletes the mapping of placeholder in namespace and
+// reports whether it existed
+func (c *Client) DeleteMapping(ctx context.Context, namespace, placeholder string) (bool, error) {
+	err := c.do(ctx, http.MethodDelete, mappingPath(placeholder), namespaceQuery(namespace), nil, nil)
+	if isNotFound(err) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// ReportFalsePositive reports the value behind a placeholder as no secret
+func (c *Client) ReportFalsePositive(ctx context.Context, report proxy.FalsePositive) (proxy.FalsePositiveResult, error) {
+	var result proxy.FalsePositiveResult
+	if err := c.do(ctx, http.MethodPost, "/admin/feedback", nil, report, &result); err != nil {
+		return proxy.FalsePositiveResult{}, err
+	}
+	return result, nil
+}
+
+// FalsePositives lists the false positives reported per rule, most
+// reported first
+func (c *Client) FalsePositives(ctx context.Context) ([]proxy.RuleFeedback, error) {
+	var response struct {
+		Rules []proxy.RuleFeedback `json:"rules"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/admin/feedback", nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Rules, nil
+}
+
+// do sends a request to path with body encoded as JSON, if any, and decodes
+// the JSON response into v, if any
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, v any) error {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode admin request: %w", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create admin request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call admin API: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return &APIError{StatusCode: resp.StatusCode, Message: strings.TrimSpace(string(body))}
+	}
+	if v == nil || resp.StatusCode == http.StatusNoContent {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("failed to decode admin response: %w", err)
+	}
+	return nil
+}
+
+// isNotFound reports whether err is a 404 response of the admin API
+func isNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// mappingPath is the admin API path of a placeholder's mapping
+func mappingPath(placeholder string) string {
+	return "/admin/mappings/" + url.PathEscape(placeholder)
+}
+
+// namespaceQuery selects a client namespace
+func namespaceQuery(namespace string) url.Values {
+	query := url.Values{}
+	if namespace != "" {
+		query.Set("namespace", namespace)
+	}
+	return query
+}
+
+// filterQuery encodes filter as query parameters of the mappings API
+func filterQuery(filter storage.PurgeFilter) url.Values {
+	query := namespaceQuery(filter.Namespace)
+	if filter.SecretType != "" {
+		query.Set("type", filter.SecretType)
+	}
+	if filter.RequestID != "" {
+		query.Set("request_id", filter.RequestID)
+	}
+	if filter.OlderThan > 0 {
+		query.Set("older_than", filter.OlderThan.String())
+	}
+	return query
+}
@@ -0,0 +1,139 @@
+package adminclient
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/proxy"
+	"github.com/hfi/llm-secret-interceptor/internal/storage"
+)
+
+// newTestAPI serves a fake mappings API knowing a single placeholder
+func newTestAPI(t *testing.T, requests *[]*http.Request) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests = append(*requests, r)
+		if r.Header.Get("Authorization") != "Bearer test-token" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		known := r.URL.Path == "/admin/mappings/[[GITHUB_TOKEN_1]]"
+		switch {
+		case r.URL.Path == "/admin/mappings" && r.Method == http.MethodGet:
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"mappings": []proxy.MappingInfo{{Placeholder: "[[GITHUB_TOKEN_1]]", RestoreCount: 2}},
+				"count":    1,
+			})
+		case r.URL.Path == "/admin/mappings" && r.Method == http.MethodDelete:
+			_ = json.NewEncoder(w).Encode(map[string]int{"deleted": 3})
+		case known && r.Method == http.MethodGet:
+			_ = json.NewEncoder(w).Encode(proxy.MappingInfo{Placeholder: "[[GITHUB_TOKEN_1]]", Namespace: "team-a"})
+		case known && r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		case r.URL.Path == "/admin/feedback" && r.Method == http.MethodPost:
+			var report proxy.FalsePositive
+			if err := json.NewDecoder(r.Body).Decode(&report); err != nil || report.Placeholder != "[[GITHUB_TOKEN_1]]" {
+				http.Error(w, "mapping not found", http.StatusNotFound)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(proxy.FalsePositiveResult{
+				Placeholder: report.Placeholder,
+				Metadata:    storage.Metadata{Rule: "github_token"},
+				Allowlisted: true,
+			})
+		case r.URL.Path == "/admin/feedback" && r.Method == http.MethodGet:
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"rules": []proxy.RuleFeedback{{Interceptor: "pattern", Rule: "github_token", Reports: 4}},
+				"total": 4,
+			})
+		default:
+			http.Error(w, "mapping not found", http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestClient_Mappings(t *testing.T) {
+	var requests []*http.Request
+	server := newTestAPI(t, &requests)
+	client := New(server.URL+"/", "test-token", nil)
+	ctx := context.Background()
+
+	filter := storage.PurgeFilter{Namespace: "team-a", SecretType: "github_token", RequestID: "req-1", OlderThan: time.Hour}
+	mappings, err := client.ListMappings(ctx, filter, 10)
+	if err != nil {
+		t.Fatalf("ListMappings() error: %v", err)
+	}
+	if len(mappings) != 1 || mappings[0].RestoreCount != 2 {
+		t.Errorf("ListMappings() = %+v", mappings)
+	}
+	query := requests[0].URL.Query()
+	for key, want := range map[string]string{"namespace": "team-a", "type": "github_token", "request_id": "req-1", "older_than": "1h0m0s", "limit": "10"} {
+		if got := query.Get(key); got != want {
+			t.Errorf("list query %s = %q, want %q", key, got, want)
+		}
+	}
+
+	info, found, err := client.LookupMapping(ctx, "team-a", "[[GITHUB_TOKEN_1]]")
+	if err != nil || !found || info.Namespace != "team-a" {
+		t.Errorf("LookupMapping() = %+v, %v, %v", info, found, err)
+	}
+	if _, found, err := client.LookupMapping(ctx, "", "[[UNKNOWN]]"); err != nil || found {
+		t.Errorf("LookupMapping(unknown) = %v, %v, want not found", found, err)
+	}
+
+	deleted, err := client.PurgeMappings(ctx, storage.PurgeFilter{}, true)
+	if err != nil || deleted != 3 {
+		t.Errorf("PurgeMappings() = %d, %v", deleted, err)
+	}
+	if got := requests[len(requests)-1].URL.Query().Get("all"); got != "true" {
+		t.Errorf("purge query all = %q", got)
+	}
+
+	if existed, err := client.DeleteMapping(ctx, "team-a", "[[GITHUB_TOKEN_1]]"); err != nil || !existed {
+		t.Errorf("DeleteMapping() = %v, %v", existed, err)
+	}
+	if existed, err := client.DeleteMapping(ctx, "", "[[UNKNOWN]]"); err != nil || existed {
+		t.Errorf("DeleteMapping(unknown) = %v, %v, want not found", existed, err)
+	}
+}
+
+func TestClient_Feedback(t *testing.T) {
+	var requests []*http.Request
+	server := newTestAPI(t, &requests)
+	client := New(server.URL, "test-token", nil)
+	ctx := context.Background()
+
+	result, err := client.ReportFalsePositive(ctx, proxy.FalsePositive{Placeholder: "[[GITHUB_TOKEN_1]]", Reason: "test fixture"})
+	if err != nil || !result.Allowlisted || result.Metadata.Rule != "github_token" {
+		t.Errorf("ReportFalsePositive() = %+v, %v", result, err)
+	}
+	if got := requests[0].Header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("report Content-Type = %q", got)
+	}
+	if _, err := client.ReportFalsePositive(ctx, proxy.FalsePositive{Placeholder: "[[UNKNOWN]]"}); !isNotFound(err) {
+		t.Errorf("ReportFalsePositive(unknown) error = %v, want 404", err)
+	}
+
+	rules, err := client.FalsePositives(ctx)
+	if err != nil || len(rules) != 1 || rules[0].Reports != 4 {
+		t.Errorf("FalsePositives() = %+v, %v", rules, err)
+	}
+}
+
+func TestClient_Unauthorized(t *testing.T) {
+	var requests []*http.Request
+	server := newTestAPI(t, &requests)
+	client := New(server.URL, "wrong", nil)
+
+	_, err := client.ListMappings(context.Background(), storage.PurgeFilter{}, 0)
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.StatusCode != http.StatusUnauthorized || apiErr.Message != "unauthorized" {
+		t.Errorf("ListMappings() error = %v, want a 401 API error", err)
+	}
+}
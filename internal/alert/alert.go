@@ -0,0 +1,322 @@
+// Package alert notifies chat channels and PagerDuty of detections that need
+// immediate attention, such as private keys, live credentials or bursts of
+// detections from one client. Repeated alerts are suppressed for a cooldown
+// window.
+package alert
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// Detection is a secret found in a client request
+type Detection struct {
+	// Client identifies the sender, e.g. "ip:10.0.0.7" or "user:alice"
+	Client      string
+	Host        string
+	RequestID   string
+	SecretType  string
+	Interceptor string
+	Rule        string
+	Time        time.Time
+}
+
+// Alert is a notification fired by a rule
+type Alert struct {
+	// Name is the name of the rule that fired
+	Name     string
+	Severity string
+	Summary  string
+	// Client, Host, RequestID, SecretType and Rule describe the detection
+	// that fired the alert
+	Client     string
+	Host       string
+	RequestID  string
+	SecretType string
+	Rule       string
+	// Count is the number of detections within Window for threshold rules
+	Count  int
+	Window time.Duration
+	Time   time.Time
+	// DedupKey is the same for alerts suppressed by the cooldown
+	DedupKey string
+}
+
+// Notifier delivers alerts to a channel
+type Notifier interface {
+	Notify(ctx context.Context, a Alert) error
+}
+
+// Rule fires an alert on matching detections
+type Rule struct {
+	Name string `yaml:"name"`
+	// SecretTypes and Rules restrict the rule to detections of these secret
+	// types and detection rules; empty lists match any
+	SecretTypes []string `yaml:"secret_types"`
+	Rules       []string `yaml:"rules"`
+	// Threshold fires once a client sent this many matching detections
+	// within Window; 0 fires on every matching detection
+	Threshold int           `yaml:"threshold"`
+	Window    time.Duration `yaml:"window"`
+	// Cooldown suppresses repeated alerts of the rule for the same client
+	Cooldown time.Duration `yaml:"cooldown"`
+	// Severity is "critical", "error", "warning" or "info"
+	Severity string `yaml:"severity"`
+	// Channels are the names of the channels notified
+	Channels []string `yaml:"channels"`
+}
+
+// ErrQueueFull is reported for alerts dropped because too many alerts are
+// waiting to be delivered
+var ErrQueueFull = errors.New("alert queue full")
+
+// ErrorHandler is called with alerts that were dropped or could not be
+// delivered to a channel
+type ErrorHandler func(a Alert, err error)
+
+// Config configures the alert rules and their channels
+type Config struct {
+	Channels  map[string]Notifier `yaml:"-"`
+	Rules     []Rule              `yaml:"rules"`
+	QueueSize int                 `yaml:"queue_size"`
+	// OnError reports dropped and undelivered alerts (nil = ignored)
+	OnError ErrorHandler `yaml:"-"`
+}
+
+const (
+	// defaultWindow is the default Rule.Window of threshold rules
+	defaultWindow = time.Minute
+	// defaultCooldown is the default Rule.Cooldown
+	defaultCooldown = 10 * time.Minute
+	// defaultQueueSize is the default Config.QueueSize
+	defaultQueueSize = 256
+	// notifyTimeout bounds delivering an alert to one channel
+	notifyTimeout = 10 * time.Second
+	// sweepInterval is how often idle clients and expired cooldowns are dropped
+	sweepInterval = time.Minute
+)
+
+// queued is an alert waiting to be delivered
+type queued struct {
+	alert     Alert
+	notifiers []Notifier
+}
+
+// rule is a validated Rule with its notifiers
+type rule struct {
+	Rule
+	notifiers []Notifier
+}
+
+// Manager evaluates detections against the rules and delivers alerts in the
+// background, so detection never waits for a webhook
+type Manager struct {
+	rules []rule
+
+	mu sync.Mutex
+	// recent holds the times of a client's recent detections per threshold
+	// rule, keyed by rule and client
+	recent map[string][]time.Time
+	// fired holds when an alert was last fired, keyed by its DedupKey
+	fired     map[string]time.Time
+	lastSweep time.Time
+	closed    bool
+
+	onError ErrorHandler
+	queue   chan queued
+	done    chan struct{}
+}
+
+// New validates cfg and starts delivering alerts
+func New(cfg Config) (*Manager, error) {
+	m := &Manager{
+		recent:  make(map[string][]time.Time),
+		fired:   make(map[string]time.Time),
+		onError: cfg.OnError,
+		done:    make(chan struct{}),
+	}
+	if m.onError == nil {
+		m.onError = func(Alert, error) {}
+	}
+	names := make(map[string]bool, len(cfg.Rules))
+	for _, r := range cfg.Rules {
+		if r.Name == "" || names[r.Name] {
+			return nil, fmt.Errorf("alert rules need unique names, got %q", r.Name)
+		}
+		names[r.Name] = true
+		if r.Threshold < 0 || r.Window < 0 || r.Cooldown < 0 {
+			return nil, fmt.Errorf("alert rule %s: threshold, window and cooldown must not be negative", r.Name)
+		}
+		if r.Threshold == 0 && len(r.SecretTypes) == 0 && len(r.Rules) == 0 {
+			return nil, fmt.Errorf("alert rule %s needs secret_types, rules or a threshold", r.Name)
+		}
+		switch r.Severity {
+		case "":
+			r.Severity = "error"
+		case "critical", "error", "warning", "info":
+		default:
+			return nil, fmt.Errorf("alert rule %s: severity must be critical, error, warning or info, got %q", r.Name, r.Severity)
+		}
+		if r.Window == 0 {
+			r.Window = defaultWindow
+		}
+		if r.Cooldown == 0 {
+			r.Cooldown = defaultCooldown
+		}
+		if len(r.Channels) == 0 {
+			return nil, fmt.Errorf("alert rule %s has no channels", r.Name)
+		}
+		validated := rule{Rule: r}
+		for _, name := range r.Channels {
+			notifier, ok := cfg.Channels[name]
+			if !ok {
+				return nil, fmt.Errorf("alert rule %s: unknown channel %q", r.Name, name)
+			}
+			validated.notifiers = append(validated.notifiers, notifier)
+		}
+		m.rules = append(m.rules, validated)
+	}
+
+	size := cfg.QueueSize
+	if size <= 0 {
+		size = defaultQueueSize
+	}
+	m.queue = make(chan queued, size)
+	go m.run()
+	return m, nil
+}
+
+// Observe evaluates d against the rules, queueing the alerts it fires
+func (m *Manager) Observe(d Detection) {
+	if d.Time.IsZero() {
+		d.Time = time.Now()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return
+	}
+	m.sweep(d.Time)
+	for _, r := range m.rules {
+		if !r.matches(d) {
+			continue
+		}
+		a := Alert{
+			Name:       r.Name,
+			Severity:   r.Severity,
+			Client:     d.Client,
+			Host:       d.Host,
+			RequestID:  d.RequestID,
+			SecretType: d.SecretType,
+			Rule:       d.Rule,
+			Count:      1,
+			Time:       d.Time,
+		}
+		if r.Threshold > 0 {
+			count, ok := m.count(r, d)
+			if !ok {
+				continue
+			}
+			a.Count, a.Window = count, r.Window
+			a.DedupKey = r.Name + "/" + d.Client
+			a.Summary = fmt.Sprintf("%d secrets detected from %s within %s (rule %s)", count, d.Client, r.Window, r.Name)
+		} else {
+			a.DedupKey = r.Name + "/" + d.Client + "/" + d.SecretType
+			a.Summary = fmt.Sprintf("%s detected from %s to %s (rule %s)", d.SecretType, d.Client, d.Host, r.Name)
+		}
+		if last, ok := m.fired[a.DedupKey]; ok && d.Time.Sub(last) < r.Cooldown {
+			continue
+		}
+		m.fired[a.DedupKey] = d.Time
+		select {
+		case m.queue <- queued{alert: a, notifiers: r.notifiers}:
+		default:
+			m.onError(a, ErrQueueFull)
+		}
+	}
+}
+
+// matches reports whether d is of the secret types and detection rules of r
+func (r rule) matches(d Detection) bool {
+	if len(r.SecretTypes) > 0 && !slices.Contains(r.SecretTypes, d.SecretType) {
+		return false
+	}
+	return len(r.Rules) == 0 || slices.Contains(r.Rules, d.Rule)
+}
+
+// count records d for the threshold rule r, reporting the detections of the
+// client within the window once they reach the threshold. The count starts
+// over after that.
+func (m *Manager) count(r rule, d Detection) (int, bool) {
+	key := r.Name + "/" + d.Client
+	times := m.recent[key]
+	// Only the last Threshold detections can reach it
+	if len(times) >= r.Threshold {
+		times = times[len(times)-r.Threshold+1:]
+	}
+	cutoff := d.Time.Add(-r.Window)
+	for len(times) > 0 && !times[0].After(cutoff) {
+		times = times[1:]
+	}
+	times = append(times, d.Time)
+	if len(times) < r.Threshold {
+		m.recent[key] = times
+		return 0, false
+	}
+	delete(m.recent, key)
+	return len(times), true
+}
+
+// sweep drops idle clients and expired cooldowns once per sweepInterval
+func (m *Manager) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < sweepInterval {
+		return
+	}
+	m.lastSweep = now
+	var longest time.Duration
+	for _, r := range m.rules {
+		longest = max(longest, r.Window, r.Cooldown)
+	}
+	for key, times := range m.recent {
+		if len(times) == 0 || now.Sub(times[len(times)-1]) > longest {
+			delete(m.recent, key)
+		}
+	}
+	for key, last := range m.fired {
+		if now.Sub(last) > longest {
+			delete(m.fired, key)
+		}
+	}
+}
+
+// run delivers queued alerts until the manager is closed
+func (m *Manager) run() {
+	defer close(m.done)
+	for q := range m.queue {
+		for _, notifier := range q.notifiers {
+			ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+			if err := notifier.Notify(ctx, q.alert); err != nil {
+				m.onError(q.alert, fmt.Errorf("failed to deliver alert: %w", err))
+			}
+			cancel()
+		}
+	}
+}
+
+// Close delivers the queued alerts and stops the manager; later detections
+// are ignored
+func (m *Manager) Close() error {
+	m.mu.Lock()
+	if !m.closed {
+		m.closed = true
+		close(m.queue)
+	}
+	m.mu.Unlock()
+	<-m.done
+	return nil
+}
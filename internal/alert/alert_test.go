@@ -0,0 +1,202 @@
+package alert
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// recordingNotifier records the alerts delivered to it
+type recordingNotifier struct {
+	mu     sync.Mutex
+	alerts []Alert
+}
+
+func (n *recordingNotifier) Notify(_ context.Context, a Alert) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.alerts = append(n.alerts, a)
+	return nil
+}
+
+func (n *recordingNotifier) delivered() []Alert {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]Alert(nil), n.alerts...)
+}
+
+func TestNew_Invalid(t *testing.T) {
+	channels := map[string]Notifier{"security": &recordingNotifier{}}
+	for _, r := range []Rule{
+		{SecretTypes: []string{"private_key"}, Channels: []string{"security"}},
+		{Name: "any", Channels: []string{"security"}},
+		{Name: "burst", Threshold: -1, Channels: []string{"security"}},
+		{Name: "keys", SecretTypes: []string{"private_key"}, Severity: "high", Channels: []string{"security"}},
+		{Name: "keys", SecretTypes: []string{"private_key"}},
+		{Name: "keys", SecretTypes: []string{"private_key"}, Channels: []string{"oncall"}},
+	} {
+		if _, err := New(Config{Channels: channels, Rules: []Rule{r}}); err == nil {
+			t.Errorf("New() with rule %+v succeeded", r)
+		}
+	}
+	duplicate := Rule{Name: "keys", SecretTypes: []string{"private_key"}, Channels: []string{"security"}}
+	if _, err := New(Config{Channels: channels, Rules: []Rule{duplicate, duplicate}}); err == nil {
+		t.Error("New() with duplicate rule names succeeded")
+	}
+}
+
+func TestManager_MatchingRule(t *testing.T) {
+	notifier := &recordingNotifier{}
+	m, err := New(Config{
+		Channels: map[string]Notifier{"security": notifier},
+		Rules: []Rule{
+			{Name: "private-keys", SecretTypes: []string{"private_key"}, Severity: "critical", Channels: []string{"security"}},
+			{Name: "live-keys", Rules: []string{"stripe_live_key"}, Cooldown: time.Minute, Channels: []string{"security"}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	now := time.Now()
+	m.Observe(Detection{Client: "ip:10.0.0.7", Host: "api.openai.com", SecretType: "private_key", Rule: "private_key_header", Time: now})
+	m.Observe(Detection{Client: "ip:10.0.0.7", SecretType: "api_key", Rule: "openai_api_key", Time: now})
+	m.Observe(Detection{Client: "ip:10.0.0.7", SecretType: "api_key", Rule: "stripe_live_key", Time: now})
+	// Suppressed by the cooldown, but not for other clients
+	m.Observe(Detection{Client: "ip:10.0.0.7", SecretType: "api_key", Rule: "stripe_live_key", Time: now.Add(30 * time.Second)})
+	m.Observe(Detection{Client: "ip:10.0.0.8", SecretType: "api_key", Rule: "stripe_live_key", Time: now.Add(30 * time.Second)})
+	// Fired again after the cooldown
+	m.Observe(Detection{Client: "ip:10.0.0.7", SecretType: "api_key", Rule: "stripe_live_key", Time: now.Add(2 * time.Minute)})
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	alerts := notifier.delivered()
+	if len(alerts) != 4 {
+		t.Fatalf("delivered %d alerts, want 4: %+v", len(alerts), alerts)
+	}
+	if a := alerts[0]; a.Name != "private-keys" || a.Severity != "critical" || a.Host != "api.openai.com" || a.Summary == "" {
+		t.Errorf("alert = %+v", a)
+	}
+	if a := alerts[1]; a.Name != "live-keys" || a.Severity != "error" || a.DedupKey != "live-keys/ip:10.0.0.7/api_key" {
+		t.Errorf("alert = %+v", a)
+	}
+	if alerts[2].Client != "ip:10.0.0.8" || alerts[3].Client != "ip:10.0.0.7" {
+		t.Errorf("alerts = %+v", alerts[2:])
+	}
+
+	// Detections after Close are ignored
+	m.Observe(Detection{Client: "ip:10.0.0.9", SecretType: "private_key"})
+}
+
+func TestManager_Threshold(t *testing.T) {
+	notifier := &recordingNotifier{}
+	m, err := New(Config{
+		Channels: map[string]Notifier{"oncall": notifier},
+		Rules:    []Rule{{Name: "burst", Threshold: 3, Window: time.Minute, Cooldown: time.Hour, Channels: []string{"oncall"}}},
+	})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+
+	now := time.Now()
+	// Two detections, then two more after the first ones left the window
+	for _, offset := range []time.Duration{0, 10 * time.Second, 70 * time.Second, 75 * time.Second} {
+		m.Observe(Detection{Client: "user:alice", SecretType: "password", Time: now.Add(offset)})
+	}
+	// Another client's detections are counted on their own
+	m.Observe(Detection{Client: "user:bob", SecretType: "password", Time: now.Add(75 * time.Second)})
+	m.Observe(Detection{Client: "user:alice", SecretType: "api_key", Time: now.Add(80 * time.Second)})
+	// Within the cooldown
+	for i := range 3 {
+		m.Observe(Detection{Client: "user:alice", SecretType: "api_key", Time: now.Add(90*time.Second + time.Duration(i)*time.Second)})
+	}
+	if err := m.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	alerts := notifier.delivered()
+	if len(alerts) != 1 {
+		t.Fatalf("delivered %d alerts, want 1: %+v", len(alerts), alerts)
+	}
+	if a := alerts[0]; a.Client != "user:alice" || a.Count != 3 || a.Window != time.Minute || a.DedupKey != "burst/user:alice" {
+		t.Errorf("alert = %+v", a)
+	}
+}
+
+func TestManager_QueueFull(t *testing.T) {
+	block := make(chan struct{})
+	notifier := notifierFunc(func(context.Context, Alert) error {
+		<-block
+		return nil
+	})
+	var dropped atomic.Int32
+	m, err := New(Config{
+		Channels:  map[string]Notifier{"slow": notifier},
+		Rules:     []Rule{{Name: "keys", SecretTypes: []string{"private_key"}, Channels: []string{"slow"}}},
+		QueueSize: 1,
+		OnError: func(_ Alert, err error) {
+			if errors.Is(err, ErrQueueFull) {
+				dropped.Add(1)
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	// Observe never blocks on a slow channel
+	done := make(chan struct{})
+	go func() {
+		for i := range 10 {
+			m.Observe(Detection{Client: string(rune('a' + i)), SecretType: "private_key"})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Observe() blocked on a full queue")
+	}
+	close(block)
+	_ = m.Close()
+	if dropped.Load() == 0 {
+		t.Error("dropped alerts not reported")
+	}
+}
+
+func TestManager_DeliveryError(t *testing.T) {
+	failed := make(chan error, 1)
+	m, err := New(Config{
+		Channels: map[string]Notifier{"down": notifierFunc(func(context.Context, Alert) error {
+			return errors.New("webhook returned 500")
+		})},
+		Rules: []Rule{{Name: "keys", SecretTypes: []string{"private_key"}, Channels: []string{"down"}}},
+		OnError: func(a Alert, err error) {
+			if a.Name == "keys" {
+				failed <- err
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	m.Observe(Detection{Client: "10.0.0.1", SecretType: "private_key"})
+	_ = m.Close()
+	select {
+	case err := <-failed:
+		if !strings.Contains(err.Error(), "webhook returned 500") {
+			t.Errorf("error = %v", err)
+		}
+	default:
+		t.Error("undelivered alert not reported")
+	}
+}
+
+// notifierFunc adapts a function to Notifier
+type notifierFunc func(context.Context, Alert) error
+
+func (f notifierFunc) Notify(ctx context.Context, a Alert) error { return f(ctx, a) }
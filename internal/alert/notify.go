@@ -0,0 +1,187 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ChannelConfig configures a channel notified by alert rules
+type ChannelConfig struct {
+	// Type is "slack", "teams" or "pagerduty"
+	Type string `yaml:"type"`
+	// URL is the incoming webhook of a Slack or Teams channel, or overrides
+	// the PagerDuty Events API endpoint
+	URL string `yaml:"url"`
+	// RoutingKey is the integration key of a PagerDuty service
+	RoutingKey string `yaml:"routing_key"`
+}
+
+// pagerDutyEventsURL is the PagerDuty Events API v2 endpoint
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// maxErrorBody limits how much of an error response is included in errors
+const maxErrorBody = 512
+
+// NewNotifier creates the notifier of a channel
+func NewNotifier(cfg ChannelConfig) (Notifier, error) {
+	client := &http.Client{Timeout: notifyTimeout}
+	switch cfg.Type {
+	case "slack", "teams":
+		if !strings.HasPrefix(cfg.URL, "https://") && !strings.HasPrefix(cfg.URL, "http://") {
+			return nil, fmt.Errorf("%s channel needs a webhook URL", cfg.Type)
+		}
+		if cfg.Type == "slack" {
+			return &slackNotifier{url: cfg.URL, client: client}, nil
+		}
+		return &teamsNotifier{url: cfg.URL, client: client}, nil
+	case "pagerduty":
+		if cfg.RoutingKey == "" {
+			return nil, fmt.Errorf("pagerduty channel needs a routing key")
+		}
+		endpoint := cfg.URL
+		if endpoint == "" {
+			endpoint = pagerDutyEventsURL
+		}
+		return &pagerDutyNotifier{url: endpoint, routingKey: cfg.RoutingKey, client: client}, nil
+	default:
+		return nil, fmt.Errorf("unknown alert channel type %q", cfg.Type)
+	}
+}
+
+// details returns the fields of a, in a fixed order
+func details(a Alert) [][2]string {
+	fields := [][2]string{
+		{"Client", a.Client},
+		{"Host", a.Host},
+		{"Secret type", a.SecretType},
+		{"Detection rule", a.Rule},
+		{"Request ID", a.RequestID},
+	}
+	if a.Window > 0 {
+		fields = append(fields, [2]string{"Detections", fmt.Sprintf("%d within %s", a.Count, a.Window)})
+	}
+	var set [][2]string
+	for _, field := range fields {
+		if field[1] != "" {
+			set = append(set, field)
+		}
+	}
+	return set
+}
+
+// slackNotifier posts alerts to a Slack incoming webhook
+type slackNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func (n *slackNotifier) Notify(ctx context.Context, a Alert) error {
+	var text strings.Builder
+	fmt.Fprintf(&text, ":rotating_light: *%s*", a.Summary)
+	for _, field := range details(a) {
+		fmt.Fprintf(&text, "\n*%s:* `%s`", field[0], field[1])
+	}
+	return postJSON(ctx, n.client, n.url, map[string]string{"text": text.String()})
+}
+
+// teamsNotifier posts alerts to a Microsoft Teams incoming webhook as a
+// message card
+type teamsNotifier struct {
+	url    string
+	client *http.Client
+}
+
+func (n *teamsNotifier) Notify(ctx context.Context, a Alert) error {
+	var facts []map[string]string
+	for _, field := range details(a) {
+		facts = append(facts, map[string]string{"name": field[0], "value": field[1]})
+	}
+	card := map[string]any{
+		"@type":      "MessageCard",
+		"@context":   "https://schema.org/extensions",
+		"summary":    a.Summary,
+		"themeColor": teamsColor(a.Severity),
+		"title":      a.Summary,
+		"sections":   []map[string]any{{"facts": facts}},
+	}
+	return postJSON(ctx, n.client, n.url, card)
+}
+
+// teamsColor returns the card color of a severity
+func teamsColor(severity string) string {
+	switch severity {
+	case "critical", "error":
+		return "D40000"
+	case "warning":
+		return "FFA500"
+	default:
+		return "0078D7"
+	}
+}
+
+// pagerDutyNotifier triggers PagerDuty incidents over the Events API v2; the
+// dedup key folds alerts of the same rule and client into one incident
+type pagerDutyNotifier struct {
+	url        string
+	routingKey string
+	client     *http.Client
+}
+
+func (n *pagerDutyNotifier) Notify(ctx context.Context, a Alert) error {
+	custom := make(map[string]string)
+	for _, field := range details(a) {
+		custom[field[0]] = field[1]
+	}
+	event := map[string]any{
+		"routing_key":  n.routingKey,
+		"event_action": "trigger",
+		"dedup_key":    a.DedupKey,
+		"payload": map[string]any{
+			"summary":        a.Summary,
+			"source":         "llm-secret-interceptor",
+			"severity":       a.Severity,
+			"timestamp":      a.Time.UTC().Format(time.RFC3339),
+			"component":      a.Host,
+			"class":          a.SecretType,
+			"custom_details": custom,
+		},
+	}
+	return postJSON(ctx, n.client, n.url, event)
+}
+
+// postJSON posts body as JSON to endpoint, failing on non-2xx responses.
+// Errors leave out the endpoint, as webhook URLs are credentials.
+func postJSON(ctx context.Context, client *http.Client, endpoint string, body any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("invalid alert channel URL")
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, msg)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
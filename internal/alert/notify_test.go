@@ -0,0 +1,120 @@
+package alert
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// webhook returns a server decoding posted JSON into a map
+func webhook(t *testing.T, status int) (*httptest.Server, <-chan map[string]any) {
+	t.Helper()
+	posted := make(chan map[string]any, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		if r.Method != http.MethodPost || r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("request = %s %s", r.Method, r.Header.Get("Content-Type"))
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Errorf("invalid JSON: %v", err)
+		}
+		posted <- body
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(server.Close)
+	return server, posted
+}
+
+var testAlert = Alert{
+	Name:       "private-keys",
+	Severity:   "critical",
+	Summary:    "private_key detected from ip:10.0.0.7 to api.openai.com (rule private-keys)",
+	Client:     "ip:10.0.0.7",
+	Host:       "api.openai.com",
+	SecretType: "private_key",
+	RequestID:  "req-1",
+	Time:       time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+	DedupKey:   "private-keys/ip:10.0.0.7/private_key",
+}
+
+func TestNewNotifier(t *testing.T) {
+	for _, cfg := range []ChannelConfig{
+		{Type: "slack"},
+		{Type: "teams", URL: "hooks.example.com"},
+		{Type: "pagerduty"},
+		{Type: "email", URL: "https://example.com"},
+	} {
+		if _, err := NewNotifier(cfg); err == nil {
+			t.Errorf("NewNotifier(%+v) succeeded", cfg)
+		}
+	}
+}
+
+func TestSlackNotifier(t *testing.T) {
+	server, posted := webhook(t, http.StatusOK)
+	n, err := NewNotifier(ChannelConfig{Type: "slack", URL: server.URL})
+	if err != nil {
+		t.Fatalf("NewNotifier() error: %v", err)
+	}
+	if err := n.Notify(context.Background(), testAlert); err != nil {
+		t.Fatalf("Notify() error: %v", err)
+	}
+	text, _ := (<-posted)["text"].(string)
+	if !strings.Contains(text, testAlert.Summary) || !strings.Contains(text, "*Request ID:* `req-1`") {
+		t.Errorf("text = %q", text)
+	}
+}
+
+func TestTeamsNotifier(t *testing.T) {
+	server, posted := webhook(t, http.StatusOK)
+	n, err := NewNotifier(ChannelConfig{Type: "teams", URL: server.URL})
+	if err != nil {
+		t.Fatalf("NewNotifier() error: %v", err)
+	}
+	if err := n.Notify(context.Background(), testAlert); err != nil {
+		t.Fatalf("Notify() error: %v", err)
+	}
+	card := <-posted
+	if card["@type"] != "MessageCard" || card["title"] != testAlert.Summary || card["themeColor"] != "D40000" {
+		t.Errorf("card = %v", card)
+	}
+}
+
+func TestPagerDutyNotifier(t *testing.T) {
+	server, posted := webhook(t, http.StatusAccepted)
+	n, err := NewNotifier(ChannelConfig{Type: "pagerduty", URL: server.URL, RoutingKey: "R0UT1NG"})
+	if err != nil {
+		t.Fatalf("NewNotifier() error: %v", err)
+	}
+	if err := n.Notify(context.Background(), testAlert); err != nil {
+		t.Fatalf("Notify() error: %v", err)
+	}
+	event := <-posted
+	if event["routing_key"] != "R0UT1NG" || event["event_action"] != "trigger" || event["dedup_key"] != testAlert.DedupKey {
+		t.Errorf("event = %v", event)
+	}
+	payload, _ := event["payload"].(map[string]any)
+	if payload["severity"] != "critical" || payload["timestamp"] != "2026-03-01T12:00:00Z" || payload["class"] != "private_key" {
+		t.Errorf("payload = %v", payload)
+	}
+}
+
+func TestPostJSON_Errors(t *testing.T) {
+	server, _ := webhook(t, http.StatusForbidden)
+	n, _ := NewNotifier(ChannelConfig{Type: "slack", URL: server.URL + "/services/T000/B000/SECRET"})
+	err := n.Notify(context.Background(), testAlert)
+	if err == nil || !strings.Contains(err.Error(), "403") {
+		t.Errorf("Notify() error = %v, want the status", err)
+	}
+
+	// Webhook URLs are credentials and stay out of errors
+	server.Close()
+	err = n.Notify(context.Background(), testAlert)
+	if err == nil || strings.Contains(err.Error(), "SECRET") {
+		t.Errorf("Notify() error = %v, want one without the URL", err)
+	}
+}
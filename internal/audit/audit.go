@@ -3,9 +3,11 @@ package audit
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
 	"log/slog"
 	"os"
+	"strconv"
 	"sync"
 	"time"
 )
@@ -22,15 +24,30 @@ const (
 	EventResponseProcessed   EventType = "response_processed"
 	EventMappingCreated      EventType = "mapping_created"
 	EventMappingExpired      EventType = "mapping_expired"
+	EventMappingsPurged      EventType = "mappings_purged"
 	EventTLSError            EventType = "tls_error"
 	EventUpstreamError       EventType = "upstream_error"
+	EventClientRejected      EventType = "client_rejected"
+	EventRequestTimeout      EventType = "request_timeout"
+	EventPinningDetected     EventType = "pinning_detected"
+	EventAdminAction         EventType = "admin_action"
 )
 
+// eventTypes lists the known event types
+var eventTypes = []EventType{
+	EventSecretDetected, EventSecretReplaced, EventPlaceholderRestored,
+	EventRequestProcessed, EventResponseProcessed, EventMappingCreated,
+	EventMappingExpired, EventMappingsPurged, EventTLSError, EventUpstreamError,
+	EventClientRejected, EventRequestTimeout, EventPinningDetected, EventAdminAction,
+}
+
 // Event represents an audit log event
 type Event struct {
 	Timestamp   time.Time         `json:"timestamp"`
 	Type        EventType         `json:"type"`
 	RequestID   string            `json:"request_id,omitempty"`
+	TraceID     string            `json:"trace_id,omitempty"`
+	SpanID      string            `json:"span_id,omitempty"`
 	Interceptor string            `json:"interceptor,omitempty"`
 	SecretType  string            `json:"secret_type,omitempty"`
 	Host        string            `json:"host,omitempty"`
@@ -42,6 +59,27 @@ type Event struct {
 	Metadata    map[string]string `json:"metadata,omitempty"`
 }
 
+// Origin describes the detection that created a mapping
+type Origin struct {
+	RequestID   string
+	Host        string
+	Interceptor string
+	Rule        string
+	SecretType  string
+}
+
+// AdminAction describes a request to the admin API
+type AdminAction struct {
+	ClientIP string
+	// Principal and Role identify the authenticated caller, if any
+	Principal string
+	Role      string
+	// Action is the HTTP method and path or the gRPC method
+	Action string
+	// Result is the response status, or why the request was refused
+	Result string
+}
+
 // Config holds audit logger configuration
 type Config struct {
 	// Enabled enables/disables audit logging
@@ -54,14 +92,40 @@ type Config struct {
 	Level string `yaml:"level"`
 
 	// Output specifies where to write logs
-	// "stdout", "stderr", or a file path
+	// "stdout", "stderr", "syslog", "kafka", "s3", "gcs", "otlp", or a file
+	// path
 	Output string `yaml:"output"`
 
-	// Format specifies log format: "json" or "text"
+	// Syslog configures the collector for the "syslog" output
+	Syslog SyslogConfig `yaml:"syslog"`
+
+	// Kafka configures the topic for the "kafka" output
+	Kafka KafkaConfig `yaml:"kafka"`
+
+	// Object configures the bucket for the "s3" and "gcs" outputs
+	Object ObjectConfig `yaml:"object"`
+
+	// Rotation rotates a file output by size or age
+	Rotation RotationConfig `yaml:"rotation"`
+
+	// OTLP configures the collector for the "otlp" output
+	OTLP OTLPConfig `yaml:"otlp"`
+
+	// Sampling drops a share of events by type and caps the event rate
+	Sampling SamplingConfig `yaml:"sampling"`
+
+	// Format specifies log format: "json", "text", or "cef" and "leef" for
+	// ArcSight and QRadar
 	Format string `yaml:"format"`
 
 	// IncludeRequestDetails includes host/path in logs
 	IncludeRequestDetails bool `yaml:"include_request_details"`
+
+	// OmitInterceptor leaves the interceptor and rule out of events
+	OmitInterceptor bool `yaml:"omit_interceptor"`
+
+	// OmitSecretType leaves the secret type out of events
+	OmitSecretType bool `yaml:"omit_secret_type"`
 }
 
 // DefaultConfig returns the default audit configuration
@@ -75,6 +139,10 @@ func DefaultConfig() *Config {
 	}
 }
 
+// TraceLookup returns the W3C trace and span IDs of an in-flight request,
+// or empty strings if the request is not traced
+type TraceLookup func(requestID string) (traceID, spanID string)
+
 // Logger handles audit logging
 type Logger struct {
 	mu      sync.RWMutex
@@ -82,6 +150,8 @@ type Logger struct {
 	logger  *slog.Logger
 	output  io.Writer
 	enabled bool
+	traces  TraceLookup
+	sampler *sampler
 }
 
 // NewLogger creates a new audit logger
@@ -90,9 +160,14 @@ func NewLogger(cfg *Config) (*Logger, error) {
 		cfg = DefaultConfig()
 	}
 
+	sampler, err := newSampler(cfg.Sampling)
+	if err != nil {
+		return nil, err
+	}
 	l := &Logger{
 		config:  cfg,
 		enabled: cfg.Enabled,
+		sampler: sampler,
 	}
 
 	if err := l.setupOutput(); err != nil {
@@ -110,8 +185,51 @@ func (l *Logger) setupOutput() error {
 		output = os.Stdout
 	case "stderr":
 		output = os.Stderr
+	case "syslog":
+		w, err := newSyslogWriter(l.config.Syslog)
+		if err != nil {
+			return err
+		}
+		output = w
+	case "kafka":
+		// Consumers parse the events, and messages are keyed by their host
+		if l.config.Format != "json" {
+			return fmt.Errorf("kafka output requires json format, got %q", l.config.Format)
+		}
+		w, err := newKafkaWriter(l.config.Kafka)
+		if err != nil {
+			return err
+		}
+		output = w
+	case "s3", "gcs":
+		// Objects are NDJSON, one event per line
+		if l.config.Format != "json" {
+			return fmt.Errorf("%s output requires json format, got %q", l.config.Output, l.config.Format)
+		}
+		w, err := newObjectWriter(l.config.Object)
+		if err != nil {
+			return err
+		}
+		output = w
+	case "otlp":
+		// The exporter takes records rather than formatted lines
+		e, err := newOTLPExporter(l.config.OTLP)
+		if err != nil {
+			return err
+		}
+		l.output = io.Discard
+		l.logger = slog.New(e)
+		return nil
 	default:
 		// File output
+		if l.config.Rotation.enabled() {
+			f, err := newRotatingFile(l.config.Output, l.config.Rotation)
+			if err != nil {
+				return err
+			}
+			output = f
+			break
+		}
 		f, err := os.OpenFile(l.config.Output, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
 		if err != nil {
 			return err
@@ -122,11 +240,14 @@ func (l *Logger) setupOutput() error {
 	l.output = output
 
 	var handler slog.Handler
-	if l.config.Format == "json" {
+	switch l.config.Format {
+	case "json":
 		handler = slog.NewJSONHandler(output, &slog.HandlerOptions{
 			Level: slog.LevelInfo,
 		})
-	} else {
+	case "cef", "leef":
+		handler = newSIEMHandler(output, l.config.Format)
+	default:
 		handler = slog.NewTextHandler(output, &slog.HandlerOptions{
 			Level: slog.LevelInfo,
 		})
@@ -142,6 +263,7 @@ func (l *Logger) Log(event *Event) {
 	enabled := l.enabled
 	config := l.config
 	logger := l.logger
+	traces := l.traces
 	l.mu.RUnlock()
 
 	if !enabled || logger == nil {
@@ -154,11 +276,24 @@ func (l *Logger) Log(event *Event) {
 	}
 
 	event.Timestamp = time.Now()
+	if l.sampler != nil && !l.sampler.allow(event.Type, event.Timestamp) {
+		return
+	}
+	if event.TraceID == "" && event.RequestID != "" && traces != nil {
+		event.TraceID, event.SpanID = traces(event.RequestID)
+	}
 
 	// Redact request details if not enabled
 	if !config.IncludeRequestDetails {
 		event.Path = ""
 	}
+	if config.OmitInterceptor {
+		event.Interceptor = ""
+		delete(event.Metadata, "rule")
+	}
+	if config.OmitSecretType {
+		event.SecretType = ""
+	}
 
 	// Build log attributes
 	attrs := []any{
@@ -168,6 +303,9 @@ func (l *Logger) Log(event *Event) {
 	if event.RequestID != "" {
 		attrs = append(attrs, slog.String("request_id", event.RequestID))
 	}
+	if event.TraceID != "" {
+		attrs = append(attrs, slog.String("trace_id", event.TraceID), slog.String("span_id", event.SpanID))
+	}
 	if event.Interceptor != "" {
 		attrs = append(attrs, slog.String("interceptor", event.Interceptor))
 	}
@@ -204,7 +342,11 @@ func (l *Logger) shouldLog(eventType EventType) bool {
 	case "minimal":
 		return eventType == EventSecretDetected ||
 			eventType == EventSecretReplaced ||
-			eventType == EventPlaceholderRestored
+			eventType == EventPlaceholderRestored ||
+			eventType == EventMappingsPurged ||
+			eventType == EventClientRejected ||
+			eventType == EventPinningDetected ||
+			eventType == EventAdminAction
 	case "standard":
 		return eventType != EventMappingCreated &&
 			eventType != EventMappingExpired
@@ -243,6 +385,100 @@ func (l *Logger) LogPlaceholderRestored(requestID string, count int) {
 	})
 }
 
+// LogMappingCreated logs the creation of a mapping for a detected secret
+func (l *Logger) LogMappingCreated(placeholder string, origin Origin) {
+	l.Log(&Event{
+		Type:        EventMappingCreated,
+		RequestID:   origin.RequestID,
+		Interceptor: origin.Interceptor,
+		SecretType:  origin.SecretType,
+		Host:        origin.Host,
+		Metadata:    originMetadata(placeholder, origin, false),
+	})
+}
+
+// LogMappingRestored logs the restoration of a placeholder in a response,
+// tracing it back to the detection that created the mapping
+func (l *Logger) LogMappingRestored(requestID, host, placeholder string, origin Origin) {
+	l.Log(&Event{
+		Type:        EventPlaceholderRestored,
+		RequestID:   requestID,
+		Interceptor: origin.Interceptor,
+		SecretType:  origin.SecretType,
+		Host:        host,
+		Count:       1,
+		Metadata:    originMetadata(placeholder, origin, true),
+	})
+}
+
+// originMetadata returns the event metadata for a mapping origin
+func originMetadata(placeholder string, origin Origin, restored bool) map[string]string {
+	metadata := map[string]string{"placeholder": placeholder}
+	if origin.Rule != "" {
+		metadata["rule"] = origin.Rule
+	}
+	if restored {
+		if origin.RequestID != "" {
+			metadata["origin_request_id"] = origin.RequestID
+		}
+		if origin.Host != "" {
+			metadata["origin_host"] = origin.Host
+		}
+	}
+	return metadata
+}
+
+// LogMappingsPurged logs a purge of mappings with the number of destroyed
+// entries and the criteria that selected them
+func (l *Logger) LogMappingsPurged(count int, criteria map[string]string) {
+	metadata := make(map[string]string, len(criteria)+1)
+	for k, v := range criteria {
+		metadata["criteria_"+k] = v
+	}
+	if len(criteria) == 0 {
+		metadata["criteria"] = "all"
+	}
+	l.Log(&Event{
+		Type:     EventMappingsPurged,
+		Count:    count,
+		Metadata: metadata,
+	})
+}
+
+// LogClientRejected logs a client refused by the proxy access control list
+func (l *Logger) LogClientRejected(clientIP, host, reason string) {
+	l.Log(&Event{
+		Type:     EventClientRejected,
+		Host:     host,
+		Metadata: map[string]string{"client_ip": clientIP, "reason": reason},
+	})
+}
+
+// LogPinningDetected logs a client detected pinning the certificate of host
+// after aborts handshakes, and whether host is no longer intercepted
+func (l *Logger) LogPinningDetected(clientIP, host string, aborts int, bypassed bool) {
+	l.Log(&Event{
+		Type:     EventPinningDetected,
+		Host:     host,
+		Count:    aborts,
+		Metadata: map[string]string{"client_ip": clientIP, "bypassed": strconv.FormatBool(bypassed)},
+	})
+}
+
+// LogAdminAction logs a change made through the admin API or a refused
+// admin request
+func (l *Logger) LogAdminAction(action AdminAction) {
+	metadata := map[string]string{"client_ip": action.ClientIP, "action": action.Action, "result": action.Result}
+	if action.Principal != "" {
+		metadata["principal"] = action.Principal
+		metadata["role"] = action.Role
+	}
+	l.Log(&Event{
+		Type:     EventAdminAction,
+		Metadata: metadata,
+	})
+}
+
 // LogRequestProcessed logs request processing
 func (l *Logger) LogRequestProcessed(requestID, method, host, path string, durationMs float64) {
 	l.Log(&Event{
@@ -296,6 +532,21 @@ func (l *Logger) SetLevel(level string) {
 	l.config.Level = level
 }
 
+// Dropped returns how many events sampling and rate limiting dropped
+func (l *Logger) Dropped() DropCounts {
+	if l.sampler == nil {
+		return DropCounts{}
+	}
+	return l.sampler.counts()
+}
+
+// SetTraceLookup correlates events with the traces of their requests
+func (l *Logger) SetTraceLookup(lookup TraceLookup) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.traces = lookup
+}
+
 // Close closes the logger
 func (l *Logger) Close() error {
 	l.mu.Lock()
@@ -306,6 +557,10 @@ func (l *Logger) Close() error {
 			return closer.Close()
 		}
 	}
+	// Exporting handlers flush their queued records
+	if closer, ok := l.logger.Handler().(io.Closer); ok {
+		return closer.Close()
+	}
 	return nil
 }
 
@@ -334,6 +589,24 @@ func (l *NopLogger) LogSecretReplaced(_ string, _ int) {}
 // LogPlaceholderRestored does nothing
 func (l *NopLogger) LogPlaceholderRestored(_ string, _ int) {}
 
+// LogMappingCreated does nothing
+func (l *NopLogger) LogMappingCreated(_ string, _ Origin) {}
+
+// LogMappingRestored does nothing
+func (l *NopLogger) LogMappingRestored(_, _, _ string, _ Origin) {}
+
+// LogMappingsPurged does nothing
+func (l *NopLogger) LogMappingsPurged(_ int, _ map[string]string) {}
+
+// LogClientRejected does nothing
+func (l *NopLogger) LogClientRejected(_, _, _ string) {}
+
+// LogPinningDetected does nothing
+func (l *NopLogger) LogPinningDetected(_, _ string, _ int, _ bool) {}
+
+// LogAdminAction does nothing
+func (l *NopLogger) LogAdminAction(_ AdminAction) {}
+
 // LogRequestProcessed does nothing
 func (l *NopLogger) LogRequestProcessed(_, _, _, _ string, _ float64) {}
 
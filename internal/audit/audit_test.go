@@ -88,6 +88,70 @@ func TestLogger_LogLevel_Minimal(t *testing.T) {
 	}
 }
 
+func TestLogger_LogClientRejected(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "audit.log")
+	logger, err := NewLogger(&Config{Enabled: true, Level: "minimal", Output: logFile, Format: "json"})
+	if err != nil {
+		t.Fatalf("NewLogger() error: %v", err)
+	}
+	defer logger.Close()
+
+	logger.LogClientRejected("10.0.0.1", "api.openai.com:443", "not_allowed")
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	for _, want := range []string{"client_rejected", "10.0.0.1", "not_allowed"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Log should contain %q: %s", want, content)
+		}
+	}
+}
+
+func TestLogger_LogPinningDetected(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "audit.log")
+	logger, err := NewLogger(&Config{Enabled: true, Level: "minimal", Output: logFile, Format: "json"})
+	if err != nil {
+		t.Fatalf("NewLogger() error: %v", err)
+	}
+	defer logger.Close()
+
+	logger.LogPinningDetected("10.0.0.1", "api.openai.com:443", 3, true)
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	for _, want := range []string{"pinning_detected", "10.0.0.1", `"count":3`, `"bypassed":"true"`} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Log should contain %q: %s", want, content)
+		}
+	}
+}
+
+func TestLogger_LogAdminAction(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "audit.log")
+	logger, err := NewLogger(&Config{Enabled: true, Level: "minimal", Output: logFile, Format: "json"})
+	if err != nil {
+		t.Fatalf("NewLogger() error: %v", err)
+	}
+	defer logger.Close()
+
+	logger.LogAdminAction(AdminAction{ClientIP: "10.0.0.1", Principal: "ci", Role: "operator", Action: "PUT /admin/mode", Result: "200"})
+	logger.LogAdminAction(AdminAction{ClientIP: "10.0.0.2", Action: "DELETE /admin/mappings", Result: "unauthenticated"})
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	for _, want := range []string{"admin_action", `"principal":"ci"`, `"role":"operator"`, `"action":"PUT /admin/mode"`, `"result":"unauthenticated"`} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Log should contain %s, got %s", want, content)
+		}
+	}
+}
+
 func TestLogger_LogLevel_Standard(t *testing.T) {
 	tmpDir := t.TempDir()
 	logFile := filepath.Join(tmpDir, "audit.log")
@@ -272,6 +336,54 @@ func TestLogger_StdoutOutput(t *testing.T) {
 	logger.LogSecretDetected("req-1", "entropy", "api_key")
 }
 
+func TestLogger_LogMappingRestored(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "audit.log")
+	logger, err := NewLogger(&Config{Enabled: true, Level: "standard", Output: logFile, Format: "json"})
+	if err != nil {
+		t.Fatalf("NewLogger() error: %v", err)
+	}
+	defer logger.Close()
+
+	logger.LogMappingRestored("req-2", "api.openai.com", "__SECRET_12345678__", Origin{
+		RequestID:   "req-1",
+		Host:        "api.anthropic.com",
+		Interceptor: "pattern",
+		Rule:        "github_pat",
+		SecretType:  "github_token",
+	})
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	for _, want := range []string{"placeholder_restored", "req-2", "__SECRET_12345678__", `"origin_request_id":"req-1"`, `"origin_host":"api.anthropic.com"`, "github_pat"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Log should contain %s, got %s", want, content)
+		}
+	}
+}
+
+func TestLogger_LogMappingsPurged(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "audit.log")
+	logger, err := NewLogger(&Config{Enabled: true, Level: "minimal", Output: logFile, Format: "json"})
+	if err != nil {
+		t.Fatalf("NewLogger() error: %v", err)
+	}
+	defer logger.Close()
+
+	logger.LogMappingsPurged(3, map[string]string{"secret_type": "api_key"})
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	for _, want := range []string{"mappings_purged", `"count":3`, `"criteria_secret_type":"api_key"`} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("Log should contain %s, got %s", want, content)
+		}
+	}
+}
+
 func TestNopLogger(t *testing.T) {
 	logger := NewNopLogger()
 
@@ -280,8 +392,13 @@ func TestNopLogger(t *testing.T) {
 	logger.LogSecretDetected("req-1", "entropy", "api_key")
 	logger.LogSecretReplaced("req-1", 1)
 	logger.LogPlaceholderRestored("req-1", 1)
+	logger.LogMappingCreated("__SECRET_1__", Origin{RequestID: "req-1"})
+	logger.LogMappingsPurged(3, nil)
+	logger.LogMappingRestored("req-2", "host", "__SECRET_1__", Origin{RequestID: "req-1"})
 	logger.LogRequestProcessed("req-1", "POST", "host", "/path", 100)
 	logger.LogResponseProcessed("req-1", "host", 100)
+	logger.LogClientRejected("10.0.0.1", "host", "denied")
+	logger.LogPinningDetected("10.0.0.1", "host", 3, false)
 	logger.LogError(EventTLSError, "req-1", "host", "error")
 	logger.Enable()
 	logger.Disable()
@@ -330,3 +447,27 @@ func TestDefaultConfig(t *testing.T) {
 		t.Errorf("Default format = %q, want 'json'", cfg.Format)
 	}
 }
+
+func TestLogger_OmitInterceptorAndSecretType(t *testing.T) {
+	logFile := filepath.Join(t.TempDir(), "audit.log")
+	logger, err := NewLogger(&Config{Enabled: true, Level: "verbose", Output: logFile, Format: "json", OmitInterceptor: true, OmitSecretType: true})
+	if err != nil {
+		t.Fatalf("NewLogger() error: %v", err)
+	}
+	defer logger.Close()
+
+	logger.LogMappingCreated("__SECRET_1__", Origin{RequestID: "req-1", Interceptor: "pattern", Rule: "github_token", SecretType: "token"})
+
+	content, err := os.ReadFile(logFile)
+	if err != nil {
+		t.Fatalf("Failed to read log file: %v", err)
+	}
+	for _, omitted := range []string{"pattern", "github_token", `"token"`} {
+		if strings.Contains(string(content), omitted) {
+			t.Errorf("log contains %s: %s", omitted, content)
+		}
+	}
+	if !strings.Contains(string(content), "req-1") {
+		t.Errorf("log lacks the request ID: %s", content)
+	}
+}
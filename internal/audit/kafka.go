@@ -0,0 +1,125 @@
+package audit
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// KafkaConfig configures producing audit events to a Kafka topic
+type KafkaConfig struct {
+	// Brokers are the host:port addresses of the bootstrap brokers
+	Brokers []string `yaml:"brokers"`
+	Topic   string   `yaml:"topic"`
+	// SASLMechanism is "", "plain", "scram-sha-256" or "scram-sha-512"
+	SASLMechanism string `yaml:"sasl_mechanism"`
+	Username      string `yaml:"username"`
+	Password      string `yaml:"password"` //#nosec G117 -- SASL credentials are part of the sink config
+	// TLS enables TLS to the brokers if set
+	TLS *tls.Config `yaml:"-"`
+	// BatchTimeout is how long events are collected before a batch is sent
+	BatchTimeout time.Duration `yaml:"batch_timeout"`
+	// OnError reports batches the producer failed to send (nil = ignored)
+	OnError func(error) `yaml:"-"`
+}
+
+// kafkaBatchTimeout is the default BatchTimeout
+const kafkaBatchTimeout = 100 * time.Millisecond
+
+// messageWriter is the part of kafka.Writer the sink uses
+type messageWriter interface {
+	WriteMessages(ctx context.Context, msgs ...kafka.Message) error
+	Close() error
+}
+
+// kafkaWriter produces each written JSON log line as a message keyed by the
+// host of the event, so the events of a host stay in one partition. Messages
+// are sent asynchronously in batches; Close flushes pending messages.
+type kafkaWriter struct {
+	writer messageWriter
+}
+
+// newKafkaWriter validates cfg and creates the producer. Brokers are
+// connected lazily, when the first batch is sent.
+func newKafkaWriter(cfg KafkaConfig) (*kafkaWriter, error) {
+	if len(cfg.Brokers) == 0 || slices.Contains(cfg.Brokers, "") {
+		return nil, fmt.Errorf("kafka brokers must be set")
+	}
+	if cfg.Topic == "" {
+		return nil, fmt.Errorf("kafka topic must be set")
+	}
+	mechanism, err := kafkaSASL(cfg)
+	if err != nil {
+		return nil, err
+	}
+	batchTimeout := cfg.BatchTimeout
+	if batchTimeout <= 0 {
+		batchTimeout = kafkaBatchTimeout
+	}
+
+	return &kafkaWriter{writer: &kafka.Writer{
+		Addr:         kafka.TCP(cfg.Brokers...),
+		Topic:        cfg.Topic,
+		Balancer:     &kafka.Hash{},
+		BatchTimeout: batchTimeout,
+		RequiredAcks: kafka.RequireAll,
+		Async:        true,
+		Transport: &kafka.Transport{
+			ClientID: "llm-secret-interceptor",
+			SASL:     mechanism,
+			TLS:      cfg.TLS,
+		},
+		ErrorLogger: kafka.LoggerFunc(func(msg string, args ...any) {
+			if cfg.OnError != nil {
+				cfg.OnError(fmt.Errorf("kafka: %s", fmt.Sprintf(msg, args...)))
+			}
+		}),
+	}}, nil
+}
+
+// kafkaSASL returns the SASL mechanism of cfg, or nil without one
+func kafkaSASL(cfg KafkaConfig) (sasl.Mechanism, error) {
+	switch strings.ToLower(cfg.SASLMechanism) {
+	case "":
+		return nil, nil
+	case "plain":
+		return plain.Mechanism{Username: cfg.Username, Password: cfg.Password}, nil
+	case "scram-sha-256":
+		return scram.Mechanism(scram.SHA256, cfg.Username, cfg.Password)
+	case "scram-sha-512":
+		return scram.Mechanism(scram.SHA512, cfg.Username, cfg.Password)
+	default:
+		return nil, fmt.Errorf("kafka SASL mechanism must be plain, scram-sha-256 or scram-sha-512, got %q", cfg.SASLMechanism)
+	}
+}
+
+// Write queues p, a single JSON log line, for the topic
+func (w *kafkaWriter) Write(p []byte) (int, error) {
+	// The handler reuses p, while messages are sent after Write returns
+	value := slices.Clone(p)
+	var event struct {
+		Host string `json:"host"`
+	}
+	msg := kafka.Message{Value: value}
+	if json.Unmarshal(value, &event) == nil && event.Host != "" {
+		msg.Key = []byte(event.Host)
+	}
+	if err := w.writer.WriteMessages(context.Background(), msg); err != nil {
+		return 0, fmt.Errorf("failed to queue Kafka message: %w", err)
+	}
+	return len(p), nil
+}
+
+// Close flushes pending messages and closes the producer
+func (w *kafkaWriter) Close() error {
+	return w.writer.Close()
+}
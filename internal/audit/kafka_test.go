@@ -0,0 +1,92 @@
+package audit
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// recordingWriter records the messages written to it
+type recordingWriter struct {
+	messages []kafka.Message
+	closed   bool
+}
+
+func (w *recordingWriter) WriteMessages(_ context.Context, msgs ...kafka.Message) error {
+	w.messages = append(w.messages, msgs...)
+	return nil
+}
+
+func (w *recordingWriter) Close() error {
+	w.closed = true
+	return nil
+}
+
+func TestKafkaOutput(t *testing.T) {
+	logger, err := NewLogger(&Config{
+		Enabled: true,
+		Level:   "standard",
+		Output:  "kafka",
+		Format:  "json",
+		Kafka:   KafkaConfig{Brokers: []string{"127.0.0.1:9092"}, Topic: "audit"},
+	})
+	if err != nil {
+		t.Fatalf("NewLogger() error: %v", err)
+	}
+	recorder := &recordingWriter{}
+	logger.output.(*kafkaWriter).writer = recorder
+
+	logger.LogRequestProcessed("req-1", "POST", "api.openai.com", "/v1/chat/completions", 12)
+	logger.LogSecretDetected("req-1", "pattern", "token")
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	if len(recorder.messages) != 2 || !recorder.closed {
+		t.Fatalf("messages = %d, closed = %t", len(recorder.messages), recorder.closed)
+	}
+	request, detection := recorder.messages[0], recorder.messages[1]
+	if string(request.Key) != "api.openai.com" || !strings.Contains(string(request.Value), `"type":"request_processed"`) {
+		t.Errorf("request message = %s: %s", request.Key, request.Value)
+	}
+	// Events without a host are spread over the partitions
+	if detection.Key != nil || !strings.Contains(string(detection.Value), `"request_id":"req-1"`) {
+		t.Errorf("detection message = %s: %s", detection.Key, detection.Value)
+	}
+}
+
+func TestNewKafkaWriter_Invalid(t *testing.T) {
+	for _, cfg := range []KafkaConfig{
+		{Topic: "audit"},
+		{Brokers: []string{"127.0.0.1:9092"}},
+		{Brokers: []string{"127.0.0.1:9092"}, Topic: "audit", SASLMechanism: "gssapi"},
+	} {
+		if _, err := newKafkaWriter(cfg); err == nil {
+			t.Errorf("newKafkaWriter(%+v) succeeded", cfg)
+		}
+	}
+
+	cfg := &Config{Enabled: true, Level: "standard", Output: "kafka", Format: "text",
+		Kafka: KafkaConfig{Brokers: []string{"127.0.0.1:9092"}, Topic: "audit"}}
+	if _, err := NewLogger(cfg); err == nil {
+		t.Error("NewLogger() with kafka output and text format succeeded")
+	}
+}
+
+func TestKafkaWriter_ReportsErrors(t *testing.T) {
+	var reported error
+	w, err := newKafkaWriter(KafkaConfig{
+		Brokers: []string{"127.0.0.1:9092"},
+		Topic:   "audit",
+		OnError: func(err error) { reported = err },
+	})
+	if err != nil {
+		t.Fatalf("newKafkaWriter() error: %v", err)
+	}
+	w.writer.(*kafka.Writer).ErrorLogger.Printf("error writing messages to topic %q: %v", "audit", "broker unavailable")
+	if reported == nil || !strings.Contains(reported.Error(), "broker unavailable") {
+		t.Errorf("reported error = %v", reported)
+	}
+}
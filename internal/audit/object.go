@@ -0,0 +1,199 @@
+package audit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+)
+
+// ObjectUploader stores objects in a bucket, implemented by the uploaders of
+// package objectstore
+type ObjectUploader interface {
+	Put(ctx context.Context, key, contentType string, body []byte) error
+}
+
+// ObjectConfig configures exporting audit events to object storage
+type ObjectConfig struct {
+	Uploader ObjectUploader `yaml:"-"`
+	// Prefix is prepended to the object keys
+	Prefix string `yaml:"prefix"`
+	// Window is how long events are collected in one object
+	Window time.Duration `yaml:"window"`
+	// MaxBytes starts a new object early once the uncompressed events of the
+	// current one exceed it
+	MaxBytes int `yaml:"max_bytes"`
+	// OnError reports failed and dropped uploads (nil = ignored)
+	OnError func(error) `yaml:"-"`
+}
+
+const (
+	// defaultObjectWindow is the default ObjectConfig.Window
+	defaultObjectWindow = 5 * time.Minute
+	// defaultObjectMaxBytes is the default ObjectConfig.MaxBytes
+	defaultObjectMaxBytes = 64 << 20
+	// objectUploadTimeout bounds uploading an object
+	objectUploadTimeout = 2 * time.Minute
+	// maxPendingObjects caps the objects kept for another upload attempt
+	maxPendingObjects = 16
+)
+
+// auditObject is a finished batch of events
+type auditObject struct {
+	key  string
+	body []byte
+}
+
+// objectWriter collects written log lines into gzip-compressed NDJSON objects,
+// one per time window, and uploads them in the background. Objects that fail
+// to upload are retried with the next one.
+type objectWriter struct {
+	cfg      ObjectConfig
+	hostname string
+
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	gz      *gzip.Writer
+	started time.Time
+	size    int
+	seq     int
+
+	// pending is only used by the upload loop
+	pending  []auditObject
+	full     chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// newObjectWriter validates cfg and starts the upload loop
+func newObjectWriter(cfg ObjectConfig) (*objectWriter, error) {
+	if cfg.Uploader == nil {
+		return nil, fmt.Errorf("object storage output requires an uploader")
+	}
+	if cfg.Window <= 0 {
+		cfg.Window = defaultObjectWindow
+	}
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = defaultObjectMaxBytes
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "proxy"
+	}
+
+	w := &objectWriter{
+		cfg:      cfg,
+		hostname: hostname,
+		full:     make(chan struct{}, 1),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	go w.run()
+	return w, nil
+}
+
+// Write adds p, a single log line, to the current object
+func (w *objectWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.gz == nil {
+		w.gz = gzip.NewWriter(&w.buf)
+		w.started = time.Now()
+	}
+	if _, err := w.gz.Write(p); err != nil {
+		return 0, err
+	}
+	w.size += len(p)
+	if w.size >= w.cfg.MaxBytes {
+		select {
+		case w.full <- struct{}{}:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+// rotate finishes the current object, returning false if it has no events
+func (w *objectWriter) rotate() (auditObject, bool) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.gz == nil {
+		return auditObject{}, false
+	}
+	if err := w.gz.Close(); err != nil {
+		w.report(fmt.Errorf("failed to compress audit events: %w", err))
+	}
+	w.seq++
+	object := auditObject{
+		key: fmt.Sprintf("%s%s/%s-%s-%d.ndjson.gz", w.cfg.Prefix, w.started.UTC().Format("2006/01/02/15"),
+			w.started.UTC().Format("20060102T150405Z"), w.hostname, w.seq),
+		body: bytes.Clone(w.buf.Bytes()),
+	}
+	w.buf.Reset()
+	w.gz = nil
+	w.size = 0
+	return object, true
+}
+
+// run uploads an object at the end of each window or when it is full, and
+// the last one when the writer is closed
+func (w *objectWriter) run() {
+	defer close(w.done)
+	ticker := time.NewTicker(w.cfg.Window)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-w.full:
+		case <-w.stop:
+			w.flush()
+			return
+		}
+		w.flush()
+	}
+}
+
+// flush uploads the current object and those that failed before
+func (w *objectWriter) flush() {
+	if object, ok := w.rotate(); ok {
+		w.pending = append(w.pending, object)
+	}
+	if dropped := len(w.pending) - maxPendingObjects; dropped > 0 {
+		w.report(fmt.Errorf("dropping %d audit objects that failed to upload", dropped))
+		w.pending = w.pending[dropped:]
+	}
+
+	remaining := w.pending[:0]
+	for _, object := range w.pending {
+		ctx, cancel := context.WithTimeout(context.Background(), objectUploadTimeout)
+		err := w.cfg.Uploader.Put(ctx, object.key, "application/gzip", object.body)
+		cancel()
+		if err != nil {
+			w.report(fmt.Errorf("failed to upload audit object %s: %w", object.key, err))
+			remaining = append(remaining, object)
+		}
+	}
+	w.pending = remaining
+}
+
+func (w *objectWriter) report(err error) {
+	if w.cfg.OnError != nil {
+		w.cfg.OnError(err)
+	}
+}
+
+// Close uploads the events collected so far and stops the upload loop
+func (w *objectWriter) Close() error {
+	w.stopOnce.Do(func() { close(w.stop) })
+	<-w.done
+	if len(w.pending) > 0 {
+		return fmt.Errorf("failed to upload %d audit objects", len(w.pending))
+	}
+	return nil
+}
@@ -0,0 +1,148 @@
+package audit
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// memoryUploader keeps uploaded objects, failing while fail is set
+type memoryUploader struct {
+	mu       sync.Mutex
+	fail     bool
+	objects  map[string][]byte
+	uploaded chan string
+}
+
+func newMemoryUploader() *memoryUploader {
+	return &memoryUploader{objects: map[string][]byte{}, uploaded: make(chan string, 16)}
+}
+
+func (u *memoryUploader) Put(_ context.Context, key, contentType string, body []byte) error {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if u.fail {
+		return errors.New("bucket unavailable")
+	}
+	if contentType != "application/gzip" {
+		return errors.New("unexpected content type " + contentType)
+	}
+	u.objects[key] = body
+	u.uploaded <- key
+	return nil
+}
+
+// lines decompresses an uploaded object
+func (u *memoryUploader) lines(t *testing.T, key string) []string {
+	t.Helper()
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	r, err := gzip.NewReader(bytes.NewReader(u.objects[key]))
+	if err != nil {
+		t.Fatalf("object %s is not gzip: %v", key, err)
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("failed to decompress %s: %v", key, err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+var objectKey = regexp.MustCompile(`^audit/\d{4}/\d\d/\d\d/\d\d/\d{8}T\d{6}Z-.+-\d+\.ndjson\.gz$`)
+
+func TestObjectOutput(t *testing.T) {
+	uploader := newMemoryUploader()
+	logger, err := NewLogger(&Config{
+		Enabled: true,
+		Level:   "standard",
+		Output:  "s3",
+		Format:  "json",
+		Object:  ObjectConfig{Uploader: uploader, Prefix: "audit/", Window: time.Hour},
+	})
+	if err != nil {
+		t.Fatalf("NewLogger() error: %v", err)
+	}
+	logger.LogSecretDetected("req-1", "pattern", "token")
+	logger.LogSecretReplaced("req-1", 1)
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	key := <-uploader.uploaded
+	if !objectKey.MatchString(key) {
+		t.Errorf("object key = %q", key)
+	}
+	lines := uploader.lines(t, key)
+	if len(lines) != 2 || !strings.Contains(lines[0], `"type":"secret_detected"`) || !strings.Contains(lines[1], `"type":"secret_replaced"`) {
+		t.Errorf("object lines = %q", lines)
+	}
+}
+
+func TestObjectWriter_MaxBytesAndRetry(t *testing.T) {
+	uploader := newMemoryUploader()
+	uploader.fail = true
+	reported := make(chan error, 16)
+	w, err := newObjectWriter(ObjectConfig{Uploader: uploader, Window: time.Hour, MaxBytes: 10,
+		OnError: func(err error) { reported <- err }})
+	if err != nil {
+		t.Fatalf("newObjectWriter() error: %v", err)
+	}
+
+	// A full object is uploaded right away, and kept while the upload fails
+	_, _ = w.Write([]byte(`{"type":"first"}` + "\n"))
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		w.mu.Lock()
+		rotated := w.gz == nil
+		w.mu.Unlock()
+		if rotated {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("full object was not rotated")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	select {
+	case err := <-reported:
+		if !strings.Contains(err.Error(), "bucket unavailable") {
+			t.Errorf("reported error = %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("failed upload was not reported")
+	}
+
+	uploader.mu.Lock()
+	uploader.fail = false
+	uploader.mu.Unlock()
+	_, _ = w.Write([]byte(`{"type":"second"}` + "\n"))
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	var lines []string
+	for len(uploader.uploaded) > 0 {
+		lines = append(lines, uploader.lines(t, <-uploader.uploaded)...)
+	}
+	if strings.Join(lines, ",") != `{"type":"first"},{"type":"second"}` {
+		t.Errorf("uploaded events = %q", lines)
+	}
+}
+
+func TestObjectOutput_Invalid(t *testing.T) {
+	for _, cfg := range []*Config{
+		{Enabled: true, Output: "gcs", Format: "json"},
+		{Enabled: true, Output: "s3", Format: "text", Object: ObjectConfig{Uploader: newMemoryUploader()}},
+	} {
+		if _, err := NewLogger(cfg); err == nil {
+			t.Errorf("NewLogger(%+v) succeeded", cfg)
+		}
+	}
+}
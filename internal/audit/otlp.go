@@ -0,0 +1,280 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// OTLPConfig configures exporting audit events as OpenTelemetry log records
+// over OTLP/HTTP with JSON encoding
+type OTLPConfig struct {
+	// Endpoint is the base URL of the collector, e.g. "http://collector:4318";
+	// records are posted to its /v1/logs path
+	Endpoint string `yaml:"endpoint"`
+	// Headers are added to every export request, e.g. for authentication
+	Headers map[string]string `yaml:"headers"`
+	// ServiceName is the service.name resource attribute
+	ServiceName string `yaml:"service_name"`
+	// BatchSize exports early once this many records are queued
+	BatchSize int `yaml:"batch_size"`
+	// Interval is how often queued records are exported
+	Interval time.Duration `yaml:"interval"`
+	// Timeout bounds an export request
+	Timeout time.Duration `yaml:"timeout"`
+	// OnError reports batches that failed to export (nil = ignored)
+	OnError func(error) `yaml:"-"`
+}
+
+const (
+	defaultOTLPBatchSize = 512
+	defaultOTLPInterval  = time.Second
+	defaultOTLPTimeout   = 10 * time.Second
+	// otlpQueueBatches caps the queue at this many batches; older records
+	// are dropped while the collector is unavailable
+	otlpQueueBatches = 16
+	// otlpSeverityInfo is the OpenTelemetry severity number of INFO
+	otlpSeverityInfo = 9
+)
+
+// otlpAttributeNames maps event attributes to OpenTelemetry semantic
+// conventions; other attributes are prefixed with "audit."
+var otlpAttributeNames = map[string]string{
+	"host":       "server.address",
+	"method":     "http.request.method",
+	"path":       "url.path",
+	"client_ip":  "client.address",
+	"error":      "error.message",
+	"request_id": "audit.request_id",
+}
+
+// otlpValue is an OTLP AnyValue; int64 values are strings in OTLP/JSON
+type otlpValue struct {
+	StringValue *string  `json:"stringValue,omitempty"`
+	IntValue    *string  `json:"intValue,omitempty"`
+	DoubleValue *float64 `json:"doubleValue,omitempty"`
+	BoolValue   *bool    `json:"boolValue,omitempty"`
+}
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano         string          `json:"timeUnixNano"`
+	ObservedTimeUnixNano string          `json:"observedTimeUnixNano"`
+	SeverityNumber       int             `json:"severityNumber"`
+	SeverityText         string          `json:"severityText"`
+	EventName            string          `json:"eventName,omitempty"`
+	Body                 otlpValue       `json:"body"`
+	Attributes           []otlpAttribute `json:"attributes"`
+	TraceID              string          `json:"traceId,omitempty"`
+	SpanID               string          `json:"spanId,omitempty"`
+}
+
+// otlpExporter is a slog.Handler queueing audit records as OTLP log records,
+// exported in batches in the background. Records carrying trace_id and
+// span_id attributes are correlated with that span.
+type otlpExporter struct {
+	cfg    OTLPConfig
+	url    string
+	client *http.Client
+
+	mu      sync.Mutex
+	records []otlpLogRecord
+
+	full     chan struct{}
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// newOTLPExporter validates cfg and starts the export loop
+func newOTLPExporter(cfg OTLPConfig) (*otlpExporter, error) {
+	if !strings.HasPrefix(cfg.Endpoint, "http://") && !strings.HasPrefix(cfg.Endpoint, "https://") {
+		return nil, fmt.Errorf("OTLP endpoint must be an http or https URL, got %q", cfg.Endpoint)
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = siemProduct
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = defaultOTLPBatchSize
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = defaultOTLPInterval
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultOTLPTimeout
+	}
+	e := &otlpExporter{
+		cfg:    cfg,
+		url:    strings.TrimRight(cfg.Endpoint, "/") + "/v1/logs",
+		client: &http.Client{Timeout: cfg.Timeout},
+		full:   make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go e.run()
+	return e, nil
+}
+
+func (e *otlpExporter) Enabled(context.Context, slog.Level) bool { return true }
+
+// WithAttrs is not used by the audit logger, which passes attributes per record
+func (e *otlpExporter) WithAttrs([]slog.Attr) slog.Handler { return e }
+
+// WithGroup is not used by the audit logger
+func (e *otlpExporter) WithGroup(string) slog.Handler { return e }
+
+// Handle queues r as a log record
+func (e *otlpExporter) Handle(_ context.Context, r slog.Record) error {
+	now := strconv.FormatInt(time.Now().UnixNano(), 10)
+	record := otlpLogRecord{
+		TimeUnixNano:         strconv.FormatInt(r.Time.UnixNano(), 10),
+		ObservedTimeUnixNano: now,
+		SeverityNumber:       otlpSeverityInfo,
+		SeverityText:         "INFO",
+	}
+	r.Attrs(func(attr slog.Attr) bool {
+		switch attr.Key {
+		case "type":
+			eventType := attr.Value.String()
+			record.EventName = "audit." + eventType
+			record.Body = otlpValue{StringValue: &eventType}
+		case "trace_id":
+			record.TraceID = attr.Value.String()
+		case "span_id":
+			record.SpanID = attr.Value.String()
+		default:
+			name, ok := otlpAttributeNames[attr.Key]
+			if !ok {
+				name = "audit." + attr.Key
+			}
+			record.Attributes = append(record.Attributes, otlpAttribute{Key: name, Value: newOTLPValue(attr.Value)})
+		}
+		return true
+	})
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if limit := e.cfg.BatchSize * otlpQueueBatches; len(e.records) >= limit {
+		e.records = e.records[len(e.records)-limit+1:]
+	}
+	e.records = append(e.records, record)
+	if len(e.records) >= e.cfg.BatchSize {
+		select {
+		case e.full <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// newOTLPValue converts a slog value
+func newOTLPValue(v slog.Value) otlpValue {
+	switch v.Kind() {
+	case slog.KindInt64:
+		s := strconv.FormatInt(v.Int64(), 10)
+		return otlpValue{IntValue: &s}
+	case slog.KindFloat64:
+		f := v.Float64()
+		return otlpValue{DoubleValue: &f}
+	case slog.KindBool:
+		b := v.Bool()
+		return otlpValue{BoolValue: &b}
+	default:
+		s := v.String()
+		return otlpValue{StringValue: &s}
+	}
+}
+
+// run exports queued records each interval, when a batch is full and when
+// the exporter is closed
+func (e *otlpExporter) run() {
+	defer close(e.done)
+	ticker := time.NewTicker(e.cfg.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-e.full:
+		case <-e.stop:
+			e.flush()
+			return
+		}
+		e.flush()
+	}
+}
+
+// flush exports the queued records in batches
+func (e *otlpExporter) flush() {
+	e.mu.Lock()
+	records := e.records
+	e.records = nil
+	e.mu.Unlock()
+
+	for len(records) > 0 {
+		n := min(len(records), e.cfg.BatchSize)
+		if err := e.export(records[:n]); err != nil && e.cfg.OnError != nil {
+			e.cfg.OnError(fmt.Errorf("failed to export %d audit events: %w", n, err))
+		}
+		records = records[n:]
+	}
+}
+
+// export posts records as an ExportLogsServiceRequest
+func (e *otlpExporter) export(records []otlpLogRecord) error {
+	serviceName := e.cfg.ServiceName
+	body, err := json.Marshal(map[string]any{
+		"resourceLogs": []map[string]any{{
+			"resource": map[string]any{
+				"attributes": []otlpAttribute{{Key: "service.name", Value: otlpValue{StringValue: &serviceName}}},
+			},
+			"scopeLogs": []map[string]any{{
+				"scope":      map[string]string{"name": siemProduct + "/audit"},
+				"logRecords": records,
+			}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range e.cfg.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, msg)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// Close exports the queued records and stops the export loop
+func (e *otlpExporter) Close() error {
+	e.stopOnce.Do(func() { close(e.stop) })
+	<-e.done
+	return nil
+}
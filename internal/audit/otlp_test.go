@@ -0,0 +1,168 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// otlpRequest is the part of an ExportLogsServiceRequest the tests inspect
+type otlpRequest struct {
+	ResourceLogs []struct {
+		Resource struct {
+			Attributes []otlpAttribute `json:"attributes"`
+		} `json:"resource"`
+		ScopeLogs []struct {
+			LogRecords []otlpLogRecord `json:"logRecords"`
+		} `json:"scopeLogs"`
+	} `json:"resourceLogs"`
+}
+
+// otlpCollector records the log records posted to it
+func otlpCollector(t *testing.T) (*httptest.Server, func() []otlpLogRecord) {
+	t.Helper()
+	var mu sync.Mutex
+	var records []otlpLogRecord
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/logs" || r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var req otlpRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for _, rl := range req.ResourceLogs {
+			if len(rl.Resource.Attributes) != 1 || *rl.Resource.Attributes[0].Value.StringValue != "proxy-test" {
+				t.Errorf("resource attributes = %+v", rl.Resource.Attributes)
+			}
+			for _, sl := range rl.ScopeLogs {
+				records = append(records, sl.LogRecords...)
+			}
+		}
+	}))
+	t.Cleanup(collector.Close)
+	return collector, func() []otlpLogRecord {
+		mu.Lock()
+		defer mu.Unlock()
+		return records
+	}
+}
+
+func TestOTLPOutput(t *testing.T) {
+	collector, records := otlpCollector(t)
+	logger, err := NewLogger(&Config{
+		Enabled: true,
+		Level:   "standard",
+		Output:  "otlp",
+		OTLP: OTLPConfig{
+			Endpoint:    collector.URL + "/",
+			Headers:     map[string]string{"Authorization": "Bearer token"},
+			ServiceName: "proxy-test",
+		},
+		IncludeRequestDetails: true,
+	})
+	if err != nil {
+		t.Fatalf("NewLogger() error: %v", err)
+	}
+	logger.SetTraceLookup(func(requestID string) (string, string) {
+		if requestID == "req-1" {
+			return "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+		}
+		return "", ""
+	})
+
+	logger.LogSecretDetected("req-1", "pattern", "token")
+	logger.LogRequestProcessed("req-2", "POST", "api.openai.com", "/v1/chat", 12.5)
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	got := records()
+	if len(got) != 2 {
+		t.Fatalf("collector received %d records, want 2", len(got))
+	}
+	detected := got[0]
+	if detected.EventName != "audit.secret_detected" || *detected.Body.StringValue != "secret_detected" {
+		t.Errorf("event = %q body %q", detected.EventName, *detected.Body.StringValue)
+	}
+	if detected.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || detected.SpanID != "00f067aa0ba902b7" {
+		t.Errorf("trace = %q/%q, want the looked up span", detected.TraceID, detected.SpanID)
+	}
+	if detected.TimeUnixNano == "" || detected.SeverityNumber != otlpSeverityInfo {
+		t.Errorf("record = %+v", detected)
+	}
+
+	request := got[1]
+	if request.TraceID != "" {
+		t.Errorf("untraced request has trace %q", request.TraceID)
+	}
+	attrs := make(map[string]otlpValue)
+	for _, attr := range request.Attributes {
+		attrs[attr.Key] = attr.Value
+	}
+	if v := attrs["server.address"]; v.StringValue == nil || *v.StringValue != "api.openai.com" {
+		t.Errorf("server.address = %+v", v)
+	}
+	if v := attrs["http.request.method"]; v.StringValue == nil || *v.StringValue != "POST" {
+		t.Errorf("http.request.method = %+v", v)
+	}
+	if v := attrs["audit.request_id"]; v.StringValue == nil || *v.StringValue != "req-2" {
+		t.Errorf("audit.request_id = %+v", v)
+	}
+	if v := attrs["audit.duration_ms"]; v.DoubleValue == nil || *v.DoubleValue != 12.5 {
+		t.Errorf("audit.duration_ms = %+v", v)
+	}
+}
+
+func TestOTLPOutput_InvalidEndpoint(t *testing.T) {
+	for _, endpoint := range []string{"", "collector:4318", "grpc://collector:4317"} {
+		if _, err := NewLogger(&Config{Enabled: true, Output: "otlp", OTLP: OTLPConfig{Endpoint: endpoint}}); err == nil {
+			t.Errorf("NewLogger() with endpoint %q succeeded", endpoint)
+		}
+	}
+}
+
+func TestOTLPExporter_QueueLimit(t *testing.T) {
+	// A collector that is never reached keeps records queued
+	e := &otlpExporter{cfg: OTLPConfig{BatchSize: 2}, full: make(chan struct{}, 1)}
+	for range 2*otlpQueueBatches + 5 {
+		record := slog.NewRecord(time.Now(), slog.LevelInfo, "", 0)
+		record.AddAttrs(slog.String("type", string(EventSecretDetected)))
+		if err := e.Handle(context.Background(), record); err != nil {
+			t.Fatalf("Handle() error: %v", err)
+		}
+	}
+	if got, want := len(e.records), 2*otlpQueueBatches; got != want {
+		t.Errorf("queued %d records, want %d", got, want)
+	}
+}
+
+func TestOTLPExporter_ReportsExportErrors(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	}))
+	defer collector.Close()
+
+	var reported error
+	e, err := newOTLPExporter(OTLPConfig{Endpoint: collector.URL, Interval: time.Hour, OnError: func(err error) { reported = err }})
+	if err != nil {
+		t.Fatalf("newOTLPExporter() error: %v", err)
+	}
+	record := slog.NewRecord(time.Now(), slog.LevelInfo, "", 0)
+	record.AddAttrs(slog.String("type", string(EventSecretDetected)))
+	_ = e.Handle(context.Background(), record)
+	_ = e.Close()
+	if reported == nil || !strings.Contains(reported.Error(), "status 503") {
+		t.Errorf("reported error = %v", reported)
+	}
+}
@@ -0,0 +1,220 @@
+package audit
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RotationConfig controls rotating a file output
+type RotationConfig struct {
+	// MaxSize rotates the file before it would grow beyond this many bytes
+	MaxSize int64 `yaml:"max_size"`
+	// MaxAge rotates the file once it has been written to for this long
+	MaxAge time.Duration `yaml:"max_age"`
+	// Compress gzips rotated files
+	Compress bool `yaml:"compress"`
+	// MaxBackups is how many rotated files are kept
+	MaxBackups int `yaml:"max_backups"`
+	// Retention removes rotated files older than this
+	Retention time.Duration `yaml:"retention"`
+	// OnError reports rotated files that could not be compressed or
+	// removed (nil = ignored)
+	OnError func(error) `yaml:"-"`
+}
+
+// enabled reports whether the file is rotated at all
+func (c RotationConfig) enabled() bool {
+	return c.MaxSize > 0 || c.MaxAge > 0
+}
+
+// backupTimeFormat names rotated files; it sorts chronologically
+const backupTimeFormat = "20060102T150405.000Z"
+
+// rotatingFile is a log file that is renamed to <name>-<time><ext> and
+// reopened when it grows too large or too old. Rotated files are compressed
+// and pruned in the background.
+type rotatingFile struct {
+	path string
+	cfg  RotationConfig
+	now  func() time.Time
+
+	mu     sync.Mutex
+	file   *os.File
+	size   int64
+	opened time.Time
+
+	// cleanup serializes compressing and pruning rotated files
+	cleanup sync.Mutex
+	wg      sync.WaitGroup
+}
+
+func newRotatingFile(path string, cfg RotationConfig) (*rotatingFile, error) {
+	f := &rotatingFile{path: filepath.Clean(path), cfg: cfg, now: time.Now}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return err
+	}
+	f.file, f.size, f.opened = file, info.Size(), f.now()
+	return nil
+}
+
+// Write appends p, rotating the file first if p would exceed its limits
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	full := f.cfg.MaxSize > 0 && f.size+int64(len(p)) > f.cfg.MaxSize
+	old := f.cfg.MaxAge > 0 && f.now().Sub(f.opened) >= f.cfg.MaxAge
+	if f.size > 0 && (full || old) {
+		if err := f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// rotate renames the current file and opens a new one
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("failed to close audit log: %w", err)
+	}
+	f.file = nil
+	ext := filepath.Ext(f.path)
+	backup := fmt.Sprintf("%s-%s%s", strings.TrimSuffix(f.path, ext), f.now().UTC().Format(backupTimeFormat), ext)
+	if err := os.Rename(f.path, backup); err != nil {
+		return fmt.Errorf("failed to rotate audit log: %w", err)
+	}
+	if err := f.open(); err != nil {
+		return fmt.Errorf("failed to reopen audit log: %w", err)
+	}
+
+	f.wg.Go(func() {
+		f.cleanup.Lock()
+		defer f.cleanup.Unlock()
+		if f.cfg.Compress {
+			if err := compressFile(backup); err != nil {
+				f.report(fmt.Errorf("failed to compress rotated audit log %s: %w", backup, err))
+			}
+		}
+		f.prune()
+	})
+	return nil
+}
+
+// compressFile replaces path with path.gz
+func compressFile(path string) error {
+	src, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return err
+	}
+	defer func() { _ = src.Close() }()
+	dst, err := os.OpenFile(path+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		_ = dst.Close()
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	return os.Remove(path)
+}
+
+// backups returns the rotated files of the log, oldest first
+func (f *rotatingFile) backups() ([]string, error) {
+	ext := filepath.Ext(f.path)
+	prefix := filepath.Base(strings.TrimSuffix(f.path, ext)) + "-"
+	entries, err := os.ReadDir(filepath.Dir(f.path))
+	if err != nil {
+		return nil, err
+	}
+	var backups []string
+	for _, entry := range entries {
+		name := entry.Name()
+		stamp, ok := strings.CutPrefix(name, prefix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		stamp = strings.TrimSuffix(strings.TrimSuffix(stamp, ".gz"), ext)
+		if _, err := time.Parse(backupTimeFormat, stamp); err == nil {
+			backups = append(backups, filepath.Join(filepath.Dir(f.path), name))
+		}
+	}
+	// The timestamps sort chronologically
+	slices.Sort(backups)
+	return backups, nil
+}
+
+// prune removes rotated files beyond MaxBackups or older than Retention
+func (f *rotatingFile) prune() {
+	if f.cfg.MaxBackups <= 0 && f.cfg.Retention <= 0 {
+		return
+	}
+	backups, err := f.backups()
+	if err != nil {
+		f.report(fmt.Errorf("failed to list rotated audit logs: %w", err))
+		return
+	}
+	for i, backup := range backups {
+		expired := len(backups)-i > f.cfg.MaxBackups && f.cfg.MaxBackups > 0
+		if !expired && f.cfg.Retention > 0 {
+			info, err := os.Stat(backup)
+			expired = err == nil && f.now().Sub(info.ModTime()) > f.cfg.Retention
+		}
+		if expired {
+			if err := os.Remove(backup); err != nil {
+				f.report(fmt.Errorf("failed to remove rotated audit log: %w", err))
+			}
+		}
+	}
+}
+
+func (f *rotatingFile) report(err error) {
+	if f.cfg.OnError != nil {
+		f.cfg.OnError(err)
+	}
+}
+
+// Close closes the file and waits for rotated files to be compressed
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	var err error
+	if f.file != nil {
+		err = f.file.Close()
+		f.file = nil
+	}
+	f.mu.Unlock()
+	f.wg.Wait()
+	return err
+}
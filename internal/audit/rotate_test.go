@@ -0,0 +1,145 @@
+package audit
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// advancingClock returns a clock starting at start that advances by step on
+// each call, so rotated files get distinct names
+func advancingClock(start time.Time, step time.Duration) func() time.Time {
+	now := start
+	return func() time.Time {
+		now = now.Add(step)
+		return now
+	}
+}
+
+func TestRotatingFile_MaxSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	f, err := newRotatingFile(path, RotationConfig{MaxSize: 20, Compress: true, MaxBackups: 2})
+	if err != nil {
+		t.Fatalf("newRotatingFile() error: %v", err)
+	}
+	f.now = advancingClock(time.Now(), time.Second)
+
+	for _, line := range []string{"event-1 0123456789\n", "event-2 0123456789\n", "event-3 0123456789\n", "event-4 0123456789\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("Write() error: %v", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	current, _ := os.ReadFile(path)
+	if string(current) != "event-4 0123456789\n" {
+		t.Errorf("current file = %q", current)
+	}
+	backups, err := f.backups()
+	if err != nil {
+		t.Fatalf("backups() error: %v", err)
+	}
+	// event-1 was pruned, the two newest rotated files are kept compressed
+	if len(backups) != 2 {
+		t.Fatalf("backups = %v, want 2", backups)
+	}
+	for i, backup := range backups {
+		if !strings.HasSuffix(backup, ".log.gz") {
+			t.Errorf("backup %s is not compressed", backup)
+			continue
+		}
+		if got := gunzipFile(t, backup); got != []string{"event-2 0123456789\n", "event-3 0123456789\n"}[i] {
+			t.Errorf("backup %s = %q", backup, got)
+		}
+	}
+}
+
+func TestRotatingFile_MaxAgeAndRetention(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.log")
+	// A rotated file from long ago
+	stale := filepath.Join(dir, "audit-20200101T000000.000Z.log")
+	if err := os.WriteFile(stale, []byte("old\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_ = os.Chtimes(stale, time.Now().Add(-48*time.Hour), time.Now().Add(-48*time.Hour))
+
+	f, err := newRotatingFile(path, RotationConfig{MaxAge: time.Hour, Retention: 24 * time.Hour})
+	if err != nil {
+		t.Fatalf("newRotatingFile() error: %v", err)
+	}
+	_, _ = f.Write([]byte("first\n"))
+	_, _ = f.Write([]byte("second\n"))
+	f.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	_, _ = f.Write([]byte("third\n"))
+	if err := f.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	backups, _ := f.backups()
+	if len(backups) != 1 {
+		t.Fatalf("backups = %v, want the rotated file only", backups)
+	}
+	if data, _ := os.ReadFile(backups[0]); string(data) != "first\nsecond\n" {
+		t.Errorf("rotated file = %q", data)
+	}
+	if current, _ := os.ReadFile(path); string(current) != "third\n" {
+		t.Errorf("current file = %q", current)
+	}
+}
+
+func TestRotatingFile_ReportsPruneErrors(t *testing.T) {
+	var reported error
+	f := &rotatingFile{
+		path: filepath.Join(t.TempDir(), "missing", "audit.log"),
+		cfg:  RotationConfig{MaxBackups: 1, OnError: func(err error) { reported = err }},
+		now:  time.Now,
+	}
+	f.prune()
+	if reported == nil || !strings.Contains(reported.Error(), "failed to list rotated audit logs") {
+		t.Errorf("reported error = %v", reported)
+	}
+}
+
+func TestLogger_FileRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	logger, err := NewLogger(&Config{
+		Enabled:  true,
+		Level:    "standard",
+		Output:   path,
+		Format:   "json",
+		Rotation: RotationConfig{MaxSize: 1},
+	})
+	if err != nil {
+		t.Fatalf("NewLogger() error: %v", err)
+	}
+	logger.LogSecretDetected("req-1", "pattern", "token")
+	logger.LogSecretDetected("req-2", "pattern", "token")
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	if current, _ := os.ReadFile(path); !strings.Contains(string(current), "req-2") || strings.Contains(string(current), "req-1") {
+		t.Errorf("current file = %s", current)
+	}
+}
+
+func gunzipFile(t *testing.T, path string) string {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	r, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("%s is not gzip: %v", path, err)
+	}
+	data, _ := io.ReadAll(r)
+	return string(data)
+}
@@ -0,0 +1,127 @@
+package audit
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/metrics"
+)
+
+// SamplingConfig thins out events before they reach the output, so verbose
+// levels cannot overwhelm it during traffic spikes
+type SamplingConfig struct {
+	// Ratios logs only this fraction of the events of a type, e.g.
+	// {"request_processed": 0.1}; unlisted types are logged in full
+	Ratios map[EventType]float64 `yaml:"ratios"`
+	// RateLimit caps the events logged per second across all types; 0 is
+	// unlimited
+	RateLimit float64 `yaml:"rate_limit"`
+	// Burst is how many events may be logged at once above RateLimit; it
+	// defaults to RateLimit
+	Burst int `yaml:"burst"`
+}
+
+// Reasons events are dropped
+const (
+	dropSampled     = "sampled"
+	dropRateLimited = "rate_limited"
+)
+
+// DropCounts counts the events dropped by sampling and rate limiting
+type DropCounts struct {
+	Sampled     uint64
+	RateLimited uint64
+}
+
+// sampler decides which events are logged. Events of a type are sampled
+// independently; the remaining ones share a token bucket.
+type sampler struct {
+	ratios map[EventType]float64
+	rate   float64
+	burst  float64
+
+	mu      sync.Mutex
+	tokens  float64
+	updated time.Time
+
+	sampled     atomic.Uint64
+	rateLimited atomic.Uint64
+}
+
+// newSampler validates cfg, returning nil if it samples nothing
+func newSampler(cfg SamplingConfig) (*sampler, error) {
+	for eventType, ratio := range cfg.Ratios {
+		if !slices.Contains(eventTypes, eventType) {
+			return nil, fmt.Errorf("unknown audit event type %q in sampling ratios", eventType)
+		}
+		if ratio < 0 || ratio > 1 {
+			return nil, fmt.Errorf("sampling ratio of %s must be between 0 and 1, got %v", eventType, ratio)
+		}
+	}
+	if cfg.RateLimit < 0 || cfg.Burst < 0 {
+		return nil, fmt.Errorf("audit rate limit and burst must not be negative")
+	}
+	if len(cfg.Ratios) == 0 && cfg.RateLimit == 0 {
+		return nil, nil
+	}
+
+	burst := float64(cfg.Burst)
+	if burst == 0 {
+		burst = max(cfg.RateLimit, 1)
+	}
+	return &sampler{
+		ratios: cfg.Ratios,
+		rate:   cfg.RateLimit,
+		burst:  burst,
+		tokens: burst,
+	}, nil
+}
+
+// allow reports whether an event of eventType at now is logged, counting it
+// as dropped otherwise
+func (s *sampler) allow(eventType EventType, now time.Time) bool {
+	if ratio, ok := s.ratios[eventType]; ok && ratio < 1 {
+		//#nosec G404 -- sampling needs no cryptographic randomness
+		if ratio == 0 || rand.Float64() >= ratio {
+			s.drop(eventType, dropSampled)
+			return false
+		}
+	}
+	if s.rate == 0 {
+		return true
+	}
+
+	s.mu.Lock()
+	if !s.updated.IsZero() {
+		s.tokens = min(s.burst, s.tokens+now.Sub(s.updated).Seconds()*s.rate)
+	}
+	s.updated = now
+	allowed := s.tokens >= 1
+	if allowed {
+		s.tokens--
+	}
+	s.mu.Unlock()
+
+	if !allowed {
+		s.drop(eventType, dropRateLimited)
+	}
+	return allowed
+}
+
+func (s *sampler) drop(eventType EventType, reason string) {
+	if reason == dropSampled {
+		s.sampled.Add(1)
+	} else {
+		s.rateLimited.Add(1)
+	}
+	metrics.RecordAuditEventDropped(string(eventType), reason)
+}
+
+// counts returns the events dropped so far
+func (s *sampler) counts() DropCounts {
+	return DropCounts{Sampled: s.sampled.Load(), RateLimited: s.rateLimited.Load()}
+}
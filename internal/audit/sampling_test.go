@@ -0,0 +1,116 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewSampler(t *testing.T) {
+	if s, err := newSampler(SamplingConfig{}); s != nil || err != nil {
+		t.Errorf("newSampler() without limits = %v, %v, want nil", s, err)
+	}
+	for _, cfg := range []SamplingConfig{
+		{Ratios: map[EventType]float64{EventRequestProcessed: 1.5}},
+		{Ratios: map[EventType]float64{EventRequestProcessed: -0.1}},
+		{Ratios: map[EventType]float64{"request_procesed": 0.5}},
+		{RateLimit: -1},
+		{RateLimit: 10, Burst: -1},
+	} {
+		if _, err := newSampler(cfg); err == nil {
+			t.Errorf("newSampler(%+v) succeeded", cfg)
+		}
+	}
+}
+
+func TestSampler_Ratios(t *testing.T) {
+	s, err := newSampler(SamplingConfig{Ratios: map[EventType]float64{
+		EventRequestProcessed: 0,
+		EventMappingCreated:   0.5,
+		EventSecretDetected:   1,
+	}})
+	if err != nil {
+		t.Fatalf("newSampler() error: %v", err)
+	}
+	now := time.Now()
+	logged := 0
+	for range 1000 {
+		if s.allow(EventRequestProcessed, now) {
+			t.Fatal("event with ratio 0 was logged")
+		}
+		if !s.allow(EventSecretDetected, now) || !s.allow(EventResponseProcessed, now) {
+			t.Fatal("event with ratio 1 or without ratio was dropped")
+		}
+		if s.allow(EventMappingCreated, now) {
+			logged++
+		}
+	}
+	if logged < 350 || logged > 650 {
+		t.Errorf("logged %d of 1000 events with ratio 0.5", logged)
+	}
+	if got := s.counts(); got.Sampled != uint64(2000-logged) || got.RateLimited != 0 {
+		t.Errorf("counts() = %+v, want %d sampled", got, 2000-logged)
+	}
+}
+
+func TestSampler_RateLimit(t *testing.T) {
+	s, err := newSampler(SamplingConfig{RateLimit: 10, Burst: 5})
+	if err != nil {
+		t.Fatalf("newSampler() error: %v", err)
+	}
+	now := time.Now()
+	allowed := 0
+	for range 20 {
+		if s.allow(EventRequestProcessed, now) {
+			allowed++
+		}
+	}
+	if allowed != 5 {
+		t.Errorf("allowed %d events at once, want the burst of 5", allowed)
+	}
+	// The bucket refills at the rate limit
+	now = now.Add(200 * time.Millisecond)
+	allowed = 0
+	for range 20 {
+		if s.allow(EventRequestProcessed, now) {
+			allowed++
+		}
+	}
+	if allowed != 2 {
+		t.Errorf("allowed %d events after 200ms, want 2", allowed)
+	}
+	if got := s.counts(); got.RateLimited != 33 || got.Sampled != 0 {
+		t.Errorf("counts() = %+v, want 33 rate limited", got)
+	}
+}
+
+func TestLogger_Sampling(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	logger, err := NewLogger(&Config{
+		Enabled: true,
+		Level:   "standard",
+		Output:  path,
+		Format:  "json",
+		Sampling: SamplingConfig{
+			Ratios: map[EventType]float64{EventRequestProcessed: 0},
+		},
+	})
+	if err != nil {
+		t.Fatalf("NewLogger() error: %v", err)
+	}
+	logger.LogRequestProcessed("req-1", "POST", "api.openai.com", "/v1/chat", 1)
+	logger.LogSecretDetected("req-1", "pattern", "token")
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), string(EventRequestProcessed)) || !strings.Contains(string(data), string(EventSecretDetected)) {
+		t.Errorf("audit log = %s, want only the detection", data)
+	}
+	if got := logger.Dropped(); got.Sampled != 1 {
+		t.Errorf("Dropped() = %+v, want 1 sampled", got)
+	}
+}
@@ -0,0 +1,198 @@
+package audit
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Identification of the proxy in CEF and LEEF headers
+const (
+	siemVendor  = "guided-traffic"
+	siemProduct = "llm-secret-interceptor"
+)
+
+// siemSeverities rates event types on the CEF scale of 0 to 10; unlisted
+// types are informational
+var siemSeverities = map[EventType]int{
+	EventSecretDetected:  5,
+	EventClientRejected:  6,
+	EventPinningDetected: 4,
+	EventTLSError:        5,
+	EventUpstreamError:   4,
+	EventRequestTimeout:  4,
+	EventAdminAction:     3,
+	EventMappingsPurged:  3,
+}
+
+// siemSeverity returns the severity of an event type
+func siemSeverity(eventType string) int {
+	if severity, ok := siemSeverities[EventType(eventType)]; ok {
+		return severity
+	}
+	return 1
+}
+
+// cefKeys maps event attributes to CEF dictionary keys; custom string
+// fields are labeled with the attribute name
+var cefKeys = map[string]string{
+	"request_id":  "externalId",
+	"host":        "dhost",
+	"method":      "requestMethod",
+	"path":        "request",
+	"count":       "cnt",
+	"error":       "msg",
+	"client_ip":   "src",
+	"interceptor": "cs1",
+	"secret_type": "cs2",
+	"principal":   "suser",
+	"duration_ms": "cfp1",
+}
+
+// leefKeys maps event attributes to LEEF attribute names
+var leefKeys = map[string]string{
+	"request_id":  "requestId",
+	"host":        "dstName",
+	"method":      "method",
+	"path":        "url",
+	"count":       "count",
+	"error":       "reason",
+	"client_ip":   "src",
+	"interceptor": "interceptor",
+	"secret_type": "secretType",
+	"principal":   "usrName",
+	"duration_ms": "durationMs",
+}
+
+// siemHandler is a slog.Handler writing audit records as ArcSight CEF or
+// QRadar LEEF 2.0 lines, one per record
+type siemHandler struct {
+	mu      *sync.Mutex
+	w       io.Writer
+	leef    bool
+	version string
+}
+
+func newSIEMHandler(w io.Writer, format string) *siemHandler {
+	version := "dev"
+	if info, ok := debug.ReadBuildInfo(); ok && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		version = info.Main.Version
+	}
+	return &siemHandler{mu: &sync.Mutex{}, w: w, leef: format == "leef", version: version}
+}
+
+func (h *siemHandler) Enabled(context.Context, slog.Level) bool { return true }
+
+// WithAttrs is not used by the audit logger, which passes attributes per record
+func (h *siemHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+// WithGroup is not used by the audit logger
+func (h *siemHandler) WithGroup(string) slog.Handler { return h }
+
+func (h *siemHandler) Handle(_ context.Context, r slog.Record) error {
+	var eventType string
+	var attrs []slog.Attr
+	r.Attrs(func(attr slog.Attr) bool {
+		if attr.Key == "type" {
+			eventType = attr.Value.String()
+		} else {
+			attrs = append(attrs, attr)
+		}
+		return true
+	})
+
+	var line string
+	if h.leef {
+		line = h.formatLEEF(r.Time, eventType, attrs)
+	} else {
+		line = h.formatCEF(r.Time, eventType, attrs)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	_, err := io.WriteString(h.w, line+"\n")
+	return err
+}
+
+// formatCEF formats a CEF:0 line
+func (h *siemHandler) formatCEF(t time.Time, eventType string, attrs []slog.Attr) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "CEF:0|%s|%s|%s|%s|%s|%d|rt=%d", cefHeader(siemVendor), cefHeader(siemProduct),
+		cefHeader(h.version), cefHeader(eventType), cefHeader(eventName(eventType)), siemSeverity(eventType), t.UnixMilli())
+	for _, attr := range attrs {
+		key, known := cefKeys[attr.Key]
+		if !known {
+			key = siemKey(attr.Key)
+		}
+		fmt.Fprintf(&b, " %s=%s", key, cefValue(attr.Value.String()))
+		switch key {
+		case "cs1", "cs2":
+			fmt.Fprintf(&b, " %sLabel=%s", key, siemKey(attr.Key))
+		case "cfp1":
+			b.WriteString(" cfp1Label=durationMs")
+		}
+	}
+	return b.String()
+}
+
+// formatLEEF formats a LEEF:2.0 line with tab-delimited attributes
+func (h *siemHandler) formatLEEF(t time.Time, eventType string, attrs []slog.Attr) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "LEEF:2.0|%s|%s|%s|%s|x09|cat=%s\tsev=%d\tdevTime=%s\tdevTimeFormat=yyyy-MM-dd'T'HH:mm:ss.SSSZ",
+		leefHeader(siemVendor), leefHeader(siemProduct), leefHeader(h.version), leefHeader(eventType),
+		leefValue(eventType), siemSeverity(eventType), t.Format("2006-01-02T15:04:05.000-0700"))
+	for _, attr := range attrs {
+		key, known := leefKeys[attr.Key]
+		if !known {
+			key = siemKey(attr.Key)
+		}
+		fmt.Fprintf(&b, "\t%s=%s", key, leefValue(attr.Value.String()))
+	}
+	return b.String()
+}
+
+// eventName returns a readable name of an event type, "secret_detected"
+// becoming "Secret detected"
+func eventName(eventType string) string {
+	name := strings.ReplaceAll(eventType, "_", " ")
+	if name == "" {
+		return name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// siemKey turns an attribute name into a camelCase key
+func siemKey(name string) string {
+	parts := strings.Split(name, "_")
+	for i := 1; i < len(parts); i++ {
+		if parts[i] != "" {
+			parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// cefHeader escapes a CEF header field
+func cefHeader(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "|", `\|`, "\n", " ", "\r", " ").Replace(s)
+}
+
+// cefValue escapes a CEF extension value
+func cefValue(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "=", `\=`, "\n", `\n`, "\r", `\r`).Replace(s)
+}
+
+// leefHeader escapes a LEEF header field
+func leefHeader(s string) string {
+	return strings.NewReplacer("|", " ", "\n", " ", "\r", " ").Replace(s)
+}
+
+// leefValue keeps a LEEF attribute value from breaking the line or its
+// tab-delimited attributes
+func leefValue(s string) string {
+	return strings.NewReplacer("\t", " ", "\n", " ", "\r", " ").Replace(s)
+}
@@ -0,0 +1,83 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// logSIEM logs a detection and a request with format and returns the lines
+func logSIEM(t *testing.T, format string) []string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audit.log")
+	logger, err := NewLogger(&Config{Enabled: true, Level: "standard", Output: path, Format: format, IncludeRequestDetails: true})
+	if err != nil {
+		t.Fatalf("NewLogger() error: %v", err)
+	}
+	logger.LogSecretDetected("req-1", "pattern", "token")
+	logger.LogRequestProcessed("req-1", "POST", "api.openai.com", "/v1/chat?a=b|c", 12.5)
+	logger.LogError(EventUpstreamError, "req-1", "api.openai.com", "dial tcp: connection refused\nretrying")
+	if err := logger.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	return strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+}
+
+func TestSIEMFormat_CEF(t *testing.T) {
+	lines := logSIEM(t, "cef")
+	if len(lines) != 3 {
+		t.Fatalf("lines = %q", lines)
+	}
+	header := regexp.MustCompile(`^CEF:0\|guided-traffic\|llm-secret-interceptor\|[^|]+\|secret_detected\|Secret detected\|5\|rt=\d+ `)
+	if !header.MatchString(lines[0]) {
+		t.Errorf("detection = %q", lines[0])
+	}
+	for _, want := range []string{"externalId=req-1", "cs1=pattern cs1Label=interceptor", "cs2=token cs2Label=secretType"} {
+		if !strings.Contains(lines[0], want) {
+			t.Errorf("detection %q lacks %q", lines[0], want)
+		}
+	}
+	for _, want := range []string{"requestMethod=POST", "dhost=api.openai.com", `request=/v1/chat?a\=b|c`, "cfp1=12.5 cfp1Label=durationMs"} {
+		if !strings.Contains(lines[1], want) {
+			t.Errorf("request %q lacks %q", lines[1], want)
+		}
+	}
+	if !strings.Contains(lines[2], `|upstream_error|Upstream error|4|`) || !strings.Contains(lines[2], `msg=dial tcp: connection refused\nretrying`) {
+		t.Errorf("error = %q", lines[2])
+	}
+}
+
+func TestSIEMFormat_LEEF(t *testing.T) {
+	lines := logSIEM(t, "leef")
+	if len(lines) != 3 {
+		t.Fatalf("lines = %q", lines)
+	}
+	if !strings.HasPrefix(lines[0], "LEEF:2.0|guided-traffic|llm-secret-interceptor|") ||
+		!strings.Contains(lines[0], "|secret_detected|x09|cat=secret_detected\tsev=5\tdevTime=") {
+		t.Errorf("detection = %q", lines[0])
+	}
+	fields := strings.Split(lines[1], "\t")
+	for _, want := range []string{"requestId=req-1", "method=POST", "dstName=api.openai.com", "url=/v1/chat?a=b|c", "durationMs=12.5"} {
+		found := false
+		for _, field := range fields {
+			found = found || field == want
+		}
+		if !found {
+			t.Errorf("request %q lacks %q", lines[1], want)
+		}
+	}
+	if !strings.Contains(lines[2], "reason=dial tcp: connection refused retrying") {
+		t.Errorf("error = %q", lines[2])
+	}
+}
+
+func TestSIEMKey(t *testing.T) {
+	for name, want := range map[string]string{"rule": "rule", "client_ip": "clientIp", "bypass_host_added": "bypassHostAdded"} {
+		if got := siemKey(name); got != want {
+			t.Errorf("siemKey(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
@@ -0,0 +1,267 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/metrics"
+)
+
+// SyslogConfig configures sending audit events to a syslog collector
+type SyslogConfig struct {
+	// Network is "udp", "tcp" or "tls"
+	Network string `yaml:"network"`
+	// Address is the host:port of the collector
+	Address string `yaml:"address"`
+	// Facility is a syslog facility name such as "auth" or "local0"
+	Facility string `yaml:"facility"`
+	// AppName identifies the proxy in the APP-NAME field
+	AppName string `yaml:"app_name"`
+	// TLS configures the connection for the "tls" network
+	TLS *tls.Config `yaml:"-"`
+	// OnError reports dropped and undelivered events (nil = ignored)
+	OnError func(error) `yaml:"-"`
+}
+
+// syslogFacilities maps facility names to their RFC 5424 codes
+var syslogFacilities = map[string]int{
+	"kern": 0, "user": 1, "mail": 2, "daemon": 3, "auth": 4, "syslog": 5,
+	"lpr": 6, "news": 7, "uucp": 8, "cron": 9, "authpriv": 10, "ftp": 11,
+	"local0": 16, "local1": 17, "local2": 18, "local3": 19,
+	"local4": 20, "local5": 21, "local6": 22, "local7": 23,
+}
+
+// syslogSeverity is the severity of audit events, informational
+const syslogSeverity = 6
+
+const (
+	// syslogTimeout bounds connecting to the collector and sending a message
+	syslogTimeout = 10 * time.Second
+	// syslogQueueSize caps the messages waiting for the collector; further
+	// events are dropped while it is slow or unavailable
+	syslogQueueSize = 1024
+	// syslogMinBackoff and syslogMaxBackoff bound the wait between redials of
+	// an unavailable collector
+	syslogMinBackoff = time.Second
+	syslogMaxBackoff = time.Minute
+)
+
+// ErrSyslogQueueFull is reported when events are dropped because the
+// collector cannot keep up
+var ErrSyslogQueueFull = errors.New("syslog queue full")
+
+// syslogWriter sends each written log line as an RFC 5424 message. Writes
+// only queue the message, so an unresponsive collector cannot stall the
+// requests being audited; a background sender delivers them. Stream
+// connections use octet-counting framing (RFC 6587, RFC 5425) and are
+// redialed when a write fails, backing off while the collector is down.
+type syslogWriter struct {
+	cfg      SyslogConfig
+	priority int
+	hostname string
+	procID   string
+
+	queue    chan []byte
+	dropping atomic.Bool
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+
+	// Owned by the sender
+	conn    net.Conn
+	backoff time.Duration
+	retryAt time.Time
+}
+
+// newSyslogWriter validates cfg, connects to the collector and starts the
+// sender
+func newSyslogWriter(cfg SyslogConfig) (*syslogWriter, error) {
+	switch cfg.Network {
+	case "udp", "tcp", "tls":
+	default:
+		return nil, fmt.Errorf("syslog network must be udp, tcp or tls, got %q", cfg.Network)
+	}
+	if _, _, err := net.SplitHostPort(cfg.Address); err != nil {
+		return nil, fmt.Errorf("invalid syslog address %q: %w", cfg.Address, err)
+	}
+	facility, ok := syslogFacilities[cfg.Facility]
+	if !ok {
+		return nil, fmt.Errorf("unknown syslog facility %q", cfg.Facility)
+	}
+	if cfg.AppName == "" {
+		cfg.AppName = "-"
+	}
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "-"
+	}
+
+	w := &syslogWriter{
+		cfg:      cfg,
+		priority: facility*8 + syslogSeverity,
+		hostname: hostname,
+		procID:   strconv.Itoa(os.Getpid()),
+		queue:    make(chan []byte, syslogQueueSize),
+		stop:     make(chan struct{}),
+		done:     make(chan struct{}),
+	}
+	if err := w.connect(); err != nil {
+		return nil, err
+	}
+	go w.run()
+	return w, nil
+}
+
+func (w *syslogWriter) connect() error {
+	var (
+		conn net.Conn
+		err  error
+	)
+	dialer := &net.Dialer{Timeout: syslogTimeout}
+	if w.cfg.Network == "tls" {
+		tlsConfig := w.cfg.TLS
+		if tlsConfig == nil {
+			tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		conn, err = tls.DialWithDialer(dialer, "tcp", w.cfg.Address, tlsConfig)
+	} else {
+		conn, err = dialer.Dial(w.cfg.Network, w.cfg.Address)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to syslog collector: %w", err)
+	}
+	w.conn = conn
+	return nil
+}
+
+// format returns the framed RFC 5424 message of a log line
+func (w *syslogWriter) format(line []byte, now time.Time) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "<%d>1 %s %s %s %s audit - ", w.priority,
+		now.UTC().Format("2006-01-02T15:04:05.000000Z07:00"), w.hostname, w.cfg.AppName, w.procID)
+	msg.Write(bytes.TrimRight(line, "\n"))
+	if w.cfg.Network == "udp" {
+		return msg.Bytes()
+	}
+	return append([]byte(strconv.Itoa(msg.Len())+" "), msg.Bytes()...)
+}
+
+// Write queues p, a single log line, for the collector. It never blocks;
+// when the queue is full the event is dropped and counted.
+func (w *syslogWriter) Write(p []byte) (int, error) {
+	select {
+	case w.queue <- w.format(p, time.Now()):
+	default:
+		metrics.RecordAuditOutputDropped("syslog")
+		// Report an overflow once, not for every dropped event
+		if w.dropping.CompareAndSwap(false, true) {
+			w.report(ErrSyslogQueueFull)
+		}
+	}
+	return len(p), nil
+}
+
+// run delivers queued messages until the writer is closed, then flushes
+// the queue
+func (w *syslogWriter) run() {
+	defer close(w.done)
+	for {
+		select {
+		case msg := <-w.queue:
+			w.deliver(msg)
+		case <-w.stop:
+			for {
+				select {
+				case msg := <-w.queue:
+					w.deliver(msg)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver sends msg, redialing the collector if its connection failed.
+// Redials back off while the collector is unavailable; msg is dropped if
+// the writer is closed meanwhile or the message cannot be sent over a new
+// connection either.
+func (w *syslogWriter) deliver(msg []byte) {
+	for {
+		redialed := false
+		if w.conn == nil {
+			if wait := time.Until(w.retryAt); wait > 0 {
+				timer := time.NewTimer(wait)
+				select {
+				case <-timer.C:
+				case <-w.stop:
+					timer.Stop()
+					w.drop(nil)
+					return
+				}
+			}
+			if err := w.connect(); err != nil {
+				w.backoff = min(max(2*w.backoff, syslogMinBackoff), syslogMaxBackoff)
+				w.retryAt = time.Now().Add(w.backoff)
+				w.report(err)
+				continue
+			}
+			w.backoff = 0
+			redialed = true
+		}
+		_, err := w.send(msg)
+		if err == nil {
+			w.dropping.Store(false)
+			return
+		}
+		if w.cfg.Network == "udp" || redialed {
+			w.drop(fmt.Errorf("failed to send syslog message: %w", err))
+			return
+		}
+		// The collector may have closed an idle stream connection
+		_ = w.conn.Close()
+		w.conn = nil
+	}
+}
+
+// drop counts an undelivered message and reports err unless it is nil
+func (w *syslogWriter) drop(err error) {
+	metrics.RecordAuditOutputDropped("syslog")
+	if err != nil {
+		w.report(err)
+	}
+}
+
+func (w *syslogWriter) report(err error) {
+	if w.cfg.OnError != nil {
+		w.cfg.OnError(err)
+	}
+}
+
+func (w *syslogWriter) send(msg []byte) (int, error) {
+	if err := w.conn.SetWriteDeadline(time.Now().Add(syslogTimeout)); err != nil {
+		return 0, err
+	}
+	return w.conn.Write(msg)
+}
+
+// Close sends the queued messages, unless the collector is unavailable,
+// and closes the connection
+func (w *syslogWriter) Close() error {
+	w.stopOnce.Do(func() { close(w.stop) })
+	<-w.done
+	if w.conn == nil {
+		return nil
+	}
+	err := w.conn.Close()
+	w.conn = nil
+	return err
+}
@@ -0,0 +1,195 @@
+package audit
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// rfc5424Header matches the header of the audit syslog messages
+var rfc5424Header = regexp.MustCompile(`^<38>1 \d{4}-\d\d-\d\dT\d\d:\d\d:\d\d\.\d{6}Z \S+ llm-secret-interceptor \d+ audit - `)
+
+func TestSyslogOutput_UDP(t *testing.T) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ListenPacket() error: %v", err)
+	}
+	defer conn.Close()
+
+	logger, err := NewLogger(&Config{
+		Enabled: true,
+		Level:   "standard",
+		Output:  "syslog",
+		Format:  "json",
+		Syslog:  SyslogConfig{Network: "udp", Address: conn.LocalAddr().String(), Facility: "auth", AppName: "llm-secret-interceptor"},
+	})
+	if err != nil {
+		t.Fatalf("NewLogger() error: %v", err)
+	}
+	defer logger.Close()
+	logger.LogSecretDetected("req-1", "pattern", "token")
+
+	buf := make([]byte, 4096)
+	_ = conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, _, err := conn.ReadFrom(buf)
+	if err != nil {
+		t.Fatalf("ReadFrom() error: %v", err)
+	}
+	msg := string(buf[:n])
+	if !rfc5424Header.MatchString(msg) {
+		t.Errorf("message %q has no RFC 5424 header", msg)
+	}
+	if !strings.Contains(msg, `"request_id":"req-1"`) || strings.HasSuffix(msg, "\n") {
+		t.Errorf("message = %q", msg)
+	}
+}
+
+func TestSyslogOutput_TCPReconnects(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error: %v", err)
+	}
+	defer listener.Close()
+	messages := make(chan string, 16)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			// Read a single octet-counted message, then drop the connection
+			r := bufio.NewReader(conn)
+			length, err := r.ReadString(' ')
+			if err == nil {
+				n, _ := strconv.Atoi(strings.TrimSpace(length))
+				msg := make([]byte, n)
+				if _, err := io.ReadFull(r, msg); err == nil {
+					messages <- string(msg)
+				}
+			}
+			_ = conn.Close()
+		}
+	}()
+
+	w, err := newSyslogWriter(SyslogConfig{Network: "tcp", Address: listener.Addr().String(), Facility: "auth", AppName: "llm-secret-interceptor"})
+	if err != nil {
+		t.Fatalf("newSyslogWriter() error: %v", err)
+	}
+	defer w.Close()
+
+	if _, err := w.Write([]byte("first\n")); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	if msg := <-messages; !rfc5424Header.MatchString(msg) || !strings.HasSuffix(msg, "first") {
+		t.Errorf("message = %q", msg)
+	}
+
+	// The collector dropped the connection; a write to it may still succeed
+	// once, before the reset is noticed and the writer redials
+	deadline := time.After(5 * time.Second)
+	for {
+		if _, err := w.Write([]byte("second\n")); err != nil {
+			t.Fatalf("Write() after disconnect error: %v", err)
+		}
+		select {
+		case msg := <-messages:
+			if !strings.HasSuffix(msg, "second") {
+				t.Errorf("message = %q", msg)
+			}
+			return
+		case <-deadline:
+			t.Fatal("collector received no message after reconnecting")
+		case <-time.After(50 * time.Millisecond):
+		}
+	}
+}
+
+func TestSyslogOutput_CollectorDown(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error: %v", err)
+	}
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err == nil {
+			accepted <- conn
+		}
+	}()
+
+	errs := make(chan error, 16)
+	w, err := newSyslogWriter(SyslogConfig{
+		Network:  "tcp",
+		Address:  listener.Addr().String(),
+		Facility: "auth",
+		OnError: func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		},
+	})
+	if err != nil {
+		t.Fatalf("newSyslogWriter() error: %v", err)
+	}
+	defer w.Close()
+	_ = listener.Close()
+	_ = (<-accepted).Close()
+
+	// Writes only queue, even with the collector gone
+	start := time.Now()
+	for range syslogQueueSize + 10 {
+		if _, err := w.Write([]byte("event\n")); err != nil {
+			t.Fatalf("Write() error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("writes took %v with the collector down", elapsed)
+	}
+	select {
+	case <-errs:
+	case <-time.After(5 * time.Second):
+		t.Error("undelivered events were not reported")
+	}
+}
+
+func TestSyslogWriter_QueueFull(t *testing.T) {
+	var reported []error
+	w := &syslogWriter{
+		cfg:   SyslogConfig{Network: "udp", AppName: "-", OnError: func(err error) { reported = append(reported, err) }},
+		queue: make(chan []byte, 1),
+	}
+	dropped := testutil.ToFloat64(metrics.AuditOutputDropped.WithLabelValues("syslog"))
+	for range 3 {
+		if _, err := w.Write([]byte("event\n")); err != nil {
+			t.Fatalf("Write() error: %v", err)
+		}
+	}
+	if got := testutil.ToFloat64(metrics.AuditOutputDropped.WithLabelValues("syslog")) - dropped; got != 2 {
+		t.Errorf("dropped events = %v, want 2", got)
+	}
+	if len(reported) != 1 || !errors.Is(reported[0], ErrSyslogQueueFull) {
+		t.Errorf("reported errors = %v, want one ErrSyslogQueueFull", reported)
+	}
+}
+
+func TestNewSyslogWriter_Invalid(t *testing.T) {
+	for _, cfg := range []SyslogConfig{
+		{Network: "http", Address: "127.0.0.1:514", Facility: "auth"},
+		{Network: "udp", Address: "collector", Facility: "auth"},
+		{Network: "udp", Address: "127.0.0.1:514", Facility: "security"},
+	} {
+		if _, err := newSyslogWriter(cfg); err == nil {
+			t.Errorf("newSyslogWriter(%+v) succeeded", cfg)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// Package awsauth provides AWS Signature Version 4 request signing for the
+// AWS services the proxy talks to directly over HTTP.
+package awsauth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	algorithm  = "AWS4-HMAC-SHA256"
+	timeFormat = "20060102T150405Z"
+	dateFormat = "20060102"
+)
+
+// Credentials holds AWS access credentials
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string //#nosec G117 -- credentials are required to sign requests
+	SessionToken    string
+}
+
+// CredentialsFromEnv reads credentials from the standard AWS environment variables
+func CredentialsFromEnv() (Credentials, error) {
+	creds := Credentials{
+		AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+	}
+	if creds.AccessKeyID == "" || creds.SecretAccessKey == "" {
+		return Credentials{}, fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set")
+	}
+	return creds, nil
+}
+
+// Signer signs HTTP requests with AWS Signature Version 4
+type Signer struct {
+	Credentials Credentials
+	Region      string
+	Service     string
+
+	// now returns the signing time (overridable for tests)
+	now func() time.Time
+}
+
+// NewSigner creates a new signer for a service in a region
+func NewSigner(creds Credentials, region, service string) *Signer {
+	return &Signer{
+		Credentials: creds,
+		Region:      region,
+		Service:     service,
+		now:         time.Now,
+	}
+}
+
+// Sign adds the X-Amz-Date, X-Amz-Security-Token, and Authorization headers
+// to the request. body must be the exact request payload.
+func (s *Signer) Sign(req *http.Request, body []byte) error {
+	now := s.now().UTC()
+	amzDate := now.Format(timeFormat)
+	date := now.Format(dateFormat)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if s.Credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.Credentials.SessionToken)
+	}
+	if req.Host == "" {
+		req.Host = req.URL.Host
+	}
+
+	payloadHash := hashHex(body)
+	if h := req.Header.Get("X-Amz-Content-Sha256"); h != "" {
+		payloadHash = h
+	}
+
+	canonicalHeaders, signedHeaders := canonicalizeHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		canonicalPath(req.URL),
+		canonicalQuery(req.URL),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := strings.Join([]string{date, s.Region, s.Service, "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{
+		algorithm,
+		amzDate,
+		scope,
+		hashHex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+s.Credentials.SecretAccessKey), []byte(date))
+	key = hmacSHA256(key, []byte(s.Region))
+	key = hmacSHA256(key, []byte(s.Service))
+	key = hmacSHA256(key, []byte("aws4_request"))
+	signature := hex.EncodeToString(hmacSHA256(key, []byte(stringToSign)))
+
+	req.Header.Set("Authorization", fmt.Sprintf("%s Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		algorithm, s.Credentials.AccessKeyID, scope, signedHeaders, signature))
+
+	return nil
+}
+
+// canonicalizeHeaders returns the canonical header block and the signed header list.
+// The host, content-type, and all x-amz-* headers are signed.
+func canonicalizeHeaders(req *http.Request) (string, string) {
+	headers := map[string]string{"host": req.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || strings.HasPrefix(lower, "x-amz-") {
+			trimmed := make([]string, len(values))
+			for i, v := range values {
+				trimmed[i] = strings.Join(strings.Fields(v), " ")
+			}
+			headers[lower] = strings.Join(trimmed, ",")
+		}
+	}
+
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		b.WriteString(name)
+		b.WriteByte(':')
+		b.WriteString(headers[name])
+		b.WriteByte('\n')
+	}
+	return b.String(), strings.Join(names, ";")
+}
+
+func canonicalPath(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+func canonicalQuery(u *url.URL) string {
+	query := u.Query()
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var parts []string
+	for _, k := range keys {
+		values := query[k]
+		sort.Strings(values)
+		for _, v := range values {
+			parts = append(parts, uriEncode(k)+"="+uriEncode(v))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// uriEncode encodes a string per the SigV4 rules (RFC 3986 unreserved characters only)
+func uriEncode(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+func hashHex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key, data []byte) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
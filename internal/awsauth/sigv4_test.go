@@ -0,0 +1,83 @@
+package awsauth
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Test vector "get-vanilla" from the AWS Signature Version 4 test suite
+func TestSigner_GetVanilla(t *testing.T) {
+	signer := NewSigner(Credentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}, "us-east-1", "service")
+	signer.now = func() time.Time {
+		return time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	if err != nil {
+		t.Fatalf("NewRequest() error: %v", err)
+	}
+
+	if err := signer.Sign(req, nil); err != nil {
+		t.Fatalf("Sign() error: %v", err)
+	}
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, " +
+		"Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization =\n%s\nwant\n%s", got, want)
+	}
+	if got := req.Header.Get("X-Amz-Date"); got != "20150830T123600Z" {
+		t.Errorf("X-Amz-Date = %s", got)
+	}
+}
+
+func TestSigner_SessionToken(t *testing.T) {
+	signer := NewSigner(Credentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "secret",
+		SessionToken:    "session",
+	}, "eu-central-1", "kms")
+
+	req, err := http.NewRequest(http.MethodPost, "https://kms.eu-central-1.amazonaws.com/", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("NewRequest() error: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", "TrentService.Encrypt")
+
+	if err := signer.Sign(req, []byte("{}")); err != nil {
+		t.Fatalf("Sign() error: %v", err)
+	}
+
+	if req.Header.Get("X-Amz-Security-Token") != "session" {
+		t.Error("session token header not set")
+	}
+	auth := req.Header.Get("Authorization")
+	if !strings.Contains(auth, "SignedHeaders=content-type;host;x-amz-date;x-amz-security-token;x-amz-target") {
+		t.Errorf("unexpected signed headers in %s", auth)
+	}
+}
+
+func TestCredentialsFromEnv(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+	if _, err := CredentialsFromEnv(); err == nil {
+		t.Error("CredentialsFromEnv() expected error without credentials")
+	}
+
+	t.Setenv("AWS_ACCESS_KEY_ID", "id")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	creds, err := CredentialsFromEnv()
+	if err != nil {
+		t.Fatalf("CredentialsFromEnv() error: %v", err)
+	}
+	if creds.AccessKeyID != "id" || creds.SecretAccessKey != "secret" {
+		t.Errorf("unexpected credentials %+v", creds)
+	}
+}
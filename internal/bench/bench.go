@@ -0,0 +1,228 @@
+// Package bench measures the detection throughput and latency of secret
+// interceptors on sample corpora, for capacity planning.
+package bench
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/interceptor"
+	"github.com/hfi/llm-secret-interceptor/internal/ruletest"
+)
+
+// AllInterceptors names the results of the full detection pipeline, which
+// runs every interceptor, deduplicates and filters
+const AllInterceptors = "all"
+
+// latencySamples caps the latencies kept per worker; beyond it a uniform
+// reservoir sample is kept
+const latencySamples = 1 << 16
+
+// Options control a benchmark
+type Options struct {
+	// Duration is how long each interceptor runs per CPU count
+	Duration time.Duration
+	// CPUs are the numbers of cores to measure with; each runs as many
+	// concurrent workers
+	CPUs []int
+}
+
+// Result is the throughput of an interceptor on a number of cores
+type Result struct {
+	Interceptor string  `json:"interceptor"`
+	CPUs        int     `json:"cpus"`
+	Calls       int64   `json:"calls"`
+	Bytes       int64   `json:"bytes"`
+	Detections  int64   `json:"detections"`
+	Seconds     float64 `json:"seconds"`
+	MBPerSecond float64 `json:"mb_per_second"`
+	// P50, P99 and Max are latencies of a single sample
+	P50 time.Duration `json:"p50_ns"`
+	P99 time.Duration `json:"p99_ns"`
+	Max time.Duration `json:"max_ns"`
+}
+
+// Report is the result of a benchmark
+type Report struct {
+	Samples     int      `json:"samples"`
+	SampleBytes int64    `json:"sample_bytes"`
+	Results     []Result `json:"results"`
+}
+
+// LoadSamples reads sample texts from files. JSON Lines files (.jsonl) are
+// read as ruletest corpora, other files are a single sample each.
+func LoadSamples(paths []string) ([]string, error) {
+	var samples []string
+	for _, path := range paths {
+		if strings.EqualFold(filepath.Ext(path), ".jsonl") {
+			corpus, err := ruletest.LoadCorpus(path)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			for _, sample := range corpus {
+				samples = append(samples, sample.Text)
+			}
+			continue
+		}
+		data, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read sample: %w", err)
+		}
+		samples = append(samples, string(data))
+	}
+	return samples, nil
+}
+
+// detector is a detection function under test
+type detector struct {
+	name   string
+	detect func(string) []interceptor.DetectedSecret
+}
+
+// Run measures the full pipeline of manager and each of its enabled
+// interceptors on samples for every CPU count in opts
+func Run(ctx context.Context, manager *interceptor.Manager, samples []string, opts Options) (*Report, error) {
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no samples to benchmark")
+	}
+	if opts.Duration <= 0 {
+		return nil, fmt.Errorf("duration must be positive, got %s", opts.Duration)
+	}
+	for _, cpus := range opts.CPUs {
+		if cpus < 1 {
+			return nil, fmt.Errorf("cpu counts must be positive, got %d", cpus)
+		}
+	}
+
+	detectors := []detector{{AllInterceptors, manager.DetectAll}}
+	for _, name := range manager.List() {
+		if i := manager.Get(name); i != nil && i.IsEnabled() {
+			detectors = append(detectors, detector{name, i.Detect})
+		}
+	}
+
+	report := &Report{Samples: len(samples)}
+	for _, sample := range samples {
+		report.SampleBytes += int64(len(sample))
+	}
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(0))
+	for _, cpus := range opts.CPUs {
+		runtime.GOMAXPROCS(cpus)
+		for _, detector := range detectors {
+			if err := ctx.Err(); err != nil {
+				return report, err
+			}
+			result := measure(ctx, detector.detect, samples, cpus, opts.Duration)
+			result.Interceptor = detector.name
+			report.Results = append(report.Results, result)
+		}
+	}
+	return report, nil
+}
+
+// workerStats are the counters of a benchmark worker
+type workerStats struct {
+	calls, bytes, detections int64
+	latencies                []time.Duration
+	max                      time.Duration
+}
+
+// measure runs detect on samples with workers goroutines for duration
+func measure(ctx context.Context, detect func(string) []interceptor.DetectedSecret, samples []string, workers int, duration time.Duration) Result {
+	ctx, cancel := context.WithTimeout(ctx, duration)
+	defer cancel()
+
+	stats := make([]workerStats, workers)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for w := range stats {
+		wg.Go(func() {
+			s := &stats[w]
+			s.latencies = make([]time.Duration, 0, latencySamples)
+			// Workers start at different samples so they do not run in lockstep
+			for i := w; ctx.Err() == nil; i++ {
+				sample := samples[i%len(samples)]
+				begin := time.Now()
+				found := detect(sample)
+				elapsed := time.Since(begin)
+
+				s.calls++
+				s.bytes += int64(len(sample))
+				s.detections += int64(len(found))
+				s.max = max(s.max, elapsed)
+				if len(s.latencies) < latencySamples {
+					s.latencies = append(s.latencies, elapsed)
+				} else if j := rand.Int64N(s.calls); j < latencySamples { //#nosec G404 -- reservoir sampling does not need a secure source
+					s.latencies[j] = elapsed
+				}
+			}
+		})
+	}
+	wg.Wait()
+	seconds := time.Since(start).Seconds()
+
+	result := Result{CPUs: workers, Seconds: seconds}
+	var latencies []time.Duration
+	for _, s := range stats {
+		result.Calls += s.calls
+		result.Bytes += s.bytes
+		result.Detections += s.detections
+		result.Max = max(result.Max, s.max)
+		latencies = append(latencies, s.latencies...)
+	}
+	result.MBPerSecond = float64(result.Bytes) / (1 << 20) / seconds
+	slices.Sort(latencies)
+	result.P50 = percentile(latencies, 0.50)
+	result.P99 = percentile(latencies, 0.99)
+	return result
+}
+
+// percentile returns the p-th percentile of sorted latencies
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[min(len(sorted)-1, int(p*float64(len(sorted))))]
+}
+
+// WriteText writes the report as a table
+func (r *Report) WriteText(w io.Writer) error {
+	fmt.Fprintf(w, "Samples: %d (%.1f KiB)\n\n", r.Samples, float64(r.SampleBytes)/1024)
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintf(tw, "CPUS\tINTERCEPTOR\tMB/S\tCALLS/S\tP50\tP99\tMAX\tDETECTIONS\t\n")
+	for _, result := range r.Results {
+		fmt.Fprintf(tw, "%d\t%s\t%.1f\t%.0f\t%s\t%s\t%s\t%d\t\n",
+			result.CPUs, result.Interceptor, result.MBPerSecond, float64(result.Calls)/result.Seconds,
+			roundLatency(result.P50), roundLatency(result.P99), roundLatency(result.Max), result.Detections)
+	}
+	return tw.Flush()
+}
+
+// roundLatency rounds d for display
+func roundLatency(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	case d >= time.Microsecond:
+		return d.Round(10 * time.Nanosecond)
+	}
+	return d
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
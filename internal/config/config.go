@@ -12,50 +12,498 @@ import (
 
 // Config represents the main configuration structure
 type Config struct {
+	// Mode is "enforce" (mask secrets) or "shadow" (detect and report, but
+	// forward requests unmodified)
+	Mode         string             `yaml:"mode"`
 	Proxy        ProxyConfig        `yaml:"proxy"`
 	TLS          TLSConfig          `yaml:"tls"`
 	Storage      StorageConfig      `yaml:"storage"`
 	Placeholder  PlaceholderConfig  `yaml:"placeholder"`
 	Interceptors InterceptorsConfig `yaml:"interceptors"`
+	Policy       PolicyConfig       `yaml:"policy"`
 	Logging      LoggingConfig      `yaml:"logging"`
 	Metrics      MetricsConfig      `yaml:"metrics"`
+	Alerting     AlertingConfig     `yaml:"alerting"`
 }
 
 // ProxyConfig contains proxy server settings
 type ProxyConfig struct {
 	Listen string `yaml:"listen"`
+	// TLSCert and TLSKey make clients connect to the proxy listener over TLS
+	// (an HTTPS proxy), sending CONNECT requests encrypted
+	TLSCert string `yaml:"tls_cert"`
+	TLSKey  string `yaml:"tls_key"`
+	// Listeners replace Listen, TLSCert, TLSKey and MTLS with several
+	// listeners, each with its own TLS and client certificate requirement
+	Listeners []ListenerConfig `yaml:"listeners"`
+	// FailureMode is "open" (keep serving) or "closed" (reject the request)
+	// when parsing, detection or the mapping store fails
+	FailureMode string `yaml:"failure_mode"`
+	// MaxRequestBody limits the size of buffered request bodies in bytes,
+	// before and after decompression (0 = unlimited)
+	MaxRequestBody int64 `yaml:"max_request_body"`
+	// StreamRequests scans request messages one at a time while forwarding,
+	// instead of buffering whole request bodies
+	StreamRequests bool `yaml:"stream_requests"`
+	// InterceptMode is "all" (intercept every CONNECT tunnel) or "allowlist"
+	// (intercept only InterceptHosts, relay everything else unchanged)
+	InterceptMode  string   `yaml:"intercept_mode"`
+	InterceptHosts []string `yaml:"intercept_hosts"`
+	// BypassHosts lists host globs ("*.bank.example") whose CONNECT tunnels
+	// are relayed without TLS interception
+	BypassHosts []string `yaml:"bypass_hosts"`
+	// RawFallback relays tunnels the proxy cannot intercept as raw bytes
+	// instead of failing the TLS handshake
+	RawFallback RawFallbackConfig `yaml:"raw_fallback"`
+	// PinningDetection spots clients repeatedly aborting handshakes with the
+	// interception certificate, as apps pinning certificates do
+	PinningDetection PinningDetectionConfig `yaml:"pinning_detection"`
+	// HTTP2 offers HTTP/2 to intercepted clients and upstream servers
+	HTTP2     bool            `yaml:"http2"`
+	WebSocket WebSocketConfig `yaml:"websocket"`
+	Retry     RetryConfig     `yaml:"retry"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	// Concurrency caps concurrent work so a burst cannot exhaust file descriptors
+	Concurrency ConcurrencyConfig `yaml:"concurrency"`
+	// Timeouts close CONNECT tunnels abandoned by their clients and bound
+	// requests to hung upstreams
+	Timeouts TimeoutConfig `yaml:"timeouts"`
+	// ACL restricts which client addresses may use the proxy and gateways
+	ACL ACLConfig `yaml:"acl"`
+	// MTLS requires client certificates on the proxy listener
+	MTLS MTLSConfig `yaml:"mtls"`
+	// Streaming trades the restore safety margin of streamed responses
+	// against perceived latency
+	Streaming StreamingConfig `yaml:"streaming"`
+	// Gateways are reverse proxy listeners forwarding to fixed upstreams
+	Gateways []GatewayConfig `yaml:"gateways"`
+	// DrainTimeout bounds how long open tunnels may keep running once the
+	// listeners are closed for a shutdown or an upgrade; they are closed
+	// when it expires
+	DrainTimeout time.Duration `yaml:"drain_timeout"`
+}
+
+// RetryConfig controls retries of idempotent requests after transient
+// upstream failures (connection resets, 502, 503)
+type RetryConfig struct {
+	// MaxAttempts caps the attempts per request, including the first
+	// (1 = no retries)
+	MaxAttempts    int           `yaml:"max_attempts"`
+	InitialBackoff time.Duration `yaml:"initial_backoff"`
+	// MaxBackoff caps the jittered backoff and the Retry-After delays that
+	// are waited for
+	MaxBackoff time.Duration `yaml:"max_backoff"`
+}
+
+// RateLimitConfig limits the requests of each client
+type RateLimitConfig struct {
+	// Key identifies clients: "client_ip", "proxy_user" (CONNECT proxy
+	// authentication; requires storage.namespace.trust_proxy_authorization,
+	// clients without a user are limited by address) or "authorization" (API
+	// key of each request; clients can evade the limit by rotating keys)
+	Key string `yaml:"key"`
+	// RequestsPerMinute refills each client's token bucket (0 = unlimited)
+	RequestsPerMinute int `yaml:"requests_per_minute"`
+	// Burst is the bucket size (0 = requests_per_minute)
+	Burst int `yaml:"burst"`
+	// ConcurrentStreams caps in-flight requests per client (0 = unlimited)
+	ConcurrentStreams int `yaml:"concurrent_streams"`
+}
+
+// ConcurrencyConfig caps concurrent tunnels and upstream requests. Work above
+// a cap waits for a free slot up to QueueTimeout and is then rejected with 503.
+type ConcurrencyConfig struct {
+	// MaxTunnels caps concurrent CONNECT tunnels (0 = unlimited)
+	MaxTunnels int `yaml:"max_tunnels"`
+	// MaxUpstreamRequests caps in-flight upstream requests, including
+	// streamed responses still being read (0 = unlimited)
+	MaxUpstreamRequests int `yaml:"max_upstream_requests"`
+	// MaxTunnelsPerClient caps concurrent CONNECT tunnels of one client
+	// address; further tunnels are refused immediately (0 = unlimited)
+	MaxTunnelsPerClient int           `yaml:"max_tunnels_per_client"`
+	QueueTimeout        time.Duration `yaml:"queue_timeout"`
+}
+
+// TimeoutConfig bounds how long connections inside CONNECT tunnels may stall
+type TimeoutConfig struct {
+	// ReadHeader bounds reading the headers of an intercepted request
+	ReadHeader time.Duration `yaml:"read_header"`
+	// Read bounds reading a whole intercepted request, body included
+	// (0 = unlimited)
+	Read time.Duration `yaml:"read"`
+	// Write bounds writing a response to an intercepted request, including
+	// streamed responses (0 = unlimited)
+	Write time.Duration `yaml:"write"`
+	// Idle closes intercepted connections waiting this long for their next
+	// request
+	Idle time.Duration `yaml:"idle"`
+	// RelayIdle closes relayed tunnels without traffic in either direction
+	// for this long (0 = never)
+	RelayIdle time.Duration `yaml:"relay_idle"`
+	// Request bounds forwarding an intercepted request, from its receipt
+	// until the response was written; exceeding it answers 504 (0 = unlimited)
+	Request time.Duration `yaml:"request"`
+}
+
+// RawFallbackConfig selects the intercepted tunnels relayed unchanged
+type RawFallbackConfig struct {
+	// NonHTTPALPN relays clients offering only ALPN protocols other than
+	// HTTP/1.1 and HTTP/2
+	NonHTTPALPN bool `yaml:"non_http_alpn"`
+	// ClientCert probes upstreams with a TLS handshake and relays those
+	// requesting a client certificate, which the proxy cannot present
+	ClientCert bool `yaml:"client_cert"`
+	// Pinning relays hosts whose clients rejected the interception
+	// certificate, as apps pinning certificates do
+	Pinning bool `yaml:"pinning"`
+	// Remember is how long probe results and rejected hosts are kept
+	Remember time.Duration `yaml:"remember"`
+}
+
+// PinningDetectionConfig controls detecting certificate pinning from
+// repeated handshake aborts of one client for one host
+type PinningDetectionConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Threshold is the number of rejected handshakes within Window marking
+	// pinning
+	Threshold int           `yaml:"threshold"`
+	Window    time.Duration `yaml:"window"`
+	// AutoBypass stops intercepting detected hosts for the client detected
+	// pinning them, as if they were listed in bypass_hosts for that client
+	AutoBypass bool `yaml:"auto_bypass"`
+	// BypassFor is how long detected hosts are bypassed (0 = until restart)
+	BypassFor time.Duration `yaml:"bypass_for"`
+}
+
+// ACLConfig lists client addresses as CIDR ranges or single IPs. Deny
+// entries take precedence; a non-empty allow list admits only its ranges.
+type ACLConfig struct {
+	Allow []string `yaml:"allow"`
+	Deny  []string `yaml:"deny"`
+}
+
+// ListenerConfig describes a proxy listener
+type ListenerConfig struct {
+	// Address is host:port, "unix:" followed by a socket path, or
+	// "systemd:" followed by the name of a socket passed by systemd
+	Address string     `yaml:"address"`
+	TLSCert string     `yaml:"tls_cert"`
+	TLSKey  string     `yaml:"tls_key"`
+	MTLS    MTLSConfig `yaml:"mtls"`
+}
+
+// MTLSConfig requires client certificates on a listener
+type MTLSConfig struct {
+	// ClientCA is a PEM bundle of the CAs issuing accepted client
+	// certificates; empty disables mTLS
+	ClientCA string `yaml:"client_ca"`
+	// Cert and Key are the listener certificate. The proxy listener falls
+	// back to a certificate issued by the interception CA.
+	Cert string `yaml:"cert"`
+	Key  string `yaml:"key"`
+}
+
+// StreamingConfig controls how streamed responses are passed to clients
+type StreamingConfig struct {
+	// FlushInterval coalesces chunks, flushing to the client at most this
+	// often (0 = flush every chunk immediately)
+	FlushInterval time.Duration `yaml:"flush_interval"`
+	// MaxHoldBack caps how long text is held back for a placeholder that
+	// may continue in the next chunk; a placeholder split by a longer pause
+	// is not restored (0 = wait for the next chunk)
+	MaxHoldBack time.Duration `yaml:"max_hold_back"`
+}
+
+// GatewayConfig is a listener that forwards every request to one upstream,
+// so clients only change their base URL instead of trusting the proxy CA
+type GatewayConfig struct {
+	Listen string `yaml:"listen"`
+	// Upstream is the base URL requests are forwarded to, e.g.
+	// "https://api.openai.com"
+	Upstream string `yaml:"upstream"`
+}
+
+// WebSocketConfig controls WebSocket connections in intercepted tunnels
+type WebSocketConfig struct {
+	// InspectHosts lists host globs ("api.example.com", "*.example.com")
+	// whose text messages are scanned for secrets; other connections are spliced
+	// unchanged
+	InspectHosts []string `yaml:"inspect_hosts"`
 }
 
 // TLSConfig contains TLS/CA certificate settings
 type TLSConfig struct {
 	CACert string `yaml:"ca_cert"`
 	CAKey  string `yaml:"ca_key"`
+	// LeafValidity is the lifetime of generated host certificates, which are
+	// regenerated once less than a tenth of it remains
+	LeafValidity time.Duration `yaml:"leaf_validity"`
+	// SharedLeafKey signs every generated host certificate for one key pair
+	// created at startup instead of generating a key per host
+	SharedLeafKey bool `yaml:"shared_leaf_key"`
+	// CertCacheSize limits the number of cached host certificates, evicting
+	// the least recently used (0 = unlimited)
+	CertCacheSize int `yaml:"cert_cache_size"`
+	// CertCacheDir persists host certificates across restarts (empty = keep
+	// them in memory only)
+	CertCacheDir string `yaml:"cert_cache_dir"`
+	// P12Password protects the integrity of the PKCS#12 bundle served at
+	// /ca.p12; the bundle holds no key
+	P12Password string `yaml:"p12_password"`
+	// WildcardDomains get one wildcard certificate for all hosts directly
+	// below them instead of one per host
+	WildcardDomains []string `yaml:"wildcard_domains"`
+	// Mirror copies attributes of upstream certificates into generated ones
+	Mirror MirrorConfig `yaml:"mirror"`
+	// CASigner keeps the CA key in a key management service instead of
+	// ca_key
+	CASigner CASignerConfig `yaml:"ca_signer"`
+	// CARotation controls replacing the CA while the proxy runs
+	CARotation CARotationConfig `yaml:"ca_rotation"`
+}
+
+// MirrorConfig controls copying the names, validity and key usage of the
+// certificate an upstream presents into the certificate generated for it
+type MirrorConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// CacheTTL is how long fetched upstream certificates are reused
+	CacheTTL time.Duration `yaml:"cache_ttl"`
+}
+
+// CASignerConfig selects where the CA key is held. GCP.KeyName names a key
+// version (.../cryptoKeys/*/cryptoKeyVersions/*).
+type CASignerConfig struct {
+	Provider string         `yaml:"provider"` // "file" (ca_key), "vault", "aws-kms" or "gcp-kms"
+	Vault    VaultKeyConfig `yaml:"vault"`
+	AWS      AWSKeyConfig   `yaml:"aws"`
+	GCP      GCPKeyConfig   `yaml:"gcp"`
+}
+
+// CARotationConfig controls loading a new CA certificate and key at runtime
+type CARotationConfig struct {
+	// Grace keeps serving cached certificates of the previous CA after a
+	// rotation (0 = regenerate them right away)
+	Grace time.Duration `yaml:"grace"`
+	// Watch rotates the CA when ca_cert or ca_key change on disk
+	Watch bool `yaml:"watch"`
+	// WatchInterval is how often the files are checked
+	WatchInterval time.Duration `yaml:"watch_interval"`
 }
 
 // StorageConfig contains mapping storage settings
 type StorageConfig struct {
-	Type  string        `yaml:"type"` // "memory" or "redis"
-	Redis RedisConfig   `yaml:"redis"`
-	TTL   time.Duration `yaml:"ttl"`
+	Type       string           `yaml:"type"` // "memory", "redis" or "dynamodb"
+	Redis      RedisConfig      `yaml:"redis"`
+	DynamoDB   DynamoDBConfig   `yaml:"dynamodb"`
+	TTL        time.Duration    `yaml:"ttl"`
+	Timeout    time.Duration    `yaml:"timeout"`     // per operation, 0 = no limit
+	MaxEntries int              `yaml:"max_entries"` // memory store only, 0 = unlimited
+	Cache      CacheConfig      `yaml:"cache"`
+	Encryption EncryptionConfig `yaml:"encryption"`
+	Namespace  NamespaceConfig  `yaml:"namespace"`
+	Snapshot   SnapshotConfig   `yaml:"snapshot"`
+}
+
+// SnapshotConfig controls encrypted on-disk snapshots of the memory store
+type SnapshotConfig struct {
+	Enabled  bool          `yaml:"enabled"`
+	Path     string        `yaml:"path"`
+	Interval time.Duration `yaml:"interval"`
+}
+
+// NamespaceConfig controls how mappings are separated between clients
+type NamespaceConfig struct {
+	// Mode is "none", "client_ip", "proxy_user", "connection" or "header".
+	// "header" trusts clients: any client can name another client's
+	// namespace, so restrict who may connect with proxy.acl.
+	Mode string `yaml:"mode"`
+	// Header is the request header carrying the namespace in "header" mode
+	Header string `yaml:"header"`
+	// TrustProxyAuthorization confirms that a proxy in front authenticates
+	// the Proxy-Authorization user, which the proxy itself does not verify.
+	// "proxy_user" mode and the "proxy_user" rate limit key require it.
+	TrustProxyAuthorization bool `yaml:"trust_proxy_authorization"`
+}
+
+// CacheConfig contains settings for the in-process cache in front of shared stores
+type CacheConfig struct {
+	Enabled    bool          `yaml:"enabled"`
+	MaxEntries int           `yaml:"max_entries"`
+	TTL        time.Duration `yaml:"ttl"`
+}
+
+// EncryptionConfig contains envelope encryption settings for stored secrets
+type EncryptionConfig struct {
+	Enabled          bool          `yaml:"enabled"`
+	Provider         string        `yaml:"provider"` // "local", "vault", "aws-kms" or "gcp-kms"
+	RotationInterval time.Duration `yaml:"rotation_interval"`
+	// RotationCheck is how often the data key age is compared with
+	// RotationInterval (0 = never rotate automatically)
+	RotationCheck time.Duration  `yaml:"rotation_check"`
+	Local         LocalKeyConfig `yaml:"local"`
+	Vault         VaultKeyConfig `yaml:"vault"`
+	AWS           AWSKeyConfig   `yaml:"aws"`
+	GCP           GCPKeyConfig   `yaml:"gcp"`
+}
+
+// LocalKeyConfig contains settings for a locally held key-encryption key
+type LocalKeyConfig struct {
+	// KeyFile holds the base64-encoded 32-byte key; LLM_PROXY_KEK is used if empty
+	KeyFile string `yaml:"key_file"`
+}
+
+// VaultKeyConfig contains settings for a Vault transit key
+type VaultKeyConfig struct {
+	Address string `yaml:"address"`
+	Mount   string `yaml:"mount"`
+	KeyName string `yaml:"key_name"`
+	// Token is read from VAULT_TOKEN if empty
+	Token string `yaml:"token"` //#nosec G117 -- Token field is intentional for Vault auth config
+}
+
+// AWSKeyConfig contains settings for an AWS KMS key
+type AWSKeyConfig struct {
+	Region   string `yaml:"region"`
+	KeyID    string `yaml:"key_id"`
+	Endpoint string `yaml:"endpoint"`
+}
+
+// GCPKeyConfig contains settings for a Google Cloud KMS key
+type GCPKeyConfig struct {
+	KeyName  string `yaml:"key_name"`
+	Endpoint string `yaml:"endpoint"`
 }
 
 // RedisConfig contains Redis connection settings
 type RedisConfig struct {
 	Address  string `yaml:"address"`
+	Username string `yaml:"username"` // Redis 6 ACL user
 	Password string `yaml:"password"` //#nosec G117 -- Password field is intentional for Redis auth config
 	DB       int    `yaml:"db"`
+
+	// IndexKey is the base64-encoded HMAC key for the reverse index; a shared
+	// random key is created in Redis if empty
+	IndexKey string `yaml:"index_key"`
+
+	TLS ClientTLSConfig `yaml:"tls"`
+
+	PoolSize     int           `yaml:"pool_size"`
+	MinIdleConns int           `yaml:"min_idle_conns"`
+	DialTimeout  time.Duration `yaml:"dial_timeout"`
+	ReadTimeout  time.Duration `yaml:"read_timeout"`
+	WriteTimeout time.Duration `yaml:"write_timeout"`
+	PoolTimeout  time.Duration `yaml:"pool_timeout"`
+}
+
+// DynamoDBConfig contains settings for the DynamoDB mapping store.
+// Credentials are read from the AWS_* environment variables.
+type DynamoDBConfig struct {
+	Table    string `yaml:"table"`
+	Region   string `yaml:"region"`
+	Endpoint string `yaml:"endpoint"` // overrides the regional endpoint
+
+	// IndexKey is the base64-encoded HMAC key for the reverse index; a shared
+	// random key is created in the table if empty
+	IndexKey string `yaml:"index_key"`
+}
+
+// ClientTLSConfig contains TLS settings for connections to Redis, Kafka
+// brokers and syslog collectors
+type ClientTLSConfig struct {
+	Enabled            bool   `yaml:"enabled"`
+	CACert             string `yaml:"ca_cert"`
+	ClientCert         string `yaml:"client_cert"`
+	ClientKey          string `yaml:"client_key"`
+	ServerName         string `yaml:"server_name"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
 }
 
 // PlaceholderConfig contains placeholder format settings
 type PlaceholderConfig struct {
 	Prefix string `yaml:"prefix"`
 	Suffix string `yaml:"suffix"`
+	// Format is "standard" (prefix, hex hash, suffix), "compact" (⟦S:hash⟧
+	// with a base62 hash, fewer tokens per placeholder) or "sequential"
+	// ([SECRET_1], [SECRET_2], ... numbered per namespace)
+	Format string `yaml:"format"`
+	// Alphabet overrides the characters encoding the hash
+	Alphabet string `yaml:"alphabet"`
+	// Mode is "deterministic" (derived from the secret's hash), "hmac"
+	// (derived with a keyed hash) or "random" (random per mapping, so
+	// observers cannot confirm a known secret)
+	Mode string `yaml:"mode"`
+	// KeyFile holds the base64-encoded HMAC key of the "hmac" mode;
+	// LLM_PROXY_PLACEHOLDER_KEY is used if empty
+	KeyFile string `yaml:"key_file"`
+	// HashLength is the number of hex characters of the secret's hash;
+	// colliding placeholders are lengthened automatically
+	HashLength int `yaml:"hash_length"`
+	// TypeTags encodes the secret type in placeholders (__SECRET_APIKEY_ab12cd34__)
+	TypeTags bool `yaml:"type_tags"`
+	// FuzzyTolerance is the number of prefix or suffix characters a
+	// placeholder in a response may lose and still be restored
+	FuzzyTolerance int             `yaml:"fuzzy_tolerance"`
+	Redaction      RedactionConfig `yaml:"redaction"`
+	// Legacy lists earlier placeholder settings whose placeholders are
+	// still restored, e.g. while migrating to a new prefix
+	Legacy []LegacyPlaceholderConfig `yaml:"legacy"`
+}
+
+// LegacyPlaceholderConfig describes placeholders issued under earlier settings
+type LegacyPlaceholderConfig struct {
+	Format     string `yaml:"format"`
+	Prefix     string `yaml:"prefix"`
+	Suffix     string `yaml:"suffix"`
+	Alphabet   string `yaml:"alphabet"`
+	HashLength int    `yaml:"hash_length"`
+	TypeTags   bool   `yaml:"type_tags"`
+}
+
+// RedactionConfig selects secret types that are replaced irreversibly
+type RedactionConfig struct {
+	// Types lists the secret types to redact; "*" redacts all types
+	Types []string `yaml:"types"`
+	// Token replaces redacted secrets
+	Token string `yaml:"token"`
+}
+
+// PolicyConfig controls how requests containing secrets are treated beyond
+// masking
+type PolicyConfig struct {
+	Block BlockConfig `yaml:"block"`
+	Warn  WarnConfig  `yaml:"warn"`
+}
+
+// WarnConfig tells clients how many secrets were masked in their request
+type WarnConfig struct {
+	// Header reports the count in the X-Secrets-Masked response header
+	Header bool `yaml:"header"`
+	// Notice appends a notice to the assistant message of non-streamed
+	// responses
+	Notice bool `yaml:"notice"`
+}
+
+// BlockConfig rejects requests containing critical secrets instead of
+// masking them
+type BlockConfig struct {
+	// Types lists the secret types that block a request; "*" blocks all types
+	Types []string `yaml:"types"`
+	// MinConfidence ignores detections below this confidence
+	MinConfidence float64 `yaml:"min_confidence"`
+	// Status is the 4xx status code of blocked requests
+	Status int `yaml:"status"`
 }
 
 // InterceptorsConfig contains settings for all secret interceptors
 type InterceptorsConfig struct {
-	Entropy   EntropyConfig   `yaml:"entropy"`
-	Bitwarden BitwardenConfig `yaml:"bitwarden"`
+	Entropy     EntropyConfig     `yaml:"entropy"`
+	Pattern     PatternConfig     `yaml:"pattern"`
+	Bitwarden   BitwardenConfig   `yaml:"bitwarden"`
+	CodeContext CodeContextConfig `yaml:"code_context"`
+	Allowlist   AllowlistConfig   `yaml:"allowlist"`
+	// DetectionBudget limits the total detection time per request (0 = unlimited).
+	// Once exceeded, remaining interceptors are skipped.
+	DetectionBudget time.Duration `yaml:"detection_budget"`
 }
 
 // EntropyConfig contains entropy-based interceptor settings
@@ -66,6 +514,49 @@ type EntropyConfig struct {
 	MaxLength int     `yaml:"max_length"`
 }
 
+// PatternConfig contains regex pattern interceptor settings
+type PatternConfig struct {
+	Enabled       bool                `yaml:"enabled"`
+	DisabledRules []string            `yaml:"disabled_rules"`
+	Rules         []PatternRuleConfig `yaml:"rules"`
+	// RuleFiles are YAML files holding further rule lists; they are read
+	// again when the configuration is reloaded
+	RuleFiles []string `yaml:"rule_files"`
+	// RuntimeRulesFile keeps the rules added through the admin API, in the
+	// format of rule files; rules cannot be added at runtime without it
+	RuntimeRulesFile string `yaml:"runtime_rules_file"`
+}
+
+// PatternRuleConfig describes a custom pattern rule
+type PatternRuleConfig struct {
+	Name       string  `yaml:"name"`
+	Pattern    string  `yaml:"pattern"`
+	Type       string  `yaml:"type"`
+	Confidence float64 `yaml:"confidence"`
+}
+
+// CodeContextConfig contains settings for the code context preprocessor that
+// suppresses false positives in code blocks, lockfiles, and file paths
+type CodeContextConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// TrustedConfidence is the detection confidence from which code context is ignored
+	TrustedConfidence float64 `yaml:"trusted_confidence"`
+}
+
+// AllowlistConfig lists values that are never masked, such as values
+// reported as false positives
+type AllowlistConfig struct {
+	// Values are allowed literally
+	Values []string `yaml:"values"`
+	// Hashes are hex-encoded SHA-256 hashes of allowed values
+	Hashes []string `yaml:"hashes"`
+	// File keeps the values allowed through false positive reports as
+	// hashes; reported values cannot be allowed without it
+	File string `yaml:"file"`
+	// AutoAddReported allows values reported as false positives
+	AutoAddReported bool `yaml:"auto_add_reported"`
+}
+
 // BitwardenConfig contains Bitwarden interceptor settings
 type BitwardenConfig struct {
 	Enabled   bool   `yaml:"enabled"`
@@ -83,6 +574,161 @@ type AuditConfig struct {
 	Enabled            bool `yaml:"enabled"`
 	LogInterceptorName bool `yaml:"log_interceptor_name"`
 	LogSecretType      bool `yaml:"log_secret_type"`
+	// Level is "minimal" (detections and security events), "standard"
+	// (also requests, responses and errors) or "verbose" (also mappings)
+	Level string `yaml:"level"`
+	// Output is "stdout", "stderr", "syslog", "kafka", "s3", "gcs", "otlp"
+	// or a file path
+	Output string `yaml:"output"`
+	// Format is "json", "text", "cef" (ArcSight) or "leef" (QRadar)
+	Format string `yaml:"format"`
+	// IncludeRequestDetails adds request paths to events
+	IncludeRequestDetails bool `yaml:"include_request_details"`
+	// Syslog configures the collector of the "syslog" output
+	Syslog AuditSyslogConfig `yaml:"syslog"`
+	// Kafka configures the topic of the "kafka" output
+	Kafka AuditKafkaConfig `yaml:"kafka"`
+	// Archive configures the bucket of the "s3" and "gcs" outputs
+	Archive AuditArchiveConfig `yaml:"archive"`
+	// Rotation rotates a file output
+	Rotation AuditRotationConfig `yaml:"rotation"`
+	// OTLP configures the collector of the "otlp" output
+	OTLP AuditOTLPConfig `yaml:"otlp"`
+	// Sampling keeps verbose levels from overwhelming the output
+	Sampling AuditSamplingConfig `yaml:"sampling"`
+}
+
+// AuditSamplingConfig drops a share of audit events by type and caps the
+// event rate; dropped events are counted in
+// llm_proxy_audit_events_dropped_total
+type AuditSamplingConfig struct {
+	// Ratios logs this fraction of the events of a type, e.g.
+	// {request_processed: 0.1}; unlisted types are logged in full
+	Ratios map[string]float64 `yaml:"ratios"`
+	// RateLimit caps the events per second across all types; 0 is unlimited
+	RateLimit float64 `yaml:"rate_limit"`
+	// Burst defaults to RateLimit
+	Burst int `yaml:"burst"`
+}
+
+// AuditOTLPConfig exports audit events as OpenTelemetry log records over
+// OTLP/HTTP. Events of requests carrying a W3C traceparent header are
+// correlated with the client's span.
+type AuditOTLPConfig struct {
+	// Endpoint is the collector's base URL, e.g. "http://otel-collector:4318"
+	Endpoint string `yaml:"endpoint"`
+	// Headers are sent with every export, e.g. for authentication
+	Headers     map[string]string `yaml:"headers"`
+	ServiceName string            `yaml:"service_name"`
+	// BatchSize exports early once this many events are queued
+	BatchSize int `yaml:"batch_size"`
+	// Interval is how often queued events are exported
+	Interval time.Duration `yaml:"interval"`
+	Timeout  time.Duration `yaml:"timeout"`
+}
+
+// AuditRotationConfig rotates the audit log file by size or age. Rotated
+// files are renamed to <name>-<time><ext>, optionally gzipped, and pruned.
+type AuditRotationConfig struct {
+	// MaxSizeMB rotates the file before it grows beyond this size; 0
+	// disables size-based rotation
+	MaxSizeMB int `yaml:"max_size_mb"`
+	// MaxAge rotates the file after it has been written to for this long; 0
+	// disables age-based rotation
+	MaxAge time.Duration `yaml:"max_age"`
+	// Compress gzips rotated files
+	Compress bool `yaml:"compress"`
+	// MaxBackups is how many rotated files are kept; 0 keeps all
+	MaxBackups int `yaml:"max_backups"`
+	// Retention removes rotated files older than this; 0 keeps them
+	Retention time.Duration `yaml:"retention"`
+}
+
+// AuditArchiveConfig exports audit events to an S3 or GCS bucket as
+// gzip-compressed NDJSON objects, one per time window. S3 credentials are
+// read from the AWS_* environment variables, GCS tokens from
+// GOOGLE_OAUTH_ACCESS_TOKEN or the metadata server.
+type AuditArchiveConfig struct {
+	Bucket string `yaml:"bucket"`
+	// Region is the region of an S3 bucket
+	Region string `yaml:"region"`
+	// Endpoint overrides the S3 or GCS endpoint, e.g. for S3-compatible stores
+	Endpoint string `yaml:"endpoint"`
+	// Prefix is prepended to the object keys
+	Prefix string `yaml:"prefix"`
+	// Window is how long events are collected in one object
+	Window time.Duration `yaml:"window"`
+	// MaxBytes starts a new object early once its uncompressed events
+	// exceed it
+	MaxBytes int `yaml:"max_bytes"`
+}
+
+// AuditKafkaConfig produces audit events to a Kafka topic, keyed by host so
+// the events of a host share a partition
+type AuditKafkaConfig struct {
+	Brokers []string `yaml:"brokers"`
+	Topic   string   `yaml:"topic"`
+	// SASLMechanism is "plain", "scram-sha-256" or "scram-sha-512"; empty
+	// disables SASL
+	SASLMechanism string          `yaml:"sasl_mechanism"`
+	Username      string          `yaml:"username"`
+	Password      string          `yaml:"password"` //#nosec G117 -- Password field is intentional for Kafka SASL config
+	TLS           ClientTLSConfig `yaml:"tls"`
+	// BatchTimeout is how long events are collected before a batch is sent
+	BatchTimeout time.Duration `yaml:"batch_timeout"`
+}
+
+// AuditSyslogConfig sends audit events to a syslog collector as RFC 5424
+// messages
+type AuditSyslogConfig struct {
+	// Network is "udp", "tcp" or "tls"
+	Network string `yaml:"network"`
+	// Address is the host:port of the collector
+	Address string `yaml:"address"`
+	// Facility is a syslog facility name such as "auth" or "local0"
+	Facility string `yaml:"facility"`
+	// AppName is sent as APP-NAME
+	AppName string `yaml:"app_name"`
+	// TLS configures the "tls" network, which implies enabled
+	TLS ClientTLSConfig `yaml:"tls"`
+}
+
+// AlertingConfig notifies chat channels and PagerDuty of detections that
+// need immediate attention
+type AlertingConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Channels are the notified channels by name
+	Channels map[string]AlertChannelConfig `yaml:"channels"`
+	Rules    []AlertRuleConfig             `yaml:"rules"`
+}
+
+// AlertChannelConfig is a Slack or Teams webhook or a PagerDuty service
+type AlertChannelConfig struct {
+	// Type is "slack", "teams" or "pagerduty"
+	Type string `yaml:"type"`
+	// URL is the incoming webhook; for PagerDuty it overrides the Events API
+	// endpoint
+	URL string `yaml:"url"`
+	// RoutingKey is the integration key of a PagerDuty service
+	RoutingKey string `yaml:"routing_key"`
+}
+
+// AlertRuleConfig fires an alert on matching detections
+type AlertRuleConfig struct {
+	Name string `yaml:"name"`
+	// SecretTypes and Rules restrict the alert to these secret types and
+	// detection rules, e.g. private_key or stripe_live_key
+	SecretTypes []string `yaml:"secret_types"`
+	Rules       []string `yaml:"rules"`
+	// Threshold fires once a client sent this many matching detections within
+	// Window; 0 fires on every matching detection
+	Threshold int           `yaml:"threshold"`
+	Window    time.Duration `yaml:"window"`
+	// Cooldown suppresses repeated alerts of the rule for the same client
+	Cooldown time.Duration `yaml:"cooldown"`
+	// Severity is "critical", "error", "warning" or "info"
+	Severity string   `yaml:"severity"`
+	Channels []string `yaml:"channels"`
 }
 
 // MetricsConfig contains Prometheus metrics settings
@@ -90,29 +736,174 @@ type MetricsConfig struct {
 	Enabled  bool   `yaml:"enabled"`
 	Endpoint string `yaml:"endpoint"`
 	Port     int    `yaml:"port"`
+	// Listen overrides Port with a listener address such as
+	// "127.0.0.1:9090" or "systemd:metrics"
+	Listen string `yaml:"listen"`
+	// MTLS requires client certificates on the management server
+	MTLS  MTLSConfig  `yaml:"mtls"`
+	PAC   PACConfig   `yaml:"pac"`
+	Admin AdminConfig `yaml:"admin"`
+	Stats StatsConfig `yaml:"stats"`
+}
+
+// StatsConfig controls the detection statistics served at /admin/stats
+type StatsConfig struct {
+	// Window is the sliding window of the recent detection counts
+	Window time.Duration `yaml:"window"`
+	// Recent is the number of latest detections listed
+	Recent int `yaml:"recent"`
+}
+
+// AdminConfig controls the authenticated admin API of the management server.
+// Without any token or client the API is off.
+type AdminConfig struct {
+	// Token is a bearer token with the operator role; it is read from
+	// LLM_PROXY_ADMIN_TOKEN if empty
+	Token string `yaml:"token"` //#nosec G117 -- Token field is intentional for admin API auth config
+	// Tokens are further named bearer tokens with a role
+	Tokens []AdminTokenConfig `yaml:"tokens"`
+	// Clients grant roles to mTLS client certificates of the management
+	// server by subject common name
+	Clients []AdminClientConfig `yaml:"clients"`
+	// GRPC serves the admin API over gRPC as well
+	GRPC GRPCAdminConfig `yaml:"grpc"`
+}
+
+// AdminTokenConfig is a named bearer token of the admin API
+type AdminTokenConfig struct {
+	// Name identifies the caller in audit events
+	Name  string `yaml:"name"`
+	Token string `yaml:"token"` //#nosec G117 -- Token field is intentional for admin API auth config
+	// Role is "viewer", which may only read, or "operator"
+	Role string `yaml:"role"`
+}
+
+// AdminClientConfig grants a role to mTLS client certificates
+type AdminClientConfig struct {
+	CommonName string `yaml:"common_name"`
+	// Role is "viewer", which may only read, or "operator"
+	Role string `yaml:"role"`
+}
+
+// GRPCAdminConfig controls the gRPC admin API
+type GRPCAdminConfig struct {
+	// Listen is the address of the gRPC listener, such as ":9091" or
+	// "systemd:admin"; empty disables it. It uses the TLS settings of the
+	// management server.
+	Listen string `yaml:"listen"`
+}
+
+// PACConfig controls the proxy auto-config file served at /proxy.pac
+type PACConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// Proxy is the host:port clients reach the proxy at; empty uses the host
+	// the PAC file was fetched from with the port of the proxy listener
+	Proxy string `yaml:"proxy"`
 }
 
 // DefaultConfig returns a configuration with sensible defaults
 func DefaultConfig() *Config {
 	return &Config{
+		Mode: "enforce",
 		Proxy: ProxyConfig{
-			Listen: ":8080",
+			Listen:         ":8080",
+			FailureMode:    "open",
+			MaxRequestBody: 32 << 20,
+			HTTP2:          true,
+			InterceptMode:  "all",
+			RawFallback: RawFallbackConfig{
+				NonHTTPALPN: true,
+				Remember:    time.Hour,
+			},
+			PinningDetection: PinningDetectionConfig{
+				Enabled:   true,
+				Threshold: 3,
+				Window:    10 * time.Minute,
+			},
+			InterceptHosts: []string{
+				"api.openai.com",
+				"*.openai.azure.com",
+				"api.anthropic.com",
+				"generativelanguage.googleapis.com",
+				"api.mistral.ai",
+				"api.cohere.com",
+				"api.groq.com",
+				"openrouter.ai",
+			},
+			Retry: RetryConfig{
+				MaxAttempts:    3,
+				InitialBackoff: 200 * time.Millisecond,
+				MaxBackoff:     5 * time.Second,
+			},
+			RateLimit: RateLimitConfig{
+				Key: "client_ip",
+			},
+			Concurrency: ConcurrencyConfig{
+				QueueTimeout: 5 * time.Second,
+			},
+			Timeouts: TimeoutConfig{
+				ReadHeader: 10 * time.Second,
+				Read:       time.Minute,
+				Idle:       2 * time.Minute,
+				RelayIdle:  15 * time.Minute,
+				Request:    10 * time.Minute,
+			},
+			DrainTimeout: 5 * time.Minute,
 		},
 		TLS: TLSConfig{
-			CACert: "./certs/ca.crt",
-			CAKey:  "./certs/ca.key",
+			CACert:        "./certs/ca.crt",
+			CAKey:         "./certs/ca.key",
+			LeafValidity:  48 * time.Hour,
+			CertCacheSize: 1000,
+			Mirror: MirrorConfig{
+				CacheTTL: time.Hour,
+			},
+			CARotation: CARotationConfig{
+				Grace:         24 * time.Hour,
+				WatchInterval: 30 * time.Second,
+			},
 		},
 		Storage: StorageConfig{
-			Type: "memory",
-			TTL:  24 * time.Hour,
+			Type:    "memory",
+			TTL:     24 * time.Hour,
+			Timeout: 2 * time.Second,
 			Redis: RedisConfig{
 				Address: "localhost:6379",
 				DB:      0,
 			},
+			Cache: CacheConfig{
+				Enabled:    false,
+				MaxEntries: 10000,
+				TTL:        time.Minute,
+			},
+			Namespace: NamespaceConfig{
+				Mode:   "none",
+				Header: "X-LLM-Proxy-Namespace",
+			},
+			Snapshot: SnapshotConfig{
+				Enabled:  false,
+				Path:     "mappings.snapshot",
+				Interval: time.Minute,
+			},
+			Encryption: EncryptionConfig{
+				Enabled:          false,
+				Provider:         "local",
+				RotationInterval: 30 * 24 * time.Hour,
+				RotationCheck:    time.Hour,
+				Vault: VaultKeyConfig{
+					Mount: "transit",
+				},
+			},
 		},
 		Placeholder: PlaceholderConfig{
-			Prefix: "__SECRET_",
-			Suffix: "__",
+			Prefix:     "__SECRET_",
+			Suffix:     "__",
+			Format:     "standard",
+			Mode:       "deterministic",
+			HashLength: 8,
+			Redaction: RedactionConfig{
+				Token: "[REDACTED]",
+			},
 		},
 		Interceptors: InterceptorsConfig{
 			Entropy: EntropyConfig{
@@ -121,9 +912,21 @@ func DefaultConfig() *Config {
 				MinLength: 8,
 				MaxLength: 128,
 			},
+			Pattern: PatternConfig{
+				Enabled: false,
+			},
 			Bitwarden: BitwardenConfig{
 				Enabled: false,
 			},
+			CodeContext: CodeContextConfig{
+				Enabled:           false,
+				TrustedConfidence: 1.0,
+			},
+		},
+		Policy: PolicyConfig{
+			Block: BlockConfig{
+				Status: 400,
+			},
 		},
 		Logging: LoggingConfig{
 			Level: "info",
@@ -131,12 +934,44 @@ func DefaultConfig() *Config {
 				Enabled:            true,
 				LogInterceptorName: true,
 				LogSecretType:      true,
+				Level:              "standard",
+				Output:             "stdout",
+				Format:             "json",
+				Syslog: AuditSyslogConfig{
+					Network:  "udp",
+					Facility: "auth",
+					AppName:  "llm-secret-interceptor",
+				},
+				Kafka: AuditKafkaConfig{
+					Topic:        "llm-secret-interceptor-audit",
+					BatchTimeout: 100 * time.Millisecond,
+				},
+				Archive: AuditArchiveConfig{
+					Prefix:   "audit/",
+					Window:   5 * time.Minute,
+					MaxBytes: 64 << 20,
+				},
+				Rotation: AuditRotationConfig{
+					MaxSizeMB:  100,
+					Compress:   true,
+					MaxBackups: 10,
+				},
+				OTLP: AuditOTLPConfig{
+					ServiceName: "llm-secret-interceptor",
+					BatchSize:   512,
+					Interval:    time.Second,
+					Timeout:     10 * time.Second,
+				},
 			},
 		},
 		Metrics: MetricsConfig{
 			Enabled:  true,
 			Endpoint: "/metrics",
 			Port:     9090,
+			Stats: StatsConfig{
+				Window: time.Hour,
+				Recent: 50,
+			},
 		},
 	}
 }
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"fmt"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redactedValue replaces credentials in the redacted configuration
+const redactedValue = "[REDACTED]"
+
+// credentialKeys are the configuration keys holding credentials
+var credentialKeys = map[string]bool{
+	"password":     true,
+	"p12_password": true,
+	"token":        true,
+	"index_key":    true,
+}
+
+// publicPaths are keys named like credentials that hold none
+var publicPaths = map[string]bool{
+	"placeholder.redaction.token": true,
+}
+
+// Redacted returns the configuration as a tree of YAML keys with set
+// credentials replaced, e.g. for display by the admin API
+func (c *Config) Redacted() (map[string]any, error) {
+	data, err := yaml.Marshal(c)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	var tree map[string]any
+	if err := yaml.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("failed to decode config: %w", err)
+	}
+	redactTree(tree, "")
+	return tree, nil
+}
+
+// redactTree replaces the credentials below the node at path
+func redactTree(node any, path string) {
+	switch node := node.(type) {
+	case map[string]any:
+		for key, value := range node {
+			keyPath := key
+			if path != "" {
+				keyPath = path + "." + key
+			}
+			if s, ok := value.(string); ok && s != "" && credentialKeys[key] && !publicPaths[keyPath] {
+				node[key] = redactedValue
+				continue
+			}
+			redactTree(value, keyPath)
+		}
+	case []any:
+		for _, value := range node {
+			redactTree(value, path)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestConfig_Redacted(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Storage.Redis.Password = "redis-pass"
+	cfg.Storage.Redis.IndexKey = "aW5kZXgta2V5"
+	cfg.TLS.P12Password = "p12-pass"
+	cfg.Metrics.Admin.Token = "admin-token"
+
+	tree, err := cfg.Redacted()
+	if err != nil {
+		t.Fatalf("Redacted() error: %v", err)
+	}
+	data, err := yaml.Marshal(tree)
+	if err != nil {
+		t.Fatalf("yaml.Marshal() error: %v", err)
+	}
+	for _, secret := range []string{"redis-pass", "aW5kZXgta2V5", "p12-pass", "admin-token"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("redacted config contains %q", secret)
+		}
+	}
+
+	storage := tree["storage"].(map[string]any)
+	if redis := storage["redis"].(map[string]any); redis["password"] != redactedValue || redis["address"] != cfg.Storage.Redis.Address {
+		t.Errorf("storage.redis = %v", redis)
+	}
+	// Unset credentials stay empty, so operators can tell them apart
+	vault := tree["tls"].(map[string]any)["ca_signer"].(map[string]any)["vault"].(map[string]any)
+	if vault["token"] != "" {
+		t.Errorf("unset vault token = %v, want empty", vault["token"])
+	}
+	redaction := tree["placeholder"].(map[string]any)["redaction"].(map[string]any)
+	if redaction["token"] != cfg.Placeholder.Redaction.Token {
+		t.Errorf("placeholder.redaction.token = %v, want it unredacted", redaction["token"])
+	}
+	if cfg.Storage.Redis.Password != "redis-pass" {
+		t.Error("Redacted() modified the configuration")
+	}
+}
@@ -0,0 +1,57 @@
+package interceptor
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+)
+
+// AllowlistFilter drops detections of values known not to be secrets, such
+// as values reported as false positives. Values are matched by their
+// SHA-256 hash, so allowlists need not hold them.
+type AllowlistFilter struct {
+	hashes map[string]bool
+}
+
+// NewAllowlistFilter creates an allowlist filter of the given values and
+// hex-encoded SHA-256 hashes of values
+func NewAllowlistFilter(values, hashes []string) *AllowlistFilter {
+	f := &AllowlistFilter{hashes: make(map[string]bool, len(values)+len(hashes))}
+	for _, value := range values {
+		f.hashes[AllowlistHash(value)] = true
+	}
+	for _, hash := range hashes {
+		f.hashes[strings.ToLower(hash)] = true
+	}
+	return f
+}
+
+// AllowlistHash returns the hex-encoded SHA-256 hash a value is allowed by
+func AllowlistHash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+// Name returns the filter name
+func (f *AllowlistFilter) Name() string {
+	return "allowlist"
+}
+
+// DropReason explains why detections are dropped
+func (f *AllowlistFilter) DropReason() string {
+	return "value is allowlisted"
+}
+
+// Filter drops detections of allowlisted values
+func (f *AllowlistFilter) Filter(_ string, secrets []DetectedSecret) []DetectedSecret {
+	if len(f.hashes) == 0 {
+		return secrets
+	}
+	kept := secrets[:0]
+	for _, s := range secrets {
+		if !f.hashes[AllowlistHash(s.Value)] {
+			kept = append(kept, s)
+		}
+	}
+	return kept
+}
@@ -2,6 +2,7 @@
 package interceptor
 
 import (
+	"fmt"
 	"math"
 	"regexp"
 	"strings"
@@ -45,44 +46,76 @@ func (e *EntropyInterceptor) Configure(config map[string]interface{}) error {
 	return nil
 }
 
+// candidatePattern finds strings that look like tokens, API keys, passwords, etc.
+var candidatePattern = regexp.MustCompile(`[A-Za-z0-9+/=_\-]{8,}`)
+
 // Detect analyzes text for high-entropy strings
 func (e *EntropyInterceptor) Detect(text string) []DetectedSecret {
 	var secrets []DetectedSecret
 
-	// Find potential secret-like strings (alphanumeric with some special chars)
-	// This regex finds strings that look like tokens, API keys, passwords, etc.
-	pattern := regexp.MustCompile(`[A-Za-z0-9+/=_\-]{8,}`)
-	matches := pattern.FindAllStringIndex(text, -1)
-
-	for _, match := range matches {
+	for _, match := range candidatePattern.FindAllStringIndex(text, -1) {
 		start, end := match[0], match[1]
 		candidate := text[start:end]
 
-		// Skip if too short or too long
-		if len(candidate) < e.minLength || len(candidate) > e.maxLength {
+		entropy, reason := e.assess(candidate)
+		if reason != "" {
 			continue
 		}
+		secrets = append(secrets, DetectedSecret{
+			Value:      candidate,
+			StartIndex: start,
+			EndIndex:   end,
+			Type:       "high_entropy",
+			Confidence: e.entropyToConfidence(entropy),
+		})
+	}
 
-		// Skip if it looks like a common word or path
-		if e.isLikelyNotSecret(candidate) {
-			continue
-		}
+	return secrets
+}
 
-		// Calculate Shannon entropy
-		entropy := e.calculateEntropy(candidate)
-
-		if entropy >= e.threshold {
-			secrets = append(secrets, DetectedSecret{
-				Value:      candidate,
-				StartIndex: start,
-				EndIndex:   end,
-				Type:       "high_entropy",
-				Confidence: e.entropyToConfidence(entropy),
-			})
+// Explain evaluates every candidate string, giving the reason it was
+// skipped or its entropy
+func (e *EntropyInterceptor) Explain(text string) []Evaluation {
+	var evaluations []Evaluation
+	for _, match := range candidatePattern.FindAllStringIndex(text, -1) {
+		start, end := match[0], match[1]
+		evaluation := Evaluation{
+			Interceptor: e.Name(),
+			Candidate:   text[start:end],
+			StartIndex:  start,
+			EndIndex:    end,
+			Outcome:     OutcomeMatched,
+		}
+		entropy, reason := e.assess(evaluation.Candidate)
+		if reason != "" {
+			evaluation.Outcome, evaluation.Reason = OutcomeSkipped, reason
+		} else {
+			evaluation.Reason = fmt.Sprintf("entropy %.2f reaches threshold %.2f", entropy, e.threshold)
 		}
+		evaluations = append(evaluations, evaluation)
 	}
+	return evaluations
+}
 
-	return secrets
+// assess returns the entropy of a candidate string and the reason it is not
+// reported as a secret, or "" if it is
+func (e *EntropyInterceptor) assess(candidate string) (float64, string) {
+	// Skip if too short or too long
+	if len(candidate) < e.minLength || len(candidate) > e.maxLength {
+		return 0, fmt.Sprintf("length %d outside %d-%d", len(candidate), e.minLength, e.maxLength)
+	}
+
+	// Skip if it looks like a common word or path
+	if reason := e.notSecretReason(candidate); reason != "" {
+		return 0, reason
+	}
+
+	// Calculate Shannon entropy
+	entropy := e.calculateEntropy(candidate)
+	if entropy < e.threshold {
+		return entropy, fmt.Sprintf("entropy %.2f below threshold %.2f", entropy, e.threshold)
+	}
+	return entropy, ""
 }
 
 // calculateEntropy calculates Shannon entropy of a string
@@ -136,16 +169,26 @@ var commonKeywords = map[string]bool{
 // uuidPattern matches UUID strings
 var uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
 
-// isLikelyNotSecret checks if a string is likely not a secret
-func (e *EntropyInterceptor) isLikelyNotSecret(s string) bool {
+// notSecretReason returns why a string is likely not a secret, or "" if it
+// may be one
+func (e *EntropyInterceptor) notSecretReason(s string) string {
 	lower := strings.ToLower(s)
 
-	return e.isLowercaseWord(s) ||
-		e.isCommonKeyword(lower) ||
-		e.isPathOrURL(s, lower) ||
-		e.isFileExtension(s, lower) ||
-		e.isShortBase64(s) ||
-		e.isUUID(lower)
+	switch {
+	case e.isLowercaseWord(s):
+		return "lowercase word"
+	case e.isCommonKeyword(lower):
+		return "common keyword"
+	case e.isPathOrURL(s, lower):
+		return "path or URL"
+	case e.isFileExtension(s, lower):
+		return "file name"
+	case e.isShortBase64(s):
+		return "short base64 value"
+	case e.isUUID(lower):
+		return "UUID"
+	}
+	return ""
 }
 
 // isLowercaseWord checks if string is all lowercase without digits (likely a word)
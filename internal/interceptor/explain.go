@@ -0,0 +1,143 @@
+package interceptor
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+)
+
+// Outcomes of an evaluation
+const (
+	// OutcomeMatched is a candidate reported as a secret
+	OutcomeMatched = "matched"
+	// OutcomeSkipped is a candidate rejected for the given reason
+	OutcomeSkipped = "skipped"
+	// OutcomeNoMatch is a rule that matched nothing
+	OutcomeNoMatch = "no_match"
+)
+
+// Evaluation is the verdict of an interceptor, or one of its rules, on a
+// candidate string
+type Evaluation struct {
+	Interceptor string `json:"interceptor"`
+	Rule        string `json:"rule,omitempty"`
+	// Candidate is the evaluated string; empty for rules without a match
+	Candidate  string `json:"candidate,omitempty"`
+	StartIndex int    `json:"start"`
+	EndIndex   int    `json:"end"`
+	Outcome    string `json:"outcome"`
+	Reason     string `json:"reason,omitempty"`
+}
+
+// Explainer is implemented by interceptors that can report how they
+// evaluated a text, including the candidates they rejected
+type Explainer interface {
+	Explain(text string) []Evaluation
+}
+
+// DroppedSecret is a detection removed after the interceptors ran
+type DroppedSecret struct {
+	Secret DetectedSecret
+	// Stage is the name of the filter, or "deduplicate"
+	Stage  string
+	Reason string
+}
+
+// Explanation tells how the manager arrived at the secrets of a text
+type Explanation struct {
+	Evaluations []Evaluation
+	Dropped     []DroppedSecret
+	// Secrets are the detections DetectAll returns
+	Secrets []DetectedSecret
+}
+
+// Explain runs the pipeline of DetectAll on text, recording every
+// evaluation of the interceptors and why detections were dropped by the
+// filters or merged during deduplication
+func (m *Manager) Explain(text string) *Explanation {
+	explanation := &Explanation{}
+	var detected []DetectedSecret
+	for _, interceptor := range m.interceptors {
+		if !interceptor.IsEnabled() {
+			explanation.Evaluations = append(explanation.Evaluations, Evaluation{
+				Interceptor: interceptor.Name(),
+				Outcome:     OutcomeSkipped,
+				Reason:      "interceptor disabled",
+			})
+			continue
+		}
+
+		secrets := interceptor.Detect(text)
+		for i := range secrets {
+			secrets[i].Source = interceptor.Name()
+		}
+		detected = append(detected, secrets...)
+
+		if explainer, ok := interceptor.(Explainer); ok {
+			explanation.Evaluations = append(explanation.Evaluations, explainer.Explain(text)...)
+			continue
+		}
+		// Interceptors that cannot explain themselves only report matches
+		for _, secret := range secrets {
+			explanation.Evaluations = append(explanation.Evaluations, Evaluation{
+				Interceptor: secret.Source,
+				Rule:        secret.Rule,
+				Candidate:   secret.Value,
+				StartIndex:  secret.StartIndex,
+				EndIndex:    secret.EndIndex,
+				Outcome:     OutcomeMatched,
+			})
+		}
+	}
+
+	for _, f := range m.filters {
+		if len(detected) == 0 {
+			break
+		}
+		// Filters may reuse the slice they are given
+		kept := f.Filter(text, slices.Clone(detected))
+		reason := "false positive in its context"
+		if r, ok := f.(interface{ DropReason() string }); ok {
+			reason = r.DropReason()
+		}
+		for _, secret := range detected {
+			if !slices.Contains(kept, secret) {
+				explanation.Dropped = append(explanation.Dropped, DroppedSecret{
+					Secret: secret,
+					Stage:  f.Name(),
+					Reason: reason,
+				})
+			}
+		}
+		detected = kept
+	}
+
+	secrets := m.deduplicateSecrets(text, slices.Clone(detected))
+	sort.Slice(secrets, func(i, j int) bool {
+		return secrets[i].StartIndex < secrets[j].StartIndex
+	})
+	for _, secret := range detected {
+		if slices.Contains(secrets, secret) {
+			continue
+		}
+		dropped := DroppedSecret{Secret: secret, Stage: "deduplicate", Reason: "duplicate of another detection"}
+		for _, kept := range secrets {
+			if secret.StartIndex < kept.EndIndex && kept.StartIndex < secret.EndIndex {
+				dropped.Reason = fmt.Sprintf("merged into the %s detection at %d-%d", ruleName(kept), kept.StartIndex, kept.EndIndex)
+				break
+			}
+		}
+		explanation.Dropped = append(explanation.Dropped, dropped)
+	}
+	explanation.Secrets = secrets
+	return explanation
+}
+
+// ruleName returns the rule of a detection, or its interceptor for those
+// without rules
+func ruleName(secret DetectedSecret) string {
+	if secret.Rule != "" {
+		return secret.Source + "/" + secret.Rule
+	}
+	return secret.Source
+}
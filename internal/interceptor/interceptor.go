@@ -1,7 +1,11 @@
 package interceptor
 
 import (
+	"context"
 	"sort"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/metrics"
 )
 
 // DetectedSecret represents a secret found by an interceptor
@@ -18,6 +22,8 @@ type DetectedSecret struct {
 	Confidence float64
 	// Source is the name of the interceptor that found this secret
 	Source string
+	// Rule is the name of the rule within the interceptor that matched (optional)
+	Rule string
 }
 
 // SecretInterceptor defines the interface for secret detection plugins
@@ -53,9 +59,19 @@ func (b *BaseInterceptor) SetEnabled(enabled bool) {
 	b.enabled = enabled
 }
 
+// DetectionFilter removes detections that are known false positives in their context
+type DetectionFilter interface {
+	// Name returns the filter name for logging
+	Name() string
+
+	// Filter returns the detections that should be kept
+	Filter(text string, secrets []DetectedSecret) []DetectedSecret
+}
+
 // Manager manages multiple secret interceptors
 type Manager struct {
 	interceptors []SecretInterceptor
+	filters      []DetectionFilter
 }
 
 // NewManager creates a new interceptor manager
@@ -70,6 +86,11 @@ func (m *Manager) Register(i SecretInterceptor) {
 	m.interceptors = append(m.interceptors, i)
 }
 
+// AddFilter adds a detection filter that runs before deduplication
+func (m *Manager) AddFilter(f DetectionFilter) {
+	m.filters = append(m.filters, f)
+}
+
 // Get returns an interceptor by name
 func (m *Manager) Get(name string) SecretInterceptor {
 	for _, i := range m.interceptors {
@@ -91,6 +112,13 @@ func (m *Manager) List() []string {
 
 // DetectAll runs all registered interceptors and aggregates results
 func (m *Manager) DetectAll(text string) []DetectedSecret {
+	return m.DetectAllContext(context.Background(), text)
+}
+
+// DetectAllContext runs all registered interceptors and aggregates results.
+// Once the context is done (e.g. a per-request detection budget expired),
+// the remaining interceptors are skipped and counted in the metrics.
+func (m *Manager) DetectAllContext(ctx context.Context, text string) []DetectedSecret {
 	var allSecrets []DetectedSecret
 
 	for _, interceptor := range m.interceptors {
@@ -99,15 +127,31 @@ func (m *Manager) DetectAll(text string) []DetectedSecret {
 			continue
 		}
 
+		// Short-circuit once the detection budget is exhausted
+		if ctx.Err() != nil {
+			metrics.RecordDetectionSkipped(interceptor.Name())
+			continue
+		}
+
+		start := time.Now()
 		secrets := interceptor.Detect(text)
+		metrics.RecordInterceptorDuration(interceptor.Name(), time.Since(start).Seconds())
 		for i := range secrets {
 			secrets[i].Source = interceptor.Name()
 		}
 		allSecrets = append(allSecrets, secrets...)
 	}
 
-	// Deduplicate overlapping secrets
-	allSecrets = m.deduplicateSecrets(allSecrets)
+	// Drop detections that are false positives in their context
+	for _, f := range m.filters {
+		if len(allSecrets) == 0 {
+			break
+		}
+		allSecrets = f.Filter(text, allSecrets)
+	}
+
+	// Deduplicate and merge overlapping secrets
+	allSecrets = m.deduplicateSecrets(text, allSecrets)
 
 	// Sort by position
 	sort.Slice(allSecrets, func(i, j int) bool {
@@ -117,9 +161,11 @@ func (m *Manager) DetectAll(text string) []DetectedSecret {
 	return allSecrets
 }
 
-// deduplicateSecrets removes duplicate and overlapping secrets
-// When secrets overlap, keep the one with higher confidence
-func (m *Manager) deduplicateSecrets(secrets []DetectedSecret) []DetectedSecret {
+// deduplicateSecrets removes duplicate secrets and merges overlapping ones.
+// Overlapping detections are merged into a single secret covering the union
+// of their spans, so no part of a partially matched secret is left exposed.
+// The merged secret keeps the metadata of the detection with the highest confidence.
+func (m *Manager) deduplicateSecrets(text string, secrets []DetectedSecret) []DetectedSecret {
 	if len(secrets) <= 1 {
 		return secrets
 	}
@@ -145,28 +191,41 @@ func (m *Manager) deduplicateSecrets(secrets []DetectedSecret) []DetectedSecret
 
 	// Sort by start position
 	sort.Slice(result, func(i, j int) bool {
-		return result[i].StartIndex < result[j].StartIndex
+		if result[i].StartIndex != result[j].StartIndex {
+			return result[i].StartIndex < result[j].StartIndex
+		}
+		return result[i].EndIndex > result[j].EndIndex
 	})
 
-	// Remove overlapping secrets (keep higher confidence)
 	if len(result) <= 1 {
 		return result
 	}
 
+	// Merge overlapping intervals
 	final := []DetectedSecret{result[0]}
 	for i := 1; i < len(result); i++ {
 		current := result[i]
 		last := &final[len(final)-1]
 
-		// Check for overlap
-		if current.StartIndex < last.EndIndex {
-			// Overlapping - keep the one with higher confidence
-			if current.Confidence > last.Confidence {
-				final[len(final)-1] = current
-			}
-		} else {
+		if current.StartIndex >= last.EndIndex {
 			final = append(final, current)
+			continue
+		}
+
+		// Overlapping - expand to the union and keep the more confident metadata
+		merged := *last
+		if current.Confidence > last.Confidence {
+			merged = current
+		}
+		merged.StartIndex = last.StartIndex
+		merged.EndIndex = last.EndIndex
+		if current.EndIndex > merged.EndIndex {
+			merged.EndIndex = current.EndIndex
+		}
+		if merged.EndIndex <= len(text) {
+			merged.Value = text[merged.StartIndex:merged.EndIndex]
 		}
+		*last = merged
 	}
 
 	return final
@@ -1,6 +1,7 @@
 package interceptor
 
 import (
+	"fmt"
 	"regexp"
 )
 
@@ -290,6 +291,7 @@ func (p *PatternInterceptor) Detect(text string) []DetectedSecret {
 				EndIndex:   end,
 				Type:       rule.Type,
 				Confidence: rule.Confidence,
+				Rule:       rule.Name,
 			})
 		}
 	}
@@ -314,7 +316,40 @@ func (p *PatternInterceptor) AddRule(name, pattern, secretType string, confidenc
 	return nil
 }
 
+// Rules returns a copy of the registered rules
+func (p *PatternInterceptor) Rules() []PatternRule {
+	return append([]PatternRule(nil), p.rules...)
+}
+
 // RuleCount returns the number of registered rules
 func (p *PatternInterceptor) RuleCount() int {
 	return len(p.rules)
 }
+
+// Explain reports every match of each rule, and the rules without one
+func (p *PatternInterceptor) Explain(text string) []Evaluation {
+	var evaluations []Evaluation
+	for _, rule := range p.rules {
+		matches := rule.Pattern.FindAllStringIndex(text, -1)
+		if len(matches) == 0 {
+			evaluations = append(evaluations, Evaluation{
+				Interceptor: p.Name(),
+				Rule:        rule.Name,
+				Outcome:     OutcomeNoMatch,
+			})
+			continue
+		}
+		for _, match := range matches {
+			evaluations = append(evaluations, Evaluation{
+				Interceptor: p.Name(),
+				Rule:        rule.Name,
+				Candidate:   text[match[0]:match[1]],
+				StartIndex:  match[0],
+				EndIndex:    match[1],
+				Outcome:     OutcomeMatched,
+				Reason:      fmt.Sprintf("type %s, confidence %.2f", rule.Type, rule.Confidence),
+			})
+		}
+	}
+	return evaluations
+}
@@ -47,24 +47,50 @@ func (r *Replacer) Replace(text string) *ReplaceResult {
 
 	result.Detected = secrets
 
-	// Sort by start position (descending) to replace from end to start
-	// This prevents index shifting issues
-	sort.Slice(secrets, func(i, j int) bool {
-		return secrets[i].StartIndex > secrets[j].StartIndex
-	})
-
-	// Replace each secret
+	// Replace every occurrence of each secret, including repetitions the
+	// detectors did not report
+	replacements := make(map[string]string, len(secrets))
 	for _, secret := range secrets {
-		placeholder := r.generator.Generate(secret.Value)
+		if _, seen := replacements[secret.Value]; seen {
+			continue
+		}
+		placeholder := r.generator.GenerateTyped(secret.Value, secret.Type, r.generator.HashLength())
 		result.Mappings[placeholder] = secret.Value
-
-		// Replace in text (from end to start to maintain indices)
-		result.Text = result.Text[:secret.StartIndex] + placeholder + result.Text[secret.EndIndex:]
+		replacements[secret.Value] = placeholder
 	}
+	result.Text = ReplaceAll(text, replacements)
 
 	return result
 }
 
+// ReplaceAll replaces all occurrences of the secrets in text with their
+// replacements in a single pass. Longer secrets take precedence where
+// secrets overlap, so a secret containing another is replaced as a whole.
+func ReplaceAll(text string, replacements map[string]string) string {
+	if len(replacements) == 0 {
+		return text
+	}
+
+	secrets := make([]string, 0, len(replacements))
+	for secret := range replacements {
+		if secret != "" {
+			secrets = append(secrets, secret)
+		}
+	}
+	sort.Slice(secrets, func(i, j int) bool {
+		if len(secrets[i]) != len(secrets[j]) {
+			return len(secrets[i]) > len(secrets[j])
+		}
+		return secrets[i] < secrets[j]
+	})
+
+	oldnew := make([]string, 0, 2*len(secrets))
+	for _, secret := range secrets {
+		oldnew = append(oldnew, secret, replacements[secret])
+	}
+	return strings.NewReplacer(oldnew...).Replace(text)
+}
+
 // ReplaceInMessages detects and replaces secrets in multiple message strings
 func (r *Replacer) ReplaceInMessages(messages []string) *ReplaceResult {
 	// Combine all messages for detection
@@ -97,27 +123,21 @@ func (r *Replacer) Restore(text string, lookup func(placeholder string) (string,
 		NotFoundCount: 0,
 	}
 
-	// Find all placeholders
-	placeholders := r.generator.FindAll(text)
-	if len(placeholders) == 0 {
+	// Find all placeholders, including near misses if fuzzy matching is enabled
+	matches := r.generator.FindMatches(text)
+	if len(matches) == 0 {
 		return result
 	}
 
-	// Find positions (from end to start)
-	indices := r.generator.FindAllIndex(text)
-
 	// Sort by position descending
-	sort.Slice(indices, func(i, j int) bool {
-		return indices[i][0] > indices[j][0]
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].Start > matches[j].Start
 	})
 
 	// Restore each placeholder
-	for _, idx := range indices {
-		start, end := idx[0], idx[1]
-		placeholder := text[start:end]
-
-		if secret, found := lookup(placeholder); found {
-			result.Text = result.Text[:start] + secret + result.Text[end:]
+	for _, m := range matches {
+		if secret, found := lookup(m.Placeholder); found {
+			result.Text = result.Text[:m.Start] + secret + result.Text[m.End:]
 			result.RestoredCount++
 		} else {
 			result.NotFoundCount++
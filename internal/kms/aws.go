@@ -0,0 +1,98 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hfi/llm-secret-interceptor/internal/awsauth"
+)
+
+// encryptionContext binds wrapped keys to this application in AWS KMS
+var encryptionContext = map[string]string{"application": "llm-secret-interceptor"}
+
+// AWSProvider wraps data keys with an AWS KMS key
+type AWSProvider struct {
+	keyID    string
+	endpoint string
+	signer   *awsauth.Signer
+	client   *http.Client
+}
+
+// NewAWSProvider creates a provider for the KMS key keyID in region. endpoint
+// overrides the regional KMS endpoint (e.g. for VPC endpoints) if non-empty.
+func NewAWSProvider(region, keyID, endpoint string, creds awsauth.Credentials) (*AWSProvider, error) {
+	if region == "" || keyID == "" {
+		return nil, fmt.Errorf("AWS region and key ID are required")
+	}
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://kms.%s.amazonaws.com", region)
+	}
+	return &AWSProvider{
+		keyID:    keyID,
+		endpoint: strings.TrimRight(endpoint, "/") + "/",
+		signer:   awsauth.NewSigner(creds, region, "kms"),
+		client:   &http.Client{Timeout: defaultHTTPTimeout},
+	}, nil
+}
+
+// Name returns the provider name
+func (p *AWSProvider) Name() string {
+	return "aws-kms"
+}
+
+// Wrap encrypts a data key with the KMS key
+func (p *AWSProvider) Wrap(ctx context.Context, dek []byte) ([]byte, error) {
+	var resp struct {
+		CiphertextBlob []byte `json:"CiphertextBlob"`
+	}
+	if err := p.call(ctx, "TrentService.Encrypt", map[string]interface{}{
+		"KeyId":             p.keyID,
+		"Plaintext":         dek,
+		"EncryptionContext": encryptionContext,
+	}, &resp); err != nil {
+		return nil, fmt.Errorf("AWS KMS encrypt failed: %w", err)
+	}
+	if len(resp.CiphertextBlob) == 0 {
+		return nil, fmt.Errorf("AWS KMS encrypt returned no ciphertext")
+	}
+	return resp.CiphertextBlob, nil
+}
+
+// Unwrap decrypts a data key with the KMS key
+func (p *AWSProvider) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	var resp struct {
+		Plaintext []byte `json:"Plaintext"`
+	}
+	if err := p.call(ctx, "TrentService.Decrypt", map[string]interface{}{
+		"KeyId":             p.keyID,
+		"CiphertextBlob":    wrapped,
+		"EncryptionContext": encryptionContext,
+	}, &resp); err != nil {
+		return nil, fmt.Errorf("AWS KMS decrypt failed: %w", err)
+	}
+	return resp.Plaintext, nil
+}
+
+// call invokes a KMS JSON API action; []byte fields are base64-encoded by encoding/json
+func (p *AWSProvider) call(ctx context.Context, target string, body map[string]interface{}, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.1")
+	req.Header.Set("X-Amz-Target", target)
+	if err := p.signer.Sign(req, payload); err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	return doJSON(p.client, req, out)
+}
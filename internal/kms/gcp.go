@@ -0,0 +1,153 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// metadataTokenURL is the GCE metadata endpoint for the default service account token
+const metadataTokenURL = "http://metadata.google.internal/computeMetadata/v1/instance/service-accounts/default/token"
+
+// TokenSource supplies OAuth2 access tokens for Google Cloud APIs
+type TokenSource interface {
+	Token(ctx context.Context) (string, error)
+}
+
+// StaticToken is a TokenSource returning a fixed access token
+type StaticToken string
+
+// Token returns the static token
+func (t StaticToken) Token(_ context.Context) (string, error) {
+	return string(t), nil
+}
+
+// MetadataTokenSource fetches access tokens from the GCE/GKE metadata server
+// and caches them until shortly before they expire
+type MetadataTokenSource struct {
+	url    string
+	client *http.Client
+
+	mu      sync.Mutex
+	token   string
+	expires time.Time
+}
+
+// NewMetadataTokenSource creates a token source backed by the metadata server
+func NewMetadataTokenSource() *MetadataTokenSource {
+	return &MetadataTokenSource{
+		url:    metadataTokenURL,
+		client: &http.Client{Timeout: defaultHTTPTimeout},
+	}
+}
+
+// Token returns a cached or freshly fetched access token
+func (s *MetadataTokenSource) Token(ctx context.Context) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.token != "" && time.Now().Before(s.expires) {
+		return s.token, nil
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	var resp struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := doJSON(s.client, req, &resp); err != nil {
+		return "", fmt.Errorf("failed to fetch metadata token: %w", err)
+	}
+
+	s.token = resp.AccessToken
+	// Refresh a minute early to avoid using a token that expires in flight
+	s.expires = time.Now().Add(time.Duration(resp.ExpiresIn)*time.Second - time.Minute)
+	return s.token, nil
+}
+
+// GCPProvider wraps data keys with a Google Cloud KMS key
+type GCPProvider struct {
+	keyName  string
+	endpoint string
+	tokens   TokenSource
+	client   *http.Client
+}
+
+// NewGCPProvider creates a provider for the crypto key keyName
+// (projects/*/locations/*/keyRings/*/cryptoKeys/*). endpoint overrides the
+// Cloud KMS API endpoint if non-empty.
+func NewGCPProvider(keyName, endpoint string, tokens TokenSource) (*GCPProvider, error) {
+	if !strings.HasPrefix(keyName, "projects/") || !strings.Contains(keyName, "/cryptoKeys/") {
+		return nil, fmt.Errorf("invalid GCP KMS key name %q", keyName)
+	}
+	if endpoint == "" {
+		endpoint = "https://cloudkms.googleapis.com"
+	}
+	return &GCPProvider{
+		keyName:  keyName,
+		endpoint: strings.TrimRight(endpoint, "/"),
+		tokens:   tokens,
+		client:   &http.Client{Timeout: defaultHTTPTimeout},
+	}, nil
+}
+
+// Name returns the provider name
+func (p *GCPProvider) Name() string {
+	return "gcp-kms"
+}
+
+// Wrap encrypts a data key with the crypto key
+func (p *GCPProvider) Wrap(ctx context.Context, dek []byte) ([]byte, error) {
+	var resp struct {
+		Ciphertext []byte `json:"ciphertext"`
+	}
+	if err := p.call(ctx, "encrypt", map[string][]byte{"plaintext": dek}, &resp); err != nil {
+		return nil, fmt.Errorf("GCP KMS encrypt failed: %w", err)
+	}
+	if len(resp.Ciphertext) == 0 {
+		return nil, fmt.Errorf("GCP KMS encrypt returned no ciphertext")
+	}
+	return resp.Ciphertext, nil
+}
+
+// Unwrap decrypts a data key with the crypto key
+func (p *GCPProvider) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	var resp struct {
+		Plaintext []byte `json:"plaintext"`
+	}
+	if err := p.call(ctx, "decrypt", map[string][]byte{"ciphertext": wrapped}, &resp); err != nil {
+		return nil, fmt.Errorf("GCP KMS decrypt failed: %w", err)
+	}
+	return resp.Plaintext, nil
+}
+
+func (p *GCPProvider) call(ctx context.Context, op string, body map[string][]byte, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	token, err := p.tokens.Token(ctx)
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/%s:%s", p.endpoint, p.keyName, op)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return doJSON(p.client, req, out)
+}
@@ -0,0 +1,350 @@
+package kms
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// sealedPrefix marks values sealed by a Keyring
+const sealedPrefix = "enc1:"
+
+// dekSize is the size of a data-encryption key in bytes
+const dekSize = 32
+
+// WrappedKey is a data-encryption key wrapped by a KeyProvider
+type WrappedKey struct {
+	ID        string    `json:"id"`
+	Provider  string    `json:"provider"`
+	Wrapped   []byte    `json:"wrapped"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// KeyStore persists wrapped data-encryption keys so that all proxy instances
+// sharing a mapping store can decrypt each other's data
+type KeyStore interface {
+	// LoadKeys returns all persisted wrapped keys
+	LoadKeys(ctx context.Context) ([]WrappedKey, error)
+
+	// SaveKey persists a wrapped key
+	SaveKey(ctx context.Context, key WrappedKey) error
+}
+
+// MemoryKeyStore is a KeyStore that keeps wrapped keys in process memory
+type MemoryKeyStore struct {
+	mu   sync.RWMutex
+	keys []WrappedKey
+}
+
+// NewMemoryKeyStore creates a new in-memory key store
+func NewMemoryKeyStore() *MemoryKeyStore {
+	return &MemoryKeyStore{}
+}
+
+// LoadKeys returns all stored wrapped keys
+func (s *MemoryKeyStore) LoadKeys(_ context.Context) ([]WrappedKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]WrappedKey(nil), s.keys...), nil
+}
+
+// SaveKey stores a wrapped key
+func (s *MemoryKeyStore) SaveKey(_ context.Context, key WrappedKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = append(s.keys, key)
+	return nil
+}
+
+// dataKey is an unwrapped data-encryption key
+type dataKey struct {
+	id        string
+	createdAt time.Time
+	aead      cipher.AEAD
+	nonceKey  []byte
+}
+
+// Keyring holds the data-encryption keys used to seal stored values.
+//
+// Sealing is deterministic per key: the nonce is derived from the plaintext
+// with HMAC-SHA256, so equal plaintexts produce equal ciphertexts under the
+// same key. This keeps reverse lookups (secret -> placeholder) possible on
+// encrypted data, at the cost of revealing equality of sealed values.
+type Keyring struct {
+	provider KeyProvider
+	store    KeyStore
+
+	mu     sync.RWMutex
+	keys   map[string]*dataKey
+	order  []string // key IDs, newest first
+	active string
+}
+
+// NewKeyring creates a keyring whose keys are wrapped by provider and persisted in store
+func NewKeyring(provider KeyProvider, store KeyStore) *Keyring {
+	return &Keyring{
+		provider: provider,
+		store:    store,
+		keys:     make(map[string]*dataKey),
+	}
+}
+
+// Init loads the persisted keys and creates a first key if there is none
+func (k *Keyring) Init(ctx context.Context) error {
+	if err := k.Load(ctx); err != nil {
+		return err
+	}
+	if k.ActiveKeyID() != "" {
+		return nil
+	}
+	_, err := k.Rotate(ctx)
+	return err
+}
+
+// Load unwraps all persisted keys not yet known and activates the newest key
+func (k *Keyring) Load(ctx context.Context) error {
+	wrapped, err := k.store.LoadKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load data keys: %w", err)
+	}
+
+	for _, w := range wrapped {
+		k.mu.RLock()
+		_, known := k.keys[w.ID]
+		k.mu.RUnlock()
+		if known {
+			continue
+		}
+
+		if w.Provider != k.provider.Name() {
+			return fmt.Errorf("data key %s was wrapped by provider %q, configured provider is %q",
+				w.ID, w.Provider, k.provider.Name())
+		}
+		dek, err := k.provider.Unwrap(ctx, w.Wrapped)
+		if err != nil {
+			return fmt.Errorf("failed to unwrap data key %s: %w", w.ID, err)
+		}
+		if err := k.add(w.ID, dek, w.CreatedAt); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Rotate generates a new data-encryption key, persists it, and makes it the
+// active key. Older keys remain available for decryption.
+func (k *Keyring) Rotate(ctx context.Context) (string, error) {
+	dek := make([]byte, dekSize)
+	if _, err := rand.Read(dek); err != nil {
+		return "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+	idBytes := make([]byte, 8)
+	if _, err := rand.Read(idBytes); err != nil {
+		return "", fmt.Errorf("failed to generate key ID: %w", err)
+	}
+	id := hex.EncodeToString(idBytes)
+
+	wrapped, err := k.provider.Wrap(ctx, dek)
+	if err != nil {
+		return "", fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	createdAt := time.Now().UTC()
+	if err := k.store.SaveKey(ctx, WrappedKey{
+		ID:        id,
+		Provider:  k.provider.Name(),
+		Wrapped:   wrapped,
+		CreatedAt: createdAt,
+	}); err != nil {
+		return "", fmt.Errorf("failed to persist data key: %w", err)
+	}
+
+	if err := k.add(id, dek, createdAt); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// RotateIfOlder rotates the active key if it is older than maxAge. Keys
+// created by other instances are loaded first, so a fleet sharing a key store
+// rotates once per interval rather than once per instance.
+func (k *Keyring) RotateIfOlder(ctx context.Context, maxAge time.Duration) (bool, error) {
+	if err := k.Load(ctx); err != nil {
+		return false, err
+	}
+
+	k.mu.RLock()
+	active := k.keys[k.active]
+	k.mu.RUnlock()
+	if active != nil && time.Since(active.createdAt) < maxAge {
+		return false, nil
+	}
+
+	if _, err := k.Rotate(ctx); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ActiveKeyID returns the ID of the key used for sealing
+func (k *Keyring) ActiveKeyID() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.active
+}
+
+// KeyIDs returns the IDs of all known keys, newest first
+func (k *Keyring) KeyIDs() []string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return append([]string(nil), k.order...)
+}
+
+// Seal encrypts a value with the active key
+func (k *Keyring) Seal(plaintext string) (string, error) {
+	k.mu.RLock()
+	key := k.keys[k.active]
+	k.mu.RUnlock()
+	if key == nil {
+		return "", fmt.Errorf("keyring has no active key")
+	}
+	return key.seal(plaintext), nil
+}
+
+// SealAll encrypts a value with every known key, active key first. It is
+// used to look up values that may have been sealed before a rotation.
+func (k *Keyring) SealAll(plaintext string) []string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	sealed := make([]string, 0, len(k.order))
+	for _, id := range k.order {
+		sealed = append(sealed, k.keys[id].seal(plaintext))
+	}
+	return sealed
+}
+
+// Open decrypts a sealed value. Keys created by other instances since the
+// last load are fetched from the key store on demand.
+func (k *Keyring) Open(ctx context.Context, sealed string) (string, error) {
+	id, payload, ok := parseSealed(sealed)
+	if !ok {
+		return "", fmt.Errorf("value is not sealed")
+	}
+
+	k.mu.RLock()
+	key := k.keys[id]
+	k.mu.RUnlock()
+	if key == nil {
+		if err := k.Load(ctx); err != nil {
+			return "", err
+		}
+		k.mu.RLock()
+		key = k.keys[id]
+		k.mu.RUnlock()
+		if key == nil {
+			return "", fmt.Errorf("unknown data key %s", id)
+		}
+	}
+
+	return key.open(payload)
+}
+
+// IsSealed reports whether a value was produced by Keyring.Seal
+func IsSealed(value string) bool {
+	_, _, ok := parseSealed(value)
+	return ok
+}
+
+// add registers an unwrapped key and activates the newest key
+func (k *Keyring) add(id string, dek []byte, createdAt time.Time) error {
+	if len(dek) != dekSize {
+		return fmt.Errorf("data key %s has invalid length %d", id, len(dek))
+	}
+
+	block, err := aes.NewCipher(derive(dek, "encryption"))
+	if err != nil {
+		return fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return fmt.Errorf("failed to create GCM: %w", err)
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if _, exists := k.keys[id]; exists {
+		return nil
+	}
+	k.keys[id] = &dataKey{
+		id:        id,
+		createdAt: createdAt,
+		aead:      aead,
+		nonceKey:  derive(dek, "nonce"),
+	}
+	k.order = append(k.order, id)
+	sort.SliceStable(k.order, func(i, j int) bool {
+		return k.keys[k.order[i]].createdAt.After(k.keys[k.order[j]].createdAt)
+	})
+	k.active = k.order[0]
+
+	return nil
+}
+
+func (d *dataKey) seal(plaintext string) string {
+	mac := hmac.New(sha256.New, d.nonceKey)
+	mac.Write([]byte(plaintext))
+	nonce := mac.Sum(nil)[:d.aead.NonceSize()]
+
+	out := d.aead.Seal(nonce, nonce, []byte(plaintext), []byte(d.id))
+	return sealedPrefix + d.id + ":" + base64.RawURLEncoding.EncodeToString(out)
+}
+
+func (d *dataKey) open(payload string) (string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode sealed value: %w", err)
+	}
+	nonceSize := d.aead.NonceSize()
+	if len(data) < nonceSize {
+		return "", fmt.Errorf("sealed value too short")
+	}
+
+	plaintext, err := d.aead.Open(nil, data[:nonceSize], data[nonceSize:], []byte(d.id))
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt sealed value: %w", err)
+	}
+	return string(plaintext), nil
+}
+
+// parseSealed splits a sealed value into key ID and payload
+func parseSealed(value string) (string, string, bool) {
+	rest, ok := strings.CutPrefix(value, sealedPrefix)
+	if !ok {
+		return "", "", false
+	}
+	id, payload, ok := strings.Cut(rest, ":")
+	if !ok || id == "" || payload == "" {
+		return "", "", false
+	}
+	return id, payload, true
+}
+
+// derive derives a purpose-specific subkey from a data key
+func derive(dek []byte, purpose string) []byte {
+	mac := hmac.New(sha256.New, dek)
+	mac.Write([]byte("llm-secret-interceptor/" + purpose))
+	return mac.Sum(nil)
+}
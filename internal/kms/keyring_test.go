@@ -0,0 +1,156 @@
+package kms
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestKeyring(t *testing.T, store KeyStore) *Keyring {
+	t.Helper()
+	provider, err := NewLocalProvider(make([]byte, 32))
+	if err != nil {
+		t.Fatalf("NewLocalProvider() error: %v", err)
+	}
+	return NewKeyring(provider, store)
+}
+
+func TestKeyring_SealOpen(t *testing.T) {
+	ctx := context.Background()
+	keyring := newTestKeyring(t, NewMemoryKeyStore())
+	if err := keyring.Init(ctx); err != nil {
+		t.Fatalf("Init() error: %v", err)
+	}
+
+	sealed, err := keyring.Seal("mysecretpassword")
+	if err != nil {
+		t.Fatalf("Seal() error: %v", err)
+	}
+	if strings.Contains(sealed, "mysecretpassword") {
+		t.Error("sealed value contains plaintext")
+	}
+	if !IsSealed(sealed) {
+		t.Error("IsSealed() = false for sealed value")
+	}
+
+	again, _ := keyring.Seal("mysecretpassword")
+	if again != sealed {
+		t.Error("Seal() should be deterministic under the same key")
+	}
+
+	opened, err := keyring.Open(ctx, sealed)
+	if err != nil {
+		t.Fatalf("Open() error: %v", err)
+	}
+	if opened != "mysecretpassword" {
+		t.Errorf("Open() = %q, want %q", opened, "mysecretpassword")
+	}
+}
+
+func TestKeyring_OpenTampered(t *testing.T) {
+	ctx := context.Background()
+	keyring := newTestKeyring(t, NewMemoryKeyStore())
+	if err := keyring.Init(ctx); err != nil {
+		t.Fatalf("Init() error: %v", err)
+	}
+
+	sealed, _ := keyring.Seal("mysecretpassword")
+	tampered := sealed[:len(sealed)-2] + "AA"
+	if _, err := keyring.Open(ctx, tampered); err == nil {
+		t.Error("Open() expected error for tampered value")
+	}
+	if _, err := keyring.Open(ctx, "plain"); err == nil {
+		t.Error("Open() expected error for unsealed value")
+	}
+}
+
+func TestKeyring_Rotate(t *testing.T) {
+	ctx := context.Background()
+	keyring := newTestKeyring(t, NewMemoryKeyStore())
+	if err := keyring.Init(ctx); err != nil {
+		t.Fatalf("Init() error: %v", err)
+	}
+	first := keyring.ActiveKeyID()
+	old, _ := keyring.Seal("mysecretpassword")
+
+	second, err := keyring.Rotate(ctx)
+	if err != nil {
+		t.Fatalf("Rotate() error: %v", err)
+	}
+	if second == first || keyring.ActiveKeyID() != second {
+		t.Errorf("active key = %s, want new key %s", keyring.ActiveKeyID(), second)
+	}
+
+	// Values sealed under the previous key remain readable
+	opened, err := keyring.Open(ctx, old)
+	if err != nil || opened != "mysecretpassword" {
+		t.Errorf("Open() = %q, %v after rotation", opened, err)
+	}
+
+	all := keyring.SealAll("mysecretpassword")
+	if len(all) != 2 || all[1] != old {
+		t.Errorf("SealAll() = %v, want active key first and old value last", all)
+	}
+}
+
+func TestKeyring_SharedStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryKeyStore()
+
+	a := newTestKeyring(t, store)
+	if err := a.Init(ctx); err != nil {
+		t.Fatalf("Init() error: %v", err)
+	}
+	b := newTestKeyring(t, store)
+	if err := b.Init(ctx); err != nil {
+		t.Fatalf("Init() error: %v", err)
+	}
+	if a.ActiveKeyID() != b.ActiveKeyID() {
+		t.Error("second instance should adopt the existing key")
+	}
+
+	// A key created by another instance is loaded on demand
+	if _, err := a.Rotate(ctx); err != nil {
+		t.Fatalf("Rotate() error: %v", err)
+	}
+	sealed, _ := a.Seal("shared")
+	opened, err := b.Open(ctx, sealed)
+	if err != nil || opened != "shared" {
+		t.Errorf("Open() = %q, %v for key from other instance", opened, err)
+	}
+}
+
+func TestKeyring_RotateIfOlder(t *testing.T) {
+	ctx := context.Background()
+	keyring := newTestKeyring(t, NewMemoryKeyStore())
+	if err := keyring.Init(ctx); err != nil {
+		t.Fatalf("Init() error: %v", err)
+	}
+
+	rotated, err := keyring.RotateIfOlder(ctx, time.Hour)
+	if err != nil || rotated {
+		t.Errorf("RotateIfOlder(1h) = %v, %v, want no rotation", rotated, err)
+	}
+
+	rotated, err = keyring.RotateIfOlder(ctx, 0)
+	if err != nil || !rotated {
+		t.Errorf("RotateIfOlder(0) = %v, %v, want rotation", rotated, err)
+	}
+	if len(keyring.KeyIDs()) != 2 {
+		t.Errorf("KeyIDs() = %v, want 2 keys", keyring.KeyIDs())
+	}
+}
+
+func TestKeyring_ProviderMismatch(t *testing.T) {
+	ctx := context.Background()
+	store := NewMemoryKeyStore()
+	if err := store.SaveKey(ctx, WrappedKey{ID: "abc", Provider: "vault", Wrapped: []byte("x")}); err != nil {
+		t.Fatalf("SaveKey() error: %v", err)
+	}
+
+	keyring := newTestKeyring(t, store)
+	if err := keyring.Init(ctx); err == nil {
+		t.Error("Init() expected error for key wrapped by another provider")
+	}
+}
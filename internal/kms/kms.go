@@ -0,0 +1,57 @@
+// Package kms provides envelope encryption for stored secrets. Data is
+// encrypted with data-encryption keys (DEKs) held in a Keyring; the DEKs are
+// themselves wrapped by a KeyProvider backed by an external key management
+// service (AWS KMS, GCP KMS, Vault transit) or a local key-encryption key.
+// The same services back crypto.Signer implementations for keys that sign
+// remotely and never leave the service; crypto.Signer carries no context, so
+// their calls are bounded by the HTTP client timeout.
+package kms
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// KeyProvider wraps and unwraps data-encryption keys with a key-encryption
+// key that never leaves the provider
+type KeyProvider interface {
+	// Name returns the provider name
+	Name() string
+
+	// Wrap encrypts a data-encryption key
+	Wrap(ctx context.Context, dek []byte) ([]byte, error)
+
+	// Unwrap decrypts a wrapped data-encryption key
+	Unwrap(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// defaultHTTPTimeout bounds calls to remote key management services
+const defaultHTTPTimeout = 10 * time.Second
+
+// maxErrorBody limits how much of an error response is included in errors
+const maxErrorBody = 512
+
+// doJSON sends a JSON request and decodes the JSON response into out
+func doJSON(client *http.Client, req *http.Request, out interface{}) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, body)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
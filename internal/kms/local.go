@@ -0,0 +1,80 @@
+package kms
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// LocalProvider wraps data keys with a locally held key-encryption key.
+// It is meant for development and for deployments without a KMS.
+type LocalProvider struct {
+	aead cipher.AEAD
+}
+
+// NewLocalProvider creates a local provider from a 32-byte key-encryption key
+func NewLocalProvider(kek []byte) (*LocalProvider, error) {
+	if len(kek) != 32 {
+		return nil, fmt.Errorf("key-encryption key must be 32 bytes, got %d", len(kek))
+	}
+	block, err := aes.NewCipher(kek)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+	return &LocalProvider{aead: aead}, nil
+}
+
+// ParseLocalKey decodes a base64-encoded key-encryption key
+func ParseLocalKey(encoded string) ([]byte, error) {
+	kek, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode key-encryption key: %w", err)
+	}
+	return kek, nil
+}
+
+// LoadLocalKey reads a base64-encoded key-encryption key from a file
+func LoadLocalKey(path string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key-encryption key: %w", err)
+	}
+	return ParseLocalKey(string(data))
+}
+
+// Name returns the provider name
+func (p *LocalProvider) Name() string {
+	return "local"
+}
+
+// Wrap encrypts a data key with the key-encryption key
+func (p *LocalProvider) Wrap(_ context.Context, dek []byte) ([]byte, error) {
+	nonce := make([]byte, p.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return p.aead.Seal(nonce, nonce, dek, nil), nil
+}
+
+// Unwrap decrypts a data key with the key-encryption key
+func (p *LocalProvider) Unwrap(_ context.Context, wrapped []byte) ([]byte, error) {
+	nonceSize := p.aead.NonceSize()
+	if len(wrapped) < nonceSize {
+		return nil, fmt.Errorf("wrapped key too short")
+	}
+	dek, err := p.aead.Open(nil, wrapped[:nonceSize], wrapped[nonceSize:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+	return dek, nil
+}
@@ -0,0 +1,193 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hfi/llm-secret-interceptor/internal/awsauth"
+)
+
+// fakeWrap and fakeUnwrap stand in for a remote KMS on base64-encoded keys
+func fakeWrap(b64 string) string {
+	raw, _ := base64.StdEncoding.DecodeString(b64)
+	return base64.StdEncoding.EncodeToString(append([]byte("wrapped:"), raw...))
+}
+
+func fakeUnwrap(b64 string) string {
+	raw, _ := base64.StdEncoding.DecodeString(b64)
+	return base64.StdEncoding.EncodeToString(bytes.TrimPrefix(raw, []byte("wrapped:")))
+}
+
+// roundTrip wraps and unwraps a key with a provider
+func roundTrip(t *testing.T, p KeyProvider) {
+	t.Helper()
+	ctx := context.Background()
+	dek := bytes.Repeat([]byte{0x42}, 32)
+
+	wrapped, err := p.Wrap(ctx, dek)
+	if err != nil {
+		t.Fatalf("Wrap() error: %v", err)
+	}
+	if bytes.Equal(wrapped, dek) {
+		t.Error("wrapped key equals plaintext key")
+	}
+
+	unwrapped, err := p.Unwrap(ctx, wrapped)
+	if err != nil {
+		t.Fatalf("Unwrap() error: %v", err)
+	}
+	if !bytes.Equal(unwrapped, dek) {
+		t.Error("Unwrap() did not return the original key")
+	}
+}
+
+func TestLocalProvider(t *testing.T) {
+	kek := base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{1}, 32))
+	key, err := ParseLocalKey(kek + "\n")
+	if err != nil {
+		t.Fatalf("ParseLocalKey() error: %v", err)
+	}
+	p, err := NewLocalProvider(key)
+	if err != nil {
+		t.Fatalf("NewLocalProvider() error: %v", err)
+	}
+	roundTrip(t, p)
+
+	if _, err := NewLocalProvider([]byte("short")); err == nil {
+		t.Error("NewLocalProvider() expected error for short key")
+	}
+}
+
+func TestVaultProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		switch r.URL.Path {
+		case "/v1/transit/encrypt/mykey":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]string{"ciphertext": "vault:v1:" + body["plaintext"]},
+			})
+		case "/v1/transit/decrypt/mykey":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]string{"plaintext": strings.TrimPrefix(body["ciphertext"], "vault:v1:")},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p, err := NewVaultProvider(server.URL, "token", "", "mykey")
+	if err != nil {
+		t.Fatalf("NewVaultProvider() error: %v", err)
+	}
+	roundTrip(t, p)
+
+	bad, _ := NewVaultProvider(server.URL, "wrong", "", "mykey")
+	if _, err := bad.Wrap(context.Background(), []byte("key")); err == nil {
+		t.Error("Wrap() expected error for rejected token")
+	}
+}
+
+func TestAWSProvider(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKID/") {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		var body map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body["KeyId"] != "alias/test" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		switch r.Header.Get("X-Amz-Target") {
+		case "TrentService.Encrypt":
+			_ = json.NewEncoder(w).Encode(map[string]string{"CiphertextBlob": fakeWrap(body["Plaintext"].(string))})
+		case "TrentService.Decrypt":
+			_ = json.NewEncoder(w).Encode(map[string]string{"Plaintext": fakeUnwrap(body["CiphertextBlob"].(string))})
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	p, err := NewAWSProvider("eu-central-1", "alias/test", server.URL, awsauth.Credentials{
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+	})
+	if err != nil {
+		t.Fatalf("NewAWSProvider() error: %v", err)
+	}
+	roundTrip(t, p)
+}
+
+func TestGCPProvider(t *testing.T) {
+	keyName := "projects/p/locations/global/keyRings/r/cryptoKeys/k"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var body map[string]string
+		_ = json.NewDecoder(r.Body).Decode(&body)
+
+		switch r.URL.Path {
+		case "/v1/" + keyName + ":encrypt":
+			_ = json.NewEncoder(w).Encode(map[string]string{"ciphertext": fakeWrap(body["plaintext"])})
+		case "/v1/" + keyName + ":decrypt":
+			_ = json.NewEncoder(w).Encode(map[string]string{"plaintext": fakeUnwrap(body["ciphertext"])})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	p, err := NewGCPProvider(keyName, server.URL, StaticToken("token"))
+	if err != nil {
+		t.Fatalf("NewGCPProvider() error: %v", err)
+	}
+	roundTrip(t, p)
+
+	if _, err := NewGCPProvider("mykey", "", StaticToken("token")); err == nil {
+		t.Error("NewGCPProvider() expected error for invalid key name")
+	}
+}
+
+func TestMetadataTokenSource(t *testing.T) {
+	calls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if r.Header.Get("Metadata-Flavor") != "Google" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "abc", "expires_in": 3600})
+	}))
+	defer server.Close()
+
+	source := NewMetadataTokenSource()
+	source.url = server.URL
+
+	for i := 0; i < 2; i++ {
+		token, err := source.Token(context.Background())
+		if err != nil || token != "abc" {
+			t.Fatalf("Token() = %q, %v", token, err)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("metadata server called %d times, want 1 (cached)", calls)
+	}
+}
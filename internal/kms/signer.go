@@ -0,0 +1,321 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/hfi/llm-secret-interceptor/internal/awsauth"
+)
+
+// hashName returns the name of the SHA-2 hash h, as used by the services
+func hashName(h crypto.Hash) (string, error) {
+	switch h {
+	case crypto.SHA256:
+		return "256", nil
+	case crypto.SHA384:
+		return "384", nil
+	case crypto.SHA512:
+		return "512", nil
+	default:
+		return "", fmt.Errorf("unsupported signature hash %v", h)
+	}
+}
+
+// parsePublicKey parses a PEM or DER encoded public key of a signing key
+func parsePublicKey(data []byte) (crypto.PublicKey, error) {
+	if block, _ := pem.Decode(data); block != nil {
+		data = block.Bytes
+	}
+	pub, err := x509.ParsePKIXPublicKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key: %w", err)
+	}
+	switch pub.(type) {
+	case *rsa.PublicKey, *ecdsa.PublicKey:
+		return pub, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type %T", pub)
+	}
+}
+
+// VaultSigner signs with a HashiCorp Vault transit key
+type VaultSigner struct {
+	address string
+	token   string
+	mount   string
+	keyName string
+	client  *http.Client
+	public  crypto.PublicKey
+}
+
+// NewVaultSigner creates a signer for the latest version of the transit
+// key keyName mounted at mount
+func NewVaultSigner(ctx context.Context, address, token, mount, keyName string) (*VaultSigner, error) {
+	p, err := NewVaultProvider(address, token, mount, keyName)
+	if err != nil {
+		return nil, err
+	}
+	s := &VaultSigner{address: p.address, token: p.token, mount: p.mount, keyName: p.keyName, client: p.client}
+
+	var resp struct {
+		Data struct {
+			LatestVersion int `json:"latest_version"`
+			Keys          map[string]struct {
+				PublicKey string `json:"public_key"`
+			} `json:"keys"`
+		} `json:"data"`
+	}
+	if err := s.call(ctx, http.MethodGet, "keys/"+url.PathEscape(keyName), nil, &resp); err != nil {
+		return nil, fmt.Errorf("failed to read vault key: %w", err)
+	}
+	key, ok := resp.Data.Keys[strconv.Itoa(resp.Data.LatestVersion)]
+	if !ok || key.PublicKey == "" {
+		return nil, fmt.Errorf("vault key %q is not an asymmetric signing key", keyName)
+	}
+	if s.public, err = parsePublicKey([]byte(key.PublicKey)); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// Public returns the public key
+func (s *VaultSigner) Public() crypto.PublicKey {
+	return s.public
+}
+
+// Sign signs digest, which was hashed with opts.HashFunc()
+func (s *VaultSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	hash, err := hashName(opts.HashFunc())
+	if err != nil {
+		return nil, err
+	}
+	body := map[string]interface{}{
+		"input":                base64.StdEncoding.EncodeToString(digest),
+		"prehashed":            true,
+		"marshaling_algorithm": "asn1",
+	}
+	if _, ok := s.public.(*rsa.PublicKey); ok {
+		body["signature_algorithm"] = "pkcs1v15"
+		if _, pss := opts.(*rsa.PSSOptions); pss {
+			body["signature_algorithm"] = "pss"
+		}
+	}
+
+	var resp struct {
+		Data struct {
+			Signature string `json:"signature"`
+		} `json:"data"`
+	}
+	path := fmt.Sprintf("sign/%s/sha2-%s", url.PathEscape(s.keyName), hash)
+	if err := s.call(context.Background(), http.MethodPost, path, body, &resp); err != nil {
+		return nil, fmt.Errorf("vault sign failed: %w", err)
+	}
+	// Signatures look like vault:v1:<base64>
+	encoded := resp.Data.Signature[strings.LastIndex(resp.Data.Signature, ":")+1:]
+	sig, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode vault signature: %w", err)
+	}
+	if len(sig) == 0 {
+		return nil, fmt.Errorf("vault sign returned no signature")
+	}
+	return sig, nil
+}
+
+func (s *VaultSigner) call(ctx context.Context, method, path string, body interface{}, out interface{}) error {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(data)
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/%s/%s", s.address, s.mount, path)
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, payload)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Vault-Token", s.token)
+
+	return doJSON(s.client, req, out)
+}
+
+// AWSSigner signs with an asymmetric AWS KMS key
+type AWSSigner struct {
+	provider *AWSProvider
+	public   crypto.PublicKey
+}
+
+// NewAWSSigner creates a signer for the KMS key keyID in region. endpoint
+// overrides the regional KMS endpoint if non-empty.
+func NewAWSSigner(ctx context.Context, region, keyID, endpoint string, creds awsauth.Credentials) (*AWSSigner, error) {
+	p, err := NewAWSProvider(region, keyID, endpoint, creds)
+	if err != nil {
+		return nil, err
+	}
+	var resp struct {
+		PublicKey []byte `json:"PublicKey"`
+		KeyUsage  string `json:"KeyUsage"`
+	}
+	if err := p.call(ctx, "TrentService.GetPublicKey", map[string]interface{}{"KeyId": keyID}, &resp); err != nil {
+		return nil, fmt.Errorf("AWS KMS get public key failed: %w", err)
+	}
+	if resp.KeyUsage != "SIGN_VERIFY" {
+		return nil, fmt.Errorf("AWS KMS key %q is not a signing key", keyID)
+	}
+	public, err := parsePublicKey(resp.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	return &AWSSigner{provider: p, public: public}, nil
+}
+
+// Public returns the public key
+func (s *AWSSigner) Public() crypto.PublicKey {
+	return s.public
+}
+
+// Sign signs digest, which was hashed with opts.HashFunc()
+func (s *AWSSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	hash, err := hashName(opts.HashFunc())
+	if err != nil {
+		return nil, err
+	}
+	algorithm := "ECDSA_SHA_" + hash
+	if _, ok := s.public.(*rsa.PublicKey); ok {
+		algorithm = "RSASSA_PKCS1_V1_5_SHA_" + hash
+		if _, pss := opts.(*rsa.PSSOptions); pss {
+			algorithm = "RSASSA_PSS_SHA_" + hash
+		}
+	}
+
+	var resp struct {
+		Signature []byte `json:"Signature"`
+	}
+	if err := s.provider.call(context.Background(), "TrentService.Sign", map[string]interface{}{
+		"KeyId":            s.provider.keyID,
+		"Message":          digest,
+		"MessageType":      "DIGEST",
+		"SigningAlgorithm": algorithm,
+	}, &resp); err != nil {
+		return nil, fmt.Errorf("AWS KMS sign failed: %w", err)
+	}
+	if len(resp.Signature) == 0 {
+		return nil, fmt.Errorf("AWS KMS sign returned no signature")
+	}
+	return resp.Signature, nil
+}
+
+// GCPSigner signs with a Google Cloud KMS asymmetric key version
+type GCPSigner struct {
+	versionName string
+	endpoint    string
+	tokens      TokenSource
+	client      *http.Client
+	public      crypto.PublicKey
+}
+
+// NewGCPSigner creates a signer for the key version versionName
+// (projects/*/locations/*/keyRings/*/cryptoKeys/*/cryptoKeyVersions/*).
+// The key's algorithm fixes the padding, so only PKCS#1 v1.5 and ECDSA keys
+// are supported. endpoint overrides the Cloud KMS API endpoint if non-empty.
+func NewGCPSigner(ctx context.Context, versionName, endpoint string, tokens TokenSource) (*GCPSigner, error) {
+	if !strings.HasPrefix(versionName, "projects/") || !strings.Contains(versionName, "/cryptoKeyVersions/") {
+		return nil, fmt.Errorf("invalid GCP KMS key version name %q", versionName)
+	}
+	if endpoint == "" {
+		endpoint = "https://cloudkms.googleapis.com"
+	}
+	s := &GCPSigner{
+		versionName: versionName,
+		endpoint:    strings.TrimRight(endpoint, "/"),
+		tokens:      tokens,
+		client:      &http.Client{Timeout: defaultHTTPTimeout},
+	}
+
+	var resp struct {
+		PEM       string `json:"pem"`
+		Algorithm string `json:"algorithm"`
+	}
+	if err := s.call(ctx, http.MethodGet, "/publicKey", nil, &resp); err != nil {
+		return nil, fmt.Errorf("GCP KMS get public key failed: %w", err)
+	}
+	if !strings.Contains(resp.Algorithm, "PKCS1") && !strings.HasPrefix(resp.Algorithm, "EC_SIGN_") {
+		return nil, fmt.Errorf("unsupported GCP KMS key algorithm %q", resp.Algorithm)
+	}
+	public, err := parsePublicKey([]byte(resp.PEM))
+	if err != nil {
+		return nil, err
+	}
+	s.public = public
+	return s, nil
+}
+
+// Public returns the public key
+func (s *GCPSigner) Public() crypto.PublicKey {
+	return s.public
+}
+
+// Sign signs digest, which was hashed with opts.HashFunc()
+func (s *GCPSigner) Sign(_ io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	hash, err := hashName(opts.HashFunc())
+	if err != nil {
+		return nil, err
+	}
+	if _, pss := opts.(*rsa.PSSOptions); pss {
+		return nil, fmt.Errorf("GCP KMS key does not sign with PSS")
+	}
+
+	var resp struct {
+		Signature []byte `json:"signature"`
+	}
+	body := map[string]map[string][]byte{"digest": {"sha" + hash: digest}}
+	if err := s.call(context.Background(), http.MethodPost, ":asymmetricSign", body, &resp); err != nil {
+		return nil, fmt.Errorf("GCP KMS sign failed: %w", err)
+	}
+	if len(resp.Signature) == 0 {
+		return nil, fmt.Errorf("GCP KMS sign returned no signature")
+	}
+	return resp.Signature, nil
+}
+
+func (s *GCPSigner) call(ctx context.Context, method, suffix string, body interface{}, out interface{}) error {
+	var payload io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		payload = bytes.NewReader(data)
+	}
+	token, err := s.tokens.Token(ctx)
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/%s%s", s.endpoint, s.versionName, suffix)
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, payload)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	return doJSON(s.client, req, out)
+}
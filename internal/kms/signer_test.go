@@ -0,0 +1,199 @@
+package kms
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hfi/llm-secret-interceptor/internal/awsauth"
+)
+
+// checkSigner signs a digest with s and verifies it against key
+func checkSigner(t *testing.T, s crypto.Signer, key crypto.Signer) {
+	t.Helper()
+	digest := sha256.Sum256([]byte("certificate"))
+	sig, err := s.Sign(rand.Reader, digest[:], crypto.SHA256)
+	if err != nil {
+		t.Fatalf("Sign() error: %v", err)
+	}
+	switch pub := key.Public().(type) {
+	case *rsa.PublicKey:
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig); err != nil {
+			t.Errorf("signature does not verify: %v", err)
+		}
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(pub, digest[:], sig) {
+			t.Error("signature does not verify")
+		}
+	}
+	if !key.Public().(interface{ Equal(crypto.PublicKey) bool }).Equal(s.Public()) {
+		t.Error("Public() does not return the key's public key")
+	}
+	if _, err := s.Sign(rand.Reader, digest[:], crypto.SHA1); err == nil {
+		t.Error("Sign() accepted SHA-1")
+	}
+}
+
+// signDigest signs digest with key as the fake services do
+func signDigest(t *testing.T, key crypto.Signer, digest []byte) []byte {
+	sig, err := key.Sign(rand.Reader, digest, crypto.SHA256)
+	if err != nil {
+		t.Errorf("failed to sign: %v", err)
+	}
+	return sig
+}
+
+func publicKeyPEM(t *testing.T, key crypto.Signer) string {
+	der, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}))
+}
+
+func TestVaultSigner(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("GenerateKey() error: %v", err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/transit/keys/ca":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{
+					"latest_version": 2,
+					"keys": map[string]interface{}{
+						"1": map[string]string{"public_key": "stale"},
+						"2": map[string]string{"public_key": publicKeyPEM(t, key)},
+					},
+				},
+			})
+		case "/v1/transit/sign/ca/sha2-256":
+			var body map[string]interface{}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body["prehashed"] != true || body["signature_algorithm"] != "pkcs1v15" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			digest, _ := base64.StdEncoding.DecodeString(body["input"].(string))
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]string{"signature": "vault:v2:" + base64.StdEncoding.EncodeToString(signDigest(t, key, digest))},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	s, err := NewVaultSigner(context.Background(), server.URL, "token", "", "ca")
+	if err != nil {
+		t.Fatalf("NewVaultSigner() error: %v", err)
+	}
+	checkSigner(t, s, key)
+
+	if _, err := NewVaultSigner(context.Background(), server.URL, "token", "", "missing"); err == nil {
+		t.Error("NewVaultSigner() expected error for missing key")
+	}
+}
+
+func TestAWSSigner(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error: %v", err)
+	}
+	der, _ := x509.MarshalPKIXPublicKey(key.Public())
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			KeyID            string `json:"KeyId"`
+			Message          []byte `json:"Message"`
+			MessageType      string `json:"MessageType"`
+			SigningAlgorithm string `json:"SigningAlgorithm"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		if body.KeyID != "alias/ca" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		switch r.Header.Get("X-Amz-Target") {
+		case "TrentService.GetPublicKey":
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"PublicKey": der, "KeyUsage": "SIGN_VERIFY"})
+		case "TrentService.Sign":
+			if body.MessageType != "DIGEST" || body.SigningAlgorithm != "ECDSA_SHA_256" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"Signature": signDigest(t, key, body.Message)})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	s, err := NewAWSSigner(context.Background(), "eu-central-1", "alias/ca", server.URL, awsauth.Credentials{
+		AccessKeyID:     "AKID",
+		SecretAccessKey: "secret",
+	})
+	if err != nil {
+		t.Fatalf("NewAWSSigner() error: %v", err)
+	}
+	checkSigner(t, s, key)
+}
+
+func TestGCPSigner(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error: %v", err)
+	}
+	const version = "projects/p/locations/l/keyRings/r/cryptoKeys/ca/cryptoKeyVersions/1"
+	algorithm := "EC_SIGN_P256_SHA256"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/" + version + "/publicKey":
+			_ = json.NewEncoder(w).Encode(map[string]string{"pem": publicKeyPEM(t, key), "algorithm": algorithm})
+		case "/v1/" + version + ":asymmetricSign":
+			var body struct {
+				Digest struct {
+					SHA256 []byte `json:"sha256"`
+				} `json:"digest"`
+			}
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"signature": signDigest(t, key, body.Digest.SHA256)})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	s, err := NewGCPSigner(context.Background(), version, server.URL, StaticToken("token"))
+	if err != nil {
+		t.Fatalf("NewGCPSigner() error: %v", err)
+	}
+	checkSigner(t, s, key)
+
+	algorithm = "RSA_SIGN_PSS_2048_SHA256"
+	if _, err := NewGCPSigner(context.Background(), version, server.URL, StaticToken("token")); err == nil {
+		t.Error("NewGCPSigner() expected error for PSS key")
+	}
+	if _, err := NewGCPSigner(context.Background(), "projects/p/cryptoKeys/ca", server.URL, StaticToken("token")); err == nil {
+		t.Error("NewGCPSigner() expected error for key name without version")
+	}
+}
@@ -0,0 +1,100 @@
+package kms
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// VaultProvider wraps data keys with a HashiCorp Vault transit key
+type VaultProvider struct {
+	address string
+	token   string
+	mount   string
+	keyName string
+	client  *http.Client
+}
+
+// NewVaultProvider creates a provider for the transit key keyName mounted at mount
+func NewVaultProvider(address, token, mount, keyName string) (*VaultProvider, error) {
+	if address == "" || keyName == "" {
+		return nil, fmt.Errorf("vault address and key name are required")
+	}
+	if token == "" {
+		return nil, fmt.Errorf("vault token is required")
+	}
+	if mount == "" {
+		mount = "transit"
+	}
+	return &VaultProvider{
+		address: strings.TrimRight(address, "/"),
+		token:   token,
+		mount:   strings.Trim(mount, "/"),
+		keyName: keyName,
+		client:  &http.Client{Timeout: defaultHTTPTimeout},
+	}, nil
+}
+
+// Name returns the provider name
+func (p *VaultProvider) Name() string {
+	return "vault"
+}
+
+// Wrap encrypts a data key with the transit key
+func (p *VaultProvider) Wrap(ctx context.Context, dek []byte) ([]byte, error) {
+	var resp struct {
+		Data struct {
+			Ciphertext string `json:"ciphertext"`
+		} `json:"data"`
+	}
+	if err := p.call(ctx, "encrypt", map[string]string{
+		"plaintext": base64.StdEncoding.EncodeToString(dek),
+	}, &resp); err != nil {
+		return nil, fmt.Errorf("vault encrypt failed: %w", err)
+	}
+	if resp.Data.Ciphertext == "" {
+		return nil, fmt.Errorf("vault encrypt returned no ciphertext")
+	}
+	return []byte(resp.Data.Ciphertext), nil
+}
+
+// Unwrap decrypts a data key with the transit key
+func (p *VaultProvider) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	var resp struct {
+		Data struct {
+			Plaintext string `json:"plaintext"`
+		} `json:"data"`
+	}
+	if err := p.call(ctx, "decrypt", map[string]string{
+		"ciphertext": string(wrapped),
+	}, &resp); err != nil {
+		return nil, fmt.Errorf("vault decrypt failed: %w", err)
+	}
+	dek, err := base64.StdEncoding.DecodeString(resp.Data.Plaintext)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode vault plaintext: %w", err)
+	}
+	return dek, nil
+}
+
+func (p *VaultProvider) call(ctx context.Context, op string, body map[string]string, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	endpoint := fmt.Sprintf("%s/v1/%s/%s/%s", p.address, p.mount, op, url.PathEscape(p.keyName))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Vault-Token", p.token)
+
+	return doJSON(p.client, req, out)
+}
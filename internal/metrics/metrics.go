@@ -2,6 +2,8 @@
 package metrics
 
 import (
+	"runtime"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
@@ -13,11 +15,19 @@ var (
 		Help: "Total number of requests processed by the proxy",
 	}, []string{"method", "host"})
 
-	// SecretsDetectedTotal counts detected secrets by interceptor
+	// SecretsDetectedTotal counts detected secrets by interceptor, type and
+	// the rule within the interceptor that matched
 	SecretsDetectedTotal = promauto.NewCounterVec(prometheus.CounterOpts{
 		Name: "llm_proxy_secrets_detected_total",
 		Help: "Total number of secrets detected",
-	}, []string{"interceptor", "type"})
+	}, []string{"interceptor", "type", "rule"})
+
+	// SecretsDetectedByHost counts detected secrets by destination host and
+	// the interceptor and rule that matched
+	SecretsDetectedByHost = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "llm_proxy_secrets_detected_by_host_total",
+		Help: "Total number of secrets detected in requests by destination host, interceptor and rule",
+	}, []string{"host", "interceptor", "rule"})
 
 	// SecretsReplacedTotal counts replaced secrets
 	SecretsReplacedTotal = promauto.NewCounter(prometheus.CounterOpts{
@@ -25,6 +35,24 @@ var (
 		Help: "Total number of secrets replaced with placeholders",
 	})
 
+	// SecretsRedacted counts secrets replaced irreversibly without a stored mapping
+	SecretsRedacted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "llm_proxy_secrets_redacted_total",
+		Help: "Total number of secrets redacted irreversibly",
+	}, []string{"type"})
+
+	// RequestsBlocked counts requests rejected because they contained a secret
+	RequestsBlocked = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "llm_proxy_requests_blocked_total",
+		Help: "Total number of requests blocked because they contained a secret",
+	}, []string{"type"})
+
+	// ShadowActions counts the actions shadow mode skipped for detected secrets
+	ShadowActions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "llm_proxy_shadow_actions_total",
+		Help: "Total number of secrets shadow mode forwarded unmodified, by the action enforce mode would have taken",
+	}, []string{"action", "type"})
+
 	// MappingStoreSize tracks the size of the mapping store
 	MappingStoreSize = promauto.NewGauge(prometheus.GaugeOpts{
 		Name: "llm_proxy_mapping_store_size",
@@ -50,6 +78,25 @@ var (
 		Help: "Total number of placeholders restored to secrets in responses",
 	})
 
+	// PlaceholdersNotFound counts placeholders in responses without a stored mapping
+	PlaceholdersNotFound = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "llm_proxy_not_found_placeholders_total",
+		Help: "Total number of placeholders in responses that could not be restored (e.g. expired mappings)",
+	})
+
+	// StoreOperations counts mapping store operations by backend, operation, and result
+	StoreOperations = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "llm_proxy_store_operations_total",
+		Help: "Total number of mapping store operations by result (hit, miss, ok, error)",
+	}, []string{"backend", "operation", "result"})
+
+	// StoreOperationDuration tracks mapping store operation latency
+	StoreOperationDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "llm_proxy_store_operation_duration_seconds",
+		Help:    "Mapping store operation latency in seconds",
+		Buckets: []float64{0.00001, 0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1, 0.5},
+	}, []string{"backend", "operation"})
+
 	// ActiveConnections tracks current active connections
 	ActiveConnections = promauto.NewGauge(prometheus.GaugeOpts{
 		Name: "llm_proxy_active_connections",
@@ -81,6 +128,12 @@ var (
 		Buckets: []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1},
 	}, []string{"interceptor"})
 
+	// DetectionSkipped counts interceptor runs skipped due to an exhausted detection budget
+	DetectionSkipped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "llm_proxy_detection_skipped_total",
+		Help: "Total number of interceptor runs skipped because the detection time budget was exhausted",
+	}, []string{"interceptor"})
+
 	// MappingCleanups counts mapping store cleanup operations
 	MappingCleanups = promauto.NewCounter(prometheus.CounterOpts{
 		Name: "llm_proxy_mapping_cleanups_total",
@@ -92,11 +145,163 @@ var (
 		Name: "llm_proxy_mappings_expired_total",
 		Help: "Total number of mappings expired and removed",
 	})
+
+	// PlaceholderCollisions counts placeholders lengthened because they
+	// collided with the placeholder of a different secret
+	PlaceholderCollisions = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "llm_proxy_placeholder_collisions_total",
+		Help: "Total number of placeholder hash collisions resolved by lengthening the hash",
+	})
+
+	// UpstreamRetries counts retried upstream requests by cause
+	UpstreamRetries = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "llm_proxy_upstream_retries_total",
+		Help: "Total number of upstream request retries by cause",
+	}, []string{"cause"})
+
+	// RateLimited counts requests rejected by client rate limits
+	RateLimited = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "llm_proxy_rate_limited_total",
+		Help: "Total number of requests rejected by client rate limits by limit (requests, concurrency)",
+	}, []string{"limit"})
+
+	// QueuedWork tracks tunnels and requests waiting for a concurrency slot
+	QueuedWork = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "llm_proxy_queued",
+		Help: "Current number of tunnels and upstream requests waiting for a concurrency slot",
+	}, []string{"limit"})
+
+	// CapacityRejections counts work rejected because no concurrency slot became free
+	CapacityRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "llm_proxy_capacity_rejections_total",
+		Help: "Total number of tunnels and upstream requests rejected at capacity",
+	}, []string{"limit"})
+
+	// ClientRejections counts requests from clients refused by the access control list
+	ClientRejections = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "llm_proxy_client_rejections_total",
+		Help: "Total number of requests from clients refused by the access control list",
+	}, []string{"reason"})
+
+	// RawFallbacks counts intercepted tunnels relayed unchanged instead
+	RawFallbacks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "llm_proxy_raw_fallbacks_total",
+		Help: "Total number of tunnels relayed without interception by reason (alpn, client_cert, pinning)",
+	}, []string{"reason"})
+
+	// PinningDetected counts hosts detected as pinned by a client
+	PinningDetected = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "llm_proxy_pinning_detected_total",
+		Help: "Total number of detected certificate pinning by host, from repeated handshake aborts of a client",
+	}, []string{"host"})
+
+	// TimeToFirstByte tracks the time from receiving a request to writing the
+	// first byte of its streamed response
+	TimeToFirstByte = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "llm_proxy_stream_time_to_first_byte_seconds",
+		Help:    "Time from receiving a request to writing the first byte of its streamed response",
+		Buckets: []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+	})
+
+	// TimeToFirstRestoredByte tracks the time from receiving a streamed
+	// upstream response to the first restored byte leaving the restore
+	// pipeline, the latency added by holding back text
+	TimeToFirstRestoredByte = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "llm_proxy_stream_time_to_first_restored_byte_seconds",
+		Help:    "Time from receiving a streamed upstream response to writing its first restored byte",
+		Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5},
+	})
+
+	// StreamHoldBackDuration tracks how long streamed text was held back for
+	// a placeholder that may continue in a later chunk
+	StreamHoldBackDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "llm_proxy_stream_hold_back_duration_seconds",
+		Help:    "Time streamed text was held back for a possibly split placeholder before it was released",
+		Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5},
+	})
+
+	// StreamChunks tracks the number of chunks read per streamed response
+	StreamChunks = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "llm_proxy_stream_chunks",
+		Help:    "Number of chunks read from upstream per streamed response",
+		Buckets: prometheus.ExponentialBuckets(1, 4, 8),
+	})
+
+	// StreamsAborted counts streamed responses aborted before their end
+	StreamsAborted = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "llm_proxy_streams_aborted_total",
+		Help: "Total number of streamed responses aborted mid-flight by reason (decode, restore, upstream, client)",
+	}, []string{"reason"})
+
+	// FailureDecisions counts processing failures by stage and the failure
+	// mode decision taken
+	FailureDecisions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "llm_proxy_failure_decisions_total",
+		Help: "Total number of processing failures by stage and decision (open: passed through, closed: rejected)",
+	}, []string{"stage", "decision"})
+
+	// MappingsEvicted counts mappings evicted because the store reached its size limit
+	MappingsEvicted = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "llm_proxy_mappings_evicted_total",
+		Help: "Total number of least recently used mappings evicted due to the store size limit",
+	})
+
+	// CertCacheSize tracks the number of cached host certificates
+	CertCacheSize = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "llm_proxy_cert_cache_size",
+		Help: "Current number of cached host certificates",
+	})
+
+	// CertCacheLookups counts host certificate cache lookups by result
+	CertCacheLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "llm_proxy_cert_cache_lookups_total",
+		Help: "Total number of host certificate cache lookups by result (hit, miss, expiring, disk_hit)",
+	}, []string{"result"})
+
+	// CertCacheEvictions counts certificates evicted because the cache was full
+	CertCacheEvictions = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "llm_proxy_cert_cache_evictions_total",
+		Help: "Total number of least recently used host certificates evicted due to the cache size limit",
+	})
+
+	// CertGenerationDuration tracks the latency of generating host certificates
+	CertGenerationDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "llm_proxy_cert_generation_duration_seconds",
+		Help:    "Host certificate generation latency in seconds",
+		Buckets: []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1},
+	})
+
+	// FalsePositivesReported counts detections reported as false positives
+	// by the interceptor and rule that matched, for tuning rules
+	FalsePositivesReported = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "llm_proxy_false_positives_reported_total",
+		Help: "Total number of detections reported as false positives by interceptor, rule and secret type",
+	}, []string{"interceptor", "rule", "type"})
+
+	// AuditEventsDropped counts audit events dropped by sampling or rate limiting
+	AuditEventsDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "llm_proxy_audit_events_dropped_total",
+		Help: "Total number of audit events not logged by event type and reason (sampled, rate_limited)",
+	}, []string{"type", "reason"})
+
+	// AuditOutputDropped counts audit events an output dropped because it
+	// could not keep up or its destination was unavailable
+	AuditOutputDropped = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "llm_proxy_audit_output_dropped_total",
+		Help: "Total number of audit events dropped by an output that could not deliver them",
+	}, []string{"output"})
+
+	// BuildInfo is always 1, labeled with the build the instance runs
+	BuildInfo = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "llm_proxy_build_info",
+		Help: "Build information of the running proxy, always 1",
+	}, []string{"version", "commit", "go_version"})
 )
 
-// RecordSecretDetected records a detected secret
-func RecordSecretDetected(interceptor, secretType string) {
-	SecretsDetectedTotal.WithLabelValues(interceptor, secretType).Inc()
+// RecordSecretDetected records a secret detected in a request to host
+func RecordSecretDetected(host, interceptor, secretType, rule string) {
+	SecretsDetectedTotal.WithLabelValues(interceptor, secretType, rule).Inc()
+	SecretsDetectedByHost.WithLabelValues(host, interceptor, rule).Inc()
 }
 
 // RecordRequestDuration records request processing duration
@@ -128,3 +333,135 @@ func RecordBytesTransferred(direction string, bytes int64) {
 func RecordInterceptorDuration(interceptor string, seconds float64) {
 	InterceptorDuration.WithLabelValues(interceptor).Observe(seconds)
 }
+
+// RecordDetectionSkipped records an interceptor run skipped due to the detection budget
+func RecordDetectionSkipped(interceptor string) {
+	DetectionSkipped.WithLabelValues(interceptor).Inc()
+}
+
+// RecordSecretRedacted records a secret redacted irreversibly
+func RecordSecretRedacted(secretType string) {
+	SecretsRedacted.WithLabelValues(secretType).Inc()
+}
+
+// RecordRequestBlocked records a request blocked for containing a secret
+func RecordRequestBlocked(secretType string) {
+	RequestsBlocked.WithLabelValues(secretType).Inc()
+}
+
+// RecordShadowAction records a secret forwarded unmodified in shadow mode
+func RecordShadowAction(action, secretType string) {
+	ShadowActions.WithLabelValues(action, secretType).Inc()
+}
+
+// RecordMappingEvicted records a mapping evicted due to the store size limit
+func RecordMappingEvicted() {
+	MappingsEvicted.Inc()
+}
+
+// RecordCertCacheLookup records a host certificate cache lookup
+func RecordCertCacheLookup(result string) {
+	CertCacheLookups.WithLabelValues(result).Inc()
+}
+
+// RecordCertGeneration records the latency of generating a host certificate
+func RecordCertGeneration(seconds float64) {
+	CertGenerationDuration.Observe(seconds)
+}
+
+// RecordPlaceholderCollision records a placeholder collision
+func RecordPlaceholderCollision() {
+	PlaceholderCollisions.Inc()
+}
+
+// RecordUpstreamRetry records a retried upstream request
+func RecordUpstreamRetry(cause string) {
+	UpstreamRetries.WithLabelValues(cause).Inc()
+}
+
+// RecordRateLimited records a request rejected by a client rate limit
+func RecordRateLimited(limit string) {
+	RateLimited.WithLabelValues(limit).Inc()
+}
+
+// RecordCapacityRejection records work rejected at a concurrency limit
+func RecordCapacityRejection(limit string) {
+	CapacityRejections.WithLabelValues(limit).Inc()
+}
+
+// RecordClientRejected records a request from a client refused by the ACL
+func RecordClientRejected(reason string) {
+	ClientRejections.WithLabelValues(reason).Inc()
+}
+
+// RecordRawFallback records a tunnel relayed without interception
+func RecordRawFallback(reason string) {
+	RawFallbacks.WithLabelValues(reason).Inc()
+}
+
+// RecordPinningDetected records a client detected pinning the certificate of host
+func RecordPinningDetected(host string) {
+	PinningDetected.WithLabelValues(host).Inc()
+}
+
+// RecordTimeToFirstByte records the time to the first byte of a streamed response
+func RecordTimeToFirstByte(seconds float64) {
+	TimeToFirstByte.Observe(seconds)
+}
+
+// RecordTimeToFirstRestoredByte records the time to the first restored byte
+// of a streamed response
+func RecordTimeToFirstRestoredByte(seconds float64) {
+	TimeToFirstRestoredByte.Observe(seconds)
+}
+
+// RecordStreamHoldBack records how long streamed text was held back
+func RecordStreamHoldBack(seconds float64) {
+	StreamHoldBackDuration.Observe(seconds)
+}
+
+// RecordStreamChunks records the number of chunks of a streamed response
+func RecordStreamChunks(chunks int) {
+	StreamChunks.Observe(float64(chunks))
+}
+
+// RecordStreamAborted records a streamed response aborted mid-flight
+func RecordStreamAborted(reason string) {
+	StreamsAborted.WithLabelValues(reason).Inc()
+}
+
+// RecordFailureDecision records a processing failure and the decision taken
+func RecordFailureDecision(stage, decision string) {
+	FailureDecisions.WithLabelValues(stage, decision).Inc()
+}
+
+// RecordPlaceholderNotFound records a placeholder that could not be restored
+func RecordPlaceholderNotFound() {
+	PlaceholdersNotFound.Inc()
+}
+
+// RecordStoreOperation records the result and latency of a mapping store operation
+func RecordStoreOperation(backend, operation, result string, seconds float64) {
+	StoreOperations.WithLabelValues(backend, operation, result).Inc()
+	StoreOperationDuration.WithLabelValues(backend, operation).Observe(seconds)
+}
+
+// RecordAuditEventDropped records an audit event dropped by sampling or rate limiting
+func RecordAuditEventDropped(eventType, reason string) {
+	AuditEventsDropped.WithLabelValues(eventType, reason).Inc()
+}
+
+// RecordAuditOutputDropped records an audit event dropped by output
+func RecordAuditOutputDropped(output string) {
+	AuditOutputDropped.WithLabelValues(output).Inc()
+}
+
+// RecordFalsePositive records a detection reported as false positive
+func RecordFalsePositive(interceptor, rule, secretType string) {
+	FalsePositivesReported.WithLabelValues(interceptor, rule, secretType).Inc()
+}
+
+// RecordBuildInfo records the version and commit the proxy was built from
+func RecordBuildInfo(version, commit string) {
+	BuildInfo.WithLabelValues(version, commit, runtime.Version()).Set(1)
+}
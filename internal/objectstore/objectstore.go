@@ -0,0 +1,147 @@
+// Package objectstore uploads objects to S3 and Google Cloud Storage over
+// their HTTP APIs, authenticated like the key management services: AWS
+// Signature Version 4 for S3 and OAuth2 bearer tokens for GCS.
+package objectstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/awsauth"
+	"github.com/hfi/llm-secret-interceptor/internal/kms"
+)
+
+// Uploader stores objects in a bucket
+type Uploader interface {
+	// Put stores body under key, replacing an existing object
+	Put(ctx context.Context, key, contentType string, body []byte) error
+}
+
+// defaultHTTPTimeout bounds a single upload
+const defaultHTTPTimeout = 60 * time.Second
+
+// maxErrorBody limits how much of an error response is included in errors
+const maxErrorBody = 512
+
+// S3 uploads objects to an S3 bucket
+type S3 struct {
+	bucket   string
+	endpoint string
+	signer   *awsauth.Signer
+	client   *http.Client
+}
+
+// NewS3 creates an uploader for bucket in region. endpoint overrides the
+// regional endpoint, e.g. for VPC endpoints or S3-compatible stores, and
+// switches to path-style addressing.
+func NewS3(bucket, region, endpoint string, creds awsauth.Credentials) (*S3, error) {
+	if bucket == "" || region == "" {
+		return nil, fmt.Errorf("S3 bucket and region are required")
+	}
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://%s.s3.%s.amazonaws.com", bucket, region)
+	} else {
+		endpoint = strings.TrimRight(endpoint, "/") + "/" + url.PathEscape(bucket)
+	}
+	return &S3{
+		bucket:   bucket,
+		endpoint: endpoint,
+		signer:   awsauth.NewSigner(creds, region, "s3"),
+		client:   &http.Client{Timeout: defaultHTTPTimeout},
+	}, nil
+}
+
+// Put stores body under key with a PutObject request
+func (s *S3) Put(ctx context.Context, key, contentType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.endpoint+"/"+escapeKey(key), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	sum := sha256.Sum256(body)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(sum[:]))
+	if err := s.signer.Sign(req, body); err != nil {
+		return err
+	}
+	if err := do(s.client, req); err != nil {
+		return fmt.Errorf("S3 upload of %s failed: %w", key, err)
+	}
+	return nil
+}
+
+// GCS uploads objects to a Google Cloud Storage bucket
+type GCS struct {
+	bucket   string
+	endpoint string
+	tokens   kms.TokenSource
+	client   *http.Client
+}
+
+// NewGCS creates an uploader for bucket. endpoint overrides the Cloud Storage
+// XML API endpoint if non-empty.
+func NewGCS(bucket, endpoint string, tokens kms.TokenSource) (*GCS, error) {
+	if bucket == "" {
+		return nil, fmt.Errorf("GCS bucket is required")
+	}
+	if endpoint == "" {
+		endpoint = "https://storage.googleapis.com"
+	}
+	return &GCS{
+		bucket:   bucket,
+		endpoint: strings.TrimRight(endpoint, "/") + "/" + url.PathEscape(bucket),
+		tokens:   tokens,
+		client:   &http.Client{Timeout: defaultHTTPTimeout},
+	}, nil
+}
+
+// Put stores body under key with an XML API upload
+func (g *GCS) Put(ctx context.Context, key, contentType string, body []byte) error {
+	token, err := g.tokens.Token(ctx)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, g.endpoint+"/"+escapeKey(key), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+token)
+	if err := do(g.client, req); err != nil {
+		return fmt.Errorf("GCS upload of %s failed: %w", key, err)
+	}
+	return nil
+}
+
+// escapeKey escapes the segments of an object key, keeping its slashes
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// do sends req, failing on non-2xx responses
+func do(client *http.Client, req *http.Request) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, body)
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
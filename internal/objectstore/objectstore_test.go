@@ -0,0 +1,72 @@
+package objectstore
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hfi/llm-secret-interceptor/internal/awsauth"
+	"github.com/hfi/llm-secret-interceptor/internal/kms"
+)
+
+// objectServer records uploads, requiring the Authorization prefix auth
+func objectServer(t *testing.T, auth string, objects map[string]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPut || !strings.HasPrefix(r.Header.Get("Authorization"), auth) {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = io.WriteString(w, "AccessDenied")
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		objects[r.URL.EscapedPath()] = r.Header.Get("Content-Type") + " " + string(body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestS3(t *testing.T) {
+	objects := map[string]string{}
+	server := objectServer(t, "AWS4-HMAC-SHA256 Credential=AKID/", objects)
+
+	s3, err := NewS3("audit", "eu-central-1", server.URL, awsauth.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"})
+	if err != nil {
+		t.Fatalf("NewS3() error: %v", err)
+	}
+	if err := s3.Put(t.Context(), "logs/2026/a b.ndjson.gz", "application/gzip", []byte("data")); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if got := objects["/audit/logs/2026/a%20b.ndjson.gz"]; got != "application/gzip data" {
+		t.Errorf("objects = %v", objects)
+	}
+
+	denied, _ := NewS3("audit", "eu-central-1", server.URL, awsauth.Credentials{AccessKeyID: "OTHER", SecretAccessKey: "secret"})
+	if err := denied.Put(t.Context(), "key", "text/plain", nil); err == nil || !strings.Contains(err.Error(), "AccessDenied") {
+		t.Errorf("Put() with wrong credentials error = %v", err)
+	}
+
+	if _, err := NewS3("", "eu-central-1", "", awsauth.Credentials{}); err == nil {
+		t.Error("NewS3() without bucket succeeded")
+	}
+}
+
+func TestGCS(t *testing.T) {
+	objects := map[string]string{}
+	server := objectServer(t, "Bearer token", objects)
+
+	gcs, err := NewGCS("audit", server.URL, kms.StaticToken("token"))
+	if err != nil {
+		t.Fatalf("NewGCS() error: %v", err)
+	}
+	if err := gcs.Put(t.Context(), "logs/a.ndjson.gz", "application/gzip", []byte("data")); err != nil {
+		t.Fatalf("Put() error: %v", err)
+	}
+	if got := objects["/audit/logs/a.ndjson.gz"]; got != "application/gzip data" {
+		t.Errorf("objects = %v", objects)
+	}
+
+	if _, err := NewGCS("", "", kms.StaticToken("token")); err == nil {
+		t.Error("NewGCS() without bucket succeeded")
+	}
+}
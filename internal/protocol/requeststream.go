@@ -0,0 +1,152 @@
+package protocol
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// MessageStreamer is implemented by handlers that can rewrite request
+// messages while the body is read, holding only one message in memory
+type MessageStreamer interface {
+	// StreamRequest copies a request body from r to w, replacing the content
+	// of each message with the result of transform
+	StreamRequest(r io.Reader, w io.Writer, transform func(Message) (string, error)) error
+}
+
+// StreamRequest copies an OpenAI request from r to w message by message.
+// Fields other than messages are copied unchanged; messages are only
+// re-encoded when transform changes their content.
+func (h *OpenAIHandler) StreamRequest(r io.Reader, w io.Writer, transform func(Message) (string, error)) error {
+	dec := json.NewDecoder(r)
+	bw := bufio.NewWriter(w)
+
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	if err := bw.WriteByte('{'); err != nil {
+		return err
+	}
+	for first := true; dec.More(); first = false {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		key, ok := tok.(string)
+		if !ok {
+			return fmt.Errorf("unexpected token %v", tok)
+		}
+		keyJSON, err := json.Marshal(key)
+		if err != nil {
+			return err
+		}
+		if !first {
+			keyJSON = append([]byte{','}, keyJSON...)
+		}
+		if _, err := bw.Write(append(keyJSON, ':')); err != nil {
+			return err
+		}
+
+		if key == "messages" {
+			err = streamMessages(dec, bw, transform)
+		} else {
+			var raw json.RawMessage
+			if err = dec.Decode(&raw); err == nil {
+				_, err = bw.Write(raw)
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := expectDelim(dec, '}'); err != nil {
+		return err
+	}
+	if err := bw.WriteByte('}'); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// streamMessages copies the messages array, flushing after every message
+func streamMessages(dec *json.Decoder, bw *bufio.Writer, transform func(Message) (string, error)) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok == nil {
+		_, err := bw.WriteString("null")
+		return err
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return fmt.Errorf("messages: expected array, got %v", tok)
+	}
+	if err := bw.WriteByte('['); err != nil {
+		return err
+	}
+
+	for first := true; dec.More(); first = false {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			return err
+		}
+		var m openAIMessage
+		if err := json.Unmarshal(raw, &m); err != nil {
+			return fmt.Errorf("messages: %w", err)
+		}
+		content := m.getContentString()
+		replaced, err := transform(Message{Role: m.Role, Content: content})
+		if err != nil {
+			return err
+		}
+		if replaced != content {
+			if raw, err = replaceContent(raw, replaced); err != nil {
+				return err
+			}
+		}
+
+		if !first {
+			if err := bw.WriteByte(','); err != nil {
+				return err
+			}
+		}
+		if _, err := bw.Write(raw); err != nil {
+			return err
+		}
+		if err := bw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if err := expectDelim(dec, ']'); err != nil {
+		return err
+	}
+	return bw.WriteByte(']')
+}
+
+// replaceContent sets the content of a raw message, keeping all other fields
+func replaceContent(raw json.RawMessage, content string) (json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return nil, err
+	}
+	fields["content"] = data
+	return json.Marshal(fields)
+}
+
+// expectDelim reads the next token and checks that it is delim
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if d, ok := tok.(json.Delim); !ok || d != delim {
+		return fmt.Errorf("expected %v, got %v", delim, tok)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package protocol
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestOpenAIHandler_StreamRequest(t *testing.T) {
+	h := NewOpenAIHandler()
+	body := `{"model":"gpt-4","messages":[{"role":"system","content":"be brief"},{"role":"user","content":"my key is SECRET","name":"bob"}],"temperature":0.5,"tools":[{"type":"function"}]}`
+
+	var out bytes.Buffer
+	var seen []Message
+	err := h.StreamRequest(strings.NewReader(body), &out, func(m Message) (string, error) {
+		seen = append(seen, m)
+		return strings.ReplaceAll(m.Content, "SECRET", "[MASKED]"), nil
+	})
+	if err != nil {
+		t.Fatalf("StreamRequest() error: %v", err)
+	}
+
+	if len(seen) != 2 || seen[1].Role != "user" || seen[1].Content != "my key is SECRET" {
+		t.Errorf("transform saw %+v", seen)
+	}
+	var got struct {
+		Model       string                       `json:"model"`
+		Messages    []map[string]json.RawMessage `json:"messages"`
+		Temperature float64                      `json:"temperature"`
+		Tools       json.RawMessage              `json:"tools"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, out.String())
+	}
+	if got.Model != "gpt-4" || got.Temperature != 0.5 || string(got.Tools) != `[{"type":"function"}]` {
+		t.Errorf("other fields not preserved: %s", out.String())
+	}
+	if string(got.Messages[1]["content"]) != `"my key is [MASKED]"` || string(got.Messages[1]["name"]) != `"bob"` {
+		t.Errorf("message = %v", got.Messages[1])
+	}
+	// Unchanged messages are copied verbatim
+	if !strings.Contains(out.String(), `{"role":"system","content":"be brief"}`) {
+		t.Errorf("unchanged message was re-encoded: %s", out.String())
+	}
+}
+
+func TestOpenAIHandler_StreamRequest_Errors(t *testing.T) {
+	h := NewOpenAIHandler()
+	identity := func(m Message) (string, error) { return m.Content, nil }
+
+	for _, body := range []string{`[]`, `{"messages":"text"}`, `{"messages":[{"role":"user"`} {
+		if err := h.StreamRequest(strings.NewReader(body), &bytes.Buffer{}, identity); err == nil {
+			t.Errorf("StreamRequest(%s) should fail", body)
+		}
+	}
+
+	errAbort := errors.New("abort")
+	err := h.StreamRequest(strings.NewReader(`{"messages":[{"role":"user","content":"x"}]}`), &bytes.Buffer{}, func(Message) (string, error) {
+		return "", errAbort
+	})
+	if !errors.Is(err, errAbort) {
+		t.Errorf("StreamRequest() error = %v, want transform error", err)
+	}
+}
@@ -39,6 +39,12 @@ type StreamingHandler interface {
 	SerializeStreamChunk(chunk *StreamChunk) ([]byte, error)
 }
 
+// StreamDeltaRewriter is implemented by streaming handlers that can replace
+// the delta content of a raw chunk, keeping all of its other fields
+type StreamDeltaRewriter interface {
+	RewriteStreamDelta(data []byte, delta string) ([]byte, error)
+}
+
 // SSEParser parses Server-Sent Events format
 type SSEParser struct {
 	reader *bufio.Reader
@@ -243,6 +249,39 @@ func (h *OpenAIHandler) SerializeStreamChunk(chunk *StreamChunk) ([]byte, error)
 	return json.Marshal(streamChunk)
 }
 
+// Ensure OpenAIHandler implements StreamDeltaRewriter
+var _ StreamDeltaRewriter = (*OpenAIHandler)(nil)
+
+// RewriteStreamDelta sets the delta content of the first choice of an OpenAI
+// streaming chunk. Other fields, such as tool calls and usage, are kept.
+func (h *OpenAIHandler) RewriteStreamDelta(data []byte, delta string) ([]byte, error) {
+	var chunk map[string]json.RawMessage
+	if err := json.Unmarshal(data, &chunk); err != nil {
+		return nil, fmt.Errorf("failed to parse stream chunk: %w", err)
+	}
+	var choices []map[string]json.RawMessage
+	if err := json.Unmarshal(chunk["choices"], &choices); err != nil || len(choices) == 0 {
+		return nil, fmt.Errorf("stream chunk has no choices")
+	}
+	var deltaFields map[string]json.RawMessage
+	if err := json.Unmarshal(choices[0]["delta"], &deltaFields); err != nil || deltaFields == nil {
+		return nil, fmt.Errorf("stream chunk has no delta")
+	}
+
+	content, err := json.Marshal(delta)
+	if err != nil {
+		return nil, err
+	}
+	deltaFields["content"] = content
+	if choices[0]["delta"], err = json.Marshal(deltaFields); err != nil {
+		return nil, err
+	}
+	if chunk["choices"], err = json.Marshal(choices); err != nil {
+		return nil, err
+	}
+	return json.Marshal(chunk)
+}
+
 // StreamBuffer handles buffering for placeholder detection in streams
 type StreamBuffer struct {
 	buffer    []byte
@@ -165,6 +165,34 @@ func TestOpenAIHandler_ParseStreamChunk(t *testing.T) {
 	}
 }
 
+func TestOpenAIHandler_RewriteStreamDelta(t *testing.T) {
+	h := NewOpenAIHandler()
+	data := `{"id":"chatcmpl-123","system_fingerprint":"fp_1","choices":[{"index":0,"delta":{"content":"Hel","role":"assistant"},"logprobs":null}]}`
+
+	out, err := h.RewriteStreamDelta([]byte(data), "Hello \"world\"")
+	if err != nil {
+		t.Fatalf("RewriteStreamDelta() error: %v", err)
+	}
+	chunk, err := h.ParseStreamChunk(out)
+	if err != nil {
+		t.Fatalf("rewritten chunk does not parse: %v", err)
+	}
+	if chunk.Delta != `Hello "world"` || chunk.Role != "assistant" {
+		t.Errorf("rewritten chunk = %+v", chunk)
+	}
+	for _, field := range []string{`"system_fingerprint":"fp_1"`, `"logprobs":null`} {
+		if !strings.Contains(string(out), field) {
+			t.Errorf("rewritten chunk lost %s: %s", field, out)
+		}
+	}
+
+	for _, data := range []string{`[DONE]`, `{"choices":[]}`, `{"choices":[{"index":0}]}`} {
+		if _, err := h.RewriteStreamDelta([]byte(data), "x"); err == nil {
+			t.Errorf("RewriteStreamDelta(%s) should fail", data)
+		}
+	}
+}
+
 func TestStreamBuffer(t *testing.T) {
 	buf := NewStreamBuffer(10)
 
@@ -0,0 +1,97 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/netip"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/metrics"
+)
+
+// clientACL decides which client addresses may use the proxy
+type clientACL struct {
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+// newClientACL parses the configured lists, returning nil when both are empty
+func newClientACL(cfg config.ACLConfig) (*clientACL, error) {
+	if len(cfg.Allow) == 0 && len(cfg.Deny) == 0 {
+		return nil, nil
+	}
+	allow, err := parsePrefixes("allow", cfg.Allow)
+	if err != nil {
+		return nil, err
+	}
+	deny, err := parsePrefixes("deny", cfg.Deny)
+	if err != nil {
+		return nil, err
+	}
+	return &clientACL{allow: allow, deny: deny}, nil
+}
+
+// parsePrefixes parses CIDR ranges; single addresses match only themselves
+func parsePrefixes(name string, entries []string) ([]netip.Prefix, error) {
+	prefixes := make([]netip.Prefix, 0, len(entries))
+	for _, entry := range entries {
+		prefix, err := netip.ParsePrefix(entry)
+		if err != nil {
+			addr, addrErr := netip.ParseAddr(entry)
+			if addrErr != nil {
+				return nil, fmt.Errorf("invalid acl %s entry %q: %w", name, entry, err)
+			}
+			prefix = netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen())
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// check returns why addr is rejected, or an empty string if it is admitted.
+// Deny entries take precedence; a non-empty allow list admits only its ranges.
+func (a *clientACL) check(addr netip.Addr) string {
+	addr = addr.Unmap()
+	if containsAddr(a.deny, addr) {
+		return "denied"
+	}
+	if len(a.allow) > 0 && !containsAddr(a.allow, addr) {
+		return "not_allowed"
+	}
+	return ""
+}
+
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// admitClient applies the access control list to the client sending r,
+// answering rejected clients with 403 and recording an audit event
+func (s *Server) admitClient(w http.ResponseWriter, r *http.Request) bool {
+	s.mu.RLock()
+	acl := s.acl
+	s.mu.RUnlock()
+	if acl == nil || fromUnixSocket(r) {
+		return true
+	}
+
+	ip := clientIP(r)
+	reason := "invalid_address"
+	if addr, err := netip.ParseAddr(ip); err == nil {
+		reason = acl.check(addr)
+	}
+	if reason == "" {
+		return true
+	}
+
+	s.logger.Warn().Str("client", ip).Str("host", r.Host).Str("reason", reason).Msg("Rejected client")
+	metrics.RecordClientRejected(reason)
+	s.auditor().LogClientRejected(ip, r.Host, reason)
+	http.Error(w, "Forbidden", http.StatusForbidden)
+	return false
+}
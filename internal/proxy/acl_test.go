@@ -0,0 +1,154 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/hfi/llm-secret-interceptor/internal/audit"
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/rs/zerolog"
+)
+
+func TestNewClientACL(t *testing.T) {
+	if acl, err := newClientACL(config.ACLConfig{}); err != nil || acl != nil {
+		t.Errorf("newClientACL() without entries = %v, %v; want nil", acl, err)
+	}
+	for _, cfg := range []config.ACLConfig{
+		{Allow: []string{"10.0.0.0/33"}},
+		{Deny: []string{"localhost"}},
+	} {
+		if _, err := newClientACL(cfg); err == nil {
+			t.Errorf("newClientACL(%+v) should fail", cfg)
+		}
+	}
+}
+
+func TestClientACL_Check(t *testing.T) {
+	acl, err := newClientACL(config.ACLConfig{
+		Allow: []string{"10.0.0.0/8", "192.168.1.20", "2001:db8::/32"},
+		Deny:  []string{"10.0.5.0/24"},
+	})
+	if err != nil {
+		t.Fatalf("newClientACL() error: %v", err)
+	}
+
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{"10.1.2.3", ""},
+		{"::ffff:10.1.2.3", ""},
+		{"192.168.1.20", ""},
+		{"2001:db8::1", ""},
+		{"10.0.5.7", "denied"},
+		{"192.168.1.21", "not_allowed"},
+		{"8.8.8.8", "not_allowed"},
+	}
+	for _, tt := range tests {
+		if got := acl.check(netip.MustParseAddr(tt.addr)); got != tt.want {
+			t.Errorf("check(%s) = %q, want %q", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestServeHTTP_RejectsClient(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Proxy.ACL.Deny = []string{"192.0.2.0/24"}
+	acl, err := newClientACL(cfg.Proxy.ACL)
+	if err != nil {
+		t.Fatalf("newClientACL() error: %v", err)
+	}
+	recorder := &recordingAudit{}
+	s := &Server{config: cfg, acl: acl, audit: recorder, logger: zerolog.Nop()}
+
+	req := httptest.NewRequest(http.MethodConnect, "api.openai.com:443", nil)
+	req.RemoteAddr = "192.0.2.1:51234"
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, req)
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("status = %d, want 403", rec.Code)
+	}
+	if len(recorder.rejected) != 1 || recorder.rejected[0] != "192.0.2.1 api.openai.com:443 denied" {
+		t.Errorf("audit events = %v", recorder.rejected)
+	}
+}
+
+// recordingAudit records audit events for tests
+type recordingAudit struct {
+	mu       sync.Mutex
+	rejected []string
+	errors   []string
+	pinning  []string
+	purged   []string
+	admin    []string
+	// pipeline records the events of intercepted requests with their request IDs
+	pipeline []string
+}
+
+func (a *recordingAudit) record(events *[]string, event string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	*events = append(*events, event)
+}
+
+// events returns a copy of events, which may still be recorded concurrently
+func (a *recordingAudit) events(events *[]string) []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return slices.Clone(*events)
+}
+
+func (a *recordingAudit) LogSecretDetected(requestID, interceptor, secretType string) {
+	a.record(&a.pipeline, fmt.Sprintf("detected %s %s/%s", requestID, interceptor, secretType))
+}
+
+func (a *recordingAudit) LogSecretReplaced(requestID string, count int) {
+	a.record(&a.pipeline, fmt.Sprintf("replaced %s %d", requestID, count))
+}
+
+func (a *recordingAudit) LogPlaceholderRestored(requestID string, count int) {
+	a.record(&a.pipeline, fmt.Sprintf("restored %s %d", requestID, count))
+}
+
+func (a *recordingAudit) LogMappingCreated(_ string, origin audit.Origin) {
+	a.record(&a.pipeline, fmt.Sprintf("mapping %s %s", origin.RequestID, origin.SecretType))
+}
+
+func (a *recordingAudit) LogRequestProcessed(requestID, method, _, _ string, _ float64) {
+	a.record(&a.pipeline, fmt.Sprintf("request %s %s", requestID, method))
+}
+
+func (a *recordingAudit) LogResponseProcessed(requestID, _ string, _ float64) {
+	a.record(&a.pipeline, "response "+requestID)
+}
+
+func (a *recordingAudit) LogClientRejected(clientIP, host, reason string) {
+	a.record(&a.rejected, clientIP+" "+host+" "+reason)
+}
+
+func (a *recordingAudit) LogError(eventType audit.EventType, requestID, host, errorMsg string) {
+	a.record(&a.errors, string(eventType)+" "+host+" "+errorMsg)
+	if requestID != "" {
+		a.record(&a.pipeline, fmt.Sprintf("%s %s", eventType, requestID))
+	}
+}
+
+func (a *recordingAudit) LogPinningDetected(clientIP, host string, aborts int, bypassed bool) {
+	a.record(&a.pinning, fmt.Sprintf("%s %s %d %t", clientIP, host, aborts, bypassed))
+}
+
+func (a *recordingAudit) LogMappingsPurged(count int, criteria map[string]string) {
+	a.record(&a.purged, fmt.Sprintf("%d %v", count, criteria))
+}
+
+func (a *recordingAudit) LogAdminAction(action audit.AdminAction) {
+	a.record(&a.admin, fmt.Sprintf("%s %s %s %s", action.Principal, action.Role, action.Action, action.Result))
+}
+
+func (a *recordingAudit) Close() error { return nil }
@@ -0,0 +1,168 @@
+package proxy
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/hfi/llm-secret-interceptor/internal/audit"
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+)
+
+// adminTokenEnv holds the admin API token when none is configured
+const adminTokenEnv = "LLM_PROXY_ADMIN_TOKEN"
+
+// Roles of admin API callers
+const (
+	// RoleViewer may read the admin API
+	RoleViewer = "viewer"
+	// RoleOperator may also change the proxy through it
+	RoleOperator = "operator"
+)
+
+// adminPrincipal is an authenticated admin API caller
+type adminPrincipal struct {
+	Name string
+	Role string
+}
+
+// allows reports whether the principal may make a request, which changes
+// the proxy if write is set
+func (p adminPrincipal) allows(write bool) bool {
+	return !write || p.Role == RoleOperator
+}
+
+// validateAdminAccess checks the tokens and clients of the admin API
+func validateAdminAccess(cfg config.AdminConfig) error {
+	validRole := func(role string) bool { return role == RoleViewer || role == RoleOperator }
+	names := make(map[string]bool)
+	for i, token := range cfg.Tokens {
+		switch {
+		case token.Name == "":
+			return fmt.Errorf("metrics.admin.tokens[%d]: name is required", i)
+		case names[token.Name]:
+			return fmt.Errorf("metrics.admin.tokens[%d]: duplicate name %q", i, token.Name)
+		case token.Token == "":
+			return fmt.Errorf("metrics.admin.tokens[%d]: token is required", i)
+		case !validRole(token.Role):
+			return fmt.Errorf("metrics.admin.tokens[%d]: role must be viewer or operator, got %q", i, token.Role)
+		}
+		names[token.Name] = true
+	}
+	for i, client := range cfg.Clients {
+		if client.CommonName == "" {
+			return fmt.Errorf("metrics.admin.clients[%d]: common_name is required", i)
+		}
+		if !validRole(client.Role) {
+			return fmt.Errorf("metrics.admin.clients[%d]: role must be viewer or operator, got %q", i, client.Role)
+		}
+	}
+	return nil
+}
+
+// adminToken returns the operator bearer token of the admin API, or "" if
+// none is set
+func (s *Server) adminToken() string {
+	if token := s.cfg().Metrics.Admin.Token; token != "" {
+		return token
+	}
+	return os.Getenv(adminTokenEnv)
+}
+
+// adminEnabled reports whether any admin API credentials are configured
+func (s *Server) adminEnabled() bool {
+	admin := s.cfg().Metrics.Admin
+	return s.adminToken() != "" || len(admin.Tokens) > 0 || len(admin.Clients) > 0
+}
+
+// bearerAuthorized reports whether an Authorization value presents token
+// as bearer token
+func bearerAuthorized(authorization, token string) bool {
+	presented, ok := strings.CutPrefix(authorization, "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(presented), []byte(token)) == 1
+}
+
+// authenticateAdmin identifies an admin API caller by the bearer token in
+// authorization or the verified client certificate of state
+func (s *Server) authenticateAdmin(authorization string, state *tls.ConnectionState) (adminPrincipal, bool) {
+	if token := s.adminToken(); token != "" && bearerAuthorized(authorization, token) {
+		return adminPrincipal{Name: "admin", Role: RoleOperator}, true
+	}
+	admin := s.cfg().Metrics.Admin
+	for _, token := range admin.Tokens {
+		if bearerAuthorized(authorization, token.Token) {
+			return adminPrincipal{Name: token.Name, Role: token.Role}, true
+		}
+	}
+	if state != nil && len(state.VerifiedChains) > 0 && len(state.VerifiedChains[0]) > 0 {
+		commonName := state.VerifiedChains[0][0].Subject.CommonName
+		for _, client := range admin.Clients {
+			if client.CommonName == commonName {
+				return adminPrincipal{Name: "cn:" + commonName, Role: client.Role}, true
+			}
+		}
+	}
+	return adminPrincipal{}, false
+}
+
+// RequireAdmin serves next only for authenticated admin API callers; only
+// operators may make requests other than GET and HEAD. Refused requests and
+// changes are audited. Without configured credentials all requests are
+// refused.
+func (s *Server) RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.adminEnabled() {
+			http.Error(w, "admin API disabled: no token configured", http.StatusForbidden)
+			return
+		}
+		action := audit.AdminAction{ClientIP: clientIP(r), Action: r.Method + " " + r.URL.Path}
+		principal, ok := s.authenticateAdmin(r.Header.Get("Authorization"), r.TLS)
+		if !ok {
+			action.Result = "unauthenticated"
+			s.auditor().LogAdminAction(action)
+			w.Header().Set("WWW-Authenticate", `Bearer realm="llm-proxy admin"`)
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		action.Principal, action.Role = principal.Name, principal.Role
+		write := r.Method != http.MethodGet && r.Method != http.MethodHead
+		if !principal.allows(write) {
+			action.Result = "forbidden"
+			s.auditor().LogAdminAction(action)
+			http.Error(w, "forbidden: the "+principal.Role+" role may only read", http.StatusForbidden)
+			return
+		}
+		if !write {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(recorder, r)
+		action.Result = strconv.Itoa(recorder.status)
+		s.auditor().LogAdminAction(action)
+	})
+}
+
+// statusRecorder records the status code written to a ResponseWriter
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	if !r.wroteHeader {
+		r.status, r.wroteHeader = status, true
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// Unwrap gives http.ResponseController access to the underlying writer
+func (r *statusRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
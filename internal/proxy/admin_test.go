@@ -0,0 +1,132 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/rs/zerolog"
+)
+
+func TestServer_RequireAdmin(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	serve := func(server *Server, authorization string) int {
+		req := httptest.NewRequest(http.MethodGet, "/admin/mappings", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		server.RequireAdmin(ok).ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	t.Setenv(adminTokenEnv, "")
+	disabled := &Server{config: config.DefaultConfig(), logger: zerolog.Nop()}
+	if code := serve(disabled, "Bearer "); code != http.StatusForbidden {
+		t.Errorf("without a token: status = %d, want 403", code)
+	}
+
+	cfg := config.DefaultConfig()
+	cfg.Metrics.Admin.Token = "s3cret"
+	server := &Server{config: cfg, logger: zerolog.Nop()}
+	tests := []struct {
+		authorization string
+		want          int
+	}{
+		{"Bearer s3cret", http.StatusOK},
+		{"Bearer wrong", http.StatusUnauthorized},
+		{"Basic s3cret", http.StatusUnauthorized},
+		{"", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		if code := serve(server, tt.authorization); code != tt.want {
+			t.Errorf("Authorization %q: status = %d, want %d", tt.authorization, code, tt.want)
+		}
+	}
+
+	t.Setenv(adminTokenEnv, "from-env")
+	if code := serve(disabled, "Bearer from-env"); code != http.StatusOK {
+		t.Errorf("token from environment: status = %d, want 200", code)
+	}
+}
+
+func TestServer_RequireAdmin_Roles(t *testing.T) {
+	t.Setenv(adminTokenEnv, "")
+	cfg := config.DefaultConfig()
+	cfg.Metrics.Admin.Tokens = []config.AdminTokenConfig{
+		{Name: "grafana", Token: "view", Role: RoleViewer},
+		{Name: "ci", Token: "operate", Role: RoleOperator},
+	}
+	cfg.Metrics.Admin.Clients = []config.AdminClientConfig{{CommonName: "fleet", Role: RoleOperator}}
+	recorder := &recordingAudit{}
+	server := &Server{config: cfg, logger: zerolog.Nop(), audit: recorder}
+	handler := server.RequireAdmin(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	serve := func(method, authorization string, state *tls.ConnectionState) int {
+		req := httptest.NewRequest(method, "/admin/mode", nil)
+		req.Header.Set("Authorization", authorization)
+		req.TLS = state
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := serve(http.MethodGet, "Bearer view", nil); code != http.StatusNoContent {
+		t.Errorf("viewer GET: status = %d, want 204", code)
+	}
+	if code := serve(http.MethodPut, "Bearer view", nil); code != http.StatusForbidden {
+		t.Errorf("viewer PUT: status = %d, want 403", code)
+	}
+	if code := serve(http.MethodPut, "Bearer operate", nil); code != http.StatusNoContent {
+		t.Errorf("operator PUT: status = %d, want 204", code)
+	}
+	if code := serve(http.MethodDelete, "Bearer wrong", nil); code != http.StatusUnauthorized {
+		t.Errorf("wrong token: status = %d, want 401", code)
+	}
+	client := &x509.Certificate{Subject: pkix.Name{CommonName: "fleet"}}
+	state := &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{client}}}
+	if code := serve(http.MethodPut, "", state); code != http.StatusNoContent {
+		t.Errorf("client certificate PUT: status = %d, want 204", code)
+	}
+
+	want := []string{
+		"grafana viewer PUT /admin/mode forbidden",
+		"ci operator PUT /admin/mode 204",
+		"  DELETE /admin/mode unauthenticated",
+		"cn:fleet operator PUT /admin/mode 204",
+	}
+	if !slices.Equal(recorder.admin, want) {
+		t.Errorf("audit events = %q, want %q", recorder.admin, want)
+	}
+}
+
+func TestValidateAdminAccess(t *testing.T) {
+	valid := config.AdminConfig{
+		Tokens:  []config.AdminTokenConfig{{Name: "ci", Token: "t", Role: RoleOperator}},
+		Clients: []config.AdminClientConfig{{CommonName: "fleet", Role: RoleViewer}},
+	}
+	if err := validateAdminAccess(valid); err != nil {
+		t.Errorf("valid config: %v", err)
+	}
+	invalid := []config.AdminConfig{
+		{Tokens: []config.AdminTokenConfig{{Token: "t", Role: RoleViewer}}},
+		{Tokens: []config.AdminTokenConfig{{Name: "ci", Role: RoleViewer}}},
+		{Tokens: []config.AdminTokenConfig{{Name: "ci", Token: "t", Role: "admin"}}},
+		{Tokens: []config.AdminTokenConfig{{Name: "ci", Token: "a", Role: RoleViewer}, {Name: "ci", Token: "b", Role: RoleViewer}}},
+		{Clients: []config.AdminClientConfig{{Role: RoleViewer}}},
+		{Clients: []config.AdminClientConfig{{CommonName: "fleet"}}},
+	}
+	for _, cfg := range invalid {
+		if err := validateAdminAccess(cfg); err == nil {
+			t.Errorf("validateAdminAccess(%+v) succeeded", cfg)
+		}
+	}
+}
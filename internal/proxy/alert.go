@@ -0,0 +1,81 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/hfi/llm-secret-interceptor/internal/alert"
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/interceptor"
+	"github.com/rs/zerolog"
+)
+
+type alertClientKey struct{}
+
+// newAlerts creates the alert manager configured in the alerting settings,
+// or nil if alerting is disabled. Dropped and undelivered alerts are logged
+// to logger.
+func newAlerts(cfg config.AlertingConfig, logger zerolog.Logger) (*alert.Manager, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	channels := make(map[string]alert.Notifier, len(cfg.Channels))
+	for name, channel := range cfg.Channels {
+		notifier, err := alert.NewNotifier(alert.ChannelConfig{
+			Type:       channel.Type,
+			URL:        channel.URL,
+			RoutingKey: channel.RoutingKey,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid alerting channel %s: %w", name, err)
+		}
+		channels[name] = notifier
+	}
+	rules := make([]alert.Rule, 0, len(cfg.Rules))
+	for _, r := range cfg.Rules {
+		rules = append(rules, alert.Rule{
+			Name:        r.Name,
+			SecretTypes: r.SecretTypes,
+			Rules:       r.Rules,
+			Threshold:   r.Threshold,
+			Window:      r.Window,
+			Cooldown:    r.Cooldown,
+			Severity:    r.Severity,
+			Channels:    r.Channels,
+		})
+	}
+	manager, err := alert.New(alert.Config{
+		Channels: channels,
+		Rules:    rules,
+		OnError: func(a alert.Alert, err error) {
+			logger.Error().Err(err).Str("rule", a.Name).Str("client", a.Client).Msg("Alert not delivered")
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid alerting config: %w", err)
+	}
+	return manager, nil
+}
+
+// withAlertClient returns a copy of req naming its client in alerts
+func withAlertClient(req *http.Request, client string) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), alertClientKey{}, client))
+}
+
+// alertDetected passes a secret detected in the request of ctx to the alert
+// rules
+func (s *Server) alertDetected(ctx context.Context, host string, secret interceptor.DetectedSecret) {
+	if s.alerts == nil {
+		return
+	}
+	client, _ := ctx.Value(alertClientKey{}).(string)
+	s.alerts.Observe(alert.Detection{
+		Client:      client,
+		Host:        host,
+		RequestID:   requestIDFromContext(ctx),
+		SecretType:  secret.Type,
+		Interceptor: secret.Source,
+		Rule:        secret.Rule,
+	})
+}
@@ -0,0 +1,201 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/hfi/llm-secret-interceptor/internal/audit"
+	"github.com/hfi/llm-secret-interceptor/internal/awsauth"
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/kms"
+	"github.com/hfi/llm-secret-interceptor/internal/objectstore"
+	"github.com/rs/zerolog"
+)
+
+// NewAuditLogger creates the audit logger configured in the logging settings.
+// Events an output fails to deliver are logged to logger.
+func NewAuditLogger(cfg *config.Config, logger zerolog.Logger) (*audit.Logger, error) {
+	settings := cfg.Logging.Audit
+	auditCfg := audit.DefaultConfig()
+	auditCfg.Enabled = settings.Enabled
+	auditCfg.IncludeRequestDetails = settings.IncludeRequestDetails
+	auditCfg.OmitInterceptor = !settings.LogInterceptorName
+	auditCfg.OmitSecretType = !settings.LogSecretType
+	if settings.Level != "" {
+		auditCfg.Level = settings.Level
+	}
+	if settings.Output != "" {
+		auditCfg.Output = settings.Output
+	}
+	rotation := settings.Rotation
+	if rotation.MaxSizeMB < 0 || rotation.MaxAge < 0 || rotation.MaxBackups < 0 || rotation.Retention < 0 {
+		return nil, fmt.Errorf("logging.audit.rotation settings must not be negative")
+	}
+	auditCfg.Rotation = audit.RotationConfig{
+		MaxSize:    int64(rotation.MaxSizeMB) << 20,
+		MaxAge:     rotation.MaxAge,
+		Compress:   rotation.Compress,
+		MaxBackups: rotation.MaxBackups,
+		Retention:  rotation.Retention,
+		OnError:    auditErrorHandler(logger, auditCfg.Output),
+	}
+	if settings.Format != "" {
+		auditCfg.Format = settings.Format
+	}
+	auditCfg.Sampling = audit.SamplingConfig{
+		RateLimit: settings.Sampling.RateLimit,
+		Burst:     settings.Sampling.Burst,
+	}
+	if len(settings.Sampling.Ratios) > 0 {
+		auditCfg.Sampling.Ratios = make(map[audit.EventType]float64, len(settings.Sampling.Ratios))
+		for eventType, ratio := range settings.Sampling.Ratios {
+			auditCfg.Sampling.Ratios[audit.EventType(eventType)] = ratio
+		}
+	}
+	if auditCfg.Output == "syslog" {
+		syslog, err := newAuditSyslogConfig(settings.Syslog)
+		if err != nil {
+			return nil, err
+		}
+		syslog.OnError = auditErrorHandler(logger, auditCfg.Output)
+		auditCfg.Syslog = syslog
+	}
+	if auditCfg.Output == "kafka" {
+		tlsConfig, err := clientTLSConfig(settings.Kafka.TLS)
+		if err != nil {
+			return nil, fmt.Errorf("invalid logging.audit.kafka TLS config: %w", err)
+		}
+		auditCfg.Kafka = audit.KafkaConfig{
+			Brokers:       settings.Kafka.Brokers,
+			Topic:         settings.Kafka.Topic,
+			SASLMechanism: settings.Kafka.SASLMechanism,
+			Username:      settings.Kafka.Username,
+			Password:      settings.Kafka.Password,
+			TLS:           tlsConfig,
+			BatchTimeout:  settings.Kafka.BatchTimeout,
+			OnError:       auditErrorHandler(logger, auditCfg.Output),
+		}
+	}
+
+	switch auditCfg.Level {
+	case "minimal", "standard", "verbose":
+	default:
+		return nil, fmt.Errorf("logging.audit.level must be minimal, standard or verbose, got %q", auditCfg.Level)
+	}
+	switch auditCfg.Format {
+	case "json", "text", "cef", "leef":
+	default:
+		return nil, fmt.Errorf("logging.audit.format must be json, text, cef or leef, got %q", auditCfg.Format)
+	}
+	if auditCfg.Output == "otlp" {
+		otlp := settings.OTLP
+		auditCfg.OTLP = audit.OTLPConfig{
+			Endpoint:    otlp.Endpoint,
+			Headers:     otlp.Headers,
+			ServiceName: otlp.ServiceName,
+			BatchSize:   otlp.BatchSize,
+			Interval:    otlp.Interval,
+			Timeout:     otlp.Timeout,
+			OnError:     auditErrorHandler(logger, auditCfg.Output),
+		}
+	}
+	if auditCfg.Output == "s3" || auditCfg.Output == "gcs" {
+		uploader, err := newAuditUploader(auditCfg.Output, settings.Archive)
+		if err != nil {
+			return nil, fmt.Errorf("invalid logging.audit.archive config: %w", err)
+		}
+		auditCfg.Object = audit.ObjectConfig{
+			Uploader: uploader,
+			Prefix:   settings.Archive.Prefix,
+			Window:   settings.Archive.Window,
+			MaxBytes: settings.Archive.MaxBytes,
+			OnError:  auditErrorHandler(logger, auditCfg.Output),
+		}
+	}
+	return audit.NewLogger(auditCfg)
+}
+
+// auditErrorHandler logs the delivery errors of an audit output
+func auditErrorHandler(logger zerolog.Logger, output string) func(error) {
+	return func(err error) {
+		logger.Error().Err(err).Str("output", output).Msg("Audit events not delivered")
+	}
+}
+
+// newAuditUploader creates the uploader of the "s3" or "gcs" audit output,
+// with credentials found like those of the key providers
+func newAuditUploader(output string, cfg config.AuditArchiveConfig) (audit.ObjectUploader, error) {
+	if output == "s3" {
+		creds, err := awsauth.CredentialsFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		return objectstore.NewS3(cfg.Bucket, cfg.Region, cfg.Endpoint, creds)
+	}
+	var tokens kms.TokenSource = kms.NewMetadataTokenSource()
+	if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+		tokens = kms.StaticToken(token)
+	}
+	return objectstore.NewGCS(cfg.Bucket, cfg.Endpoint, tokens)
+}
+
+// newAuditSyslogConfig maps the syslog settings of the audit log
+func newAuditSyslogConfig(cfg config.AuditSyslogConfig) (audit.SyslogConfig, error) {
+	syslog := audit.SyslogConfig{
+		Network:  cfg.Network,
+		Address:  cfg.Address,
+		Facility: cfg.Facility,
+		AppName:  cfg.AppName,
+	}
+	if cfg.Network == "tls" {
+		tlsSettings := cfg.TLS
+		tlsSettings.Enabled = true
+		tlsConfig, err := clientTLSConfig(tlsSettings)
+		if err != nil {
+			return syslog, fmt.Errorf("invalid logging.audit.syslog TLS config: %w", err)
+		}
+		syslog.TLS = tlsConfig
+	}
+	return syslog, nil
+}
+
+// auditLogger is the audit logging used by the proxy, implemented by
+// audit.Logger and audit.NopLogger
+type auditLogger interface {
+	LogSecretDetected(requestID, interceptor, secretType string)
+	LogSecretReplaced(requestID string, count int)
+	LogPlaceholderRestored(requestID string, count int)
+	LogMappingCreated(placeholder string, origin audit.Origin)
+	LogRequestProcessed(requestID, method, host, path string, durationMs float64)
+	LogResponseProcessed(requestID, host string, durationMs float64)
+	LogClientRejected(clientIP, host, reason string)
+	LogPinningDetected(clientIP, host string, aborts int, bypassed bool)
+	LogMappingsPurged(count int, criteria map[string]string)
+	LogAdminAction(action audit.AdminAction)
+	LogError(eventType audit.EventType, requestID, host, errorMsg string)
+	Close() error
+}
+
+// auditor returns the server's audit logger, or a no-op logger when none is set
+func (s *Server) auditor() auditLogger {
+	if s.audit == nil {
+		return audit.NewNopLogger()
+	}
+	return s.audit
+}
+
+// auditTransport audits failed upstream round trips with the request ID of
+// their request. Requests given up by their client are not upstream errors.
+type auditTransport struct {
+	base  http.RoundTripper
+	audit auditLogger
+}
+
+func (t *auditTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil && req.Context().Err() == nil {
+		t.audit.LogError(audit.EventUpstreamError, requestIDFromContext(req.Context()), req.URL.Host, err.Error())
+	}
+	return resp, err
+}
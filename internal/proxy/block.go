@@ -0,0 +1,75 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/interceptor"
+)
+
+// errSecretBlocked marks requests rejected by the block policy
+var errSecretBlocked = errors.New("request blocked: secret detected")
+
+// validateBlockPolicy checks the status code of blocked requests
+func validateBlockPolicy(cfg config.BlockConfig) error {
+	if len(cfg.Types) > 0 && (cfg.Status < 400 || cfg.Status > 499) {
+		return fmt.Errorf("policy.block.status must be a 4xx status code, got %d", cfg.Status)
+	}
+	return nil
+}
+
+// blocks reports whether secret blocks its request
+func blocks(cfg config.BlockConfig, secret interceptor.DetectedSecret) bool {
+	if secret.Confidence < cfg.MinConfidence {
+		return false
+	}
+	return slices.Contains(cfg.Types, "*") || slices.Contains(cfg.Types, secret.Type)
+}
+
+// blockingSecret returns the first secret that blocks its request
+func blockingSecret(cfg config.BlockConfig, secrets []interceptor.DetectedSecret) (interceptor.DetectedSecret, bool) {
+	if len(cfg.Types) == 0 {
+		return interceptor.DetectedSecret{}, false
+	}
+	for _, secret := range secrets {
+		if blocks(cfg, secret) {
+			return secret, true
+		}
+	}
+	return interceptor.DetectedSecret{}, false
+}
+
+// writeBlocked answers a blocked request with an error in the schema of the
+// provider it was addressed to, so SDKs surface the message
+func writeBlocked(w http.ResponseWriter, req *http.Request, status int, cause error) {
+	message := "llm-secret-interceptor: " + cause.Error()
+	var body any
+	switch {
+	case isAnthropicRequest(req):
+		body = map[string]any{
+			"type":  "error",
+			"error": map[string]any{"type": "invalid_request_error", "message": message},
+		}
+	case matchHost([]string{"generativelanguage.googleapis.com", "*-aiplatform.googleapis.com"}, req.URL.Host):
+		body = map[string]any{
+			"error": map[string]any{"code": status, "message": message, "status": "INVALID_ARGUMENT"},
+		}
+	default:
+		body = map[string]any{
+			"error": map[string]any{"message": message, "type": "invalid_request_error", "param": nil, "code": "secret_detected"},
+		}
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		http.Error(w, cause.Error(), status)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_, _ = w.Write(data)
+}
@@ -0,0 +1,54 @@
+package proxy
+
+import (
+	"net/http"
+	"path"
+	"strconv"
+)
+
+// CAHandler serves the interception CA certificate for installation in
+// trust stores: PEM encoded for paths ending in .crt or .pem, DER encoded
+// for .der and .cer, and as PKCS#12 bundle protected by tls.p12_password for
+// .p12 and .pfx
+func (s *Server) CAHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var body []byte
+		name := path.Base(r.URL.Path)
+		switch path.Ext(name) {
+		case ".crt", ".pem":
+			body = s.certManager.GetCACertificate()
+			w.Header().Set("Content-Type", "application/x-pem-file")
+		case ".der", ".cer":
+			body = s.certManager.authority().anchor.Raw
+			// Lets browsers and mobile platforms offer to install the CA
+			w.Header().Set("Content-Type", "application/x-x509-ca-cert")
+		case ".p12", ".pfx":
+			var err error
+			body, err = EncodePKCS12TrustStore(s.certManager.authority().anchor, caFriendlyName, s.cfg().TLS.P12Password)
+			if err != nil {
+				s.logger.Error().Err(err).Msg("Failed to encode CA certificate")
+				http.Error(w, "failed to encode CA certificate", http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/x-pkcs12")
+		default:
+			http.NotFound(w, r)
+			return
+		}
+
+		w.Header().Set("Content-Disposition", "attachment; filename="+strconv.Quote(name))
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		if r.Method == http.MethodHead {
+			return
+		}
+		if _, err := w.Write(body); err != nil {
+			s.logger.Debug().Err(err).Msg("Failed to write CA certificate")
+		}
+	})
+}
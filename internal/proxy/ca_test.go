@@ -0,0 +1,74 @@
+package proxy
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/rs/zerolog"
+)
+
+func TestCAHandler(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+	if err := GenerateCA(certPath, keyPath); err != nil {
+		t.Fatalf("GenerateCA() error: %v", err)
+	}
+	cm, err := NewCertManager(certPath, keyPath)
+	if err != nil {
+		t.Fatalf("NewCertManager() error: %v", err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.TLS.P12Password = "secret"
+	s := &Server{config: cfg, certManager: cm, logger: zerolog.Nop()}
+
+	get := func(method, path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.CAHandler().ServeHTTP(rec, httptest.NewRequest(method, path, nil))
+		return rec
+	}
+
+	rec := get(http.MethodGet, "/ca.crt")
+	block, _ := pem.Decode(rec.Body.Bytes())
+	if rec.Code != http.StatusOK || block == nil || !bytes.Equal(block.Bytes, cm.authority().cert.Raw) {
+		t.Errorf("/ca.crt: status %d, body is not the PEM CA certificate", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-pem-file" {
+		t.Errorf("/ca.crt Content-Type = %q", ct)
+	}
+
+	rec = get(http.MethodGet, "/ca.der")
+	cert, err := x509.ParseCertificate(rec.Body.Bytes())
+	if rec.Code != http.StatusOK || err != nil || !cert.IsCA {
+		t.Errorf("/ca.der: status %d, parse error %v", rec.Code, err)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-x509-ca-cert" {
+		t.Errorf("/ca.der Content-Type = %q", ct)
+	}
+
+	rec = get(http.MethodGet, "/ca.p12")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("/ca.p12: status %d", rec.Code)
+	}
+	if cert := decodePKCS12Cert(t, rec.Body.Bytes(), "secret"); !cert.Equal(cm.authority().cert) {
+		t.Error("/ca.p12 holds a different certificate")
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-pkcs12" {
+		t.Errorf("/ca.p12 Content-Type = %q", ct)
+	}
+
+	if rec := get(http.MethodHead, "/ca.der"); rec.Body.Len() != 0 || rec.Header().Get("Content-Length") == "" {
+		t.Error("HEAD should send headers only")
+	}
+	if rec := get(http.MethodPost, "/ca.crt"); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want 405", rec.Code)
+	}
+	if rec := get(http.MethodGet, "/ca.key"); rec.Code != http.StatusNotFound {
+		t.Errorf("/ca.key status = %d, want 404", rec.Code)
+	}
+}
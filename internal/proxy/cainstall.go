@@ -0,0 +1,70 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// caFriendlyName names the CA in trust stores
+const caFriendlyName = "LLM Secret Interceptor CA"
+
+// CAInstallInstructions returns commands adding the CA certificate at
+// certPath, or the PKCS#12 bundle at p12Path, to common trust stores
+func CAInstallInstructions(certPath, p12Path string) string {
+	var b strings.Builder
+	section := func(title string, lines ...string) {
+		fmt.Fprintf(&b, "# %s\n", title)
+		for _, line := range lines {
+			fmt.Fprintf(&b, "%s\n", line)
+		}
+		b.WriteString("\n")
+	}
+	section("Windows (PowerShell as administrator)",
+		fmt.Sprintf(`Import-Certificate -FilePath "%s" -CertStoreLocation Cert:\LocalMachine\Root`, certPath),
+		fmt.Sprintf(`# or: certutil -addstore -f Root "%s"`, certPath),
+		fmt.Sprintf(`# or open %s and place it in "Trusted Root Certification Authorities"`, p12Path))
+	section("macOS",
+		fmt.Sprintf(`sudo security add-trusted-cert -d -r trustRoot -k /Library/Keychains/System.keychain "%s"`, certPath),
+		fmt.Sprintf(`# or open %s in Keychain Access and set it to "Always Trust"`, p12Path))
+	section("Debian / Ubuntu",
+		fmt.Sprintf(`sudo cp "%s" /usr/local/share/ca-certificates/llm-secret-interceptor.crt`, certPath),
+		"sudo update-ca-certificates")
+	section("RHEL / Fedora",
+		fmt.Sprintf(`sudo cp "%s" /etc/pki/ca-trust/source/anchors/llm-secret-interceptor.crt`, certPath),
+		"sudo update-ca-trust")
+	section("Java",
+		fmt.Sprintf(`keytool -importcert -cacerts -alias llm-secret-interceptor -file "%s"`, certPath))
+	section("Node.js / Python (per process)",
+		fmt.Sprintf(`export NODE_EXTRA_CA_CERTS="%s"`, certPath),
+		fmt.Sprintf(`export REQUESTS_CA_BUNDLE="%s"  # replaces the default bundle`, certPath))
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// CAInstallHandler serves commands downloading the CA certificate from this
+// listener and installing it on common platforms
+func (s *Server) CAInstallHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		base := scheme + "://" + r.Host
+		var b strings.Builder
+		b.WriteString("# Download\n")
+		fmt.Fprintf(&b, "curl -fsSLo ca.crt %s/ca.crt\n", base)
+		fmt.Fprintf(&b, "curl -fsSLo ca.p12 %s/ca.p12\n\n", base)
+		b.WriteString(CAInstallInstructions("ca.crt", "ca.p12"))
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		if _, err := w.Write([]byte(b.String())); err != nil {
+			s.logger.Debug().Err(err).Msg("Failed to write CA install instructions")
+		}
+	})
+}
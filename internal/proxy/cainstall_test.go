@@ -0,0 +1,40 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rs/zerolog"
+)
+
+func TestCAInstallHandler(t *testing.T) {
+	s := &Server{logger: zerolog.Nop()}
+
+	rec := httptest.NewRecorder()
+	s.CAInstallHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://proxy.internal:9090/ca/install", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"curl -fsSLo ca.crt http://proxy.internal:9090/ca.crt",
+		"curl -fsSLo ca.p12 http://proxy.internal:9090/ca.p12",
+		`Import-Certificate -FilePath "ca.crt"`,
+		"security add-trusted-cert",
+		"update-ca-certificates",
+		"update-ca-trust",
+		"keytool -importcert",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("instructions lack %q", want)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	s.CAInstallHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/ca/install", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want %d", rec.Code, http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,192 @@
+package proxy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// caRotation is the CA replaced by the last rotation, whose cached
+// certificates are served until graceUntil
+type caRotation struct {
+	previous   *certAuthority
+	graceUntil time.Time
+	timer      *time.Timer
+}
+
+// CAInfo describes the CA issuing certificates
+type CAInfo struct {
+	Fingerprint string    `json:"fingerprint"`
+	Subject     string    `json:"subject"`
+	NotAfter    time.Time `json:"not_after"`
+	// Previous is the CA replaced by a rotation while its certificates are
+	// still served
+	Previous *PreviousCAInfo `json:"previous,omitempty"`
+}
+
+// PreviousCAInfo describes a rotated-out CA
+type PreviousCAInfo struct {
+	Fingerprint string    `json:"fingerprint"`
+	Subject     string    `json:"subject"`
+	GraceUntil  time.Time `json:"grace_until"`
+}
+
+// fingerprint returns the SHA-256 fingerprint of the signing certificate
+func (ca *certAuthority) fingerprint() string {
+	sum := sha256.Sum256(ca.cert.Raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// Rotate loads a new CA certificate and key, or only the certificate when
+// signing with an external signer. Certificates for new hosts are
+// issued by it right away, while cached certificates of the previous CA are
+// served for grace and regenerated afterwards.
+func (cm *CertManager) Rotate(caCertPath, caKeyPath string, grace time.Duration) error {
+	ca, err := cm.loadCertAuthority(caCertPath, caKeyPath)
+	if err != nil {
+		return err
+	}
+
+	cm.caMu.Lock()
+	defer cm.caMu.Unlock()
+	if cm.rotation != nil && cm.rotation.timer != nil {
+		cm.rotation.timer.Stop()
+	}
+	cm.rotation = &caRotation{previous: cm.ca, graceUntil: time.Now().Add(grace)}
+	cm.ca = ca
+	if grace <= 0 {
+		cm.rotation = nil
+		cm.cache.clear()
+		return nil
+	}
+	rotation := cm.rotation
+	rotation.timer = time.AfterFunc(grace, func() { cm.endGrace(rotation) })
+	return nil
+}
+
+// endGrace drops the certificates of the previous CA once rotation's grace
+// period is over, unless another rotation replaced it
+func (cm *CertManager) endGrace(rotation *caRotation) {
+	cm.caMu.Lock()
+	defer cm.caMu.Unlock()
+	if cm.rotation != rotation {
+		return
+	}
+	cm.rotation = nil
+	cm.cache.clear()
+}
+
+// CAInfo describes the active CA and, during a grace period, the previous one
+func (cm *CertManager) CAInfo() CAInfo {
+	cm.caMu.RLock()
+	defer cm.caMu.RUnlock()
+	info := CAInfo{
+		Fingerprint: cm.ca.fingerprint(),
+		Subject:     cm.ca.cert.Subject.String(),
+		NotAfter:    cm.ca.cert.NotAfter,
+	}
+	if cm.rotation != nil {
+		info.Previous = &PreviousCAInfo{
+			Fingerprint: cm.rotation.previous.fingerprint(),
+			Subject:     cm.rotation.previous.cert.Subject.String(),
+			GraceUntil:  cm.rotation.graceUntil,
+		}
+	}
+	return info
+}
+
+// RotateCA reloads the configured CA certificate and key
+func (s *Server) RotateCA() error {
+	cfg := s.cfg().TLS
+	if err := s.certManager.Rotate(cfg.CACert, cfg.CAKey, cfg.CARotation.Grace); err != nil {
+		return fmt.Errorf("failed to rotate CA: %w", err)
+	}
+	s.logger.Info().
+		Str("fingerprint", s.certManager.CAInfo().Fingerprint).
+		Dur("grace", cfg.CARotation.Grace).
+		Msg("Rotated CA")
+	return nil
+}
+
+// CAAdminHandler serves the active CA as JSON, and rotates it on POST
+func (s *Server) CAAdminHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			if err := s.RotateCA(); err != nil {
+				s.logger.Error().Err(err).Msg("Failed to rotate CA")
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(s.certManager.CAInfo()); err != nil {
+			s.logger.Debug().Err(err).Msg("Failed to write CA info")
+		}
+	})
+}
+
+// caFileState identifies a version of the CA files
+type caFileState struct {
+	size    [2]int64
+	modTime [2]time.Time
+}
+
+// statCAFiles returns the state of the CA certificate and key files
+func statCAFiles(caCertPath, caKeyPath string) (caFileState, error) {
+	var state caFileState
+	for i, path := range []string{caCertPath, caKeyPath} {
+		info, err := os.Stat(filepath.Clean(path))
+		if err != nil {
+			return state, err
+		}
+		state.size[i] = info.Size()
+		state.modTime[i] = info.ModTime()
+	}
+	return state, nil
+}
+
+// watchCA rotates the CA whenever its files change, until stop is closed.
+// Files that fail to load, e.g. when caught mid-write, are retried on the
+// next check.
+func (s *Server) watchCA(stop <-chan struct{}) {
+	cfg := s.cfg().TLS
+	last, err := statCAFiles(cfg.CACert, cfg.CAKey)
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("Failed to check CA files")
+	}
+
+	ticker := time.NewTicker(cfg.CARotation.WatchInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+		state, err := statCAFiles(cfg.CACert, cfg.CAKey)
+		if err != nil {
+			s.logger.Warn().Err(err).Msg("Failed to check CA files")
+			continue
+		}
+		if state == last {
+			continue
+		}
+		if err := s.RotateCA(); err != nil {
+			s.logger.Error().Err(err).Msg("Failed to rotate CA after file change")
+			continue
+		}
+		last = state
+	}
+}
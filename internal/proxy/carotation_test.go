@@ -0,0 +1,167 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/rs/zerolog"
+)
+
+// writeTestCA generates a CA into dir and returns its paths
+func writeTestCA(t *testing.T, dir string) (string, string) {
+	t.Helper()
+	caPath, keyPath := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+	if err := GenerateCA(caPath, keyPath); err != nil {
+		t.Fatalf("GenerateCA failed: %v", err)
+	}
+	return caPath, keyPath
+}
+
+func TestCertManager_Rotate(t *testing.T) {
+	caPath, keyPath := writeTestCA(t, t.TempDir())
+	cm, err := NewCertManager(caPath, keyPath)
+	if err != nil {
+		t.Fatalf("NewCertManager failed: %v", err)
+	}
+	old, err := cm.GetCertificate(&tls.ClientHelloInfo{ServerName: "api.openai.com"})
+	if err != nil {
+		t.Fatalf("GetCertificate failed: %v", err)
+	}
+	oldFingerprint := cm.CAInfo().Fingerprint
+
+	newCA, newKey := writeTestCA(t, t.TempDir())
+	if err := cm.Rotate(newCA, newKey, time.Hour); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	info := cm.CAInfo()
+	if info.Fingerprint == oldFingerprint {
+		t.Error("fingerprint unchanged after rotation")
+	}
+	if info.Previous == nil || info.Previous.Fingerprint != oldFingerprint {
+		t.Errorf("previous CA = %+v, want fingerprint %s", info.Previous, oldFingerprint)
+	}
+
+	// Cached certificates of the old CA are served during the grace period
+	cached, err := cm.GetCertificate(&tls.ClientHelloInfo{ServerName: "api.openai.com"})
+	if err != nil {
+		t.Fatalf("GetCertificate failed: %v", err)
+	}
+	if cached != old {
+		t.Error("cached certificate not served during grace period")
+	}
+
+	// New hosts get certificates of the new CA
+	fresh, err := cm.GetCertificate(&tls.ClientHelloInfo{ServerName: "api.anthropic.com"})
+	if err != nil {
+		t.Fatalf("GetCertificate failed: %v", err)
+	}
+	if err := fresh.Leaf.CheckSignatureFrom(cm.authority().cert); err != nil {
+		t.Errorf("new certificate not issued by the new CA: %v", err)
+	}
+
+	// Without a grace period, certificates are regenerated right away
+	if err := cm.Rotate(caPath, keyPath, 0); err != nil {
+		t.Fatalf("Rotate failed: %v", err)
+	}
+	if cm.CAInfo().Previous != nil {
+		t.Error("previous CA reported without grace period")
+	}
+	renewed, err := cm.GetCertificate(&tls.ClientHelloInfo{ServerName: "api.openai.com"})
+	if err != nil {
+		t.Fatalf("GetCertificate failed: %v", err)
+	}
+	if renewed == old {
+		t.Error("certificate of the rotated-out CA served after the grace period")
+	}
+
+	if err := cm.Rotate(filepath.Join(t.TempDir(), "missing.crt"), keyPath, 0); err == nil {
+		t.Error("Rotate succeeded with a missing certificate")
+	}
+	if cm.CAInfo().Fingerprint != oldFingerprint {
+		t.Error("failed rotation replaced the CA")
+	}
+}
+
+func TestCAAdminHandler(t *testing.T) {
+	dir := t.TempDir()
+	caPath, keyPath := writeTestCA(t, dir)
+	cm, err := NewCertManager(caPath, keyPath)
+	if err != nil {
+		t.Fatalf("NewCertManager failed: %v", err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.TLS.CACert, cfg.TLS.CAKey = caPath, keyPath
+	s := &Server{config: cfg, certManager: cm, logger: zerolog.Nop()}
+
+	get := func(method string) (int, CAInfo) {
+		rec := httptest.NewRecorder()
+		s.CAAdminHandler().ServeHTTP(rec, httptest.NewRequest(method, "/admin/ca", nil))
+		var info CAInfo
+		if rec.Code == http.StatusOK {
+			if err := json.NewDecoder(rec.Body).Decode(&info); err != nil {
+				t.Fatalf("failed to decode CA info: %v", err)
+			}
+		}
+		return rec.Code, info
+	}
+
+	code, before := get(http.MethodGet)
+	if code != http.StatusOK || before.Fingerprint != cm.CAInfo().Fingerprint {
+		t.Fatalf("GET = %d %+v", code, before)
+	}
+
+	// Replace the files and rotate
+	writeTestCA(t, dir)
+	code, after := get(http.MethodPost)
+	if code != http.StatusOK {
+		t.Fatalf("POST = %d", code)
+	}
+	if after.Fingerprint == before.Fingerprint || after.Previous == nil {
+		t.Errorf("POST did not rotate the CA: %+v", after)
+	}
+
+	if code, _ := get(http.MethodDelete); code != http.StatusMethodNotAllowed {
+		t.Errorf("DELETE = %d, want %d", code, http.StatusMethodNotAllowed)
+	}
+}
+
+func TestServer_WatchCA(t *testing.T) {
+	dir := t.TempDir()
+	caPath, keyPath := writeTestCA(t, dir)
+	cm, err := NewCertManager(caPath, keyPath)
+	if err != nil {
+		t.Fatalf("NewCertManager failed: %v", err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.TLS.CACert, cfg.TLS.CAKey = caPath, keyPath
+	cfg.TLS.CARotation.WatchInterval = 10 * time.Millisecond
+	s := &Server{config: cfg, certManager: cm, logger: zerolog.Nop()}
+	before := cm.CAInfo().Fingerprint
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		s.watchCA(stop)
+		close(done)
+	}()
+	defer func() {
+		close(stop)
+		<-done
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	writeTestCA(t, dir)
+	deadline := time.Now().Add(5 * time.Second)
+	for cm.CAInfo().Fingerprint == before {
+		if time.Now().After(deadline) {
+			t.Fatal("CA not rotated after its files changed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
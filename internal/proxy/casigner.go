@@ -0,0 +1,45 @@
+package proxy
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"os"
+
+	"github.com/hfi/llm-secret-interceptor/internal/awsauth"
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/kms"
+)
+
+// NewCASigner creates the signer holding the CA key selected in cfg, or nil
+// when the key is read from tls.ca_key
+func NewCASigner(ctx context.Context, cfg config.CASignerConfig) (crypto.Signer, error) {
+	switch cfg.Provider {
+	case "", "file":
+		return nil, nil
+
+	case "vault":
+		token := cfg.Vault.Token
+		if token == "" {
+			token = os.Getenv("VAULT_TOKEN")
+		}
+		return kms.NewVaultSigner(ctx, cfg.Vault.Address, token, cfg.Vault.Mount, cfg.Vault.KeyName)
+
+	case "aws-kms":
+		creds, err := awsauth.CredentialsFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		return kms.NewAWSSigner(ctx, cfg.AWS.Region, cfg.AWS.KeyID, cfg.AWS.Endpoint, creds)
+
+	case "gcp-kms":
+		var tokens kms.TokenSource = kms.NewMetadataTokenSource()
+		if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+			tokens = kms.StaticToken(token)
+		}
+		return kms.NewGCPSigner(ctx, cfg.GCP.KeyName, cfg.GCP.Endpoint, tokens)
+
+	default:
+		return nil, fmt.Errorf("unknown CA signer %q", cfg.Provider)
+	}
+}
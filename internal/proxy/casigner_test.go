@@ -0,0 +1,99 @@
+package proxy
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+)
+
+// remoteSigner hides a key behind crypto.Signer, as a KMS client does
+type remoteSigner struct {
+	key   crypto.Signer
+	signs int
+}
+
+func (s *remoteSigner) Public() crypto.PublicKey {
+	return s.key.Public()
+}
+
+func (s *remoteSigner) Sign(r io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	s.signs++
+	return s.key.Sign(r, digest, opts)
+}
+
+func TestCertManager_Signer(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "KMS CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	if err != nil {
+		t.Fatalf("CreateCertificate failed: %v", err)
+	}
+	certPath := filepath.Join(t.TempDir(), "ca.crt")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	signer := &remoteSigner{key: key}
+	cm, err := NewCertManagerWithSigner(certPath, signer)
+	if err != nil {
+		t.Fatalf("NewCertManagerWithSigner failed: %v", err)
+	}
+	cert, err := cm.GetCertificate(&tls.ClientHelloInfo{ServerName: "api.openai.com"})
+	if err != nil {
+		t.Fatalf("GetCertificate failed: %v", err)
+	}
+	if signer.signs != 1 {
+		t.Errorf("signer used %d times, want 1", signer.signs)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(cm.authority().cert)
+	if _, err := cert.Leaf.Verify(x509.VerifyOptions{Roots: roots, DNSName: "api.openai.com"}); err != nil {
+		t.Errorf("certificate does not verify against the CA: %v", err)
+	}
+
+	// The certificate must be issued for the signer's key
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey failed: %v", err)
+	}
+	if _, err := NewCertManagerWithSigner(certPath, other); err == nil {
+		t.Error("NewCertManagerWithSigner accepted a key not matching the certificate")
+	}
+}
+
+func TestNewCASigner(t *testing.T) {
+	for _, provider := range []string{"", "file"} {
+		signer, err := NewCASigner(context.Background(), config.CASignerConfig{Provider: provider})
+		if err != nil || signer != nil {
+			t.Errorf("provider %q: signer %v, error %v; want neither", provider, signer, err)
+		}
+	}
+	if _, err := NewCASigner(context.Background(), config.CASignerConfig{Provider: "pkcs11"}); err == nil {
+		t.Error("NewCASigner accepted an unknown provider")
+	}
+}
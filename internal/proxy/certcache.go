@@ -0,0 +1,116 @@
+package proxy
+
+import (
+	"container/list"
+	"crypto/tls"
+	"sync"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/metrics"
+)
+
+// certCache holds generated host certificates, evicting the least recently
+// used beyond its capacity
+type certCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // front = most recently used
+	items    map[string]*list.Element
+}
+
+// certEntry is a cached certificate of a host
+type certEntry struct {
+	host string
+	cert *tls.Certificate
+}
+
+// newCertCache returns a cache for capacity certificates (0 = unlimited)
+func newCertCache(capacity int) *certCache {
+	return &certCache{capacity: capacity, order: list.New(), items: make(map[string]*list.Element)}
+}
+
+// Results of certificate cache lookups
+const (
+	certHit      = "hit"
+	certMiss     = "miss"
+	certExpiring = "expiring"
+	certDiskHit  = "disk_hit"
+)
+
+// get returns the certificate cached for host unless it is due for
+// renewal, and the result of the lookup
+func (c *certCache) get(host string, now time.Time) (*tls.Certificate, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[host]
+	if !ok {
+		return nil, certMiss
+	}
+	cert := el.Value.(*certEntry).cert
+	if renewDue(cert, now) {
+		return nil, certExpiring
+	}
+	c.order.MoveToFront(el)
+	return cert, certHit
+}
+
+// add caches cert for host, replacing an older one
+func (c *certCache) add(host string, cert *tls.Certificate) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[host]; ok {
+		el.Value.(*certEntry).cert = cert
+		c.order.MoveToFront(el)
+		return
+	}
+	c.items[host] = c.order.PushFront(&certEntry{host: host, cert: cert})
+	c.evict()
+	metrics.CertCacheSize.Set(float64(c.order.Len()))
+}
+
+// resize changes the capacity, evicting certificates to fit it
+func (c *certCache) resize(capacity int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.capacity = capacity
+	c.evict()
+	metrics.CertCacheSize.Set(float64(c.order.Len()))
+}
+
+// clear removes all certificates
+func (c *certCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.order.Init()
+	c.items = make(map[string]*list.Element)
+	metrics.CertCacheSize.Set(0)
+}
+
+// evict removes the least recently used certificates beyond the capacity;
+// callers hold the lock
+func (c *certCache) evict() {
+	for c.capacity > 0 && c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.items, oldest.Value.(*certEntry).host)
+		metrics.CertCacheEvictions.Inc()
+	}
+}
+
+// len returns the number of cached certificates
+func (c *certCache) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// renewDue reports whether less than a tenth of the validity of cert
+// remains at now
+func renewDue(cert *tls.Certificate, now time.Time) bool {
+	leaf := cert.Leaf
+	if leaf == nil {
+		return false
+	}
+	lifetime := leaf.NotAfter.Sub(leaf.NotBefore)
+	return leaf.NotAfter.Sub(now) < lifetime/10
+}
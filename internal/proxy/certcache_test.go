@@ -0,0 +1,76 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"testing"
+	"time"
+)
+
+// testCert returns a certificate valid from notBefore to notAfter
+func testCert(notBefore, notAfter time.Time) *tls.Certificate {
+	return &tls.Certificate{Leaf: &x509.Certificate{NotBefore: notBefore, NotAfter: notAfter}}
+}
+
+func TestCertCache_LRU(t *testing.T) {
+	now := time.Now()
+	c := newCertCache(2)
+	a, b, d := testCert(now, now.Add(time.Hour)), testCert(now, now.Add(time.Hour)), testCert(now, now.Add(time.Hour))
+	c.add("a", a)
+	c.add("b", b)
+	if _, result := c.get("a", now); result != certHit {
+		t.Fatalf("get(a) = %s, want hit", result)
+	}
+	c.add("d", d)
+
+	if _, result := c.get("b", now); result != certMiss {
+		t.Error("least recently used certificate was not evicted")
+	}
+	if got, _ := c.get("a", now); got != a {
+		t.Error("recently used certificate was evicted")
+	}
+	if c.len() != 2 {
+		t.Errorf("len() = %d, want 2", c.len())
+	}
+
+	c.resize(1)
+	if c.len() != 1 {
+		t.Errorf("len() after resize = %d, want 1", c.len())
+	}
+}
+
+func TestRenewDue(t *testing.T) {
+	now := time.Now()
+	for _, tt := range []struct {
+		name string
+		cert *tls.Certificate
+		want bool
+	}{
+		{"fresh", testCert(now.Add(-time.Hour), now.Add(47*time.Hour)), false},
+		{"last tenth", testCert(now.Add(-44*time.Hour), now.Add(4*time.Hour)), true},
+		{"expired", testCert(now.Add(-48*time.Hour), now.Add(-time.Minute)), true},
+		{"unparsed", &tls.Certificate{}, false},
+	} {
+		if got := renewDue(tt.cert, now); got != tt.want {
+			t.Errorf("%s: renewDue() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCertManager_RenewsExpiringCertificates(t *testing.T) {
+	cm := newTestCertManager(t)
+	now := time.Now()
+	expiring := testCert(now.Add(-365*24*time.Hour), now.Add(time.Hour))
+	cm.cache.add("example.com", expiring)
+
+	cert, err := cm.GetCertificate(&tls.ClientHelloInfo{ServerName: "example.com"})
+	if err != nil {
+		t.Fatalf("GetCertificate failed: %v", err)
+	}
+	if cert == expiring || cert.Leaf.NotAfter.Before(now.Add(24*time.Hour)) {
+		t.Error("certificate about to expire was not regenerated")
+	}
+	if again, _ := cm.GetCertificate(&tls.ClientHelloInfo{ServerName: "example.com"}); again != cert {
+		t.Error("regenerated certificate was not cached")
+	}
+}
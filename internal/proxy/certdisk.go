@@ -0,0 +1,97 @@
+package proxy
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SetCacheDir persists generated host certificates in dir, creating it if
+// needed, and loads certificates from there before generating new ones
+func (cm *CertManager) SetCacheDir(dir string) error {
+	dir = filepath.Clean(dir)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return fmt.Errorf("failed to create certificate cache directory: %w", err)
+	}
+	cm.cacheDir = dir
+	return nil
+}
+
+// certCacheFile returns the file holding the certificate of host in dir.
+// Characters not found in hostnames are replaced; certificates are checked
+// against the host when loaded.
+func certCacheFile(dir, host string) string {
+	name := strings.Map(func(r rune) rune {
+		if r == '.' || r == '-' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') {
+			return r
+		}
+		return '_'
+	}, host)
+	return filepath.Join(dir, name+".pem")
+}
+
+// loadCachedCert reads the certificate of host from the cache directory.
+// It returns nil unless the certificate was issued by the current CA for
+// host and is not due for renewal.
+func (cm *CertManager) loadCachedCert(host string, now time.Time) *tls.Certificate {
+	if cm.cacheDir == "" {
+		return nil
+	}
+	data, err := os.ReadFile(certCacheFile(cm.cacheDir, host)) //#nosec G304 -- configured directory, sanitized host name
+	if err != nil {
+		return nil
+	}
+	cert, err := tls.X509KeyPair(data, data)
+	if err != nil || cert.Leaf == nil {
+		return nil
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(cm.authority().cert)
+	if _, err := cert.Leaf.Verify(x509.VerifyOptions{Roots: roots, DNSName: host, CurrentTime: now}); err != nil {
+		return nil
+	}
+	if renewDue(&cert, now) {
+		return nil
+	}
+	return &cert
+}
+
+// storeCachedCert writes the certificate of host and its key to the cache
+// directory, replacing the file atomically
+func (cm *CertManager) storeCachedCert(host string, cert *tls.Certificate) error {
+	if cm.cacheDir == "" {
+		return nil
+	}
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		return fmt.Errorf("failed to encode key: %w", err)
+	}
+	var buf bytes.Buffer
+	for _, der := range cert.Certificate {
+		_ = pem.Encode(&buf, &pem.Block{Type: "CERTIFICATE", Bytes: der})
+	}
+	_ = pem.Encode(&buf, &pem.Block{Type: "PRIVATE KEY", Bytes: key})
+
+	tmp, err := os.CreateTemp(cm.cacheDir, ".cert-*")
+	if err != nil {
+		return fmt.Errorf("failed to create certificate file: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write certificate file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write certificate file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), certCacheFile(cm.cacheDir, host)); err != nil {
+		return fmt.Errorf("failed to replace certificate file: %w", err)
+	}
+	return nil
+}
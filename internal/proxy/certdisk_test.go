@@ -0,0 +1,78 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCertCacheFile(t *testing.T) {
+	for host, want := range map[string]string{
+		"api.openai.com": "api.openai.com.pem",
+		"../etc/passwd":  ".._etc_passwd.pem",
+		"::1":            "__1.pem",
+	} {
+		if got := certCacheFile("/cache", host); got != filepath.Join("/cache", want) {
+			t.Errorf("certCacheFile(%q) = %s, want %s", host, got, want)
+		}
+	}
+}
+
+func TestCertManager_CacheDir(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "certs")
+	hello := &tls.ClientHelloInfo{ServerName: "api.openai.com"}
+
+	caDir := t.TempDir()
+	caPath, keyPath := filepath.Join(caDir, "ca.crt"), filepath.Join(caDir, "ca.key")
+	if err := GenerateCA(caPath, keyPath); err != nil {
+		t.Fatalf("GenerateCA failed: %v", err)
+	}
+	cm, err := NewCertManager(caPath, keyPath)
+	if err != nil {
+		t.Fatalf("NewCertManager failed: %v", err)
+	}
+	if err := cm.SetCacheDir(dir); err != nil {
+		t.Fatalf("SetCacheDir failed: %v", err)
+	}
+	cert, err := cm.GetCertificate(hello)
+	if err != nil {
+		t.Fatalf("GetCertificate failed: %v", err)
+	}
+	info, err := os.Stat(certCacheFile(dir, "api.openai.com"))
+	if err != nil {
+		t.Fatalf("certificate not persisted: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("certificate file mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	// A restarted manager with the same CA loads the certificate
+	restarted, err := NewCertManager(caPath, keyPath)
+	if err != nil {
+		t.Fatalf("NewCertManager failed: %v", err)
+	}
+	if err := restarted.SetCacheDir(dir); err != nil {
+		t.Fatalf("SetCacheDir failed: %v", err)
+	}
+	loaded, err := restarted.GetCertificate(hello)
+	if err != nil {
+		t.Fatalf("GetCertificate failed: %v", err)
+	}
+	if !loaded.Leaf.Equal(cert.Leaf) {
+		t.Error("restarted manager generated a new certificate instead of loading it")
+	}
+
+	// A manager with another CA ignores it
+	other := newTestCertManager(t)
+	if err := other.SetCacheDir(dir); err != nil {
+		t.Fatalf("SetCacheDir failed: %v", err)
+	}
+	regenerated, err := other.GetCertificate(hello)
+	if err != nil {
+		t.Fatalf("GetCertificate failed: %v", err)
+	}
+	if regenerated.Leaf.Equal(cert.Leaf) {
+		t.Error("certificate issued by another CA was loaded")
+	}
+}
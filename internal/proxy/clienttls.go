@@ -0,0 +1,50 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+)
+
+// clientTLSConfig builds the TLS configuration of a connection to Redis,
+// Kafka or a syslog collector, returning nil if TLS is disabled
+func clientTLSConfig(cfg config.ClientTLSConfig) (*tls.Config, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion:         tls.VersionTLS12,
+		ServerName:         cfg.ServerName,
+		InsecureSkipVerify: cfg.InsecureSkipVerify, //#nosec G402 -- opt-in for self-signed test deployments
+	}
+
+	if cfg.CACert != "" {
+		caPEM, err := os.ReadFile(filepath.Clean(cfg.CACert))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caPEM) {
+			return nil, fmt.Errorf("no certificates found in %s", cfg.CACert)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if cfg.ClientCert != "" || cfg.ClientKey != "" {
+		if cfg.ClientCert == "" || cfg.ClientKey == "" {
+			return nil, fmt.Errorf("client_cert and client_key must be set together")
+		}
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCert, cfg.ClientKey)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,49 @@
+package proxy
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+)
+
+func TestClientTLSConfig(t *testing.T) {
+	tlsConfig, err := clientTLSConfig(config.ClientTLSConfig{})
+	if err != nil || tlsConfig != nil {
+		t.Errorf("clientTLSConfig(disabled) = %v, %v, want nil", tlsConfig, err)
+	}
+
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "ca.crt")
+	keyPath := filepath.Join(dir, "ca.key")
+	if err := GenerateCA(certPath, keyPath); err != nil {
+		t.Fatalf("GenerateCA() error: %v", err)
+	}
+
+	tlsConfig, err = clientTLSConfig(config.ClientTLSConfig{
+		Enabled:    true,
+		CACert:     certPath,
+		ClientCert: certPath,
+		ClientKey:  keyPath,
+		ServerName: "redis.internal",
+	})
+	if err != nil {
+		t.Fatalf("clientTLSConfig() error: %v", err)
+	}
+	if tlsConfig.RootCAs == nil {
+		t.Error("RootCAs not set")
+	}
+	if len(tlsConfig.Certificates) != 1 {
+		t.Error("client certificate not loaded")
+	}
+	if tlsConfig.ServerName != "redis.internal" {
+		t.Errorf("ServerName = %q", tlsConfig.ServerName)
+	}
+
+	if _, err := clientTLSConfig(config.ClientTLSConfig{Enabled: true, ClientCert: certPath}); err == nil {
+		t.Error("clientTLSConfig() expected error for client cert without key")
+	}
+	if _, err := clientTLSConfig(config.ClientTLSConfig{Enabled: true, CACert: keyPath}); err == nil {
+		t.Error("clientTLSConfig() expected error for CA file without certificates")
+	}
+}
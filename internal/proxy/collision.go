@@ -0,0 +1,74 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hfi/llm-secret-interceptor/internal/metrics"
+	"github.com/hfi/llm-secret-interceptor/internal/storage"
+	"github.com/hfi/llm-secret-interceptor/pkg/placeholder"
+)
+
+// collisionStep is the number of hash characters added per placeholder collision
+const collisionStep = 4
+
+// maxSequenceAttempts caps the numbers tried for a numbered placeholder, as
+// each taken number costs a store round-trip
+const maxSequenceAttempts = 64
+
+// storePlaceholder stores secret under its placeholder and returns the
+// placeholder. If the placeholder already maps to a different secret, the
+// hash is lengthened until the collision is resolved. Lengthening is
+// deterministic, so every instance arrives at the same placeholder.
+// ErrPlaceholderConflict is only returned if even the full hash collides.
+// Random and numbered placeholders are only generated for secrets not
+// stored yet.
+func storePlaceholder(ctx context.Context, store storage.MappingStore, generator *placeholder.Generator, secret string, meta storage.Metadata) (string, error) {
+	if !generator.Deterministic() {
+		existing, found, err := store.LookupBySecret(ctx, secret)
+		if err != nil {
+			return generator.GenerateTyped(secret, meta.SecretType, generator.HashLength()), err
+		}
+		if found {
+			return existing, nil
+		}
+	}
+	if generator.Sequential() {
+		return storeSequential(ctx, store, generator, secret, meta)
+	}
+	_, maxLen := generator.HashLengthRange()
+	for hashLen := generator.HashLength(); ; hashLen += collisionStep {
+		ph := generator.GenerateTyped(secret, meta.SecretType, hashLen)
+		err := store.Store(ctx, ph, secret, meta)
+		if !errors.Is(err, storage.ErrPlaceholderConflict) || hashLen >= maxLen {
+			return ph, err
+		}
+		metrics.RecordPlaceholderCollision()
+	}
+}
+
+// storeSequential stores secret under the next free number of the store's
+// namespace. Stores keeping counters hand out numbers shared by all
+// instances; otherwise numbers taken by other instances or before a restart
+// are skipped, up to maxSequenceAttempts numbers per secret.
+func storeSequential(ctx context.Context, store storage.MappingStore, generator *placeholder.Generator, secret string, meta storage.Metadata) (string, error) {
+	namespace := storage.NamespaceOf(store)
+	for range maxSequenceAttempts {
+		n, ok, err := storage.NextSequence(ctx, store)
+		if err != nil {
+			// Mask the secret with a local number while the counter fails
+			return generator.GenerateSequence(generator.NextSequence(namespace), meta.SecretType), err
+		}
+		if !ok {
+			n = generator.NextSequence(namespace)
+		}
+		ph := generator.GenerateSequence(n, meta.SecretType)
+		err = store.Store(ctx, ph, secret, meta)
+		if !errors.Is(err, storage.ErrPlaceholderConflict) {
+			return ph, err
+		}
+		metrics.RecordPlaceholderCollision()
+	}
+	return "", fmt.Errorf("%w: no free placeholder number in %d attempts", storage.ErrPlaceholderConflict, maxSequenceAttempts)
+}
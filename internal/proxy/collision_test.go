@@ -0,0 +1,209 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/hfi/llm-secret-interceptor/internal/protocol"
+	"github.com/hfi/llm-secret-interceptor/internal/storage"
+	"github.com/hfi/llm-secret-interceptor/pkg/placeholder"
+)
+
+func TestStorePlaceholder_LengthensOnCollision(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore(0)
+	defer store.Close()
+	gen := placeholder.NewGenerator("__SECRET_", "__")
+
+	secret := "xY9zW8vU7tS6rQ5pO4nM3lK2"
+	short := gen.Generate(secret)
+	if err := store.Store(ctx, short, "some-other-secret", storage.Metadata{}); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+
+	ph, err := storePlaceholder(ctx, store, gen, secret, storage.Metadata{})
+	if err != nil {
+		t.Fatalf("storePlaceholder() error: %v", err)
+	}
+	if want := gen.GenerateLength(secret, gen.HashLength()+collisionStep); ph != want {
+		t.Errorf("placeholder = %q, want %q", ph, want)
+	}
+	if got, found, _ := store.Lookup(ctx, ph); !found || got != secret {
+		t.Errorf("Lookup(%q) = %q, %v", ph, got, found)
+	}
+
+	// Storing again resolves to the same lengthened placeholder
+	again, err := storePlaceholder(ctx, store, gen, secret, storage.Metadata{})
+	if err != nil || again != ph {
+		t.Errorf("storePlaceholder() again = %q, %v, want %q", again, err, ph)
+	}
+}
+
+func TestStorePlaceholder_FullHashCollision(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore(0)
+	defer store.Close()
+	gen := placeholder.NewGenerator("__SECRET_", "__")
+
+	secret := "xY9zW8vU7tS6rQ5pO4nM3lK2"
+	for n := gen.HashLength(); n <= placeholder.MaxHashLength; n += collisionStep {
+		if err := store.Store(ctx, gen.GenerateLength(secret, n), "some-other-secret", storage.Metadata{}); err != nil {
+			t.Fatalf("Store() error: %v", err)
+		}
+	}
+
+	if _, err := storePlaceholder(ctx, store, gen, secret, storage.Metadata{}); !errors.Is(err, storage.ErrPlaceholderConflict) {
+		t.Errorf("storePlaceholder() error = %v, want ErrPlaceholderConflict", err)
+	}
+}
+
+func TestSecretService_ProcessRequest_PlaceholderCollision(t *testing.T) {
+	service := setupTestService()
+	defer service.GetStore().Close()
+	handler := protocol.NewOpenAIHandler()
+	ctx := context.Background()
+
+	// Another secret already owns the placeholder of this one
+	secret := "xY9zW8vU7tS6rQ5pO4nM3lK2"
+	gen := placeholder.NewGenerator("__SECRET_", "__")
+	short := gen.Generate(secret)
+	if err := service.GetStore().Store(ctx, short, "some-other-secret", storage.Metadata{}); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+
+	result := service.ProcessRequest(ctx, []byte(`{"model":"gpt-4","messages":[{"role":"user","content":"The password is `+secret+` ok"}]}`), handler)
+	if result.Error != nil {
+		t.Fatalf("ProcessRequest() error: %v", result.Error)
+	}
+	if containsBytes(result.ModifiedBody, []byte(secret)) {
+		t.Error("secret not masked")
+	}
+	long := gen.GenerateLength(secret, gen.HashLength()+collisionStep)
+	if !containsBytes(result.ModifiedBody, []byte(long)) {
+		t.Errorf("lengthened placeholder %q not used: %s", long, result.ModifiedBody)
+	}
+
+	// The lengthened placeholder restores this secret, not the other one
+	resp := []byte(`{"id":"1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"Got ` + long + `"},"finish_reason":"stop"}]}`)
+	restored := service.ProcessResponse(ctx, resp, handler)
+	if !containsBytes(restored.ModifiedBody, []byte(secret)) {
+		t.Errorf("secret not restored: %s", restored.ModifiedBody)
+	}
+}
+
+func TestStorePlaceholder_Sequential(t *testing.T) {
+	ctx := context.Background()
+	base := storage.NewMemoryStore(0)
+	defer base.Close()
+	gen := placeholder.NewSequentialGenerator(placeholder.SequentialPrefix, placeholder.SequentialSuffix)
+	alice := storage.WithNamespace(base, "alice")
+	bob := storage.WithNamespace(base, "bob")
+
+	// Another instance already took number 1 in alice's namespace
+	if err := alice.Store(ctx, "[SECRET_1]", "taken-elsewhere", storage.Metadata{}); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+
+	tests := []struct {
+		store  storage.MappingStore
+		secret string
+		want   string
+	}{
+		{alice, "first-secret", "[SECRET_2]"},
+		{alice, "second-secret", "[SECRET_3]"},
+		{alice, "first-secret", "[SECRET_2]"},
+		{bob, "first-secret", "[SECRET_1]"},
+	}
+	for _, tt := range tests {
+		ph, err := storePlaceholder(ctx, tt.store, gen, tt.secret, storage.Metadata{})
+		if err != nil {
+			t.Fatalf("storePlaceholder(%q) error: %v", tt.secret, err)
+		}
+		if ph != tt.want {
+			t.Errorf("storePlaceholder(%q) = %q, want %q", tt.secret, ph, tt.want)
+		}
+		if got, found, _ := tt.store.Lookup(ctx, ph); !found || got != tt.secret {
+			t.Errorf("Lookup(%q) = %q, %v", ph, got, found)
+		}
+	}
+}
+
+func TestSecretService_SequentialPlaceholders(t *testing.T) {
+	base := setupTestService()
+	defer base.GetStore().Close()
+	gen := placeholder.NewSequentialGenerator(placeholder.SequentialPrefix, placeholder.SequentialSuffix)
+	service := NewSecretService(base.GetManager(), base.GetStore(), gen, protocol.NewRegistry())
+	handler := protocol.NewOpenAIHandler()
+	ctx := context.Background()
+
+	secret := "xY9zW8vU7tS6rQ5pO4nM3lK2"
+	result := service.ProcessRequest(ctx, []byte(`{"model":"gpt-4","messages":[{"role":"user","content":"The password is `+secret+` ok"}]}`), handler)
+	if result.Error != nil {
+		t.Fatalf("ProcessRequest() error: %v", result.Error)
+	}
+	if !containsBytes(result.ModifiedBody, []byte("[SECRET_1]")) {
+		t.Fatalf("numbered placeholder not used: %s", result.ModifiedBody)
+	}
+
+	resp := []byte(`{"id":"1","object":"chat.completion","choices":[{"index":0,"message":{"role":"assistant","content":"Got [SECRET_1]"},"finish_reason":"stop"}]}`)
+	restored := service.ProcessResponse(ctx, resp, handler)
+	if !containsBytes(restored.ModifiedBody, []byte(secret)) {
+		t.Errorf("secret not restored: %s", restored.ModifiedBody)
+	}
+}
+
+func TestStorePlaceholder_SequentialAttemptsCapped(t *testing.T) {
+	ctx := context.Background()
+	store := storage.NewMemoryStore(0)
+	defer store.Close()
+	gen := placeholder.NewSequentialGenerator(placeholder.SequentialPrefix, placeholder.SequentialSuffix)
+
+	// A restarted instance finds the numbers taken before the restart
+	for n := 1; n <= maxSequenceAttempts+1; n++ {
+		if err := store.Store(ctx, gen.GenerateSequence(n, ""), fmt.Sprintf("old-secret-%d", n), storage.Metadata{}); err != nil {
+			t.Fatalf("Store() error: %v", err)
+		}
+	}
+
+	if _, err := storePlaceholder(ctx, store, gen, "new-secret", storage.Metadata{}); !errors.Is(err, storage.ErrPlaceholderConflict) {
+		t.Fatalf("storePlaceholder() error = %v, want ErrPlaceholderConflict after %d attempts", err, maxSequenceAttempts)
+	}
+	// The counter moved past the tried numbers
+	ph, err := storePlaceholder(ctx, store, gen, "new-secret", storage.Metadata{})
+	if err != nil || ph != gen.GenerateSequence(maxSequenceAttempts+2, "") {
+		t.Errorf("storePlaceholder() = %q, %v, want the next free number", ph, err)
+	}
+}
+
+func TestStorePlaceholder_SequentialSharedCounter(t *testing.T) {
+	ctx := context.Background()
+	mr := miniredis.RunT(t)
+	newStore := func() storage.MappingStore {
+		store, err := storage.NewRedisStore(storage.RedisOptions{Address: mr.Addr()}, time.Hour)
+		if err != nil {
+			t.Fatalf("NewRedisStore() error: %v", err)
+		}
+		t.Cleanup(func() { _ = store.Close() })
+		return storage.WithNamespace(storage.NewInstrumentedStore(store, "redis"), "alice")
+	}
+	newGen := func() *placeholder.Generator {
+		return placeholder.NewSequentialGenerator(placeholder.SequentialPrefix, placeholder.SequentialSuffix)
+	}
+
+	// Two instances, or one before and after a restart, share the counter
+	first, err := storePlaceholder(ctx, newStore(), newGen(), "first-secret", storage.Metadata{})
+	if err != nil || first != "[SECRET_1]" {
+		t.Fatalf("storePlaceholder() = %q, %v, want [SECRET_1]", first, err)
+	}
+	second, err := storePlaceholder(ctx, newStore(), newGen(), "second-secret", storage.Metadata{})
+	if err != nil || second != "[SECRET_2]" {
+		t.Errorf("storePlaceholder() = %q, %v, want [SECRET_2]", second, err)
+	}
+	if ttl := mr.TTL("llm-secret:seq:alice\x00"); ttl != time.Hour {
+		t.Errorf("counter TTL = %v, want the store TTL", ttl)
+	}
+}
@@ -0,0 +1,162 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/metrics"
+)
+
+// errAtCapacity marks work rejected because no slot became free in time
+var errAtCapacity = errors.New("proxy at capacity")
+
+// slots is a counting semaphore with a bounded wait queue. A nil *slots
+// admits everything.
+type slots struct {
+	name    string // limit label reported with metrics
+	free    chan struct{}
+	timeout time.Duration
+}
+
+// newSlots returns a semaphore admitting n holders, or nil when n is 0.
+// Callers wait up to timeout for a slot.
+func newSlots(name string, n int, timeout time.Duration) *slots {
+	if n <= 0 {
+		return nil
+	}
+	return &slots{name: name, free: make(chan struct{}, n), timeout: timeout}
+}
+
+// acquire takes a slot, waiting while all are held. It returns a function
+// releasing the slot, or errAtCapacity once the queue timeout passes.
+func (s *slots) acquire(ctx context.Context) (func(), error) {
+	if s == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case s.free <- struct{}{}:
+	default:
+		metrics.QueuedWork.WithLabelValues(s.name).Inc()
+		err := s.wait(ctx)
+		metrics.QueuedWork.WithLabelValues(s.name).Dec()
+		if err != nil {
+			metrics.RecordCapacityRejection(s.name)
+			return nil, err
+		}
+	}
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { <-s.free })
+	}, nil
+}
+
+// wait blocks until a slot is taken, the queue timeout passes or ctx is done
+func (s *slots) wait(ctx context.Context) error {
+	timer := time.NewTimer(s.timeout)
+	defer timer.Stop()
+	select {
+	case s.free <- struct{}{}:
+		return nil
+	case <-timer.C:
+		return errAtCapacity
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// slotTransport holds a slot from sending a request until its response body
+// is closed
+type slotTransport struct {
+	base  http.RoundTripper
+	slots *slots
+}
+
+// RoundTrip sends req once a slot is free
+func (t *slotTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	release, err := t.slots.acquire(req.Context())
+	if err != nil {
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+		return nil, err
+	}
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	resp.Body = &releasingBody{ReadCloser: resp.Body, release: release}
+	return resp, nil
+}
+
+// releasingBody releases a slot when the body is closed
+type releasingBody struct {
+	io.ReadCloser
+	release func()
+}
+
+func (b *releasingBody) Close() error {
+	defer b.release()
+	return b.ReadCloser.Close()
+}
+
+// Write forwards to the body of a switched protocol response
+func (b *releasingBody) Write(p []byte) (int, error) {
+	w, ok := b.ReadCloser.(io.Writer)
+	if !ok {
+		return 0, errors.New("response body is not writable")
+	}
+	return w.Write(p)
+}
+
+// errTooManyTunnels marks CONNECT requests above the per-client tunnel cap
+var errTooManyTunnels = errors.New("too many tunnels from this client")
+
+// clientTunnels counts the open tunnels of each client. A nil
+// *clientTunnels admits everything.
+type clientTunnels struct {
+	limit  int
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// newClientTunnels returns a per-client tunnel cap, or nil when limit is 0
+func newClientTunnels(limit int) *clientTunnels {
+	if limit <= 0 {
+		return nil
+	}
+	return &clientTunnels{limit: limit, counts: make(map[string]int)}
+}
+
+// acquire counts a tunnel of client, returning a function releasing it or
+// errTooManyTunnels when the client is at its cap
+func (c *clientTunnels) acquire(client string) (func(), error) {
+	if c == nil {
+		return func() {}, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts[client] >= c.limit {
+		metrics.RecordCapacityRejection("tunnels_per_client")
+		return nil, errTooManyTunnels
+	}
+	c.counts[client]++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if c.counts[client]--; c.counts[client] == 0 {
+				delete(c.counts, client)
+			}
+		})
+	}, nil
+}
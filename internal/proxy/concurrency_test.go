@@ -0,0 +1,91 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/rs/zerolog"
+)
+
+func TestSlots(t *testing.T) {
+	if newSlots("test", 0, time.Second) != nil {
+		t.Error("newSlots(0) should disable the limit")
+	}
+	var unlimited *slots
+	if _, err := unlimited.acquire(context.Background()); err != nil {
+		t.Errorf("nil slots acquire() error: %v", err)
+	}
+
+	s := newSlots("test", 1, 20*time.Millisecond)
+	release, err := s.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire() error: %v", err)
+	}
+	if _, err := s.acquire(context.Background()); !errors.Is(err, errAtCapacity) {
+		t.Errorf("acquire() at capacity error = %v, want errAtCapacity", err)
+	}
+
+	// A queued caller gets the slot once it is released
+	go func() {
+		time.Sleep(5 * time.Millisecond)
+		release()
+		release()
+	}()
+	s.timeout = time.Second
+	second, err := s.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("queued acquire() error: %v", err)
+	}
+	second()
+	if len(s.free) != 0 {
+		t.Errorf("%d slots held after release, want 0", len(s.free))
+	}
+}
+
+func TestSlotTransport(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer upstream.Close()
+
+	transport := &slotTransport{base: http.DefaultTransport, slots: newSlots("test", 1, 20*time.Millisecond)}
+	client := &http.Client{Transport: transport}
+
+	resp, err := client.Get(upstream.URL)
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	// The slot is held while the response body is open
+	if _, err := client.Post(upstream.URL, "text/plain", strings.NewReader("x")); !errors.Is(err, errAtCapacity) {
+		t.Errorf("second request error = %v, want errAtCapacity", err)
+	}
+	_ = resp.Body.Close()
+
+	resp, err = client.Get(upstream.URL)
+	if err != nil {
+		t.Fatalf("Get() after close error: %v", err)
+	}
+	_ = resp.Body.Close()
+}
+
+func TestHandleConnect_AtCapacity(t *testing.T) {
+	s := &Server{config: config.DefaultConfig(), tunnelSlots: newSlots("tunnels", 1, 10*time.Millisecond), logger: zerolog.Nop()}
+	held, err := s.tunnelSlots.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire() error: %v", err)
+	}
+	defer held()
+
+	rec := httptest.NewRecorder()
+	s.ServeHTTP(rec, httptest.NewRequest(http.MethodConnect, "api.openai.com:443", nil))
+	if rec.Code != http.StatusServiceUnavailable || rec.Header().Get("Retry-After") == "" {
+		t.Errorf("CONNECT at capacity: status %d, Retry-After %q", rec.Code, rec.Header().Get("Retry-After"))
+	}
+}
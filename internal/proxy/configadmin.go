@@ -0,0 +1,79 @@
+package proxy
+
+import (
+	"net/http"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// configEnvironment lists the environment variables that select the
+// configuration file or stand in for unset settings
+var configEnvironment = []string{
+	"CONFIG_PATH",
+	"CONFIG_BASE_DIR",
+	adminTokenEnv,
+	"LLM_PROXY_KEK",
+	"LLM_PROXY_PLACEHOLDER_KEY",
+	"VAULT_TOKEN",
+	"AWS_ACCESS_KEY_ID",
+	"AWS_SECRET_ACCESS_KEY",
+	"AWS_SESSION_TOKEN",
+	"GOOGLE_OAUTH_ACCESS_TOKEN",
+}
+
+// EffectiveConfig is the configuration in effect with credentials redacted,
+// and the names of the configuration environment variables that are set
+type EffectiveConfig struct {
+	Config      map[string]any `json:"config" yaml:"config"`
+	Environment []string       `json:"environment" yaml:"environment"`
+}
+
+// EffectiveConfig returns the configuration the server runs with, after
+// defaults, the configuration file and reloads were applied
+func (s *Server) EffectiveConfig() (EffectiveConfig, error) {
+	tree, err := s.cfg().Redacted()
+	if err != nil {
+		return EffectiveConfig{}, err
+	}
+	environment := make([]string, 0, len(configEnvironment))
+	for _, name := range configEnvironment {
+		if _, ok := os.LookupEnv(name); ok {
+			environment = append(environment, name)
+		}
+	}
+	return EffectiveConfig{Config: tree, Environment: environment}, nil
+}
+
+// ConfigHandler serves the effective configuration as JSON, or as YAML with
+// format=yaml. It must be wrapped with RequireAdmin.
+func (s *Server) ConfigHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		effective, err := s.EffectiveConfig()
+		if err != nil {
+			s.logger.Error().Err(err).Msg("Failed to render effective config")
+			http.Error(w, "failed to render effective config", http.StatusInternalServerError)
+			return
+		}
+		if r.URL.Query().Get("format") != "yaml" {
+			s.writeAdminJSON(w, effective)
+			return
+		}
+		data, err := yaml.Marshal(effective)
+		if err != nil {
+			s.logger.Error().Err(err).Msg("Failed to render effective config")
+			http.Error(w, "failed to render effective config", http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/yaml")
+		if _, err := w.Write(data); err != nil {
+			s.logger.Debug().Err(err).Msg("Failed to write effective config")
+		}
+	})
+}
@@ -0,0 +1,56 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/rs/zerolog"
+	"gopkg.in/yaml.v3"
+)
+
+func TestServer_ConfigHandler(t *testing.T) {
+	t.Setenv("VAULT_TOKEN", "vault-token-value")
+	cfg := config.DefaultConfig()
+	cfg.Storage.Redis.Password = "redis-pass"
+	cfg.Proxy.Listen = ":18080"
+	server := &Server{config: cfg, logger: zerolog.Nop()}
+
+	rec := httptest.NewRecorder()
+	server.ConfigHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/config", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	for _, secret := range []string{"redis-pass", "vault-token-value"} {
+		if strings.Contains(rec.Body.String(), secret) {
+			t.Errorf("effective config leaks %q", secret)
+		}
+	}
+	var effective EffectiveConfig
+	if err := json.Unmarshal(rec.Body.Bytes(), &effective); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if listen := effective.Config["proxy"].(map[string]any)["listen"]; listen != ":18080" {
+		t.Errorf("proxy.listen = %v, want the running value", listen)
+	}
+	found := false
+	for _, name := range effective.Environment {
+		found = found || name == "VAULT_TOKEN"
+	}
+	if !found {
+		t.Errorf("environment = %v, want VAULT_TOKEN listed", effective.Environment)
+	}
+
+	rec = httptest.NewRecorder()
+	server.ConfigHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/config?format=yaml", nil))
+	var tree map[string]any
+	if err := yaml.Unmarshal(rec.Body.Bytes(), &tree); err != nil || rec.Header().Get("Content-Type") != "application/yaml" {
+		t.Fatalf("YAML response invalid: %v", err)
+	}
+	if _, ok := tree["config"]; !ok {
+		t.Errorf("YAML response = %v", tree)
+	}
+}
@@ -0,0 +1,184 @@
+package proxy
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// dashboardHTML is the management dashboard. It holds no data; the page
+// asks for the admin token and reads everything from the admin API.
+//
+//go:embed web/dashboard.html
+var dashboardHTML []byte
+
+// eventsKeepAlive is how often idle event streams send a comment, keeping
+// intermediaries from closing them
+const eventsKeepAlive = 15 * time.Second
+
+// Status summarizes what the proxy is doing for the dashboard
+type Status struct {
+	Mode         string                         `json:"mode"`
+	Store        StoreStatus                    `json:"store"`
+	Interceptors map[string]InterceptorSettings `json:"interceptors"`
+}
+
+// StoreStatus describes the mapping store
+type StoreStatus struct {
+	Type     string `json:"type"`
+	Mappings int    `json:"mappings"`
+}
+
+// Status returns the mode, mapping store and interceptor settings in effect
+func (s *Server) Status() Status {
+	cfg := s.cfg()
+	status := Status{
+		Mode:         cfg.Mode,
+		Store:        StoreStatus{Type: cfg.Storage.Type, Mappings: s.store.Size()},
+		Interceptors: make(map[string]InterceptorSettings),
+	}
+	for _, name := range tunableInterceptors {
+		status.Interceptors[name], _ = interceptorSettings(&cfg.Interceptors, name)
+	}
+	return status
+}
+
+// SetMode switches new requests to enforce or shadow mode until the
+// configuration is reloaded or the proxy restarts
+func (s *Server) SetMode(mode string) error {
+	if err := validateMode(mode); err != nil {
+		return err
+	}
+
+	s.tuneMu.Lock()
+	defer s.tuneMu.Unlock()
+
+	next := *s.cfg()
+	next.Mode = mode
+	if _, err := s.Reload(&next); err != nil {
+		return err
+	}
+	s.logger.Info().Str("mode", mode).Msg("Mode changed through the admin API")
+	return nil
+}
+
+// DashboardHandler serves the dashboard page. It is public; the data it
+// shows is fetched with the admin token entered on the page.
+func (s *Server) DashboardHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		header := w.Header()
+		header.Set("Content-Type", "text/html; charset=utf-8")
+		header.Set("Content-Security-Policy",
+			"default-src 'none'; script-src 'unsafe-inline'; style-src 'unsafe-inline'; connect-src 'self'; frame-ancestors 'none'")
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("Referrer-Policy", "no-referrer")
+		if r.Method == http.MethodHead {
+			return
+		}
+		if _, err := w.Write(dashboardHTML); err != nil {
+			s.logger.Debug().Err(err).Msg("Failed to write dashboard")
+		}
+	})
+}
+
+// StatusHandler serves the Status as JSON. It must be wrapped with
+// RequireAdmin.
+func (s *Server) StatusHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.writeAdminJSON(w, s.Status())
+	})
+}
+
+// ModeHandler returns the mode on GET and changes it with a PUT of
+// {"mode": "enforce" | "shadow"}. It must be wrapped with RequireAdmin.
+func (s *Server) ModeHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut:
+			var update struct {
+				Mode string `json:"mode"`
+			}
+			if !decodeAdminJSON(w, r, &update) {
+				return
+			}
+			if err := s.SetMode(update.Mode); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		s.writeAdminJSON(w, map[string]string{"mode": s.cfg().Mode})
+	})
+}
+
+// EventsHandler streams detections as server-sent "detection" events while
+// the client stays connected. It must be wrapped with RequireAdmin.
+func (s *Server) EventsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if s.stats == nil {
+			http.Error(w, "detection stats unavailable", http.StatusNotFound)
+			return
+		}
+
+		// The stream outlives the write timeout of the management server
+		controller := http.NewResponseController(w)
+		if err := controller.SetWriteDeadline(time.Time{}); err != nil {
+			s.logger.Debug().Err(err).Msg("Failed to clear event stream deadline")
+		}
+		events, unsubscribe := s.stats.subscribe()
+		defer unsubscribe()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.WriteHeader(http.StatusOK)
+		if err := controller.Flush(); err != nil {
+			s.logger.Debug().Err(err).Msg("Event stream not flushable")
+			return
+		}
+
+		keepAlive := time.NewTicker(eventsKeepAlive)
+		defer keepAlive.Stop()
+		for {
+			var err error
+			select {
+			case <-r.Context().Done():
+				return
+			case <-keepAlive.C:
+				_, err = fmt.Fprint(w, ": keep-alive\n\n")
+			case event := <-events:
+				var data []byte
+				if data, err = json.Marshal(event); err == nil {
+					_, err = fmt.Fprintf(w, "event: detection\ndata: %s\n\n", data)
+				}
+			}
+			if err == nil {
+				err = controller.Flush()
+			}
+			if err != nil {
+				s.logger.Debug().Err(err).Msg("Event stream closed")
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,125 @@
+package proxy
+
+import (
+	"bufio"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/interceptor"
+	"github.com/hfi/llm-secret-interceptor/internal/storage"
+)
+
+func newDashboardTestServer(t *testing.T) *Server {
+	t.Helper()
+	server := newInterceptorsTestServer(t, nil)
+	store := storage.NewMemoryStore(time.Hour)
+	t.Cleanup(func() { _ = store.Close() })
+	server.store = store
+	server.stats = newDetectionStats(config.DefaultConfig().Metrics.Stats)
+	return server
+}
+
+func TestDashboardHandler(t *testing.T) {
+	server := newDashboardTestServer(t)
+
+	rec := httptest.NewRecorder()
+	server.DashboardHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/dashboard", nil))
+	if rec.Code != http.StatusOK || !strings.HasPrefix(rec.Header().Get("Content-Type"), "text/html") {
+		t.Fatalf("status = %d, content type = %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	if !strings.Contains(rec.Header().Get("Content-Security-Policy"), "connect-src 'self'") {
+		t.Errorf("CSP = %q", rec.Header().Get("Content-Security-Policy"))
+	}
+	for _, endpoint := range []string{"/admin/status", "/admin/stats", "/admin/events", "/admin/mode"} {
+		if !strings.Contains(rec.Body.String(), endpoint) {
+			t.Errorf("dashboard does not use %s", endpoint)
+		}
+	}
+}
+
+func TestStatusAndModeHandlers(t *testing.T) {
+	server := newDashboardTestServer(t)
+	if err := server.store.Store(t.Context(), "[[TOKEN_1]]", "secret", storage.Metadata{}); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+
+	rec := httptest.NewRecorder()
+	server.StatusHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/status", nil))
+	var status Status
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatalf("invalid status: %v", err)
+	}
+	if status.Mode != "enforce" || status.Store.Mappings != 1 || status.Store.Type != "memory" {
+		t.Errorf("status = %+v", status)
+	}
+	if entropy := status.Interceptors["entropy"]; entropy.Enabled == nil || !*entropy.Enabled {
+		t.Errorf("entropy settings = %+v", entropy)
+	}
+
+	rec = httptest.NewRecorder()
+	server.ModeHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/admin/mode", strings.NewReader(`{"mode":"shadow"}`)))
+	if rec.Code != http.StatusOK || !server.shadow() {
+		t.Errorf("PUT shadow: status %d, shadow %v", rec.Code, server.shadow())
+	}
+
+	rec = httptest.NewRecorder()
+	server.ModeHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/admin/mode", strings.NewReader(`{"mode":"audit"}`)))
+	if rec.Code != http.StatusBadRequest || !server.shadow() {
+		t.Errorf("PUT invalid mode: status %d, shadow %v", rec.Code, server.shadow())
+	}
+}
+
+func TestEventsHandler(t *testing.T) {
+	server := newDashboardTestServer(t)
+	ts := httptest.NewServer(server.EventsHandler())
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL)
+	if err != nil {
+		t.Fatalf("GET error: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("content type = %q", resp.Header.Get("Content-Type"))
+	}
+
+	// The subscription exists once the headers are flushed
+	server.stats.record("api.openai.com", interceptor.DetectedSecret{Source: "pattern", Rule: "github_token", Type: "token"}, "mask")
+
+	reader := bufio.NewReader(resp.Body)
+	var lines []string
+	for len(lines) < 2 {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read error: %v", err)
+		}
+		lines = append(lines, strings.TrimSpace(line))
+	}
+	if lines[0] != "event: detection" {
+		t.Fatalf("event line = %q", lines[0])
+	}
+	var event DetectionEvent
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(lines[1], "data: ")), &event); err != nil {
+		t.Fatalf("invalid event data %q: %v", lines[1], err)
+	}
+	if event.Host != "api.openai.com" || event.Rule != "github_token" || event.Action != "mask" {
+		t.Errorf("event = %+v", event)
+	}
+}
+
+func TestDetectionStats_Requests(t *testing.T) {
+	stats := newDetectionStats(config.StatsConfig{Window: time.Hour})
+	stats.recordRequest("api.openai.com")
+	stats.recordRequest("api.openai.com")
+	stats.recordRequest("api.anthropic.com")
+
+	snapshot := stats.snapshot()
+	if snapshot.Total.Requests != 3 || snapshot.InWindow.RequestsByHost["api.openai.com"] != 2 {
+		t.Errorf("snapshot = %+v", snapshot)
+	}
+}
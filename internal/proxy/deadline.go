@@ -0,0 +1,39 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"github.com/hfi/llm-secret-interceptor/internal/audit"
+)
+
+// errRequestTimeout marks requests exceeding proxy.timeouts.request
+var errRequestTimeout = errors.New("request deadline exceeded")
+
+// withDeadline returns a copy of req whose context ends after the configured
+// request timeout, and the function releasing it
+func (s *Server) withDeadline(req *http.Request) (*http.Request, context.CancelFunc) {
+	timeout := s.cfg().Proxy.Timeouts.Request
+	if timeout <= 0 {
+		return req, func() {}
+	}
+	ctx, cancel := context.WithTimeoutCause(req.Context(), timeout, errRequestTimeout)
+	return req.WithContext(ctx), cancel
+}
+
+// timedOut reports whether req ran out of time, recording the timeout in
+// the audit log when it did. Failures past the deadline are reported as the
+// timeout, whatever stage they surfaced in.
+func (s *Server) timedOut(req *http.Request, stage string) bool {
+	if !errors.Is(context.Cause(req.Context()), errRequestTimeout) {
+		return false
+	}
+	s.logger.Warn().
+		Str("host", req.URL.Host).
+		Str("stage", stage).
+		Dur("timeout", s.cfg().Proxy.Timeouts.Request).
+		Msg("Request deadline exceeded")
+	s.auditor().LogError(audit.EventRequestTimeout, requestIDFromContext(req.Context()), req.URL.Host, stage+": "+errRequestTimeout.Error())
+	return true
+}
@@ -0,0 +1,79 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+)
+
+func TestForward_RequestTimeout(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		handler http.HandlerFunc
+		status  int
+		stage   string
+	}{
+		{
+			name: "hung upstream",
+			handler: func(_ http.ResponseWriter, r *http.Request) {
+				_, _ = io.ReadAll(r.Body)
+				<-r.Context().Done()
+			},
+			status: http.StatusGatewayTimeout,
+			stage:  "request",
+		},
+		{
+			name: "stalled stream",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				_, _ = io.ReadAll(r.Body)
+				w.Header().Set("Content-Type", "text/event-stream")
+				_, _ = io.WriteString(w, "data: {}\n\n")
+				w.(http.Flusher).Flush()
+				<-r.Context().Done()
+			},
+			status: http.StatusOK,
+			stage:  "stream",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewServer(tt.handler)
+			defer upstream.Close()
+			upstreamURL, _ := url.Parse(upstream.URL)
+
+			cfg := config.DefaultConfig()
+			cfg.Proxy.Timeouts.Request = 100 * time.Millisecond
+			recorder := &recordingAudit{}
+			server := newTestServer(t, cfg)
+			server.audit = recorder
+
+			body := `{"model":"gpt-4","messages":[{"role":"user","content":"hello"}]}`
+			req := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			done := make(chan struct{})
+			go func() {
+				defer close(done)
+				server.handleGateway(rec, req, upstreamURL)
+			}()
+			select {
+			case <-done:
+			case <-time.After(5 * time.Second):
+				t.Fatal("request not ended by its deadline")
+			}
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			want := "request_timeout " + upstreamURL.Host + " " + tt.stage + ": request deadline exceeded"
+			if len(recorder.errors) != 1 || recorder.errors[0] != want {
+				t.Errorf("audit events = %v, want [%s]", recorder.errors, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package proxy
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// errUnsupportedEncoding marks request bodies whose Content-Encoding cannot
+// be decoded; they are rejected rather than forwarded unscanned
+var errUnsupportedEncoding = errors.New("unsupported content encoding")
+
+// contentEncoding returns the normalized Content-Encoding of h; identity is
+// reported as no encoding
+func contentEncoding(h http.Header) string {
+	encoding := strings.ToLower(strings.TrimSpace(h.Get("Content-Encoding")))
+	if encoding == "identity" {
+		return ""
+	}
+	return encoding
+}
+
+// supportedEncoding reports whether bodies with encoding can be decoded
+func supportedEncoding(encoding string) bool {
+	switch encoding {
+	case "", "gzip", "x-gzip", "deflate":
+		return true
+	default:
+		return false
+	}
+}
+
+// newDecodingReader returns a reader decoding r according to encoding
+func newDecodingReader(encoding string, r io.Reader) (io.ReadCloser, error) {
+	switch encoding {
+	case "":
+		return io.NopCloser(r), nil
+	case "gzip", "x-gzip":
+		return gzip.NewReader(r)
+	case "deflate":
+		return zlib.NewReader(r)
+	default:
+		return nil, fmt.Errorf("%w: %q", errUnsupportedEncoding, encoding)
+	}
+}
+
+// decodeBody decodes a complete body according to encoding. Decoded bodies
+// above limit bytes fail with errRequestTooLarge; a limit of 0 means no limit.
+func decodeBody(encoding string, body []byte, limit int64) ([]byte, error) {
+	if encoding == "" {
+		return body, nil
+	}
+	reader, err := newDecodingReader(encoding, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := readLimited(reader, limit)
+	if closeErr := reader.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode %s body: %w", encoding, err)
+	}
+	return decoded, nil
+}
+
+// newEncodingWriter returns a writer encoding to w according to encoding;
+// closing it flushes the encoder but does not close w
+func newEncodingWriter(encoding string, w io.Writer) (io.WriteCloser, error) {
+	switch encoding {
+	case "":
+		return nopWriteCloser{w}, nil
+	case "gzip", "x-gzip":
+		return gzip.NewWriter(w), nil
+	case "deflate":
+		return zlib.NewWriter(w), nil
+	default:
+		return nil, fmt.Errorf("%w: %q", errUnsupportedEncoding, encoding)
+	}
+}
+
+// encodeBody encodes a complete body according to encoding
+func encodeBody(encoding string, body []byte) ([]byte, error) {
+	if encoding == "" {
+		return body, nil
+	}
+	var buf bytes.Buffer
+	writer, err := newEncodingWriter(encoding, &buf)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := writer.Write(body); err != nil {
+		return nil, fmt.Errorf("failed to encode %s body: %w", encoding, err)
+	}
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode %s body: %w", encoding, err)
+	}
+	return buf.Bytes(), nil
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+// filterAcceptEncoding removes content codings the proxy cannot decode
+// from an Accept-Encoding header, so upstreams only compress responses in
+// a way placeholders can still be restored from
+func filterAcceptEncoding(value string) string {
+	var kept []string
+	for _, coding := range strings.Split(value, ",") {
+		coding = strings.TrimSpace(coding)
+		name, _, _ := strings.Cut(coding, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "identity" || name == "*" || (name != "" && supportedEncoding(name)) {
+			kept = append(kept, coding)
+		}
+	}
+	return strings.Join(kept, ", ")
+}
@@ -0,0 +1,89 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/hfi/llm-secret-interceptor/internal/awsauth"
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/kms"
+	"github.com/hfi/llm-secret-interceptor/internal/storage"
+)
+
+// NewKeyProvider creates the key provider selected in the encryption configuration
+func NewKeyProvider(cfg config.EncryptionConfig) (kms.KeyProvider, error) {
+	switch cfg.Provider {
+	case "", "local":
+		var kek []byte
+		var err error
+		if cfg.Local.KeyFile != "" {
+			kek, err = kms.LoadLocalKey(cfg.Local.KeyFile)
+		} else if env := os.Getenv("LLM_PROXY_KEK"); env != "" {
+			kek, err = kms.ParseLocalKey(env)
+		} else {
+			return nil, fmt.Errorf("local key provider requires key_file or LLM_PROXY_KEK")
+		}
+		if err != nil {
+			return nil, err
+		}
+		return kms.NewLocalProvider(kek)
+
+	case "vault":
+		token := cfg.Vault.Token
+		if token == "" {
+			token = os.Getenv("VAULT_TOKEN")
+		}
+		return kms.NewVaultProvider(cfg.Vault.Address, token, cfg.Vault.Mount, cfg.Vault.KeyName)
+
+	case "aws-kms":
+		creds, err := awsauth.CredentialsFromEnv()
+		if err != nil {
+			return nil, err
+		}
+		return kms.NewAWSProvider(cfg.AWS.Region, cfg.AWS.KeyID, cfg.AWS.Endpoint, creds)
+
+	case "gcp-kms":
+		var tokens kms.TokenSource = kms.NewMetadataTokenSource()
+		if token := os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"); token != "" {
+			tokens = kms.StaticToken(token)
+		}
+		return kms.NewGCPProvider(cfg.GCP.KeyName, cfg.GCP.Endpoint, tokens)
+
+	default:
+		return nil, fmt.Errorf("unknown key provider %q", cfg.Provider)
+	}
+}
+
+// newEncryptedStore wraps store with envelope encryption, persisting wrapped
+// data keys in keyStore
+func newEncryptedStore(ctx context.Context, cfg config.EncryptionConfig, store storage.MappingStore, keyStore kms.KeyStore) (*storage.EncryptedStore, *kms.Keyring, error) {
+	provider, err := NewKeyProvider(cfg)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create key provider: %w", err)
+	}
+
+	keyring := kms.NewKeyring(provider, keyStore)
+	if err := keyring.Init(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to initialize keyring: %w", err)
+	}
+
+	return storage.NewEncryptedStore(store, keyring), keyring, nil
+}
+
+// RotateDataKey rotates the store's data-encryption key if the active key is
+// older than the configured rotation interval
+func (s *Server) RotateDataKey(ctx context.Context) error {
+	if s.keyring == nil || s.cfg().Storage.Encryption.RotationInterval <= 0 {
+		return nil
+	}
+
+	rotated, err := s.keyring.RotateIfOlder(ctx, s.cfg().Storage.Encryption.RotationInterval)
+	if err != nil {
+		return fmt.Errorf("failed to rotate data key: %w", err)
+	}
+	if rotated {
+		s.logger.Info().Str("key_id", s.keyring.ActiveKeyID()).Msg("Rotated store data key")
+	}
+	return nil
+}
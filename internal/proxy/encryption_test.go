@@ -0,0 +1,76 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"testing"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/kms"
+	"github.com/hfi/llm-secret-interceptor/internal/storage"
+	"github.com/rs/zerolog"
+)
+
+func TestNewKeyProvider(t *testing.T) {
+	t.Setenv("LLM_PROXY_KEK", "")
+	cfg := config.DefaultConfig().Storage.Encryption
+
+	if _, err := NewKeyProvider(cfg); err == nil {
+		t.Error("NewKeyProvider() expected error for local provider without key")
+	}
+
+	t.Setenv("LLM_PROXY_KEK", base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32)))
+	provider, err := NewKeyProvider(cfg)
+	if err != nil {
+		t.Fatalf("NewKeyProvider() error: %v", err)
+	}
+	if provider.Name() != "local" {
+		t.Errorf("Name() = %q, want local", provider.Name())
+	}
+
+	cfg.Provider = "unknown"
+	if _, err := NewKeyProvider(cfg); err == nil {
+		t.Error("NewKeyProvider() expected error for unknown provider")
+	}
+}
+
+func TestNewEncryptedStore_Rotation(t *testing.T) {
+	t.Setenv("LLM_PROXY_KEK", base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32)))
+	cfg := config.DefaultConfig()
+	cfg.Storage.Encryption.Enabled = true
+
+	store, keyring, err := newEncryptedStore(context.Background(), cfg.Storage.Encryption,
+		storage.NewMemoryStore(time.Hour), kms.NewMemoryKeyStore())
+	if err != nil {
+		t.Fatalf("newEncryptedStore() error: %v", err)
+	}
+	defer store.Close()
+
+	if err := store.Store(context.Background(), "__SECRET_12345678__", "mysecretpassword", storage.Metadata{}); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+
+	server := &Server{config: cfg, store: store, keyring: keyring, logger: zerolog.Nop()}
+	first := keyring.ActiveKeyID()
+
+	if err := server.RotateDataKey(context.Background()); err != nil {
+		t.Fatalf("RotateDataKey() error: %v", err)
+	}
+	if keyring.ActiveKeyID() != first {
+		t.Error("RotateDataKey() rotated a key younger than the rotation interval")
+	}
+
+	cfg.Storage.Encryption.RotationInterval = time.Nanosecond
+	if err := server.RotateDataKey(context.Background()); err != nil {
+		t.Fatalf("RotateDataKey() error: %v", err)
+	}
+	if keyring.ActiveKeyID() == first {
+		t.Error("RotateDataKey() did not rotate an expired key")
+	}
+
+	if got, found, _ := store.Lookup(context.Background(), "__SECRET_12345678__"); !found || got != "mysecretpassword" {
+		t.Errorf("Lookup() after rotation = %q, %v", got, found)
+	}
+}
@@ -0,0 +1,247 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"text/tabwriter"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/interceptor"
+)
+
+// Actions the proxy takes on a request or secret
+const (
+	actionForward = "forward"
+	actionMask    = "mask"
+	actionRedact  = "redact"
+	actionBlock   = "block"
+)
+
+// ExplainSecret is a detection in an explained text
+type ExplainSecret struct {
+	Interceptor string  `json:"interceptor"`
+	Rule        string  `json:"rule,omitempty"`
+	Type        string  `json:"type"`
+	Confidence  float64 `json:"confidence"`
+	Value       string  `json:"value"`
+	StartIndex  int     `json:"start"`
+	EndIndex    int     `json:"end"`
+}
+
+func newExplainSecret(secret interceptor.DetectedSecret) ExplainSecret {
+	return ExplainSecret{
+		Interceptor: secret.Source,
+		Rule:        secret.Rule,
+		Type:        secret.Type,
+		Confidence:  secret.Confidence,
+		Value:       secret.Value,
+		StartIndex:  secret.StartIndex,
+		EndIndex:    secret.EndIndex,
+	}
+}
+
+// rule returns the rule of the detection, or the interceptor for those
+// without rules
+func (e ExplainSecret) rule() string {
+	if e.Rule != "" {
+		return e.Interceptor + "/" + e.Rule
+	}
+	return e.Interceptor
+}
+
+// ExplainDropped is a detection removed by a filter or deduplication
+type ExplainDropped struct {
+	ExplainSecret
+	Stage  string `json:"stage"`
+	Reason string `json:"reason"`
+}
+
+// ExplainDecision is what the proxy does with a detected secret
+type ExplainDecision struct {
+	ExplainSecret
+	Action string `json:"action"`
+	Reason string `json:"reason"`
+}
+
+// ExplainReport tells why a text was or was not masked: every rule and
+// candidate the interceptors evaluated, the detections dropped afterwards
+// and the final decision for each secret
+type ExplainReport struct {
+	Mode string `json:"mode"`
+	// Action is what happens to a request containing the text
+	Action      string                   `json:"action"`
+	Evaluations []interceptor.Evaluation `json:"evaluations"`
+	Dropped     []ExplainDropped         `json:"dropped"`
+	Decisions   []ExplainDecision        `json:"decisions"`
+}
+
+// Explain reports how the running proxy treats text
+func (s *Server) Explain(text string) *ExplainReport {
+	return explain(s.cfg(), s.detector(), s.redaction, text)
+}
+
+// Explain reports how a proxy configured by cfg treats text
+func Explain(cfg *config.Config, text string) (*ExplainReport, error) {
+	if err := validateBlockPolicy(cfg.Policy.Block); err != nil {
+		return nil, err
+	}
+	manager, err := NewInterceptorManager(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize interceptors: %w", err)
+	}
+	generator, err := newPlaceholderGenerator(cfg.Placeholder)
+	if err != nil {
+		return nil, err
+	}
+	redaction, err := newRedactionPolicy(cfg.Placeholder.Redaction, generator)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redaction config: %w", err)
+	}
+	return explain(cfg, manager, redaction, text), nil
+}
+
+// explain follows maskSecrets, recording the reason of each decision
+func explain(cfg *config.Config, manager *interceptor.Manager, redaction *redactionPolicy, text string) *ExplainReport {
+	explanation := manager.Explain(text)
+	// Interceptors disabled in the configuration are not even registered
+	var evaluations []interceptor.Evaluation
+	for _, configured := range []struct {
+		name    string
+		enabled bool
+	}{
+		{"entropy", cfg.Interceptors.Entropy.Enabled},
+		{"pattern", cfg.Interceptors.Pattern.Enabled},
+	} {
+		if !configured.enabled && manager.Get(configured.name) == nil {
+			evaluations = append(evaluations, interceptor.Evaluation{
+				Interceptor: configured.name,
+				Outcome:     interceptor.OutcomeSkipped,
+				Reason:      fmt.Sprintf("disabled by interceptors.%s.enabled", configured.name),
+			})
+		}
+	}
+	report := &ExplainReport{
+		Mode:        cfg.Mode,
+		Action:      actionForward,
+		Evaluations: append(evaluations, explanation.Evaluations...),
+		Dropped:     make([]ExplainDropped, 0, len(explanation.Dropped)),
+		Decisions:   make([]ExplainDecision, 0, len(explanation.Secrets)),
+	}
+	for _, dropped := range explanation.Dropped {
+		report.Dropped = append(report.Dropped, ExplainDropped{
+			ExplainSecret: newExplainSecret(dropped.Secret),
+			Stage:         dropped.Stage,
+			Reason:        dropped.Reason,
+		})
+	}
+	if len(explanation.Secrets) == 0 {
+		return report
+	}
+
+	block := cfg.Policy.Block
+	_, blocked := blockingSecret(block, explanation.Secrets)
+	for _, secret := range explanation.Secrets {
+		decision := ExplainDecision{ExplainSecret: newExplainSecret(secret)}
+		switch {
+		case blocked && blocks(block, secret):
+			decision.Action = actionBlock
+			decision.Reason = fmt.Sprintf("policy.block covers type %s", secret.Type)
+		case blocked:
+			decision.Action = actionBlock
+			decision.Reason = "another secret blocks the request"
+		case redaction.redacts(secret.Type):
+			decision.Action = actionRedact
+			decision.Reason = fmt.Sprintf("placeholder.redaction covers type %s", secret.Type)
+		default:
+			decision.Action = actionMask
+			decision.Reason = "replaced by a placeholder, restored in the response"
+		}
+		// Masking any secret makes the request masked rather than redacted
+		if report.Action != actionMask {
+			report.Action = decision.Action
+		}
+		report.Decisions = append(report.Decisions, decision)
+	}
+
+	if cfg.Mode == "shadow" {
+		for i := range report.Decisions {
+			decision := &report.Decisions[i]
+			decision.Reason = fmt.Sprintf("shadow mode, enforce mode would %s: %s", decision.Action, decision.Reason)
+			decision.Action = actionForward
+		}
+		report.Action = actionForward
+	}
+	return report
+}
+
+// ExplainHandler answers POST {"text": "..."} with the ExplainReport of
+// the text. It must be wrapped with RequireAdmin.
+func (s *Server) ExplainHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req struct {
+			Text string `json:"text"`
+		}
+		if !decodeAdminJSON(w, r, &req) {
+			return
+		}
+		s.writeAdminJSON(w, s.Explain(req.Text))
+	})
+}
+
+// WriteText writes a human-readable report. Rules without a match are only
+// listed if verbose.
+func (r *ExplainReport) WriteText(w io.Writer, verbose bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "EVALUATED\tOUTCOME\tCANDIDATE\tSPAN\tREASON\n")
+	for _, evaluation := range r.Evaluations {
+		if !verbose && evaluation.Outcome == interceptor.OutcomeNoMatch {
+			continue
+		}
+		name := evaluation.Interceptor
+		if evaluation.Rule != "" {
+			name += "/" + evaluation.Rule
+		}
+		candidate, span := "", ""
+		if evaluation.Outcome != interceptor.OutcomeNoMatch && evaluation.Candidate != "" {
+			candidate = fmt.Sprintf("%q", evaluation.Candidate)
+			span = fmt.Sprintf("%d-%d", evaluation.StartIndex, evaluation.EndIndex)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", name, evaluation.Outcome, candidate, span, evaluation.Reason)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(r.Dropped) > 0 {
+		fmt.Fprintln(w, "\nDropped detections:")
+		for _, dropped := range r.Dropped {
+			fmt.Fprintf(w, "  %s %q %d-%d [%s]: %s\n", dropped.rule(), dropped.Value,
+				dropped.StartIndex, dropped.EndIndex, dropped.Stage, dropped.Reason)
+		}
+	}
+	if len(r.Decisions) > 0 {
+		fmt.Fprintln(w, "\nDecisions:")
+		for _, decision := range r.Decisions {
+			fmt.Fprintf(w, "  %s %q %d-%d (%s, %.2f): %s, %s\n", decision.rule(), decision.Value,
+				decision.StartIndex, decision.EndIndex, decision.Type, decision.Confidence, decision.Action, decision.Reason)
+		}
+	} else {
+		fmt.Fprintln(w, "\nNo secrets detected.")
+	}
+	_, err := fmt.Fprintf(w, "\nRequest (%s mode): %s\n", r.Mode, r.Action)
+	return err
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *ExplainReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,117 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/hfi/llm-secret-interceptor/internal/metrics"
+	"github.com/hfi/llm-secret-interceptor/internal/storage"
+)
+
+// Processing stages reported with failure decisions
+const (
+	stageDecode    = "decode"
+	stageParse     = "parse"
+	stageDetection = "detection"
+	stageStore     = "store"
+	stageRestore   = "restore"
+)
+
+var (
+	// errStoreUnavailable marks failures of the mapping store that reject a
+	// request in fail-closed mode
+	errStoreUnavailable = errors.New("mapping store unavailable")
+	// errUnparsableRequest marks requests rejected in fail-closed mode
+	// because their body could not be parsed for scanning
+	errUnparsableRequest = errors.New("request body cannot be scanned")
+	// errDetectionIncomplete marks requests rejected in fail-closed mode
+	// because not every interceptor ran
+	errDetectionIncomplete = errors.New("secret detection incomplete")
+)
+
+// validateFailureMode checks the proxy failure mode
+func validateFailureMode(mode string) error {
+	switch mode {
+	case "", "open", "closed":
+		return nil
+	default:
+		return fmt.Errorf("unknown failure mode %q", mode)
+	}
+}
+
+// failClosed reports whether processing failures reject the request instead
+// of letting it through
+func (s *Server) failClosed() bool {
+	return s.cfg().Proxy.FailureMode == "closed"
+}
+
+// rejectFailure records a failure at stage and reports whether the failure
+// mode rejects the request
+func (s *Server) rejectFailure(stage string) bool {
+	decision := "open"
+	if s.failClosed() {
+		decision = "closed"
+	}
+	metrics.RecordFailureDecision(stage, decision)
+	return s.failClosed()
+}
+
+// restorer looks up placeholders for restoring a response. It records
+// restored and unresolvable placeholders and remembers the first store error,
+// leaving placeholders that could not be looked up unchanged.
+type restorer struct {
+	ctx      context.Context
+	store    storage.MappingStore
+	err      error
+	restored int
+}
+
+func newRestorer(ctx context.Context, store storage.MappingStore) *restorer {
+	return &restorer{ctx: ctx, store: store}
+}
+
+// lookup resolves a placeholder; it matches placeholder.Generator.RestorePlaceholders
+func (r *restorer) lookup(ph string) (string, bool) {
+	secret, found, err := r.store.Lookup(r.ctx, ph)
+	if err != nil {
+		if r.err == nil {
+			r.err = fmt.Errorf("%w: %v", errStoreUnavailable, err)
+		}
+		return "", false
+	}
+	if found {
+		r.restored++
+		metrics.PlaceholdersRestored.Inc()
+		// Usage accounting is best effort and never blocks a restoration
+		_ = storage.RecordRestore(r.ctx, r.store, ph)
+	} else {
+		metrics.RecordPlaceholderNotFound()
+	}
+	return secret, found
+}
+
+// auditRestored audits the placeholders restore resolved, if any
+func (s *Server) auditRestored(ctx context.Context, restore *restorer) {
+	if restore.restored > 0 {
+		s.auditor().LogPlaceholderRestored(requestIDFromContext(ctx), restore.restored)
+	}
+}
+
+// errorStatus returns the status code reported to the client for err
+func errorStatus(err error, fallback int) int {
+	if errors.Is(err, errStoreUnavailable) || errors.Is(err, errDetectionIncomplete) || errors.Is(err, errAtCapacity) {
+		return http.StatusServiceUnavailable
+	}
+	if errors.Is(err, errUnparsableRequest) {
+		return http.StatusUnprocessableEntity
+	}
+	if errors.Is(err, errRequestTooLarge) {
+		return http.StatusRequestEntityTooLarge
+	}
+	if errors.Is(err, errUnsupportedEncoding) {
+		return http.StatusUnsupportedMediaType
+	}
+	return fallback
+}
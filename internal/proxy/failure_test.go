@@ -0,0 +1,227 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/interceptor"
+	"github.com/hfi/llm-secret-interceptor/internal/protocol"
+	"github.com/hfi/llm-secret-interceptor/internal/storage"
+	"github.com/hfi/llm-secret-interceptor/pkg/placeholder"
+	"github.com/rs/zerolog"
+)
+
+// unavailableStore is a mapping store whose backend is down
+type unavailableStore struct {
+	storage.MappingStore
+}
+
+func (unavailableStore) Lookup(context.Context, string) (string, bool, error) {
+	return "", false, errors.New("connection refused")
+}
+
+func newFailureTestServer(mode string) *Server {
+	cfg := config.DefaultConfig()
+	cfg.Proxy.FailureMode = mode
+	return &Server{
+		config:      cfg,
+		placeholder: placeholder.NewGenerator("__SECRET_", "__"),
+		logger:      zerolog.Nop(),
+	}
+}
+
+// slowInterceptor exhausts any detection budget before finishing
+type slowInterceptor struct{}
+
+func (slowInterceptor) Name() string { return "slow" }
+
+func (slowInterceptor) Configure(map[string]interface{}) error { return nil }
+
+func (slowInterceptor) IsEnabled() bool { return true }
+
+func (slowInterceptor) SetEnabled(bool) {}
+
+func (slowInterceptor) Detect(string) []interceptor.DetectedSecret {
+	time.Sleep(20 * time.Millisecond)
+	return nil
+}
+
+func newFailureTestRequestServer(mode string, interceptors ...interceptor.SecretInterceptor) *Server {
+	server := newFailureTestServer(mode)
+	server.registry = protocol.NewRegistry()
+	server.registry.Register(protocol.NewOpenAIHandler())
+	server.interceptors = interceptor.NewManager()
+	for _, i := range interceptors {
+		server.interceptors.Register(i)
+	}
+	return server
+}
+
+func newFailureTestResponse(body string) *http.Response {
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}
+}
+
+func TestValidateFailureMode(t *testing.T) {
+	for _, mode := range []string{"", "open", "closed"} {
+		if err := validateFailureMode(mode); err != nil {
+			t.Errorf("validateFailureMode(%q) error: %v", mode, err)
+		}
+	}
+	if err := validateFailureMode("bogus"); err == nil {
+		t.Error("validateFailureMode() expected error for unknown mode")
+	}
+}
+
+func TestProcessJSONResponse_StoreFailure(t *testing.T) {
+	store := unavailableStore{storage.NewMemoryStore(time.Hour)}
+	defer store.Close()
+	body := `{"content":"key __SECRET_12345678__"}`
+
+	open := newFailureTestServer("open")
+	resp, err := open.processJSONResponse(context.Background(), newFailureTestResponse(body), store)
+	if err != nil {
+		t.Fatalf("processJSONResponse() fail-open error: %v", err)
+	}
+	got, _ := io.ReadAll(resp.Body)
+	if string(got) != body {
+		t.Errorf("processJSONResponse() fail-open body = %s, want unchanged", got)
+	}
+
+	closed := newFailureTestServer("closed")
+	_, err = closed.processJSONResponse(context.Background(), newFailureTestResponse(body), store)
+	if !errors.Is(err, errStoreUnavailable) {
+		t.Fatalf("processJSONResponse() fail-closed error = %v, want errStoreUnavailable", err)
+	}
+	if status := errorStatus(err, http.StatusInternalServerError); status != http.StatusServiceUnavailable {
+		t.Errorf("errorStatus() = %d, want 503", status)
+	}
+}
+
+func TestProcessStreamingResponse_StoreFailureClosed(t *testing.T) {
+	store := unavailableStore{storage.NewMemoryStore(time.Hour)}
+	defer store.Close()
+
+	server := newFailureTestServer("closed")
+	resp := newFailureTestResponse("data: {\"content\":\"__SECRET_12345678__ followed by more text\"}\n\n")
+	resp.Header.Set("Content-Type", "text/event-stream")
+
+	processed, err := server.processStreamingResponse(context.Background(), resp, store)
+	if err != nil {
+		t.Fatalf("processStreamingResponse() error: %v", err)
+	}
+	if _, err := io.ReadAll(processed.Body); !errors.Is(err, errStoreUnavailable) {
+		t.Errorf("reading stream error = %v, want errStoreUnavailable", err)
+	}
+}
+
+func TestProcessRequest_ParseFailure(t *testing.T) {
+	var forwarded string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		forwarded = string(body)
+	}))
+	defer upstream.Close()
+	store := storage.NewMemoryStore(time.Hour)
+	defer store.Close()
+	body := `{"model":"gpt-4","messages":"not a list"}`
+
+	// Fail-open forwards the original body unchanged
+	open := newFailureTestRequestServer("open")
+	req := httptest.NewRequest(http.MethodPost, upstream.URL+"/v1/chat/completions", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := open.processRequest(req, store)
+	if err != nil {
+		t.Fatalf("processRequest() fail-open error: %v", err)
+	}
+	_ = resp.Body.Close()
+	if forwarded != body {
+		t.Errorf("forwarded body = %q, want %q", forwarded, body)
+	}
+
+	forwarded = ""
+	closed := newFailureTestRequestServer("closed")
+	req = httptest.NewRequest(http.MethodPost, upstream.URL+"/v1/chat/completions", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	_, err = closed.processRequest(req, store)
+	if status := errorStatus(err, http.StatusBadGateway); status != http.StatusUnprocessableEntity {
+		t.Errorf("errorStatus() = %d, want 422 (error: %v)", status, err)
+	}
+	if forwarded != "" {
+		t.Errorf("fail-closed forwarded %q", forwarded)
+	}
+}
+
+func TestProcessRequest_DetectionBudgetExceeded(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer upstream.Close()
+	store := storage.NewMemoryStore(time.Hour)
+	defer store.Close()
+	body := `{"model":"gpt-4","messages":[{"role":"user","content":"hello"}]}`
+
+	for _, mode := range []string{"open", "closed"} {
+		server := newFailureTestRequestServer(mode, slowInterceptor{}, slowInterceptor{})
+		server.config.Interceptors.DetectionBudget = time.Millisecond
+		req := httptest.NewRequest(http.MethodPost, upstream.URL+"/v1/chat/completions", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := server.processRequest(req, store)
+		if mode == "open" {
+			if err != nil {
+				t.Fatalf("processRequest() fail-open error: %v", err)
+			}
+			_ = resp.Body.Close()
+			continue
+		}
+		if !errors.Is(err, errDetectionIncomplete) || errorStatus(err, http.StatusBadGateway) != http.StatusServiceUnavailable {
+			t.Errorf("processRequest() fail-closed error = %v, want errDetectionIncomplete", err)
+		}
+	}
+}
+
+// brokenCounterStore is a mapping store whose placeholder counters are down
+type brokenCounterStore struct {
+	storage.MappingStore
+}
+
+func (brokenCounterStore) NextSequence(context.Context, string) (int, error) {
+	return 0, errors.New("connection refused")
+}
+
+func TestMaskSecrets_SequenceCounterFailureOpen(t *testing.T) {
+	store := brokenCounterStore{storage.NewMemoryStore(time.Hour)}
+	defer store.Close()
+	const secret = "xY9zW8vU7tS6rQ5pO4nM3lK2"
+
+	server := newFailureTestServer("open")
+	interceptors, err := NewInterceptorManager(server.config)
+	if err != nil {
+		t.Fatalf("NewInterceptorManager() error: %v", err)
+	}
+	server.interceptors = interceptors
+	server.placeholder = placeholder.NewSequentialGenerator(placeholder.SequentialPrefix, placeholder.SequentialSuffix)
+
+	masked, count, err := server.maskSecrets(context.Background(), context.Background(), store, "token "+secret, "api.openai.com")
+	if err != nil {
+		t.Fatalf("maskSecrets() fail-open error: %v", err)
+	}
+	if count != 1 || masked != "token [SECRET_1]" {
+		t.Errorf("maskSecrets() = %q, %d, want the secret masked with a local number", masked, count)
+	}
+
+	closed := newFailureTestServer("closed")
+	closed.interceptors = interceptors
+	closed.placeholder = server.placeholder
+	if _, _, err := closed.maskSecrets(context.Background(), context.Background(), store, "token "+secret, "api.openai.com"); !errors.Is(err, errStoreUnavailable) {
+		t.Errorf("maskSecrets() fail-closed error = %v, want errStoreUnavailable", err)
+	}
+}
@@ -0,0 +1,228 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/interceptor"
+	"github.com/hfi/llm-secret-interceptor/internal/metrics"
+	"github.com/hfi/llm-secret-interceptor/internal/storage"
+	"gopkg.in/yaml.v3"
+)
+
+// feedbackAdminPath is the path of the false positive feedback admin API
+const feedbackAdminPath = "/admin/feedback"
+
+// errNoMapping marks reported placeholders without a stored mapping
+var errNoMapping = errors.New("no mapping for placeholder")
+
+// FalsePositive reports the value behind a placeholder as no secret
+type FalsePositive struct {
+	Placeholder string `json:"placeholder"`
+	Namespace   string `json:"namespace,omitempty"`
+	Reason      string `json:"reason,omitempty"`
+}
+
+// FalsePositiveResult describes the detection a false positive was
+// reported for, without its value
+type FalsePositiveResult struct {
+	Placeholder string           `json:"placeholder"`
+	Metadata    storage.Metadata `json:"metadata"`
+	// Allowlisted is set if the value is no longer masked
+	Allowlisted bool `json:"allowlisted"`
+}
+
+// RuleFeedback counts the false positives reported for a rule. Rules are
+// named after their interceptor when it has no rules.
+type RuleFeedback struct {
+	Interceptor string `json:"interceptor"`
+	Rule        string `json:"rule"`
+	Reports     int    `json:"reports"`
+}
+
+// AllowlistEntry is a value allowed through a false positive report, kept
+// in the allowlist file by its hash
+type AllowlistEntry struct {
+	Hash        string    `yaml:"hash"`
+	SecretType  string    `yaml:"secret_type,omitempty"`
+	Interceptor string    `yaml:"interceptor,omitempty"`
+	Rule        string    `yaml:"rule,omitempty"`
+	Reason      string    `yaml:"reason,omitempty"`
+	ReportedAt  time.Time `yaml:"reported_at"`
+}
+
+// falsePositiveCounts counts reported false positives by interceptor and
+// rule since the proxy started. The zero value is ready to use.
+type falsePositiveCounts struct {
+	mu     sync.Mutex
+	counts map[RuleFeedback]int
+}
+
+// add counts a false positive of a detection described by metadata
+func (c *falsePositiveCounts) add(metadata storage.Metadata) {
+	key := RuleFeedback{Interceptor: metadata.Interceptor, Rule: metadata.Rule}
+	if key.Rule == "" {
+		key.Rule = key.Interceptor
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.counts == nil {
+		c.counts = make(map[RuleFeedback]int)
+	}
+	c.counts[key]++
+}
+
+// list returns the counts, most reported rules first
+func (c *falsePositiveCounts) list() []RuleFeedback {
+	c.mu.Lock()
+	rules := make([]RuleFeedback, 0, len(c.counts))
+	for key, reports := range c.counts {
+		key.Reports = reports
+		rules = append(rules, key)
+	}
+	c.mu.Unlock()
+	sort.Slice(rules, func(i, j int) bool {
+		if rules[i].Reports != rules[j].Reports {
+			return rules[i].Reports > rules[j].Reports
+		}
+		return rules[i].Rule < rules[j].Rule
+	})
+	return rules
+}
+
+// loadAllowlist reads the allowlist file; a missing file allows nothing
+func loadAllowlist(path string) ([]AllowlistEntry, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path) //#nosec G304 -- the allowlist file is named by the operator's configuration
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read allowlist: %w", err)
+	}
+	var entries []AllowlistEntry
+	if err := yaml.Unmarshal(data, &entries); err != nil {
+		return nil, fmt.Errorf("failed to parse allowlist %s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// ReportFalsePositive records that the value behind a placeholder is no
+// secret. With interceptors.allowlist.auto_add_reported the value is added
+// to the allowlist file and no longer masked in new requests.
+func (s *Server) ReportFalsePositive(ctx context.Context, report FalsePositive) (FalsePositiveResult, error) {
+	mapping, found, err := s.store.LookupMapping(ctx, storage.NamespacedPlaceholder(report.Namespace, report.Placeholder))
+	if err != nil {
+		return FalsePositiveResult{}, fmt.Errorf("failed to look up mapping: %w", err)
+	}
+	if !found {
+		return FalsePositiveResult{}, fmt.Errorf("%w %s", errNoMapping, report.Placeholder)
+	}
+
+	metadata := mapping.Metadata
+	metrics.RecordFalsePositive(metadata.Interceptor, metadata.Rule, metadata.SecretType)
+	s.falsePositives.add(metadata)
+	s.logger.Info().
+		Str("interceptor", metadata.Interceptor).
+		Str("rule", metadata.Rule).
+		Str("type", metadata.SecretType).
+		Msg("False positive reported")
+
+	result := FalsePositiveResult{Placeholder: report.Placeholder, Metadata: metadata}
+	if !s.cfg().Interceptors.Allowlist.AutoAddReported {
+		return result, nil
+	}
+	err = s.allowReported(AllowlistEntry{
+		Hash:        interceptor.AllowlistHash(mapping.Secret),
+		SecretType:  metadata.SecretType,
+		Interceptor: metadata.Interceptor,
+		Rule:        metadata.Rule,
+		Reason:      report.Reason,
+		ReportedAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		return result, err
+	}
+	result.Allowlisted = true
+	return result, nil
+}
+
+// allowReported adds entry to the allowlist file and applies it to new
+// requests
+func (s *Server) allowReported(entry AllowlistEntry) error {
+	s.tuneMu.Lock()
+	defer s.tuneMu.Unlock()
+
+	next := *s.cfg()
+	path := next.Interceptors.Allowlist.File
+	previous, err := loadAllowlist(path)
+	if err != nil {
+		return err
+	}
+	if slices.ContainsFunc(previous, func(e AllowlistEntry) bool { return e.Hash == entry.Hash }) {
+		return nil
+	}
+	if err := saveYAML(path, append(previous[:len(previous):len(previous)], entry), "allowlist"); err != nil {
+		return err
+	}
+	if _, err := s.Reload(&next); err != nil {
+		if restoreErr := saveYAML(path, previous, "allowlist"); restoreErr != nil {
+			s.logger.Error().Err(restoreErr).Msg("Failed to restore allowlist")
+		}
+		return err
+	}
+	s.logger.Info().Str("rule", entry.Rule).Str("type", entry.SecretType).Msg("Reported value allowlisted")
+	return nil
+}
+
+// FeedbackAdminHandler serves the false positive feedback admin API:
+//
+//	GET  /admin/feedback  lists the false positives reported per rule
+//	POST /admin/feedback  reports a placeholder given as {placeholder, namespace, reason}
+//
+// It must be wrapped with RequireAdmin.
+func (s *Server) FeedbackAdminHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			rules := s.falsePositives.list()
+			total := 0
+			for _, rule := range rules {
+				total += rule.Reports
+			}
+			s.writeAdminJSON(w, map[string]any{"rules": rules, "total": total})
+		case http.MethodPost:
+			var report FalsePositive
+			if !decodeAdminJSON(w, r, &report) {
+				return
+			}
+			if report.Placeholder == "" {
+				http.Error(w, "placeholder is required", http.StatusBadRequest)
+				return
+			}
+			result, err := s.ReportFalsePositive(r.Context(), report)
+			switch {
+			case errors.Is(err, errNoMapping):
+				http.Error(w, "mapping not found", http.StatusNotFound)
+			case err != nil:
+				s.logger.Error().Err(err).Msg("Failed to record false positive")
+				http.Error(w, "failed to record false positive", http.StatusInternalServerError)
+			default:
+				s.writeAdminJSON(w, result)
+			}
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
@@ -0,0 +1,90 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/metrics"
+)
+
+// gateway is a reverse proxy listener forwarding to a fixed upstream
+type gateway struct {
+	listen     string
+	upstream   *url.URL
+	httpServer *http.Server
+}
+
+// newGateways validates the configured gateways
+func newGateways(cfgs []config.GatewayConfig) ([]*gateway, error) {
+	gateways := make([]*gateway, 0, len(cfgs))
+	for i, cfg := range cfgs {
+		if cfg.Listen == "" {
+			return nil, fmt.Errorf("gateway %d: listen address required", i)
+		}
+		upstream, err := url.Parse(cfg.Upstream)
+		if err != nil {
+			return nil, fmt.Errorf("gateway %d: invalid upstream: %w", i, err)
+		}
+		if (upstream.Scheme != "http" && upstream.Scheme != "https") || upstream.Host == "" {
+			return nil, fmt.Errorf("gateway %d: upstream %q must be an http or https URL", i, cfg.Upstream)
+		}
+		gateways = append(gateways, &gateway{listen: cfg.Listen, upstream: upstream})
+	}
+	return gateways, nil
+}
+
+// startGateway starts serving g
+func (s *Server) startGateway(g *gateway) error {
+	s.logger.Info().Str("listen", g.listen).Str("upstream", g.upstream.String()).Msg("Starting gateway")
+
+	g.httpServer = &http.Server{
+		Addr: g.listen,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			s.handleGateway(w, r, g.upstream)
+		}),
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       120 * time.Second,
+	}
+
+	ln, err := s.listen(g.listen)
+	if err != nil {
+		return fmt.Errorf("failed to listen on gateway %s: %w", g.listen, err)
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := g.httpServer.Serve(ln); err != nil && err != http.ErrServerClosed {
+			s.logger.Error().Err(err).Str("listen", g.listen).Msg("Gateway error")
+		}
+	}()
+
+	return nil
+}
+
+// handleGateway forwards a request received by a gateway to its upstream
+func (s *Server) handleGateway(w http.ResponseWriter, r *http.Request, upstream *url.URL) {
+	metrics.RecordRequest(r.Method, upstream.Host)
+	start := time.Now()
+
+	if !s.admitClient(w, r) {
+		return
+	}
+
+	target := upstream.JoinPath(r.URL.Path)
+	if !strings.HasPrefix(target.Path, "/") {
+		// Upstreams without a base path join to a relative path
+		target.Path = "/" + target.Path
+	}
+	target.RawQuery = r.URL.RawQuery
+	r.URL = target
+	r.Host = upstream.Host
+	r.RequestURI = ""
+	s.forward(w, r, s.newConnClient(r))
+
+	metrics.RecordRequestDuration("request", time.Since(start).Seconds())
+}
@@ -0,0 +1,402 @@
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+
+	"github.com/hfi/llm-secret-interceptor/internal/audit"
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/storage"
+	adminv1 "github.com/hfi/llm-secret-interceptor/pkg/adminapi/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gopkg.in/yaml.v3"
+)
+
+// grpcAdmin serves the admin API over gRPC
+type grpcAdmin struct {
+	adminv1.UnimplementedAdminServiceServer
+	s *Server
+}
+
+// grpcReadMethods are the admin RPCs the viewer role may call
+var grpcReadMethods = map[string]bool{
+	adminv1.AdminService_Health_FullMethodName:          true,
+	adminv1.AdminService_GetStatus_FullMethodName:       true,
+	adminv1.AdminService_GetConfig_FullMethodName:       true,
+	adminv1.AdminService_ListRules_FullMethodName:       true,
+	adminv1.AdminService_ListMappings_FullMethodName:    true,
+	adminv1.AdminService_LookupMapping_FullMethodName:   true,
+	adminv1.AdminService_WatchDetections_FullMethodName: true,
+}
+
+// NewGRPCAdminServer creates a gRPC server of the admin API. Callers
+// authenticate like those of the HTTP admin API, presenting a token as
+// "authorization: Bearer <token>" metadata or a client certificate; it uses
+// the TLS settings of the management server.
+func (s *Server) NewGRPCAdminServer() (*grpc.Server, error) {
+	opts := []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			action, err := s.authorizeGRPC(ctx, info.FullMethod)
+			if err != nil {
+				return nil, err
+			}
+			resp, err := handler(ctx, req)
+			if !grpcReadMethods[info.FullMethod] {
+				action.Result = status.Code(err).String()
+				s.auditor().LogAdminAction(action)
+			}
+			return resp, err
+		}),
+		grpc.ChainStreamInterceptor(func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if _, err := s.authorizeGRPC(stream.Context(), info.FullMethod); err != nil {
+				return err
+			}
+			return handler(srv, stream)
+		}),
+	}
+	tlsConfig, err := s.ManagementTLSConfig()
+	if err != nil {
+		return nil, err
+	}
+	if tlsConfig != nil {
+		tlsConfig.NextProtos = []string{"h2"}
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+	}
+
+	server := grpc.NewServer(opts...)
+	adminv1.RegisterAdminServiceServer(server, &grpcAdmin{s: s})
+	return server, nil
+}
+
+// authorizeGRPC authenticates the caller of a gRPC admin method and checks
+// its role, auditing refused calls
+func (s *Server) authorizeGRPC(ctx context.Context, method string) (audit.AdminAction, error) {
+	action := audit.AdminAction{Action: method}
+	if !s.adminEnabled() {
+		return action, status.Error(codes.PermissionDenied, "admin API disabled: no token configured")
+	}
+	var authorization string
+	if values := metadata.ValueFromIncomingContext(ctx, "authorization"); len(values) > 0 {
+		authorization = values[0]
+	}
+	var state *tls.ConnectionState
+	if p, ok := peer.FromContext(ctx); ok {
+		action.ClientIP = p.Addr.String()
+		if host, _, err := net.SplitHostPort(action.ClientIP); err == nil {
+			action.ClientIP = host
+		}
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			state = &info.State
+		}
+	}
+
+	principal, ok := s.authenticateAdmin(authorization, state)
+	if !ok {
+		action.Result = "unauthenticated"
+		s.auditor().LogAdminAction(action)
+		return action, status.Error(codes.Unauthenticated, "unauthorized")
+	}
+	action.Principal, action.Role = principal.Name, principal.Role
+	if !principal.allows(!grpcReadMethods[method]) {
+		action.Result = "forbidden"
+		s.auditor().LogAdminAction(action)
+		return action, status.Error(codes.PermissionDenied, "forbidden: the "+principal.Role+" role may only read")
+	}
+	return action, nil
+}
+
+func (g *grpcAdmin) Health(context.Context, *adminv1.HealthRequest) (*adminv1.HealthResponse, error) {
+	return &adminv1.HealthResponse{Status: "ok", Mode: g.s.cfg().Mode}, nil
+}
+
+func (g *grpcAdmin) GetStatus(context.Context, *adminv1.GetStatusRequest) (*adminv1.GetStatusResponse, error) {
+	return &adminv1.GetStatusResponse{Status: statusProto(g.s.Status())}, nil
+}
+
+func (g *grpcAdmin) SetMode(_ context.Context, req *adminv1.SetModeRequest) (*adminv1.SetModeResponse, error) {
+	if err := g.s.SetMode(req.GetMode()); err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &adminv1.SetModeResponse{Status: statusProto(g.s.Status())}, nil
+}
+
+func (g *grpcAdmin) UpdateInterceptor(_ context.Context, req *adminv1.UpdateInterceptorRequest) (*adminv1.UpdateInterceptorResponse, error) {
+	update := req.GetSettings()
+	settings := InterceptorSettings{
+		Enabled:           update.Enabled,
+		Threshold:         update.Threshold,
+		TrustedConfidence: update.TrustedConfidence,
+	}
+	if update.MinLength != nil {
+		minLength := int(update.GetMinLength())
+		settings.MinLength = &minLength
+	}
+	if update.MaxLength != nil {
+		maxLength := int(update.GetMaxLength())
+		settings.MaxLength = &maxLength
+	}
+	if update.GetUpdateDisabledRules() {
+		settings.DisabledRules = append([]string{}, update.GetDisabledRules()...)
+	}
+
+	tuned, err := g.s.TuneInterceptor(req.GetName(), settings)
+	if errors.Is(err, errUnknownInterceptor) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &adminv1.UpdateInterceptorResponse{Settings: interceptorSettingsProto(tuned)}, nil
+}
+
+func (g *grpcAdmin) GetConfig(context.Context, *adminv1.GetConfigRequest) (*adminv1.GetConfigResponse, error) {
+	effective, err := g.s.EffectiveConfig()
+	if err == nil {
+		var data []byte
+		if data, err = yaml.Marshal(effective.Config); err == nil {
+			return &adminv1.GetConfigResponse{Yaml: string(data), Environment: effective.Environment}, nil
+		}
+	}
+	return nil, g.internal(err, "failed to render configuration")
+}
+
+func (g *grpcAdmin) ListRules(context.Context, *adminv1.ListRulesRequest) (*adminv1.ListRulesResponse, error) {
+	rules, err := g.s.PatternRules()
+	if err != nil {
+		return nil, g.internal(err, "failed to list pattern rules")
+	}
+	response := &adminv1.ListRulesResponse{Rules: make([]*adminv1.Rule, len(rules))}
+	for i, rule := range rules {
+		response.Rules[i] = ruleProto(rule)
+	}
+	return response, nil
+}
+
+func (g *grpcAdmin) AddRule(_ context.Context, req *adminv1.AddRuleRequest) (*adminv1.AddRuleResponse, error) {
+	rule := config.PatternRuleConfig{
+		Name:       req.GetName(),
+		Pattern:    req.GetPattern(),
+		Type:       req.GetType(),
+		Confidence: req.GetConfidence(),
+	}
+	err := g.s.AddPatternRule(rule)
+	switch {
+	case errors.Is(err, errRuleExists):
+		return nil, status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, errNoRuntimeRulesFile):
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	case err != nil:
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	return &adminv1.AddRuleResponse{Rule: ruleProto(RuleInfo{
+		Name:       rule.Name,
+		Pattern:    rule.Pattern,
+		Type:       rule.Type,
+		Confidence: rule.Confidence,
+		Source:     "runtime",
+	})}, nil
+}
+
+func (g *grpcAdmin) SetRuleDisabled(_ context.Context, req *adminv1.SetRuleDisabledRequest) (*adminv1.SetRuleDisabledResponse, error) {
+	rule, err := g.s.SetPatternRuleDisabled(req.GetName(), req.GetDisabled())
+	if errors.Is(err, errUnknownRule) {
+		return nil, status.Error(codes.NotFound, err.Error())
+	}
+	if err != nil {
+		return nil, g.internal(err, "failed to update pattern rule")
+	}
+	return &adminv1.SetRuleDisabledResponse{Rule: ruleProto(rule)}, nil
+}
+
+func (g *grpcAdmin) ListMappings(ctx context.Context, req *adminv1.ListMappingsRequest) (*adminv1.ListMappingsResponse, error) {
+	if req.GetLimit() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid limit")
+	}
+	filter, err := purgeFilter(req.GetFilter())
+	if err != nil {
+		return nil, err
+	}
+	mappings, err := g.s.ListMappings(ctx, filter, int(req.GetLimit()))
+	if err != nil {
+		return nil, g.internal(err, "failed to list mappings")
+	}
+	response := &adminv1.ListMappingsResponse{Mappings: make([]*adminv1.Mapping, len(mappings))}
+	for i, mapping := range mappings {
+		response.Mappings[i] = mappingProto(mapping)
+	}
+	return response, nil
+}
+
+func (g *grpcAdmin) LookupMapping(ctx context.Context, req *adminv1.LookupMappingRequest) (*adminv1.LookupMappingResponse, error) {
+	if req.GetPlaceholder() == "" {
+		return nil, status.Error(codes.InvalidArgument, "placeholder is required")
+	}
+	info, found, err := g.s.LookupMappingInfo(ctx, req.GetNamespace(), req.GetPlaceholder())
+	if err != nil {
+		return nil, g.internal(err, "failed to look up mapping")
+	}
+	if !found {
+		return nil, status.Error(codes.NotFound, "mapping not found")
+	}
+	return &adminv1.LookupMappingResponse{Mapping: mappingProto(info)}, nil
+}
+
+func (g *grpcAdmin) DeleteMapping(ctx context.Context, req *adminv1.DeleteMappingRequest) (*adminv1.DeleteMappingResponse, error) {
+	if req.GetPlaceholder() == "" {
+		return nil, status.Error(codes.InvalidArgument, "placeholder is required")
+	}
+	deleted, err := g.s.PurgeMappings(ctx, storage.PurgeFilter{Namespace: req.GetNamespace(), Placeholder: req.GetPlaceholder()})
+	if err != nil {
+		return nil, g.internal(err, "failed to delete mapping")
+	}
+	if deleted == 0 {
+		return nil, status.Error(codes.NotFound, "mapping not found")
+	}
+	return &adminv1.DeleteMappingResponse{}, nil
+}
+
+func (g *grpcAdmin) PurgeMappings(ctx context.Context, req *adminv1.PurgeMappingsRequest) (*adminv1.PurgeMappingsResponse, error) {
+	filter, err := purgeFilter(req.GetFilter())
+	if err != nil {
+		return nil, err
+	}
+	if filter.IsEmpty() && !req.GetAll() {
+		return nil, status.Error(codes.InvalidArgument, "refusing to purge all mappings without all")
+	}
+	deleted, err := g.s.PurgeMappings(ctx, filter)
+	if err != nil {
+		return nil, g.internal(err, "failed to purge mappings")
+	}
+	return &adminv1.PurgeMappingsResponse{Deleted: int64(deleted)}, nil
+}
+
+func (g *grpcAdmin) WatchDetections(_ *adminv1.WatchDetectionsRequest, stream grpc.ServerStreamingServer[adminv1.WatchDetectionsResponse]) error {
+	if g.s.stats == nil {
+		return status.Error(codes.Unavailable, "detection stats unavailable")
+	}
+	events, unsubscribe := g.s.stats.subscribe()
+	defer unsubscribe()
+	// Clients see the headers once detections are delivered
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case event := <-events:
+			err := stream.Send(&adminv1.WatchDetectionsResponse{Detection: &adminv1.Detection{
+				Time:        timestamppb.New(event.Time),
+				Host:        event.Host,
+				Interceptor: event.Interceptor,
+				Rule:        event.Rule,
+				Type:        event.Type,
+				Action:      event.Action,
+			}})
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// internal logs err and returns it as an Internal status without details
+func (g *grpcAdmin) internal(err error, msg string) error {
+	g.s.logger.Error().Err(err).Msg("Admin API: " + msg)
+	return status.Error(codes.Internal, msg)
+}
+
+// purgeFilter converts a mapping filter of the gRPC API
+func purgeFilter(filter *adminv1.MappingFilter) (storage.PurgeFilter, error) {
+	converted := storage.PurgeFilter{
+		Namespace:  filter.GetNamespace(),
+		SecretType: filter.GetSecretType(),
+		RequestID:  filter.GetRequestId(),
+	}
+	if olderThan := filter.GetOlderThan(); olderThan != nil {
+		converted.OlderThan = olderThan.AsDuration()
+		if olderThan.CheckValid() != nil || converted.OlderThan <= 0 {
+			return converted, status.Error(codes.InvalidArgument, "invalid older_than duration")
+		}
+	}
+	return converted, nil
+}
+
+// statusProto converts a Status for the gRPC API
+func statusProto(s Status) *adminv1.Status {
+	converted := &adminv1.Status{
+		Mode:         s.Mode,
+		StoreType:    s.Store.Type,
+		Mappings:     int64(s.Store.Mappings),
+		Interceptors: make(map[string]*adminv1.InterceptorSettings, len(s.Interceptors)),
+	}
+	for name, settings := range s.Interceptors {
+		converted.Interceptors[name] = interceptorSettingsProto(settings)
+	}
+	return converted
+}
+
+// interceptorSettingsProto converts InterceptorSettings for the gRPC API
+func interceptorSettingsProto(settings InterceptorSettings) *adminv1.InterceptorSettings {
+	converted := &adminv1.InterceptorSettings{
+		Enabled:           settings.Enabled,
+		Threshold:         settings.Threshold,
+		DisabledRules:     settings.DisabledRules,
+		TrustedConfidence: settings.TrustedConfidence,
+	}
+	if settings.MinLength != nil {
+		minLength := int32(min(*settings.MinLength, 1<<31-1)) //#nosec G115 -- clamped to the int32 range
+		converted.MinLength = &minLength
+	}
+	if settings.MaxLength != nil {
+		maxLength := int32(min(*settings.MaxLength, 1<<31-1)) //#nosec G115 -- clamped to the int32 range
+		converted.MaxLength = &maxLength
+	}
+	return converted
+}
+
+// ruleProto converts a RuleInfo for the gRPC API
+func ruleProto(rule RuleInfo) *adminv1.Rule {
+	return &adminv1.Rule{
+		Name:        rule.Name,
+		Pattern:     rule.Pattern,
+		Type:        rule.Type,
+		Confidence:  rule.Confidence,
+		Description: rule.Description,
+		Source:      rule.Source,
+		Disabled:    rule.Disabled,
+	}
+}
+
+// mappingProto converts a MappingInfo for the gRPC API
+func mappingProto(info MappingInfo) *adminv1.Mapping {
+	mapping := &adminv1.Mapping{
+		Placeholder:  info.Placeholder,
+		Namespace:    info.Namespace,
+		RestoreCount: info.RestoreCount,
+		SecretType:   info.Metadata.SecretType,
+		Interceptor:  info.Metadata.Interceptor,
+		Rule:         info.Metadata.Rule,
+		SourceHost:   info.Metadata.SourceHost,
+		RequestId:    info.Metadata.RequestID,
+	}
+	if !info.CreatedAt.IsZero() {
+		mapping.CreatedAt = timestamppb.New(info.CreatedAt)
+	}
+	if !info.LastUsed.IsZero() {
+		mapping.LastUsed = timestamppb.New(info.LastUsed)
+	}
+	if !info.LastRestored.IsZero() {
+		mapping.LastRestored = timestamppb.New(info.LastRestored)
+	}
+	return mapping
+}
@@ -0,0 +1,185 @@
+package proxy
+
+import (
+	"context"
+	"net"
+	"slices"
+	"testing"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/interceptor"
+	"github.com/hfi/llm-secret-interceptor/internal/storage"
+	adminv1 "github.com/hfi/llm-secret-interceptor/pkg/adminapi/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newGRPCAdminClient serves the gRPC admin API of server in memory
+func newGRPCAdminClient(t *testing.T, server *Server) adminv1.AdminServiceClient {
+	t.Helper()
+	grpcServer, err := server.NewGRPCAdminServer()
+	if err != nil {
+		t.Fatalf("NewGRPCAdminServer() error: %v", err)
+	}
+	listener := bufconn.Listen(1 << 20)
+	go func() { _ = grpcServer.Serve(listener) }()
+	t.Cleanup(grpcServer.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient() error: %v", err)
+	}
+	t.Cleanup(func() { _ = conn.Close() })
+	return adminv1.NewAdminServiceClient(conn)
+}
+
+func adminContext(t *testing.T, token string) context.Context {
+	return metadata.AppendToOutgoingContext(t.Context(), "authorization", "Bearer "+token)
+}
+
+func TestGRPCAdmin_Auth(t *testing.T) {
+	t.Setenv(adminTokenEnv, "")
+	server := newDashboardTestServer(t)
+	client := newGRPCAdminClient(t, server)
+
+	_, err := client.Health(adminContext(t, "secret"), &adminv1.HealthRequest{})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("without token: %v, want PermissionDenied", err)
+	}
+
+	server.config.Metrics.Admin.Token = "secret"
+	if _, err := client.Health(adminContext(t, "wrong"), &adminv1.HealthRequest{}); status.Code(err) != codes.Unauthenticated {
+		t.Errorf("wrong token: %v, want Unauthenticated", err)
+	}
+	health, err := client.Health(adminContext(t, "secret"), &adminv1.HealthRequest{})
+	if err != nil || health.GetMode() != "enforce" {
+		t.Errorf("Health() = %v, %v", health, err)
+	}
+
+	stream, err := client.WatchDetections(adminContext(t, "wrong"), &adminv1.WatchDetectionsRequest{})
+	if err == nil {
+		_, err = stream.Recv()
+	}
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("stream with wrong token: %v, want Unauthenticated", err)
+	}
+}
+
+func TestGRPCAdmin_Roles(t *testing.T) {
+	t.Setenv(adminTokenEnv, "")
+	server := newDashboardTestServer(t)
+	recorder := &recordingAudit{}
+	server.audit = recorder
+	server.config.Metrics.Admin.Tokens = []config.AdminTokenConfig{
+		{Name: "grafana", Token: "view", Role: RoleViewer},
+		{Name: "ci", Token: "operate", Role: RoleOperator},
+	}
+	client := newGRPCAdminClient(t, server)
+
+	if _, err := client.GetStatus(adminContext(t, "view"), &adminv1.GetStatusRequest{}); err != nil {
+		t.Errorf("viewer GetStatus() error: %v", err)
+	}
+	if _, err := client.SetMode(adminContext(t, "view"), &adminv1.SetModeRequest{Mode: "shadow"}); status.Code(err) != codes.PermissionDenied {
+		t.Errorf("viewer SetMode() error = %v, want PermissionDenied", err)
+	}
+	if _, err := client.SetMode(adminContext(t, "operate"), &adminv1.SetModeRequest{Mode: "shadow"}); err != nil {
+		t.Errorf("operator SetMode() error: %v", err)
+	}
+
+	want := []string{
+		"grafana viewer " + adminv1.AdminService_SetMode_FullMethodName + " forbidden",
+		"ci operator " + adminv1.AdminService_SetMode_FullMethodName + " OK",
+	}
+	if !slices.Equal(recorder.admin, want) {
+		t.Errorf("audit events = %q, want %q", recorder.admin, want)
+	}
+}
+
+func TestGRPCAdmin_StatusAndMode(t *testing.T) {
+	server := newDashboardTestServer(t)
+	server.config.Metrics.Admin.Token = "secret"
+	client := newGRPCAdminClient(t, server)
+	ctx := adminContext(t, "secret")
+
+	resp, err := client.SetMode(ctx, &adminv1.SetModeRequest{Mode: "shadow"})
+	if err != nil || resp.GetStatus().GetMode() != "shadow" || !server.shadow() {
+		t.Fatalf("SetMode(shadow) = %v, %v", resp, err)
+	}
+	if _, err := client.SetMode(ctx, &adminv1.SetModeRequest{Mode: "audit"}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("SetMode(audit) error = %v, want InvalidArgument", err)
+	}
+
+	disabled := false
+	tuned, err := client.UpdateInterceptor(ctx, &adminv1.UpdateInterceptorRequest{
+		Name:     "entropy",
+		Settings: &adminv1.InterceptorSettings{Enabled: &disabled},
+	})
+	if err != nil || tuned.GetSettings().GetEnabled() {
+		t.Fatalf("UpdateInterceptor() = %v, %v", tuned, err)
+	}
+	if _, err := client.UpdateInterceptor(ctx, &adminv1.UpdateInterceptorRequest{Name: "unknown", Settings: &adminv1.InterceptorSettings{}}); status.Code(err) != codes.NotFound {
+		t.Errorf("UpdateInterceptor(unknown) error = %v, want NotFound", err)
+	}
+
+	got, err := client.GetStatus(ctx, &adminv1.GetStatusRequest{})
+	if err != nil {
+		t.Fatalf("GetStatus() error: %v", err)
+	}
+	if got.GetStatus().GetStoreType() != "memory" || got.GetStatus().GetInterceptors()["entropy"].GetEnabled() {
+		t.Errorf("GetStatus() = %v", got)
+	}
+}
+
+func TestGRPCAdmin_Mappings(t *testing.T) {
+	server := newDashboardTestServer(t)
+	server.config.Metrics.Admin.Token = "secret"
+	client := newGRPCAdminClient(t, server)
+	ctx := adminContext(t, "secret")
+	if err := server.store.Store(t.Context(), "__SECRET_1__", "sk-first", storage.Metadata{SecretType: "api_key"}); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+
+	mapping, err := client.LookupMapping(ctx, &adminv1.LookupMappingRequest{Placeholder: "__SECRET_1__"})
+	if err != nil || mapping.GetMapping().GetSecretType() != "api_key" {
+		t.Fatalf("LookupMapping() = %v, %v", mapping, err)
+	}
+	if _, err := client.PurgeMappings(ctx, &adminv1.PurgeMappingsRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("PurgeMappings() without all: %v, want InvalidArgument", err)
+	}
+	if _, err := client.DeleteMapping(ctx, &adminv1.DeleteMappingRequest{Placeholder: "__SECRET_1__"}); err != nil {
+		t.Fatalf("DeleteMapping() error: %v", err)
+	}
+	if _, err := client.LookupMapping(ctx, &adminv1.LookupMappingRequest{Placeholder: "__SECRET_1__"}); status.Code(err) != codes.NotFound {
+		t.Errorf("LookupMapping() after delete: %v, want NotFound", err)
+	}
+}
+
+func TestGRPCAdmin_WatchDetections(t *testing.T) {
+	server := newDashboardTestServer(t)
+	server.config.Metrics.Admin.Token = "secret"
+	client := newGRPCAdminClient(t, server)
+
+	stream, err := client.WatchDetections(adminContext(t, "secret"), &adminv1.WatchDetectionsRequest{})
+	if err != nil {
+		t.Fatalf("WatchDetections() error: %v", err)
+	}
+	// The headers are sent once the server subscribed
+	if _, err := stream.Header(); err != nil {
+		t.Fatalf("Header() error: %v", err)
+	}
+	server.stats.record("api.openai.com", interceptor.DetectedSecret{Source: "pattern", Rule: "github_token", Type: "token"}, "mask")
+
+	resp, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv() error: %v", err)
+	}
+	if detection := resp.GetDetection(); detection.GetHost() != "api.openai.com" || detection.GetRule() != "github_token" || detection.GetAction() != "mask" {
+		t.Errorf("detection = %v", detection)
+	}
+}
@@ -0,0 +1,192 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// listenFDsEnv lists the addresses of the listening sockets handed to an
+	// upgraded process, in the order of its descriptors from fd 3 on
+	listenFDsEnv = "LLM_PROXY_LISTEN_FDS"
+	// readyFDEnv names the descriptor an upgraded process writes to once it
+	// serves on the inherited sockets
+	readyFDEnv = "LLM_PROXY_READY_FD"
+	// upgradeTimeout bounds the start of an upgraded process
+	upgradeTimeout = 30 * time.Second
+)
+
+// inheritedListeners returns the listening sockets handed over by the
+// previous process or by systemd, keyed by address
+func inheritedListeners() (map[string]net.Listener, error) {
+	value := os.Getenv(listenFDsEnv)
+	if value == "" {
+		return systemdListeners()
+	}
+	listeners := make(map[string]net.Listener)
+	for i, addr := range strings.Split(value, ",") {
+		file := os.NewFile(uintptr(3+i), addr)
+		ln, err := net.FileListener(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to use inherited listener %s: %w", addr, err)
+		}
+		if err := file.Close(); err != nil {
+			return nil, fmt.Errorf("failed to close inherited descriptor: %w", err)
+		}
+		listeners[addr] = ln
+	}
+	return listeners, nil
+}
+
+// listen returns the listener for addr, taking over a socket inherited from
+// the previous process when there is one
+func (s *Server) listen(addr string) (net.Listener, error) {
+	s.listenMu.Lock()
+	defer s.listenMu.Unlock()
+
+	ln, ok := s.inherited[addr]
+	if ok {
+		delete(s.inherited, addr)
+		s.logger.Info().Str("listen", addr).Msg("Using inherited listener")
+		if unix, ok := ln.(*net.UnixListener); ok {
+			// Remove the socket file on shutdown, as its creator would
+			unix.SetUnlinkOnClose(true)
+		}
+	} else {
+		var err error
+		if ln, err = listenSocket(addr); err != nil {
+			return nil, err
+		}
+	}
+	s.listeners = append(s.listeners, listenerAddr{addr: addr, ln: ln})
+	return ln, nil
+}
+
+// Listen opens a management listener on addr, which takes the same forms as
+// proxy listener addresses and is handed over on upgrades like them
+func (s *Server) Listen(addr string) (net.Listener, error) {
+	return s.listen(addr)
+}
+
+// listenerAddr is a listening socket and the address it was configured with
+type listenerAddr struct {
+	addr string
+	ln   net.Listener
+}
+
+// notifyUpgraded tells the previous process that this one serves on the
+// inherited listeners, and closes the ones the configuration no longer uses
+func (s *Server) notifyUpgraded() {
+	s.listenMu.Lock()
+	for addr, ln := range s.inherited {
+		s.logger.Info().Str("listen", addr).Msg("Closing unused inherited listener")
+		if err := ln.Close(); err != nil {
+			s.logger.Debug().Err(err).Msg("Failed to close inherited listener")
+		}
+	}
+	s.inherited = nil
+	s.listenMu.Unlock()
+
+	fd, err := strconv.Atoi(os.Getenv(readyFDEnv))
+	if err != nil {
+		return
+	}
+	ready := os.NewFile(uintptr(fd), "ready")
+	if _, err := ready.Write([]byte{1}); err != nil {
+		s.logger.Warn().Err(err).Msg("Failed to signal readiness to the previous process")
+	}
+	_ = ready.Close()
+}
+
+// Upgrade starts a new instance of the running executable on the listening
+// sockets of this one and returns once it serves. The caller then stops this
+// server, whose open tunnels drain while the new process accepts connections.
+func (s *Server) Upgrade() error {
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate executable: %w", err)
+	}
+
+	s.listenMu.Lock()
+	files := make([]*os.File, 0, len(s.listeners)+1)
+	addrs := make([]string, 0, len(s.listeners))
+	for _, l := range s.listeners {
+		fl, ok := l.ln.(interface{ File() (*os.File, error) })
+		if !ok {
+			continue
+		}
+		if unix, ok := l.ln.(*net.UnixListener); ok {
+			// The socket file stays in place for the upgraded process
+			unix.SetUnlinkOnClose(false)
+		}
+		file, err := fl.File()
+		if err != nil {
+			s.listenMu.Unlock()
+			closeFiles(files)
+			return fmt.Errorf("failed to duplicate listener %s: %w", l.addr, err)
+		}
+		files = append(files, file)
+		addrs = append(addrs, l.addr)
+	}
+	s.listenMu.Unlock()
+	defer closeFiles(files)
+
+	ready, readyW, err := os.Pipe()
+	if err != nil {
+		return fmt.Errorf("failed to create readiness pipe: %w", err)
+	}
+	defer func() { _ = ready.Close() }()
+	files = append(files, readyW)
+
+	cmd := exec.Command(executable, os.Args[1:]...) //#nosec G204 -- re-executes this binary with its own arguments
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.ExtraFiles = files
+	cmd.Env = append(os.Environ(),
+		listenFDsEnv+"="+strings.Join(addrs, ","),
+		readyFDEnv+"="+strconv.Itoa(3+len(addrs)),
+	)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("failed to start upgraded process: %w", err)
+	}
+	go func() { _ = cmd.Wait() }()
+	closeFiles(files[len(files)-1:])
+	files = files[:len(files)-1]
+
+	// The child writes to the pipe once it serves; the pipe only reports
+	// EOF when it exited before
+	readyErr := make(chan error, 1)
+	go func() {
+		_, err := ready.Read(make([]byte, 1))
+		readyErr <- err
+	}()
+	select {
+	case err := <-readyErr:
+		if errors.Is(err, io.EOF) {
+			return errors.New("upgraded process exited before it was ready")
+		}
+		if err != nil {
+			return fmt.Errorf("failed to wait for upgraded process: %w", err)
+		}
+	case <-time.After(upgradeTimeout):
+		_ = cmd.Process.Kill()
+		return fmt.Errorf("upgraded process not ready after %s", upgradeTimeout)
+	}
+
+	s.logger.Info().Int("pid", cmd.Process.Pid).Msg("Upgraded process is serving")
+	return nil
+}
+
+// closeFiles closes duplicated descriptors
+func closeFiles(files []*os.File) {
+	for _, f := range files {
+		_ = f.Close()
+	}
+}
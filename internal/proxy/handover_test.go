@@ -0,0 +1,118 @@
+package proxy
+
+import (
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/rs/zerolog"
+)
+
+func TestListen_Inherited(t *testing.T) {
+	inherited, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	unused, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{
+		logger:    zerolog.Nop(),
+		inherited: map[string]net.Listener{":8080": inherited, ":9000": unused},
+	}
+
+	ln, err := s.listen(":8080")
+	if err != nil {
+		t.Fatalf("listen() error: %v", err)
+	}
+	if ln != inherited {
+		t.Error("listen() did not take over the inherited listener")
+	}
+	fresh, err := s.listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen() error: %v", err)
+	}
+	defer func() { _ = fresh.Close() }()
+	defer func() { _ = ln.Close() }()
+	if len(s.listeners) != 2 {
+		t.Errorf("listeners = %d, want 2", len(s.listeners))
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = r.Close() }()
+	// notifyUpgraded closes the descriptor it is given
+	fd, err := syscall.Dup(int(w.Fd()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = w.Close()
+	t.Setenv(readyFDEnv, strconv.Itoa(fd))
+	s.notifyUpgraded()
+
+	if _, err := unused.Accept(); err == nil {
+		t.Error("unused inherited listener is still open")
+	}
+	buf := make([]byte, 1)
+	if n, err := r.Read(buf); n != 1 || err != nil {
+		t.Errorf("readiness not signalled: n=%d err=%v", n, err)
+	}
+}
+
+func TestDrainTunnels(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Proxy.DrainTimeout = 50 * time.Millisecond
+	s := &Server{config: cfg, logger: zerolog.Nop()}
+
+	s.tunnels.Add(1)
+	done := make(chan struct{})
+	go func() {
+		s.drainTunnels()
+		close(done)
+	}()
+	select {
+	case <-done:
+		t.Fatal("drainTunnels() returned while a tunnel is open")
+	case <-time.After(20 * time.Millisecond):
+	}
+	s.tunnels.Done()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("drainTunnels() did not return after the tunnel closed")
+	}
+
+	// An open tunnel is closed once the drain timeout expires
+	clientConn, peer := net.Pipe()
+	defer peer.Close()
+	s.tunnels.Add(1)
+	go func() {
+		defer s.tunnels.Done()
+		defer s.trackTunnel(clientConn)()
+		_, _ = io.Copy(io.Discard, clientConn)
+	}()
+	start := time.Now()
+	s.drainTunnels()
+	if elapsed := time.Since(start); elapsed < cfg.Proxy.DrainTimeout || elapsed > time.Second {
+		t.Errorf("drainTunnels() took %s, want about %s", elapsed, cfg.Proxy.DrainTimeout)
+	}
+	if _, err := peer.Write([]byte("x")); err == nil {
+		t.Error("tunnel connection still open after the drain timeout")
+	}
+
+	// Tunnels hijacked after draining gave up are closed right away
+	late, latePeer := net.Pipe()
+	defer latePeer.Close()
+	s.trackTunnel(late)()
+	if _, err := latePeer.Write([]byte("x")); err == nil {
+		t.Error("tunnel hijacked after draining is open")
+	}
+}
@@ -0,0 +1,114 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/storage"
+)
+
+// healthCheckTimeout bounds each health check, so probes are answered
+// before they time out
+const healthCheckTimeout = 2 * time.Second
+
+// CheckStore is a health check that fails if the mapping store backend
+// does not answer
+func (s *Server) CheckStore() (bool, string) {
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+	if err := storage.Ping(ctx, s.store); err != nil {
+		return false, err.Error()
+	}
+	return true, ""
+}
+
+// CheckCA is a health check that fails once the CA certificate expired,
+// as clients reject every certificate issued by it
+func (s *Server) CheckCA() (bool, string) {
+	if s.certManager == nil {
+		return false, "no CA loaded"
+	}
+	info := s.certManager.CAInfo()
+	if !time.Now().Before(info.NotAfter) {
+		return false, fmt.Sprintf("CA certificate expired at %s", info.NotAfter.UTC().Format(time.RFC3339))
+	}
+	return true, ""
+}
+
+// CheckUpstreams is a health check that fails if none of the gateway
+// upstreams and intercepted hosts accepts a connection. Host globs are not
+// checked.
+func (s *Server) CheckUpstreams() (bool, string) {
+	addrs := s.upstreamAddrs()
+	if len(addrs) == 0 {
+		return true, ""
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), healthCheckTimeout)
+	defer cancel()
+	var (
+		mu          sync.Mutex
+		unreachable []string
+		wg          sync.WaitGroup
+	)
+	for _, addr := range addrs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var dialer net.Dialer
+			conn, err := dialer.DialContext(ctx, "tcp", addr)
+			if err != nil {
+				mu.Lock()
+				unreachable = append(unreachable, addr)
+				mu.Unlock()
+				return
+			}
+			_ = conn.Close()
+		}()
+	}
+	wg.Wait()
+
+	if len(unreachable) < len(addrs) {
+		return true, ""
+	}
+	sort.Strings(unreachable)
+	return false, "no upstream reachable: " + strings.Join(unreachable, ", ")
+}
+
+// upstreamAddrs returns the addresses of the gateway upstreams and the
+// intercepted hosts without globs
+func (s *Server) upstreamAddrs() []string {
+	seen := make(map[string]bool)
+	var addrs []string
+	add := func(addr string) {
+		if !seen[addr] {
+			seen[addr] = true
+			addrs = append(addrs, addr)
+		}
+	}
+	for _, g := range s.gateways {
+		port := g.upstream.Port()
+		if port == "" {
+			port = "443"
+			if g.upstream.Scheme == "http" {
+				port = "80"
+			}
+		}
+		add(net.JoinHostPort(g.upstream.Hostname(), port))
+	}
+	for _, host := range s.cfg().Proxy.InterceptHosts {
+		if strings.ContainsAny(host, "*?[") {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(host); err != nil {
+			host = net.JoinHostPort(host, "443")
+		}
+		add(host)
+	}
+	return addrs
+}
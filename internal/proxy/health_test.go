@@ -0,0 +1,79 @@
+package proxy
+
+import (
+	"net"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/storage"
+)
+
+func TestCheckStore(t *testing.T) {
+	mr := miniredis.RunT(t)
+	store, err := storage.NewRedisStore(storage.RedisOptions{Address: mr.Addr()}, time.Hour)
+	if err != nil {
+		t.Fatalf("NewRedisStore() error: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	server := &Server{config: config.DefaultConfig(), store: storage.NewInstrumentedStore(store, "redis")}
+
+	if ok, msg := server.CheckStore(); !ok {
+		t.Errorf("CheckStore() = false, %q with Redis up", msg)
+	}
+	mr.Close()
+	if ok, _ := server.CheckStore(); ok {
+		t.Error("CheckStore() = true with Redis down")
+	}
+}
+
+func TestCheckCA(t *testing.T) {
+	server := &Server{config: config.DefaultConfig(), certManager: newTestCertManager(t)}
+	if ok, msg := server.CheckCA(); !ok {
+		t.Errorf("CheckCA() = false, %q for a new CA", msg)
+	}
+	if ok, _ := (&Server{config: config.DefaultConfig()}).CheckCA(); ok {
+		t.Error("CheckCA() = true without a CA")
+	}
+}
+
+func TestCheckUpstreams(t *testing.T) {
+	// A closed port refuses connections right away
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error: %v", err)
+	}
+	unreachable := closed.Addr().String()
+	_ = closed.Close()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error: %v", err)
+	}
+	t.Cleanup(func() { _ = ln.Close() })
+	reachable := ln.Addr().String()
+
+	newServer := func(upstream string, hosts ...string) *Server {
+		cfg := config.DefaultConfig()
+		cfg.Proxy.InterceptHosts = hosts
+		s := &Server{config: cfg}
+		if upstream != "" {
+			s.gateways = []*gateway{{upstream: &url.URL{Scheme: "http", Host: upstream}}}
+		}
+		return s
+	}
+
+	if ok, msg := newServer("", "*.example.com").CheckUpstreams(); !ok {
+		t.Errorf("CheckUpstreams() = false, %q with only host globs", msg)
+	}
+	if ok, msg := newServer(reachable, unreachable).CheckUpstreams(); !ok {
+		t.Errorf("CheckUpstreams() = false, %q with a reachable gateway upstream", msg)
+	}
+	ok, msg := newServer("", unreachable, "*.example.com").CheckUpstreams()
+	if ok || !strings.Contains(msg, unreachable) {
+		t.Errorf("CheckUpstreams() = %v, %q, want %s unreachable", ok, msg, unreachable)
+	}
+}
@@ -0,0 +1,37 @@
+package proxy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/interceptor"
+	"github.com/hfi/llm-secret-interceptor/internal/protocol"
+	"github.com/hfi/llm-secret-interceptor/internal/storage"
+	"github.com/hfi/llm-secret-interceptor/pkg/placeholder"
+	"github.com/rs/zerolog"
+)
+
+// newTestServer returns a server masking secrets found by the pattern
+// interceptor in OpenAI requests, backed by a memory store that is closed
+// when the test ends. A nil cfg uses the defaults.
+func newTestServer(t *testing.T, cfg *config.Config) *Server {
+	t.Helper()
+	if cfg == nil {
+		cfg = config.DefaultConfig()
+	}
+	manager := interceptor.NewManager()
+	manager.Register(interceptor.NewPatternInterceptor())
+	registry := protocol.NewRegistry()
+	registry.Register(protocol.NewOpenAIHandler())
+	store := storage.NewMemoryStore(time.Hour)
+	t.Cleanup(func() { _ = store.Close() })
+	return &Server{
+		config:       cfg,
+		registry:     registry,
+		interceptors: manager,
+		store:        store,
+		placeholder:  placeholder.NewGenerator("__SECRET_", "__"),
+		logger:       zerolog.Nop(),
+	}
+}
@@ -0,0 +1,69 @@
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+)
+
+// matchHost reports whether host, optionally with a port, matches one of
+// patterns. Patterns are case-insensitive globs: "*" matches any run of
+// characters, so "*.example.com" matches every subdomain of example.com.
+func matchHost(patterns []string, host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	for _, pattern := range patterns {
+		pattern = strings.TrimSuffix(strings.ToLower(pattern), ".")
+		if ok, _ := path.Match(pattern, host); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// validateHostPatterns checks the syntax of host patterns
+func validateHostPatterns(name string, patterns []string) error {
+	for _, pattern := range patterns {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid %s pattern %q: %w", name, pattern, err)
+		}
+	}
+	return nil
+}
+
+// validateInterceptMode checks the intercept mode and host patterns
+func validateInterceptMode(cfg config.ProxyConfig) error {
+	switch cfg.InterceptMode {
+	case "", "all", "allowlist":
+	default:
+		return fmt.Errorf("unknown intercept mode %q", cfg.InterceptMode)
+	}
+	if err := validateHostPatterns("intercept_hosts", cfg.InterceptHosts); err != nil {
+		return err
+	}
+	return validateHostPatterns("bypass_hosts", cfg.BypassHosts)
+}
+
+// intercepts reports whether CONNECT tunnels of client to host are
+// intercepted rather than relayed unchanged
+func (s *Server) intercepts(client, host string) bool {
+	return interceptsHost(s.cfg().Proxy, host) && !s.pinning.isBypassed(client, host, time.Now())
+}
+
+// interceptsHost reports whether cfg intercepts host, not counting hosts
+// bypassed for certificate pinning
+func interceptsHost(cfg config.ProxyConfig, host string) bool {
+	if matchHost(cfg.BypassHosts, host) {
+		return false
+	}
+	if cfg.InterceptMode == "allowlist" {
+		return matchHost(cfg.InterceptHosts, host)
+	}
+	return true
+}
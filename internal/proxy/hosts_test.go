@@ -0,0 +1,80 @@
+package proxy
+
+import (
+	"testing"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+)
+
+func TestMatchHost(t *testing.T) {
+	patterns := []string{"api.openai.com", "*.example.com", "bank?.test"}
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"api.openai.com", true},
+		{"API.OpenAI.com:443", true},
+		{"openai.com", false},
+		{"chat.example.com", true},
+		{"a.b.example.com:8443", true},
+		{"example.com", false},
+		{"notexample.com", false},
+		{"bank1.test", true},
+		{"bank12.test", false},
+	}
+	for _, tt := range tests {
+		if got := matchHost(patterns, tt.host); got != tt.want {
+			t.Errorf("matchHost(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestValidateHostPatterns(t *testing.T) {
+	if err := validateHostPatterns("bypass_hosts", []string{"*.example.com", "api.openai.com"}); err != nil {
+		t.Errorf("validateHostPatterns() error: %v", err)
+	}
+	if err := validateHostPatterns("bypass_hosts", []string{"[a-"}); err == nil {
+		t.Error("validateHostPatterns() should reject malformed patterns")
+	}
+}
+
+func TestServerIntercepts(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Proxy.BypassHosts = []string{"*.bank.example"}
+	server := &Server{config: cfg}
+
+	if !server.intercepts("10.0.0.1", "example.com:443") {
+		t.Error("mode all should intercept unlisted hosts")
+	}
+	if server.intercepts("10.0.0.1", "www.bank.example:443") {
+		t.Error("bypass hosts should not be intercepted")
+	}
+
+	cfg.Proxy.InterceptMode = "allowlist"
+	cfg.Proxy.InterceptHosts = []string{"api.openai.com", "*.bank.example"}
+	if !server.intercepts("10.0.0.1", "api.openai.com:443") {
+		t.Error("allowlisted host should be intercepted")
+	}
+	if server.intercepts("10.0.0.1", "example.com:443") {
+		t.Error("allowlist mode should not intercept unlisted hosts")
+	}
+	if server.intercepts("10.0.0.1", "www.bank.example:443") {
+		t.Error("bypass hosts take precedence over the allowlist")
+	}
+}
+
+func TestValidateInterceptMode(t *testing.T) {
+	cfg := config.DefaultConfig().Proxy
+	if err := validateInterceptMode(cfg); err != nil {
+		t.Errorf("validateInterceptMode() error: %v", err)
+	}
+	cfg.InterceptMode = "some"
+	if err := validateInterceptMode(cfg); err == nil {
+		t.Error("unknown intercept mode should fail")
+	}
+	cfg.InterceptMode = "allowlist"
+	cfg.InterceptHosts = []string{"[a-"}
+	if err := validateInterceptMode(cfg); err == nil {
+		t.Error("malformed intercept host should fail")
+	}
+}
@@ -0,0 +1,277 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/storage"
+)
+
+// hopHeaders are connection-specific headers that are not forwarded to the
+// intercepted client
+var hopHeaders = []string{
+	"Connection",
+	"Proxy-Connection",
+	"Keep-Alive",
+	"Proxy-Authenticate",
+	"Proxy-Authorization",
+	"Te",
+	"Trailer",
+	"Transfer-Encoding",
+	"Upgrade",
+}
+
+// protocols returns the HTTP versions offered to clients and upstream servers
+func protocols(cfg config.ProxyConfig) *http.Protocols {
+	p := new(http.Protocols)
+	p.SetHTTP1(true)
+	p.SetHTTP2(cfg.HTTP2)
+	return p
+}
+
+// nextProtos returns the ALPN protocols offered to intercepted clients
+func nextProtos(cfg config.ProxyConfig) []string {
+	if cfg.HTTP2 {
+		return []string{"h2", "http/1.1"}
+	}
+	return []string{"http/1.1"}
+}
+
+// newUpstreamTransport returns the transport forwarding requests upstream
+func newUpstreamTransport(cfg config.ProxyConfig) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Protocols = protocols(cfg)
+	return transport
+}
+
+// upstream returns the transport forwarding requests to upstream servers,
+// retrying transient failures as configured and auditing those that fail
+func (s *Server) upstream() http.RoundTripper {
+	var base http.RoundTripper = http.DefaultTransport
+	if s.transport != nil {
+		base = s.transport
+	}
+	if retry := s.cfg().Proxy.Retry; retry.MaxAttempts > 1 {
+		base = &retryTransport{base: base, policy: retry}
+	}
+	if s.upstreamSlots != nil {
+		// Retries keep the slot of their request
+		base = &slotTransport{base: base, slots: s.upstreamSlots}
+	}
+	return &auditTransport{base: base, audit: s.auditor()}
+}
+
+// serveTLSConnection serves HTTP/1.1 or HTTP/2, as negotiated via ALPN, on
+// an intercepted TLS connection until the client closes it
+func (s *Server) serveTLSConnection(clientConn *tls.Conn, targetHost string, client connClient) {
+	cfg := s.cfg().Proxy
+	ln := newConnListener(clientConn)
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			s.handleInterceptedRequest(w, req, targetHost, client)
+		}),
+		Protocols:         protocols(cfg),
+		ReadHeaderTimeout: cfg.Timeouts.ReadHeader,
+		ReadTimeout:       cfg.Timeouts.Read,
+		WriteTimeout:      cfg.Timeouts.Write,
+		IdleTimeout:       cfg.Timeouts.Idle,
+		ConnState: func(_ net.Conn, state http.ConnState) {
+			if state == http.StateClosed || state == http.StateHijacked {
+				_ = ln.Close()
+			}
+		},
+		ErrorLog: log.New(s.logger, "", 0),
+	}
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, net.ErrClosed) && err != http.ErrServerClosed {
+		s.logger.Debug().Err(err).Msg("Intercepted connection closed")
+	}
+}
+
+// handleInterceptedRequest processes a single request read from an
+// intercepted connection
+func (s *Server) handleInterceptedRequest(w http.ResponseWriter, req *http.Request, targetHost string, client connClient) {
+	// Set the correct host and scheme
+	req.URL.Scheme = "https"
+	req.URL.Host = targetHost
+	req.RequestURI = ""
+	s.forward(w, req, client)
+}
+
+// forward processes a request addressed to its upstream server and writes
+// the processed response to w. Mappings are kept in the client's namespace,
+// unless the request selects its own.
+func (s *Server) forward(w http.ResponseWriter, req *http.Request, client connClient) {
+	start := time.Now()
+	req = withAlertClient(withMaskedCount(withRequestID(req)), client.identity)
+	req.Body = countBody(req.Body, "request")
+	if tc, ok := parseTraceparent(req.Header.Get(traceparentHeader)); ok {
+		id := requestIDFromContext(req.Context())
+		s.traces.Store(id, tc)
+		defer s.traces.CompareAndDelete(id, tc)
+	}
+	s.stats.recordRequest(req.URL.Host)
+
+	// Enforce the client's rate limits
+	if s.limiter != nil {
+		release, retryAfter, err := s.limiter.acquire(s.rateLimitIdentity(client, req), time.Now())
+		if err != nil {
+			s.logger.Warn().Err(err).Str("host", req.URL.Host).Msg("Client rate limited")
+			writeRateLimited(w, req, retryAfter, err)
+			return
+		}
+		defer release()
+	}
+
+	// Scope mappings to the client's namespace
+	store := storage.WithNamespace(s.store, s.requestNamespace(client.namespace, req))
+
+	if isWebSocketUpgrade(req) {
+		s.handleWebSocket(w, req, store)
+		return
+	}
+
+	// Bound the time the upstream may take, streamed responses included
+	req, cancel := s.withDeadline(req)
+	defer cancel()
+
+	// Process and forward the request
+	req = withInterimResponses(req, w)
+	resp, err := s.processRequest(req, store)
+	if err != nil && s.timedOut(req, "request") {
+		http.Error(w, errRequestTimeout.Error(), http.StatusGatewayTimeout)
+		return
+	}
+	if errors.Is(err, errSecretBlocked) {
+		s.logger.Warn().Err(err).Str("host", req.URL.Host).Msg("Blocked request")
+		writeBlocked(w, req, s.cfg().Policy.Block.Status, err)
+		return
+	}
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to process request")
+		http.Error(w, err.Error(), errorStatus(err, http.StatusBadGateway))
+		return
+	}
+	requestID := requestIDFromContext(req.Context())
+	s.auditor().LogRequestProcessed(requestID, req.Method, req.URL.Host, req.URL.Path, milliseconds(time.Since(start)))
+
+	// Process the response
+	processedResp, err := s.processResponse(req.Context(), resp, store)
+	if err != nil {
+		if closeErr := resp.Body.Close(); closeErr != nil {
+			s.logger.Debug().Err(closeErr).Msg("Failed to close response body")
+		}
+		if s.timedOut(req, "response") {
+			http.Error(w, errRequestTimeout.Error(), http.StatusGatewayTimeout)
+			return
+		}
+		s.logger.Error().Err(err).Msg("Failed to process response")
+		http.Error(w, err.Error(), errorStatus(err, http.StatusInternalServerError))
+		return
+	}
+	defer func() {
+		if closeErr := processedResp.Body.Close(); closeErr != nil {
+			s.logger.Debug().Err(closeErr).Msg("Failed to close processed response body")
+		}
+	}()
+
+	// Write response back to client
+	s.warnMasked(req.Context(), processedResp)
+	if err := s.writeResponse(w, processedResp, start); err != nil {
+		// The status is sent, so a timeout can only cut the body short
+		if !s.timedOut(req, "stream") {
+			s.logger.Debug().Err(err).Msg("Failed to write response")
+		}
+		return
+	}
+	s.auditor().LogResponseProcessed(requestID, req.URL.Host, milliseconds(time.Since(start)))
+}
+
+// milliseconds returns d in fractional milliseconds, as audit events report durations
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// writeResponse copies resp to w, streaming bodies of unknown length as
+// configured in proxy.streaming. start is when the request was received, or
+// zero if the response is not measured.
+func (s *Server) writeResponse(w http.ResponseWriter, resp *http.Response, start time.Time) error {
+	header := w.Header()
+	for key, values := range resp.Header {
+		header[key] = append(header[key], values...)
+	}
+	for _, key := range hopHeaders {
+		header.Del(key)
+	}
+	// Trailers are only sent with chunked bodies
+	length := resp.ContentLength
+	if len(resp.Trailer) > 0 {
+		length = -1
+		for key := range resp.Trailer {
+			header.Add("Trailer", key)
+		}
+	}
+	if length >= 0 {
+		header.Set("Content-Length", strconv.FormatInt(length, 10))
+	} else {
+		header.Del("Content-Length")
+	}
+	w.WriteHeader(resp.StatusCode)
+
+	body := &countingReader{r: resp.Body, direction: "response"}
+	var err error
+	if length >= 0 {
+		_, err = io.Copy(w, body)
+	} else {
+		err = copyStream(w, body, s.cfg().Proxy.Streaming.FlushInterval, start)
+	}
+	if err != nil {
+		return err
+	}
+	// The trailer values are known once the body was read
+	for key, values := range resp.Trailer {
+		header[key] = values
+	}
+	return nil
+}
+
+// connListener is a net.Listener handing out a single accepted connection
+type connListener struct {
+	conn     net.Conn
+	accepted sync.Once
+	closed   sync.Once
+	done     chan struct{}
+}
+
+func newConnListener(conn net.Conn) *connListener {
+	return &connListener{conn: conn, done: make(chan struct{})}
+}
+
+// Accept returns the connection once, then blocks until the listener is closed
+func (l *connListener) Accept() (net.Conn, error) {
+	var conn net.Conn
+	l.accepted.Do(func() { conn = l.conn })
+	if conn != nil {
+		return conn, nil
+	}
+	<-l.done
+	return nil, net.ErrClosed
+}
+
+// Close unblocks Accept; the connection itself is closed by the HTTP server
+func (l *connListener) Close() error {
+	l.closed.Do(func() { close(l.done) })
+	return nil
+}
+
+// Addr returns the local address of the connection
+func (l *connListener) Addr() net.Addr {
+	return l.conn.LocalAddr()
+}
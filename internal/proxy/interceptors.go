@@ -0,0 +1,117 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/interceptor"
+	"gopkg.in/yaml.v3"
+)
+
+// NewInterceptorManager builds an interceptor manager with all interceptors
+// enabled in the configuration
+func NewInterceptorManager(cfg *config.Config) (*interceptor.Manager, error) {
+	manager := interceptor.NewManager()
+
+	if cfg.Interceptors.Entropy.Enabled {
+		manager.Register(interceptor.NewEntropyInterceptor(
+			cfg.Interceptors.Entropy.Threshold,
+			cfg.Interceptors.Entropy.MinLength,
+			cfg.Interceptors.Entropy.MaxLength,
+		))
+	}
+
+	if cfg.Interceptors.Pattern.Enabled {
+		pattern := interceptor.NewPatternInterceptor()
+		rules, err := configuredPatternRules(cfg.Interceptors.Pattern)
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range rules {
+			rule := r.rule
+			if err := pattern.AddRule(rule.Name, rule.Pattern, rule.Type, rule.Confidence); err != nil {
+				return nil, fmt.Errorf("invalid pattern rule %q: %w", rule.Name, err)
+			}
+		}
+		if len(cfg.Interceptors.Pattern.DisabledRules) > 0 {
+			disabled := make([]interface{}, len(cfg.Interceptors.Pattern.DisabledRules))
+			for i, name := range cfg.Interceptors.Pattern.DisabledRules {
+				disabled[i] = name
+			}
+			if err := pattern.Configure(map[string]interface{}{"disabled_rules": disabled}); err != nil {
+				return nil, fmt.Errorf("failed to configure pattern interceptor: %w", err)
+			}
+		}
+		manager.Register(pattern)
+	}
+
+	allowlist := cfg.Interceptors.Allowlist
+	if allowlist.AutoAddReported && allowlist.File == "" {
+		return nil, errors.New("interceptors.allowlist.auto_add_reported requires interceptors.allowlist.file")
+	}
+	reported, err := loadAllowlist(allowlist.File)
+	if err != nil {
+		return nil, err
+	}
+	hashes := slices.Clone(allowlist.Hashes)
+	for _, entry := range reported {
+		hashes = append(hashes, entry.Hash)
+	}
+	if len(allowlist.Values) > 0 || len(hashes) > 0 {
+		manager.AddFilter(interceptor.NewAllowlistFilter(allowlist.Values, hashes))
+	}
+
+	if cfg.Interceptors.CodeContext.Enabled {
+		manager.AddFilter(interceptor.NewCodeContextFilter(cfg.Interceptors.CodeContext.TrustedConfidence))
+	}
+
+	return manager, nil
+}
+
+// sourcedRule is a configured pattern rule and where it was defined
+type sourcedRule struct {
+	rule   config.PatternRuleConfig
+	source string
+}
+
+// configuredPatternRules returns the custom pattern rules of the
+// configuration, its rule files and the runtime rules file, in this order
+func configuredPatternRules(cfg config.PatternConfig) ([]sourcedRule, error) {
+	var rules []sourcedRule
+	for _, rule := range cfg.Rules {
+		rules = append(rules, sourcedRule{rule: rule, source: "config"})
+	}
+	for _, path := range cfg.RuleFiles {
+		fileRules, err := LoadRuleFile(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, rule := range fileRules {
+			rules = append(rules, sourcedRule{rule: rule, source: path})
+		}
+	}
+	runtimeRules, err := loadRuntimeRules(cfg.RuntimeRulesFile)
+	if err != nil {
+		return nil, err
+	}
+	for _, rule := range runtimeRules {
+		rules = append(rules, sourcedRule{rule: rule, source: "runtime"})
+	}
+	return rules, nil
+}
+
+// LoadRuleFile reads a YAML list of pattern rules
+func LoadRuleFile(path string) ([]config.PatternRuleConfig, error) {
+	data, err := os.ReadFile(path) //#nosec G304 -- rule files are named by the operator's configuration
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rule file: %w", err)
+	}
+	var rules []config.PatternRuleConfig
+	if err := yaml.Unmarshal(data, &rules); err != nil {
+		return nil, fmt.Errorf("failed to parse rule file %s: %w", path, err)
+	}
+	return rules, nil
+}
@@ -0,0 +1,45 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
+)
+
+// withInterimResponses returns a copy of req passing informational
+// responses from the upstream, such as 103 Early Hints, on to w. 100 Continue
+// is answered by the client-facing server once the body is read, and 101 is
+// handled by the WebSocket relay.
+func withInterimResponses(req *http.Request, w http.ResponseWriter) *http.Request {
+	trace := &httptrace.ClientTrace{
+		Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			if code == http.StatusContinue || code == http.StatusSwitchingProtocols {
+				return nil
+			}
+			// Interim headers must not leak into the final response
+			h := w.Header()
+			saved := h.Clone()
+			for key, values := range header {
+				h[key] = values
+			}
+			w.WriteHeader(code)
+			clear(h)
+			for key, values := range saved {
+				h[key] = values
+			}
+			return nil
+		},
+	}
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+}
+
+// forwardTrailer passes the trailer of a client request, complete once its
+// body was read, on to the upstream request. Trailers require a chunked body.
+func forwardTrailer(upstream, client *http.Request) {
+	if len(client.Trailer) == 0 {
+		return
+	}
+	upstream.Trailer = client.Trailer
+	upstream.ContentLength = -1
+	upstream.Header.Del("Content-Length")
+}
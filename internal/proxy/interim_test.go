@@ -0,0 +1,98 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"net/textproto"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+)
+
+func TestInterceptedConnection_InterimAndTrailers(t *testing.T) {
+	body := `{"model":"gpt-4","messages":[{"role":"user","content":"hello"}]}`
+
+	var gotExpect, gotTrailer string
+	upstream := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotExpect = r.Header.Get("Expect")
+		_, _ = io.ReadAll(r.Body)
+		gotTrailer = r.Trailer.Get("X-Checksum")
+
+		w.Header().Set("Link", "</style.css>; rel=preload")
+		w.WriteHeader(http.StatusEarlyHints)
+		w.Header().Del("Link")
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Trailer", "X-Usage")
+		_, _ = io.WriteString(w, `{"choices":[]}`)
+		w.Header().Set("X-Usage", "42")
+	}))
+	upstream.EnableHTTP2 = true
+	upstream.StartTLS()
+	defer upstream.Close()
+
+	for _, tt := range []struct {
+		name  string
+		http2 bool
+	}{
+		{"http2", true},
+		{"http1", false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			gotExpect, gotTrailer = "", ""
+			client := newInterceptTestClient(t, upstream, func(cfg *config.Config) {
+				cfg.Proxy.HTTP2 = tt.http2
+			})
+			client.Transport.(*http.Transport).ExpectContinueTimeout = 5 * time.Second
+
+			var interim []int
+			var link string
+			trace := &httptrace.ClientTrace{
+				Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+					interim = append(interim, code)
+					if code == http.StatusEarlyHints {
+						link = header.Get("Link")
+					}
+					return nil
+				},
+			}
+			req, _ := http.NewRequestWithContext(httptrace.WithClientTrace(t.Context(), trace),
+				http.MethodPost, upstream.URL+"/v1/chat/completions", io.NopCloser(strings.NewReader(body)))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Expect", "100-continue")
+			req.Trailer = http.Header{"X-Checksum": []string{"abc"}}
+
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error: %v", err)
+			}
+			defer resp.Body.Close()
+			if _, err := io.ReadAll(resp.Body); err != nil {
+				t.Fatalf("ReadAll() error: %v", err)
+			}
+
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d, want 200", resp.StatusCode)
+			}
+			if gotExpect != "" {
+				t.Errorf("upstream Expect = %q, want none", gotExpect)
+			}
+			if gotTrailer != "abc" {
+				t.Errorf("upstream request trailer = %q, want abc", gotTrailer)
+			}
+			if got := resp.Trailer.Get("X-Usage"); got != "42" {
+				t.Errorf("response trailer = %q, want 42", got)
+			}
+			if !slices.Contains(interim, http.StatusEarlyHints) || link == "" {
+				t.Errorf("interim responses = %v (Link %q), want 103 with Link", interim, link)
+			}
+			if resp.Header.Get("Link") != "" {
+				t.Errorf("final response carries interim header Link")
+			}
+		})
+	}
+}
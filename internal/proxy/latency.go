@@ -0,0 +1,187 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/metrics"
+)
+
+// readResult is an item read by readAhead
+type readResult[T any] struct {
+	value T
+	err   error
+}
+
+// readAhead calls next in a goroutine until it fails, so callers can wait
+// for the next item and a timer at once. The goroutine stops delivering once
+// done is closed.
+func readAhead[T any](next func() (T, error), done <-chan struct{}) <-chan readResult[T] {
+	results := make(chan readResult[T])
+	go func() {
+		for {
+			value, err := next()
+			select {
+			case results <- readResult[T]{value: value, err: err}:
+			case <-done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+	return results
+}
+
+// holdBackTimer limits how long streamed text is held back and records how
+// long it was. A zero limit never expires.
+type holdBackTimer struct {
+	limit time.Duration
+	timer *time.Timer
+	C     <-chan time.Time
+	// since is when the held back text was first held, zero if none is
+	since time.Time
+}
+
+// update starts the timer when text is held and stops it when none is
+func (h *holdBackTimer) update(held bool) {
+	switch {
+	case held && h.since.IsZero():
+		h.since = time.Now()
+	case !held && !h.since.IsZero():
+		metrics.RecordStreamHoldBack(time.Since(h.since).Seconds())
+		h.since = time.Time{}
+	}
+	switch {
+	case h.limit <= 0:
+	case held && h.C == nil:
+		h.timer = time.NewTimer(h.limit)
+		h.C = h.timer.C
+	case !held && h.C != nil:
+		h.stop()
+	}
+}
+
+// stop stops the timer; it is started again by the next update
+func (h *holdBackTimer) stop() {
+	if h.timer != nil {
+		h.timer.Stop()
+	}
+	h.C = nil
+}
+
+// streamMetrics records the metrics of a restored stream: the time to its
+// first restored byte, its chunk count and whether it was aborted
+type streamMetrics struct {
+	w       io.Writer
+	start   time.Time
+	chunks  int
+	written bool
+}
+
+// newStreamMetrics records the metrics of a stream restored to w, received
+// now
+func newStreamMetrics(w io.Writer) *streamMetrics {
+	return &streamMetrics{w: w, start: time.Now()}
+}
+
+// Write writes restored bytes, recording the time to the first
+func (m *streamMetrics) Write(p []byte) (int, error) {
+	if !m.written && len(p) > 0 {
+		m.written = true
+		metrics.RecordTimeToFirstRestoredByte(time.Since(m.start).Seconds())
+	}
+	return m.w.Write(p)
+}
+
+// chunk records a chunk read from upstream
+func (m *streamMetrics) chunk() {
+	m.chunks++
+	metrics.StreamingChunksProcessed.Inc()
+}
+
+// finish records the chunk count of the stream and, unless reason is empty,
+// that it was aborted
+func (m *streamMetrics) finish(reason string) {
+	metrics.RecordStreamChunks(m.chunks)
+	if reason != "" {
+		metrics.RecordStreamAborted(reason)
+	}
+}
+
+// copyStream copies a body of unknown length to w. Chunks are flushed as
+// they arrive, or coalesced and flushed at most every flushInterval. The
+// time to the first byte since start is recorded unless start is zero.
+func copyStream(w http.ResponseWriter, body io.Reader, flushInterval time.Duration, start time.Time) error {
+	rc := http.NewResponseController(w)
+	first := true
+	write := func(p []byte) error {
+		if _, err := w.Write(p); err != nil {
+			return err
+		}
+		if first && !start.IsZero() {
+			first = false
+			metrics.RecordTimeToFirstByte(time.Since(start).Seconds())
+		}
+		return nil
+	}
+
+	if flushInterval <= 0 {
+		buf := make([]byte, 32*1024)
+		for {
+			n, err := body.Read(buf)
+			if n > 0 {
+				if writeErr := write(buf[:n]); writeErr != nil {
+					return writeErr
+				}
+				if flushErr := rc.Flush(); flushErr != nil {
+					return flushErr
+				}
+			}
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	chunks := readAhead(func() ([]byte, error) {
+		buf := make([]byte, 32*1024)
+		n, err := body.Read(buf)
+		return buf[:n], err
+	}, done)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+	pending := false
+	for {
+		select {
+		case chunk := <-chunks:
+			if len(chunk.value) > 0 {
+				if err := write(chunk.value); err != nil {
+					return err
+				}
+				pending = true
+			}
+			if chunk.err == io.EOF {
+				return rc.Flush()
+			}
+			if chunk.err != nil {
+				return chunk.err
+			}
+		case <-ticker.C:
+			if pending {
+				pending = false
+				if err := rc.Flush(); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+// flushCounter counts the flushes of a response
+type flushCounter struct {
+	*httptest.ResponseRecorder
+	flushes int
+}
+
+func (f *flushCounter) Flush() {
+	f.flushes++
+	f.ResponseRecorder.Flush()
+}
+
+func TestCopyStream(t *testing.T) {
+	chunks := []string{"data: 1\n\n", "data: 2\n\n", "data: 3\n\n", "data: 4\n\n"}
+	tests := []struct {
+		name          string
+		flushInterval time.Duration
+		maxFlushes    int
+	}{
+		{"immediate", 0, len(chunks)},
+		{"coalesced", time.Hour, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pr, pw := io.Pipe()
+			go func() {
+				for _, chunk := range chunks {
+					_, _ = io.WriteString(pw, chunk)
+				}
+				_ = pw.Close()
+			}()
+
+			w := &flushCounter{ResponseRecorder: httptest.NewRecorder()}
+			if err := copyStream(w, pr, tt.flushInterval, time.Now()); err != nil {
+				t.Fatalf("copyStream() error: %v", err)
+			}
+			if got := w.Body.String(); got != strings.Join(chunks, "") {
+				t.Errorf("body = %q", got)
+			}
+			if w.flushes == 0 || w.flushes > tt.maxFlushes {
+				t.Errorf("flushes = %d, want 1..%d", w.flushes, tt.maxFlushes)
+			}
+		})
+	}
+}
+
+func TestProcessStreamingResponse_MaxHoldBack(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Proxy.Streaming.MaxHoldBack = 20 * time.Millisecond
+	server := newTestServer(t, cfg)
+	store := server.store
+
+	req := httptest.NewRequest(http.MethodPost, "https://api.openai.com/v1/chat/completions", nil)
+	req.Header.Set("Content-Type", "application/json")
+	pr, pw := io.Pipe()
+	defer pw.Close()
+	resp := &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+		Body:       pr,
+		Request:    req,
+	}
+	processed, err := server.processStreamingResponse(context.Background(), resp, store)
+	if err != nil {
+		t.Fatalf("processStreamingResponse() error: %v", err)
+	}
+	defer processed.Body.Close()
+
+	// The upstream pauses after text short enough to start a placeholder
+	go func() {
+		_, _ = io.WriteString(pw, `data: {"choices":[{"index":0,"delta":{"content":"Hi"}}]}`+"\n\n")
+	}()
+
+	line := make(chan string, 1)
+	go func() {
+		got, _ := bufio.NewReader(processed.Body).ReadString('\n')
+		line <- got
+	}()
+	select {
+	case got := <-line:
+		if !strings.Contains(got, `"content":"Hi"`) {
+			t.Errorf("released event = %q", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("held back text was not released")
+	}
+}
+
+func TestProcessStreamingResponse_Aborted(t *testing.T) {
+	event := `data: {"choices":[{"index":0,"delta":{},"finish_reason":"stop"}]}` + "\n\n"
+	tests := []struct {
+		name   string
+		reason string
+		// abort ends the stream after the first event
+		abort func(upstream *io.PipeWriter, processed io.Closer)
+	}{
+		{"upstream", "upstream", func(upstream *io.PipeWriter, _ io.Closer) {
+			upstream.CloseWithError(errors.New("connection reset"))
+		}},
+		{"client", "client", func(upstream *io.PipeWriter, processed io.Closer) {
+			_ = processed.Close()
+			_, _ = io.WriteString(upstream, event)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newTestServer(t, nil)
+			store := server.store
+
+			req := httptest.NewRequest(http.MethodPost, "https://api.openai.com/v1/chat/completions", nil)
+			req.Header.Set("Content-Type", "application/json")
+			pr, pw := io.Pipe()
+			defer pw.Close()
+			resp := &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"text/event-stream"}},
+				Body:       pr,
+				Request:    req,
+			}
+			aborted := metrics.StreamsAborted.WithLabelValues(tt.reason)
+			before := testutil.ToFloat64(aborted)
+			processed, err := server.processStreamingResponse(context.Background(), resp, store)
+			if err != nil {
+				t.Fatalf("processStreamingResponse() error: %v", err)
+			}
+			defer processed.Body.Close()
+
+			go func() { _, _ = io.WriteString(pw, event) }()
+			if _, err := bufio.NewReader(processed.Body).ReadString('\n'); err != nil {
+				t.Fatalf("reading first event: %v", err)
+			}
+			tt.abort(pw, processed.Body)
+
+			deadline := time.Now().Add(2 * time.Second)
+			for testutil.ToFloat64(aborted) != before+1 {
+				if time.Now().After(deadline) {
+					t.Fatalf("aborted streams (%s) = %v, want %v", tt.reason, testutil.ToFloat64(aborted), before+1)
+				}
+				time.Sleep(5 * time.Millisecond)
+			}
+		})
+	}
+}
@@ -0,0 +1,142 @@
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+)
+
+// unixPrefix marks listener addresses that are Unix socket paths
+const unixPrefix = "unix:"
+
+// unixConnKey marks the contexts of connections accepted on a Unix socket
+type unixConnKey struct{}
+
+// proxyListeners returns the configured proxy listeners; without
+// proxy.listeners this is proxy.listen with proxy.tls_cert and proxy.mtls
+func proxyListeners(cfg config.ProxyConfig) []config.ListenerConfig {
+	if len(cfg.Listeners) > 0 {
+		return cfg.Listeners
+	}
+	return []config.ListenerConfig{{Address: cfg.Listen, TLSCert: cfg.TLSCert, TLSKey: cfg.TLSKey, MTLS: cfg.MTLS}}
+}
+
+// validateListeners checks that every listener has an address
+func validateListeners(listeners []config.ListenerConfig) error {
+	for i, l := range listeners {
+		if l.Address == "" || l.Address == unixPrefix || l.Address == systemdPrefix {
+			return fmt.Errorf("listener %d: address required", i)
+		}
+	}
+	return nil
+}
+
+// listenNetwork splits a listener address into network and address
+func listenNetwork(addr string) (string, string) {
+	if path, ok := strings.CutPrefix(addr, unixPrefix); ok {
+		return "unix", path
+	}
+	return "tcp", addr
+}
+
+// listenSocket binds addr, replacing a stale Unix socket left behind by a
+// process that did not shut down cleanly
+func listenSocket(addr string) (net.Listener, error) {
+	if name, ok := strings.CutPrefix(addr, systemdPrefix); ok {
+		return nil, fmt.Errorf("no socket named %q was passed by systemd", name)
+	}
+	network, address := listenNetwork(addr)
+	if network == "unix" {
+		if info, err := os.Lstat(address); err == nil && info.Mode().Type() == fs.ModeSocket {
+			if err := os.Remove(address); err != nil {
+				return nil, fmt.Errorf("failed to remove stale socket: %w", err)
+			}
+		}
+	}
+	lc := net.ListenConfig{}
+	return lc.Listen(context.Background(), network, address)
+}
+
+// startListener serves the proxy on l
+func (s *Server) startListener(l config.ListenerConfig) error {
+	tlsConfig, err := s.listenerTLSConfig(l)
+	if err != nil {
+		return fmt.Errorf("invalid tls config for listener %s: %w", l.Address, err)
+	}
+
+	ln, err := s.listen(l.Address)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", l.Address, err)
+	}
+	if tlsConfig != nil {
+		// CONNECT requests arrive encrypted, and with mTLS only from clients
+		// with a certificate from the client CA
+		ln = tls.NewListener(ln, tlsConfig)
+	}
+	s.logger.Info().
+		Str("listen", l.Address).
+		Bool("tls", tlsConfig != nil).
+		Bool("mtls", l.MTLS.ClientCA != "").
+		Msg("Starting proxy server")
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.httpServer.Serve(ln); err != nil && err != http.ErrServerClosed {
+			s.logger.Error().Err(err).Str("listen", l.Address).Msg("Server error")
+		}
+	}()
+	return nil
+}
+
+// listenerTLSConfig returns the TLS configuration of l, or nil if it
+// serves plaintext
+func (s *Server) listenerTLSConfig(l config.ListenerConfig) (*tls.Config, error) {
+	if l.TLSCert == "" && l.TLSKey == "" {
+		return newMTLSConfig(l.MTLS, s.listenerCertificate())
+	}
+	if l.TLSCert == "" || l.TLSKey == "" {
+		return nil, fmt.Errorf("tls_cert and tls_key must be set together")
+	}
+	cert, err := tls.LoadX509KeyPair(l.TLSCert, l.TLSKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load listener certificate: %w", err)
+	}
+	if l.MTLS.ClientCA != "" {
+		return newMTLSConfig(l.MTLS, func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return &cert, nil
+		})
+	}
+	// CONNECT is an HTTP/1.1 request: the tunnel takes over the connection
+	return &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		Certificates: []tls.Certificate{cert},
+		NextProtos:   []string{"http/1.1"},
+	}, nil
+}
+
+// markUnixConn records in the connection context whether c was accepted on
+// a Unix socket
+func markUnixConn(ctx context.Context, c net.Conn) context.Context {
+	if tlsConn, ok := c.(*tls.Conn); ok {
+		c = tlsConn.NetConn()
+	}
+	if c.LocalAddr().Network() == "unix" {
+		return context.WithValue(ctx, unixConnKey{}, true)
+	}
+	return ctx
+}
+
+// fromUnixSocket reports whether r arrived on a Unix socket, whose access
+// is controlled by file permissions rather than client addresses
+func fromUnixSocket(r *http.Request) bool {
+	unix, _ := r.Context().Value(unixConnKey{}).(bool)
+	return unix
+}
@@ -0,0 +1,216 @@
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/storage"
+	"github.com/rs/zerolog"
+)
+
+func TestProxyListeners(t *testing.T) {
+	cfg := config.DefaultConfig().Proxy
+	cfg.MTLS.ClientCA = "ca.crt"
+	got := proxyListeners(cfg)
+	if len(got) != 1 || got[0].Address != cfg.Listen || got[0].MTLS.ClientCA != "ca.crt" {
+		t.Errorf("proxyListeners() = %+v, want proxy.listen with proxy.mtls", got)
+	}
+
+	cfg.Listeners = []config.ListenerConfig{{Address: "127.0.0.1:8080"}, {Address: "unix:/run/proxy.sock"}}
+	if got := proxyListeners(cfg); len(got) != 2 {
+		t.Errorf("proxyListeners() = %+v, want proxy.listeners", got)
+	}
+
+	if err := validateListeners([]config.ListenerConfig{{Address: "unix:"}}); err == nil {
+		t.Error("validateListeners() should reject an empty socket path")
+	}
+}
+
+func TestStart_MultipleListeners(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer upstream.Close()
+
+	dir := t.TempDir()
+	socket := filepath.Join(dir, "proxy.sock")
+	// A socket left behind by a crashed process is replaced
+	stale, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	_ = stale.Close()
+
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tcpAddr := tcp.Addr().String()
+	_ = tcp.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Proxy.Listeners = []config.ListenerConfig{{Address: tcpAddr}, {Address: "unix:" + socket}}
+	cfg.Proxy.ACL.Allow = []string{"192.0.2.0/24"}
+	cfg.Proxy.DrainTimeout = time.Second
+	acl, err := newClientACL(cfg.Proxy.ACL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{config: cfg, acl: acl, store: storage.NewMemoryStore(time.Hour), logger: zerolog.Nop()}
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+
+	get := func(client *http.Client) int {
+		t.Helper()
+		resp, err := client.Get(upstream.URL)
+		if err != nil {
+			t.Fatalf("request through proxy failed: %v", err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		return resp.StatusCode
+	}
+
+	// The TCP listener applies the ACL, which does not allow loopback
+	proxyURL, _ := url.Parse("http://" + tcpAddr)
+	tcpClient := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+	if code := get(tcpClient); code != http.StatusForbidden {
+		t.Errorf("TCP listener status = %d, want 403", code)
+	}
+
+	// The Unix socket is guarded by file permissions instead
+	unixClient := &http.Client{Transport: &http.Transport{
+		Proxy: http.ProxyURL(proxyURL),
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, "unix", socket)
+		},
+	}}
+	if code := get(unixClient); code != http.StatusOK {
+		t.Errorf("Unix socket status = %d, want 200", code)
+	}
+
+	if err := s.Stop(); err != nil {
+		t.Fatalf("Stop() error: %v", err)
+	}
+	if _, err := os.Stat(socket); !os.IsNotExist(err) {
+		t.Errorf("socket file not removed on stop: %v", err)
+	}
+}
+
+// writeListenerCert writes a certificate for localhost issued by the CA of
+// cm, returning the certificate and key paths
+func writeListenerCert(t *testing.T, cm *CertManager) (string, string) {
+	t.Helper()
+	cert, err := cm.GetCertificate(&tls.ClientHelloInfo{ServerName: "localhost"})
+	if err != nil {
+		t.Fatalf("GetCertificate() error: %v", err)
+	}
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() error: %v", err)
+	}
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "proxy.crt"), filepath.Join(dir, "proxy.key")
+	if err := os.WriteFile(certPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Certificate[0]}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: key}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certPath, keyPath
+}
+
+func TestListenerTLSConfig(t *testing.T) {
+	s := &Server{}
+	if cfg, err := s.listenerTLSConfig(config.ListenerConfig{Address: ":8080"}); err != nil || cfg != nil {
+		t.Errorf("listenerTLSConfig() without TLS = %v, %v; want nil", cfg, err)
+	}
+	for _, l := range []config.ListenerConfig{
+		{TLSCert: "proxy.crt"},
+		{TLSCert: "missing.crt", TLSKey: "missing.key"},
+	} {
+		if _, err := s.listenerTLSConfig(l); err == nil {
+			t.Errorf("listenerTLSConfig(%+v) should fail", l)
+		}
+	}
+}
+
+func TestStart_TLSListener(t *testing.T) {
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer upstream.Close()
+
+	dir := t.TempDir()
+	caPath, caKeyPath := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+	if err := GenerateCA(caPath, caKeyPath); err != nil {
+		t.Fatalf("GenerateCA() error: %v", err)
+	}
+	cm, err := NewCertManager(caPath, caKeyPath)
+	if err != nil {
+		t.Fatalf("NewCertManager() error: %v", err)
+	}
+	certPath, keyPath := writeListenerCert(t, cm)
+
+	tcp, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, port, _ := net.SplitHostPort(tcp.Addr().String())
+	_ = tcp.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Proxy.Listen = "127.0.0.1:" + port
+	cfg.Proxy.TLSCert, cfg.Proxy.TLSKey = certPath, keyPath
+	// Relay the tunnel, so the client talks TLS to the upstream through the
+	// TLS connection to the proxy
+	cfg.Proxy.BypassHosts = []string{"127.0.0.1"}
+	cfg.Proxy.DrainTimeout = time.Second
+	s := &Server{config: cfg, store: storage.NewMemoryStore(time.Hour), logger: zerolog.Nop()}
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start() error: %v", err)
+	}
+	defer func() { _ = s.Stop() }()
+
+	// The proxy and the upstream are verified with the same roots
+	roots := upstream.Client().Transport.(*http.Transport).TLSClientConfig.RootCAs.Clone()
+	roots.AppendCertsFromPEM(cm.GetCACertificate())
+	proxyURL, _ := url.Parse("https://localhost:" + port)
+	client := &http.Client{Transport: &http.Transport{
+		Proxy:           http.ProxyURL(proxyURL),
+		TLSClientConfig: &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12},
+	}}
+	defer client.CloseIdleConnections()
+
+	resp, err := client.Get(upstream.URL)
+	if err != nil {
+		t.Fatalf("CONNECT through the TLS listener failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != "ok" {
+		t.Errorf("response = %d %q, want 200 ok", resp.StatusCode, body)
+	}
+
+	// Plaintext clients are not served
+	plainURL, _ := url.Parse("http://localhost:" + port)
+	plain := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(plainURL)}, Timeout: 5 * time.Second}
+	if resp, err := plain.Get(upstream.URL); err == nil {
+		_ = resp.Body.Close()
+		t.Error("plaintext CONNECT was accepted")
+	}
+}
@@ -0,0 +1,200 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/storage"
+)
+
+const (
+	// mappingsAdminPath is the path of the mappings admin API
+	mappingsAdminPath = "/admin/mappings"
+	// defaultMappingsLimit caps mapping listings without a limit parameter
+	defaultMappingsLimit = 100
+)
+
+// MappingInfo describes a stored mapping without its secret
+type MappingInfo struct {
+	Placeholder  string           `json:"placeholder"`
+	Namespace    string           `json:"namespace,omitempty"`
+	CreatedAt    time.Time        `json:"created_at,omitzero"`
+	LastUsed     time.Time        `json:"last_used,omitzero"`
+	RestoreCount int64            `json:"restore_count"`
+	LastRestored time.Time        `json:"last_restored,omitzero"`
+	Metadata     storage.Metadata `json:"metadata"`
+}
+
+// newMappingInfo describes mapping, whose placeholder is the key it is
+// stored under
+func newMappingInfo(mapping *storage.Mapping) MappingInfo {
+	namespace, placeholder := storage.SplitNamespace(mapping.Placeholder)
+	return MappingInfo{
+		Placeholder:  placeholder,
+		Namespace:    namespace,
+		CreatedAt:    mapping.CreatedAt,
+		LastUsed:     mapping.LastUsed,
+		RestoreCount: mapping.RestoreCount,
+		LastRestored: mapping.LastRestored,
+		Metadata:     mapping.Metadata,
+	}
+}
+
+// ListMappings describes up to limit mappings selected by filter, newest
+// first; a limit of 0 lists all of them
+func (s *Server) ListMappings(ctx context.Context, filter storage.PurgeFilter, limit int) ([]MappingInfo, error) {
+	mappings, err := storage.List(ctx, s.store, filter, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mappings: %w", err)
+	}
+	infos := make([]MappingInfo, len(mappings))
+	for i := range mappings {
+		infos[i] = newMappingInfo(&mappings[i])
+	}
+	return infos, nil
+}
+
+// LookupMappingInfo describes the mapping of placeholder in namespace
+// without refreshing it
+func (s *Server) LookupMappingInfo(ctx context.Context, namespace, placeholder string) (MappingInfo, bool, error) {
+	mapping, found, err := s.store.LookupMapping(ctx, storage.NamespacedPlaceholder(namespace, placeholder))
+	if err != nil {
+		return MappingInfo{}, false, fmt.Errorf("failed to look up mapping: %w", err)
+	}
+	if !found {
+		return MappingInfo{}, false, nil
+	}
+	return newMappingInfo(mapping), true, nil
+}
+
+// MappingsAdminHandler serves the mappings admin API, which never reveals
+// secrets:
+//
+//	GET    /admin/mappings                lists mappings selected by the filter
+//	DELETE /admin/mappings                purges mappings selected by the filter
+//	GET    /admin/mappings/{placeholder}  describes a mapping
+//	DELETE /admin/mappings/{placeholder}  deletes a mapping
+//
+// The filter is given by the namespace, type, request_id and older_than
+// query parameters; listings take a limit. Purging all mappings requires
+// all=true. It must be wrapped with RequireAdmin.
+func (s *Server) MappingsAdminHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		placeholder := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, mappingsAdminPath), "/")
+		if placeholder == "" {
+			s.serveMappings(w, r)
+		} else {
+			s.serveMapping(w, r, placeholder)
+		}
+	})
+}
+
+// serveMappings lists or purges the mappings selected by the request's filter
+func (s *Server) serveMappings(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter, err := mappingsFilter(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		limit := defaultMappingsLimit
+		if value := query.Get("limit"); value != "" {
+			if limit, err = strconv.Atoi(value); err != nil || limit < 0 {
+				http.Error(w, "invalid limit", http.StatusBadRequest)
+				return
+			}
+		}
+		mappings, err := s.ListMappings(r.Context(), filter, limit)
+		if err != nil {
+			s.logger.Error().Err(err).Msg("Failed to list mappings")
+			http.Error(w, "failed to list mappings", http.StatusInternalServerError)
+			return
+		}
+		s.writeAdminJSON(w, map[string]any{"mappings": mappings, "count": len(mappings)})
+	case http.MethodDelete:
+		if filter.IsEmpty() && query.Get("all") != "true" {
+			http.Error(w, "refusing to purge all mappings without all=true", http.StatusBadRequest)
+			return
+		}
+		deleted, err := s.PurgeMappings(r.Context(), filter)
+		if err != nil {
+			s.logger.Error().Err(err).Int("deleted", deleted).Msg("Failed to purge mappings")
+			http.Error(w, "failed to purge mappings", http.StatusInternalServerError)
+			return
+		}
+		s.writeAdminJSON(w, map[string]int{"deleted": deleted})
+	default:
+		w.Header().Set("Allow", "GET, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// serveMapping describes or deletes the mapping of a placeholder
+func (s *Server) serveMapping(w http.ResponseWriter, r *http.Request, placeholder string) {
+	namespace := r.URL.Query().Get("namespace")
+	switch r.Method {
+	case http.MethodGet:
+		info, found, err := s.LookupMappingInfo(r.Context(), namespace, placeholder)
+		if err != nil {
+			s.logger.Error().Err(err).Msg("Failed to look up mapping")
+			http.Error(w, "failed to look up mapping", http.StatusInternalServerError)
+			return
+		}
+		if !found {
+			http.Error(w, "mapping not found", http.StatusNotFound)
+			return
+		}
+		s.writeAdminJSON(w, info)
+	case http.MethodDelete:
+		filter := storage.PurgeFilter{Namespace: namespace, Placeholder: placeholder}
+		deleted, err := s.PurgeMappings(r.Context(), filter)
+		if err != nil {
+			s.logger.Error().Err(err).Msg("Failed to delete mapping")
+			http.Error(w, "failed to delete mapping", http.StatusInternalServerError)
+			return
+		}
+		if deleted == 0 {
+			http.Error(w, "mapping not found", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, DELETE")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// mappingsFilter parses the filter query parameters of the mappings API
+func mappingsFilter(query url.Values) (storage.PurgeFilter, error) {
+	filter := storage.PurgeFilter{
+		Namespace:  query.Get("namespace"),
+		SecretType: query.Get("type"),
+		RequestID:  query.Get("request_id"),
+	}
+	if value := query.Get("older_than"); value != "" {
+		olderThan, err := time.ParseDuration(value)
+		if err != nil || olderThan <= 0 {
+			return filter, errors.New("invalid older_than duration")
+		}
+		filter.OlderThan = olderThan
+	}
+	return filter, nil
+}
+
+// writeAdminJSON writes v as JSON response of an admin request
+func (s *Server) writeAdminJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		s.logger.Debug().Err(err).Msg("Failed to write admin response")
+	}
+}
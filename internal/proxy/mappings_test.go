@@ -0,0 +1,148 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/storage"
+	"github.com/rs/zerolog"
+)
+
+func newMappingsAdminServer(t *testing.T) (*Server, *recordingAudit) {
+	t.Helper()
+	store, _, err := newMappingStore(context.Background(), config.DefaultConfig().Storage)
+	if err != nil {
+		t.Fatalf("newMappingStore() error: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+
+	ctx := context.Background()
+	_ = store.Store(ctx, "__SECRET_1__", "sk-first", storage.Metadata{SecretType: "api_key", RequestID: "req-1"})
+	_ = store.Store(ctx, "__SECRET_2__", "hunter2", storage.Metadata{SecretType: "password", RequestID: "req-2"})
+	_ = storage.WithNamespace(store, "ip:10.0.0.5").Store(ctx, "__SECRET_1__", "sk-client", storage.Metadata{SecretType: "api_key"})
+
+	recorder := &recordingAudit{}
+	return &Server{config: config.DefaultConfig(), store: store, logger: zerolog.Nop(), audit: recorder}, recorder
+}
+
+func serveMappingsAdmin(server *Server, method, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	server.MappingsAdminHandler().ServeHTTP(rec, httptest.NewRequest(method, target, nil))
+	return rec
+}
+
+func TestMappingsAdminHandler_List(t *testing.T) {
+	server, _ := newMappingsAdminServer(t)
+
+	rec := serveMappingsAdmin(server, http.MethodGet, "/admin/mappings?type=api_key")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	for _, secret := range []string{"sk-first", "hunter2", "sk-client"} {
+		if strings.Contains(rec.Body.String(), secret) {
+			t.Errorf("listing leaks secret %q", secret)
+		}
+	}
+
+	var resp struct {
+		Mappings []MappingInfo `json:"mappings"`
+		Count    int           `json:"count"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if resp.Count != 2 || len(resp.Mappings) != 2 {
+		t.Fatalf("listed %+v, want the two api_key mappings", resp)
+	}
+	namespaces := map[string]bool{}
+	for _, m := range resp.Mappings {
+		if m.Placeholder != "__SECRET_1__" || m.Metadata.SecretType != "api_key" {
+			t.Errorf("unexpected mapping %+v", m)
+		}
+		namespaces[m.Namespace] = true
+	}
+	if !namespaces[""] || !namespaces["ip:10.0.0.5"] {
+		t.Errorf("namespaces = %v, want the shared and the client namespace", namespaces)
+	}
+
+	rec = serveMappingsAdmin(server, http.MethodGet, "/admin/mappings?limit=1")
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Count != 1 {
+		t.Errorf("limit=1 listed %d mappings, %v", resp.Count, err)
+	}
+
+	for _, target := range []string{"/admin/mappings?limit=-1", "/admin/mappings?older_than=soon"} {
+		if rec := serveMappingsAdmin(server, http.MethodGet, target); rec.Code != http.StatusBadRequest {
+			t.Errorf("GET %s: status = %d, want 400", target, rec.Code)
+		}
+	}
+	if rec := serveMappingsAdmin(server, http.MethodPost, "/admin/mappings"); rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST: status = %d, want 405", rec.Code)
+	}
+}
+
+func TestMappingsAdminHandler_Placeholder(t *testing.T) {
+	server, recorder := newMappingsAdminServer(t)
+
+	rec := serveMappingsAdmin(server, http.MethodGet, "/admin/mappings/__SECRET_1__?namespace=ip:10.0.0.5")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("lookup status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "sk-client") {
+		t.Error("lookup leaks the secret")
+	}
+	var info MappingInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil || info.Namespace != "ip:10.0.0.5" || info.CreatedAt.IsZero() {
+		t.Errorf("lookup = %+v, %v", info, err)
+	}
+
+	if rec := serveMappingsAdmin(server, http.MethodGet, "/admin/mappings/__SECRET_9__"); rec.Code != http.StatusNotFound {
+		t.Errorf("lookup of unknown placeholder: status = %d, want 404", rec.Code)
+	}
+
+	if rec := serveMappingsAdmin(server, http.MethodDelete, "/admin/mappings/__SECRET_1__"); rec.Code != http.StatusNoContent {
+		t.Fatalf("delete status = %d: %s", rec.Code, rec.Body.String())
+	}
+	ctx := context.Background()
+	if _, found, _ := server.store.Lookup(ctx, "__SECRET_1__"); found {
+		t.Error("mapping still stored after delete")
+	}
+	if _, found, _ := storage.WithNamespace(server.store, "ip:10.0.0.5").Lookup(ctx, "__SECRET_1__"); !found {
+		t.Error("delete removed the mapping of another namespace")
+	}
+	if len(recorder.purged) != 1 || !strings.Contains(recorder.purged[0], "placeholder:__SECRET_1__") {
+		t.Errorf("audit events = %v, want the deletion", recorder.purged)
+	}
+
+	if rec := serveMappingsAdmin(server, http.MethodDelete, "/admin/mappings/__SECRET_1__"); rec.Code != http.StatusNotFound {
+		t.Errorf("second delete: status = %d, want 404", rec.Code)
+	}
+}
+
+func TestMappingsAdminHandler_Purge(t *testing.T) {
+	server, recorder := newMappingsAdminServer(t)
+
+	if rec := serveMappingsAdmin(server, http.MethodDelete, "/admin/mappings"); rec.Code != http.StatusBadRequest {
+		t.Errorf("unfiltered purge: status = %d, want 400", rec.Code)
+	}
+
+	rec := serveMappingsAdmin(server, http.MethodDelete, "/admin/mappings?request_id=req-2")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"deleted":1`) {
+		t.Fatalf("purge = %d %s, want 1 deleted", rec.Code, rec.Body.String())
+	}
+	if server.store.Size() != 2 {
+		t.Errorf("Size() = %d after purge, want 2", server.store.Size())
+	}
+
+	rec = serveMappingsAdmin(server, http.MethodDelete, "/admin/mappings?all=true")
+	if rec.Code != http.StatusOK || server.store.Size() != 0 {
+		t.Errorf("purge all = %d, Size() = %d", rec.Code, server.store.Size())
+	}
+	if len(recorder.purged) != 2 {
+		t.Errorf("audit events = %v, want both purges", recorder.purged)
+	}
+}
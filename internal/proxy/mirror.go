@@ -0,0 +1,132 @@
+package proxy
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"slices"
+	"sync"
+	"time"
+)
+
+// mirroredKeyUsage are the key usages copied from upstream certificates;
+// the leaf key is always RSA
+const mirroredKeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageKeyAgreement
+
+// mirrorAttributes copies the names, validity and key usage of upstream to
+// template, which stays valid for hostname
+func mirrorAttributes(template, upstream *x509.Certificate, hostname string) {
+	template.Subject.CommonName = upstream.Subject.CommonName
+	if template.Subject.CommonName == "" {
+		template.Subject.CommonName = hostname
+	}
+	if err := upstream.VerifyHostname(hostname); err == nil {
+		template.DNSNames = slices.Clone(upstream.DNSNames)
+		template.IPAddresses = slices.Clone(upstream.IPAddresses)
+	} else {
+		// Keep the names of the template, which cover hostname
+		template.DNSNames = append(template.DNSNames, upstream.DNSNames...)
+		template.IPAddresses = append(template.IPAddresses, upstream.IPAddresses...)
+	}
+	template.NotBefore = upstream.NotBefore
+	template.NotAfter = upstream.NotAfter
+	if usage := upstream.KeyUsage & mirroredKeyUsage; usage != 0 {
+		template.KeyUsage = usage
+	}
+	if len(upstream.ExtKeyUsage) > 0 {
+		template.ExtKeyUsage = slices.Clone(upstream.ExtKeyUsage)
+	}
+}
+
+// upstreamCerts caches the certificates presented by upstreams, keyed by
+// address and server name
+type upstreamCerts struct {
+	mu    sync.Mutex
+	certs map[string]upstreamCertEntry
+}
+
+// upstreamCertEntry is a cached upstream certificate
+type upstreamCertEntry struct {
+	cert    *x509.Certificate
+	expires time.Time
+}
+
+// newUpstreamCerts returns an empty upstream certificate cache
+func newUpstreamCerts() *upstreamCerts {
+	return &upstreamCerts{certs: make(map[string]upstreamCertEntry)}
+}
+
+// lookup returns the certificate cached for key, if not expired
+func (c *upstreamCerts) lookup(key string, now time.Time) *x509.Certificate {
+	if c == nil {
+		return nil
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.certs[key]
+	if !ok || now.After(entry.expires) || now.After(entry.cert.NotAfter) {
+		delete(c.certs, key)
+		return nil
+	}
+	return entry.cert
+}
+
+// remember caches cert for key until ttl passed
+func (c *upstreamCerts) remember(key string, cert *x509.Certificate, ttl time.Duration, now time.Time) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.certs[key] = upstreamCertEntry{cert: cert, expires: now.Add(ttl)}
+}
+
+// certificateFor returns the tls.Config GetCertificate hook for a tunnel to
+// host, mirroring the upstream certificate when enabled
+func (s *Server) certificateFor(host string) func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if !s.cfg().TLS.Mirror.Enabled {
+		return s.certManager.GetCertificate
+	}
+	return func(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+		return s.certManager.GetMirroredCertificate(hello, func() *x509.Certificate {
+			return s.upstreamCertificate(hello.Context(), host, hello.ServerName)
+		})
+	}
+}
+
+// upstreamCertificate returns the certificate presented by the upstream at
+// host for serverName, or nil if it cannot be fetched or is not trusted.
+// Untrusted certificates are not mirrored; requests to them fail anyway.
+func (s *Server) upstreamCertificate(ctx context.Context, host, serverName string) *x509.Certificate {
+	if serverName == "" {
+		serverName, _, _ = net.SplitHostPort(host)
+	}
+	key := host + "/" + serverName
+	now := time.Now()
+	if cert := s.upstreamCerts.lookup(key, now); cert != nil {
+		return cert
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if s.transport != nil && s.transport.TLSClientConfig != nil {
+		tlsConfig = s.transport.TLSClientConfig.Clone()
+	}
+	tlsConfig.ServerName = serverName
+
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	dialer := tls.Dialer{Config: tlsConfig}
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		s.logger.Debug().Err(err).Str("host", host).Msg("Failed to fetch upstream certificate, not mirroring it")
+		return nil
+	}
+	defer func() { _ = conn.Close() }()
+	certs := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(certs) == 0 {
+		return nil
+	}
+	s.upstreamCerts.remember(key, certs[0], s.cfg().TLS.Mirror.CacheTTL, now)
+	return certs[0]
+}
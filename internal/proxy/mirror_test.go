@@ -0,0 +1,77 @@
+package proxy
+
+import (
+	"crypto/x509"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+)
+
+func TestMirrorAttributes(t *testing.T) {
+	upstream := &x509.Certificate{
+		DNSNames:    []string{"api.openai.com", "*.openai.com"},
+		NotBefore:   time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:    time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC),
+		KeyUsage:    x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	template := &x509.Certificate{DNSNames: []string{"chat.openai.com"}, KeyUsage: x509.KeyUsageKeyEncipherment}
+	mirrorAttributes(template, upstream, "chat.openai.com")
+	if !slices.Equal(template.DNSNames, upstream.DNSNames) {
+		t.Errorf("DNSNames = %v, want %v", template.DNSNames, upstream.DNSNames)
+	}
+	if !template.NotBefore.Equal(upstream.NotBefore) || !template.NotAfter.Equal(upstream.NotAfter) {
+		t.Errorf("validity = %v - %v, want the upstream's", template.NotBefore, template.NotAfter)
+	}
+	if template.KeyUsage != x509.KeyUsageDigitalSignature {
+		t.Errorf("KeyUsage = %v, want digital signature only", template.KeyUsage)
+	}
+	if !slices.Equal(template.ExtKeyUsage, upstream.ExtKeyUsage) {
+		t.Errorf("ExtKeyUsage = %v, want %v", template.ExtKeyUsage, upstream.ExtKeyUsage)
+	}
+
+	// Names not covering the host are added to the host's
+	template = &x509.Certificate{DNSNames: []string{"api.example.com"}}
+	mirrorAttributes(template, upstream, "api.example.com")
+	if want := []string{"api.example.com", "api.openai.com", "*.openai.com"}; !slices.Equal(template.DNSNames, want) {
+		t.Errorf("DNSNames = %v, want %v", template.DNSNames, want)
+	}
+}
+
+func TestInterceptedConnection_MirrorsUpstreamCertificate(t *testing.T) {
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.Copy(io.Discard, r.Body)
+	}))
+	defer upstream.Close()
+	real := upstream.Certificate()
+
+	for _, enabled := range []bool{true, false} {
+		client := newInterceptTestClient(t, upstream, func(cfg *config.Config) {
+			cfg.TLS.Mirror.Enabled = enabled
+		})
+		// The upstream certificate is issued for example.com
+		client.Transport.(*http.Transport).TLSClientConfig.ServerName = "example.com"
+
+		resp, err := client.Get(upstream.URL)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		_ = resp.Body.Close()
+		forged := resp.TLS.PeerCertificates[0]
+
+		mirrored := forged.NotBefore.Equal(real.NotBefore) &&
+			slices.Equal(forged.DNSNames, real.DNSNames) &&
+			slices.EqualFunc(forged.IPAddresses, real.IPAddresses, net.IP.Equal)
+		if mirrored != enabled {
+			t.Errorf("mirror enabled %v: forged certificate names %v, not before %v; upstream %v, %v",
+				enabled, forged.DNSNames, forged.NotBefore, real.DNSNames, real.NotBefore)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+)
+
+// newMTLSConfig builds the TLS configuration of a listener that requires
+// client certificates issued by cfg.ClientCA, returning nil if mTLS is
+// disabled. Without a configured certificate the listener presents the one
+// returned by fallback.
+func newMTLSConfig(cfg config.MTLSConfig, fallback func(*tls.ClientHelloInfo) (*tls.Certificate, error)) (*tls.Config, error) {
+	if cfg.ClientCA == "" {
+		if cfg.Cert != "" || cfg.Key != "" {
+			return nil, fmt.Errorf("mtls cert and key require client_ca")
+		}
+		return nil, nil
+	}
+
+	caPEM, err := os.ReadFile(filepath.Clean(cfg.ClientCA))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read client CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in %s", cfg.ClientCA)
+	}
+
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ClientAuth: tls.RequireAndVerifyClientCert,
+		ClientCAs:  pool,
+		NextProtos: []string{"http/1.1"},
+	}
+
+	switch {
+	case cfg.Cert != "" && cfg.Key != "":
+		cert, err := tls.LoadX509KeyPair(cfg.Cert, cfg.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load listener certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	case cfg.Cert != "" || cfg.Key != "":
+		return nil, fmt.Errorf("mtls cert and key must be set together")
+	case fallback != nil:
+		tlsConfig.GetCertificate = fallback
+	default:
+		return nil, fmt.Errorf("mtls requires a listener cert and key")
+	}
+	return tlsConfig, nil
+}
+
+// ManagementTLSConfig returns the TLS configuration of the management
+// server, or nil if it does not require client certificates
+func (s *Server) ManagementTLSConfig() (*tls.Config, error) {
+	tlsConfig, err := newMTLSConfig(s.cfg().Metrics.MTLS, s.listenerCertificate())
+	if err != nil {
+		return nil, fmt.Errorf("invalid management mtls config: %w", err)
+	}
+	return tlsConfig, nil
+}
+
+// listenerCertificate returns a source of listener certificates issued by
+// the interception CA, or nil without a CA
+func (s *Server) listenerCertificate() func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	if s.certManager == nil {
+		return nil
+	}
+	return s.certManager.GetCertificate
+}
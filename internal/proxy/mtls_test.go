@@ -0,0 +1,113 @@
+package proxy
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"log"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+)
+
+// issueClientCert signs a client certificate with the CA of cm
+func issueClientCert(t *testing.T, cm *CertManager) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("GenerateKey() error: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "laptop-42"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, cm.authority().cert, &key.PublicKey, cm.authority().key)
+	if err != nil {
+		t.Fatalf("CreateCertificate() error: %v", err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestNewMTLSConfig(t *testing.T) {
+	if cfg, err := newMTLSConfig(config.MTLSConfig{}, nil); err != nil || cfg != nil {
+		t.Errorf("newMTLSConfig() without client CA = %v, %v; want nil", cfg, err)
+	}
+
+	caPath := filepath.Join(t.TempDir(), "ca.crt")
+	if err := GenerateCA(caPath, filepath.Join(filepath.Dir(caPath), "ca.key")); err != nil {
+		t.Fatalf("GenerateCA() error: %v", err)
+	}
+	for _, cfg := range []config.MTLSConfig{
+		{Cert: "listener.crt", Key: "listener.key"},
+		{ClientCA: filepath.Join(t.TempDir(), "missing.crt")},
+		{ClientCA: caPath, Cert: "listener.crt"},
+		{ClientCA: caPath},
+	} {
+		if _, err := newMTLSConfig(cfg, nil); err == nil {
+			t.Errorf("newMTLSConfig(%+v) should fail", cfg)
+		}
+	}
+}
+
+func TestMTLSListener(t *testing.T) {
+	dir := t.TempDir()
+	caPath, keyPath := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+	if err := GenerateCA(caPath, keyPath); err != nil {
+		t.Fatalf("GenerateCA() error: %v", err)
+	}
+	cm, err := NewCertManager(caPath, keyPath)
+	if err != nil {
+		t.Fatalf("NewCertManager() error: %v", err)
+	}
+	s := &Server{certManager: cm}
+
+	tlsConfig, err := newMTLSConfig(config.MTLSConfig{ClientCA: caPath}, s.listenerCertificate())
+	if err != nil {
+		t.Fatalf("newMTLSConfig() error: %v", err)
+	}
+	listener := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	listener.TLS = tlsConfig
+	listener.Config.ErrorLog = log.New(io.Discard, "", 0)
+	listener.StartTLS()
+	defer listener.Close()
+
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(cm.GetCACertificate())
+	client := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+			RootCAs:      roots,
+			Certificates: certs,
+			ServerName:   "localhost",
+			MinVersion:   tls.VersionTLS12,
+		}}}
+	}
+
+	if resp, err := client().Get(listener.URL); err == nil {
+		_ = resp.Body.Close()
+		t.Error("client without certificate was accepted")
+	}
+
+	resp, err := client(issueClientCert(t, cm)).Get(listener.URL)
+	if err != nil {
+		t.Fatalf("Get() with client certificate error: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("status = %d, want 200", resp.StatusCode)
+	}
+}
@@ -0,0 +1,118 @@
+package proxy
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+)
+
+// validateNamespaceConfig checks the namespace mode
+func validateNamespaceConfig(cfg config.NamespaceConfig) error {
+	switch cfg.Mode {
+	case "", "none", "client_ip", "connection":
+		return nil
+	case "proxy_user":
+		// Unauthenticated clients could claim any user's namespace
+		if !cfg.TrustProxyAuthorization {
+			return fmt.Errorf("namespace mode \"proxy_user\" requires trust_proxy_authorization, as the proxy does not authenticate users")
+		}
+		return nil
+	case "header":
+		if cfg.Header == "" {
+			return fmt.Errorf("namespace mode \"header\" requires a header name")
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown namespace mode %q", cfg.Mode)
+	}
+}
+
+// connectionNamespace derives the mapping namespace of an intercepted
+// connection from its CONNECT request
+func (s *Server) connectionNamespace(r *http.Request) string {
+	switch s.cfg().Storage.Namespace.Mode {
+	case "client_ip":
+		return "ip:" + clientIP(r)
+	case "proxy_user":
+		if user := proxyUser(r.Header.Get("Proxy-Authorization")); user != "" {
+			return "user:" + user
+		}
+		// Clients without a user never share a namespace
+		return s.newConnectionNamespace()
+	case "connection":
+		return s.newConnectionNamespace()
+	}
+	return ""
+}
+
+// newConnectionNamespace returns a random namespace of its own
+func (s *Server) newConnectionNamespace() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to generate connection namespace")
+	}
+	return "conn:" + hex.EncodeToString(id)
+}
+
+// requestNamespace returns the mapping namespace of a request on a connection.
+// In header mode the namespace header is removed before the request is forwarded.
+func (s *Server) requestNamespace(connNamespace string, req *http.Request) string {
+	if s.cfg().Storage.Namespace.Mode != "header" {
+		return connNamespace
+	}
+
+	header := s.cfg().Storage.Namespace.Header
+	value := strings.TrimSpace(req.Header.Get(header))
+	req.Header.Del(header)
+	if value == "" {
+		return ""
+	}
+	return "header:" + value
+}
+
+// proxyUser extracts the user name from a Basic Proxy-Authorization header
+func proxyUser(auth string) string {
+	encoded, ok := strings.CutPrefix(auth, "Basic ")
+	if !ok {
+		return ""
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return ""
+	}
+	user, _, _ := strings.Cut(string(decoded), ":")
+	return user
+}
+
+// connClient identifies the client of a proxied connection
+type connClient struct {
+	namespace string // mapping namespace of the connection
+	identity  string // rate limiting identity of the connection
+}
+
+// newConnClient identifies the client sending the CONNECT or gateway request r
+func (s *Server) newConnClient(r *http.Request) connClient {
+	identity := "ip:" + clientIP(r)
+	if s.cfg().Proxy.RateLimit.Key == "proxy_user" {
+		// Clients without a user are limited by their address
+		if user := proxyUser(r.Header.Get("Proxy-Authorization")); user != "" {
+			identity = "user:" + user
+		}
+	}
+	return connClient{namespace: s.connectionNamespace(r), identity: identity}
+}
+
+// clientIP returns the address of the client sending r without its port
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
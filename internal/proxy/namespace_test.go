@@ -0,0 +1,111 @@
+package proxy
+
+import (
+	"encoding/base64"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/rs/zerolog"
+)
+
+func newNamespaceTestServer(mode string) *Server {
+	cfg := config.DefaultConfig()
+	cfg.Storage.Namespace.Mode = mode
+	return &Server{config: cfg, logger: zerolog.Nop()}
+}
+
+func TestValidateNamespaceConfig(t *testing.T) {
+	for _, mode := range []string{"", "none", "client_ip", "proxy_user", "connection", "header"} {
+		if err := validateNamespaceConfig(config.NamespaceConfig{Mode: mode, Header: "X-NS", TrustProxyAuthorization: true}); err != nil {
+			t.Errorf("validateNamespaceConfig(%q) error: %v", mode, err)
+		}
+	}
+	if err := validateNamespaceConfig(config.NamespaceConfig{Mode: "proxy_user"}); err == nil {
+		t.Error("validateNamespaceConfig() expected error for proxy_user mode without authenticated users")
+	}
+	if err := validateNamespaceConfig(config.NamespaceConfig{Mode: "header"}); err == nil {
+		t.Error("validateNamespaceConfig() expected error for header mode without header")
+	}
+	if err := validateNamespaceConfig(config.NamespaceConfig{Mode: "bogus"}); err == nil {
+		t.Error("validateNamespaceConfig() expected error for unknown mode")
+	}
+}
+
+func TestConnectionNamespace(t *testing.T) {
+	connect, _ := http.NewRequest(http.MethodConnect, "http://api.openai.com:443", nil)
+	connect.RemoteAddr = "10.0.0.5:51234"
+	connect.Header.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("alice:pw")))
+
+	tests := []struct {
+		mode string
+		want string
+	}{
+		{"none", ""},
+		{"client_ip", "ip:10.0.0.5"},
+		{"proxy_user", "user:alice"},
+	}
+	for _, tt := range tests {
+		if got := newNamespaceTestServer(tt.mode).connectionNamespace(connect); got != tt.want {
+			t.Errorf("connectionNamespace(%s) = %q, want %q", tt.mode, got, tt.want)
+		}
+	}
+
+	s := newNamespaceTestServer("connection")
+	first, second := s.connectionNamespace(connect), s.connectionNamespace(connect)
+	if first == "" || first == second {
+		t.Errorf("connection namespaces %q and %q should be unique", first, second)
+	}
+
+	// Clients without a proxy user get a namespace of their own
+	anonymous, _ := http.NewRequest(http.MethodConnect, "http://api.openai.com:443", nil)
+	s = newNamespaceTestServer("proxy_user")
+	first, second = s.connectionNamespace(anonymous), s.connectionNamespace(anonymous)
+	if !strings.HasPrefix(first, "conn:") || first == second {
+		t.Errorf("anonymous namespaces %q and %q should be unique", first, second)
+	}
+}
+
+func TestNewConnClient_ProxyUser(t *testing.T) {
+	s := newNamespaceTestServer("none")
+	s.config.Proxy.RateLimit.Key = "proxy_user"
+
+	connect, _ := http.NewRequest(http.MethodConnect, "http://api.openai.com:443", nil)
+	connect.RemoteAddr = "10.0.0.5:51234"
+	if got := s.newConnClient(connect).identity; got != "ip:10.0.0.5" {
+		t.Errorf("identity without proxy user = %q, want the client address", got)
+	}
+	connect.Header.Set("Proxy-Authorization", "Basic "+base64.StdEncoding.EncodeToString([]byte("alice:pw")))
+	if got := s.newConnClient(connect).identity; got != "user:alice" {
+		t.Errorf("identity = %q, want user:alice", got)
+	}
+}
+
+func TestRequestNamespace_Header(t *testing.T) {
+	s := newNamespaceTestServer("header")
+
+	req, _ := http.NewRequest(http.MethodPost, "https://api.openai.com/v1/chat/completions", nil)
+	req.Header.Set("X-LLM-Proxy-Namespace", "team-a")
+
+	if got := s.requestNamespace("", req); got != "header:team-a" {
+		t.Errorf("requestNamespace() = %q, want header:team-a", got)
+	}
+	if req.Header.Get("X-LLM-Proxy-Namespace") != "" {
+		t.Error("namespace header should be removed before forwarding")
+	}
+
+	// Other modes keep the connection namespace
+	if got := newNamespaceTestServer("client_ip").requestNamespace("ip:10.0.0.5", req); got != "ip:10.0.0.5" {
+		t.Errorf("requestNamespace() = %q, want connection namespace", got)
+	}
+}
+
+func TestProxyUser(t *testing.T) {
+	if got := proxyUser("Basic " + base64.StdEncoding.EncodeToString([]byte("bob:secret"))); got != "bob" {
+		t.Errorf("proxyUser() = %q, want bob", got)
+	}
+	if got := proxyUser("Bearer token"); got != "" {
+		t.Errorf("proxyUser() = %q for non-basic auth", got)
+	}
+}
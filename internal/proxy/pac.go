@@ -0,0 +1,96 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// PACHandler serves a proxy auto-config file routing the intercepted LLM
+// provider hosts through the proxy and all other traffic directly
+func (s *Server) PACHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		proxyAddr, err := s.pacProxy(r)
+		if err != nil {
+			s.logger.Error().Err(err).Msg("Failed to generate PAC file")
+			http.Error(w, "proxy address unknown, set metrics.pac.proxy", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-ns-proxy-autoconfig")
+		w.Header().Set("Cache-Control", "no-cache")
+		cfg := s.cfg().Proxy
+		if _, err := w.Write([]byte(pacFile(proxyAddr, cfg.InterceptHosts, cfg.BypassHosts))); err != nil {
+			s.logger.Debug().Err(err).Msg("Failed to write PAC file")
+		}
+	})
+}
+
+// pacProxy returns the address PAC clients reach the proxy at
+func (s *Server) pacProxy(r *http.Request) (string, error) {
+	cfg := s.cfg()
+	if cfg.Metrics.PAC.Proxy != "" {
+		return cfg.Metrics.PAC.Proxy, nil
+	}
+	for _, l := range proxyListeners(cfg.Proxy) {
+		if l.MTLS.ClientCA != "" || l.TLSCert != "" {
+			// Browsers cannot present client certificates to proxies, and
+			// PROXY entries are plaintext
+			continue
+		}
+		network, addr := listenNetwork(l.Address)
+		if network != "tcp" || strings.HasPrefix(addr, systemdPrefix) {
+			continue
+		}
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			continue
+		}
+		if ip := net.ParseIP(host); host == "" || (ip != nil && ip.IsUnspecified()) {
+			// Listening on all interfaces: use the address the client
+			// fetched the PAC file from
+			host = r.Host
+			if h, _, err := net.SplitHostPort(r.Host); err == nil {
+				host = h
+			}
+		}
+		return net.JoinHostPort(host, port), nil
+	}
+	return "", fmt.Errorf("no plain TCP proxy listener")
+}
+
+// pacFile generates a PAC script. Host patterns map directly to shExpMatch
+// globs.
+func pacFile(proxyAddr string, intercept, bypass []string) string {
+	var b strings.Builder
+	b.WriteString("function FindProxyForURL(url, host) {\n")
+	b.WriteString("  host = host.toLowerCase();\n")
+	writeMatch := func(patterns []string, result string) {
+		if len(patterns) == 0 {
+			return
+		}
+		conditions := make([]string, len(patterns))
+		for i, pattern := range patterns {
+			conditions[i] = "shExpMatch(host, " + jsString(strings.ToLower(strings.TrimSuffix(pattern, "."))) + ")"
+		}
+		fmt.Fprintf(&b, "  if (%s) {\n    return %s;\n  }\n", strings.Join(conditions, " ||\n      "), jsString(result))
+	}
+	writeMatch(bypass, "DIRECT")
+	writeMatch(intercept, "PROXY "+proxyAddr)
+	b.WriteString("  return \"DIRECT\";\n}\n")
+	return b.String()
+}
+
+// jsString quotes s as a JavaScript string literal
+func jsString(s string) string {
+	data, _ := json.Marshal(s)
+	return string(data)
+}
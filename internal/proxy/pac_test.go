@@ -0,0 +1,77 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/rs/zerolog"
+)
+
+func TestPACHandler(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Proxy.InterceptHosts = []string{"api.openai.com", "*.openai.azure.com"}
+	cfg.Proxy.BypassHosts = []string{"internal.openai.azure.com"}
+	s := &Server{config: cfg, logger: zerolog.Nop()}
+
+	rec := httptest.NewRecorder()
+	s.PACHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "http://llm-proxy.corp.example:9090/proxy.pac", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-ns-proxy-autoconfig" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		`shExpMatch(host, "api.openai.com")`,
+		`shExpMatch(host, "*.openai.azure.com")`,
+		`return "PROXY llm-proxy.corp.example:8080";`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("PAC file missing %s:\n%s", want, body)
+		}
+	}
+	// Bypassed hosts are checked first
+	if strings.Index(body, "internal.openai.azure.com") > strings.Index(body, "PROXY") {
+		t.Errorf("bypass hosts must precede the proxy rule:\n%s", body)
+	}
+}
+
+func TestPACProxy(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "http://10.1.2.3:9090/proxy.pac", nil)
+	tests := []struct {
+		name string
+		cfg  func(*config.Config)
+		want string
+	}{
+		{"configured", func(c *config.Config) { c.Metrics.PAC.Proxy = "proxy.example:3128" }, "proxy.example:3128"},
+		{"all interfaces", func(c *config.Config) { c.Proxy.Listen = "0.0.0.0:8080" }, "10.1.2.3:8080"},
+		{"fixed address", func(c *config.Config) { c.Proxy.Listen = "10.0.0.5:8081" }, "10.0.0.5:8081"},
+		{"skips unix and mtls", func(c *config.Config) {
+			c.Proxy.Listeners = []config.ListenerConfig{
+				{Address: "unix:/run/proxy.sock"},
+				{Address: ":8443", MTLS: config.MTLSConfig{ClientCA: "ca.crt"}},
+				{Address: ":8080"},
+			}
+		}, "10.1.2.3:8080"},
+		{"none", func(c *config.Config) { c.Proxy.Listen = "unix:/run/proxy.sock" }, ""},
+	}
+	for _, tt := range tests {
+		cfg := config.DefaultConfig()
+		tt.cfg(cfg)
+		s := &Server{config: cfg}
+		got, err := s.pacProxy(req)
+		if tt.want == "" {
+			if err == nil {
+				t.Errorf("%s: pacProxy() = %q, want error", tt.name, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s: pacProxy() = %q, %v; want %q", tt.name, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package proxy
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/metrics"
+)
+
+// pinningDetector counts the handshakes clients reject per host, and keeps
+// the clients and hosts detected as pinned that are no longer intercepted.
+// Bypasses apply to the detected client only, so one client cannot turn off
+// interception of a host for everyone.
+type pinningDetector struct {
+	mu       sync.Mutex
+	aborts   map[pinningKey][]time.Time
+	bypassed map[pinningKey]time.Time // zero = until restart
+}
+
+// pinningKey is a client connecting to a host
+type pinningKey struct {
+	client string
+	host   string
+}
+
+// newPinningDetector returns a detector without recorded aborts
+func newPinningDetector() *pinningDetector {
+	return &pinningDetector{
+		aborts:   make(map[pinningKey][]time.Time),
+		bypassed: make(map[pinningKey]time.Time),
+	}
+}
+
+// recordAbort records a rejected handshake of client with host and returns
+// the number of aborts within window once it reaches threshold, 0 before.
+// The count starts over after a detection.
+func (d *pinningDetector) recordAbort(client, host string, threshold int, window time.Duration, now time.Time) int {
+	if d == nil {
+		return 0
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Forget aborts that left the window, of all clients, so the map
+	// cannot grow without bounds
+	for key, times := range d.aborts {
+		for len(times) > 0 && now.Sub(times[0]) > window {
+			times = times[1:]
+		}
+		if len(times) == 0 {
+			delete(d.aborts, key)
+		} else {
+			d.aborts[key] = times
+		}
+	}
+
+	key := pinningKey{client: client, host: host}
+	times := append(d.aborts[key], now)
+	if len(times) < threshold {
+		d.aborts[key] = times
+		return 0
+	}
+	delete(d.aborts, key)
+	return len(times)
+}
+
+// bypass stops intercepting host for client for duration (0 = until
+// restart)
+func (d *pinningDetector) bypass(client, host string, duration time.Duration, now time.Time) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	// Forget expired bypasses, so the map cannot grow without bounds
+	for key, until := range d.bypassed {
+		if !until.IsZero() && now.After(until) {
+			delete(d.bypassed, key)
+		}
+	}
+
+	var until time.Time
+	if duration > 0 {
+		until = now.Add(duration)
+	}
+	d.bypassed[pinningKey{client: client, host: host}] = until
+}
+
+// isBypassed reports whether client was detected pinning host and host is
+// bypassed for it
+func (d *pinningDetector) isBypassed(client, host string, now time.Time) bool {
+	if d == nil {
+		return false
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	key := pinningKey{client: client, host: host}
+	until, ok := d.bypassed[key]
+	if !ok {
+		return false
+	}
+	if !until.IsZero() && now.After(until) {
+		delete(d.bypassed, key)
+		return false
+	}
+	return true
+}
+
+// detectPinning records a handshake with host that client failed with err,
+// and reports pinning once the client rejected the interception certificate
+// often enough. Connections merely closed during the handshake, as by
+// health probes and port scans, are not counted.
+func (s *Server) detectPinning(client, host string, err error) {
+	cfg := s.cfg().Proxy.PinningDetection
+	if !cfg.Enabled || !rejectedByClient(err) {
+		return
+	}
+	now := time.Now()
+	aborts := s.pinning.recordAbort(client, host, cfg.Threshold, cfg.Window, now)
+	if aborts == 0 {
+		return
+	}
+
+	s.logger.Warn().
+		Str("client", client).
+		Str("host", host).
+		Int("aborts", aborts).
+		Bool("bypass", cfg.AutoBypass).
+		Msg("Client appears to pin the certificate of host")
+	metrics.RecordPinningDetected(host)
+	s.auditor().LogPinningDetected(client, host, aborts, cfg.AutoBypass)
+	if cfg.AutoBypass {
+		s.pinning.bypass(client, host, cfg.BypassFor, now)
+		s.logger.Warn().
+			Str("client", client).
+			Str("host", host).
+			Dur("for", cfg.BypassFor).
+			Msg("Interception of host bypassed for client, its traffic is no longer masked")
+	}
+}
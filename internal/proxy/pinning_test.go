@@ -0,0 +1,157 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+)
+
+func TestPinningDetector(t *testing.T) {
+	now := time.Now()
+	d := newPinningDetector()
+
+	if n := d.recordAbort("10.0.0.1", "api.example:443", 3, time.Minute, now); n != 0 {
+		t.Errorf("first abort detected pinning (%d)", n)
+	}
+	// Other clients and hosts are counted separately
+	d.recordAbort("10.0.0.2", "api.example:443", 3, time.Minute, now)
+	d.recordAbort("10.0.0.1", "other.example:443", 3, time.Minute, now)
+	if n := d.recordAbort("10.0.0.1", "api.example:443", 3, time.Minute, now.Add(time.Second)); n != 0 {
+		t.Errorf("second abort detected pinning (%d)", n)
+	}
+	if n := d.recordAbort("10.0.0.1", "api.example:443", 3, time.Minute, now.Add(2*time.Second)); n != 3 {
+		t.Errorf("third abort = %d, want 3", n)
+	}
+	// The count starts over after a detection
+	if n := d.recordAbort("10.0.0.1", "api.example:443", 3, time.Minute, now.Add(3*time.Second)); n != 0 {
+		t.Errorf("abort after detection = %d, want 0", n)
+	}
+
+	// Aborts outside the window are forgotten
+	later := now.Add(time.Hour)
+	d.recordAbort("10.0.0.2", "api.example:443", 3, time.Minute, later)
+	if n := d.recordAbort("10.0.0.2", "api.example:443", 3, time.Minute, later); n != 0 {
+		t.Errorf("aborts outside the window counted (%d)", n)
+	}
+
+	d.bypass("10.0.0.1", "api.example:443", time.Minute, now)
+	d.bypass("10.0.0.1", "pinned.example:443", 0, now)
+	if !d.isBypassed("10.0.0.1", "api.example:443", now) || !d.isBypassed("10.0.0.1", "pinned.example:443", later) {
+		t.Error("bypassed hosts not reported")
+	}
+	// Other clients are still intercepted
+	if d.isBypassed("10.0.0.2", "pinned.example:443", now) {
+		t.Error("bypass applied to another client")
+	}
+	if d.isBypassed("10.0.0.1", "api.example:443", later) {
+		t.Error("bypass did not expire")
+	}
+
+	var none *pinningDetector
+	none.bypass("10.0.0.1", "api.example:443", 0, now)
+	if none.recordAbort("10.0.0.1", "api.example:443", 1, time.Minute, now) != 0 || none.isBypassed("10.0.0.1", "api.example:443", now) {
+		t.Error("nil detector recorded state")
+	}
+}
+
+func TestDetectPinning_IgnoresClosedConnections(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Proxy.PinningDetection.Threshold = 1
+	cfg.Proxy.PinningDetection.AutoBypass = true
+	recorder := &recordingAudit{}
+	server := &Server{config: cfg, pinning: newPinningDetector(), audit: recorder, logger: zerolog.Nop()}
+
+	// Clients dropping the connection, health probes and port scans
+	for _, err := range []error{
+		io.EOF,
+		io.ErrUnexpectedEOF,
+		&net.OpError{Op: "read", Err: net.ErrClosed},
+	} {
+		server.detectPinning("10.0.0.1", "api.example:443", err)
+	}
+	if len(recorder.pinning) != 0 || server.pinning.isBypassed("10.0.0.1", "api.example:443", time.Now()) {
+		t.Errorf("closed connections detected as pinning: %v", recorder.pinning)
+	}
+
+	rejected := &net.OpError{Op: "remote error", Err: errors.New("tls: bad certificate")}
+	server.detectPinning("10.0.0.1", "api.example:443", rejected)
+	if len(recorder.pinning) != 1 || !server.pinning.isBypassed("10.0.0.1", "api.example:443", time.Now()) {
+		t.Errorf("rejected certificate not detected as pinning: %v", recorder.pinning)
+	}
+	if !server.intercepts("10.0.0.2", "api.example:443") {
+		t.Error("host bypassed for other clients")
+	}
+}
+
+func TestHandleConnect_PinningAutoBypass(t *testing.T) {
+	cm := newTestCertManager(t)
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "direct")
+	}))
+	upstream.Config.ErrorLog = log.New(io.Discard, "", 0)
+	defer upstream.Close()
+	target := strings.TrimPrefix(upstream.URL, "https://")
+
+	cfg := config.DefaultConfig()
+	cfg.Proxy.PinningDetection.Threshold = 2
+	cfg.Proxy.PinningDetection.AutoBypass = true
+	recorder := &recordingAudit{}
+	server := &Server{
+		config:      cfg,
+		certManager: cm,
+		pinning:     newPinningDetector(),
+		audit:       recorder,
+		logger:      zerolog.Nop(),
+	}
+	proxy := httptest.NewUnstartedServer(server)
+	proxy.Config.ErrorLog = log.New(io.Discard, "", 0)
+	proxy.Start()
+	defer proxy.Close()
+	proxyAddr := strings.TrimPrefix(proxy.URL, "http://")
+
+	// The client trusts only the real certificate, as a pinning app does
+	roots := x509.NewCertPool()
+	roots.AddCert(upstream.Certificate())
+	clientConfig := &tls.Config{RootCAs: roots, ServerName: "example.com", MinVersion: tls.VersionTLS12}
+
+	before := testutil.ToFloat64(metrics.PinningDetected.WithLabelValues(target))
+	for i := 0; i < 2; i++ {
+		if _, err := getThroughTunnel(t, proxyAddr, target, clientConfig.Clone()); err == nil {
+			t.Fatalf("handshake %d was not intercepted", i+1)
+		}
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for !server.pinning.isBypassed("127.0.0.1", target, time.Now()) {
+		if time.Now().After(deadline) {
+			t.Fatal("host not bypassed after repeated aborts")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := testutil.ToFloat64(metrics.PinningDetected.WithLabelValues(target)); got != before+1 {
+		t.Errorf("pinning detections = %v, want %v", got, before+1)
+	}
+	if len(recorder.pinning) != 1 || !strings.HasSuffix(recorder.pinning[0], target+" 2 true") {
+		t.Errorf("audit events = %v", recorder.pinning)
+	}
+
+	body, err := getThroughTunnel(t, proxyAddr, target, clientConfig)
+	if err != nil {
+		t.Fatalf("request through bypassed tunnel failed: %v", err)
+	}
+	if body != "direct" {
+		t.Errorf("body = %q, want direct", body)
+	}
+}
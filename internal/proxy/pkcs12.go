@@ -0,0 +1,244 @@
+package proxy
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1" //#nosec G505 -- PKCS#12 MAC understood by all trust store tools
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"unicode/utf16"
+)
+
+// pkcs12Iterations is the MAC key derivation iteration count
+const pkcs12Iterations = 2048
+
+var (
+	oidData             = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidCertBag          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 12, 10, 1, 3}
+	oidX509Certificate  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 22, 1}
+	oidFriendlyName     = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 20}
+	oidSHA1             = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	oidAnyExtendedUsage = asn1.ObjectIdentifier{2, 5, 29, 37, 0}
+	// oidJavaTrustedUsage marks certificates keytool imports as trusted
+	oidJavaTrustedUsage = asn1.ObjectIdentifier{2, 16, 840, 1, 113894, 746875, 1, 1}
+)
+
+type pkcs12PFX struct {
+	Version  int
+	AuthSafe pkcs12ContentInfo
+	MacData  pkcs12MacData
+}
+
+type pkcs12ContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue
+}
+
+type pkcs12MacData struct {
+	Mac        pkcs12DigestInfo
+	MacSalt    []byte
+	Iterations int
+}
+
+type pkcs12DigestInfo struct {
+	Algorithm pkix.AlgorithmIdentifier
+	Digest    []byte
+}
+
+type pkcs12SafeBag struct {
+	ID         asn1.ObjectIdentifier
+	Value      asn1.RawValue
+	Attributes []pkcs12Attribute `asn1:"set"`
+}
+
+type pkcs12Attribute struct {
+	ID     asn1.ObjectIdentifier
+	Values asn1.RawValue
+}
+
+type pkcs12CertBag struct {
+	ID   asn1.ObjectIdentifier
+	Data asn1.RawValue
+}
+
+// EncodePKCS12TrustStore returns a PKCS#12 bundle holding cert as a trusted
+// certificate without a key, as imported by Windows, macOS and Java trust
+// store tooling. The bundle is not encrypted; password protects its
+// integrity only.
+func EncodePKCS12TrustStore(cert *x509.Certificate, friendlyName, password string) ([]byte, error) {
+	certData, err := asn1.Marshal(cert.Raw)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode certificate: %w", err)
+	}
+	certBag, err := asn1.Marshal(pkcs12CertBag{ID: oidX509Certificate, Data: explicitTag0(certData)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode certificate bag: %w", err)
+	}
+	name, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagBMPString, Bytes: bmpString(friendlyName)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode friendly name: %w", err)
+	}
+	trustedUsage, err := asn1.Marshal(oidAnyExtendedUsage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode trusted usage: %w", err)
+	}
+	safeContents, err := asn1.Marshal([]pkcs12SafeBag{{
+		ID:    oidCertBag,
+		Value: explicitTag0(certBag),
+		Attributes: []pkcs12Attribute{
+			{ID: oidFriendlyName, Values: asn1Set(name)},
+			{ID: oidJavaTrustedUsage, Values: asn1Set(trustedUsage)},
+		},
+	}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode safe contents: %w", err)
+	}
+	authSafe, err := asn1.Marshal([]pkcs12ContentInfo{dataContentInfo(safeContents)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode authenticated safe: %w", err)
+	}
+
+	salt := make([]byte, 8)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %w", err)
+	}
+	// Passwords are zero-terminated; 3 derives the MAC key
+	key := pkcs12DeriveKey(append(bmpString(password), 0, 0), salt, 3, pkcs12Iterations, sha1.Size)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(authSafe)
+
+	pfx, err := asn1.Marshal(pkcs12PFX{
+		Version:  3,
+		AuthSafe: dataContentInfo(authSafe),
+		MacData: pkcs12MacData{
+			Mac: pkcs12DigestInfo{
+				Algorithm: pkix.AlgorithmIdentifier{Algorithm: oidSHA1, Parameters: asn1.NullRawValue},
+				Digest:    mac.Sum(nil),
+			},
+			MacSalt:    salt,
+			Iterations: pkcs12Iterations,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode PKCS#12: %w", err)
+	}
+	return pfx, nil
+}
+
+// LoadCAAnchor reads the certificate clients trust of the CA bundle at
+// certPath
+func LoadCAAnchor(certPath string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(filepath.Clean(certPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+	var ders [][]byte
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type == "CERTIFICATE" {
+			ders = append(ders, block.Bytes)
+		}
+	}
+	if len(ders) == 0 {
+		return nil, fmt.Errorf("failed to decode CA certificate PEM")
+	}
+	_, anchor, err := parseCAChain(ders)
+	if err != nil {
+		return nil, err
+	}
+	return anchor, nil
+}
+
+// WriteCAPKCS12 writes the certificate clients trust of the CA bundle at
+// certPath to p12Path as PKCS#12 trust store bundle
+func WriteCAPKCS12(certPath, p12Path, password string) error {
+	anchor, err := LoadCAAnchor(certPath)
+	if err != nil {
+		return err
+	}
+	p12, err := EncodePKCS12TrustStore(anchor, caFriendlyName, password)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Clean(p12Path), p12, 0644); err != nil { //#nosec G306 -- holds the public CA certificate only
+		return fmt.Errorf("failed to write PKCS#12 bundle: %w", err)
+	}
+	return nil
+}
+
+// dataContentInfo wraps data in a PKCS#7 data content info
+func dataContentInfo(data []byte) pkcs12ContentInfo {
+	octets, _ := asn1.Marshal(data)
+	return pkcs12ContentInfo{ContentType: oidData, Content: explicitTag0(octets)}
+}
+
+// explicitTag0 wraps the DER encoded der in an explicit [0] tag
+func explicitTag0(der []byte) asn1.RawValue {
+	return asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: der}
+}
+
+// asn1Set wraps the DER encoded der in a SET
+func asn1Set(der []byte) asn1.RawValue {
+	return asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: der}
+}
+
+// bmpString returns s as big-endian UTF-16
+func bmpString(s string) []byte {
+	units := utf16.Encode([]rune(s))
+	raw := make([]byte, 0, 2*len(units))
+	for _, u := range units {
+		raw = append(raw, byte(u>>8), byte(u))
+	}
+	return raw
+}
+
+// pkcs12DeriveKey derives size bytes for purpose id from password and salt
+// with SHA-1, as specified in RFC 7292 appendix B.2
+func pkcs12DeriveKey(password, salt []byte, id byte, iterations, size int) []byte {
+	const v = 64 // SHA-1 block size
+	repeat := func(b []byte) []byte {
+		if len(b) == 0 {
+			return nil
+		}
+		out := make([]byte, v*((len(b)+v-1)/v))
+		for i := range out {
+			out[i] = b[i%len(b)]
+		}
+		return out
+	}
+
+	d := make([]byte, v)
+	for i := range d {
+		d[i] = id
+	}
+	input := append(repeat(salt), repeat(password)...)
+
+	var key []byte
+	for len(key) < size {
+		h := sha1.New() //#nosec G401 -- PKCS#12 key derivation
+		h.Write(d)
+		h.Write(input)
+		a := h.Sum(nil)
+		for i := 1; i < iterations; i++ {
+			sum := sha1.Sum(a) //#nosec G401 -- PKCS#12 key derivation
+			a = sum[:]
+		}
+		key = append(key, a...)
+
+		// Add the output plus one to every block of the input
+		b := repeat(a)
+		for j := 0; j < len(input); j += v {
+			carry := 1
+			for k := v - 1; k >= 0; k-- {
+				sum := int(input[j+k]) + int(b[k]) + carry
+				input[j+k] = byte(sum)
+				carry = sum >> 8
+			}
+		}
+	}
+	return key[:size]
+}
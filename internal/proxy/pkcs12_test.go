@@ -0,0 +1,115 @@
+package proxy
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha1" //#nosec G505 -- PKCS#12 MAC
+	"crypto/x509"
+	"encoding/asn1"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// decodePKCS12Cert checks the MAC of a bundle made by EncodePKCS12TrustStore
+// and returns its certificate
+func decodePKCS12Cert(t *testing.T, data []byte, password string) *x509.Certificate {
+	t.Helper()
+	var pfx pkcs12PFX
+	if _, err := asn1.Unmarshal(data, &pfx); err != nil {
+		t.Fatalf("failed to decode PFX: %v", err)
+	}
+	var authSafe []byte
+	if _, err := asn1.Unmarshal(pfx.AuthSafe.Content.Bytes, &authSafe); err != nil {
+		t.Fatalf("failed to decode authenticated safe: %v", err)
+	}
+
+	key := pkcs12DeriveKey(append(bmpString(password), 0, 0), pfx.MacData.MacSalt, 3, pfx.MacData.Iterations, sha1.Size)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(authSafe)
+	if !hmac.Equal(mac.Sum(nil), pfx.MacData.Mac.Digest) {
+		t.Fatal("MAC does not verify")
+	}
+
+	var contents []pkcs12ContentInfo
+	if _, err := asn1.Unmarshal(authSafe, &contents); err != nil || len(contents) != 1 {
+		t.Fatalf("failed to decode content infos: %v", err)
+	}
+	var safeContents []byte
+	if _, err := asn1.Unmarshal(contents[0].Content.Bytes, &safeContents); err != nil {
+		t.Fatalf("failed to decode safe contents: %v", err)
+	}
+	var bags []pkcs12SafeBag
+	if _, err := asn1.Unmarshal(safeContents, &bags); err != nil || len(bags) != 1 {
+		t.Fatalf("failed to decode safe bags: %v", err)
+	}
+	if !bags[0].ID.Equal(oidCertBag) {
+		t.Fatalf("bag type = %v, want certificate bag", bags[0].ID)
+	}
+	var certBag pkcs12CertBag
+	if _, err := asn1.Unmarshal(bags[0].Value.Bytes, &certBag); err != nil {
+		t.Fatalf("failed to decode certificate bag: %v", err)
+	}
+	var der []byte
+	if _, err := asn1.Unmarshal(certBag.Data.Bytes, &der); err != nil {
+		t.Fatalf("failed to decode certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse certificate: %v", err)
+	}
+	return cert
+}
+
+func TestEncodePKCS12TrustStore(t *testing.T) {
+	cm := newTestCertManager(t)
+	ca := cm.authority().cert
+
+	for _, password := range []string{"", "changeit", "pässwörd"} {
+		data, err := EncodePKCS12TrustStore(ca, caFriendlyName, password)
+		if err != nil {
+			t.Fatalf("EncodePKCS12TrustStore failed: %v", err)
+		}
+		if cert := decodePKCS12Cert(t, data, password); !cert.Equal(ca) {
+			t.Errorf("password %q: bundle holds a different certificate", password)
+		}
+	}
+}
+
+func TestPKCS12DeriveKey(t *testing.T) {
+	salt := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	a := pkcs12DeriveKey(append(bmpString("secret"), 0, 0), salt, 3, 2048, 20)
+	b := pkcs12DeriveKey(append(bmpString("secret"), 0, 0), salt, 3, 2048, 20)
+	if !bytes.Equal(a, b) {
+		t.Error("key derivation is not deterministic")
+	}
+	if c := pkcs12DeriveKey(append(bmpString("other"), 0, 0), salt, 3, 2048, 20); bytes.Equal(a, c) {
+		t.Error("different passwords derive the same key")
+	}
+	if d := pkcs12DeriveKey(append(bmpString("secret"), 0, 0), salt, 1, 2048, 24); len(d) != 24 || bytes.Equal(a, d[:20]) {
+		t.Error("key purposes or sizes not distinguished")
+	}
+}
+
+func TestWriteCAPKCS12(t *testing.T) {
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+	if err := GenerateCA(certPath, keyPath); err != nil {
+		t.Fatalf("GenerateCA failed: %v", err)
+	}
+	p12Path := filepath.Join(dir, "ca.p12")
+	if err := WriteCAPKCS12(certPath, p12Path, "secret"); err != nil {
+		t.Fatalf("WriteCAPKCS12 failed: %v", err)
+	}
+	data, err := os.ReadFile(p12Path)
+	if err != nil {
+		t.Fatalf("failed to read bundle: %v", err)
+	}
+	if cert := decodePKCS12Cert(t, data, "secret"); !cert.IsCA {
+		t.Error("bundle does not hold the CA certificate")
+	}
+
+	if err := WriteCAPKCS12(keyPath, p12Path, ""); err == nil {
+		t.Error("WriteCAPKCS12 accepted a key file")
+	}
+}
@@ -0,0 +1,137 @@
+package proxy
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/pkg/placeholder"
+)
+
+// newPlaceholderGenerator creates the placeholder generator for cfg
+func newPlaceholderGenerator(cfg config.PlaceholderConfig) (*placeholder.Generator, error) {
+	if cfg.FuzzyTolerance < 0 || cfg.FuzzyTolerance > placeholder.MaxFuzzyTolerance {
+		return nil, fmt.Errorf("placeholder fuzzy tolerance must be between 0 and %d, got %d",
+			placeholder.MaxFuzzyTolerance, cfg.FuzzyTolerance)
+	}
+
+	prefix, suffix, alphabet := cfg.Prefix, cfg.Suffix, cfg.Alphabet
+	switch cfg.Format {
+	case "", "standard":
+	case "sequential":
+		gen := placeholder.NewSequentialGenerator(placeholder.SequentialPrefix, placeholder.SequentialSuffix)
+		if cfg.TypeTags {
+			gen = gen.WithTypeTags()
+		}
+		return withLegacyPlaceholders(gen.WithFuzzyMatching(cfg.FuzzyTolerance), cfg.Legacy)
+	case "compact":
+		prefix, suffix = placeholder.CompactPrefix, placeholder.CompactSuffix
+		if alphabet == "" {
+			alphabet = placeholder.CompactAlphabet
+		}
+	default:
+		return nil, fmt.Errorf("unknown placeholder format %q", cfg.Format)
+	}
+
+	var gen *placeholder.Generator
+	switch cfg.Mode {
+	case "", "deterministic":
+		gen = placeholder.NewGeneratorWithHashLength(prefix, suffix, cfg.HashLength)
+	case "hmac":
+		key, err := loadPlaceholderKey(cfg.KeyFile)
+		if err != nil {
+			return nil, err
+		}
+		gen = placeholder.NewKeyedGenerator(prefix, suffix, cfg.HashLength, key)
+	case "random":
+		gen = placeholder.NewRandomGenerator(prefix, suffix, cfg.HashLength)
+	default:
+		return nil, fmt.Errorf("unknown placeholder mode %q", cfg.Mode)
+	}
+
+	if alphabet != "" {
+		var err error
+		if gen, err = gen.WithAlphabet(alphabet, cfg.HashLength); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateHashLength(gen, cfg.HashLength); err != nil {
+		return nil, err
+	}
+
+	if cfg.TypeTags {
+		gen = gen.WithTypeTags()
+	}
+	return withLegacyPlaceholders(gen.WithFuzzyMatching(cfg.FuzzyTolerance), cfg.Legacy)
+}
+
+// withLegacyPlaceholders makes gen recognize the placeholders of earlier
+// settings. Only their format matters, so they are never keyed or random.
+func withLegacyPlaceholders(gen *placeholder.Generator, legacy []config.LegacyPlaceholderConfig) (*placeholder.Generator, error) {
+	if len(legacy) == 0 {
+		return gen, nil
+	}
+
+	generators := make([]placeholder.Scheme, 0, len(legacy))
+	for i, cfg := range legacy {
+		hashLen := cfg.HashLength
+		if hashLen == 0 {
+			hashLen = placeholder.DefaultHashLength
+		}
+		old, err := newPlaceholderGenerator(config.PlaceholderConfig{
+			Format:     cfg.Format,
+			Prefix:     cfg.Prefix,
+			Suffix:     cfg.Suffix,
+			Alphabet:   cfg.Alphabet,
+			HashLength: hashLen,
+			TypeTags:   cfg.TypeTags,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid legacy placeholder %d: %w", i, err)
+		}
+		generators = append(generators, old)
+	}
+	return gen.WithLegacy(generators...), nil
+}
+
+// validateHashLength checks the configured placeholder hash length against
+// the range supported by the generator's alphabet
+func validateHashLength(gen *placeholder.Generator, hashLen int) error {
+	minLen, maxLen := gen.HashLengthRange()
+	if hashLen < minLen || hashLen > maxLen {
+		return fmt.Errorf("placeholder hash length must be between %d and %d, got %d",
+			minLen, maxLen, hashLen)
+	}
+	return nil
+}
+
+// minPlaceholderKeyLength is the shortest accepted HMAC key in bytes
+const minPlaceholderKeyLength = 16
+
+// loadPlaceholderKey reads the base64-encoded HMAC key of the "hmac" mode
+// from path, or from LLM_PROXY_PLACEHOLDER_KEY if path is empty
+func loadPlaceholderKey(path string) ([]byte, error) {
+	encoded := os.Getenv("LLM_PROXY_PLACEHOLDER_KEY")
+	if path != "" {
+		data, err := os.ReadFile(filepath.Clean(path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read placeholder key: %w", err)
+		}
+		encoded = string(data)
+	}
+	if encoded == "" {
+		return nil, fmt.Errorf("placeholder mode \"hmac\" requires key_file or LLM_PROXY_PLACEHOLDER_KEY")
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode placeholder key: %w", err)
+	}
+	if len(key) < minPlaceholderKeyLength {
+		return nil, fmt.Errorf("placeholder key must be at least %d bytes, got %d", minPlaceholderKeyLength, len(key))
+	}
+	return key, nil
+}
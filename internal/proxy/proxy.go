@@ -3,17 +3,23 @@ package proxy
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"crypto/tls"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net"
 	"net/http"
 	"sync"
 	"time"
 
+	"github.com/hfi/llm-secret-interceptor/internal/alert"
+	"github.com/hfi/llm-secret-interceptor/internal/audit"
 	"github.com/hfi/llm-secret-interceptor/internal/config"
 	"github.com/hfi/llm-secret-interceptor/internal/interceptor"
+	"github.com/hfi/llm-secret-interceptor/internal/kms"
 	"github.com/hfi/llm-secret-interceptor/internal/metrics"
 	"github.com/hfi/llm-secret-interceptor/internal/protocol"
 	"github.com/hfi/llm-secret-interceptor/internal/storage"
@@ -28,99 +34,297 @@ type Server struct {
 	registry     *protocol.Registry
 	interceptors *interceptor.Manager
 	store        storage.MappingStore
+	keyring      *kms.Keyring
 	placeholder  *placeholder.Generator
-	httpServer   *http.Server
-	logger       zerolog.Logger
-	wg           sync.WaitGroup
+	transport    *http.Transport
+	redaction    *redactionPolicy
+	limiter      *rateLimiter
+	acl          *clientACL
+	audit        auditLogger
+	// alerts notifies channels of detections, nil if alerting is disabled
+	alerts *alert.Manager
+	// traces holds the trace contexts of in-flight requests by request ID
+	traces sync.Map
+	// tunnelSlots and upstreamSlots cap concurrent CONNECT tunnels and
+	// in-flight upstream requests
+	tunnelSlots   *slots
+	upstreamSlots *slots
+	clientTunnels *clientTunnels
+	// fallbackHosts remembers upstreams whose tunnels are relayed unchanged
+	fallbackHosts *fallbackHosts
+	// upstreamCerts caches upstream certificates mirrored into generated ones
+	upstreamCerts *upstreamCerts
+	// pinning detects clients pinning certificates and the hosts bypassed
+	// because of it
+	pinning *pinningDetector
+	// stats counts detections for the admin API
+	stats *detectionStats
+	// falsePositives counts reported false positives for the admin API
+	falsePositives falsePositiveCounts
+	httpServer     *http.Server
+	gateways       []*gateway
+	logger         zerolog.Logger
+	wg             sync.WaitGroup
+	// stopCAWatch stops watching the CA files
+	stopCAWatch chan struct{}
+	// mu guards config, interceptors and acl, which Reload replaces
+	mu sync.RWMutex
+	// tuneMu serializes configuration changes made through the admin API
+	tuneMu sync.Mutex
+	// tunnels tracks CONNECT tunnels and WebSocket connections, which
+	// outlive the http.Server that accepted them; tunnelConns holds their
+	// hijacked client connections, closed when draining times out
+	tunnels     sync.WaitGroup
+	tunnelMu    sync.Mutex
+	tunnelConns map[net.Conn]struct{}
+	drained     bool
+	// listeners are the sockets handed to an upgraded process; inherited
+	// are those handed over by the previous one
+	listenMu  sync.Mutex
+	listeners []listenerAddr
+	inherited map[string]net.Listener
 }
 
 // NewServer creates a new proxy server instance
 func NewServer(cfg *config.Config, logger zerolog.Logger) (*Server, error) {
 	// Initialize certificate manager
-	certManager, err := NewCertManager(cfg.TLS.CACert, cfg.TLS.CAKey)
+	signer, err := NewCASigner(context.Background(), cfg.TLS.CASigner)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize CA signer: %w", err)
+	}
+	var certManager *CertManager
+	if signer != nil {
+		certManager, err = NewCertManagerWithSigner(cfg.TLS.CACert, signer)
+	} else {
+		certManager, err = NewCertManager(cfg.TLS.CACert, cfg.TLS.CAKey)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to initialize certificate manager: %w", err)
 	}
+	if err := certManager.SetLeafValidity(cfg.TLS.LeafValidity); err != nil {
+		return nil, err
+	}
+	certManager.SetCacheSize(cfg.TLS.CertCacheSize)
+	if cfg.TLS.CertCacheDir != "" {
+		if err := certManager.SetCacheDir(cfg.TLS.CertCacheDir); err != nil {
+			return nil, err
+		}
+	}
+	if err := certManager.SetWildcardDomains(cfg.TLS.WildcardDomains); err != nil {
+		return nil, err
+	}
+	if cfg.TLS.SharedLeafKey {
+		if err := certManager.UseSharedKey(); err != nil {
+			return nil, err
+		}
+	}
 
 	// Initialize protocol registry
 	registry := protocol.NewRegistry()
 	registry.Register(protocol.NewOpenAIHandler())
 
 	// Initialize interceptor manager
-	interceptorManager := interceptor.NewManager()
-	if cfg.Interceptors.Entropy.Enabled {
-		entropyInterceptor := interceptor.NewEntropyInterceptor(
-			cfg.Interceptors.Entropy.Threshold,
-			cfg.Interceptors.Entropy.MinLength,
-			cfg.Interceptors.Entropy.MaxLength,
-		)
-		interceptorManager.Register(entropyInterceptor)
+	interceptorManager, err := NewInterceptorManager(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize interceptors: %w", err)
+	}
+
+	if err := validateFailureMode(cfg.Proxy.FailureMode); err != nil {
+		return nil, err
+	}
+	if err := validateInterceptMode(cfg.Proxy); err != nil {
+		return nil, err
+	}
+	if err := validateHostPatterns("websocket.inspect_hosts", cfg.Proxy.WebSocket.InspectHosts); err != nil {
+		return nil, err
+	}
+	if err := validateListeners(proxyListeners(cfg.Proxy)); err != nil {
+		return nil, err
+	}
+	if err := validateBlockPolicy(cfg.Policy.Block); err != nil {
+		return nil, err
+	}
+	if err := validateMode(cfg.Mode); err != nil {
+		return nil, err
+	}
+	if err := validateAdminAccess(cfg.Metrics.Admin); err != nil {
+		return nil, err
+	}
+	if cfg.Mode == "shadow" {
+		logger.Warn().Msg("Shadow mode: secrets are detected but forwarded unmasked")
+	}
+	gateways, err := newGateways(cfg.Proxy.Gateways)
+	if err != nil {
+		return nil, err
+	}
+	limiter, err := newRateLimiter(cfg.Proxy.RateLimit, cfg.Storage.Namespace.TrustProxyAuthorization)
+	if err != nil {
+		return nil, err
+	}
+	acl, err := newClientACL(cfg.Proxy.ACL)
+	if err != nil {
+		return nil, err
+	}
+	concurrency := cfg.Proxy.Concurrency
+	if concurrency.MaxTunnels < 0 || concurrency.MaxUpstreamRequests < 0 || concurrency.MaxTunnelsPerClient < 0 {
+		return nil, fmt.Errorf("concurrency limits must not be negative")
 	}
 
 	// Initialize storage
-	var store storage.MappingStore
-	if cfg.Storage.Type == "redis" {
-		store, err = storage.NewRedisStore(
-			cfg.Storage.Redis.Address,
-			cfg.Storage.Redis.Password,
-			cfg.Storage.Redis.DB,
-			cfg.Storage.TTL,
-		)
-		if err != nil {
-			return nil, fmt.Errorf("failed to initialize Redis store: %w", err)
-		}
-	} else {
-		store = storage.NewMemoryStore(cfg.Storage.TTL)
+	if err := validateNamespaceConfig(cfg.Storage.Namespace); err != nil {
+		return nil, err
+	}
+	store, keyring, err := newMappingStore(context.Background(), cfg.Storage)
+	if err != nil {
+		return nil, err
 	}
 
 	// Initialize placeholder generator
-	placeholderGen := placeholder.NewGenerator(cfg.Placeholder.Prefix, cfg.Placeholder.Suffix)
+	placeholderGen, err := newPlaceholderGenerator(cfg.Placeholder)
+	if err != nil {
+		return nil, closeOnError(store, err)
+	}
+	placeholderGen = placeholderGen.WithSequenceTTL(cfg.Storage.TTL)
+	redaction, err := newRedactionPolicy(cfg.Placeholder.Redaction, placeholderGen)
+	if err != nil {
+		if closeErr := store.Close(); closeErr != nil {
+			err = fmt.Errorf("%w (close store: %v)", err, closeErr)
+		}
+		return nil, fmt.Errorf("invalid redaction config: %w", err)
+	}
+	auditLog, err := NewAuditLogger(cfg, logger)
+	if err != nil {
+		return nil, closeOnError(store, fmt.Errorf("failed to initialize audit logger: %w", err))
+	}
+	alerts, err := newAlerts(cfg.Alerting, logger)
+	if err != nil {
+		_ = auditLog.Close()
+		return nil, closeOnError(store, err)
+	}
+	inherited, err := inheritedListeners()
+	if err != nil {
+		return nil, closeOnError(store, err)
+	}
 
 	server := &Server{
-		config:       cfg,
-		certManager:  certManager,
-		registry:     registry,
-		interceptors: interceptorManager,
-		store:        store,
-		placeholder:  placeholderGen,
-		logger:       logger,
+		config:        cfg,
+		certManager:   certManager,
+		registry:      registry,
+		interceptors:  interceptorManager,
+		store:         store,
+		keyring:       keyring,
+		placeholder:   placeholderGen,
+		transport:     newUpstreamTransport(cfg.Proxy),
+		redaction:     redaction,
+		gateways:      gateways,
+		limiter:       limiter,
+		acl:           acl,
+		audit:         auditLog,
+		alerts:        alerts,
+		tunnelSlots:   newSlots("tunnels", concurrency.MaxTunnels, concurrency.QueueTimeout),
+		upstreamSlots: newSlots("upstream_requests", concurrency.MaxUpstreamRequests, concurrency.QueueTimeout),
+		clientTunnels: newClientTunnels(concurrency.MaxTunnelsPerClient),
+		fallbackHosts: newFallbackHosts(),
+		upstreamCerts: newUpstreamCerts(),
+		pinning:       newPinningDetector(),
+		stats:         newDetectionStats(cfg.Metrics.Stats),
+		logger:        logger,
+		inherited:     inherited,
 	}
+	auditLog.SetTraceLookup(server.traceOf)
 
 	return server, nil
 }
 
 // Start starts the proxy server
 func (s *Server) Start() error {
-	s.logger.Info().Str("listen", s.config.Proxy.Listen).Msg("Starting proxy server")
-
 	s.httpServer = &http.Server{
-		Addr:              s.config.Proxy.Listen,
 		Handler:           s,
 		ReadHeaderTimeout: 10 * time.Second,
 		ReadTimeout:       30 * time.Second,
 		WriteTimeout:      60 * time.Second,
 		IdleTimeout:       120 * time.Second,
+		ConnContext:       markUnixConn,
 		// Disable HTTP/2 for easier request manipulation
 		TLSNextProto: make(map[string]func(*http.Server, *tls.Conn, http.Handler)),
 	}
 
-	lc := net.ListenConfig{}
-	ln, err := lc.Listen(context.Background(), "tcp", s.config.Proxy.Listen)
-	if err != nil {
-		return fmt.Errorf("failed to listen: %w", err)
+	for _, l := range proxyListeners(s.cfg().Proxy) {
+		if err := s.startListener(l); err != nil {
+			return err
+		}
 	}
 
-	s.wg.Add(1)
-	go func() {
-		defer s.wg.Done()
-		if err := s.httpServer.Serve(ln); err != nil && err != http.ErrServerClosed {
-			s.logger.Error().Err(err).Msg("Server error")
+	for _, g := range s.gateways {
+		if err := s.startGateway(g); err != nil {
+			return err
 		}
-	}()
+	}
+	s.notifyUpgraded()
+
+	if rotation := s.cfg().TLS.CARotation; rotation.Watch && rotation.WatchInterval > 0 {
+		s.stopCAWatch = make(chan struct{})
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.watchCA(s.stopCAWatch)
+		}()
+	}
 
 	return nil
 }
 
+// drainTunnels waits up to proxy.drain_timeout for open tunnels to finish,
+// then closes those still open and waits for them to end
+func (s *Server) drainTunnels() {
+	drained := make(chan struct{})
+	go func() {
+		s.tunnels.Wait()
+		close(drained)
+	}()
+	timeout := s.cfg().Proxy.DrainTimeout
+	s.logger.Info().Dur("timeout", timeout).Msg("Waiting for open tunnels")
+	select {
+	case <-drained:
+	case <-time.After(timeout):
+		s.logger.Warn().Int("tunnels", s.closeTunnels()).Msg("Drain timeout reached, closing open tunnels")
+		<-drained
+	}
+}
+
+// trackTunnel registers the hijacked client connection of a tunnel, so it
+// is closed if draining times out. The returned function unregisters it.
+func (s *Server) trackTunnel(conn net.Conn) func() {
+	s.tunnelMu.Lock()
+	defer s.tunnelMu.Unlock()
+	if s.drained {
+		// Draining already gave up on open tunnels
+		_ = conn.Close()
+		return func() {}
+	}
+	if s.tunnelConns == nil {
+		s.tunnelConns = make(map[net.Conn]struct{})
+	}
+	s.tunnelConns[conn] = struct{}{}
+	return func() {
+		s.tunnelMu.Lock()
+		defer s.tunnelMu.Unlock()
+		delete(s.tunnelConns, conn)
+	}
+}
+
+// closeTunnels closes the client connections of open tunnels and of those
+// hijacked later, returning how many were open
+func (s *Server) closeTunnels() int {
+	s.tunnelMu.Lock()
+	defer s.tunnelMu.Unlock()
+	s.drained = true
+	for conn := range s.tunnelConns {
+		_ = conn.Close()
+	}
+	return len(s.tunnelConns)
+}
+
 // Stop gracefully stops the proxy server
 func (s *Server) Stop() error {
 	s.logger.Info().Msg("Stopping proxy server")
@@ -131,13 +335,33 @@ func (s *Server) Stop() error {
 	if err := s.httpServer.Shutdown(ctx); err != nil {
 		return fmt.Errorf("failed to shutdown server: %w", err)
 	}
+	for _, g := range s.gateways {
+		if g.httpServer == nil {
+			continue
+		}
+		if err := g.httpServer.Shutdown(ctx); err != nil {
+			return fmt.Errorf("failed to shutdown gateway %s: %w", g.listen, err)
+		}
+	}
 
+	if s.stopCAWatch != nil {
+		close(s.stopCAWatch)
+	}
 	s.wg.Wait()
+	s.drainTunnels()
 
 	// Close storage
 	if err := s.store.Close(); err != nil {
 		return fmt.Errorf("failed to close store: %w", err)
 	}
+	if s.alerts != nil {
+		if err := s.alerts.Close(); err != nil {
+			return fmt.Errorf("failed to close alerts: %w", err)
+		}
+	}
+	if err := s.auditor().Close(); err != nil {
+		return fmt.Errorf("failed to close audit logger: %w", err)
+	}
 
 	return nil
 }
@@ -147,6 +371,10 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	metrics.RecordRequest(r.Method, r.Host)
 	start := time.Now()
 
+	if !s.admitClient(w, r) {
+		return
+	}
+
 	if r.Method == http.MethodConnect {
 		// HTTPS CONNECT tunnel
 		s.handleConnect(w, r)
@@ -162,6 +390,33 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 func (s *Server) handleConnect(w http.ResponseWriter, r *http.Request) {
 	s.logger.Debug().Str("host", r.Host).Msg("CONNECT request")
 
+	// Hold a tunnel slot for the lifetime of the connection
+	release, err := s.tunnelSlots.acquire(r.Context())
+	if err != nil {
+		s.logger.Warn().Err(err).Str("host", r.Host).Msg("Rejecting CONNECT request")
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer release()
+	releaseClient, err := s.clientTunnels.acquire(clientIP(r))
+	if err != nil {
+		s.logger.Warn().Err(err).Str("client", clientIP(r)).Str("host", r.Host).Msg("Rejecting CONNECT request")
+		w.Header().Set("Retry-After", "1")
+		http.Error(w, err.Error(), http.StatusTooManyRequests)
+		return
+	}
+	defer releaseClient()
+	s.tunnels.Add(1)
+	defer s.tunnels.Done()
+	metrics.ActiveConnections.Inc()
+	defer metrics.ActiveConnections.Dec()
+
+	if !s.intercepts(clientIP(r), r.Host) {
+		s.handleTunnel(w, r)
+		return
+	}
+
 	// Hijack the connection
 	hijacker, ok := w.(http.Hijacker)
 	if !ok {
@@ -175,6 +430,7 @@ func (s *Server) handleConnect(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	defer s.trackTunnel(clientConn)()
 
 	// Send 200 Connection Established
 	_, err = clientConn.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n"))
@@ -188,82 +444,52 @@ func (s *Server) handleConnect(w http.ResponseWriter, r *http.Request) {
 
 	// Create TLS config with dynamic certificate
 	tlsConfig := &tls.Config{
-		GetCertificate: s.certManager.GetCertificate,
+		GetCertificate: s.certificateFor(r.Host),
 		MinVersion:     tls.VersionTLS12,
+		NextProtos:     nextProtos(s.cfg().Proxy),
+	}
+
+	// Record the handshake, so tunnels that cannot be intercepted are
+	// relayed instead of failing
+	conn := clientConn
+	var rec *recordingConn
+	var fallback string
+	if rawFallbackEnabled(s.cfg().Proxy.RawFallback) {
+		rec = &recordingConn{Conn: clientConn}
+		conn = rec
+		tlsConfig.GetConfigForClient = func(hello *tls.ClientHelloInfo) (*tls.Config, error) {
+			if fallback = s.rawFallbackReason(hello.Context(), r.Host, hello); fallback != "" {
+				rec.muted = true
+				return nil, errRawFallback
+			}
+			return nil, nil
+		}
 	}
 
 	// Wrap client connection with TLS
-	tlsClientConn := tls.Server(clientConn, tlsConfig)
+	tlsClientConn := tls.Server(conn, tlsConfig)
 	handshakeCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 	if err := tlsClientConn.HandshakeContext(handshakeCtx); err != nil {
-		s.logger.Error().Err(err).Msg("TLS handshake failed")
+		if fallback != "" {
+			s.relayRecorded(r.Context(), rec, r.Host, fallback)
+		} else {
+			s.logger.Error().Err(err).Msg("TLS handshake failed")
+			s.auditor().LogError(audit.EventTLSError, "", r.Host, "client handshake: "+err.Error())
+			s.rememberRejection(r.Host, err)
+			s.detectPinning(clientIP(r), r.Host, err)
+		}
 		if closeErr := clientConn.Close(); closeErr != nil {
 			s.logger.Debug().Err(closeErr).Msg("Failed to close client connection")
 		}
 		return
 	}
+	if rec != nil {
+		rec.stopRecording()
+	}
 
 	// Handle the TLS connection
-	s.handleTLSConnection(tlsClientConn, r.Host)
-}
-
-// handleTLSConnection processes requests over an intercepted TLS connection
-func (s *Server) handleTLSConnection(clientConn *tls.Conn, targetHost string) {
-	defer func() {
-		if err := clientConn.Close(); err != nil {
-			s.logger.Debug().Err(err).Msg("Failed to close TLS client connection")
-		}
-	}()
-
-	reader := bufio.NewReader(clientConn)
-
-	for {
-		// Read HTTP request from client
-		req, err := http.ReadRequest(reader)
-		if err != nil {
-			if err != io.EOF {
-				s.logger.Debug().Err(err).Msg("Failed to read request")
-			}
-			return
-		}
-
-		// Set the correct host and scheme
-		req.URL.Scheme = "https"
-		req.URL.Host = targetHost
-		req.RequestURI = ""
-
-		// Process and forward the request
-		resp, err := s.processRequest(req)
-		if err != nil {
-			s.logger.Error().Err(err).Msg("Failed to process request")
-			s.sendErrorResponse(clientConn, http.StatusBadGateway, err.Error())
-			return
-		}
-
-		// Process the response
-		processedResp, err := s.processResponse(resp)
-		if err != nil {
-			s.logger.Error().Err(err).Msg("Failed to process response")
-			if closeErr := resp.Body.Close(); closeErr != nil {
-				s.logger.Debug().Err(closeErr).Msg("Failed to close response body")
-			}
-			s.sendErrorResponse(clientConn, http.StatusInternalServerError, err.Error())
-			return
-		}
-
-		// Write response back to client
-		if err := processedResp.Write(clientConn); err != nil {
-			s.logger.Debug().Err(err).Msg("Failed to write response")
-			if closeErr := processedResp.Body.Close(); closeErr != nil {
-				s.logger.Debug().Err(closeErr).Msg("Failed to close processed response body")
-			}
-			return
-		}
-		if closeErr := processedResp.Body.Close(); closeErr != nil {
-			s.logger.Debug().Err(closeErr).Msg("Failed to close processed response body")
-		}
-	}
+	s.serveTLSConnection(tlsClientConn, r.Host, s.newConnClient(r))
 }
 
 // handleHTTP handles plain HTTP requests (passthrough)
@@ -271,7 +497,8 @@ func (s *Server) handleHTTP(w http.ResponseWriter, r *http.Request) {
 	s.logger.Debug().Str("url", r.URL.String()).Msg("HTTP request")
 
 	// For plain HTTP, just proxy through
-	resp, err := http.DefaultTransport.RoundTrip(r)
+	r.Body = countBody(r.Body, "request")
+	resp, err := s.upstream().RoundTrip(r)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadGateway)
 		return
@@ -291,19 +518,19 @@ func (s *Server) handleHTTP(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(resp.StatusCode)
 
 	// Copy body
-	if _, err := io.Copy(w, resp.Body); err != nil {
+	if _, err := io.Copy(w, &countingReader{r: resp.Body, direction: "response"}); err != nil {
 		s.logger.Debug().Err(err).Msg("Failed to copy response body")
 	}
 }
 
 // processRequest intercepts and modifies outgoing requests
-func (s *Server) processRequest(req *http.Request) (*http.Response, error) {
+func (s *Server) processRequest(req *http.Request, store storage.MappingStore) (*http.Response, error) {
 	// Check if we can handle this protocol
 	handler := s.registry.Detect(req)
 	if handler == nil {
 		// Passthrough - no protocol handler
 		s.logger.Debug().Str("url", req.URL.String()).Msg("Passthrough request (no handler)")
-		return http.DefaultTransport.RoundTrip(req)
+		return s.upstream().RoundTrip(req)
 	}
 
 	s.logger.Debug().
@@ -311,8 +538,14 @@ func (s *Server) processRequest(req *http.Request) (*http.Response, error) {
 		Str("handler", handler.Name()).
 		Msg("Processing request")
 
+	// Scan messages while forwarding instead of buffering the body
+	if streamer, ok := handler.(protocol.MessageStreamer); ok && s.cfg().Proxy.StreamRequests {
+		return s.streamRequest(req, store, streamer)
+	}
+
 	// Read request body
-	body, err := io.ReadAll(req.Body)
+	limit := s.cfg().Proxy.MaxRequestBody
+	body, err := readLimited(req.Body, limit)
 	if closeErr := req.Body.Close(); closeErr != nil {
 		s.logger.Debug().Err(closeErr).Msg("Failed to close request body")
 	}
@@ -320,75 +553,199 @@ func (s *Server) processRequest(req *http.Request) (*http.Response, error) {
 		return nil, fmt.Errorf("failed to read request body: %w", err)
 	}
 
-	// Parse request
+	// Decode compressed bodies; undecodable bodies are rejected so secrets
+	// are never forwarded unscanned
+	encoding := contentEncoding(req.Header)
+	raw := body
+	body, err = decodeBody(encoding, body, limit)
+	if errors.Is(err, errRequestTooLarge) {
+		return nil, err
+	}
+	if err != nil {
+		metrics.RecordFailureDecision(stageDecode, "closed")
+		return nil, fmt.Errorf("%w: %v", errUnsupportedEncoding, err)
+	}
+
+	// Parse request; unparsable bodies are forwarded unchanged in fail-open mode
 	msg, err := handler.ParseRequest(body)
 	if err != nil {
+		if s.rejectFailure(stageParse) {
+			s.logger.Warn().Err(err).Msg("Failed to parse request, rejecting")
+			return nil, fmt.Errorf("%w: %v", errUnparsableRequest, err)
+		}
 		s.logger.Warn().Err(err).Msg("Failed to parse request, passing through")
-		// Restore body and passthrough
-		req.Body = io.NopCloser(io.NopCloser(io.LimitReader(io.MultiReader(io.NopCloser(io.LimitReader(nil, 0))), 0)))
-		return http.DefaultTransport.RoundTrip(req)
+		req.Body = io.NopCloser(bytes.NewReader(raw))
+		req.GetBody = func() (io.ReadCloser, error) {
+			return io.NopCloser(bytes.NewReader(raw)), nil
+		}
+		req.ContentLength = int64(len(raw))
+		return s.upstream().RoundTrip(req)
+	}
+
+	// Limit the total detection time for this request
+	detectCtx := req.Context()
+	budget := s.cfg().Interceptors.DetectionBudget
+	if budget > 0 {
+		var cancel context.CancelFunc
+		detectCtx, cancel = context.WithTimeout(detectCtx, budget)
+		defer cancel()
 	}
 
 	// Process each message for secrets
 	modified := false
 	for i, m := range msg.Messages {
-		// Detect secrets
-		secrets := s.interceptors.DetectAll(m.Content)
-		if len(secrets) == 0 {
+		content, found, err := s.maskSecrets(req.Context(), detectCtx, store, m.Content, req.URL.Host)
+		if err != nil {
+			return nil, err
+		}
+		if found == 0 {
 			continue
 		}
 
-		modified = true
 		s.logger.Info().
-			Int("secrets_found", len(secrets)).
+			Int("secrets_found", found).
 			Str("role", m.Role).
 			Msg("Detected secrets in message")
-
-		// Replace secrets with placeholders
-		content := m.Content
-		for _, secret := range secrets {
-			ph := s.placeholder.Generate(secret.Value)
-
-			// Store mapping
-			if err := s.store.Store(ph, secret.Value); err != nil {
-				s.logger.Error().Err(err).Msg("Failed to store mapping")
-			}
-
-			// Replace in content
-			content = replaceSecret(content, secret, ph)
-
-			// Update metrics
-			metrics.RecordSecretDetected(secret.Source, secret.Type)
-			metrics.SecretsReplacedTotal.Inc()
+		if content != m.Content {
+			// Shadow mode leaves content unchanged
+			modified = true
+			msg.Messages[i].Content = content
 		}
+	}
 
-		msg.Messages[i].Content = content
+	if detectCtx.Err() == context.DeadlineExceeded {
+		s.logger.Warn().
+			Str("url", req.URL.String()).
+			Dur("budget", budget).
+			Msg("Detection budget exceeded, remaining interceptors skipped")
+		if s.rejectFailure(stageDetection) {
+			return nil, fmt.Errorf("%w: detection budget of %s exceeded", errDetectionIncomplete, budget)
+		}
 	}
 
-	// Serialize back if modified
+	// Serialize back if modified, in the original encoding
+	body = raw
 	if modified {
 		body, err = handler.SerializeRequest(msg)
 		if err != nil {
 			return nil, fmt.Errorf("failed to serialize request: %w", err)
 		}
+		if body, err = encodeBody(encoding, body); err != nil {
+			return nil, err
+		}
 	}
 
 	// Create new request with modified body
-	newReq, err := http.NewRequestWithContext(req.Context(), req.Method, req.URL.String(), io.NopCloser(newBytesReader(body)))
+	newReq, err := http.NewRequestWithContext(req.Context(), req.Method, req.URL.String(), bytes.NewReader(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
 	// Copy headers
-	newReq.Header = req.Header.Clone()
+	newReq.Header = upstreamHeader(req.Header)
 	newReq.Header.Set("Content-Length", fmt.Sprintf("%d", len(body)))
+	forwardTrailer(newReq, req)
 
 	// Forward request
-	return http.DefaultTransport.RoundTrip(newReq)
+	return s.upstream().RoundTrip(newReq)
+}
+
+// maskSecrets replaces the secrets detected in content with placeholders
+// stored in store. It returns the masked content and the number of detected
+// secrets; in fail-closed mode store failures are returned as errors.
+func (s *Server) maskSecrets(ctx, detectCtx context.Context, store storage.MappingStore, content, host string) (string, int, error) {
+	secrets := s.detector().DetectAllContext(detectCtx, content)
+	if len(secrets) == 0 {
+		return content, 0, nil
+	}
+	requestID := requestIDFromContext(ctx)
+	for _, secret := range secrets {
+		s.auditor().LogSecretDetected(requestID, secret.Source, secret.Type)
+		s.alertDetected(ctx, host, secret)
+	}
+	if s.shadow() {
+		s.recordShadow(host, secrets)
+		s.stats.recordAll(host, secrets, "shadow")
+		return content, len(secrets), nil
+	}
+
+	// Blocked requests are rejected before any mapping is stored
+	if secret, ok := blockingSecret(s.cfg().Policy.Block, secrets); ok {
+		s.stats.recordAll(host, secrets, "block")
+		metrics.RecordSecretDetected(host, secret.Source, secret.Type, secret.Rule)
+		metrics.RecordRequestBlocked(secret.Type)
+		return "", 0, fmt.Errorf("%w (type %s)", errSecretBlocked, secret.Type)
+	}
+
+	replacements := make(map[string]string, len(secrets))
+	for _, secret := range secrets {
+		metrics.RecordSecretDetected(host, secret.Source, secret.Type, secret.Rule)
+		action := "mask"
+		if s.redaction.redacts(secret.Type) {
+			action = "redact"
+		}
+		s.stats.record(host, secret, action)
+		if _, seen := replacements[secret.Value]; seen {
+			continue
+		}
+
+		// Redacted secrets are replaced for good and never stored
+		if s.redaction.redacts(secret.Type) {
+			replacements[secret.Value] = s.redaction.token
+			metrics.RecordSecretRedacted(secret.Type)
+			continue
+		}
+
+		// Store mapping; in fail-open mode the secret is still masked,
+		// but the placeholder is not restored in the response
+		ph, err := storePlaceholder(ctx, store, s.placeholder, secret.Value, storage.Metadata{
+			SecretType:  secret.Type,
+			Interceptor: secret.Source,
+			Rule:        secret.Rule,
+			SourceHost:  host,
+			RequestID:   requestID,
+		})
+		if errors.Is(err, storage.ErrPlaceholderConflict) {
+			// Even the full hash collides; mask this secret for good
+			s.logger.Warn().Str("type", secret.Type).Msg("Unresolvable placeholder collision, secret redacted")
+			replacements[secret.Value] = s.redaction.fallbackToken()
+			continue
+		}
+		if err != nil {
+			s.logger.Error().Err(err).Msg("Failed to store mapping")
+			if s.rejectFailure(stageStore) {
+				return "", 0, fmt.Errorf("%w: %v", errStoreUnavailable, err)
+			}
+		}
+
+		if ph == "" {
+			// No placeholder to restore; never drop the secret unmasked
+			ph = s.redaction.fallbackToken()
+		}
+		replacements[secret.Value] = ph
+		metrics.SecretsReplacedTotal.Inc()
+		if err == nil {
+			s.auditor().LogMappingCreated(ph, audit.Origin{
+				RequestID:   requestID,
+				Host:        host,
+				Interceptor: secret.Source,
+				Rule:        secret.Rule,
+				SecretType:  secret.Type,
+			})
+		}
+	}
+
+	addMasked(ctx, len(replacements))
+	if len(replacements) > 0 {
+		s.auditor().LogSecretReplaced(requestID, len(replacements))
+	}
+
+	// Replace all occurrences in a single pass
+	return interceptor.ReplaceAll(content, replacements), len(secrets), nil
 }
 
 // processResponse intercepts and modifies incoming responses
-func (s *Server) processResponse(resp *http.Response) (*http.Response, error) {
+func (s *Server) processResponse(ctx context.Context, resp *http.Response, store storage.MappingStore) (*http.Response, error) {
 	start := time.Now()
 	defer func() {
 		metrics.RecordRequestDuration("response", time.Since(start).Seconds())
@@ -399,15 +756,15 @@ func (s *Server) processResponse(resp *http.Response) (*http.Response, error) {
 
 	// Handle streaming responses (SSE)
 	if isStreamingResponse(contentType) {
-		return s.processStreamingResponse(resp)
+		return s.processStreamingResponse(ctx, resp, store)
 	}
 
 	// Handle regular JSON responses
-	return s.processJSONResponse(resp)
+	return s.processJSONResponse(ctx, resp, store)
 }
 
 // processJSONResponse handles non-streaming JSON responses
-func (s *Server) processJSONResponse(resp *http.Response) (*http.Response, error) {
+func (s *Server) processJSONResponse(ctx context.Context, resp *http.Response, store storage.MappingStore) (*http.Response, error) {
 	// Read response body
 	body, err := io.ReadAll(resp.Body)
 	if closeErr := resp.Body.Close(); closeErr != nil {
@@ -417,25 +774,55 @@ func (s *Server) processJSONResponse(resp *http.Response) (*http.Response, error
 		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
+	// Decode compressed bodies, passing undecodable ones through unchanged
+	encoding := contentEncoding(resp.Header)
+	decoded, err := decodeBody(encoding, body, 0)
+	if err != nil {
+		s.logger.Warn().Err(err).Msg("Cannot decode response, placeholders not restored")
+		resp.Body = io.NopCloser(newBytesReader(body))
+		return resp, nil
+	}
+
 	// Restore placeholders
-	newBody := s.placeholder.RestorePlaceholders(string(body), func(ph string) (string, bool) {
-		secret, found := s.store.Lookup(ph)
-		if found {
-			metrics.PlaceholdersRestored.Inc()
+	restore := newRestorer(ctx, store)
+	restored := s.placeholder.RestorePlaceholders(string(decoded), restore.lookup)
+	s.auditRestored(ctx, restore)
+	if restore.err != nil {
+		s.logger.Error().Err(restore.err).Msg("Failed to restore placeholders")
+		if s.rejectFailure(stageRestore) {
+			return nil, restore.err
 		}
-		return secret, found
-	})
+	}
 
-	// Create new response with restored body
-	resp.Body = io.NopCloser(newBytesReader([]byte(newBody)))
+	// Create new response with restored body in the original encoding
+	newBody, err := encodeBody(encoding, s.appendNotice(ctx, resp, []byte(restored)))
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(newBytesReader(newBody))
 	resp.ContentLength = int64(len(newBody))
 	resp.Header.Set("Content-Length", fmt.Sprintf("%d", len(newBody)))
 
 	return resp, nil
 }
 
-// processStreamingResponse handles SSE streaming responses
-func (s *Server) processStreamingResponse(resp *http.Response) (*http.Response, error) {
+// processStreamingResponse handles SSE streaming responses. Event streams of
+// requests with a streaming protocol handler are restored event by event, so
+// placeholders split across events are restored; other streams are restored
+// as raw text.
+func (s *Server) processStreamingResponse(ctx context.Context, resp *http.Response, store storage.MappingStore) (*http.Response, error) {
+	// Compressed streams are decoded and forwarded uncompressed
+	encoding := contentEncoding(resp.Header)
+	if !supportedEncoding(encoding) {
+		s.logger.Warn().Str("encoding", encoding).Msg("Cannot decode stream, placeholders not restored")
+		return resp, nil
+	}
+
+	var handler protocol.StreamingHandler
+	if resp.Request != nil && mediaType(resp.Header.Get("Content-Type")) == "text/event-stream" {
+		handler, _ = s.registry.Detect(resp.Request).(protocol.StreamingHandler)
+	}
+
 	// Create a pipe for streaming
 	pr, pw := io.Pipe()
 
@@ -452,68 +839,53 @@ func (s *Server) processStreamingResponse(resp *http.Response) (*http.Response,
 			}
 		}()
 
-		// Buffer for read-ahead
-		bufferSize := s.placeholder.MaxLength()
-		buffer := make([]byte, 0, bufferSize*2)
-
-		reader := bufio.NewReader(resp.Body)
-
-		for {
-			// Read chunk
-			chunk, err := reader.ReadBytes('\n')
-			if err != nil && err != io.EOF {
-				s.logger.Error().Err(err).Msg("Error reading stream")
-				return
+		restore := newRestorer(ctx, store)
+		// restoreText restores placeholders in streamed text; in fail-closed
+		// mode the stream is aborted on the first store error
+		restoreText := func(text string) (string, error) {
+			restored := s.placeholder.RestorePlaceholders(text, restore.lookup)
+			if restore.err != nil && s.failClosed() {
+				return "", restore.err
 			}
+			return restored, nil
+		}
+		stream := newStreamMetrics(pw)
 
-			if len(chunk) > 0 {
-				metrics.StreamingChunksProcessed.Inc()
-
-				// Append to buffer
-				buffer = append(buffer, chunk...)
-
-				// Process buffer - keep last bufferSize bytes for potential partial placeholders
-				if len(buffer) > bufferSize {
-					// Process safe part
-					safeLen := len(buffer) - bufferSize
-					safePart := string(buffer[:safeLen])
-
-					// Restore placeholders in safe part
-					restored := s.placeholder.RestorePlaceholders(safePart, func(ph string) (string, bool) {
-						secret, found := s.store.Lookup(ph)
-						if found {
-							metrics.PlaceholdersRestored.Inc()
-						}
-						return secret, found
-					})
-
-					// Write restored content
-					if _, err := pw.Write([]byte(restored)); err != nil {
-						s.logger.Error().Err(err).Msg("Error writing to pipe")
-						return
-					}
-
-					// Keep remaining buffer
-					buffer = buffer[safeLen:]
-				}
-			}
+		decoded, err := newDecodingReader(encoding, resp.Body)
+		if err != nil {
+			s.logger.Error().Err(err).Msg("Failed to decode stream")
+			stream.finish("decode")
+			pw.CloseWithError(err)
+			return
+		}
 
-			if err == io.EOF {
-				// Flush remaining buffer
-				if len(buffer) > 0 {
-					restored := s.placeholder.RestorePlaceholders(string(buffer), func(ph string) (string, bool) {
-						secret, found := s.store.Lookup(ph)
-						if found {
-							metrics.PlaceholdersRestored.Inc()
-						}
-						return secret, found
-					})
-					if _, writeErr := pw.Write([]byte(restored)); writeErr != nil {
-						s.logger.Debug().Err(writeErr).Msg("Error writing final buffer to pipe")
-					}
-				}
-				return
-			}
+		if handler != nil {
+			err = s.restoreEventStream(decoded, stream, handler, restoreText)
+		} else {
+			err = s.restoreRawStream(decoded, stream, restoreText)
+		}
+		s.auditRestored(ctx, restore)
+		switch {
+		case restore.err != nil && s.failClosed():
+			s.logger.Error().Err(restore.err).Msg("Failed to restore placeholders, aborting stream")
+			metrics.RecordFailureDecision(stageRestore, "closed")
+			stream.finish("restore")
+			pw.CloseWithError(restore.err)
+			return
+		case restore.err != nil:
+			s.logger.Error().Err(restore.err).Msg("Failed to restore placeholders")
+			metrics.RecordFailureDecision(stageRestore, "open")
+		}
+		switch {
+		case errors.Is(err, io.ErrClosedPipe):
+			s.logger.Debug().Msg("Client closed stream")
+			stream.finish("client")
+		case err != nil:
+			s.logger.Error().Err(err).Msg("Error processing stream")
+			stream.finish("upstream")
+			pw.CloseWithError(err)
+		default:
+			stream.finish("")
 		}
 	}()
 
@@ -531,25 +903,112 @@ func (s *Server) processStreamingResponse(resp *http.Response) (*http.Response,
 
 	// Remove Content-Length for streaming
 	newResp.Header.Del("Content-Length")
+	newResp.Header.Del("Content-Encoding")
 
 	return newResp, nil
 }
 
-// sendErrorResponse sends an HTTP error response
-func (s *Server) sendErrorResponse(conn net.Conn, statusCode int, message string) {
-	resp := &http.Response{
-		StatusCode: statusCode,
-		Status:     http.StatusText(statusCode),
-		Proto:      "HTTP/1.1",
-		ProtoMajor: 1,
-		ProtoMinor: 1,
-		Header:     make(http.Header),
-		Body:       io.NopCloser(newBytesReader([]byte(message))),
-	}
-	resp.Header.Set("Content-Type", "text/plain")
-	resp.Header.Set("Content-Length", fmt.Sprintf("%d", len(message)))
-	if err := resp.Write(conn); err != nil {
-		s.logger.Debug().Err(err).Msg("Failed to write error response")
+// sseEvent is an event read from an SSE stream
+type sseEvent struct {
+	eventType string
+	data      []byte
+}
+
+// restoreEventStream restores the deltas of an SSE stream read from r
+func (s *Server) restoreEventStream(r io.Reader, w *streamMetrics, handler protocol.StreamingHandler, restore func(string) (string, error)) error {
+	parser := protocol.NewSSEParser(r)
+	processor := newStreamProcessor(handler, w, s.placeholder, s.placeholder.MaxLength(), restore)
+
+	done := make(chan struct{})
+	defer close(done)
+	events := readAhead(func() (sseEvent, error) {
+		eventType, data, err := parser.ReadEvent()
+		return sseEvent{eventType: eventType, data: data}, err
+	}, done)
+
+	holdBack := &holdBackTimer{limit: s.cfg().Proxy.Streaming.MaxHoldBack}
+	defer holdBack.update(false)
+	for {
+		select {
+		case event := <-events:
+			if event.err == io.EOF {
+				return processor.Flush()
+			}
+			if event.err != nil {
+				return fmt.Errorf("failed to read stream: %w", event.err)
+			}
+			w.chunk()
+			if err := processor.ProcessEvent(event.value.eventType, event.value.data); err != nil {
+				return err
+			}
+		case <-holdBack.C:
+			// Release held back text rather than stall the client
+			holdBack.stop()
+			if err := processor.Flush(); err != nil {
+				return err
+			}
+		}
+		holdBack.update(processor.held())
+	}
+}
+
+// restoreRawStream restores placeholders in a stream read from r as text,
+// holding back the longest possible placeholder between reads
+func (s *Server) restoreRawStream(r io.Reader, w *streamMetrics, restore func(string) (string, error)) error {
+	// Buffer for read-ahead
+	bufferSize := s.placeholder.MaxLength()
+	buffer := make([]byte, 0, bufferSize*2)
+	reader := bufio.NewReader(r)
+
+	// writeRestored restores and writes the first n bytes of the buffer
+	writeRestored := func(n int) error {
+		restored, err := restore(string(buffer[:n]))
+		if err != nil {
+			return err
+		}
+		if _, err := w.Write([]byte(restored)); err != nil {
+			return err
+		}
+		buffer = buffer[n:]
+		return nil
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	lines := readAhead(func() ([]byte, error) {
+		return reader.ReadBytes('\n')
+	}, done)
+
+	holdBack := &holdBackTimer{limit: s.cfg().Proxy.Streaming.MaxHoldBack}
+	defer holdBack.update(false)
+	for {
+		select {
+		case line := <-lines:
+			if line.err != nil && line.err != io.EOF {
+				return fmt.Errorf("failed to read stream: %w", line.err)
+			}
+			if len(line.value) > 0 {
+				w.chunk()
+				buffer = append(buffer, line.value...)
+
+				// Keep the last bufferSize bytes for potential partial placeholders
+				if len(buffer) > bufferSize {
+					if err := writeRestored(len(buffer) - bufferSize); err != nil {
+						return err
+					}
+				}
+			}
+			if line.err == io.EOF {
+				return writeRestored(len(buffer))
+			}
+		case <-holdBack.C:
+			// Release held back text rather than stall the client
+			holdBack.stop()
+			if err := writeRestored(len(buffer)); err != nil {
+				return err
+			}
+		}
+		holdBack.update(len(buffer) > 0)
 	}
 }
 
@@ -561,14 +1020,21 @@ func (s *Server) UpdateMappingStoreSize() {
 // Helper functions
 
 func isStreamingResponse(contentType string) bool {
-	return contentType == "text/event-stream" ||
-		contentType == "application/x-ndjson" ||
-		contentType == "application/stream+json"
+	switch mediaType(contentType) {
+	case "text/event-stream", "application/x-ndjson", "application/stream+json":
+		return true
+	}
+	return false
 }
 
-func replaceSecret(content string, secret interceptor.DetectedSecret, placeholder string) string {
-	// Simple replacement - could be optimized for multiple occurrences
-	return content[:secret.StartIndex] + placeholder + content[secret.EndIndex:]
+// mediaType returns the lowercase media type of a Content-Type header
+// without its parameters, or "" if it is malformed
+func mediaType(contentType string) string {
+	mt, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return ""
+	}
+	return mt
 }
 
 type bytesReader struct {
@@ -0,0 +1,199 @@
+package proxy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/metrics"
+)
+
+var (
+	// errRateLimited marks requests above a client's requests per minute
+	errRateLimited = errors.New("request rate limit exceeded")
+	// errTooManyStreams marks requests above a client's concurrent streams
+	errTooManyStreams = errors.New("concurrent stream limit exceeded")
+)
+
+// rateLimitSweepInterval is how often idle client buckets are dropped
+const rateLimitSweepInterval = time.Minute
+
+// rateLimiter keeps a token bucket and an in-flight count per client
+type rateLimiter struct {
+	rate    float64 // tokens per second, 0 = unlimited
+	burst   float64
+	streams int
+
+	mu        sync.Mutex
+	clients   map[string]*clientLimit
+	lastSweep time.Time
+}
+
+// clientLimit is the rate limiting state of one client
+type clientLimit struct {
+	tokens  float64
+	updated time.Time
+	active  int
+}
+
+// newRateLimiter returns the limiter configured by cfg, or nil when no limit
+// is set. The proxy_user key requires trustProxyAuthorization, as the proxy
+// does not authenticate users.
+func newRateLimiter(cfg config.RateLimitConfig, trustProxyAuthorization bool) (*rateLimiter, error) {
+	switch cfg.Key {
+	case "", "client_ip", "authorization":
+	case "proxy_user":
+		// Unauthenticated clients could claim a fresh user per request
+		if !trustProxyAuthorization {
+			return nil, fmt.Errorf("rate limit key \"proxy_user\" requires storage.namespace.trust_proxy_authorization, as the proxy does not authenticate users")
+		}
+	default:
+		return nil, fmt.Errorf("unknown rate limit key %q", cfg.Key)
+	}
+	if cfg.RequestsPerMinute < 0 || cfg.Burst < 0 || cfg.ConcurrentStreams < 0 {
+		return nil, fmt.Errorf("rate limits must not be negative")
+	}
+	if cfg.RequestsPerMinute == 0 && cfg.ConcurrentStreams == 0 {
+		return nil, nil
+	}
+
+	burst := cfg.Burst
+	if burst == 0 {
+		burst = cfg.RequestsPerMinute
+	}
+	return &rateLimiter{
+		rate:    float64(cfg.RequestsPerMinute) / 60,
+		burst:   float64(burst),
+		streams: cfg.ConcurrentStreams,
+		clients: make(map[string]*clientLimit),
+	}, nil
+}
+
+// acquire admits a request of client at now. It returns a function releasing
+// the client's stream once the request is done, or the time to wait before
+// retrying and errRateLimited or errTooManyStreams.
+func (l *rateLimiter) acquire(client string, now time.Time) (func(), time.Duration, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+	c, ok := l.clients[client]
+	if !ok {
+		c = &clientLimit{tokens: l.burst, updated: now}
+		l.clients[client] = c
+	}
+	l.refill(c, now)
+
+	if l.streams > 0 && c.active >= l.streams {
+		metrics.RecordRateLimited("concurrency")
+		return nil, time.Second, errTooManyStreams
+	}
+	if l.rate > 0 {
+		if c.tokens < 1 {
+			metrics.RecordRateLimited("requests")
+			wait := time.Duration((1 - c.tokens) / l.rate * float64(time.Second))
+			return nil, wait, errRateLimited
+		}
+		c.tokens--
+	}
+	c.active++
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.mu.Lock()
+			c.active--
+			l.mu.Unlock()
+		})
+	}, 0, nil
+}
+
+// refill adds the tokens earned by c since its last update
+func (l *rateLimiter) refill(c *clientLimit, now time.Time) {
+	if elapsed := now.Sub(c.updated).Seconds(); elapsed > 0 {
+		c.tokens = math.Min(l.burst, c.tokens+elapsed*l.rate)
+		c.updated = now
+	}
+}
+
+// sweep drops clients without requests in flight whose bucket is full again,
+// so that state is only kept for recently active clients
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < rateLimitSweepInterval {
+		return
+	}
+	l.lastSweep = now
+	for client, c := range l.clients {
+		l.refill(c, now)
+		if c.active == 0 && c.tokens >= l.burst {
+			delete(l.clients, client)
+		}
+	}
+}
+
+// rateLimitIdentity returns the identity a request is rate limited by. With
+// the authorization key, requests are limited per API key and fall back to
+// the client address when they carry none. Clients choose their keys, so
+// rotating keys evades this limit.
+func (s *Server) rateLimitIdentity(client connClient, req *http.Request) string {
+	if s.cfg().Proxy.RateLimit.Key != "authorization" {
+		return client.identity
+	}
+	for _, header := range []string{"Authorization", "X-Api-Key", "Api-Key", "X-Goog-Api-Key"} {
+		if value := req.Header.Get(header); value != "" {
+			// Keep credentials out of the limiter state
+			sum := sha256.Sum256([]byte(value))
+			return "key:" + hex.EncodeToString(sum[:16])
+		}
+	}
+	return client.identity
+}
+
+// writeRateLimited answers a rate limited request with 429 and an error body
+// shaped like the rate limit errors of the provider it was addressed to, so
+// SDK clients back off as they would for the provider itself
+func writeRateLimited(w http.ResponseWriter, req *http.Request, retryAfter time.Duration, cause error) {
+	var body any
+	message := "llm-secret-interceptor: " + cause.Error()
+	if isAnthropicRequest(req) {
+		body = map[string]any{
+			"type":  "error",
+			"error": map[string]any{"type": "rate_limit_error", "message": message},
+		}
+	} else {
+		code := "rate_limit_exceeded"
+		if errors.Is(cause, errTooManyStreams) {
+			code = "concurrent_requests_exceeded"
+		}
+		body = map[string]any{
+			"error": map[string]any{"message": message, "type": "requests", "param": nil, "code": code},
+		}
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		http.Error(w, cause.Error(), http.StatusTooManyRequests)
+		return
+	}
+
+	seconds := max(int64(math.Ceil(retryAfter.Seconds())), 1)
+	w.Header().Set("Retry-After", strconv.FormatInt(seconds, 10))
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusTooManyRequests)
+	_, _ = w.Write(data)
+}
+
+// isAnthropicRequest reports whether req is addressed to the Anthropic API
+func isAnthropicRequest(req *http.Request) bool {
+	return matchHost([]string{"api.anthropic.com"}, req.URL.Host) ||
+		req.Header.Get("Anthropic-Version") != "" ||
+		strings.HasSuffix(req.URL.Path, "/v1/messages")
+}
@@ -0,0 +1,193 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/rs/zerolog"
+)
+
+func TestNewRateLimiter(t *testing.T) {
+	if l, err := newRateLimiter(config.RateLimitConfig{Key: "client_ip"}, false); err != nil || l != nil {
+		t.Errorf("newRateLimiter() without limits = %v, %v; want nil", l, err)
+	}
+	for _, cfg := range []config.RateLimitConfig{
+		{Key: "cookie", RequestsPerMinute: 10},
+		{RequestsPerMinute: -1},
+		{Key: "proxy_user", RequestsPerMinute: 10},
+	} {
+		if _, err := newRateLimiter(cfg, false); err == nil {
+			t.Errorf("newRateLimiter(%+v) should fail", cfg)
+		}
+	}
+	if _, err := newRateLimiter(config.RateLimitConfig{Key: "proxy_user", RequestsPerMinute: 10}, true); err != nil {
+		t.Errorf("newRateLimiter() with trusted proxy users error: %v", err)
+	}
+}
+
+func TestRateLimiter_Requests(t *testing.T) {
+	l, err := newRateLimiter(config.RateLimitConfig{RequestsPerMinute: 60, Burst: 2}, false)
+	if err != nil {
+		t.Fatalf("newRateLimiter() error: %v", err)
+	}
+	now := time.Now()
+
+	for i := range 2 {
+		release, _, err := l.acquire("ip:a", now)
+		if err != nil {
+			t.Fatalf("request %d: acquire() error: %v", i, err)
+		}
+		release()
+	}
+	_, wait, err := l.acquire("ip:a", now)
+	if !errors.Is(err, errRateLimited) || wait != time.Second {
+		t.Errorf("acquire() over burst = %v, %v; want errRateLimited after 1s", wait, err)
+	}
+	if _, _, err := l.acquire("ip:b", now); err != nil {
+		t.Errorf("other client limited: %v", err)
+	}
+	if _, _, err := l.acquire("ip:a", now.Add(time.Second)); err != nil {
+		t.Errorf("acquire() after refill error: %v", err)
+	}
+}
+
+func TestRateLimiter_ConcurrentStreams(t *testing.T) {
+	l, err := newRateLimiter(config.RateLimitConfig{ConcurrentStreams: 1}, false)
+	if err != nil {
+		t.Fatalf("newRateLimiter() error: %v", err)
+	}
+	now := time.Now()
+
+	release, _, err := l.acquire("ip:a", now)
+	if err != nil {
+		t.Fatalf("acquire() error: %v", err)
+	}
+	if _, _, err := l.acquire("ip:a", now); !errors.Is(err, errTooManyStreams) {
+		t.Errorf("second stream error = %v, want errTooManyStreams", err)
+	}
+	release()
+	release()
+	if _, _, err := l.acquire("ip:a", now); err != nil {
+		t.Errorf("acquire() after release error: %v", err)
+	}
+}
+
+func TestRateLimiter_Sweep(t *testing.T) {
+	l, err := newRateLimiter(config.RateLimitConfig{RequestsPerMinute: 60}, false)
+	if err != nil {
+		t.Fatalf("newRateLimiter() error: %v", err)
+	}
+	now := time.Now()
+	release, _, _ := l.acquire("ip:a", now)
+	release()
+	held, _, _ := l.acquire("ip:b", now)
+
+	l.acquire("ip:c", now.Add(2*rateLimitSweepInterval))
+	if _, ok := l.clients["ip:a"]; ok {
+		t.Error("idle client was not swept")
+	}
+	if _, ok := l.clients["ip:b"]; !ok {
+		t.Error("client with a request in flight was swept")
+	}
+	held()
+}
+
+func TestRateLimitIdentity(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.Proxy.RateLimit.Key = "authorization"
+	s := &Server{config: cfg, logger: zerolog.Nop()}
+	client := connClient{identity: "ip:10.0.0.1"}
+
+	first := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+	first.Header.Set("Authorization", "Bearer sk-one")
+	second := httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+	second.Header.Set("X-Api-Key", "sk-two")
+	anonymous := httptest.NewRequest(http.MethodPost, "/v1/chat/completions", nil)
+
+	a, b := s.rateLimitIdentity(client, first), s.rateLimitIdentity(client, second)
+	if a == b || a == client.identity || b == client.identity {
+		t.Errorf("API keys not told apart: %q, %q", a, b)
+	}
+	if got := s.rateLimitIdentity(client, anonymous); got != client.identity {
+		t.Errorf("rateLimitIdentity() without key = %q, want %q", got, client.identity)
+	}
+
+	s.config.Proxy.RateLimit.Key = "client_ip"
+	if got := s.rateLimitIdentity(client, first); got != client.identity {
+		t.Errorf("rateLimitIdentity() = %q, want %q", got, client.identity)
+	}
+}
+
+func TestWriteRateLimited(t *testing.T) {
+	tests := []struct {
+		url       string
+		errorType string
+	}{
+		{"https://api.openai.com/v1/chat/completions", "requests"},
+		{"https://api.anthropic.com/v1/messages", "rate_limit_error"},
+	}
+	for _, tt := range tests {
+		req := &http.Request{URL: mustParseURL(t, tt.url), Header: http.Header{}}
+		rec := httptest.NewRecorder()
+		writeRateLimited(rec, req, 1500*time.Millisecond, errRateLimited)
+
+		if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") != "2" {
+			t.Errorf("%s: status %d, Retry-After %q", tt.url, rec.Code, rec.Header().Get("Retry-After"))
+		}
+		var body struct {
+			Error struct {
+				Type string `json:"type"`
+			} `json:"error"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil || body.Error.Type != tt.errorType {
+			t.Errorf("%s: body %s, want error type %s", tt.url, rec.Body.String(), tt.errorType)
+		}
+	}
+}
+
+func TestForward_RateLimited(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer upstream.Close()
+	upstreamURL := mustParseURL(t, upstream.URL)
+
+	cfg := config.DefaultConfig()
+	cfg.Proxy.RateLimit.RequestsPerMinute = 1
+	limiter, err := newRateLimiter(cfg.Proxy.RateLimit, false)
+	if err != nil {
+		t.Fatalf("newRateLimiter() error: %v", err)
+	}
+	s := newTestServer(t, cfg)
+	s.limiter = limiter
+	gw := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.handleGateway(w, r, upstreamURL)
+	}))
+	defer gw.Close()
+
+	for i, want := range []int{http.StatusNoContent, http.StatusTooManyRequests} {
+		resp, err := http.Get(gw.URL + "/v1/models")
+		if err != nil {
+			t.Fatalf("request %d: Get() error: %v", i, err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != want {
+			t.Errorf("request %d: status = %d, want %d", i, resp.StatusCode, want)
+		}
+	}
+}
+
+func mustParseURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(raw)
+	if err != nil {
+		t.Fatalf("url.Parse(%q) error: %v", raw, err)
+	}
+	return u
+}
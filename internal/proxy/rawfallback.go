@@ -0,0 +1,233 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/metrics"
+)
+
+// errRawFallback aborts the interception of a tunnel relayed unchanged
+var errRawFallback = errors.New("tunnel relayed without interception")
+
+// Reasons a tunnel is relayed without interception
+const (
+	fallbackALPN       = "alpn"
+	fallbackClientCert = "client_cert"
+	fallbackPinning    = "pinning"
+)
+
+// probeTimeout bounds the handshake probing an upstream for client
+// certificate requests
+const probeTimeout = 5 * time.Second
+
+// recordingConn records the bytes read from a client until the TLS
+// handshake is done, so they can be replayed to the upstream when the tunnel
+// is relayed instead. Once muted it discards writes, keeping alerts of the
+// aborted handshake from the client.
+type recordingConn struct {
+	net.Conn
+	recorded bytes.Buffer
+	done     bool
+	muted    bool
+}
+
+func (c *recordingConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if !c.done {
+		c.recorded.Write(p[:n])
+	}
+	return n, err
+}
+
+func (c *recordingConn) Write(p []byte) (int, error) {
+	if c.muted {
+		return len(p), nil
+	}
+	return c.Conn.Write(p)
+}
+
+// stopRecording ends the recording once the handshake is done; it must be
+// called before the connection is read from other goroutines
+func (c *recordingConn) stopRecording() {
+	c.done = true
+	c.recorded = bytes.Buffer{}
+}
+
+// fallbackHosts remembers per host whether tunnels to it are relayed, and
+// why. A nil *fallbackHosts remembers nothing.
+type fallbackHosts struct {
+	mu    sync.Mutex
+	hosts map[string]fallbackEntry
+}
+
+// fallbackEntry is a remembered decision; an empty reason intercepts
+type fallbackEntry struct {
+	reason  string
+	expires time.Time
+}
+
+// newFallbackHosts returns an empty host memory
+func newFallbackHosts() *fallbackHosts {
+	return &fallbackHosts{hosts: make(map[string]fallbackEntry)}
+}
+
+// lookup returns the decision remembered for host
+func (f *fallbackHosts) lookup(host string, now time.Time) (string, bool) {
+	if f == nil {
+		return "", false
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	entry, ok := f.hosts[host]
+	if !ok || now.After(entry.expires) {
+		delete(f.hosts, host)
+		return "", false
+	}
+	return entry.reason, true
+}
+
+// remember records the decision for host until ttl has passed
+func (f *fallbackHosts) remember(host, reason string, ttl time.Duration, now time.Time) {
+	if f == nil || ttl <= 0 {
+		return
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for h, entry := range f.hosts {
+		if now.After(entry.expires) {
+			delete(f.hosts, h)
+		}
+	}
+	f.hosts[host] = fallbackEntry{reason: reason, expires: now.Add(ttl)}
+}
+
+// rawFallbackReason returns why the tunnel to host opened by hello is
+// relayed without interception, or an empty string to intercept it
+func (s *Server) rawFallbackReason(ctx context.Context, host string, hello *tls.ClientHelloInfo) string {
+	cfg := s.cfg().Proxy.RawFallback
+	if cfg.NonHTTPALPN && !speaksHTTP(hello.SupportedProtos) {
+		return fallbackALPN
+	}
+	if !cfg.ClientCert && !cfg.Pinning {
+		return ""
+	}
+
+	now := time.Now()
+	reason, ok := s.fallbackHosts.lookup(host, now)
+	if (reason == fallbackPinning && cfg.Pinning) || (reason == fallbackClientCert && cfg.ClientCert) {
+		return reason
+	}
+	if ok || !cfg.ClientCert {
+		return ""
+	}
+
+	requested, err := s.probeClientCert(ctx, host, hello)
+	if err != nil {
+		// Unreachable upstreams fail the intercepted request as usual
+		s.logger.Debug().Err(err).Str("host", host).Msg("Failed to probe upstream for client certificate requests")
+		return ""
+	}
+	reason = ""
+	if requested {
+		reason = fallbackClientCert
+	}
+	s.fallbackHosts.remember(host, reason, cfg.Remember, now)
+	return reason
+}
+
+// speaksHTTP reports whether a client offering protos can be intercepted;
+// clients without ALPN speak HTTP/1.1
+func speaksHTTP(protos []string) bool {
+	return len(protos) == 0 || slices.Contains(protos, "http/1.1") || slices.Contains(protos, "h2")
+}
+
+// probeClientCert reports whether the upstream at host requests a client
+// certificate in a handshake like the one the client started
+func (s *Server) probeClientCert(ctx context.Context, host string, hello *tls.ClientHelloInfo) (bool, error) {
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if s.transport != nil && s.transport.TLSClientConfig != nil {
+		tlsConfig = s.transport.TLSClientConfig.Clone()
+	}
+	tlsConfig.ServerName = hello.ServerName
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName, _, _ = net.SplitHostPort(host)
+	}
+	tlsConfig.NextProtos = hello.SupportedProtos
+	var requested bool
+	tlsConfig.GetClientCertificate = func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+		requested = true
+		return &tls.Certificate{}, nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	dialer := tls.Dialer{Config: tlsConfig}
+	conn, err := dialer.DialContext(ctx, "tcp", host)
+	if conn != nil {
+		_ = conn.Close()
+	}
+	if requested {
+		// The upstream may fail the handshake without a certificate
+		return true, nil
+	}
+	return false, err
+}
+
+// rejectedByClient reports whether a failed handshake was aborted by the
+// client because it did not accept the interception certificate
+func rejectedByClient(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "remote error" && strings.Contains(opErr.Err.Error(), "certificate")
+}
+
+// rememberRejection records a host whose client rejected the interception
+// certificate, so its next tunnels are relayed
+func (s *Server) rememberRejection(host string, err error) {
+	cfg := s.cfg().Proxy.RawFallback
+	if !cfg.Pinning || !rejectedByClient(err) {
+		return
+	}
+	s.logger.Warn().Err(err).Str("host", host).Msg("Client rejected the interception certificate, relaying its next tunnels")
+	s.fallbackHosts.remember(host, fallbackPinning, cfg.Remember, time.Now())
+}
+
+// relayRecorded relays a tunnel whose client already sent the start of its
+// TLS handshake, replaying the recorded bytes to the upstream
+func (s *Server) relayRecorded(ctx context.Context, rec *recordingConn, host, reason string) {
+	metrics.RecordRawFallback(reason)
+	s.logger.Info().Str("host", host).Str("reason", reason).Msg("Relaying tunnel without interception")
+
+	dialer := net.Dialer{Timeout: 10 * time.Second}
+	upstreamConn, err := dialer.DialContext(ctx, "tcp", host)
+	if err != nil {
+		s.logger.Error().Err(err).Str("host", host).Msg("Failed to connect tunnel")
+		return
+	}
+	defer func() {
+		if err := upstreamConn.Close(); err != nil {
+			s.logger.Debug().Err(err).Msg("Failed to close tunnel upstream")
+		}
+	}()
+	if _, err := upstreamConn.Write(rec.recorded.Bytes()); err != nil {
+		s.logger.Error().Err(err).Str("host", host).Msg("Failed to replay handshake upstream")
+		return
+	}
+	rec.stopRecording()
+	rec.muted = false
+
+	splice(rec.Conn, rec.Conn, upstreamConn, s.cfg().Proxy.Timeouts.RelayIdle)
+}
+
+// rawFallbackEnabled reports whether any fallback is configured
+func rawFallbackEnabled(cfg config.RawFallbackConfig) bool {
+	return cfg.NonHTTPALPN || cfg.ClientCert || cfg.Pinning
+}
@@ -0,0 +1,235 @@
+package proxy
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+)
+
+func TestSpeaksHTTP(t *testing.T) {
+	for _, tt := range []struct {
+		protos []string
+		want   bool
+	}{
+		{nil, true},
+		{[]string{"h2", "http/1.1"}, true},
+		{[]string{"http/1.1"}, true},
+		{[]string{"imap"}, false},
+		{[]string{"xmpp-client", "acme-tls/1"}, false},
+	} {
+		if got := speaksHTTP(tt.protos); got != tt.want {
+			t.Errorf("speaksHTTP(%v) = %v, want %v", tt.protos, got, tt.want)
+		}
+	}
+}
+
+func TestFallbackHosts(t *testing.T) {
+	now := time.Now()
+	f := newFallbackHosts()
+	f.remember("a.example:443", fallbackPinning, time.Minute, now)
+	f.remember("b.example:443", "", time.Minute, now)
+
+	if reason, ok := f.lookup("a.example:443", now); !ok || reason != fallbackPinning {
+		t.Errorf("lookup(a) = %q, %v; want pinning", reason, ok)
+	}
+	if reason, ok := f.lookup("b.example:443", now); !ok || reason != "" {
+		t.Errorf("lookup(b) = %q, %v; want remembered interception", reason, ok)
+	}
+	if _, ok := f.lookup("a.example:443", now.Add(2*time.Minute)); ok {
+		t.Error("lookup() returned an expired decision")
+	}
+
+	var none *fallbackHosts
+	none.remember("a.example:443", fallbackPinning, time.Minute, now)
+	if _, ok := none.lookup("a.example:443", now); ok {
+		t.Error("nil fallbackHosts remembered a host")
+	}
+}
+
+// getThroughTunnel opens a CONNECT tunnel to target through proxyAddr, starts
+// TLS with tlsConfig and returns the body of a GET request
+func getThroughTunnel(t *testing.T, proxyAddr, target string, tlsConfig *tls.Config) (string, error) {
+	t.Helper()
+	conn, err := net.DialTimeout("tcp", proxyAddr, 5*time.Second)
+	if err != nil {
+		t.Fatalf("Dial() error: %v", err)
+	}
+	defer conn.Close()
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	fmt.Fprintf(conn, "CONNECT %s HTTP/1.1\r\nHost: %s\r\n\r\n", target, target)
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatalf("reading CONNECT response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("CONNECT status = %d, want 200", resp.StatusCode)
+	}
+
+	tlsConn := tls.Client(conn, tlsConfig)
+	if err := tlsConn.Handshake(); err != nil {
+		return "", err
+	}
+	fmt.Fprintf(tlsConn, "GET / HTTP/1.1\r\nHost: %s\r\nConnection: close\r\n\r\n", target)
+	resp, err = http.ReadResponse(bufio.NewReader(tlsConn), nil)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	return string(body), err
+}
+
+func TestHandleConnect_RawFallback(t *testing.T) {
+	dir := t.TempDir()
+	caPath, keyPath := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+	if err := GenerateCA(caPath, keyPath); err != nil {
+		t.Fatalf("GenerateCA() error: %v", err)
+	}
+	cm, err := NewCertManager(caPath, keyPath)
+	if err != nil {
+		t.Fatalf("NewCertManager() error: %v", err)
+	}
+
+	for _, tt := range []struct {
+		name      string
+		configure func(*config.RawFallbackConfig)
+		upstream  *tls.Config
+		client    []string
+		reason    string
+		// rejectFirst makes the first client reject the interception
+		// certificate before the tunnel is relayed
+		rejectFirst bool
+	}{
+		{
+			name:      "non-HTTP ALPN",
+			configure: func(*config.RawFallbackConfig) {},
+			upstream:  &tls.Config{NextProtos: []string{"x-custom"}},
+			client:    []string{"x-custom"},
+			reason:    fallbackALPN,
+		},
+		{
+			name:      "client certificate requested",
+			configure: func(cfg *config.RawFallbackConfig) { cfg.ClientCert = true },
+			upstream:  &tls.Config{ClientAuth: tls.RequestClientCert},
+			client:    []string{"http/1.1"},
+			reason:    fallbackClientCert,
+		},
+		{
+			name:        "pinning",
+			configure:   func(cfg *config.RawFallbackConfig) { cfg.Pinning = true },
+			upstream:    &tls.Config{},
+			client:      []string{"http/1.1"},
+			reason:      fallbackPinning,
+			rejectFirst: true,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			upstream := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+				_, _ = io.WriteString(w, "direct")
+			}))
+			upstream.TLS = tt.upstream
+			upstream.Config.TLSNextProto = map[string]func(*http.Server, *tls.Conn, http.Handler){
+				// A protocol the proxy cannot parse, answering like HTTP/1.1
+				"x-custom": func(_ *http.Server, c *tls.Conn, _ http.Handler) {
+					defer c.Close()
+					if _, err := http.ReadRequest(bufio.NewReader(c)); err == nil {
+						_, _ = io.WriteString(c, "HTTP/1.1 200 OK\r\nContent-Length: 6\r\n\r\ndirect")
+					}
+				},
+			}
+			upstream.Config.ErrorLog = log.New(io.Discard, "", 0)
+			upstream.StartTLS()
+			defer upstream.Close()
+			target := strings.TrimPrefix(upstream.URL, "https://")
+
+			cfg := config.DefaultConfig()
+			tt.configure(&cfg.Proxy.RawFallback)
+			transport := newUpstreamTransport(cfg.Proxy)
+			transport.TLSClientConfig = upstream.Client().Transport.(*http.Transport).TLSClientConfig.Clone()
+			server := &Server{
+				config:        cfg,
+				certManager:   cm,
+				transport:     transport,
+				fallbackHosts: newFallbackHosts(),
+				logger:        zerolog.Nop(),
+			}
+			proxy := httptest.NewUnstartedServer(server)
+			proxy.Config.ErrorLog = log.New(io.Discard, "", 0)
+			proxy.Start()
+			defer proxy.Close()
+			proxyAddr := strings.TrimPrefix(proxy.URL, "http://")
+
+			// Only the real upstream certificate is trusted, so the request
+			// succeeds only through a relayed tunnel
+			roots := x509.NewCertPool()
+			roots.AddCert(upstream.Certificate())
+			clientConfig := &tls.Config{RootCAs: roots, ServerName: "example.com", NextProtos: tt.client, MinVersion: tls.VersionTLS12}
+
+			if tt.rejectFirst {
+				if _, err := getThroughTunnel(t, proxyAddr, target, clientConfig.Clone()); err == nil {
+					t.Fatal("first handshake was not intercepted")
+				}
+			}
+			before := testutil.ToFloat64(metrics.RawFallbacks.WithLabelValues(tt.reason))
+			body, err := getThroughTunnel(t, proxyAddr, target, clientConfig)
+			if err != nil {
+				t.Fatalf("request through relayed tunnel failed: %v", err)
+			}
+			if body != "direct" {
+				t.Errorf("body = %q, want direct", body)
+			}
+			if got := testutil.ToFloat64(metrics.RawFallbacks.WithLabelValues(tt.reason)); got != before+1 {
+				t.Errorf("raw fallbacks(%s) = %v, want %v", tt.reason, got, before+1)
+			}
+		})
+	}
+}
+
+func TestHandleConnect_NoRawFallback(t *testing.T) {
+	upstream := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "direct")
+	}))
+	upstream.TLS = &tls.Config{NextProtos: []string{"x-custom"}}
+	upstream.StartTLS()
+	defer upstream.Close()
+
+	dir := t.TempDir()
+	caPath, keyPath := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+	if err := GenerateCA(caPath, keyPath); err != nil {
+		t.Fatalf("GenerateCA() error: %v", err)
+	}
+	cm, err := NewCertManager(caPath, keyPath)
+	if err != nil {
+		t.Fatalf("NewCertManager() error: %v", err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.Proxy.RawFallback = config.RawFallbackConfig{}
+	proxy := httptest.NewUnstartedServer(&Server{config: cfg, certManager: cm, logger: zerolog.Nop()})
+	proxy.Config.ErrorLog = log.New(io.Discard, "", 0)
+	proxy.Start()
+	defer proxy.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(upstream.Certificate())
+	clientConfig := &tls.Config{RootCAs: roots, ServerName: "example.com", NextProtos: []string{"x-custom"}, MinVersion: tls.VersionTLS12}
+	if _, err := getThroughTunnel(t, strings.TrimPrefix(proxy.URL, "http://"), strings.TrimPrefix(upstream.URL, "https://"), clientConfig); err == nil {
+		t.Error("tunnel was relayed with the fallback disabled")
+	}
+}
@@ -0,0 +1,61 @@
+package proxy
+
+import (
+	"fmt"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/pkg/placeholder"
+)
+
+// defaultRedactionToken masks secrets that cannot get a restorable
+// placeholder when no redaction policy is configured
+const defaultRedactionToken = "[REDACTED]"
+
+// redactionPolicy decides which secrets are replaced irreversibly with a
+// generic token instead of a restorable placeholder. Redacted secrets are
+// never stored, so they cannot be restored or read from the proxy.
+type redactionPolicy struct {
+	all   bool
+	types map[string]bool
+	token string
+}
+
+// newRedactionPolicy creates the redaction policy, returning nil if no
+// secret types are redacted
+func newRedactionPolicy(cfg config.RedactionConfig, generator *placeholder.Generator) (*redactionPolicy, error) {
+	if len(cfg.Types) == 0 {
+		return nil, nil
+	}
+	if cfg.Token == "" {
+		return nil, fmt.Errorf("redaction token must not be empty")
+	}
+	if len(generator.FindAll(cfg.Token)) > 0 {
+		return nil, fmt.Errorf("redaction token %q must not look like a placeholder", cfg.Token)
+	}
+
+	policy := &redactionPolicy{
+		types: make(map[string]bool, len(cfg.Types)),
+		token: cfg.Token,
+	}
+	for _, secretType := range cfg.Types {
+		if secretType == "*" {
+			policy.all = true
+		}
+		policy.types[secretType] = true
+	}
+	return policy, nil
+}
+
+// fallbackToken returns the token masking secrets whose placeholder is
+// already taken by a different secret
+func (p *redactionPolicy) fallbackToken() string {
+	if p == nil {
+		return defaultRedactionToken
+	}
+	return p.token
+}
+
+// redacts reports whether secrets of the given type are redacted
+func (p *redactionPolicy) redacts(secretType string) bool {
+	return p != nil && (p.all || p.types[secretType])
+}
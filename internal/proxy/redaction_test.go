@@ -0,0 +1,72 @@
+package proxy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/protocol"
+	"github.com/hfi/llm-secret-interceptor/pkg/placeholder"
+)
+
+func TestNewRedactionPolicy(t *testing.T) {
+	generator := placeholder.NewGenerator("__SECRET_", "__")
+
+	policy, err := newRedactionPolicy(config.RedactionConfig{Token: "[REDACTED]"}, generator)
+	if err != nil || policy != nil {
+		t.Fatalf("newRedactionPolicy() without types = %v, %v, want nil", policy, err)
+	}
+	if policy.redacts("password") {
+		t.Error("nil policy should not redact")
+	}
+
+	policy, err = newRedactionPolicy(config.RedactionConfig{Types: []string{"private_key"}, Token: "[REDACTED]"}, generator)
+	if err != nil {
+		t.Fatalf("newRedactionPolicy() error: %v", err)
+	}
+	if !policy.redacts("private_key") || policy.redacts("password") {
+		t.Error("policy should redact exactly the configured types")
+	}
+
+	policy, err = newRedactionPolicy(config.RedactionConfig{Types: []string{"*"}, Token: "[REDACTED]"}, generator)
+	if err != nil || !policy.redacts("anything") {
+		t.Errorf("wildcard policy should redact all types (err %v)", err)
+	}
+
+	if _, err := newRedactionPolicy(config.RedactionConfig{Types: []string{"*"}}, generator); err == nil {
+		t.Error("newRedactionPolicy() expected error for empty token")
+	}
+	if _, err := newRedactionPolicy(config.RedactionConfig{Types: []string{"*"}, Token: "__SECRET_abcdef12__"}, generator); err == nil {
+		t.Error("newRedactionPolicy() expected error for a token that looks like a placeholder")
+	}
+}
+
+func TestSecretService_ProcessRequest_Redaction(t *testing.T) {
+	service := setupTestService()
+	defer service.GetStore().Close()
+
+	if err := service.SetRedaction(config.RedactionConfig{Types: []string{"*"}, Token: "[REDACTED]"}); err != nil {
+		t.Fatalf("SetRedaction() error: %v", err)
+	}
+
+	body := []byte(`{
+		"model": "gpt-4",
+		"messages": [
+			{"role": "user", "content": "My API key is aB3cD4eF5gH6iJ7kL8mN9oP0qR please help"}
+		]
+	}`)
+
+	result := service.ProcessRequest(context.Background(), body, protocol.NewOpenAIHandler())
+	if result.Error != nil {
+		t.Fatalf("ProcessRequest error: %v", result.Error)
+	}
+	if containsBytes(result.ModifiedBody, []byte("aB3cD4eF5gH6iJ7kL8mN9oP0qR")) {
+		t.Error("Original secret still in modified body")
+	}
+	if !containsBytes(result.ModifiedBody, []byte("[REDACTED]")) {
+		t.Errorf("Redaction token not found in modified body: %s", result.ModifiedBody)
+	}
+	if size := service.GetStore().Size(); size != 0 {
+		t.Errorf("Store size = %d, redacted secrets must not be stored", size)
+	}
+}
@@ -0,0 +1,93 @@
+package proxy
+
+import (
+	"reflect"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/interceptor"
+)
+
+// cfg returns the configuration currently in effect
+func (s *Server) cfg() *config.Config {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.config
+}
+
+// detector returns the interceptor manager currently in effect
+func (s *Server) detector() *interceptor.Manager {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.interceptors
+}
+
+// Reload applies cfg to the running server. Interceptors, host lists,
+// client ACLs and per-request settings take effect for new requests while
+// open tunnels keep running; settings bound at startup keep their current
+// values, and the names of those that differ are returned. Nothing is
+// changed when cfg is invalid.
+func (s *Server) Reload(cfg *config.Config) ([]string, error) {
+	if err := validateFailureMode(cfg.Proxy.FailureMode); err != nil {
+		return nil, err
+	}
+	if err := validateInterceptMode(cfg.Proxy); err != nil {
+		return nil, err
+	}
+	if err := validateHostPatterns("websocket.inspect_hosts", cfg.Proxy.WebSocket.InspectHosts); err != nil {
+		return nil, err
+	}
+	if err := validateBlockPolicy(cfg.Policy.Block); err != nil {
+		return nil, err
+	}
+	if err := validateMode(cfg.Mode); err != nil {
+		return nil, err
+	}
+	if err := validateAdminAccess(cfg.Metrics.Admin); err != nil {
+		return nil, err
+	}
+	interceptors, err := NewInterceptorManager(cfg)
+	if err != nil {
+		return nil, err
+	}
+	acl, err := newClientACL(cfg.Proxy.ACL)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	next := *cfg
+	ignored := keepStartupSettings(&next, s.config)
+	s.config = &next
+	s.interceptors = interceptors
+	s.acl = acl
+	return ignored, nil
+}
+
+// keepStartupSettings copies the settings that only apply at startup from
+// current to next, returning the names of those that differed
+func keepStartupSettings(next, current *config.Config) []string {
+	var ignored []string
+	keep := func(name string, dst, src any) {
+		d, v := reflect.ValueOf(dst).Elem(), reflect.ValueOf(src).Elem()
+		if !reflect.DeepEqual(d.Interface(), v.Interface()) {
+			ignored = append(ignored, name)
+			d.Set(v)
+		}
+	}
+	keep("proxy.listen", &next.Proxy.Listen, &current.Proxy.Listen)
+	keep("proxy.listeners", &next.Proxy.Listeners, &current.Proxy.Listeners)
+	keep("proxy.http2", &next.Proxy.HTTP2, &current.Proxy.HTTP2)
+	keep("proxy.rate_limit", &next.Proxy.RateLimit, &current.Proxy.RateLimit)
+	keep("proxy.concurrency", &next.Proxy.Concurrency, &current.Proxy.Concurrency)
+	keep("proxy.tls_cert", &next.Proxy.TLSCert, &current.Proxy.TLSCert)
+	keep("proxy.tls_key", &next.Proxy.TLSKey, &current.Proxy.TLSKey)
+	keep("proxy.mtls", &next.Proxy.MTLS, &current.Proxy.MTLS)
+	keep("proxy.gateways", &next.Proxy.Gateways, &current.Proxy.Gateways)
+	keep("tls", &next.TLS, &current.TLS)
+	keep("storage", &next.Storage, &current.Storage)
+	keep("placeholder", &next.Placeholder, &current.Placeholder)
+	keep("metrics", &next.Metrics, &current.Metrics)
+	keep("logging.audit", &next.Logging.Audit, &current.Logging.Audit)
+	return ignored
+}
@@ -0,0 +1,64 @@
+package proxy
+
+import (
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+)
+
+func TestReload(t *testing.T) {
+	cfg := config.DefaultConfig()
+	interceptors, err := NewInterceptorManager(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{config: cfg, interceptors: interceptors}
+
+	next := config.DefaultConfig()
+	next.Proxy.BypassHosts = []string{"*.bank.example"}
+	next.Proxy.ACL.Deny = []string{"192.0.2.1"}
+	next.Interceptors.Pattern.Enabled = true
+	next.Proxy.Listen = ":9999"
+	next.Storage.Type = "redis"
+
+	ignored, err := s.Reload(next)
+	if err != nil {
+		t.Fatalf("Reload() error: %v", err)
+	}
+	if !slices.Equal(ignored, []string{"proxy.listen", "storage"}) {
+		t.Errorf("Reload() ignored = %v, want [proxy.listen storage]", ignored)
+	}
+	if s.intercepts("10.0.0.1", "www.bank.example:443") {
+		t.Error("reloaded bypass host is still intercepted")
+	}
+	if names := s.detector().List(); !slices.Contains(names, "pattern") {
+		t.Errorf("interceptors = %v, want pattern enabled", names)
+	}
+	if s.cfg().Proxy.Listen != cfg.Proxy.Listen || s.cfg().Storage.Type != cfg.Storage.Type {
+		t.Error("startup settings were replaced")
+	}
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("CONNECT", "api.openai.com:443", nil)
+	req.RemoteAddr = "192.0.2.1:5000"
+	if s.admitClient(rec, req) {
+		t.Error("reloaded deny list was not applied")
+	}
+}
+
+func TestReload_Invalid(t *testing.T) {
+	cfg := config.DefaultConfig()
+	s := &Server{config: cfg}
+
+	next := config.DefaultConfig()
+	next.Proxy.BypassHosts = []string{"*.bank.example"}
+	next.Proxy.FailureMode = "sometimes"
+	if _, err := s.Reload(next); err == nil {
+		t.Fatal("Reload() expected error for invalid failure mode")
+	}
+	if s.cfg() != cfg {
+		t.Error("invalid configuration was applied")
+	}
+}
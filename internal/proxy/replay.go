@@ -0,0 +1,275 @@
+package proxy
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/protocol"
+	"github.com/hfi/llm-secret-interceptor/internal/storage"
+)
+
+// replayDefaultPath is the API path of records that name none
+const replayDefaultPath = "/v1/chat/completions"
+
+// ReplayRecord is a recorded exchange with an LLM API
+type ReplayRecord struct {
+	// ID optionally identifies the record in reports
+	ID string `json:"id,omitempty"`
+	// Host is the API host; records of hosts the configuration does not
+	// intercept are skipped
+	Host string `json:"host,omitempty"`
+	// Path selects the protocol, by default the chat completions API
+	Path     string          `json:"path,omitempty"`
+	Request  json.RawMessage `json:"request"`
+	Response json.RawMessage `json:"response,omitempty"`
+}
+
+// ReadReplayRecords parses JSON Lines of ReplayRecords. Empty lines and
+// lines starting with '#' are ignored.
+func ReadReplayRecords(r io.Reader) ([]ReplayRecord, error) {
+	var records []ReplayRecord
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 32*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		var record ReplayRecord
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if len(record.Request) == 0 {
+			return nil, fmt.Errorf("line %d: request is required", lineNo)
+		}
+		if record.ID == "" {
+			record.ID = fmt.Sprintf("line-%d", lineNo)
+		}
+		records = append(records, record)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read records: %w", err)
+	}
+	return records, nil
+}
+
+// ReplayDetection is a secret detected in a replayed request
+type ReplayDetection struct {
+	Interceptor string  `json:"interceptor"`
+	Rule        string  `json:"rule,omitempty"`
+	Type        string  `json:"type"`
+	Confidence  float64 `json:"confidence"`
+	// Excerpt is the secret with its middle masked
+	Excerpt string `json:"excerpt"`
+}
+
+// ruleKey returns the rule of the detection, or the interceptor for those
+// without rules
+func (d ReplayDetection) ruleKey() string {
+	if d.Rule != "" {
+		return d.Rule
+	}
+	return d.Interceptor
+}
+
+// ReplayResult is what the proxy would do with a record
+type ReplayResult struct {
+	ID       string `json:"id"`
+	Protocol string `json:"protocol,omitempty"`
+	// Skipped explains why the record was not processed
+	Skipped    string            `json:"skipped,omitempty"`
+	Error      string            `json:"error,omitempty"`
+	Detections []ReplayDetection `json:"detections,omitempty"`
+	Replaced   int               `json:"replaced"`
+	Redacted   int               `json:"redacted"`
+	// Blocked is set when the block policy would refuse the request
+	Blocked bool `json:"blocked"`
+	// Restored and NotFound count the placeholders in the response
+	Restored int `json:"restored"`
+	NotFound int `json:"not_found"`
+}
+
+// ReplayReport summarizes a replay
+type ReplayReport struct {
+	Records    int `json:"records"`
+	Skipped    int `json:"skipped"`
+	Errors     int `json:"errors"`
+	Detections int `json:"detections"`
+	Replaced   int `json:"replaced"`
+	Redacted   int `json:"redacted"`
+	Blocked    int `json:"blocked"`
+	// ByRule counts detections by rule, or by interceptor for those
+	// without rules
+	ByRule  map[string]int `json:"by_rule"`
+	Results []ReplayResult `json:"results"`
+}
+
+// Replay feeds records through the secret service configured by cfg and
+// reports what the proxy would detect, replace, redact and block. Mappings
+// only live in memory for the replay, so responses restore the placeholders
+// of requests replayed before them.
+func Replay(ctx context.Context, cfg *config.Config, records []ReplayRecord) (*ReplayReport, error) {
+	if err := validateBlockPolicy(cfg.Policy.Block); err != nil {
+		return nil, err
+	}
+	manager, err := NewInterceptorManager(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize interceptors: %w", err)
+	}
+	generator, err := newPlaceholderGenerator(cfg.Placeholder)
+	if err != nil {
+		return nil, err
+	}
+	store := storage.NewMemoryStore(time.Hour)
+	defer func() { _ = store.Close() }()
+
+	registry := protocol.NewRegistry()
+	registry.Register(protocol.NewOpenAIHandler())
+	service := NewSecretService(manager, store, generator, registry)
+	if err := service.SetRedaction(cfg.Placeholder.Redaction); err != nil {
+		return nil, err
+	}
+
+	report := &ReplayReport{Records: len(records), ByRule: make(map[string]int), Results: make([]ReplayResult, 0, len(records))}
+	for _, record := range records {
+		result := replayRecord(ctx, cfg, service, registry, record)
+		switch {
+		case result.Skipped != "":
+			report.Skipped++
+		case result.Error != "":
+			report.Errors++
+		}
+		report.Detections += len(result.Detections)
+		report.Replaced += result.Replaced
+		report.Redacted += result.Redacted
+		if result.Blocked {
+			report.Blocked++
+		}
+		for _, detection := range result.Detections {
+			report.ByRule[detection.ruleKey()]++
+		}
+		report.Results = append(report.Results, result)
+	}
+	return report, nil
+}
+
+// replayRecord replays a single record
+func replayRecord(ctx context.Context, cfg *config.Config, service *SecretService, registry *protocol.Registry, record ReplayRecord) ReplayResult {
+	result := ReplayResult{ID: record.ID}
+	if record.Host != "" && !interceptsHost(cfg.Proxy, record.Host) {
+		result.Skipped = "host not intercepted"
+		return result
+	}
+	path := record.Path
+	if path == "" {
+		path = replayDefaultPath
+	}
+	handler := registry.Detect(&http.Request{
+		Method: http.MethodPost,
+		URL:    &url.URL{Scheme: "https", Host: record.Host, Path: path},
+		Header: http.Header{"Content-Type": {"application/json"}},
+	})
+	if handler == nil {
+		result.Skipped = "unsupported API path"
+		return result
+	}
+	result.Protocol = handler.Name()
+
+	processed := service.ProcessRequest(ctx, record.Request, handler)
+	for _, secret := range processed.Detected {
+		result.Detections = append(result.Detections, ReplayDetection{
+			Interceptor: secret.Source,
+			Rule:        secret.Rule,
+			Type:        secret.Type,
+			Confidence:  secret.Confidence,
+			Excerpt:     maskSecret(secret.Value),
+		})
+	}
+	result.Replaced = processed.SecretsReplaced
+	result.Redacted = processed.SecretsRedacted
+	_, result.Blocked = blockingSecret(cfg.Policy.Block, processed.Detected)
+	if processed.Error != nil {
+		result.Error = processed.Error.Error()
+		return result
+	}
+
+	if len(record.Response) > 0 {
+		restored := service.ProcessResponse(ctx, record.Response, handler)
+		result.Restored = restored.PlaceholdersRestored
+		result.NotFound = restored.PlaceholdersNotFound
+		if restored.Error != nil {
+			result.Error = fmt.Sprintf("response: %v", restored.Error)
+		}
+	}
+	return result
+}
+
+// maskSecret hides the middle of a secret so reports never contain it
+func maskSecret(value string) string {
+	if len(value) <= 8 {
+		return strings.Repeat("*", len(value))
+	}
+	return value[:4] + strings.Repeat("*", len(value)-8) + value[len(value)-4:]
+}
+
+// WriteText writes a human-readable report, listing the records with
+// detections or errors, or all records and every detection if verbose
+func (r *ReplayReport) WriteText(w io.Writer, verbose bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "RECORD\tDETECTIONS\tREPLACED\tREDACTED\tBLOCKED\tRESTORED\tNOTE\n")
+	for _, result := range r.Results {
+		note := result.Skipped
+		if result.Error != "" {
+			note = "error: " + result.Error
+		}
+		if !verbose && len(result.Detections) == 0 && note == "" {
+			continue
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%t\t%d\t%s\n", result.ID, len(result.Detections),
+			result.Replaced, result.Redacted, result.Blocked, result.Restored, note)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	rules := make([]string, 0, len(r.ByRule))
+	for rule := range r.ByRule {
+		rules = append(rules, rule)
+	}
+	sort.Strings(rules)
+	fmt.Fprintf(w, "\nRecords: %d, skipped: %d, errors: %d\n", r.Records, r.Skipped, r.Errors)
+	fmt.Fprintf(w, "Detections: %d, replaced: %d, redacted: %d, blocked requests: %d\n",
+		r.Detections, r.Replaced, r.Redacted, r.Blocked)
+	for _, rule := range rules {
+		fmt.Fprintf(w, "  %s: %d\n", rule, r.ByRule[rule])
+	}
+
+	if verbose {
+		for _, result := range r.Results {
+			for _, detection := range result.Detections {
+				fmt.Fprintf(w, "  %s [%s] %s %.2f %s\n", result.ID, detection.ruleKey(),
+					detection.Type, detection.Confidence, detection.Excerpt)
+			}
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *ReplayReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
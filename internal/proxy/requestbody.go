@@ -0,0 +1,182 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/hfi/llm-secret-interceptor/internal/metrics"
+	"github.com/hfi/llm-secret-interceptor/internal/protocol"
+	"github.com/hfi/llm-secret-interceptor/internal/storage"
+)
+
+// errRequestTooLarge marks request bodies above proxy.max_request_body
+var errRequestTooLarge = errors.New("request body too large")
+
+// readLimited reads r completely, failing with errRequestTooLarge once it
+// yields more than limit bytes; a limit of 0 means no limit
+func readLimited(r io.Reader, limit int64) ([]byte, error) {
+	if limit <= 0 {
+		return io.ReadAll(r)
+	}
+	data, err := io.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: limit is %d bytes", errRequestTooLarge, limit)
+	}
+	return data, nil
+}
+
+// upstreamHeader returns the headers of a request forwarded upstream.
+// Responses must come back in an encoding placeholders can be restored from.
+// Expect is dropped: the client got its 100 Continue from the proxy, and
+// the body is ready to send.
+func upstreamHeader(h http.Header) http.Header {
+	header := h.Clone()
+	header.Del("Expect")
+	if accept := header.Get("Accept-Encoding"); accept != "" {
+		if accept = filterAcceptEncoding(accept); accept != "" {
+			header.Set("Accept-Encoding", accept)
+		} else {
+			header.Del("Accept-Encoding")
+		}
+	}
+	return header
+}
+
+// streamRequest forwards req while its messages are scanned one at a time,
+// without buffering the whole body. The body is already being sent when
+// parsing or detection fails, so these failures abort the request in either
+// failure mode.
+func (s *Server) streamRequest(req *http.Request, store storage.MappingStore, streamer protocol.MessageStreamer) (*http.Response, error) {
+	ctx := req.Context()
+	encoding := contentEncoding(req.Header)
+	decoded, err := newDecodingReader(encoding, req.Body)
+	if err != nil {
+		metrics.RecordFailureDecision(stageDecode, "closed")
+		if closeErr := req.Body.Close(); closeErr != nil {
+			s.logger.Debug().Err(closeErr).Msg("Failed to close request body")
+		}
+		if errors.Is(err, errUnsupportedEncoding) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("%w: %v", errUnsupportedEncoding, err)
+	}
+
+	// Limit the total detection time for this request
+	detectCtx, cancel := ctx, context.CancelFunc(func() {})
+	budget := s.cfg().Interceptors.DetectionBudget
+	if budget > 0 {
+		detectCtx, cancel = context.WithTimeout(ctx, budget)
+	}
+
+	mask := func(m protocol.Message) (string, error) {
+		if detectCtx.Err() == context.DeadlineExceeded {
+			metrics.RecordFailureDecision(stageDetection, "closed")
+			return "", fmt.Errorf("%w: detection budget of %s exceeded", errDetectionIncomplete, budget)
+		}
+		content, found, err := s.maskSecrets(ctx, detectCtx, store, m.Content, req.URL.Host)
+		if err != nil {
+			return "", err
+		}
+		if found > 0 {
+			s.logger.Info().
+				Int("secrets_found", found).
+				Str("role", m.Role).
+				Msg("Detected secrets in message")
+		}
+		return content, nil
+	}
+
+	pr, pw := io.Pipe()
+	streamErr := make(chan error, 1)
+	go func() {
+		defer cancel()
+		defer func() {
+			if err := decoded.Close(); err != nil {
+				s.logger.Debug().Err(err).Msg("Failed to close decoded request body")
+			}
+			if err := req.Body.Close(); err != nil {
+				s.logger.Debug().Err(err).Msg("Failed to close request body")
+			}
+		}()
+
+		err := s.streamMessages(decoded, pw, encoding, streamer, mask)
+		if err != nil {
+			s.logger.Error().Err(err).Msg("Failed to stream request, aborting")
+		}
+		streamErr <- err
+		pw.CloseWithError(err)
+	}()
+
+	newReq, err := http.NewRequestWithContext(ctx, req.Method, req.URL.String(), pr)
+	if err != nil {
+		pr.CloseWithError(err)
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	newReq.Header = upstreamHeader(req.Header)
+	newReq.Header.Del("Content-Length")
+	newReq.ContentLength = -1
+	forwardTrailer(newReq, req)
+
+	resp, err := s.upstream().RoundTrip(newReq)
+	if err != nil {
+		// Report why the body was cut short rather than the transport error
+		select {
+		case streamErr := <-streamErr:
+			if streamErr != nil {
+				return nil, streamErr
+			}
+		default:
+		}
+		pr.CloseWithError(err)
+		return nil, err
+	}
+	return resp, nil
+}
+
+// streamMessages writes the body read from r to w in encoding, passing each
+// message through mask
+func (s *Server) streamMessages(r io.Reader, w io.Writer, encoding string, streamer protocol.MessageStreamer, mask func(protocol.Message) (string, error)) error {
+	out := &errWriter{w: w}
+	encoded, err := newEncodingWriter(encoding, out)
+	if err != nil {
+		return err
+	}
+
+	var maskErr error
+	err = streamer.StreamRequest(r, encoded, func(m protocol.Message) (string, error) {
+		content, err := mask(m)
+		maskErr = err
+		return content, err
+	})
+	if err != nil {
+		if maskErr != nil {
+			return maskErr
+		}
+		if out.err != nil {
+			return out.err
+		}
+		metrics.RecordFailureDecision(stageParse, "closed")
+		return fmt.Errorf("%w: %v", errUnparsableRequest, err)
+	}
+	return encoded.Close()
+}
+
+// errWriter remembers the first error returned by w
+type errWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (e *errWriter) Write(p []byte) (int, error) {
+	n, err := e.w.Write(p)
+	if err != nil && e.err == nil {
+		e.err = err
+	}
+	return n, err
+}
@@ -0,0 +1,41 @@
+package proxy
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+)
+
+// requestIDHeader carries a client-supplied request ID
+const requestIDHeader = "X-Request-Id"
+
+// maxRequestIDLength bounds client-supplied request IDs
+const maxRequestIDLength = 128
+
+type requestIDKey struct{}
+
+// withRequestID returns a copy of req carrying its request ID in the
+// context. The client's X-Request-Id is reused if present, so detections can
+// be correlated with client logs.
+func withRequestID(req *http.Request) *http.Request {
+	id := req.Header.Get(requestIDHeader)
+	if id == "" || len(id) > maxRequestIDLength {
+		id = newRequestID()
+	}
+	return req.WithContext(context.WithValue(req.Context(), requestIDKey{}, id))
+}
+
+// requestIDFromContext returns the request ID stored in ctx, if any
+func requestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// newRequestID generates a random request ID
+func newRequestID() string {
+	id := make([]byte, 8)
+	// crypto/rand never fails on supported platforms; a zero ID is harmless
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
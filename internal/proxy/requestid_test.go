@@ -0,0 +1,26 @@
+package proxy
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestWithRequestID(t *testing.T) {
+	req, _ := http.NewRequest(http.MethodPost, "https://api.openai.com/v1/chat/completions", nil)
+	first := requestIDFromContext(withRequestID(req).Context())
+	second := requestIDFromContext(withRequestID(req).Context())
+	if first == "" || first == second {
+		t.Errorf("generated request IDs = %q, %q, want unique non-empty IDs", first, second)
+	}
+
+	req.Header.Set(requestIDHeader, "client-req-1")
+	if got := requestIDFromContext(withRequestID(req).Context()); got != "client-req-1" {
+		t.Errorf("request ID = %q, want client-supplied ID", got)
+	}
+
+	req.Header.Set(requestIDHeader, strings.Repeat("x", maxRequestIDLength+1))
+	if got := requestIDFromContext(withRequestID(req).Context()); len(got) > maxRequestIDLength {
+		t.Errorf("oversized client request ID was accepted")
+	}
+}
@@ -0,0 +1,132 @@
+package proxy
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/metrics"
+)
+
+// retryTransport retries idempotent requests after transient upstream
+// failures
+type retryTransport struct {
+	base   http.RoundTripper
+	policy config.RetryConfig
+}
+
+// RoundTrip sends req, retrying it with jittered backoff while the failure
+// is transient and attempts remain
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := t.base.RoundTrip(req)
+		if attempt >= t.policy.MaxAttempts || !replayable(req) {
+			return resp, err
+		}
+		cause := retryCause(resp, err)
+		if cause == "" {
+			return resp, err
+		}
+
+		delay := backoff(t.policy, attempt)
+		if resp != nil {
+			if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+				if after > t.policy.MaxBackoff {
+					// The upstream asks for more patience than we have
+					return resp, err
+				}
+				delay = after
+			}
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+			_ = resp.Body.Close()
+		}
+
+		if req.GetBody != nil {
+			body, bodyErr := req.GetBody()
+			if bodyErr != nil {
+				return nil, bodyErr
+			}
+			req.Body = body
+		}
+
+		metrics.RecordUpstreamRetry(cause)
+		if err := sleepContext(req.Context(), delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// replayable reports whether req is idempotent and its body can be sent again
+func replayable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+	default:
+		if req.Header.Get("Idempotency-Key") == "" && req.Header.Get("X-Idempotency-Key") == "" {
+			return false
+		}
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// retryCause classifies a transient failure for the retry metric; it returns
+// an empty string for failures that are not retried
+func retryCause(resp *http.Response, err error) string {
+	switch {
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return "connection_reset"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "connection_refused"
+	case err != nil:
+		return ""
+	case resp.StatusCode == http.StatusBadGateway:
+		return "status_502"
+	case resp.StatusCode == http.StatusServiceUnavailable:
+		return "status_503"
+	}
+	return ""
+}
+
+// backoff returns the full-jitter exponential delay before the retry
+// following attempt
+func backoff(policy config.RetryConfig, attempt int) time.Duration {
+	limit := policy.InitialBackoff << (attempt - 1)
+	if limit <= 0 || limit > policy.MaxBackoff {
+		limit = policy.MaxBackoff
+	}
+	if limit <= 0 {
+		return 0
+	}
+	return rand.N(limit) //#nosec G404 -- jitter does not need a secure source
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return max(time.Until(at), 0), true
+	}
+	return 0, false
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
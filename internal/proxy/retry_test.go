@@ -0,0 +1,124 @@
+package proxy
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+)
+
+func TestRetryTransport(t *testing.T) {
+	policy := config.RetryConfig{MaxAttempts: 3, InitialBackoff: time.Millisecond, MaxBackoff: 10 * time.Millisecond}
+
+	tests := []struct {
+		name       string
+		method     string
+		header     http.Header
+		retryAfter string
+		want       int
+	}{
+		{"idempotent method", http.MethodPut, nil, "", 3},
+		{"post", http.MethodPost, nil, "", 1},
+		{"post with idempotency key", http.MethodPost, http.Header{"Idempotency-Key": {"abc"}}, "", 3},
+		{"short retry-after", http.MethodGet, nil, "0", 3},
+		{"long retry-after", http.MethodGet, nil, "60", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attempts := 0
+			var bodies []string
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts++
+				body, _ := io.ReadAll(r.Body)
+				bodies = append(bodies, string(body))
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer upstream.Close()
+
+			req, _ := http.NewRequest(tt.method, upstream.URL, strings.NewReader("payload"))
+			for key, values := range tt.header {
+				req.Header[key] = values
+			}
+			resp, err := (&retryTransport{base: http.DefaultTransport, policy: policy}).RoundTrip(req)
+			if err != nil {
+				t.Fatalf("RoundTrip() error: %v", err)
+			}
+			_ = resp.Body.Close()
+
+			if attempts != tt.want {
+				t.Errorf("attempts = %d, want %d", attempts, tt.want)
+			}
+			if resp.StatusCode != http.StatusServiceUnavailable {
+				t.Errorf("status = %d, want the last upstream response", resp.StatusCode)
+			}
+			for _, body := range bodies {
+				if body != "payload" {
+					t.Errorf("attempt body = %q, want payload", body)
+				}
+			}
+		})
+	}
+}
+
+func TestRetryTransport_RecoversAfterFailure(t *testing.T) {
+	attempts := 0
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			// Drop the connection without a response
+			conn, _, _ := http.NewResponseController(w).Hijack()
+			_ = conn.Close()
+			return
+		}
+		_, _ = io.WriteString(w, "ok")
+	}))
+	defer upstream.Close()
+
+	transport := &retryTransport{
+		base:   &http.Transport{DisableKeepAlives: true},
+		policy: config.RetryConfig{MaxAttempts: 2, MaxBackoff: time.Millisecond},
+	}
+	req, _ := http.NewRequest(http.MethodGet, upstream.URL, nil)
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("RoundTrip() error: %v", err)
+	}
+	defer resp.Body.Close()
+	if body, _ := io.ReadAll(resp.Body); string(body) != "ok" || attempts != 2 {
+		t.Errorf("body = %q after %d attempts, want ok after 2", body, attempts)
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	if d, ok := retryAfter("3"); !ok || d != 3*time.Second {
+		t.Errorf("retryAfter(3) = %v, %v", d, ok)
+	}
+	date := time.Now().Add(time.Hour).UTC().Format(http.TimeFormat)
+	if d, ok := retryAfter(date); !ok || d < 59*time.Minute {
+		t.Errorf("retryAfter(%q) = %v, %v", date, d, ok)
+	}
+	for _, value := range []string{"", "soon", "-1"} {
+		if _, ok := retryAfter(value); ok {
+			t.Errorf("retryAfter(%q) should not parse", value)
+		}
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := config.RetryConfig{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 300 * time.Millisecond}
+	for attempt := 1; attempt <= 40; attempt++ {
+		if d := backoff(policy, attempt); d < 0 || d >= policy.MaxBackoff {
+			t.Errorf("backoff(%d) = %v, want below %v", attempt, d, policy.MaxBackoff)
+		}
+	}
+	if d := backoff(config.RetryConfig{}, 1); d != 0 {
+		t.Errorf("backoff() without delays = %v, want 0", d)
+	}
+}
@@ -0,0 +1,323 @@
+package proxy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/interceptor"
+	"gopkg.in/yaml.v3"
+)
+
+// rulesAdminPath is the path of the pattern rules admin API
+const rulesAdminPath = "/admin/rules"
+
+var (
+	// errUnknownRule marks pattern rule names that are not defined
+	errUnknownRule = errors.New("unknown pattern rule")
+	// errRuleExists marks added rules whose name is taken
+	errRuleExists = errors.New("pattern rule already exists")
+	// errNoRuntimeRulesFile rejects added rules that could not be saved
+	errNoRuntimeRulesFile = errors.New("adding rules requires interceptors.pattern.runtime_rules_file")
+)
+
+// RuleInfo describes a pattern rule. Source is "builtin", "config",
+// "runtime" or the rule file defining it.
+type RuleInfo struct {
+	Name        string  `json:"name"`
+	Pattern     string  `json:"pattern"`
+	Type        string  `json:"type"`
+	Confidence  float64 `json:"confidence"`
+	Description string  `json:"description,omitempty"`
+	Source      string  `json:"source"`
+	Disabled    bool    `json:"disabled"`
+}
+
+// loadRuntimeRules reads the runtime rules file; a missing file holds no rules
+func loadRuntimeRules(path string) ([]config.PatternRuleConfig, error) {
+	if path == "" {
+		return nil, nil
+	}
+	rules, err := LoadRuleFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return rules, err
+}
+
+// saveRuntimeRules replaces the runtime rules file with rules
+func saveRuntimeRules(path string, rules []config.PatternRuleConfig) error {
+	return saveYAML(path, rules, "runtime rules")
+}
+
+// saveYAML atomically replaces the file at path with v encoded as YAML;
+// errors name the file's content as what
+func saveYAML(path string, v any, what string) error {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", what, err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write %s: %w", what, err)
+	}
+	defer func() {
+		// No-op after the rename
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write %s: %w", what, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", what, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write %s: %w", what, err)
+	}
+	return nil
+}
+
+// PatternRules lists the built-in and configured pattern rules, including
+// disabled ones
+func (s *Server) PatternRules() ([]RuleInfo, error) {
+	cfg := s.cfg().Interceptors.Pattern
+	var rules []RuleInfo
+	for _, rule := range interceptor.NewPatternInterceptor().Rules() {
+		rules = append(rules, RuleInfo{
+			Name:        rule.Name,
+			Pattern:     rule.Pattern.String(),
+			Type:        rule.Type,
+			Confidence:  rule.Confidence,
+			Description: rule.Description,
+			Source:      "builtin",
+		})
+	}
+	configured, err := configuredPatternRules(cfg)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range configured {
+		rules = append(rules, RuleInfo{
+			Name:       r.rule.Name,
+			Pattern:    r.rule.Pattern,
+			Type:       r.rule.Type,
+			Confidence: r.rule.Confidence,
+			Source:     r.source,
+		})
+	}
+	for i := range rules {
+		rules[i].Disabled = slices.Contains(cfg.DisabledRules, rules[i].Name)
+	}
+	return rules, nil
+}
+
+// AddPatternRule validates rule, saves it to the runtime rules file and
+// applies it to new requests
+func (s *Server) AddPatternRule(rule config.PatternRuleConfig) error {
+	if rule.Name == "" || rule.Type == "" {
+		return errors.New("rule name and type are required")
+	}
+	if rule.Confidence <= 0 || rule.Confidence > 1 {
+		return errors.New("rule confidence must be in (0, 1]")
+	}
+	if rule.Pattern == "" {
+		return errors.New("rule pattern is required")
+	}
+	if _, err := regexp.Compile(rule.Pattern); err != nil {
+		return fmt.Errorf("invalid rule pattern: %w", err)
+	}
+
+	s.tuneMu.Lock()
+	defer s.tuneMu.Unlock()
+
+	next := *s.cfg()
+	path := next.Interceptors.Pattern.RuntimeRulesFile
+	if path == "" {
+		return errNoRuntimeRulesFile
+	}
+	existing, err := s.PatternRules()
+	if err != nil {
+		return err
+	}
+	for _, r := range existing {
+		if r.Name == rule.Name {
+			return fmt.Errorf("%w: %q", errRuleExists, rule.Name)
+		}
+	}
+
+	previous, err := loadRuntimeRules(path)
+	if err != nil {
+		return err
+	}
+	if err := saveRuntimeRules(path, append(previous[:len(previous):len(previous)], rule)); err != nil {
+		return err
+	}
+	if _, err := s.Reload(&next); err != nil {
+		if restoreErr := saveRuntimeRules(path, previous); restoreErr != nil {
+			s.logger.Error().Err(restoreErr).Msg("Failed to restore runtime rules")
+		}
+		return err
+	}
+	s.logger.Info().Str("rule", rule.Name).Str("type", rule.Type).Msg("Pattern rule added through the admin API")
+	return nil
+}
+
+// SetPatternRuleDisabled disables or enables the named pattern rule for new
+// requests. The change lasts until the configuration is reloaded or the
+// proxy restarts.
+func (s *Server) SetPatternRuleDisabled(name string, disabled bool) (RuleInfo, error) {
+	s.tuneMu.Lock()
+	defer s.tuneMu.Unlock()
+
+	rules, err := s.PatternRules()
+	if err != nil {
+		return RuleInfo{}, err
+	}
+	index := slices.IndexFunc(rules, func(r RuleInfo) bool { return r.Name == name })
+	if index < 0 {
+		return RuleInfo{}, fmt.Errorf("%w %q", errUnknownRule, name)
+	}
+
+	next := *s.cfg()
+	disabledRules := slices.DeleteFunc(slices.Clone(next.Interceptors.Pattern.DisabledRules), func(r string) bool { return r == name })
+	if disabled {
+		disabledRules = append(disabledRules, name)
+	}
+	next.Interceptors.Pattern.DisabledRules = disabledRules
+	if _, err := s.Reload(&next); err != nil {
+		return RuleInfo{}, err
+	}
+	s.logger.Info().Str("rule", name).Bool("disabled", disabled).Msg("Pattern rule changed through the admin API")
+
+	rule := rules[index]
+	rule.Disabled = disabled
+	return rule, nil
+}
+
+// RulesAdminHandler serves the pattern rules admin API:
+//
+//	GET  /admin/rules         lists all pattern rules
+//	POST /admin/rules         adds a rule given as {name, pattern, type, confidence}
+//	GET  /admin/rules/{name}  describes a rule
+//	PUT  /admin/rules/{name}  disables or enables it with {"disabled": bool}
+//
+// It must be wrapped with RequireAdmin.
+func (s *Server) RulesAdminHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, rulesAdminPath), "/")
+		if name == "" {
+			s.serveRules(w, r)
+		} else {
+			s.serveRule(w, r, name)
+		}
+	})
+}
+
+// serveRules lists or adds pattern rules
+func (s *Server) serveRules(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		rules, err := s.PatternRules()
+		if err != nil {
+			s.logger.Error().Err(err).Msg("Failed to list pattern rules")
+			http.Error(w, "failed to list pattern rules", http.StatusInternalServerError)
+			return
+		}
+		s.writeAdminJSON(w, map[string]any{"rules": rules, "pattern_enabled": s.cfg().Interceptors.Pattern.Enabled})
+	case http.MethodPost:
+		var rule struct {
+			Name       string  `json:"name"`
+			Pattern    string  `json:"pattern"`
+			Type       string  `json:"type"`
+			Confidence float64 `json:"confidence"`
+		}
+		if !decodeAdminJSON(w, r, &rule) {
+			return
+		}
+		err := s.AddPatternRule(config.PatternRuleConfig(rule))
+		switch {
+		case errors.Is(err, errRuleExists):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case errors.Is(err, errNoRuntimeRulesFile):
+			http.Error(w, err.Error(), http.StatusConflict)
+		case err != nil:
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			s.writeAdminJSON(w, RuleInfo{
+				Name:       rule.Name,
+				Pattern:    rule.Pattern,
+				Type:       rule.Type,
+				Confidence: rule.Confidence,
+				Source:     "runtime",
+			})
+		}
+	default:
+		w.Header().Set("Allow", "GET, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// serveRule describes, disables or enables a pattern rule
+func (s *Server) serveRule(w http.ResponseWriter, r *http.Request, name string) {
+	var rule RuleInfo
+	var err error
+	switch r.Method {
+	case http.MethodGet:
+		var rules []RuleInfo
+		if rules, err = s.PatternRules(); err == nil {
+			err = fmt.Errorf("%w %q", errUnknownRule, name)
+			if i := slices.IndexFunc(rules, func(r RuleInfo) bool { return r.Name == name }); i >= 0 {
+				rule, err = rules[i], nil
+			}
+		}
+	case http.MethodPut:
+		var update struct {
+			Disabled *bool `json:"disabled"`
+		}
+		if !decodeAdminJSON(w, r, &update) {
+			return
+		}
+		if update.Disabled == nil {
+			http.Error(w, "disabled is required", http.StatusBadRequest)
+			return
+		}
+		rule, err = s.SetPatternRuleDisabled(name, *update.Disabled)
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if errors.Is(err, errUnknownRule) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		s.logger.Error().Err(err).Str("rule", name).Msg("Failed to serve pattern rule")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	s.writeAdminJSON(w, rule)
+}
+
+// decodeAdminJSON decodes the JSON body of an admin request into v,
+// answering 400 and returning false when it is invalid
+func decodeAdminJSON(w http.ResponseWriter, r *http.Request, v any) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+		return false
+	}
+	return true
+}
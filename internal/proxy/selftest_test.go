@@ -0,0 +1,75 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/rs/zerolog"
+)
+
+func TestSelfTest(t *testing.T) {
+	dir := t.TempDir()
+	cfg := config.DefaultConfig()
+	cfg.TLS.CACert, cfg.TLS.CAKey = filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+	if err := GenerateCA(cfg.TLS.CACert, cfg.TLS.CAKey); err != nil {
+		t.Fatalf("GenerateCA() error: %v", err)
+	}
+	// Settings that would defeat the canned request are overridden
+	cfg.Mode = "shadow"
+	cfg.Proxy.BypassHosts = []string{"localhost"}
+	cfg.Placeholder.Redaction.Types = []string{"*"}
+
+	report, err := SelfTest(t.Context(), cfg, zerolog.Nop())
+	if err != nil {
+		t.Fatalf("SelfTest() error: %v", err)
+	}
+	if !report.OK() || len(report.Checks) != 4 {
+		var out bytes.Buffer
+		_ = report.WriteText(&out)
+		t.Fatalf("self-test failed:\n%s", out.String())
+	}
+
+	var out bytes.Buffer
+	if err := report.WriteJSON(&out); err != nil {
+		t.Fatalf("WriteJSON() error: %v", err)
+	}
+	var decoded struct {
+		OK     bool            `json:"ok"`
+		Checks []SelfTestCheck `json:"checks"`
+	}
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil || !decoded.OK || len(decoded.Checks) != 4 {
+		t.Errorf("JSON report = %s (%v)", out.String(), err)
+	}
+	if strings.Contains(out.String(), selfTestSecret) {
+		t.Errorf("report contains the secret: %s", out.String())
+	}
+}
+
+func TestSelfTest_MissingCA(t *testing.T) {
+	cfg := config.DefaultConfig()
+	cfg.TLS.CACert = filepath.Join(t.TempDir(), "missing.crt")
+	cfg.TLS.CAKey = filepath.Join(t.TempDir(), "missing.key")
+	if _, err := SelfTest(t.Context(), cfg, zerolog.Nop()); err == nil {
+		t.Error("SelfTest() without CA succeeded")
+	}
+}
+
+func TestSelfTestReport_OK(t *testing.T) {
+	report := &SelfTestReport{}
+	if report.OK() {
+		t.Error("empty report is OK")
+	}
+	report.add("a", true, "")
+	report.add("b", false, "broken")
+	if report.OK() {
+		t.Error("report with a failed check is OK")
+	}
+	var out bytes.Buffer
+	if err := report.WriteText(&out); err != nil || !strings.Contains(out.String(), "FAIL  b: broken") {
+		t.Errorf("WriteText() = %q, %v", out.String(), err)
+	}
+}
@@ -1,6 +1,11 @@
 package proxy
 
 import (
+	"context"
+	"errors"
+
+	"github.com/hfi/llm-secret-interceptor/internal/audit"
+	"github.com/hfi/llm-secret-interceptor/internal/config"
 	"github.com/hfi/llm-secret-interceptor/internal/interceptor"
 	"github.com/hfi/llm-secret-interceptor/internal/protocol"
 	"github.com/hfi/llm-secret-interceptor/internal/storage"
@@ -14,6 +19,8 @@ type SecretService struct {
 	generator *placeholder.Generator
 	replacer  *interceptor.Replacer
 	registry  *protocol.Registry
+	redaction *redactionPolicy
+	audit     auditLogger
 }
 
 // NewSecretService creates a new secret service
@@ -32,6 +39,30 @@ func NewSecretService(
 	}
 }
 
+// SetRedaction configures secret types that are redacted irreversibly
+func (s *SecretService) SetRedaction(cfg config.RedactionConfig) error {
+	policy, err := newRedactionPolicy(cfg, s.generator)
+	if err != nil {
+		return err
+	}
+	s.redaction = policy
+	return nil
+}
+
+// SetAuditLogger sets the logger auditing detections, replacements and
+// restorations; without one nothing is audited
+func (s *SecretService) SetAuditLogger(logger auditLogger) {
+	s.audit = logger
+}
+
+// auditor returns the audit logger, or a no-op logger when none is set
+func (s *SecretService) auditor() auditLogger {
+	if s.audit == nil {
+		return audit.NewNopLogger()
+	}
+	return s.audit
+}
+
 // ProcessRequestResult contains the result of processing a request
 type ProcessRequestResult struct {
 	// ModifiedBody contains the request body with secrets replaced
@@ -40,12 +71,17 @@ type ProcessRequestResult struct {
 	SecretsFound int
 	// SecretsReplaced is the number of secrets replaced
 	SecretsReplaced int
+	// SecretsRedacted is the number of replaced secrets that were redacted
+	// irreversibly instead of stored
+	SecretsRedacted int
+	// Detected lists the detected secrets
+	Detected []interceptor.DetectedSecret
 	// Error contains any error that occurred
 	Error error
 }
 
 // ProcessRequest detects and replaces secrets in an LLM request
-func (s *SecretService) ProcessRequest(body []byte, handler protocol.Handler) *ProcessRequestResult {
+func (s *SecretService) ProcessRequest(ctx context.Context, body []byte, handler protocol.Handler) *ProcessRequestResult {
 	result := &ProcessRequestResult{
 		ModifiedBody: body,
 	}
@@ -58,6 +94,7 @@ func (s *SecretService) ProcessRequest(body []byte, handler protocol.Handler) *P
 	}
 
 	// Process each message
+	requestID := requestIDFromContext(ctx)
 	modified := false
 	for i, message := range msg.Messages {
 		// Detect and replace secrets
@@ -67,27 +104,73 @@ func (s *SecretService) ProcessRequest(body []byte, handler protocol.Handler) *P
 			modified = true
 			result.SecretsFound += len(replaceResult.Detected)
 			result.SecretsReplaced += len(replaceResult.Mappings)
+			result.Detected = append(result.Detected, replaceResult.Detected...)
+			for _, detected := range replaceResult.Detected {
+				s.auditor().LogSecretDetected(requestID, detected.Source, detected.Type)
+			}
 
-			// Store mappings
+			// Store mappings, collecting the final replacement of each secret
+			origins := make(map[string]interceptor.DetectedSecret, len(replaceResult.Detected))
+			for _, detected := range replaceResult.Detected {
+				origins[detected.Value] = detected
+			}
+			replacements := make(map[string]string, len(replaceResult.Mappings))
 			for ph, secret := range replaceResult.Mappings {
+				replacements[secret] = ph
+				origin := origins[secret]
+				if s.redaction.redacts(origin.Type) {
+					// Redacted secrets are replaced for good and never stored
+					replacements[secret] = s.redaction.token
+					result.SecretsRedacted++
+					continue
+				}
+
 				// Check if we already have this secret stored
-				if existingPh, found := s.store.LookupBySecret(secret); found {
+				existingPh, found, err := s.store.LookupBySecret(ctx, secret)
+				if err != nil {
+					result.Error = err
+				}
+				if found {
 					// Reuse existing placeholder
-					replaceResult.Text = replaceWithPlaceholder(replaceResult.Text, ph, existingPh)
+					replacements[secret] = existingPh
 				} else {
 					// Store new mapping
-					if err := s.store.Store(ph, secret); err != nil {
+					meta := storage.Metadata{
+						SecretType:  origin.Type,
+						Interceptor: origin.Source,
+						Rule:        origin.Rule,
+						RequestID:   requestID,
+					}
+					stored, err := storePlaceholder(ctx, s.store, s.generator, secret, meta)
+					switch {
+					case errors.Is(err, storage.ErrPlaceholderConflict):
+						// Even the full hash collides with another secret
+						replacements[secret] = s.redaction.fallbackToken()
+					case err != nil:
 						// Storage error - continue but log
 						result.Error = err
+					default:
+						// Possibly lengthened to resolve a collision
+						replacements[secret] = stored
+						s.auditor().LogMappingCreated(stored, audit.Origin{
+							RequestID:   requestID,
+							Interceptor: origin.Source,
+							Rule:        origin.Rule,
+							SecretType:  origin.Type,
+						})
 					}
 				}
 			}
 
-			// Update message content
-			msg.Messages[i].Content = replaceResult.Text
+			// Update message content, replacing all occurrences in a single pass
+			msg.Messages[i].Content = interceptor.ReplaceAll(message.Content, replacements)
 		}
 	}
 
+	if result.SecretsReplaced > 0 {
+		s.auditor().LogSecretReplaced(requestID, result.SecretsReplaced)
+	}
+
 	// Serialize back if modified
 	if modified {
 		newBody, err := handler.SerializeRequest(msg)
@@ -114,7 +197,7 @@ type ProcessResponseResult struct {
 }
 
 // ProcessResponse restores placeholders to secrets in an LLM response
-func (s *SecretService) ProcessResponse(body []byte, handler protocol.Handler) *ProcessResponseResult {
+func (s *SecretService) ProcessResponse(ctx context.Context, body []byte, handler protocol.Handler) *ProcessResponseResult {
 	result := &ProcessResponseResult{
 		ModifiedBody: body,
 	}
@@ -130,9 +213,7 @@ func (s *SecretService) ProcessResponse(body []byte, handler protocol.Handler) *
 	modified := false
 	for i, message := range msg.Messages {
 		// Restore placeholders
-		restoreResult := s.replacer.Restore(message.Content, func(ph string) (string, bool) {
-			return s.store.Lookup(ph)
-		})
+		restoreResult := s.replacer.Restore(message.Content, s.lookupFunc(ctx, &result.Error))
 
 		if restoreResult.RestoredCount > 0 || restoreResult.NotFoundCount > 0 {
 			modified = true
@@ -144,6 +225,10 @@ func (s *SecretService) ProcessResponse(body []byte, handler protocol.Handler) *
 		}
 	}
 
+	if result.PlaceholdersRestored > 0 {
+		s.auditor().LogPlaceholderRestored(requestIDFromContext(ctx), result.PlaceholdersRestored)
+	}
+
 	// Serialize back if modified
 	if modified {
 		newBody, err := handler.SerializeResponse(msg)
@@ -158,7 +243,7 @@ func (s *SecretService) ProcessResponse(body []byte, handler protocol.Handler) *
 }
 
 // ProcessStreamChunk processes a single streaming chunk
-func (s *SecretService) ProcessStreamChunk(data []byte, handler protocol.StreamingHandler) ([]byte, error) {
+func (s *SecretService) ProcessStreamChunk(ctx context.Context, data []byte, handler protocol.StreamingHandler) ([]byte, error) {
 	chunk, err := handler.ParseStreamChunk(data)
 	if err != nil {
 		return data, err
@@ -171,11 +256,14 @@ func (s *SecretService) ProcessStreamChunk(data []byte, handler protocol.Streami
 
 	// Restore placeholders in delta content
 	if chunk.Delta != "" {
-		restoreResult := s.replacer.Restore(chunk.Delta, func(ph string) (string, bool) {
-			return s.store.Lookup(ph)
-		})
+		var lookupErr error
+		restoreResult := s.replacer.Restore(chunk.Delta, s.lookupFunc(ctx, &lookupErr))
+		if lookupErr != nil {
+			return data, lookupErr
+		}
 
 		if restoreResult.RestoredCount > 0 {
+			s.auditor().LogPlaceholderRestored(requestIDFromContext(ctx), restoreResult.RestoredCount)
 			chunk.Delta = restoreResult.Text
 			return handler.SerializeStreamChunk(chunk)
 		}
@@ -184,19 +272,20 @@ func (s *SecretService) ProcessStreamChunk(data []byte, handler protocol.Streami
 	return data, nil
 }
 
-// replaceWithPlaceholder replaces one placeholder with another in text
-func replaceWithPlaceholder(text, oldPh, newPh string) string {
-	result := ""
-	for i := 0; i < len(text); {
-		if i+len(oldPh) <= len(text) && text[i:i+len(oldPh)] == oldPh {
-			result += newPh
-			i += len(oldPh)
-		} else {
-			result += string(text[i])
-			i++
+// lookupFunc returns a placeholder lookup on the store that records the
+// first store error in errp
+func (s *SecretService) lookupFunc(ctx context.Context, errp *error) func(string) (string, bool) {
+	return func(ph string) (string, bool) {
+		secret, found, err := s.store.Lookup(ctx, ph)
+		if err != nil && *errp == nil {
+			*errp = err
+		}
+		if found {
+			// Usage accounting is best effort and never blocks a restoration
+			_ = storage.RecordRestore(ctx, s.store, ph)
 		}
+		return secret, found
 	}
-	return result
 }
 
 // GetStore returns the underlying store
@@ -0,0 +1,39 @@
+package proxy
+
+import (
+	"fmt"
+
+	"github.com/hfi/llm-secret-interceptor/internal/interceptor"
+	"github.com/hfi/llm-secret-interceptor/internal/metrics"
+)
+
+// validateMode checks the deployment mode
+func validateMode(mode string) error {
+	switch mode {
+	case "enforce", "shadow":
+		return nil
+	}
+	return fmt.Errorf("mode must be enforce or shadow, got %q", mode)
+}
+
+// shadow reports whether requests are forwarded unmodified
+func (s *Server) shadow() bool {
+	return s.cfg().Mode == "shadow"
+}
+
+// recordShadow records the action enforce mode would have taken for each
+// secret detected in a request to host
+func (s *Server) recordShadow(host string, secrets []interceptor.DetectedSecret) {
+	block := s.cfg().Policy.Block
+	for _, secret := range secrets {
+		metrics.RecordSecretDetected(host, secret.Source, secret.Type, secret.Rule)
+		action := "mask"
+		switch {
+		case blocks(block, secret):
+			action = "block"
+		case s.redaction.redacts(secret.Type):
+			action = "redact"
+		}
+		metrics.RecordShadowAction(action, secret.Type)
+	}
+}
@@ -0,0 +1,252 @@
+package proxy
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/interceptor"
+)
+
+// statsBuckets is the number of buckets the sliding window is counted in
+const statsBuckets = 60
+
+// DetectionCounts counts detections by host, rule and secret type, and the
+// requests forwarded by host. Rules are named after their interceptor when
+// it has no rules.
+type DetectionCounts struct {
+	Detections     int            `json:"detections"`
+	ByHost         map[string]int `json:"by_host"`
+	ByRule         map[string]int `json:"by_rule"`
+	ByType         map[string]int `json:"by_type"`
+	Requests       int            `json:"requests"`
+	RequestsByHost map[string]int `json:"requests_by_host"`
+}
+
+func newDetectionCounts() DetectionCounts {
+	return DetectionCounts{
+		ByHost:         make(map[string]int),
+		ByRule:         make(map[string]int),
+		ByType:         make(map[string]int),
+		RequestsByHost: make(map[string]int),
+	}
+}
+
+// add counts a detection
+func (c *DetectionCounts) add(event DetectionEvent) {
+	rule := event.Rule
+	if rule == "" {
+		rule = event.Interceptor
+	}
+	c.Detections++
+	c.ByHost[event.Host]++
+	c.ByRule[rule]++
+	c.ByType[event.Type]++
+}
+
+// merge adds the counts of other
+func (c *DetectionCounts) merge(other DetectionCounts) {
+	c.Detections += other.Detections
+	for k, v := range other.ByHost {
+		c.ByHost[k] += v
+	}
+	for k, v := range other.ByRule {
+		c.ByRule[k] += v
+	}
+	for k, v := range other.ByType {
+		c.ByType[k] += v
+	}
+	c.Requests += other.Requests
+	for k, v := range other.RequestsByHost {
+		c.RequestsByHost[k] += v
+	}
+}
+
+// DetectionEvent is a detected secret, without its value
+type DetectionEvent struct {
+	Time        time.Time `json:"time"`
+	Host        string    `json:"host"`
+	Interceptor string    `json:"interceptor"`
+	Rule        string    `json:"rule,omitempty"`
+	Type        string    `json:"type"`
+	// Action is mask, redact, block or shadow
+	Action string `json:"action"`
+}
+
+// DetectionStats are the detection counts since the proxy started and over
+// the sliding window, and the latest detections, newest first
+type DetectionStats struct {
+	Since    time.Time        `json:"since"`
+	Window   string           `json:"window"`
+	Total    DetectionCounts  `json:"total"`
+	InWindow DetectionCounts  `json:"in_window"`
+	Recent   []DetectionEvent `json:"recent"`
+}
+
+// statsBucket counts the detections of one slice of the sliding window
+type statsBucket struct {
+	start  time.Time
+	counts DetectionCounts
+}
+
+// detectionStats counts detections in memory for the admin API. A nil
+// detectionStats records nothing.
+type detectionStats struct {
+	mu      sync.Mutex
+	since   time.Time
+	window  time.Duration
+	width   time.Duration
+	total   DetectionCounts
+	buckets [statsBuckets]statsBucket
+	// recent is a ring of the latest detections; next is the slot written next
+	recent []DetectionEvent
+	next   int
+	// subscribers receive every detection until they unsubscribe
+	subscribers map[chan DetectionEvent]struct{}
+	now         func() time.Time
+}
+
+// newDetectionStats creates detection stats as configured
+func newDetectionStats(cfg config.StatsConfig) *detectionStats {
+	window := cfg.Window
+	if window <= 0 {
+		window = time.Hour
+	}
+	return &detectionStats{
+		since:  time.Now(),
+		window: window,
+		width:  max(window/statsBuckets, time.Second),
+		total:  newDetectionCounts(),
+		recent: make([]DetectionEvent, 0, max(cfg.Recent, 0)),
+		now:    time.Now,
+
+		subscribers: make(map[chan DetectionEvent]struct{}),
+	}
+}
+
+// bucket returns the bucket counting the detections at now, the mutex held
+func (d *detectionStats) bucket(now time.Time) *DetectionCounts {
+	start := now.Truncate(d.width)
+	bucket := &d.buckets[(start.UnixNano()/int64(d.width))%statsBuckets]
+	if !bucket.start.Equal(start) {
+		*bucket = statsBucket{start: start, counts: newDetectionCounts()}
+	}
+	return &bucket.counts
+}
+
+// recordRequest counts a request forwarded to host
+func (d *detectionStats) recordRequest(host string) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.total.Requests++
+	d.total.RequestsByHost[host]++
+	counts := d.bucket(d.now())
+	counts.Requests++
+	counts.RequestsByHost[host]++
+}
+
+// subscribe returns a channel receiving detections as they are recorded,
+// and a function ending the subscription. Detections are dropped while the
+// channel is full.
+func (d *detectionStats) subscribe() (<-chan DetectionEvent, func()) {
+	events := make(chan DetectionEvent, 64)
+	d.mu.Lock()
+	d.subscribers[events] = struct{}{}
+	d.mu.Unlock()
+	return events, func() {
+		d.mu.Lock()
+		delete(d.subscribers, events)
+		d.mu.Unlock()
+	}
+}
+
+// recordAll records secrets detected in a request to host
+func (d *detectionStats) recordAll(host string, secrets []interceptor.DetectedSecret, action string) {
+	for _, secret := range secrets {
+		d.record(host, secret, action)
+	}
+}
+
+// record records a secret detected in a request to host
+func (d *detectionStats) record(host string, secret interceptor.DetectedSecret, action string) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	event := DetectionEvent{
+		Time:        now,
+		Host:        host,
+		Interceptor: secret.Source,
+		Rule:        secret.Rule,
+		Type:        secret.Type,
+		Action:      action,
+	}
+	d.total.add(event)
+	d.bucket(now).add(event)
+	for subscriber := range d.subscribers {
+		select {
+		case subscriber <- event:
+		default:
+		}
+	}
+
+	if cap(d.recent) == 0 {
+		return
+	}
+	if len(d.recent) < cap(d.recent) {
+		d.recent = append(d.recent, event)
+	} else {
+		d.recent[d.next] = event
+	}
+	d.next = (d.next + 1) % cap(d.recent)
+}
+
+// snapshot returns the current stats
+func (d *detectionStats) snapshot() DetectionStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	stats := DetectionStats{
+		Since:    d.since,
+		Window:   d.window.String(),
+		Total:    newDetectionCounts(),
+		InWindow: newDetectionCounts(),
+		Recent:   make([]DetectionEvent, 0, len(d.recent)),
+	}
+	stats.Total.merge(d.total)
+	for _, bucket := range d.buckets {
+		if bucket.counts.ByHost != nil && now.Sub(bucket.start) < d.window {
+			stats.InWindow.merge(bucket.counts)
+		}
+	}
+	for i := 1; i <= len(d.recent); i++ {
+		stats.Recent = append(stats.Recent, d.recent[(d.next-i+len(d.recent))%len(d.recent)])
+	}
+	return stats
+}
+
+// StatsHandler serves the detection stats as JSON. They name hosts and
+// rules but never contain secrets; it must be wrapped with RequireAdmin.
+func (s *Server) StatsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if s.stats == nil {
+			http.Error(w, "detection stats unavailable", http.StatusNotFound)
+			return
+		}
+		s.writeAdminJSON(w, s.stats.snapshot())
+	})
+}
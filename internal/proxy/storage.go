@@ -0,0 +1,205 @@
+package proxy
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+
+	"github.com/hfi/llm-secret-interceptor/internal/awsauth"
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/kms"
+	"github.com/hfi/llm-secret-interceptor/internal/storage"
+)
+
+// newMappingStore creates the mapping store selected in the storage
+// configuration, wrapped with caching, encryption and operation timeouts as
+// configured. The keyring
+// is nil unless encryption is enabled.
+func newMappingStore(ctx context.Context, cfg config.StorageConfig) (storage.MappingStore, *kms.Keyring, error) {
+	base, err := newBaseStore(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Wrapped data keys are persisted in the base store when it supports it,
+	// so they are shared by all instances using the same backend
+	var keyStore kms.KeyStore = kms.NewMemoryKeyStore()
+	if ks, ok := base.(kms.KeyStore); ok {
+		keyStore = ks
+	}
+
+	memStore, snapshots := base.(*storage.MemoryStore)
+	snapshots = snapshots && cfg.Snapshot.Enabled
+	if snapshots {
+		if err := seedSnapshotKeys(ctx, cfg, keyStore); err != nil {
+			return nil, nil, closeOnError(base, err)
+		}
+	}
+
+	backend := cfg.Type
+	if !isSharedStore(cfg.Type) {
+		backend = "memory"
+	}
+	store := storage.MappingStore(storage.NewInstrumentedStore(base, backend))
+	if cfg.Cache.Enabled && isSharedStore(cfg.Type) {
+		// The cache sits below encryption, so cached secrets stay sealed
+		store = storage.NewCachedStore(store, cfg.Cache.MaxEntries, cfg.Cache.TTL)
+	}
+
+	var keyring *kms.Keyring
+	if cfg.Encryption.Enabled {
+		store, keyring, err = newEncryptedStore(ctx, cfg.Encryption, store, keyStore)
+		if err != nil {
+			return nil, nil, closeOnError(base, fmt.Errorf("failed to initialize store encryption: %w", err))
+		}
+	}
+
+	if snapshots {
+		if _, err := memStore.EnableSnapshots(ctx, cfg.Snapshot.Path, cfg.Snapshot.Interval, keyring, keyStore); err != nil {
+			return nil, nil, closeOnError(base, fmt.Errorf("failed to restore snapshot: %w", err))
+		}
+	}
+
+	// The timeout is applied outermost so it also bounds key provider calls
+	return storage.WithTimeout(store, cfg.Timeout), keyring, nil
+}
+
+// seedSnapshotKeys loads the data keys saved in the snapshot file into
+// keyStore, so the keyring can decrypt the snapshot after a restart
+func seedSnapshotKeys(ctx context.Context, cfg config.StorageConfig, keyStore kms.KeyStore) error {
+	if !cfg.Encryption.Enabled {
+		return fmt.Errorf("storage snapshots require storage.encryption to be enabled")
+	}
+	if cfg.Snapshot.Path == "" {
+		return fmt.Errorf("storage snapshots require a path")
+	}
+
+	keys, err := storage.ReadSnapshotKeys(cfg.Snapshot.Path)
+	if err != nil {
+		return err
+	}
+	for _, key := range keys {
+		if err := keyStore.SaveKey(ctx, key); err != nil {
+			return fmt.Errorf("failed to load snapshot keys: %w", err)
+		}
+	}
+	return nil
+}
+
+// closeOnError closes store after a failed setup step, keeping err as the
+// primary error
+func closeOnError(store storage.MappingStore, err error) error {
+	if closeErr := store.Close(); closeErr != nil {
+		return fmt.Errorf("%w (close: %v)", err, closeErr)
+	}
+	return err
+}
+
+// OpenMappingStore opens the configured mapping store outside of a running
+// proxy, e.g. for maintenance commands. Only shared stores can be opened;
+// the memory store lives inside the proxy process.
+func OpenMappingStore(ctx context.Context, cfg config.StorageConfig) (storage.MappingStore, error) {
+	if !isSharedStore(cfg.Type) {
+		return nil, fmt.Errorf("storage type %q is not shared and cannot be opened outside the proxy", cfg.Type)
+	}
+	store, _, err := newMappingStore(ctx, cfg)
+	return store, err
+}
+
+// PurgeMappings deletes the mappings selected by filter from the proxy's store
+// and records the purge in the audit log
+func (s *Server) PurgeMappings(ctx context.Context, filter storage.PurgeFilter) (int, error) {
+	deleted, err := storage.Purge(ctx, s.store, filter)
+	s.auditor().LogMappingsPurged(deleted, filter.Criteria())
+	if err != nil {
+		return deleted, fmt.Errorf("failed to purge mappings: %w", err)
+	}
+	s.logger.Info().
+		Int("deleted", deleted).
+		Interface("criteria", filter.Criteria()).
+		Msg("Purged mappings")
+	return deleted, nil
+}
+
+// isSharedStore reports whether a storage type is shared between instances
+func isSharedStore(storageType string) bool {
+	return storageType == "redis" || storageType == "dynamodb"
+}
+
+// newBaseStore creates the memory, Redis or DynamoDB store
+func newBaseStore(cfg config.StorageConfig) (storage.MappingStore, error) {
+	switch cfg.Type {
+	case "redis":
+		return newRedisStore(cfg)
+	case "dynamodb":
+		return newDynamoDBStore(cfg)
+	default:
+		store := storage.NewMemoryStore(cfg.TTL)
+		store.SetMaxEntries(cfg.MaxEntries)
+		return store, nil
+	}
+}
+
+// newDynamoDBStore creates the DynamoDB store with credentials from the environment
+func newDynamoDBStore(cfg config.StorageConfig) (storage.MappingStore, error) {
+	creds, err := awsauth.CredentialsFromEnv()
+	if err != nil {
+		return nil, err
+	}
+
+	var indexKey []byte
+	if cfg.DynamoDB.IndexKey != "" {
+		indexKey, err = base64.StdEncoding.DecodeString(cfg.DynamoDB.IndexKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DynamoDB index key: %w", err)
+		}
+	}
+
+	store, err := storage.NewDynamoDBStore(storage.DynamoDBOptions{
+		Table:       cfg.DynamoDB.Table,
+		Region:      cfg.DynamoDB.Region,
+		Endpoint:    cfg.DynamoDB.Endpoint,
+		Credentials: creds,
+		IndexKey:    indexKey,
+	}, cfg.TTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize DynamoDB store: %w", err)
+	}
+	return store, nil
+}
+
+// newRedisStore creates the Redis store
+func newRedisStore(cfg config.StorageConfig) (storage.MappingStore, error) {
+
+	tlsConfig, err := clientTLSConfig(cfg.Redis.TLS)
+	if err != nil {
+		return nil, fmt.Errorf("failed to configure Redis TLS: %w", err)
+	}
+
+	var indexKey []byte
+	if cfg.Redis.IndexKey != "" {
+		indexKey, err = base64.StdEncoding.DecodeString(cfg.Redis.IndexKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid Redis index key: %w", err)
+		}
+	}
+
+	store, err := storage.NewRedisStore(storage.RedisOptions{
+		Address:      cfg.Redis.Address,
+		Username:     cfg.Redis.Username,
+		Password:     cfg.Redis.Password,
+		DB:           cfg.Redis.DB,
+		IndexKey:     indexKey,
+		TLS:          tlsConfig,
+		PoolSize:     cfg.Redis.PoolSize,
+		MinIdleConns: cfg.Redis.MinIdleConns,
+		DialTimeout:  cfg.Redis.DialTimeout,
+		ReadTimeout:  cfg.Redis.ReadTimeout,
+		WriteTimeout: cfg.Redis.WriteTimeout,
+		PoolTimeout:  cfg.Redis.PoolTimeout,
+	}, cfg.TTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize Redis store: %w", err)
+	}
+	return store, nil
+}
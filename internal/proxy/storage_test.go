@@ -0,0 +1,176 @@
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/storage"
+	"github.com/rs/zerolog"
+)
+
+func TestNewMappingStore_Memory(t *testing.T) {
+	cfg := config.DefaultConfig().Storage
+	store, keyring, err := newMappingStore(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("newMappingStore() error: %v", err)
+	}
+	defer store.Close()
+
+	if _, ok := store.(*storage.TimeoutStore); !ok {
+		t.Errorf("newMappingStore() = %T, want *storage.TimeoutStore", store)
+	}
+	if keyring != nil {
+		t.Error("keyring should be nil without encryption")
+	}
+
+	cfg.Timeout = 0
+	untimed, _, err := newMappingStore(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("newMappingStore() error: %v", err)
+	}
+	defer untimed.Close()
+
+	if _, ok := untimed.(*storage.InstrumentedStore); !ok {
+		t.Errorf("newMappingStore() without timeout = %T, want *storage.InstrumentedStore", untimed)
+	}
+}
+
+func TestNewMappingStore_RedisCacheEncryption(t *testing.T) {
+	t.Setenv("LLM_PROXY_KEK", base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32)))
+	mr := miniredis.RunT(t)
+
+	cfg := config.DefaultConfig().Storage
+	cfg.Type = "redis"
+	cfg.Redis.Address = mr.Addr()
+	cfg.Cache.Enabled = true
+	cfg.Encryption.Enabled = true
+	cfg.Timeout = 0
+
+	store, keyring, err := newMappingStore(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("newMappingStore() error: %v", err)
+	}
+	defer store.Close()
+
+	if _, ok := store.(*storage.EncryptedStore); !ok {
+		t.Errorf("newMappingStore() = %T, want *storage.EncryptedStore", store)
+	}
+	if keyring == nil {
+		t.Fatal("keyring should be set with encryption")
+	}
+
+	// Wrapped data keys are persisted in Redis
+	if !mr.Exists("llm-secret:keyring") {
+		t.Error("keyring not persisted in Redis")
+	}
+
+	if err := store.Store(context.Background(), "__SECRET_12345678__", "mysecretpassword", storage.Metadata{}); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+	raw, err := mr.Get("llm-secret:p:__SECRET_12345678__")
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	if raw == "mysecretpassword" {
+		t.Error("secret stored in Redis as plaintext")
+	}
+	if got, found, _ := store.Lookup(context.Background(), "__SECRET_12345678__"); !found || got != "mysecretpassword" {
+		t.Errorf("Lookup() = %q, %v", got, found)
+	}
+}
+
+func TestNewMappingStore_RedisUnreachable(t *testing.T) {
+	cfg := config.DefaultConfig().Storage
+	cfg.Type = "redis"
+	cfg.Redis.Address = "127.0.0.1:1"
+	cfg.Redis.DialTimeout = 100 * time.Millisecond
+
+	if _, _, err := newMappingStore(context.Background(), cfg); err == nil {
+		t.Error("newMappingStore() expected error for unreachable Redis")
+	}
+}
+
+func TestServer_PurgeMappings(t *testing.T) {
+	store, _, err := newMappingStore(context.Background(), config.DefaultConfig().Storage)
+	if err != nil {
+		t.Fatalf("newMappingStore() error: %v", err)
+	}
+	defer store.Close()
+	server := &Server{config: config.DefaultConfig(), store: store, logger: zerolog.Nop()}
+
+	_ = store.Store(context.Background(), "__SECRET_1__", "secret1", storage.Metadata{SecretType: "api_key"})
+	_ = store.Store(context.Background(), "__SECRET_2__", "secret2", storage.Metadata{SecretType: "password"})
+
+	deleted, err := server.PurgeMappings(context.Background(), storage.PurgeFilter{SecretType: "api_key"})
+	if err != nil || deleted != 1 {
+		t.Fatalf("PurgeMappings() = %d, %v, want 1", deleted, err)
+	}
+	if size := store.Size(); size != 1 {
+		t.Errorf("Size() = %d after purge, want 1", size)
+	}
+}
+
+func TestOpenMappingStore_Memory(t *testing.T) {
+	if _, err := OpenMappingStore(context.Background(), config.DefaultConfig().Storage); err == nil {
+		t.Error("OpenMappingStore() expected error for the memory store")
+	}
+}
+
+func TestNewMappingStore_MemorySnapshot(t *testing.T) {
+	t.Setenv("LLM_PROXY_KEK", base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32)))
+
+	cfg := config.DefaultConfig().Storage
+	cfg.Encryption.Enabled = true
+	cfg.Snapshot.Enabled = true
+	cfg.Snapshot.Path = filepath.Join(t.TempDir(), "mappings.snapshot")
+
+	store, _, err := newMappingStore(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("newMappingStore() error: %v", err)
+	}
+	if err := store.Store(context.Background(), "__SECRET_12345678__", "mysecretpassword", storage.Metadata{}); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	restarted, _, err := newMappingStore(context.Background(), cfg)
+	if err != nil {
+		t.Fatalf("newMappingStore() after restart error: %v", err)
+	}
+	defer restarted.Close()
+	if got, found, _ := restarted.Lookup(context.Background(), "__SECRET_12345678__"); !found || got != "mysecretpassword" {
+		t.Errorf("Lookup() after restart = %q, %v", got, found)
+	}
+}
+
+func TestNewMappingStore_SnapshotRequiresEncryption(t *testing.T) {
+	cfg := config.DefaultConfig().Storage
+	cfg.Snapshot.Enabled = true
+	cfg.Snapshot.Path = filepath.Join(t.TempDir(), "mappings.snapshot")
+
+	if _, _, err := newMappingStore(context.Background(), cfg); err == nil {
+		t.Error("newMappingStore() should fail without encryption")
+	}
+}
+
+func TestNewMappingStore_DynamoDBRequiresCredentials(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "")
+
+	cfg := config.DefaultConfig().Storage
+	cfg.Type = "dynamodb"
+	cfg.DynamoDB.Table = "mappings"
+	cfg.DynamoDB.Region = "eu-central-1"
+
+	if _, _, err := newMappingStore(context.Background(), cfg); err == nil {
+		t.Error("newMappingStore() should fail without AWS credentials")
+	}
+}
@@ -2,83 +2,148 @@ package proxy
 
 import (
 	"bytes"
+	"context"
 	"io"
 
 	"github.com/hfi/llm-secret-interceptor/internal/protocol"
+	"github.com/hfi/llm-secret-interceptor/pkg/placeholder"
 )
 
-// StreamProcessor handles streaming response processing with buffering
+// StreamProcessor restores placeholders in the deltas of a streamed
+// response. Delta text is held back until no placeholder can span the
+// boundary, so placeholders split across events are restored as a whole.
 type StreamProcessor struct {
-	service     *SecretService
-	handler     protocol.StreamingHandler
-	buffer      *protocol.StreamBuffer
-	writer      io.Writer
+	handler   protocol.StreamingHandler
+	restore   func(string) (string, error)
+	generator *placeholder.Generator
+	maxLength int
+	events    *protocol.SSEWriter
+
+	// pending is delta text held back for a placeholder that may continue
+	// in a later event; last is the latest event carrying a delta
+	pending     string
+	last        streamEvent
 	accumulated string
 }
 
-// NewStreamProcessor creates a new stream processor
+// streamEvent is a parsed SSE event
+type streamEvent struct {
+	eventType string
+	data      []byte
+	chunk     *protocol.StreamChunk
+}
+
+// NewStreamProcessor creates a new stream processor. Mapping lookups are
+// bound to ctx.
 func NewStreamProcessor(
+	ctx context.Context,
 	service *SecretService,
 	handler protocol.StreamingHandler,
 	writer io.Writer,
 	maxPlaceholderLen int,
+) *StreamProcessor {
+	restore := func(content string) (string, error) {
+		var lookupErr error
+		result := service.replacer.Restore(content, service.lookupFunc(ctx, &lookupErr))
+		return result.Text, lookupErr
+	}
+	return newStreamProcessor(handler, writer, service.generator, maxPlaceholderLen, restore)
+}
+
+// newStreamProcessor creates a stream processor writing SSE events to writer
+// and restoring delta text with restore
+func newStreamProcessor(
+	handler protocol.StreamingHandler,
+	writer io.Writer,
+	generator *placeholder.Generator,
+	maxPlaceholderLen int,
+	restore func(string) (string, error),
 ) *StreamProcessor {
 	return &StreamProcessor{
-		service: service,
-		handler: handler,
-		buffer:  protocol.NewStreamBuffer(maxPlaceholderLen),
-		writer:  writer,
+		handler:   handler,
+		restore:   restore,
+		generator: generator,
+		maxLength: maxPlaceholderLen,
+		events:    protocol.NewSSEWriter(writer),
 	}
 }
 
 // ProcessChunk processes a single SSE data chunk
 func (sp *StreamProcessor) ProcessChunk(data []byte) error {
-	// Parse the chunk
-	chunk, err := sp.handler.ParseStreamChunk(data)
-	if err != nil {
-		// If we can't parse, pass through
-		return sp.writeSSEEvent(data)
-	}
+	return sp.ProcessEvent("", data)
+}
 
-	// If done marker, flush buffer and forward
-	if chunk.IsDone {
+// ProcessEvent processes a single SSE event. Events without delta text, such
+// as tool calls or events of other formats, are restored as a whole once the
+// held back text has been written.
+func (sp *StreamProcessor) ProcessEvent(eventType string, data []byte) error {
+	chunk, err := sp.handler.ParseStreamChunk(data)
+	if err != nil || chunk.IsDone || chunk.Delta == "" {
+		// Keep the order of text and other events
 		if err := sp.flushAll(); err != nil {
 			return err
 		}
-		return sp.writeSSEEvent(data)
+		restored, err := sp.restore(string(data))
+		if err != nil {
+			return err
+		}
+		return sp.events.WriteEvent(eventType, []byte(restored))
 	}
 
-	// Add delta to accumulated content
 	sp.accumulated += chunk.Delta
+	sp.pending += chunk.Delta
+	sp.last = streamEvent{eventType: eventType, data: data, chunk: chunk}
 
-	// Add delta to buffer
-	sp.buffer.Write([]byte(chunk.Delta))
-
-	// Flush safe content
-	safe := sp.buffer.Flush()
-	if safe != nil {
-		// Process the safe content for placeholder restoration
-		processed := sp.processContent(string(safe))
-
-		// Create a new chunk with processed content
-		outputChunk := &protocol.StreamChunk{
-			Delta:        processed,
-			Role:         chunk.Role,
-			FinishReason: "",
-			Metadata:     chunk.Metadata,
-		}
+	cut := sp.safeCut(sp.pending)
+	if cut == 0 {
+		return nil
+	}
+	text := sp.pending[:cut]
+	sp.pending = sp.pending[cut:]
+	return sp.emit(text)
+}
 
-		serialized, err := sp.handler.SerializeStreamChunk(outputChunk)
-		if err != nil {
-			return err
+// safeCut returns how much of text can be restored and written without
+// cutting a placeholder. The last maxLength bytes are held back for a
+// placeholder that is not complete yet; complete placeholders across that
+// boundary are written whole.
+func (sp *StreamProcessor) safeCut(text string) int {
+	cut := len(text) - sp.maxLength
+	if cut <= 0 {
+		return 0
+	}
+	if sp.generator != nil {
+		for _, m := range sp.generator.FindMatches(text) {
+			if m.Start < cut && m.End > cut {
+				cut = m.End
+			}
 		}
+	}
+	return cut
+}
 
-		if err := sp.writeSSEEvent(serialized); err != nil {
-			return err
-		}
+// emit restores text and writes it in an event shaped like the latest delta
+// event
+func (sp *StreamProcessor) emit(text string) error {
+	restored, err := sp.restore(text)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	var data []byte
+	if rewriter, ok := sp.handler.(protocol.StreamDeltaRewriter); ok {
+		data, err = rewriter.RewriteStreamDelta(sp.last.data, restored)
+	} else {
+		data, err = sp.handler.SerializeStreamChunk(&protocol.StreamChunk{
+			Delta:    restored,
+			Role:     sp.last.chunk.Role,
+			Metadata: sp.last.chunk.Metadata,
+		})
+	}
+	if err != nil {
+		return err
+	}
+	return sp.events.WriteEvent(sp.last.eventType, data)
 }
 
 // Flush processes and sends any remaining buffered content
@@ -87,43 +152,21 @@ func (sp *StreamProcessor) Flush() error {
 }
 
 func (sp *StreamProcessor) flushAll() error {
-	remaining := sp.buffer.FlushAll()
-	if len(remaining) == 0 {
+	if sp.pending == "" {
 		return nil
 	}
-
-	// Process remaining content
-	processed := sp.processContent(string(remaining))
-
-	// Create final chunk
-	chunk := &protocol.StreamChunk{
-		Delta: processed,
-	}
-
-	serialized, err := sp.handler.SerializeStreamChunk(chunk)
-	if err != nil {
-		return err
-	}
-
-	return sp.writeSSEEvent(serialized)
+	text := sp.pending
+	sp.pending = ""
+	return sp.emit(text)
 }
 
-func (sp *StreamProcessor) processContent(content string) string {
-	result := sp.service.replacer.Restore(content, func(ph string) (string, bool) {
-		return sp.service.store.Lookup(ph)
-	})
-	return result.Text
+func (sp *StreamProcessor) processContent(content string) (string, error) {
+	return sp.restore(content)
 }
 
-func (sp *StreamProcessor) writeSSEEvent(data []byte) error {
-	// Write in SSE format
-	var buf bytes.Buffer
-	buf.WriteString("data: ")
-	buf.Write(data)
-	buf.WriteString("\n\n")
-
-	_, err := sp.writer.Write(buf.Bytes())
-	return err
+// held reports whether delta text is held back
+func (sp *StreamProcessor) held() bool {
+	return sp.pending != ""
 }
 
 // GetAccumulated returns the accumulated content from all chunks
@@ -138,8 +181,9 @@ type StreamReader struct {
 	done      bool
 }
 
-// NewStreamReader creates a new stream reader
+// NewStreamReader creates a new stream reader. Mapping lookups are bound to ctx.
 func NewStreamReader(
+	ctx context.Context,
 	r io.Reader,
 	service *SecretService,
 	handler protocol.StreamingHandler,
@@ -147,7 +191,7 @@ func NewStreamReader(
 ) *StreamReader {
 	return &StreamReader{
 		reader:    protocol.NewSSEParser(r),
-		processor: NewStreamProcessor(service, handler, nil, maxPlaceholderLen),
+		processor: NewStreamProcessor(ctx, service, handler, nil, maxPlaceholderLen),
 		done:      false,
 	}
 }
@@ -189,7 +233,10 @@ func (sr *StreamReader) ReadProcessedEvent() ([]byte, error) {
 
 	// Process delta content
 	if chunk.Delta != "" {
-		result := sr.processor.processContent(chunk.Delta)
+		result, err := sr.processor.processContent(chunk.Delta)
+		if err != nil {
+			return data, err
+		}
 		if result != chunk.Delta {
 			chunk.Delta = result
 			return sr.processor.handler.SerializeStreamChunk(chunk)
@@ -0,0 +1,57 @@
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// systemdPrefix marks listener addresses naming a socket passed by
+	// systemd socket activation
+	systemdPrefix = "systemd:"
+	// systemdFirstFD is the first descriptor passed by systemd
+	systemdFirstFD = 3
+)
+
+// systemdListeners returns the sockets passed by systemd socket activation,
+// keyed by "systemd:" and their FileDescriptorName. Sockets without a unique
+// name are keyed by their position instead.
+func systemdListeners() (map[string]net.Listener, error) {
+	pid, pidErr := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	count, countErr := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	names := strings.Split(os.Getenv("LISTEN_FDNAMES"), ":")
+	// The sockets are meant for this process only, not for its children
+	for _, env := range []string{"LISTEN_PID", "LISTEN_FDS", "LISTEN_FDNAMES"} {
+		if err := os.Unsetenv(env); err != nil {
+			return nil, fmt.Errorf("failed to unset %s: %w", env, err)
+		}
+	}
+	if pidErr != nil || countErr != nil || pid != os.Getpid() || count <= 0 {
+		return nil, nil
+	}
+
+	seen := make(map[string]int, len(names))
+	for _, name := range names {
+		seen[name]++
+	}
+	listeners := make(map[string]net.Listener, count)
+	for i := range count {
+		name := strconv.Itoa(i)
+		if i < len(names) && names[i] != "" && seen[names[i]] == 1 {
+			name = names[i]
+		}
+		file := os.NewFile(uintptr(systemdFirstFD+i), name)
+		ln, err := net.FileListener(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to use systemd socket %s: %w", name, err)
+		}
+		if err := file.Close(); err != nil {
+			return nil, fmt.Errorf("failed to close systemd descriptor: %w", err)
+		}
+		listeners[systemdPrefix+name] = ln
+	}
+	return listeners, nil
+}
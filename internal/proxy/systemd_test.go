@@ -0,0 +1,92 @@
+package proxy
+
+import (
+	"net"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// TestSystemdListeners re-runs the test binary with sockets at the
+// descriptors systemd uses
+func TestSystemdListeners(t *testing.T) {
+	if os.Getenv("SYSTEMD_HELPER") == "1" {
+		systemdListenersHelper(t)
+		return
+	}
+
+	var files []*os.File
+	var addrs []string
+	for range 3 {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = ln.Close() }()
+		file, err := ln.(*net.TCPListener).File()
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = file.Close() }()
+		files = append(files, file)
+		addrs = append(addrs, ln.Addr().String())
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestSystemdListeners$") //#nosec G204 -- runs the test binary itself
+	cmd.ExtraFiles = files
+	cmd.Env = append(os.Environ(),
+		"SYSTEMD_HELPER=1",
+		"LISTEN_FDS=3",
+		// Duplicate names fall back to the position
+		"LISTEN_FDNAMES=proxy:dup:dup",
+		"WANT_ADDRS="+addrs[0]+","+addrs[1]+","+addrs[2],
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("helper failed: %v\n%s", err, out)
+	}
+}
+
+func systemdListenersHelper(t *testing.T) {
+	// systemd sets LISTEN_PID to the pid of the activated process
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+	want := map[string]int{"systemd:proxy": 0, "systemd:1": 1, "systemd:2": 2}
+	wantAddrs := strings.Split(os.Getenv("WANT_ADDRS"), ",")
+
+	listeners, err := systemdListeners()
+	if err != nil {
+		t.Fatalf("systemdListeners() error: %v", err)
+	}
+	if len(listeners) != len(want) {
+		t.Fatalf("systemdListeners() = %v, want keys %v", listeners, want)
+	}
+	for key, i := range want {
+		ln, ok := listeners[key]
+		if !ok {
+			t.Errorf("missing listener %s", key)
+			continue
+		}
+		if got := ln.Addr().String(); got != wantAddrs[i] {
+			t.Errorf("listener %s address = %s, want %s", key, got, wantAddrs[i])
+		}
+	}
+	if os.Getenv("LISTEN_FDS") != "" {
+		t.Error("LISTEN_FDS was not unset")
+	}
+}
+
+func TestSystemdListeners_OtherProcess(t *testing.T) {
+	t.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()+1))
+	t.Setenv("LISTEN_FDS", "1")
+	listeners, err := systemdListeners()
+	if err != nil || listeners != nil {
+		t.Errorf("systemdListeners() = %v, %v; want nothing for another process", listeners, err)
+	}
+}
+
+func TestListen_MissingSystemdSocket(t *testing.T) {
+	if _, err := listenSocket("systemd:proxy"); err == nil {
+		t.Error("listenSocket() should fail without a socket passed by systemd")
+	}
+}
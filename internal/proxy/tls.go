@@ -1,6 +1,7 @@
 package proxy
 
 import (
+	"crypto"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/tls"
@@ -14,43 +15,125 @@ import (
 	"path/filepath"
 	"sync"
 	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/metrics"
 )
 
 // CertManager handles dynamic certificate generation for TLS interception
 type CertManager struct {
-	caCert    *x509.Certificate
-	caKey     *rsa.PrivateKey
-	caTLSCert tls.Certificate
-	cache     map[string]*tls.Certificate
-	cacheMu   sync.RWMutex
+	// ca issues new certificates; Rotate replaces it
+	ca   *certAuthority
+	caMu sync.RWMutex
+	// rotation tracks the CA replaced last while its certificates are served
+	rotation *caRotation
+	// leafKey is shared by all generated certificates when set
+	leafKey *rsa.PrivateKey
+	keyMu   sync.RWMutex
+	cache   *certCache
+	// cacheDir persists generated certificates when set
+	cacheDir string
+	// wildcardDomains get one wildcard certificate for their subdomains
+	wildcardDomains []string
+	// signer holds the CA key outside of ca_key when set
+	signer crypto.Signer
+	// validity is the lifetime of generated certificates
+	validity time.Duration
+}
+
+const (
+	// defaultLeafValidity is the lifetime of generated certificates unless
+	// configured otherwise
+	defaultLeafValidity = 48 * time.Hour
+	// maxLeafValidity is the longest lifetime clients accept for leaf
+	// certificates
+	maxLeafValidity = 398 * 24 * time.Hour
+)
+
+// certAuthority is a CA certificate and key issuing host certificates
+type certAuthority struct {
+	cert *x509.Certificate
+	// key signs certificates; it may be held by a key management service
+	key crypto.Signer
+	// chain holds the CA certificates served with generated certificates,
+	// and anchor is the one clients are asked to trust
+	chain  [][]byte
+	anchor *x509.Certificate
 }
 
 // NewCertManager creates a new certificate manager
 func NewCertManager(caCertPath, caKeyPath string) (*CertManager, error) {
+	return newCertManager(caCertPath, caKeyPath, nil)
+}
+
+// NewCertManagerWithSigner creates a certificate manager signing with
+// signer, e.g. a key held by a KMS or HSM, instead of a key file
+func NewCertManagerWithSigner(caCertPath string, signer crypto.Signer) (*CertManager, error) {
+	return newCertManager(caCertPath, "", signer)
+}
+
+func newCertManager(caCertPath, caKeyPath string, signer crypto.Signer) (*CertManager, error) {
+	cm := &CertManager{signer: signer, cache: newCertCache(0), validity: defaultLeafValidity}
+	ca, err := cm.loadCertAuthority(caCertPath, caKeyPath)
+	if err != nil {
+		return nil, err
+	}
+	cm.ca = ca
+	return cm, nil
+}
+
+// loadCertAuthority reads a CA certificate, or chain, and its key from
+// caKeyPath unless the manager signs with an external signer
+func (cm *CertManager) loadCertAuthority(caCertPath, caKeyPath string) (*certAuthority, error) {
 	// Clean and validate paths to prevent path traversal
 	caCertPath = filepath.Clean(caCertPath)
-	caKeyPath = filepath.Clean(caKeyPath)
 
 	// Load CA certificate
 	caCertPEM, err := os.ReadFile(caCertPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read CA certificate: %w", err)
 	}
-
-	caKeyPEM, err := os.ReadFile(caKeyPath)
+	var ders [][]byte
+	for block, rest := pem.Decode(caCertPEM); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type == "CERTIFICATE" {
+			ders = append(ders, block.Bytes)
+		}
+	}
+	if len(ders) == 0 {
+		return nil, fmt.Errorf("failed to decode CA certificate PEM")
+	}
+	chain, anchor, err := parseCAChain(ders)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read CA key: %w", err)
+		return nil, err
+	}
+	caCert, err := x509.ParseCertificate(ders[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse CA certificate: %w", err)
 	}
 
-	// Parse CA certificate
-	caCertBlock, _ := pem.Decode(caCertPEM)
-	if caCertBlock == nil {
-		return nil, fmt.Errorf("failed to decode CA certificate PEM")
+	key := cm.signer
+	if key == nil {
+		if key, err = loadCAKey(caKeyPath); err != nil {
+			return nil, err
+		}
+	}
+	public, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool })
+	if !ok || !public.Equal(caCert.PublicKey) {
+		return nil, fmt.Errorf("CA key does not match the CA certificate")
 	}
 
-	caCert, err := x509.ParseCertificate(caCertBlock.Bytes)
+	return &certAuthority{
+		cert:   caCert,
+		key:    key,
+		chain:  chain,
+		anchor: anchor,
+	}, nil
+}
+
+// loadCAKey reads a PEM encoded CA private key
+func loadCAKey(caKeyPath string) (crypto.Signer, error) {
+	caKeyPEM, err := os.ReadFile(filepath.Clean(caKeyPath))
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse CA certificate: %w", err)
+		return nil, fmt.Errorf("failed to read CA key: %w", err)
 	}
 
 	// Parse CA private key
@@ -60,69 +143,158 @@ func NewCertManager(caCertPath, caKeyPath string) (*CertManager, error) {
 	}
 
 	caKey, err := x509.ParsePKCS1PrivateKey(caKeyBlock.Bytes)
-	if err != nil {
-		// Try PKCS8 format
-		key, err2 := x509.ParsePKCS8PrivateKey(caKeyBlock.Bytes)
-		if err2 != nil {
-			return nil, fmt.Errorf("failed to parse CA key: %w (also tried PKCS8: %v)", err, err2)
+	if err == nil {
+		return caKey, nil
+	}
+	if ecKey, ecErr := x509.ParseECPrivateKey(caKeyBlock.Bytes); ecErr == nil {
+		return ecKey, nil
+	}
+	// Try PKCS8 format
+	key, err2 := x509.ParsePKCS8PrivateKey(caKeyBlock.Bytes)
+	if err2 != nil {
+		return nil, fmt.Errorf("failed to parse CA key: %w (also tried PKCS8: %v)", err, err2)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, fmt.Errorf("CA key of type %T cannot sign", key)
+	}
+	return signer, nil
+}
+
+// authority returns the CA issuing new certificates
+func (cm *CertManager) authority() *certAuthority {
+	cm.caMu.RLock()
+	defer cm.caMu.RUnlock()
+	return cm.ca
+}
+
+// parseCAChain checks that each certificate of a CA bundle, signing
+// certificate first, is issued by the next. It returns the intermediates to
+// serve with generated certificates, leaving out a self-signed root, and the
+// topmost certificate as the trust anchor.
+func parseCAChain(ders [][]byte) ([][]byte, *x509.Certificate, error) {
+	certs := make([]*x509.Certificate, len(ders))
+	for i, der := range ders {
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse CA certificate %d: %w", i, err)
+		}
+		if !cert.IsCA {
+			return nil, nil, fmt.Errorf("CA certificate %d (%s) is not a CA", i, cert.Subject.CommonName)
 		}
-		var ok bool
-		caKey, ok = key.(*rsa.PrivateKey)
-		if !ok {
-			return nil, fmt.Errorf("CA key is not an RSA key")
+		if i > 0 {
+			if err := certs[i-1].CheckSignatureFrom(cert); err != nil {
+				return nil, nil, fmt.Errorf("CA certificate %d is not issued by certificate %d: %w", i-1, i, err)
+			}
 		}
+		certs[i] = cert
 	}
 
-	// Create TLS certificate for CA
-	caTLSCert, err := tls.X509KeyPair(caCertPEM, caKeyPEM)
+	var chain [][]byte
+	for _, cert := range certs {
+		if cert.CheckSignatureFrom(cert) == nil {
+			// Clients already trust the root
+			break
+		}
+		chain = append(chain, cert.Raw)
+	}
+	return chain, certs[len(certs)-1], nil
+}
+
+// UseSharedKey generates one key pair for all certificates generated from
+// now on, so a new hostname only costs a signature instead of a key
+// generation. Clients see the same public key for every host.
+func (cm *CertManager) UseSharedKey() error {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create TLS certificate: %w", err)
+		return fmt.Errorf("failed to generate shared leaf key: %w", err)
 	}
+	cm.keyMu.Lock()
+	cm.leafKey = key
+	cm.keyMu.Unlock()
+	return nil
+}
 
-	return &CertManager{
-		caCert:    caCert,
-		caKey:     caKey,
-		caTLSCert: caTLSCert,
-		cache:     make(map[string]*tls.Certificate),
-	}, nil
+// SetCacheSize limits the number of cached host certificates. When the
+// limit is reached, the least recently used certificate is evicted. 0
+// disables the limit.
+func (cm *CertManager) SetCacheSize(size int) {
+	cm.cache.resize(size)
+}
+
+// SetLeafValidity sets the lifetime of certificates generated from now on.
+// Cached certificates are regenerated once less than a tenth of their
+// lifetime remains.
+func (cm *CertManager) SetLeafValidity(validity time.Duration) error {
+	if validity <= 0 || validity > maxLeafValidity {
+		return fmt.Errorf("leaf certificate validity %s must be positive and at most %s", validity, maxLeafValidity)
+	}
+	cm.validity = validity
+	return nil
 }
 
 // GetCertificate returns a certificate for the given hostname
-// Generates a new certificate on-the-fly if not cached
+// Generates a new certificate on-the-fly if not cached or about to expire
 func (cm *CertManager) GetCertificate(hello *tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return cm.GetMirroredCertificate(hello, nil)
+}
+
+// GetMirroredCertificate returns a certificate for the given hostname like
+// GetCertificate. New certificates copy the names, validity and key usage of
+// the certificate returned by upstream, unless it returns nil.
+func (cm *CertManager) GetMirroredCertificate(hello *tls.ClientHelloInfo, upstream func() *x509.Certificate) (*tls.Certificate, error) {
 	hostname := hello.ServerName
 	if hostname == "" {
 		hostname = "localhost"
 	}
-
-	// Check cache first
-	cm.cacheMu.RLock()
-	if cert, ok := cm.cache[hostname]; ok {
-		cm.cacheMu.RUnlock()
+	hostname = cm.certName(hostname)
+
+	// Check cache first, then the cache directory
+	now := time.Now()
+	cert, result := cm.cache.get(hostname, now)
+	if cert == nil {
+		if cert = cm.loadCachedCert(hostname, now); cert != nil {
+			result = certDiskHit
+			cm.cache.add(hostname, cert)
+		}
+	}
+	metrics.RecordCertCacheLookup(result)
+	if cert != nil {
 		return cert, nil
 	}
-	cm.cacheMu.RUnlock()
 
 	// Generate new certificate
-	cert, err := cm.generateCert(hostname)
+	var mirrored *x509.Certificate
+	if upstream != nil {
+		mirrored = upstream()
+	}
+	cert, err := cm.generateCert(hostname, mirrored)
 	if err != nil {
 		return nil, err
 	}
+	metrics.RecordCertGeneration(time.Since(now).Seconds())
 
 	// Cache the generated certificate
-	cm.cacheMu.Lock()
-	cm.cache[hostname] = cert
-	cm.cacheMu.Unlock()
+	cm.cache.add(hostname, cert)
+	if err := cm.storeCachedCert(hostname, cert); err != nil {
+		metrics.RecordTLSError("cert_cache_write")
+	}
 
 	return cert, nil
 }
 
-// generateCert generates a certificate for the given hostname signed by the CA
-func (cm *CertManager) generateCert(hostname string) (*tls.Certificate, error) {
-	// Generate a new RSA key pair for this certificate
-	privKey, err := rsa.GenerateKey(rand.Reader, 2048)
-	if err != nil {
-		return nil, fmt.Errorf("failed to generate key: %w", err)
+// generateCert generates a certificate for the given hostname signed by the
+// CA, mirroring the attributes of upstream if not nil
+func (cm *CertManager) generateCert(hostname string, upstream *x509.Certificate) (*tls.Certificate, error) {
+	// Use the shared key pair, or generate one for this certificate
+	cm.keyMu.RLock()
+	privKey := cm.leafKey
+	cm.keyMu.RUnlock()
+	if privKey == nil {
+		var err error
+		if privKey, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+			return nil, fmt.Errorf("failed to generate key: %w", err)
+		}
 	}
 
 	// Generate serial number
@@ -138,22 +310,30 @@ func (cm *CertManager) generateCert(hostname string) (*tls.Certificate, error) {
 			CommonName:   hostname,
 			Organization: []string{"LLM Secret Interceptor"},
 		},
-		NotBefore:             time.Now().Add(-time.Hour),
-		NotAfter:              time.Now().Add(24 * time.Hour * 365), // 1 year
+		NotBefore:             time.Now().Add(-time.Hour), // tolerate client clock skew
+		NotAfter:              time.Now().Add(cm.validity),
 		KeyUsage:              x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
 		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
 		BasicConstraintsValid: true,
 	}
-
 	// Add hostname as SAN
 	if ip := net.ParseIP(hostname); ip != nil {
 		template.IPAddresses = []net.IP{ip}
 	} else {
 		template.DNSNames = []string{hostname}
 	}
+	if upstream != nil {
+		mirrorAttributes(template, upstream, hostname)
+	}
+
+	ca := cm.authority()
+	if template.NotAfter.After(ca.cert.NotAfter) {
+		// Clients reject certificates outliving their issuer
+		template.NotAfter = ca.cert.NotAfter
+	}
 
 	// Sign the certificate with CA
-	certDER, err := x509.CreateCertificate(rand.Reader, template, cm.caCert, &privKey.PublicKey, cm.caKey)
+	certDER, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &privKey.PublicKey, ca.key)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create certificate: %w", err)
 	}
@@ -168,14 +348,18 @@ func (cm *CertManager) generateCert(hostname string) (*tls.Certificate, error) {
 		return nil, fmt.Errorf("failed to create TLS certificate: %w", err)
 	}
 
+	// Serve the intermediates, so clients trusting the root can verify it
+	tlsCert.Certificate = append(tlsCert.Certificate, ca.chain...)
+
 	return &tlsCert, nil
 }
 
-// GetCACertificate returns the CA certificate in PEM format
+// GetCACertificate returns the certificate clients need to trust in PEM
+// format: the topmost certificate of the CA bundle
 func (cm *CertManager) GetCACertificate() []byte {
 	return pem.EncodeToMemory(&pem.Block{
 		Type:  "CERTIFICATE",
-		Bytes: cm.caCert.Raw,
+		Bytes: cm.authority().anchor.Raw,
 	})
 }
 
@@ -1,11 +1,19 @@
 package proxy
 
 import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
 	"crypto/tls"
 	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"math/big"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 )
 
 func TestGenerateCA(t *testing.T) {
@@ -185,3 +193,207 @@ func TestGetCACertificate(t *testing.T) {
 		t.Error("CA certificate is not in PEM format")
 	}
 }
+
+// newTestCertManager returns a certificate manager for a new CA
+func newTestCertManager(tb testing.TB) *CertManager {
+	tb.Helper()
+	dir := tb.TempDir()
+	certPath, keyPath := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+	if err := GenerateCA(certPath, keyPath); err != nil {
+		tb.Fatalf("GenerateCA failed: %v", err)
+	}
+	cm, err := NewCertManager(certPath, keyPath)
+	if err != nil {
+		tb.Fatalf("NewCertManager failed: %v", err)
+	}
+	return cm
+}
+
+func TestCertManager_SharedKey(t *testing.T) {
+	publicKeys := func(cm *CertManager) (any, any) {
+		t.Helper()
+		a, err := cm.GetCertificate(&tls.ClientHelloInfo{ServerName: "a.example.com"})
+		if err != nil {
+			t.Fatalf("GetCertificate failed: %v", err)
+		}
+		b, err := cm.GetCertificate(&tls.ClientHelloInfo{ServerName: "b.example.com"})
+		if err != nil {
+			t.Fatalf("GetCertificate failed: %v", err)
+		}
+		return a.Leaf.PublicKey, b.Leaf.PublicKey
+	}
+
+	cm := newTestCertManager(t)
+	a, b := publicKeys(cm)
+	if a.(*rsa.PublicKey).Equal(b) {
+		t.Error("certificates share a key without UseSharedKey")
+	}
+
+	cm = newTestCertManager(t)
+	if err := cm.UseSharedKey(); err != nil {
+		t.Fatalf("UseSharedKey failed: %v", err)
+	}
+	a, b = publicKeys(cm)
+	if !a.(*rsa.PublicKey).Equal(b) {
+		t.Error("certificates use different keys with UseSharedKey")
+	}
+}
+
+func BenchmarkCertManager_GenerateCert(b *testing.B) {
+	for _, bb := range []struct {
+		name   string
+		shared bool
+	}{
+		{"per_host_key", false},
+		{"shared_key", true},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			cm := newTestCertManager(b)
+			if bb.shared {
+				if err := cm.UseSharedKey(); err != nil {
+					b.Fatalf("UseSharedKey failed: %v", err)
+				}
+			}
+			for i := 0; b.Loop(); i++ {
+				if _, err := cm.generateCert(fmt.Sprintf("host%d.example.com", i), nil); err != nil {
+					b.Fatalf("generateCert failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// writeIntermediateCA writes a CA bundle of an intermediate issued by a new
+// root, followed by the root, and the intermediate key. It returns the
+// bundle and key paths and the root.
+func writeIntermediateCA(t *testing.T) (string, string, *x509.Certificate) {
+	t.Helper()
+	newCA := func(name string, parent *x509.Certificate, parentKey *rsa.PrivateKey) (*x509.Certificate, *rsa.PrivateKey) {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatalf("GenerateKey failed: %v", err)
+		}
+		template := &x509.Certificate{
+			SerialNumber:          big.NewInt(time.Now().UnixNano()),
+			Subject:               pkix.Name{CommonName: name},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().Add(24 * time.Hour),
+			KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+			BasicConstraintsValid: true,
+			IsCA:                  true,
+		}
+		if parent == nil {
+			parent, parentKey = template, key
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, parent, &key.PublicKey, parentKey)
+		if err != nil {
+			t.Fatalf("CreateCertificate failed: %v", err)
+		}
+		cert, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatalf("ParseCertificate failed: %v", err)
+		}
+		return cert, key
+	}
+	root, rootKey := newCA("Corporate Root", nil, nil)
+	intermediate, key := newCA("Proxy Intermediate", root, rootKey)
+
+	dir := t.TempDir()
+	certPath, keyPath := filepath.Join(dir, "ca.crt"), filepath.Join(dir, "ca.key")
+	bundle := append(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: intermediate.Raw}),
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: root.Raw})...)
+	if err := os.WriteFile(certPath, bundle, 0600); err != nil {
+		t.Fatal(err)
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	if err := os.WriteFile(keyPath, keyPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certPath, keyPath, root
+}
+
+func TestCertManager_IntermediateCA(t *testing.T) {
+	certPath, keyPath, root := writeIntermediateCA(t)
+	cm, err := NewCertManager(certPath, keyPath)
+	if err != nil {
+		t.Fatalf("NewCertManager failed: %v", err)
+	}
+
+	cert, err := cm.GetCertificate(&tls.ClientHelloInfo{ServerName: "api.openai.com"})
+	if err != nil {
+		t.Fatalf("GetCertificate failed: %v", err)
+	}
+	if len(cert.Certificate) != 2 {
+		t.Fatalf("served chain has %d certificates, want leaf and intermediate", len(cert.Certificate))
+	}
+
+	// Clients trusting only the root verify the served chain
+	roots := x509.NewCertPool()
+	roots.AddCert(root)
+	intermediates := x509.NewCertPool()
+	for _, der := range cert.Certificate[1:] {
+		c, err := x509.ParseCertificate(der)
+		if err != nil {
+			t.Fatalf("ParseCertificate failed: %v", err)
+		}
+		intermediates.AddCert(c)
+	}
+	if _, err := cert.Leaf.Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates, DNSName: "api.openai.com"}); err != nil {
+		t.Errorf("served chain does not verify against the root: %v", err)
+	}
+	if cert.Leaf.NotAfter.After(cm.authority().cert.NotAfter) {
+		t.Error("host certificate outlives the intermediate")
+	}
+
+	block, _ := pem.Decode(cm.GetCACertificate())
+	if block == nil || !bytes.Equal(block.Bytes, root.Raw) {
+		t.Error("GetCACertificate() does not return the root")
+	}
+}
+
+func TestParseCAChain_Order(t *testing.T) {
+	certPath, _, _ := writeIntermediateCA(t)
+	data, err := os.ReadFile(certPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ders [][]byte
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		ders = append(ders, block.Bytes)
+	}
+
+	if chain, anchor, err := parseCAChain(ders); err != nil || len(chain) != 1 || !bytes.Equal(anchor.Raw, ders[1]) {
+		t.Errorf("parseCAChain() = %d intermediates, %v; want the intermediate and the root as anchor", len(chain), err)
+	}
+	if _, _, err := parseCAChain([][]byte{ders[1], ders[0]}); err == nil {
+		t.Error("parseCAChain() accepted a bundle in the wrong order")
+	}
+}
+
+func TestCertManager_LeafValidity(t *testing.T) {
+	cm := newTestCertManager(t)
+	validity := func(host string) time.Duration {
+		t.Helper()
+		cert, err := cm.GetCertificate(&tls.ClientHelloInfo{ServerName: host})
+		if err != nil {
+			t.Fatalf("GetCertificate failed: %v", err)
+		}
+		return time.Until(cert.Leaf.NotAfter).Round(time.Hour)
+	}
+
+	if got := validity("default.example.com"); got != defaultLeafValidity {
+		t.Errorf("default validity = %s, want %s", got, defaultLeafValidity)
+	}
+	if err := cm.SetLeafValidity(2 * time.Hour); err != nil {
+		t.Fatalf("SetLeafValidity failed: %v", err)
+	}
+	if got := validity("short.example.com"); got != 2*time.Hour {
+		t.Errorf("validity = %s, want 2h", got)
+	}
+
+	for _, invalid := range []time.Duration{0, -time.Hour, 400 * 24 * time.Hour} {
+		if err := cm.SetLeafValidity(invalid); err == nil {
+			t.Errorf("SetLeafValidity(%s) succeeded", invalid)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package proxy
+
+import (
+	"encoding/hex"
+	"strings"
+)
+
+// traceparentHeader carries the W3C trace context of a request
+const traceparentHeader = "traceparent"
+
+// traceContext identifies the span a client request belongs to
+type traceContext struct {
+	traceID string
+	spanID  string
+}
+
+// parseTraceparent parses a W3C traceparent header value
+// ("00-<trace ID>-<parent span ID>-<flags>")
+func parseTraceparent(value string) (traceContext, bool) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return traceContext{}, false
+	}
+	// Version 00 has exactly four fields; later versions may append more
+	if parts[0] == "00" && len(parts) != 4 {
+		return traceContext{}, false
+	}
+	for _, part := range parts[:4] {
+		if _, err := hex.DecodeString(part); err != nil || strings.ToLower(part) != part {
+			return traceContext{}, false
+		}
+	}
+	if strings.Trim(parts[1], "0") == "" || strings.Trim(parts[2], "0") == "" {
+		return traceContext{}, false
+	}
+	return traceContext{traceID: parts[1], spanID: parts[2]}, true
+}
+
+// traceOf returns the trace context of an in-flight request; it correlates
+// audit events with client traces
+func (s *Server) traceOf(requestID string) (string, string) {
+	if tc, ok := s.traces.Load(requestID); ok {
+		tc := tc.(traceContext)
+		return tc.traceID, tc.spanID
+	}
+	return "", ""
+}
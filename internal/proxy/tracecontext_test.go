@@ -0,0 +1,60 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tc, ok := parseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	if !ok || tc.traceID != "4bf92f3577b34da6a3ce929d0e0e4736" || tc.spanID != "00f067aa0ba902b7" {
+		t.Errorf("parseTraceparent() = %+v, %v", tc, ok)
+	}
+	// Future versions may append fields
+	if _, ok := parseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra"); !ok {
+		t.Error("parseTraceparent() rejected a later version")
+	}
+
+	for _, value := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e47zz-00f067aa0ba902b7-01",
+	} {
+		if tc, ok := parseTraceparent(value); ok {
+			t.Errorf("parseTraceparent(%q) = %+v, want invalid", value, tc)
+		}
+	}
+}
+
+func TestTraceOf(t *testing.T) {
+	var server *Server
+	traced := make(chan [2]string, 1)
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceID, spanID := server.traceOf("req-7")
+		traced <- [2]string{traceID, spanID}
+	}))
+	defer upstream.Close()
+
+	server, client := newInterceptTestProxy(t, upstream, func(*config.Config) {})
+	req, _ := http.NewRequest(http.MethodGet, upstream.URL+"/v1/models", nil)
+	req.Header.Set(requestIDHeader, "req-7")
+	req.Header.Set(traceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("Do() error: %v", err)
+	}
+	_ = resp.Body.Close()
+
+	if got := <-traced; got != [2]string{"4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"} {
+		t.Errorf("traceOf() during the request = %q", got)
+	}
+}
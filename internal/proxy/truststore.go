@@ -0,0 +1,140 @@
+package proxy
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// Trust stores the CA can be installed into
+const (
+	// TrustStoreSystem is the macOS System keychain, the Windows Root store
+	// or the Linux ca-certificates bundle
+	TrustStoreSystem = "system"
+	// TrustStoreNSS are the NSS databases of Firefox, and of Chromium on Linux
+	TrustStoreNSS = "nss"
+)
+
+// caTrustFile names the CA certificate in Linux anchor directories
+const caTrustFile = "llm-secret-interceptor.crt"
+
+// TrustStoreStep is a command installing the CA into a trust store, or the
+// reason the trust store is skipped
+type TrustStoreStep struct {
+	Store   string
+	Command []string
+	Skip    string
+}
+
+// EncodeCA encodes the CA certificate as pem, der or p12
+func EncodeCA(anchor *x509.Certificate, format, p12Password string) ([]byte, error) {
+	switch format {
+	case "pem", "crt":
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: anchor.Raw}), nil
+	case "der", "cer":
+		return anchor.Raw, nil
+	case "p12", "pfx":
+		return EncodePKCS12TrustStore(anchor, caFriendlyName, p12Password)
+	default:
+		return nil, fmt.Errorf("unknown CA format %q, want pem, der or p12", format)
+	}
+}
+
+// TrustStorePlan returns the steps installing the CA certificate at certPath
+// into the given trust stores of the operating system goos. NSS databases
+// are looked up below home; lookPath reports whether a tool is installed.
+func TrustStorePlan(goos, certPath, home string, stores []string, lookPath func(string) (string, error)) []TrustStoreStep {
+	installed := func(tool string) bool {
+		_, err := lookPath(tool)
+		return err == nil
+	}
+
+	var steps []TrustStoreStep
+	if slices.Contains(stores, TrustStoreSystem) {
+		steps = append(steps, systemTrustSteps(goos, certPath, installed)...)
+	}
+	if slices.Contains(stores, TrustStoreNSS) {
+		steps = append(steps, nssTrustSteps(goos, certPath, home, installed)...)
+	}
+	return steps
+}
+
+// systemTrustSteps installs the CA into the trust store of the OS
+func systemTrustSteps(goos, certPath string, installed func(string) bool) []TrustStoreStep {
+	switch goos {
+	case "darwin":
+		return []TrustStoreStep{{Store: TrustStoreSystem, Command: []string{
+			"security", "add-trusted-cert", "-d", "-r", "trustRoot", "-k", "/Library/Keychains/System.keychain", certPath,
+		}}}
+	case "windows":
+		return []TrustStoreStep{{Store: TrustStoreSystem, Command: []string{"certutil", "-addstore", "-f", "Root", certPath}}}
+	case "linux":
+		switch {
+		case installed("update-ca-certificates"):
+			return []TrustStoreStep{
+				{Store: TrustStoreSystem, Command: []string{"cp", certPath, filepath.Join("/usr/local/share/ca-certificates", caTrustFile)}},
+				{Store: TrustStoreSystem, Command: []string{"update-ca-certificates"}},
+			}
+		case installed("update-ca-trust"):
+			return []TrustStoreStep{
+				{Store: TrustStoreSystem, Command: []string{"cp", certPath, filepath.Join("/etc/pki/ca-trust/source/anchors", caTrustFile)}},
+				{Store: TrustStoreSystem, Command: []string{"update-ca-trust"}},
+			}
+		}
+		return []TrustStoreStep{{Store: TrustStoreSystem, Skip: "neither update-ca-certificates nor update-ca-trust is installed"}}
+	default:
+		return []TrustStoreStep{{Store: TrustStoreSystem, Skip: "unsupported operating system " + goos}}
+	}
+}
+
+// nssTrustSteps installs the CA into every NSS database below home
+func nssTrustSteps(goos, certPath, home string, installed func(string) bool) []TrustStoreStep {
+	if goos == "windows" {
+		return []TrustStoreStep{{Store: TrustStoreNSS, Skip: "Firefox on Windows trusts the system store with security.enterprise_roots.enabled"}}
+	}
+	if home == "" {
+		return []TrustStoreStep{{Store: TrustStoreNSS, Skip: "home directory unknown"}}
+	}
+	dbs := nssDatabases(goos, home)
+	if len(dbs) == 0 {
+		return []TrustStoreStep{{Store: TrustStoreNSS, Skip: "no NSS databases found in " + home}}
+	}
+	if !installed("certutil") {
+		return []TrustStoreStep{{Store: TrustStoreNSS, Skip: "certutil is not installed (libnss3-tools or nss-tools)"}}
+	}
+	steps := make([]TrustStoreStep, 0, len(dbs))
+	for _, db := range dbs {
+		steps = append(steps, TrustStoreStep{Store: TrustStoreNSS, Command: []string{
+			"certutil", "-A", "-d", "sql:" + db, "-t", "C,,", "-n", caFriendlyName, "-i", certPath,
+		}})
+	}
+	return steps
+}
+
+// nssDatabases returns the directories of the NSS databases of the user
+// whose home directory is home
+func nssDatabases(goos, home string) []string {
+	patterns := []string{filepath.Join(home, ".pki", "nssdb", "cert9.db")}
+	if goos == "darwin" {
+		patterns = append(patterns, filepath.Join(home, "Library", "Application Support", "Firefox", "Profiles", "*", "cert9.db"))
+	} else {
+		patterns = append(patterns,
+			filepath.Join(home, ".mozilla", "firefox", "*", "cert9.db"),
+			filepath.Join(home, "snap", "firefox", "common", ".mozilla", "firefox", "*", "cert9.db"))
+	}
+
+	var dbs []string
+	for _, pattern := range patterns {
+		// Glob only fails on malformed patterns
+		matches, _ := filepath.Glob(pattern)
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && info.Mode().IsRegular() {
+				dbs = append(dbs, filepath.Dir(match))
+			}
+		}
+	}
+	return dbs
+}
@@ -0,0 +1,125 @@
+package proxy
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func lookPathOf(tools ...string) func(string) (string, error) {
+	return func(tool string) (string, error) {
+		for _, t := range tools {
+			if t == tool {
+				return "/usr/bin/" + tool, nil
+			}
+		}
+		return "", errors.New("not found")
+	}
+}
+
+func TestTrustStorePlan_System(t *testing.T) {
+	testCases := []struct {
+		name  string
+		goos  string
+		tools []string
+		want  string
+	}{
+		{name: "macos", goos: "darwin", want: "security add-trusted-cert -d -r trustRoot -k /Library/Keychains/System.keychain ca.crt"},
+		{name: "windows", goos: "windows", want: "certutil -addstore -f Root ca.crt"},
+		{name: "debian", goos: "linux", tools: []string{"update-ca-certificates"}, want: "cp ca.crt /usr/local/share/ca-certificates/llm-secret-interceptor.crt; update-ca-certificates"},
+		{name: "rhel", goos: "linux", tools: []string{"update-ca-trust"}, want: "cp ca.crt /etc/pki/ca-trust/source/anchors/llm-secret-interceptor.crt; update-ca-trust"},
+		{name: "unknown linux", goos: "linux", want: "skip"},
+		{name: "plan9", goos: "plan9", want: "skip"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			steps := TrustStorePlan(tc.goos, "ca.crt", t.TempDir(), []string{TrustStoreSystem}, lookPathOf(tc.tools...))
+			var commands []string
+			for _, step := range steps {
+				if step.Store != TrustStoreSystem {
+					t.Errorf("step %+v in store %s", step, step.Store)
+				}
+				if step.Skip != "" {
+					commands = append(commands, "skip")
+				} else {
+					commands = append(commands, strings.Join(step.Command, " "))
+				}
+			}
+			if got := strings.Join(commands, "; "); got != tc.want {
+				t.Errorf("plan = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestTrustStorePlan_NSS(t *testing.T) {
+	home := t.TempDir()
+	profile := filepath.Join(home, ".mozilla", "firefox", "abc.default")
+	for _, dir := range []string{profile, filepath.Join(home, ".pki", "nssdb")} {
+		if err := os.MkdirAll(dir, 0o700); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, "cert9.db"), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	steps := TrustStorePlan("linux", "ca.crt", home, []string{TrustStoreNSS}, lookPathOf("certutil"))
+	if len(steps) != 2 {
+		t.Fatalf("steps = %+v, want one per NSS database", steps)
+	}
+	for _, step := range steps {
+		command := strings.Join(step.Command, " ")
+		if !strings.HasPrefix(command, "certutil -A -d sql:"+home) || !strings.HasSuffix(command, "-i ca.crt") {
+			t.Errorf("command = %q", command)
+		}
+	}
+
+	steps = TrustStorePlan("linux", "ca.crt", home, []string{TrustStoreNSS}, lookPathOf())
+	if len(steps) != 1 || !strings.Contains(steps[0].Skip, "certutil") {
+		t.Errorf("without certutil steps = %+v, want a skip", steps)
+	}
+	steps = TrustStorePlan("linux", "ca.crt", t.TempDir(), []string{TrustStoreNSS}, lookPathOf("certutil"))
+	if len(steps) != 1 || steps[0].Skip == "" {
+		t.Errorf("without databases steps = %+v, want a skip", steps)
+	}
+}
+
+func TestEncodeCA(t *testing.T) {
+	dir := t.TempDir()
+	certPath := filepath.Join(dir, "ca.crt")
+	if err := GenerateCA(certPath, filepath.Join(dir, "ca.key")); err != nil {
+		t.Fatalf("GenerateCA() error: %v", err)
+	}
+	anchor, err := LoadCAAnchor(certPath)
+	if err != nil {
+		t.Fatalf("LoadCAAnchor() error: %v", err)
+	}
+
+	pemData, err := EncodeCA(anchor, "pem", "")
+	if err != nil {
+		t.Fatalf("EncodeCA(pem) error: %v", err)
+	}
+	if block, _ := pem.Decode(pemData); block == nil || !bytes.Equal(block.Bytes, anchor.Raw) {
+		t.Error("PEM does not hold the CA certificate")
+	}
+	der, err := EncodeCA(anchor, "der", "")
+	if err != nil {
+		t.Fatalf("EncodeCA(der) error: %v", err)
+	}
+	if cert, err := x509.ParseCertificate(der); err != nil || !cert.Equal(anchor) {
+		t.Errorf("DER does not hold the CA certificate: %v", err)
+	}
+	if p12, err := EncodeCA(anchor, "p12", "secret"); err != nil || len(p12) == 0 {
+		t.Errorf("EncodeCA(p12) = %d bytes, %v", len(p12), err)
+	}
+	if _, err := EncodeCA(anchor, "jks", ""); err == nil {
+		t.Error("EncodeCA(jks) expected error")
+	}
+}
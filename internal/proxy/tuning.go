@@ -0,0 +1,200 @@
+package proxy
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+)
+
+// interceptorsAdminPath is the path of the interceptors admin API
+const interceptorsAdminPath = "/admin/interceptors"
+
+// tunableInterceptors names the interceptors and filters the admin API tunes
+var tunableInterceptors = []string{"entropy", "pattern", "code_context"}
+
+// errUnknownInterceptor marks interceptor names that cannot be tuned
+var errUnknownInterceptor = errors.New("unknown interceptor")
+
+// InterceptorSettings are the settings of an interceptor that can be tuned
+// at runtime. In updates, unset fields are left unchanged; an empty
+// disabled_rules list enables all pattern rules again.
+type InterceptorSettings struct {
+	Enabled           *bool    `json:"enabled,omitempty"`
+	Threshold         *float64 `json:"threshold,omitempty"`
+	MinLength         *int     `json:"min_length,omitempty"`
+	MaxLength         *int     `json:"max_length,omitempty"`
+	DisabledRules     []string `json:"disabled_rules,omitempty"`
+	TrustedConfidence *float64 `json:"trusted_confidence,omitempty"`
+}
+
+// interceptorSettings returns the current settings of the named interceptor
+func interceptorSettings(cfg *config.InterceptorsConfig, name string) (InterceptorSettings, error) {
+	switch name {
+	case "entropy":
+		entropy := cfg.Entropy
+		return InterceptorSettings{
+			Enabled:   &entropy.Enabled,
+			Threshold: &entropy.Threshold,
+			MinLength: &entropy.MinLength,
+			MaxLength: &entropy.MaxLength,
+		}, nil
+	case "pattern":
+		pattern := cfg.Pattern
+		return InterceptorSettings{
+			Enabled:       &pattern.Enabled,
+			DisabledRules: append([]string{}, pattern.DisabledRules...),
+		}, nil
+	case "code_context":
+		codeContext := cfg.CodeContext
+		return InterceptorSettings{
+			Enabled:           &codeContext.Enabled,
+			TrustedConfidence: &codeContext.TrustedConfidence,
+		}, nil
+	}
+	return InterceptorSettings{}, fmt.Errorf("%w %q", errUnknownInterceptor, name)
+}
+
+// applyInterceptorSettings applies the set fields of update to the named
+// interceptor in cfg
+func applyInterceptorSettings(cfg *config.InterceptorsConfig, name string, update InterceptorSettings) error {
+	var unsupported []string
+	switch name {
+	case "entropy":
+		if update.DisabledRules != nil {
+			unsupported = append(unsupported, "disabled_rules")
+		}
+		if update.TrustedConfidence != nil {
+			unsupported = append(unsupported, "trusted_confidence")
+		}
+		entropy := cfg.Entropy
+		if update.Enabled != nil {
+			entropy.Enabled = *update.Enabled
+		}
+		if update.Threshold != nil {
+			entropy.Threshold = *update.Threshold
+		}
+		if update.MinLength != nil {
+			entropy.MinLength = *update.MinLength
+		}
+		if update.MaxLength != nil {
+			entropy.MaxLength = *update.MaxLength
+		}
+		if entropy.Threshold <= 0 {
+			return errors.New("entropy threshold must be positive")
+		}
+		if entropy.MinLength < 1 || entropy.MaxLength < entropy.MinLength {
+			return errors.New("entropy lengths must satisfy 1 <= min_length <= max_length")
+		}
+		cfg.Entropy = entropy
+	case "pattern":
+		if update.Threshold != nil || update.MinLength != nil || update.MaxLength != nil {
+			unsupported = append(unsupported, "threshold and lengths")
+		}
+		if update.TrustedConfidence != nil {
+			unsupported = append(unsupported, "trusted_confidence")
+		}
+		if update.Enabled != nil {
+			cfg.Pattern.Enabled = *update.Enabled
+		}
+		if update.DisabledRules != nil {
+			cfg.Pattern.DisabledRules = append([]string{}, update.DisabledRules...)
+		}
+	case "code_context":
+		if update.Threshold != nil || update.MinLength != nil || update.MaxLength != nil {
+			unsupported = append(unsupported, "threshold and lengths")
+		}
+		if update.DisabledRules != nil {
+			unsupported = append(unsupported, "disabled_rules")
+		}
+		if update.TrustedConfidence != nil {
+			if *update.TrustedConfidence < 0 || *update.TrustedConfidence > 1 {
+				return errors.New("trusted_confidence must be between 0 and 1")
+			}
+			cfg.CodeContext.TrustedConfidence = *update.TrustedConfidence
+		}
+		if update.Enabled != nil {
+			cfg.CodeContext.Enabled = *update.Enabled
+		}
+	default:
+		return fmt.Errorf("%w %q", errUnknownInterceptor, name)
+	}
+	if len(unsupported) > 0 {
+		return fmt.Errorf("%s cannot be set for %s", strings.Join(unsupported, ", "), name)
+	}
+	return nil
+}
+
+// TuneInterceptor applies update to the named interceptor and rebuilds the
+// interceptors for new requests, as a reload would. The change lasts until
+// the configuration is reloaded or the proxy restarts.
+func (s *Server) TuneInterceptor(name string, update InterceptorSettings) (InterceptorSettings, error) {
+	s.tuneMu.Lock()
+	defer s.tuneMu.Unlock()
+
+	next := *s.cfg()
+	if err := applyInterceptorSettings(&next.Interceptors, name, update); err != nil {
+		return InterceptorSettings{}, err
+	}
+	if _, err := s.Reload(&next); err != nil {
+		return InterceptorSettings{}, err
+	}
+	s.logger.Info().Str("interceptor", name).Msg("Interceptor settings changed through the admin API")
+	return interceptorSettings(&next.Interceptors, name)
+}
+
+// InterceptorsAdminHandler serves the interceptors admin API:
+//
+//	GET /admin/interceptors         lists the settings of all interceptors
+//	GET /admin/interceptors/{name}  returns the settings of an interceptor
+//	PUT /admin/interceptors/{name}  changes them with an InterceptorSettings body
+//
+// It must be wrapped with RequireAdmin.
+func (s *Server) InterceptorsAdminHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(strings.TrimPrefix(r.URL.Path, interceptorsAdminPath), "/")
+		if name == "" {
+			if r.Method != http.MethodGet {
+				w.Header().Set("Allow", http.MethodGet)
+				http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+				return
+			}
+			cfg := s.cfg().Interceptors
+			all := make(map[string]InterceptorSettings)
+			for _, name := range tunableInterceptors {
+				all[name], _ = interceptorSettings(&cfg, name)
+			}
+			s.writeAdminJSON(w, all)
+			return
+		}
+
+		var settings InterceptorSettings
+		var err error
+		switch r.Method {
+		case http.MethodGet:
+			cfg := s.cfg().Interceptors
+			settings, err = interceptorSettings(&cfg, name)
+		case http.MethodPut:
+			var update InterceptorSettings
+			if !decodeAdminJSON(w, r, &update) {
+				return
+			}
+			settings, err = s.TuneInterceptor(name, update)
+		default:
+			w.Header().Set("Allow", "GET, PUT")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if errors.Is(err, errUnknownInterceptor) {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		s.writeAdminJSON(w, settings)
+	})
+}
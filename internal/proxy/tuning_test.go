@@ -0,0 +1,101 @@
+package proxy
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/rs/zerolog"
+)
+
+// newInterceptorsTestServer returns a server detecting with the interceptors
+// of the default configuration changed by configure, if not nil
+func newInterceptorsTestServer(t *testing.T, configure func(*config.Config)) *Server {
+	t.Helper()
+	cfg := config.DefaultConfig()
+	if configure != nil {
+		configure(cfg)
+	}
+	interceptors, err := NewInterceptorManager(cfg)
+	if err != nil {
+		t.Fatalf("NewInterceptorManager() error: %v", err)
+	}
+	return &Server{config: cfg, interceptors: interceptors, logger: zerolog.Nop()}
+}
+
+func serveInterceptorsAdmin(server *Server, method, target, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	server.InterceptorsAdminHandler().ServeHTTP(rec, httptest.NewRequest(method, target, strings.NewReader(body)))
+	return rec
+}
+
+func TestInterceptorsAdminHandler_Tune(t *testing.T) {
+	server := newInterceptorsTestServer(t, nil)
+	const secret = "xY9zW8vU7tS6rQ5pO4nM3lK2"
+	if len(server.detector().DetectAll("token "+secret)) == 0 {
+		t.Fatal("entropy interceptor should detect the secret by default")
+	}
+
+	rec := serveInterceptorsAdmin(server, http.MethodPut, "/admin/interceptors/entropy", `{"threshold": 7.5}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var settings InterceptorSettings
+	if err := json.Unmarshal(rec.Body.Bytes(), &settings); err != nil || settings.Threshold == nil || *settings.Threshold != 7.5 {
+		t.Errorf("PUT response = %s, %v", rec.Body.String(), err)
+	}
+	if got := server.cfg().Interceptors.Entropy; got.Threshold != 7.5 || !got.Enabled || got.MinLength != 8 {
+		t.Errorf("entropy config = %+v, want only the threshold changed", got)
+	}
+	if len(server.detector().DetectAll("token "+secret)) != 0 {
+		t.Error("raised threshold not applied to detection")
+	}
+
+	rec = serveInterceptorsAdmin(server, http.MethodPut, "/admin/interceptors/pattern", `{"enabled": true, "disabled_rules": ["github_token"]}`)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("PUT pattern status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if server.detector().Get("pattern") == nil {
+		t.Error("pattern interceptor not enabled")
+	}
+
+	rec = serveInterceptorsAdmin(server, http.MethodGet, "/admin/interceptors", "")
+	var all map[string]InterceptorSettings
+	if err := json.Unmarshal(rec.Body.Bytes(), &all); err != nil || len(all) != 3 {
+		t.Fatalf("GET all = %s, %v", rec.Body.String(), err)
+	}
+	if rules := all["pattern"].DisabledRules; len(rules) != 1 || rules[0] != "github_token" {
+		t.Errorf("pattern disabled rules = %v", rules)
+	}
+}
+
+func TestInterceptorsAdminHandler_Invalid(t *testing.T) {
+	server := newInterceptorsTestServer(t, nil)
+
+	tests := []struct {
+		method, target, body string
+		want                 int
+	}{
+		{http.MethodPut, "/admin/interceptors/bitwarden", `{"enabled": true}`, http.StatusNotFound},
+		{http.MethodGet, "/admin/interceptors/unknown", "", http.StatusNotFound},
+		{http.MethodPut, "/admin/interceptors/entropy", `{"threshold": -1}`, http.StatusBadRequest},
+		{http.MethodPut, "/admin/interceptors/entropy", `{"min_length": 200}`, http.StatusBadRequest},
+		{http.MethodPut, "/admin/interceptors/entropy", `{"disabled_rules": []}`, http.StatusBadRequest},
+		{http.MethodPut, "/admin/interceptors/code_context", `{"trusted_confidence": 2}`, http.StatusBadRequest},
+		{http.MethodPut, "/admin/interceptors/entropy", `{"treshold": 5}`, http.StatusBadRequest},
+		{http.MethodPost, "/admin/interceptors/entropy", `{}`, http.StatusMethodNotAllowed},
+		{http.MethodPut, "/admin/interceptors", `{}`, http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		rec := serveInterceptorsAdmin(server, tt.method, tt.target, tt.body)
+		if rec.Code != tt.want {
+			t.Errorf("%s %s %s: status = %d, want %d", tt.method, tt.target, tt.body, rec.Code, tt.want)
+		}
+	}
+	if got := server.cfg().Interceptors.Entropy; got != config.DefaultConfig().Interceptors.Entropy {
+		t.Errorf("invalid updates changed the entropy settings to %+v", got)
+	}
+}
@@ -0,0 +1,139 @@
+package proxy
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/metrics"
+)
+
+// handleTunnel relays a CONNECT tunnel to its target without TLS
+// interception, so the proxy never sees the traffic
+func (s *Server) handleTunnel(w http.ResponseWriter, r *http.Request) {
+	s.logger.Debug().Str("host", r.Host).Msg("Tunneling without interception")
+
+	dialer := net.Dialer{Timeout: 10 * time.Second}
+	upstreamConn, err := dialer.DialContext(r.Context(), "tcp", r.Host)
+	if err != nil {
+		s.logger.Error().Err(err).Str("host", r.Host).Msg("Failed to connect tunnel")
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	defer func() {
+		if err := upstreamConn.Close(); err != nil {
+			s.logger.Debug().Err(err).Msg("Failed to close tunnel upstream")
+		}
+	}()
+
+	clientConn, clientBuf, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to hijack connection")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer s.trackTunnel(clientConn)()
+	defer func() {
+		if err := clientConn.Close(); err != nil {
+			s.logger.Debug().Err(err).Msg("Failed to close client connection")
+		}
+	}()
+
+	if _, err := clientConn.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		s.logger.Error().Err(err).Msg("Failed to send connection established")
+		return
+	}
+
+	splice(clientConn, clientBuf.Reader, upstreamConn, s.cfg().Proxy.Timeouts.RelayIdle)
+}
+
+// splice copies data between a client and an upstream connection until both
+// directions are done. Data from the client is read from clientReader, which
+// may hold bytes buffered before the connection was hijacked. A clean end of
+// one direction is passed on as a half-close; a failed direction, or idle
+// time without traffic in either direction (0 = no limit), closes both.
+func splice(clientConn net.Conn, clientReader io.Reader, upstreamConn net.Conn, idle time.Duration) {
+	var lastActive atomic.Int64
+	lastActive.Store(time.Now().UnixNano())
+	closeBoth := sync.OnceFunc(func() {
+		_ = clientConn.Close()
+		_ = upstreamConn.Close()
+	})
+
+	done := make(chan struct{}, 2)
+	relay := func(dst net.Conn, src io.Reader, direction string) {
+		_, err := io.Copy(dst, &activityReader{r: &countingReader{r: src, direction: direction}, lastActive: &lastActive})
+		if err != nil {
+			closeBoth()
+		} else if cw, ok := dst.(interface{ CloseWrite() error }); ok {
+			// Propagate the end of the stream, keeping the other direction open
+			_ = cw.CloseWrite()
+		} else {
+			_ = dst.Close()
+		}
+		done <- struct{}{}
+	}
+	go relay(upstreamConn, clientReader, "request")
+	go relay(clientConn, upstreamConn, "response")
+
+	var idleCheck <-chan time.Time
+	if idle > 0 {
+		ticker := time.NewTicker(max(idle/4, 10*time.Millisecond))
+		defer ticker.Stop()
+		idleCheck = ticker.C
+	}
+	for pending := 2; pending > 0; {
+		select {
+		case <-done:
+			pending--
+		case <-idleCheck:
+			if time.Since(time.Unix(0, lastActive.Load())) >= idle {
+				closeBoth()
+			}
+		}
+	}
+}
+
+// activityReader records the time of each successful read
+type activityReader struct {
+	r          io.Reader
+	lastActive *atomic.Int64
+}
+
+func (a *activityReader) Read(p []byte) (int, error) {
+	n, err := a.r.Read(p)
+	if n > 0 {
+		a.lastActive.Store(time.Now().UnixNano())
+	}
+	return n, err
+}
+
+// countingReader counts the bytes read through it as transferred in
+// direction, "request" or "response"
+type countingReader struct {
+	r         io.Reader
+	direction string
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	if n > 0 {
+		metrics.RecordBytesTransferred(c.direction, int64(n))
+	}
+	return n, err
+}
+
+// countBody counts the bytes read from body as transferred in direction;
+// http.NoBody is returned unchanged
+func countBody(body io.ReadCloser, direction string) io.ReadCloser {
+	if body == nil || body == http.NoBody {
+		return body
+	}
+	return struct {
+		io.Reader
+		io.Closer
+	}{&countingReader{r: body, direction: direction}, body}
+}
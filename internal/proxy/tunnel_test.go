@@ -0,0 +1,199 @@
+package proxy
+
+import (
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/rs/zerolog"
+)
+
+func TestHandleConnect_BypassHosts(t *testing.T) {
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "direct")
+	}))
+	defer upstream.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Proxy.BypassHosts = []string{"127.0.0.*"}
+	// No certificate manager: bypassed tunnels are never intercepted
+	proxy := httptest.NewServer(&Server{config: cfg, logger: zerolog.Nop()})
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	transport := upstream.Client().Transport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(proxyURL)
+	client := &http.Client{Transport: transport}
+	defer client.CloseIdleConnections()
+
+	resp, err := client.Get(upstream.URL)
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+
+	if string(body) != "direct" {
+		t.Errorf("body = %q, want %q", body, "direct")
+	}
+	if !resp.TLS.PeerCertificates[0].Equal(upstream.Certificate()) {
+		t.Error("tunnel did not present the upstream certificate")
+	}
+}
+
+func TestHandleConnect_Metrics(t *testing.T) {
+	active := make(chan float64, 1)
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		active <- testutil.ToFloat64(metrics.ActiveConnections)
+		_, _ = io.WriteString(w, "direct")
+	}))
+	defer upstream.Close()
+
+	cfg := config.DefaultConfig()
+	cfg.Proxy.BypassHosts = []string{"127.0.0.*"}
+	proxy := httptest.NewServer(&Server{config: cfg, logger: zerolog.Nop()})
+	defer proxy.Close()
+	proxyURL, _ := url.Parse(proxy.URL)
+
+	transport := upstream.Client().Transport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyURL(proxyURL)
+	client := &http.Client{Transport: transport}
+
+	sent := testutil.ToFloat64(metrics.BytesTransferred.WithLabelValues("request"))
+	received := testutil.ToFloat64(metrics.BytesTransferred.WithLabelValues("response"))
+	resp, err := client.Get(upstream.URL)
+	if err != nil {
+		t.Fatalf("Get() error: %v", err)
+	}
+	_, _ = io.ReadAll(resp.Body)
+	_ = resp.Body.Close()
+
+	// Tunnels of earlier tests may still be closing
+	during := <-active
+	if during < 1 {
+		t.Errorf("active connections during the tunnel = %v, want at least 1", during)
+	}
+	if got := testutil.ToFloat64(metrics.BytesTransferred.WithLabelValues("request")); got <= sent {
+		t.Error("bytes sent through the tunnel not counted")
+	}
+	if got := testutil.ToFloat64(metrics.BytesTransferred.WithLabelValues("response")); got <= received {
+		t.Error("bytes received through the tunnel not counted")
+	}
+
+	client.CloseIdleConnections()
+	deadline := time.Now().Add(2 * time.Second)
+	for testutil.ToFloat64(metrics.ActiveConnections) > during-1 {
+		if time.Now().After(deadline) {
+			t.Fatalf("active connections after the tunnel closed = %v, want at most %v", testutil.ToFloat64(metrics.ActiveConnections), during-1)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSplice_IdleTimeout(t *testing.T) {
+	client, clientPeer := net.Pipe()
+	upstream, upstreamPeer := net.Pipe()
+	defer clientPeer.Close()
+	defer upstreamPeer.Close()
+
+	done := make(chan struct{})
+	go func() {
+		splice(client, client, upstream, 50*time.Millisecond)
+		close(done)
+	}()
+
+	// Traffic keeps the tunnel open
+	go func() { _, _ = io.Copy(io.Discard, upstreamPeer) }()
+	for range 4 {
+		if _, err := clientPeer.Write([]byte("ping")); err != nil {
+			t.Fatalf("Write() error: %v", err)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	select {
+	case <-done:
+		t.Fatal("active tunnel was closed")
+	default:
+	}
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("idle tunnel was not closed")
+	}
+}
+
+func TestSplice_HalfClose(t *testing.T) {
+	clientLn, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clientLn.Close()
+	upstreamLn, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer upstreamLn.Close()
+
+	clientPeer, err := net.Dial("tcp", clientLn.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer clientPeer.Close()
+	client, _ := clientLn.Accept()
+	upstream, err := net.Dial("tcp", upstreamLn.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	upstreamPeer, _ := upstreamLn.Accept()
+	defer upstreamPeer.Close()
+
+	go splice(client, client, upstream, 0)
+
+	// The client finishes its request; the response still arrives
+	_, _ = clientPeer.Write([]byte("request"))
+	_ = clientPeer.(*net.TCPConn).CloseWrite()
+	got, _ := io.ReadAll(upstreamPeer)
+	if string(got) != "request" {
+		t.Errorf("upstream read %q, want request", got)
+	}
+	_, _ = upstreamPeer.Write([]byte("response"))
+	_ = upstreamPeer.Close()
+	got, _ = io.ReadAll(clientPeer)
+	if string(got) != "response" {
+		t.Errorf("client read %q, want response", got)
+	}
+}
+
+func TestClientTunnels(t *testing.T) {
+	tunnels := newClientTunnels(2)
+	release1, err := tunnels.acquire("10.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tunnels.acquire("10.0.0.1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tunnels.acquire("10.0.0.1"); err != errTooManyTunnels {
+		t.Errorf("acquire() error = %v, want errTooManyTunnels", err)
+	}
+	if _, err := tunnels.acquire("10.0.0.2"); err != nil {
+		t.Errorf("other client rejected: %v", err)
+	}
+	release1()
+	release1()
+	if _, err := tunnels.acquire("10.0.0.1"); err != nil {
+		t.Errorf("acquire() after release error: %v", err)
+	}
+
+	if newClientTunnels(0) != nil {
+		t.Error("newClientTunnels(0) should disable the cap")
+	}
+}
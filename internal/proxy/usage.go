@@ -0,0 +1,43 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/hfi/llm-secret-interceptor/internal/storage"
+)
+
+// MappingUsageStats aggregates how often the proxy's mappings were restored
+func (s *Server) MappingUsageStats(ctx context.Context) (storage.UsageStats, error) {
+	stats, err := storage.CollectUsageStats(ctx, s.store)
+	if err != nil {
+		return stats, fmt.Errorf("failed to collect usage stats: %w", err)
+	}
+	return stats, nil
+}
+
+// UsageHandler serves the mapping usage stats as JSON. The stats are
+// aggregates and contain no secrets or placeholders.
+func (s *Server) UsageHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		stats, err := s.MappingUsageStats(r.Context())
+		if err != nil {
+			s.logger.Error().Err(err).Msg("Failed to collect usage stats")
+			http.Error(w, "failed to collect usage stats", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(stats); err != nil {
+			s.logger.Debug().Err(err).Msg("Failed to write usage stats")
+		}
+	})
+}
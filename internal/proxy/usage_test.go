@@ -0,0 +1,71 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+	"github.com/hfi/llm-secret-interceptor/internal/protocol"
+	"github.com/hfi/llm-secret-interceptor/internal/storage"
+	"github.com/rs/zerolog"
+)
+
+func TestSecretService_RecordsRestores(t *testing.T) {
+	service := setupTestService()
+	defer service.GetStore().Close()
+	handler := protocol.NewOpenAIHandler()
+
+	secret := "xY9zW8vU7tS6rQ5pO4nM3lK2"
+	service.ProcessRequest(context.Background(), []byte(`{"model":"gpt-4","messages":[{"role":"user","content":"The password is `+secret+` ok"}]}`), handler)
+	ph, found, _ := service.GetStore().LookupBySecret(context.Background(), secret)
+	if !found {
+		t.Fatal("secret not stored")
+	}
+
+	response := []byte(`{"choices":[{"index":0,"message":{"role":"assistant","content":"Noted ` + ph + ` and ` + ph + `"}}]}`)
+	service.ProcessResponse(context.Background(), response, handler)
+
+	mapping, _, _ := service.GetStore().LookupMapping(context.Background(), ph)
+	if mapping == nil || mapping.RestoreCount == 0 || mapping.LastRestored.IsZero() {
+		t.Errorf("restore not recorded: %+v", mapping)
+	}
+}
+
+func TestServer_UsageHandler(t *testing.T) {
+	store, _, err := newMappingStore(context.Background(), config.DefaultConfig().Storage)
+	if err != nil {
+		t.Fatalf("newMappingStore() error: %v", err)
+	}
+	defer store.Close()
+	server := &Server{config: config.DefaultConfig(), store: store, logger: zerolog.Nop()}
+
+	_ = store.Store(context.Background(), "__SECRET_1__", "secret1", storage.Metadata{SecretType: "api_key"})
+	_ = storage.RecordRestore(context.Background(), store, "__SECRET_1__")
+
+	rec := httptest.NewRecorder()
+	server.UsageHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/admin/usage", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "secret1") || strings.Contains(rec.Body.String(), "__SECRET_1__") {
+		t.Error("usage stats leak mappings")
+	}
+
+	var stats storage.UsageStats
+	if err := json.Unmarshal(rec.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if stats.Mappings != 1 || stats.Restores != 1 || stats.MostRestoredTypes[0] != "api_key" {
+		t.Errorf("stats = %+v", stats)
+	}
+
+	rec = httptest.NewRecorder()
+	server.UsageHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/admin/usage", nil))
+	if rec.Code != http.StatusMethodNotAllowed {
+		t.Errorf("POST status = %d, want 405", rec.Code)
+	}
+}
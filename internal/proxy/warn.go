@@ -0,0 +1,75 @@
+package proxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+
+	"github.com/hfi/llm-secret-interceptor/internal/protocol"
+)
+
+// maskedHeader reports the number of secrets masked in a request
+const maskedHeader = "X-Secrets-Masked"
+
+type maskedCountKey struct{}
+
+// withMaskedCount returns a copy of req counting the secrets masked in it
+func withMaskedCount(req *http.Request) *http.Request {
+	return req.WithContext(context.WithValue(req.Context(), maskedCountKey{}, new(atomic.Int64)))
+}
+
+// addMasked adds n masked secrets to the count of the request of ctx
+func addMasked(ctx context.Context, n int) {
+	if count, ok := ctx.Value(maskedCountKey{}).(*atomic.Int64); ok {
+		count.Add(int64(n))
+	}
+}
+
+// maskedCount returns the number of secrets masked in the request of ctx
+func maskedCount(ctx context.Context) int {
+	if count, ok := ctx.Value(maskedCountKey{}).(*atomic.Int64); ok {
+		return int(count.Load())
+	}
+	return 0
+}
+
+// maskedNotice is the notice appended to replies
+func maskedNotice(n int) string {
+	if n == 1 {
+		return "\n\n[llm-secret-interceptor] 1 secret was masked before sending this request."
+	}
+	return fmt.Sprintf("\n\n[llm-secret-interceptor] %d secrets were masked before sending this request.", n)
+}
+
+// warnMasked sets the masked secret header on resp when configured
+func (s *Server) warnMasked(ctx context.Context, resp *http.Response) {
+	if n := maskedCount(ctx); n > 0 && s.cfg().Policy.Warn.Header {
+		resp.Header.Set(maskedHeader, strconv.Itoa(n))
+	}
+}
+
+// appendNotice appends the masked secret notice to the assistant message of
+// a response body, returning body unchanged when it has none
+func (s *Server) appendNotice(ctx context.Context, resp *http.Response, body []byte) []byte {
+	n := maskedCount(ctx)
+	if n == 0 || !s.cfg().Policy.Warn.Notice || resp.StatusCode/100 != 2 || resp.Request == nil {
+		return body
+	}
+	handler := s.registry.Detect(resp.Request)
+	if handler == nil {
+		return body
+	}
+	msg, err := handler.ParseResponse(body)
+	if err != nil || len(msg.Messages) == 0 {
+		return body
+	}
+	msg.Messages[0] = protocol.Message{Role: msg.Messages[0].Role, Content: msg.Messages[0].Content + maskedNotice(n)}
+	annotated, err := handler.SerializeResponse(msg)
+	if err != nil {
+		s.logger.Debug().Err(err).Msg("Failed to append masked secret notice")
+		return body
+	}
+	return annotated
+}
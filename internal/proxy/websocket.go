@@ -0,0 +1,309 @@
+package proxy
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/storage"
+)
+
+// WebSocket opcodes (RFC 6455, section 5.2)
+const (
+	wsContinuation = 0x0
+	wsText         = 0x1
+)
+
+// maxWebSocketMessage limits the size of inspected text messages
+const maxWebSocketMessage = 16 << 20
+
+// errWebSocketMessageTooLarge is returned for inspected messages above
+// maxWebSocketMessage
+var errWebSocketMessageTooLarge = errors.New("websocket message too large")
+
+// isWebSocketUpgrade reports whether req opens a WebSocket connection
+func isWebSocketUpgrade(req *http.Request) bool {
+	return headerHasToken(req.Header, "Connection", "upgrade") &&
+		strings.EqualFold(req.Header.Get("Upgrade"), "websocket")
+}
+
+// headerHasToken reports whether the comma-separated header key contains token
+func headerHasToken(h http.Header, key, token string) bool {
+	for _, value := range h.Values(key) {
+		for _, t := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(t), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// handleWebSocket forwards a WebSocket handshake upstream and splices both
+// connections once it succeeds. Text messages are scanned for secrets on
+// hosts listed in proxy.websocket.inspect_hosts.
+func (s *Server) handleWebSocket(w http.ResponseWriter, req *http.Request, store storage.MappingStore) {
+	// The hijacked connection outlives the server that accepted it
+	s.tunnels.Add(1)
+	defer s.tunnels.Done()
+	inspect := matchHost(s.cfg().Proxy.WebSocket.InspectHosts, req.URL.Host)
+	if inspect {
+		// Compressed messages cannot be inspected
+		req.Header.Del("Sec-WebSocket-Extensions")
+	}
+
+	resp, err := s.upstream().RoundTrip(req)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to open WebSocket upstream")
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		// Handshake refused, relay the response
+		defer func() {
+			if err := resp.Body.Close(); err != nil {
+				s.logger.Debug().Err(err).Msg("Failed to close response body")
+			}
+		}()
+		if err := s.writeResponse(w, resp, time.Time{}); err != nil {
+			s.logger.Debug().Err(err).Msg("Failed to write response")
+		}
+		return
+	}
+	upstreamConn, ok := resp.Body.(io.ReadWriteCloser)
+	if !ok {
+		http.Error(w, "upstream connection not upgradable", http.StatusBadGateway)
+		return
+	}
+	defer func() {
+		if err := upstreamConn.Close(); err != nil {
+			s.logger.Debug().Err(err).Msg("Failed to close WebSocket upstream")
+		}
+	}()
+
+	clientConn, clientBuf, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to hijack WebSocket connection")
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer s.trackTunnel(clientConn)()
+	defer func() {
+		if err := clientConn.Close(); err != nil {
+			s.logger.Debug().Err(err).Msg("Failed to close WebSocket client")
+		}
+	}()
+
+	// Complete the handshake with the client
+	if _, err := fmt.Fprintf(clientBuf, "HTTP/1.1 %s\r\n", resp.Status); err != nil {
+		return
+	}
+	if err := resp.Header.Write(clientBuf); err != nil {
+		return
+	}
+	if _, err := clientBuf.WriteString("\r\n"); err != nil {
+		return
+	}
+	if err := clientBuf.Flush(); err != nil {
+		s.logger.Debug().Err(err).Msg("Failed to complete WebSocket handshake")
+		return
+	}
+	s.logger.Debug().Str("host", req.URL.Host).Bool("inspect", inspect).Msg("WebSocket connection established")
+
+	// Splice both directions; the first to end closes the connection
+	fromClient := &countingReader{r: clientBuf.Reader, direction: "request"}
+	fromUpstream := &countingReader{r: upstreamConn, direction: "response"}
+	var toUpstream, toClient func() error
+	if inspect {
+		ctx := req.Context()
+		toUpstream = func() error {
+			return pumpWebSocket(upstreamConn, fromClient, true, func(text string) (string, error) {
+				masked, _, err := s.maskSecrets(ctx, ctx, store, text, req.URL.Host)
+				return masked, err
+			})
+		}
+		toClient = func() error {
+			return pumpWebSocket(clientConn, fromUpstream, false, func(text string) (string, error) {
+				return s.restoreText(ctx, store, text)
+			})
+		}
+	} else {
+		toUpstream = func() error {
+			_, err := io.Copy(upstreamConn, fromClient)
+			return err
+		}
+		toClient = func() error {
+			_, err := io.Copy(clientConn, fromUpstream)
+			return err
+		}
+	}
+	done := make(chan error, 2)
+	go func() { done <- toUpstream() }()
+	go func() { done <- toClient() }()
+	if err := <-done; err != nil && !errors.Is(err, io.EOF) {
+		s.logger.Debug().Err(err).Msg("WebSocket connection closed")
+	}
+}
+
+// restoreText restores placeholders in text; in fail-closed mode store
+// failures are returned as errors
+func (s *Server) restoreText(ctx context.Context, store storage.MappingStore, text string) (string, error) {
+	restore := newRestorer(ctx, store)
+	restored := s.placeholder.RestorePlaceholders(text, restore.lookup)
+	s.auditRestored(ctx, restore)
+	if restore.err != nil {
+		s.logger.Error().Err(restore.err).Msg("Failed to restore placeholders")
+		if s.rejectFailure(stageRestore) {
+			return "", restore.err
+		}
+	}
+	return restored, nil
+}
+
+// wsFrame is a single WebSocket frame
+type wsFrame struct {
+	fin     bool
+	rsv     byte
+	opcode  byte
+	masked  bool
+	mask    [4]byte
+	payload []byte
+}
+
+// pumpWebSocket copies frames from src to dst, passing complete text
+// messages through transform. Fragmented text messages are reassembled and
+// sent as a single frame; other frames are copied unchanged. Frames sent by
+// clients are masked.
+func pumpWebSocket(dst io.Writer, src io.Reader, mask bool, transform func(string) (string, error)) error {
+	var message []byte
+	inText := false
+	for {
+		f, err := readWebSocketFrame(src)
+		if err != nil {
+			return err
+		}
+
+		// Compressed (RSV1) messages are not inspected
+		if f.opcode == wsText && f.rsv == 0 || f.opcode == wsContinuation && inText {
+			message = append(message, f.payload...)
+			if len(message) > maxWebSocketMessage {
+				return errWebSocketMessageTooLarge
+			}
+			if inText = !f.fin; inText {
+				continue
+			}
+			text, err := transform(string(message))
+			if err != nil {
+				return err
+			}
+			message = nil
+			f = &wsFrame{fin: true, opcode: wsText, masked: mask, payload: []byte(text)}
+			if mask {
+				if _, err := rand.Read(f.mask[:]); err != nil {
+					return fmt.Errorf("failed to generate mask: %w", err)
+				}
+			}
+		}
+
+		if err := writeWebSocketFrame(dst, f); err != nil {
+			return err
+		}
+	}
+}
+
+// readWebSocketFrame reads a frame and unmasks its payload
+func readWebSocketFrame(r io.Reader) (*wsFrame, error) {
+	var header [2]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	f := &wsFrame{
+		fin:    header[0]&0x80 != 0,
+		rsv:    header[0] & 0x70,
+		opcode: header[0] & 0x0f,
+		masked: header[1]&0x80 != 0,
+	}
+
+	length := uint64(header[1] & 0x7f)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > maxWebSocketMessage {
+		return nil, errWebSocketMessageTooLarge
+	}
+
+	if f.masked {
+		if _, err := io.ReadFull(r, f.mask[:]); err != nil {
+			return nil, err
+		}
+	}
+	f.payload = make([]byte, length)
+	if _, err := io.ReadFull(r, f.payload); err != nil {
+		return nil, err
+	}
+	if f.masked {
+		maskBytes(f.mask, f.payload)
+	}
+	return f, nil
+}
+
+// writeWebSocketFrame writes f, masking its payload if f.masked is set
+func writeWebSocketFrame(w io.Writer, f *wsFrame) error {
+	buf := make([]byte, 0, 14+len(f.payload))
+	b0 := f.rsv | f.opcode
+	if f.fin {
+		b0 |= 0x80
+	}
+	var b1 byte
+	if f.masked {
+		b1 = 0x80
+	}
+
+	switch n := len(f.payload); {
+	case n < 126:
+		buf = append(buf, b0, b1|byte(n))
+	case n <= 0xffff:
+		buf = append(buf, b0, b1|126)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(n))
+	default:
+		buf = append(buf, b0, b1|127)
+		buf = binary.BigEndian.AppendUint64(buf, uint64(n))
+	}
+
+	start := len(buf)
+	if f.masked {
+		buf = append(buf, f.mask[:]...)
+		start += 4
+	}
+	buf = append(buf, f.payload...)
+	if f.masked {
+		maskBytes(f.mask, buf[start:])
+	}
+
+	_, err := w.Write(buf)
+	return err
+}
+
+// maskBytes applies the WebSocket masking key to b in place
+func maskBytes(key [4]byte, b []byte) {
+	for i := range b {
+		b[i] ^= key[i%4]
+	}
+}
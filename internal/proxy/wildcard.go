@@ -0,0 +1,42 @@
+package proxy
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// SetWildcardDomains issues one wildcard certificate, e.g. for
+// *.openai.azure.com, to all hosts directly below one of domains instead of
+// a certificate per host
+func (cm *CertManager) SetWildcardDomains(domains []string) error {
+	normalized := make([]string, 0, len(domains))
+	for _, domain := range domains {
+		domain = strings.ToLower(strings.TrimSuffix(strings.TrimPrefix(domain, "*."), "."))
+		// Clients reject wildcards directly below a top-level domain
+		if strings.Count(domain, ".") < 1 || strings.ContainsAny(domain, "*/:") {
+			return fmt.Errorf("invalid wildcard domain %q", domain)
+		}
+		normalized = append(normalized, domain)
+	}
+	cm.wildcardDomains = normalized
+	return nil
+}
+
+// certName returns the name of the certificate covering hostname: a
+// wildcard for hosts below a wildcard domain, hostname otherwise
+func (cm *CertManager) certName(hostname string) string {
+	if len(cm.wildcardDomains) == 0 || net.ParseIP(hostname) != nil {
+		return hostname
+	}
+	label, parent, ok := strings.Cut(strings.ToLower(hostname), ".")
+	if !ok || label == "" {
+		return hostname
+	}
+	for _, domain := range cm.wildcardDomains {
+		if parent == domain {
+			return "*." + domain
+		}
+	}
+	return hostname
+}
@@ -0,0 +1,55 @@
+package proxy
+
+import (
+	"crypto/tls"
+	"testing"
+)
+
+func TestCertManager_WildcardDomains(t *testing.T) {
+	cm := newTestCertManager(t)
+	if err := cm.SetWildcardDomains([]string{"*.OpenAI.Azure.com"}); err != nil {
+		t.Fatalf("SetWildcardDomains failed: %v", err)
+	}
+
+	for host, want := range map[string]string{
+		"east.openai.azure.com":   "*.openai.azure.com",
+		"West.OpenAI.Azure.com":   "*.openai.azure.com",
+		"a.b.openai.azure.com":    "a.b.openai.azure.com",
+		"openai.azure.com":        "openai.azure.com",
+		"api.openai.com":          "api.openai.com",
+		"127.0.0.1":               "127.0.0.1",
+		"evilopenai.azure.com":    "evilopenai.azure.com",
+		"east.openai.azure.com.x": "east.openai.azure.com.x",
+	} {
+		if got := cm.certName(host); got != want {
+			t.Errorf("certName(%q) = %q, want %q", host, got, want)
+		}
+	}
+
+	east, err := cm.GetCertificate(&tls.ClientHelloInfo{ServerName: "east.openai.azure.com"})
+	if err != nil {
+		t.Fatalf("GetCertificate failed: %v", err)
+	}
+	west, err := cm.GetCertificate(&tls.ClientHelloInfo{ServerName: "west.openai.azure.com"})
+	if err != nil {
+		t.Fatalf("GetCertificate failed: %v", err)
+	}
+	if east != west {
+		t.Error("hosts below a wildcard domain got separate certificates")
+	}
+	if err := west.Leaf.VerifyHostname("west.openai.azure.com"); err != nil {
+		t.Errorf("wildcard certificate does not cover host: %v", err)
+	}
+	if cm.cache.len() != 1 {
+		t.Errorf("cache holds %d certificates, want 1", cm.cache.len())
+	}
+}
+
+func TestCertManager_WildcardDomainsInvalid(t *testing.T) {
+	cm := newTestCertManager(t)
+	for _, domain := range []string{"com", "*.com", "a.*.example.com", ""} {
+		if err := cm.SetWildcardDomains([]string{domain}); err == nil {
+			t.Errorf("SetWildcardDomains(%q) succeeded", domain)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+package ruletest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"regexp/syntax"
+	"unicode"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+)
+
+// Backtracking risk levels. The proxy matches with RE2, which runs in
+// linear time; the risk applies when a rule is shared with backtracking
+// engines such as PCRE, Java or JavaScript.
+const (
+	RiskLow    = "low"
+	RiskMedium = "medium"
+	RiskHigh   = "high"
+)
+
+// SampleMatch is a match of a pattern in a sample string
+type SampleMatch struct {
+	// Sample is the index of the sample
+	Sample int    `json:"sample"`
+	Start  int    `json:"start"`
+	End    int    `json:"end"`
+	Value  string `json:"value"`
+}
+
+// RuleCheck is the result of checking a pattern against sample strings
+type RuleCheck struct {
+	Name    string `json:"name,omitempty"`
+	Pattern string `json:"pattern"`
+	// Error is set when the pattern does not compile
+	Error string `json:"error,omitempty"`
+	// Risk estimates the catastrophic backtracking risk of the pattern
+	Risk string `json:"risk,omitempty"`
+	// Warnings explain the risk and point out likely mistakes
+	Warnings []string      `json:"warnings,omitempty"`
+	Matches  []SampleMatch `json:"matches"`
+}
+
+// CheckReport is the result of checking patterns against sample strings
+type CheckReport struct {
+	Samples []string    `json:"samples"`
+	Rules   []RuleCheck `json:"rules"`
+}
+
+// Failed reports whether a pattern does not compile
+func (r *CheckReport) Failed() bool {
+	for _, rule := range r.Rules {
+		if rule.Error != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckPattern compiles pattern, estimates its backtracking risk and
+// matches it against samples
+func CheckPattern(pattern string, samples []string) RuleCheck {
+	check := RuleCheck{Pattern: pattern, Matches: []SampleMatch{}}
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+	parsed, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+
+	level := 0
+	analyze(parsed.Simplify(), func(l int, warning string) {
+		level = max(level, l)
+		check.Warnings = append(check.Warnings, warning)
+	})
+	check.Risk = []string{RiskLow, RiskMedium, RiskHigh}[level]
+	if compiled.MatchString("") {
+		check.Warnings = append(check.Warnings, "pattern matches the empty string")
+	}
+
+	for i, sample := range samples {
+		for _, match := range compiled.FindAllStringIndex(sample, -1) {
+			check.Matches = append(check.Matches, SampleMatch{
+				Sample: i,
+				Start:  match[0],
+				End:    match[1],
+				Value:  sample[match[0]:match[1]],
+			})
+		}
+	}
+	return check
+}
+
+// CheckRules checks the pattern of every rule against samples and points
+// out rules the admin API would refuse
+func CheckRules(rules []config.PatternRuleConfig, samples []string) *CheckReport {
+	report := &CheckReport{Samples: samples}
+	for _, rule := range rules {
+		check := CheckPattern(rule.Pattern, samples)
+		check.Name = rule.Name
+		if rule.Name == "" || rule.Type == "" {
+			check.Warnings = append(check.Warnings, "rule name and type are required")
+		}
+		if rule.Confidence <= 0 || rule.Confidence > 1 {
+			check.Warnings = append(check.Warnings, "rule confidence must be in (0, 1]")
+		}
+		report.Rules = append(report.Rules, check)
+	}
+	return report
+}
+
+// analyze walks a simplified expression and reports constructs that
+// backtracking engines may take exponential or polynomial time on
+func analyze(re *syntax.Regexp, warn func(level int, warning string)) {
+	switch re.Op {
+	case syntax.OpStar, syntax.OpPlus:
+		sub := re.Sub[0]
+		if containsRepeat(sub) {
+			warn(2, fmt.Sprintf("nested quantifier in %s", re))
+		} else if alt := findAlternate(sub); alt != nil && overlappingBranches(alt) {
+			warn(2, fmt.Sprintf("repeated alternation with overlapping branches in %s", re))
+		}
+	case syntax.OpConcat:
+		var previous *syntax.Regexp
+		for _, sub := range re.Sub {
+			switch {
+			case sub.Op == syntax.OpStar || sub.Op == syntax.OpPlus:
+				if previous != nil && overlaps(firstRunes(previous.Sub[0]), firstRunes(sub.Sub[0])) {
+					warn(1, fmt.Sprintf("adjacent quantifiers %s and %s can match the same text", previous, sub))
+				}
+				previous = sub
+			case sub.Op != syntax.OpQuest && !emptyWidth(sub):
+				previous = nil
+			}
+		}
+	}
+	for _, sub := range re.Sub {
+		analyze(sub, warn)
+	}
+}
+
+// containsRepeat reports whether re contains an unbounded quantifier
+func containsRepeat(re *syntax.Regexp) bool {
+	if re.Op == syntax.OpStar || re.Op == syntax.OpPlus {
+		return true
+	}
+	for _, sub := range re.Sub {
+		if containsRepeat(sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// findAlternate returns the first alternation in re, if any
+func findAlternate(re *syntax.Regexp) *syntax.Regexp {
+	if re.Op == syntax.OpAlternate {
+		return re
+	}
+	for _, sub := range re.Sub {
+		if alt := findAlternate(sub); alt != nil {
+			return alt
+		}
+	}
+	return nil
+}
+
+// overlappingBranches reports whether two branches of an alternation can
+// start with the same rune
+func overlappingBranches(alt *syntax.Regexp) bool {
+	for i := range alt.Sub {
+		for j := i + 1; j < len(alt.Sub); j++ {
+			if overlaps(firstRunes(alt.Sub[i]), firstRunes(alt.Sub[j])) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// emptyWidth reports whether re is an assertion that consumes no text
+func emptyWidth(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText,
+		syntax.OpEndText, syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return true
+	}
+	return false
+}
+
+// anyRune is the rune range matching every rune
+var anyRune = []rune{0, unicode.MaxRune}
+
+// firstRunes returns the ranges of runes re can start with, as lo-hi pairs.
+// It errs on the side of too many runes.
+func firstRunes(re *syntax.Regexp) []rune {
+	switch re.Op {
+	case syntax.OpLiteral:
+		if len(re.Rune) == 0 {
+			return anyRune
+		}
+		r := re.Rune[0]
+		runes := []rune{r, r}
+		if re.Flags&syntax.FoldCase != 0 {
+			for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+				runes = append(runes, f, f)
+			}
+		}
+		return runes
+	case syntax.OpCharClass:
+		return re.Rune
+	case syntax.OpCapture, syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		return firstRunes(re.Sub[0])
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if !emptyWidth(sub) {
+				if sub.Op == syntax.OpStar || sub.Op == syntax.OpQuest {
+					// The next element may start the match as well
+					return anyRune
+				}
+				return firstRunes(sub)
+			}
+		}
+		return anyRune
+	case syntax.OpAlternate:
+		var runes []rune
+		for _, sub := range re.Sub {
+			runes = append(runes, firstRunes(sub)...)
+		}
+		return runes
+	default:
+		return anyRune
+	}
+}
+
+// overlaps reports whether two lists of rune ranges intersect
+func overlaps(a, b []rune) bool {
+	for i := 0; i+1 < len(a); i += 2 {
+		for j := 0; j+1 < len(b); j += 2 {
+			if a[i] <= b[j+1] && b[j] <= a[i+1] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WriteText writes a human-readable report
+func (r *CheckReport) WriteText(w io.Writer) error {
+	for i, rule := range r.Rules {
+		if i > 0 {
+			fmt.Fprintln(w)
+		}
+		if rule.Name != "" {
+			fmt.Fprintf(w, "Rule %s: %s\n", rule.Name, rule.Pattern)
+		} else {
+			fmt.Fprintf(w, "Pattern: %s\n", rule.Pattern)
+		}
+		if rule.Error != "" {
+			fmt.Fprintf(w, "  ERROR %s\n", rule.Error)
+			continue
+		}
+		fmt.Fprintf(w, "  Backtracking risk: %s\n", rule.Risk)
+		for _, warning := range rule.Warnings {
+			fmt.Fprintf(w, "  WARNING %s\n", warning)
+		}
+		fmt.Fprintf(w, "  Matches: %d of %d samples\n", matchedSamples(rule.Matches), len(r.Samples))
+		for _, match := range rule.Matches {
+			fmt.Fprintf(w, "    sample %d [%d:%d] %q\n", match.Sample+1, match.Start, match.End, match.Value)
+		}
+	}
+	return nil
+}
+
+// matchedSamples counts the samples with at least one match
+func matchedSamples(matches []SampleMatch) int {
+	count := 0
+	for i, match := range matches {
+		if i == 0 || matches[i-1].Sample != match.Sample {
+			count++
+		}
+	}
+	return count
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *CheckReport) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,99 @@
+package ruletest
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/hfi/llm-secret-interceptor/internal/config"
+)
+
+func TestCheckPattern_Risk(t *testing.T) {
+	testCases := []struct {
+		pattern string
+		want    string
+	}{
+		{pattern: `ghp_[A-Za-z0-9]{36}`, want: RiskLow},
+		{pattern: `\bsk-[a-z]+-[0-9]+\b`, want: RiskLow},
+		{pattern: `(?:foo|bar)+`, want: RiskLow},
+		{pattern: `\d+\w+`, want: RiskMedium},
+		{pattern: `(a+)+b`, want: RiskHigh},
+		{pattern: `(?:\w+\s?)*$`, want: RiskHigh},
+		{pattern: `(?:a\d|[a-z]x)+`, want: RiskHigh},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.pattern, func(t *testing.T) {
+			check := CheckPattern(tc.pattern, nil)
+			if check.Error != "" {
+				t.Fatalf("CheckPattern() error: %s", check.Error)
+			}
+			if check.Risk != tc.want {
+				t.Errorf("risk = %s, want %s (warnings %v)", check.Risk, tc.want, check.Warnings)
+			}
+			if tc.want != RiskLow && len(check.Warnings) == 0 {
+				t.Error("risky pattern without warnings")
+			}
+		})
+	}
+}
+
+func TestCheckPattern_Matches(t *testing.T) {
+	check := CheckPattern(`tok_[0-9]{4}`, []string{"a tok_1234 and tok_5678", "nothing", "tok_0000"})
+	if check.Error != "" {
+		t.Fatalf("CheckPattern() error: %s", check.Error)
+	}
+	want := []SampleMatch{
+		{Sample: 0, Start: 2, End: 10, Value: "tok_1234"},
+		{Sample: 0, Start: 15, End: 23, Value: "tok_5678"},
+		{Sample: 2, Start: 0, End: 8, Value: "tok_0000"},
+	}
+	if len(check.Matches) != len(want) {
+		t.Fatalf("matches = %+v, want %+v", check.Matches, want)
+	}
+	for i := range want {
+		if check.Matches[i] != want[i] {
+			t.Errorf("match %d = %+v, want %+v", i, check.Matches[i], want[i])
+		}
+	}
+}
+
+func TestCheckPattern_Invalid(t *testing.T) {
+	check := CheckPattern(`tok_[0-9`, []string{"tok_1"})
+	if check.Error == "" || len(check.Matches) != 0 {
+		t.Errorf("CheckPattern() = %+v, want a compile error", check)
+	}
+
+	check = CheckPattern(`x*`, nil)
+	if !strings.Contains(strings.Join(check.Warnings, "\n"), "empty string") {
+		t.Errorf("warnings = %v, want an empty match warning", check.Warnings)
+	}
+}
+
+func TestCheckRules(t *testing.T) {
+	report := CheckRules([]config.PatternRuleConfig{
+		{Name: "good", Pattern: `tok_[0-9]{4}`, Type: "token", Confidence: 0.9},
+		{Name: "incomplete", Pattern: `key_[a-z]+`},
+		{Name: "broken", Pattern: `(`, Type: "token", Confidence: 0.9},
+	}, []string{"tok_1234 key_abc"})
+
+	if !report.Failed() {
+		t.Error("Failed() = false with a broken pattern")
+	}
+	if len(report.Rules[0].Warnings) != 0 || len(report.Rules[0].Matches) != 1 {
+		t.Errorf("good rule = %+v", report.Rules[0])
+	}
+	if len(report.Rules[1].Warnings) != 2 {
+		t.Errorf("incomplete rule warnings = %v, want name/type and confidence", report.Rules[1].Warnings)
+	}
+
+	var text bytes.Buffer
+	if err := report.WriteText(&text); err != nil {
+		t.Fatalf("WriteText() error: %v", err)
+	}
+	for _, want := range []string{"Rule good", `"tok_1234"`, "Backtracking risk: low", "ERROR"} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text report lacks %q:\n%s", want, text.String())
+		}
+	}
+}
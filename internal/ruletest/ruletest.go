@@ -0,0 +1,338 @@
+// Package ruletest evaluates secret interceptors against a labeled corpus and
+// reports precision and recall per rule.
+package ruletest
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/hfi/llm-secret-interceptor/internal/interceptor"
+)
+
+// Sample is a single labeled corpus entry
+type Sample struct {
+	// ID optionally identifies the sample in reports
+	ID string `json:"id,omitempty"`
+	// Text is the input that is fed through the interceptors
+	Text string `json:"text"`
+	// Secrets lists the true secrets contained in Text.
+	// An empty list marks the sample as a benign lookalike.
+	Secrets []LabeledSecret `json:"secrets,omitempty"`
+}
+
+// LabeledSecret is a true secret expected to be detected
+type LabeledSecret struct {
+	// Value is the secret as it appears in the sample text
+	Value string `json:"value"`
+	// Rule optionally names the rule expected to detect this secret.
+	// It is used to compute per-rule recall.
+	Rule string `json:"rule,omitempty"`
+}
+
+// LoadCorpus reads a JSON Lines corpus file, one Sample per line.
+// Empty lines and lines starting with '#' are ignored.
+func LoadCorpus(path string) ([]Sample, error) {
+	f, err := os.Open(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open corpus: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	return ReadCorpus(f)
+}
+
+// ReadCorpus parses a JSON Lines corpus from a reader
+func ReadCorpus(r io.Reader) ([]Sample, error) {
+	var samples []Sample
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	lineNo := 0
+	for scanner.Scan() {
+		lineNo++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var sample Sample
+		if err := json.Unmarshal([]byte(line), &sample); err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNo, err)
+		}
+		if sample.ID == "" {
+			sample.ID = fmt.Sprintf("line-%d", lineNo)
+		}
+		for _, s := range sample.Secrets {
+			if !strings.Contains(sample.Text, s.Value) {
+				return nil, fmt.Errorf("line %d: labeled secret not found in text", lineNo)
+			}
+		}
+		samples = append(samples, sample)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read corpus: %w", err)
+	}
+
+	return samples, nil
+}
+
+// RuleStats holds the evaluation counters for a single rule
+type RuleStats struct {
+	Rule           string `json:"rule"`
+	TruePositives  int    `json:"true_positives"`
+	FalsePositives int    `json:"false_positives"`
+	// Expected is the number of labeled secrets attributed to this rule
+	Expected int `json:"expected"`
+	// Found is the number of expected secrets this rule detected
+	Found int `json:"found"`
+}
+
+// Precision returns TP / (TP + FP), or 0 if the rule never fired
+func (s *RuleStats) Precision() float64 {
+	total := s.TruePositives + s.FalsePositives
+	if total == 0 {
+		return 0
+	}
+	return float64(s.TruePositives) / float64(total)
+}
+
+// Recall returns the share of expected secrets the rule detected,
+// or 0 if no secrets were attributed to the rule
+func (s *RuleStats) Recall() float64 {
+	if s.Expected == 0 {
+		return 0
+	}
+	return float64(s.Found) / float64(s.Expected)
+}
+
+// MarshalJSON includes the derived precision and recall values
+func (s *RuleStats) MarshalJSON() ([]byte, error) {
+	type plain RuleStats
+	return json.Marshal(struct {
+		*plain
+		Precision float64 `json:"precision"`
+		Recall    float64 `json:"recall"`
+	}{(*plain)(s), s.Precision(), s.Recall()})
+}
+
+// Finding describes a single false positive or false negative
+type Finding struct {
+	SampleID string `json:"sample_id"`
+	Rule     string `json:"rule,omitempty"`
+	// Excerpt is a masked excerpt of the value involved
+	Excerpt string `json:"excerpt"`
+}
+
+// Report is the result of evaluating a corpus
+type Report struct {
+	Samples        int          `json:"samples"`
+	LabeledSecrets int          `json:"labeled_secrets"`
+	Detected       int          `json:"detected"`
+	Rules          []*RuleStats `json:"rules"`
+	FalsePositives []Finding    `json:"false_positives"`
+	FalseNegatives []Finding    `json:"false_negatives"`
+}
+
+// Recall returns the share of labeled secrets detected by any rule
+func (r *Report) Recall() float64 {
+	if r.LabeledSecrets == 0 {
+		return 0
+	}
+	return float64(r.Detected) / float64(r.LabeledSecrets)
+}
+
+// RuleKey returns the identifier used to attribute a detection to a rule.
+// Interceptors without named rules are reported under their own name.
+func RuleKey(s interceptor.DetectedSecret) string {
+	if s.Rule != "" {
+		return s.Rule
+	}
+	return s.Source
+}
+
+// Evaluate runs every interceptor in the manager over the corpus.
+// Detections are attributed per interceptor rather than after the manager's
+// deduplication, so every rule gets credit (or blame) for what it matched.
+func Evaluate(manager *interceptor.Manager, samples []Sample) *Report {
+	report := &Report{Samples: len(samples)}
+	stats := make(map[string]*RuleStats)
+	ruleStats := func(rule string) *RuleStats {
+		if s, ok := stats[rule]; ok {
+			return s
+		}
+		s := &RuleStats{Rule: rule}
+		stats[rule] = s
+		return s
+	}
+
+	for _, sample := range samples {
+		spans := labeledSpans(sample)
+		detections := detectEach(manager, sample.Text)
+
+		// Classify every detection
+		for _, d := range detections {
+			rule := RuleKey(d)
+			if idx := overlappingSpan(spans, d.StartIndex, d.EndIndex); idx >= 0 {
+				ruleStats(rule).TruePositives++
+				spans[idx].hitBy[rule] = true
+			} else {
+				ruleStats(rule).FalsePositives++
+				report.FalsePositives = append(report.FalsePositives, Finding{
+					SampleID: sample.ID,
+					Rule:     rule,
+					Excerpt:  mask(d.Value),
+				})
+			}
+		}
+
+		// Compute recall per labeled secret
+		for _, secret := range sample.Secrets {
+			report.LabeledSecrets++
+			hitBy := make(map[string]bool)
+			for _, sp := range spans {
+				if sp.value == secret.Value {
+					for rule := range sp.hitBy {
+						hitBy[rule] = true
+					}
+				}
+			}
+			if len(hitBy) > 0 {
+				report.Detected++
+			} else {
+				report.FalseNegatives = append(report.FalseNegatives, Finding{
+					SampleID: sample.ID,
+					Rule:     secret.Rule,
+					Excerpt:  mask(secret.Value),
+				})
+			}
+			if secret.Rule != "" {
+				rs := ruleStats(secret.Rule)
+				rs.Expected++
+				if hitBy[secret.Rule] {
+					rs.Found++
+				}
+			}
+		}
+	}
+
+	for _, s := range stats {
+		report.Rules = append(report.Rules, s)
+	}
+	sort.Slice(report.Rules, func(i, j int) bool {
+		return report.Rules[i].Rule < report.Rules[j].Rule
+	})
+
+	return report
+}
+
+// detectEach runs each enabled interceptor individually
+func detectEach(manager *interceptor.Manager, text string) []interceptor.DetectedSecret {
+	var all []interceptor.DetectedSecret
+	for _, name := range manager.List() {
+		i := manager.Get(name)
+		if i == nil || !i.IsEnabled() {
+			continue
+		}
+		for _, d := range i.Detect(text) {
+			d.Source = i.Name()
+			all = append(all, d)
+		}
+	}
+	return all
+}
+
+type span struct {
+	value      string
+	start, end int
+	hitBy      map[string]bool
+}
+
+// labeledSpans returns every occurrence of every labeled secret in the sample
+func labeledSpans(sample Sample) []span {
+	var spans []span
+	for _, secret := range sample.Secrets {
+		if secret.Value == "" {
+			continue
+		}
+		offset := 0
+		for {
+			idx := strings.Index(sample.Text[offset:], secret.Value)
+			if idx < 0 {
+				break
+			}
+			start := offset + idx
+			spans = append(spans, span{
+				value: secret.Value,
+				start: start,
+				end:   start + len(secret.Value),
+				hitBy: make(map[string]bool),
+			})
+			offset = start + len(secret.Value)
+		}
+	}
+	return spans
+}
+
+func overlappingSpan(spans []span, start, end int) int {
+	for i, sp := range spans {
+		if start < sp.end && sp.start < end {
+			return i
+		}
+	}
+	return -1
+}
+
+// mask hides the middle of a value so reports never contain full secrets
+func mask(value string) string {
+	if len(value) <= 8 {
+		return strings.Repeat("*", len(value))
+	}
+	return value[:4] + strings.Repeat("*", len(value)-8) + value[len(value)-4:]
+}
+
+// WriteText writes a human-readable report
+func (r *Report) WriteText(w io.Writer, verbose bool) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "RULE\tTP\tFP\tPRECISION\tEXPECTED\tRECALL\n")
+	for _, s := range r.Rules {
+		recall := "-"
+		if s.Expected > 0 {
+			recall = fmt.Sprintf("%.2f", s.Recall())
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%.2f\t%d\t%s\n",
+			s.Rule, s.TruePositives, s.FalsePositives, s.Precision(), s.Expected, recall)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "\nSamples: %d, labeled secrets: %d, detected: %d, overall recall: %.2f\n",
+		r.Samples, r.LabeledSecrets, r.Detected, r.Recall())
+	fmt.Fprintf(w, "False positives: %d, false negatives: %d\n",
+		len(r.FalsePositives), len(r.FalseNegatives))
+
+	if verbose {
+		for _, f := range r.FalsePositives {
+			fmt.Fprintf(w, "  FP %s [%s] %s\n", f.SampleID, f.Rule, f.Excerpt)
+		}
+		for _, f := range r.FalseNegatives {
+			fmt.Fprintf(w, "  FN %s [%s] %s\n", f.SampleID, f.Rule, f.Excerpt)
+		}
+	}
+
+	return nil
+}
+
+// WriteJSON writes the report as indented JSON
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
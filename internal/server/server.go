@@ -5,7 +5,11 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"net"
 	"net/http"
+	"runtime"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
@@ -21,6 +25,14 @@ type HealthStatus struct {
 	Checks    map[string]string `json:"checks,omitempty"`
 }
 
+// VersionInfo describes the build the server runs
+type VersionInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	BuildTime string `json:"build_time,omitempty"`
+	GoVersion string `json:"go_version"`
+}
+
 // HealthChecker is a function that checks component health
 type HealthChecker func() (ok bool, message string)
 
@@ -32,6 +44,8 @@ type Server struct {
 	checkers  map[string]HealthChecker
 	startTime time.Time
 	version   string
+	commit    string
+	buildTime string
 }
 
 // Config holds management server configuration
@@ -51,8 +65,17 @@ type Config struct {
 	// LivePath is the path for liveness checks
 	LivePath string `yaml:"live_path"`
 
+	// VersionPath is the path for build information (empty = not served)
+	VersionPath string `yaml:"version_path"`
+
 	// Version is the application version
 	Version string `yaml:"-"`
+
+	// Commit is the git commit the application was built from
+	Commit string `yaml:"-"`
+
+	// BuildTime is the build timestamp
+	BuildTime string `yaml:"-"`
 }
 
 // DefaultConfig returns the default server configuration
@@ -63,6 +86,7 @@ func DefaultConfig() *Config {
 		HealthPath:  "/health",
 		ReadyPath:   "/ready",
 		LivePath:    "/live",
+		VersionPath: "/version",
 		Version:     "dev",
 	}
 }
@@ -78,6 +102,8 @@ func New(cfg *Config) *Server {
 		checkers:  make(map[string]HealthChecker),
 		startTime: time.Now(),
 		version:   cfg.Version,
+		commit:    cfg.Commit,
+		buildTime: cfg.BuildTime,
 	}
 
 	// Register routes
@@ -85,12 +111,17 @@ func New(cfg *Config) *Server {
 	s.mux.HandleFunc(cfg.HealthPath, s.healthHandler)
 	s.mux.HandleFunc(cfg.ReadyPath, s.readyHandler)
 	s.mux.HandleFunc(cfg.LivePath, s.liveHandler)
+	if cfg.VersionPath != "" {
+		s.mux.HandleFunc(cfg.VersionPath, s.versionHandler)
+	}
 
 	s.server = &http.Server{
-		Addr:         cfg.Addr,
-		Handler:      s.mux,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
+		Addr:              cfg.Addr,
+		Handler:           s.mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		ReadTimeout:       30 * time.Second,
+		WriteTimeout:      30 * time.Second,
+		IdleTimeout:       60 * time.Second,
 	}
 
 	return s
@@ -103,21 +134,59 @@ func (s *Server) RegisterHealthCheck(name string, checker HealthChecker) {
 	s.checkers[name] = checker
 }
 
+// Handle registers an additional handler for pattern
+func (s *Server) Handle(pattern string, handler http.Handler) {
+	s.mux.Handle(pattern, handler)
+}
+
 // Start starts the management server
 func (s *Server) Start() error {
 	return s.server.ListenAndServe()
 }
 
+// Serve serves the management endpoints on ln instead of listening on Addr
+func (s *Server) Serve(ln net.Listener) error {
+	return s.server.Serve(ln)
+}
+
 // Stop gracefully shuts down the server
 func (s *Server) Stop(ctx context.Context) error {
 	return s.server.Shutdown(ctx)
 }
 
-// healthHandler returns detailed health status
-func (s *Server) healthHandler(w http.ResponseWriter, _ *http.Request) {
+// checkResult is the outcome of one health checker
+type checkResult struct {
+	ok      bool
+	message string
+}
+
+// runChecks runs all health checkers concurrently, so slow checks of
+// remote dependencies add up to the slowest one only
+func (s *Server) runChecks() map[string]checkResult {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]checkResult, len(s.checkers))
+	)
+	for name, checker := range s.checkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ok, msg := checker()
+			mu.Lock()
+			results[name] = checkResult{ok: ok, message: msg}
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// healthHandler returns detailed health status
+func (s *Server) healthHandler(w http.ResponseWriter, _ *http.Request) {
 	status := &HealthStatus{
 		Status:    "healthy",
 		Timestamp: time.Now(),
@@ -128,16 +197,16 @@ func (s *Server) healthHandler(w http.ResponseWriter, _ *http.Request) {
 
 	// Run all health checks
 	allHealthy := true
-	for name, checker := range s.checkers {
-		ok, msg := checker()
-		if ok {
+	for name, result := range s.runChecks() {
+		if result.ok {
 			status.Checks[name] = "ok"
 		} else {
-			status.Checks[name] = msg
+			status.Checks[name] = result.message
 			allHealthy = false
 		}
 	}
 
+	w.Header().Set("Content-Type", "application/json")
 	if !allHealthy {
 		status.Status = "unhealthy"
 		w.WriteHeader(http.StatusServiceUnavailable)
@@ -145,7 +214,6 @@ func (s *Server) healthHandler(w http.ResponseWriter, _ *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
 	if err := json.NewEncoder(w).Encode(status); err != nil {
 		http.Error(w, "Failed to encode status", http.StatusInternalServerError)
 	}
@@ -153,19 +221,20 @@ func (s *Server) healthHandler(w http.ResponseWriter, _ *http.Request) {
 
 // readyHandler indicates if the service is ready to receive traffic
 func (s *Server) readyHandler(w http.ResponseWriter, _ *http.Request) {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-
 	// Check all health checkers
-	for name, checker := range s.checkers {
-		ok, _ := checker()
-		if !ok {
-			w.WriteHeader(http.StatusServiceUnavailable)
-			if _, err := fmt.Fprintf(w, "not ready: %s check failed", name); err != nil {
-				return
-			}
+	var failed []string
+	for name, result := range s.runChecks() {
+		if !result.ok {
+			failed = append(failed, name)
+		}
+	}
+	if len(failed) > 0 {
+		sort.Strings(failed)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		if _, err := fmt.Fprintf(w, "not ready: %s check failed", strings.Join(failed, ", ")); err != nil {
 			return
 		}
+		return
 	}
 
 	w.WriteHeader(http.StatusOK)
@@ -185,6 +254,20 @@ func (s *Server) liveHandler(w http.ResponseWriter, _ *http.Request) {
 	}
 }
 
+// versionHandler returns the build information
+func (s *Server) versionHandler(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	info := VersionInfo{
+		Version:   s.version,
+		Commit:    s.commit,
+		BuildTime: s.buildTime,
+		GoVersion: runtime.Version(),
+	}
+	if err := json.NewEncoder(w).Encode(info); err != nil {
+		http.Error(w, "Failed to encode version", http.StatusInternalServerError)
+	}
+}
+
 // Handler returns the HTTP handler for testing
 func (s *Server) Handler() http.Handler {
 	return s.mux
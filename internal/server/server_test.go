@@ -3,8 +3,11 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"io"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"runtime"
 	"testing"
 	"time"
 )
@@ -269,3 +272,69 @@ func TestServer_Addr(t *testing.T) {
 		t.Errorf("Addr() = %q, want ':8080'", srv.Addr())
 	}
 }
+
+func TestServer_ChecksRunConcurrently(t *testing.T) {
+	srv := New(DefaultConfig())
+	for _, name := range []string{"redis", "upstream"} {
+		srv.RegisterHealthCheck(name, func() (bool, string) {
+			time.Sleep(200 * time.Millisecond)
+			return false, name + " down"
+		})
+	}
+
+	start := time.Now()
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/ready", nil))
+	if elapsed := time.Since(start); elapsed >= 400*time.Millisecond {
+		t.Errorf("ready took %v, want the checks run concurrently", elapsed)
+	}
+	if rec.Code != http.StatusServiceUnavailable || rec.Body.String() != "not ready: redis, upstream check failed" {
+		t.Errorf("ready = %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+func TestServer_HandleAndServe(t *testing.T) {
+	srv := New(DefaultConfig())
+	srv.Handle("/extra", http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("extra"))
+	}))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen() error: %v", err)
+	}
+	go func() { _ = srv.Serve(ln) }()
+	t.Cleanup(func() { _ = srv.Stop(context.Background()) })
+
+	for path, want := range map[string]string{"/extra": "extra", "/live": "alive"} {
+		resp, err := http.Get("http://" + ln.Addr().String() + path)
+		if err != nil {
+			t.Fatalf("GET %s error: %v", path, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if string(body) != want {
+			t.Errorf("GET %s = %q, want %q", path, body, want)
+		}
+	}
+}
+
+func TestServer_VersionHandler(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Version = "1.2.3"
+	cfg.Commit = "abc1234"
+	srv := New(cfg)
+
+	rec := httptest.NewRecorder()
+	srv.Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/version", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("version status = %d, want %d", rec.Code, http.StatusOK)
+	}
+	var info VersionInfo
+	if err := json.Unmarshal(rec.Body.Bytes(), &info); err != nil {
+		t.Fatalf("Failed to unmarshal response: %v", err)
+	}
+	if info.Version != "1.2.3" || info.Commit != "abc1234" || info.GoVersion != runtime.Version() {
+		t.Errorf("version = %+v", info)
+	}
+}
@@ -0,0 +1,197 @@
+package storage
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CachedStore serves hot lookups from a bounded in-process LRU cache and
+// writes through to an underlying (typically remote) store. Cache entries
+// expire after a short TTL so that mappings removed or changed by other
+// instances are picked up, and so that lookups regularly reach the
+// underlying store and refresh its TTL.
+type CachedStore struct {
+	inner MappingStore
+	ttl   time.Duration
+
+	mu            sync.Mutex
+	byPlaceholder *lru[string, cacheEntry]
+	bySecret      *lru[string, cacheEntry]
+}
+
+// cacheEntry is a cached value with its expiry time
+type cacheEntry struct {
+	value   string
+	expires time.Time
+}
+
+// NewCachedStore creates a caching wrapper holding up to maxEntries mappings for ttl
+func NewCachedStore(inner MappingStore, maxEntries int, ttl time.Duration) *CachedStore {
+	return &CachedStore{
+		inner:         inner,
+		ttl:           ttl,
+		byPlaceholder: newLRU[string, cacheEntry](maxEntries),
+		bySecret:      newLRU[string, cacheEntry](maxEntries),
+	}
+}
+
+// Store writes the mapping through to the underlying store and caches it
+func (c *CachedStore) Store(ctx context.Context, placeholder, secret string, meta Metadata) error {
+	if err := c.inner.Store(ctx, placeholder, secret, meta); err != nil {
+		return err
+	}
+	c.cache(placeholder, secret)
+	return nil
+}
+
+// Lookup retrieves a secret by its placeholder, from the cache if possible
+func (c *CachedStore) Lookup(ctx context.Context, placeholder string) (string, bool, error) {
+	c.mu.Lock()
+	entry, ok := c.byPlaceholder.get(placeholder)
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.value, true, nil
+	}
+
+	secret, found, err := c.inner.Lookup(ctx, placeholder)
+	if err != nil {
+		return "", false, err
+	}
+	if !found {
+		c.invalidate(placeholder)
+		return "", false, nil
+	}
+	c.cache(placeholder, secret)
+	return secret, true, nil
+}
+
+// LookupMapping retrieves the full mapping from the underlying store; only
+// secrets and placeholders are cached
+func (c *CachedStore) LookupMapping(ctx context.Context, placeholder string) (*Mapping, bool, error) {
+	return c.inner.LookupMapping(ctx, placeholder)
+}
+
+// LookupBySecret retrieves a placeholder by the secret value, from the cache if possible
+func (c *CachedStore) LookupBySecret(ctx context.Context, secret string) (string, bool, error) {
+	c.mu.Lock()
+	entry, ok := c.bySecret.get(secret)
+	c.mu.Unlock()
+	if ok && time.Now().Before(entry.expires) {
+		return entry.value, true, nil
+	}
+
+	placeholder, found, err := c.inner.LookupBySecret(ctx, secret)
+	if err != nil {
+		return "", false, err
+	}
+	if !found {
+		c.mu.Lock()
+		c.bySecret.remove(secret)
+		c.mu.Unlock()
+		return "", false, nil
+	}
+	c.cache(placeholder, secret)
+	return placeholder, true, nil
+}
+
+// Touch updates the TTL of the mapping in the underlying store
+func (c *CachedStore) Touch(ctx context.Context, placeholder string) error {
+	return c.inner.Touch(ctx, placeholder)
+}
+
+// Cleanup drops expired cache entries and cleans up the underlying store
+func (c *CachedStore) Cleanup() error {
+	now := time.Now()
+
+	c.mu.Lock()
+	for _, cache := range []*lru[string, cacheEntry]{c.byPlaceholder, c.bySecret} {
+		var expired []string
+		cache.each(func(key string, entry cacheEntry) {
+			if !now.Before(entry.expires) {
+				expired = append(expired, key)
+			}
+		})
+		for _, key := range expired {
+			cache.remove(key)
+		}
+	}
+	c.mu.Unlock()
+
+	return c.inner.Cleanup()
+}
+
+// Purge deletes the mappings selected by filter from the underlying store
+// and drops the whole cache, so no purged secret is served from it
+func (c *CachedStore) Purge(ctx context.Context, filter PurgeFilter) (int, error) {
+	deleted, err := Purge(ctx, c.inner, filter)
+
+	c.mu.Lock()
+	c.byPlaceholder.clear()
+	c.bySecret.clear()
+	c.mu.Unlock()
+
+	return deleted, err
+}
+
+// List returns the mappings of the underlying store selected by filter
+func (c *CachedStore) List(ctx context.Context, filter PurgeFilter, limit int) ([]Mapping, error) {
+	return List(ctx, c.inner, filter, limit)
+}
+
+// Ping checks the connection of the underlying store
+func (c *CachedStore) Ping(ctx context.Context) error {
+	return Ping(ctx, c.inner)
+}
+
+// NextSequence increments a placeholder counter of the underlying store
+func (c *CachedStore) NextSequence(ctx context.Context, scope string) (int, error) {
+	sequencer, ok := c.inner.(Sequencer)
+	if !ok {
+		return 0, errNoSequencer
+	}
+	return sequencer.NextSequence(ctx, scope)
+}
+
+// RecordRestore counts a restoration of placeholder in the underlying store,
+// including restorations served from the cache
+func (c *CachedStore) RecordRestore(ctx context.Context, placeholder string) error {
+	return RecordRestore(ctx, c.inner, placeholder)
+}
+
+// UsageStats aggregates the restoration counts of the underlying store
+func (c *CachedStore) UsageStats(ctx context.Context) (UsageStats, error) {
+	return CollectUsageStats(ctx, c.inner)
+}
+
+// Size returns the number of mappings in the underlying store
+func (c *CachedStore) Size() int {
+	return c.inner.Size()
+}
+
+// Close clears the cache and closes the underlying store
+func (c *CachedStore) Close() error {
+	c.mu.Lock()
+	c.byPlaceholder.clear()
+	c.bySecret.clear()
+	c.mu.Unlock()
+	return c.inner.Close()
+}
+
+func (c *CachedStore) cache(placeholder, secret string) {
+	expires := time.Now().Add(c.ttl)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.byPlaceholder.add(placeholder, cacheEntry{value: secret, expires: expires})
+	c.bySecret.add(secret, cacheEntry{value: placeholder, expires: expires})
+}
+
+func (c *CachedStore) invalidate(placeholder string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.byPlaceholder.get(placeholder); ok {
+		c.bySecret.remove(entry.value)
+	}
+	c.byPlaceholder.remove(placeholder)
+}
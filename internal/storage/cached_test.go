@@ -0,0 +1,88 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCachedStore_WriteThrough(t *testing.T) {
+	inner := NewMockStore()
+	store := NewCachedStore(inner, 10, time.Minute)
+
+	if err := store.Store(context.Background(), "__SECRET_1__", "secret1", Metadata{}); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+	if inner.mappings["__SECRET_1__"] != "secret1" {
+		t.Error("Store() did not write through to the underlying store")
+	}
+
+	// Hot lookups are served from the cache
+	inner.lookupCalls = 0
+	for i := 0; i < 3; i++ {
+		if got, found, _ := store.Lookup(context.Background(), "__SECRET_1__"); !found || got != "secret1" {
+			t.Errorf("Lookup() = %q, %v", got, found)
+		}
+		if got, found, _ := store.LookupBySecret(context.Background(), "secret1"); !found || got != "__SECRET_1__" {
+			t.Errorf("LookupBySecret() = %q, %v", got, found)
+		}
+	}
+	if inner.lookupCalls != 0 {
+		t.Errorf("underlying store looked up %d times, want 0", inner.lookupCalls)
+	}
+}
+
+func TestCachedStore_ReadThrough(t *testing.T) {
+	inner := NewMockStore()
+	store := NewCachedStore(inner, 10, time.Minute)
+
+	// Mapping created by another instance
+	if err := inner.Store(context.Background(), "__SECRET_1__", "secret1", Metadata{}); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+	inner.lookupCalls = 0
+
+	_, _, _ = store.Lookup(context.Background(), "__SECRET_1__")
+	_, _, _ = store.Lookup(context.Background(), "__SECRET_1__")
+	if inner.lookupCalls != 1 {
+		t.Errorf("underlying store looked up %d times, want 1", inner.lookupCalls)
+	}
+}
+
+func TestCachedStore_Expiry(t *testing.T) {
+	inner := NewMockStore()
+	store := NewCachedStore(inner, 10, 10*time.Millisecond)
+
+	if err := store.Store(context.Background(), "__SECRET_1__", "secret1", Metadata{}); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+
+	// Removed elsewhere; visible once the cache entry expires
+	delete(inner.mappings, "__SECRET_1__")
+	if _, found, _ := store.Lookup(context.Background(), "__SECRET_1__"); !found {
+		t.Error("Lookup() should be served from cache before expiry")
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	if _, found, _ := store.Lookup(context.Background(), "__SECRET_1__"); found {
+		t.Error("Lookup() should miss after cache expiry and removal")
+	}
+}
+
+func TestCachedStore_Eviction(t *testing.T) {
+	inner := NewMockStore()
+	store := NewCachedStore(inner, 2, time.Minute)
+
+	for _, ph := range []string{"__SECRET_1__", "__SECRET_2__", "__SECRET_3__"} {
+		if err := store.Store(context.Background(), ph, "secret"+ph, Metadata{}); err != nil {
+			t.Fatalf("Store() error: %v", err)
+		}
+	}
+
+	inner.lookupCalls = 0
+	_, _, _ = store.Lookup(context.Background(), "__SECRET_1__") // evicted, read from inner
+	_, _, _ = store.Lookup(context.Background(), "__SECRET_3__") // cached
+	if inner.lookupCalls != 1 {
+		t.Errorf("underlying store looked up %d times, want 1", inner.lookupCalls)
+	}
+}
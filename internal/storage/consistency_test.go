@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/hfi/llm-secret-interceptor/internal/awsauth"
+	"github.com/hfi/llm-secret-interceptor/internal/kms"
+)
+
+// sharedStores returns two instances of a backend sharing the same data,
+// like two proxy replicas in front of one Redis
+type sharedStores func(t *testing.T) (MappingStore, MappingStore)
+
+func consistencyBackends() map[string]sharedStores {
+	return map[string]sharedStores{
+		"memory": func(t *testing.T) (MappingStore, MappingStore) {
+			store := NewMemoryStore(time.Hour)
+			t.Cleanup(func() { _ = store.Close() })
+			return store, store
+		},
+		"redis": func(t *testing.T) (MappingStore, MappingStore) {
+			mr := miniredis.RunT(t)
+			return newSharedRedisStore(t, mr), newSharedRedisStore(t, mr)
+		},
+		"dynamodb": func(t *testing.T) (MappingStore, MappingStore) {
+			first, _ := setupDynamoDBStore(t)
+			second, err := NewDynamoDBStore(DynamoDBOptions{
+				Table:       "mappings",
+				Region:      "eu-central-1",
+				Endpoint:    first.endpoint,
+				Credentials: awsauth.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"},
+			}, time.Hour)
+			if err != nil {
+				t.Fatalf("NewDynamoDBStore() error: %v", err)
+			}
+			t.Cleanup(func() { _ = second.Close() })
+			return first, second
+		},
+	}
+}
+
+func newSharedRedisStore(t *testing.T, mr *miniredis.Miniredis) *RedisStore {
+	t.Helper()
+	store, err := NewRedisStore(RedisOptions{Address: mr.Addr()}, time.Hour)
+	if err != nil {
+		t.Fatalf("NewRedisStore() error: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return store
+}
+
+// storeConcurrently stores placeholder from both instances in parallel,
+// with secretFor choosing the secret of each writer
+func storeConcurrently(first, second MappingStore, placeholder string, secretFor func(i int) string) []error {
+	const writers = 16
+	errs := make([]error, writers)
+	var wg sync.WaitGroup
+	for i := 0; i < writers; i++ {
+		store := first
+		if i%2 == 1 {
+			store = second
+		}
+		wg.Add(1)
+		go func(i int, store MappingStore) {
+			defer wg.Done()
+			errs[i] = store.Store(context.Background(), placeholder, secretFor(i), Metadata{RequestID: fmt.Sprintf("req-%d", i)})
+		}(i, store)
+	}
+	wg.Wait()
+	return errs
+}
+
+func TestConsistency_ConcurrentSameSecret(t *testing.T) {
+	for name, backend := range consistencyBackends() {
+		t.Run(name, func(t *testing.T) {
+			first, second := backend(t)
+
+			for i, err := range storeConcurrently(first, second, "__SECRET_12345678__", func(int) string { return "mysecretpassword" }) {
+				if err != nil {
+					t.Errorf("Store() by writer %d error: %v", i, err)
+				}
+			}
+
+			for _, store := range []MappingStore{first, second} {
+				if secret, found, err := store.Lookup(context.Background(), "__SECRET_12345678__"); err != nil || !found || secret != "mysecretpassword" {
+					t.Errorf("Lookup() = %q, %v, %v", secret, found, err)
+				}
+				if ph, found, err := store.LookupBySecret(context.Background(), "mysecretpassword"); err != nil || !found || ph != "__SECRET_12345678__" {
+					t.Errorf("LookupBySecret() = %q, %v, %v", ph, found, err)
+				}
+			}
+		})
+	}
+}
+
+func TestConsistency_ConcurrentConflictingSecrets(t *testing.T) {
+	for name, backend := range consistencyBackends() {
+		t.Run(name, func(t *testing.T) {
+			first, second := backend(t)
+
+			errs := storeConcurrently(first, second, "__SECRET_12345678__", func(i int) string { return fmt.Sprintf("secret-%d", i) })
+			winner := -1
+			for i, err := range errs {
+				switch {
+				case err == nil:
+					if winner >= 0 {
+						t.Errorf("writers %d and %d both stored the placeholder", winner, i)
+					}
+					winner = i
+				case !errors.Is(err, ErrPlaceholderConflict):
+					t.Errorf("Store() by writer %d error: %v", i, err)
+				}
+			}
+			if winner < 0 {
+				t.Fatal("no writer stored the placeholder")
+			}
+
+			// Every instance resolves the placeholder to the winning secret
+			want := fmt.Sprintf("secret-%d", winner)
+			for _, store := range []MappingStore{first, second} {
+				if secret, _, _ := store.Lookup(context.Background(), "__SECRET_12345678__"); secret != want {
+					t.Errorf("Lookup() = %q, want %q", secret, want)
+				}
+			}
+		})
+	}
+}
+
+func TestConsistency_RestoreKeepsOriginalMetadata(t *testing.T) {
+	for name, backend := range consistencyBackends() {
+		t.Run(name, func(t *testing.T) {
+			first, second := backend(t)
+			ctx := context.Background()
+
+			if err := first.Store(ctx, "__SECRET_12345678__", "mysecretpassword", Metadata{RequestID: "first"}); err != nil {
+				t.Fatalf("Store() error: %v", err)
+			}
+			if err := second.Store(ctx, "__SECRET_12345678__", "mysecretpassword", Metadata{RequestID: "second"}); err != nil {
+				t.Fatalf("Store() of the same mapping error: %v", err)
+			}
+
+			mapping, found, err := second.LookupMapping(ctx, "__SECRET_12345678__")
+			if err != nil || !found || mapping.Metadata.RequestID != "first" {
+				t.Errorf("LookupMapping() = %+v, %v, %v, want metadata of the first store", mapping, found, err)
+			}
+		})
+	}
+}
+
+func TestConsistency_EncryptedAcrossRotation(t *testing.T) {
+	provider, err := kms.NewLocalProvider(make([]byte, 32))
+	if err != nil {
+		t.Fatalf("NewLocalProvider() error: %v", err)
+	}
+	keyring := kms.NewKeyring(provider, kms.NewMemoryKeyStore())
+	if err := keyring.Init(context.Background()); err != nil {
+		t.Fatalf("Init() error: %v", err)
+	}
+	inner := NewMemoryStore(time.Hour)
+	defer inner.Close()
+	store := NewEncryptedStore(inner, keyring)
+	ctx := context.Background()
+
+	if err := store.Store(ctx, "__SECRET_12345678__", "mysecretpassword", Metadata{}); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+	if _, err := keyring.Rotate(ctx); err != nil {
+		t.Fatalf("Rotate() error: %v", err)
+	}
+
+	// The sealed value differs under the new key, but the secret is the same
+	if err := store.Store(ctx, "__SECRET_12345678__", "mysecretpassword", Metadata{}); err != nil {
+		t.Errorf("Store() after rotation error: %v", err)
+	}
+	if err := store.Store(ctx, "__SECRET_12345678__", "othersecret", Metadata{}); !errors.Is(err, ErrPlaceholderConflict) {
+		t.Errorf("Store() of a different secret = %v, want ErrPlaceholderConflict", err)
+	}
+}
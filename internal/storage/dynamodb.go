@@ -0,0 +1,712 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/awsauth"
+	"github.com/hfi/llm-secret-interceptor/internal/kms"
+)
+
+const (
+	// dynamoBatchSize is the maximum number of requests in a BatchWriteItem call
+	dynamoBatchSize = 25
+	// dynamoMaxErrorBody limits how much of an error response is read
+	dynamoMaxErrorBody = 4096
+	// dynamoHTTPTimeout bounds a single DynamoDB API call
+	dynamoHTTPTimeout = 10 * time.Second
+)
+
+// Item key prefixes; all items share the partition key attribute "pk"
+const (
+	dynamoPlaceholderPrefix = "p#"
+	dynamoSecretPrefix      = "h#"
+	dynamoKeyPrefix         = "k#"
+	dynamoIndexKeyItem      = "index-key"
+)
+
+// errConditionFailed reports a failed DynamoDB condition expression
+var errConditionFailed = errors.New("conditional check failed")
+
+// DynamoDBStore is a MappingStore backed by a DynamoDB table, for
+// deployments without Redis such as ECS or Lambda.
+//
+// The table needs a string partition key named "pk" and should have
+// DynamoDB TTL enabled on the "expires_at" attribute, which removes expired
+// mappings server-side. As TTL deletion is lazy, reads also check the
+// expiry. Reverse index items are named after an HMAC of the secret, like
+// the Redis store's keys. Restore counts are kept on the placeholder item.
+// Size is refreshed by a periodic table scan.
+type DynamoDBStore struct {
+	endpoint        string
+	table           string
+	signer          *awsauth.Signer
+	client          *http.Client
+	ttl             time.Duration
+	indexKey        []byte
+	size            atomic.Int64
+	cleanupInterval time.Duration
+	stopCleanup     chan struct{}
+}
+
+// DynamoDBOptions contains connection settings for a DynamoDBStore
+type DynamoDBOptions struct {
+	Table       string
+	Region      string
+	Credentials awsauth.Credentials
+
+	// Endpoint overrides the regional endpoint (e.g. for VPC endpoints or
+	// DynamoDB Local) if non-empty
+	Endpoint string
+
+	// IndexKey is the HMAC key for the reverse index. If empty, a random key
+	// is created once and shared by all instances through the table.
+	IndexKey []byte
+}
+
+// NewDynamoDBStore creates a new DynamoDB-based mapping store
+func NewDynamoDBStore(opts DynamoDBOptions, ttl time.Duration) (*DynamoDBStore, error) {
+	if opts.Table == "" || opts.Region == "" {
+		return nil, fmt.Errorf("DynamoDB table and region are required")
+	}
+	endpoint := opts.Endpoint
+	if endpoint == "" {
+		endpoint = fmt.Sprintf("https://dynamodb.%s.amazonaws.com", opts.Region)
+	}
+
+	store := &DynamoDBStore{
+		endpoint:        strings.TrimRight(endpoint, "/") + "/",
+		table:           opts.Table,
+		signer:          awsauth.NewSigner(opts.Credentials, opts.Region, "dynamodb"),
+		client:          &http.Client{Timeout: dynamoHTTPTimeout},
+		ttl:             ttl,
+		indexKey:        opts.IndexKey,
+		cleanupInterval: 5 * time.Minute,
+		stopCleanup:     make(chan struct{}),
+	}
+
+	// The index key lookup doubles as the connection test
+	ctx := context.Background()
+	if len(store.indexKey) == 0 {
+		key, err := store.loadIndexKey(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load index key: %w", err)
+		}
+		store.indexKey = key
+	} else if _, _, err := store.getItem(ctx, dynamoIndexKeyItem); err != nil {
+		return nil, fmt.Errorf("failed to connect to DynamoDB: %w", err)
+	}
+
+	if err := store.Cleanup(); err != nil {
+		return nil, fmt.Errorf("failed to count mappings: %w", err)
+	}
+	go store.cleanupLoop()
+
+	return store, nil
+}
+
+// Store saves a new secret-placeholder mapping
+func (d *DynamoDBStore) Store(ctx context.Context, placeholder, secret string, meta Metadata) error {
+	metadata, err := json.Marshal(meta)
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata: %w", err)
+	}
+
+	now := time.Now()
+	digest := secretDigest(d.indexKey, secret)
+	mapping := dynamoItem{
+		"pk":         stringValue(dynamoPlaceholderPrefix + placeholder),
+		"secret":     stringValue(secret),
+		"digest":     stringValue(digest),
+		"metadata":   stringValue(string(metadata)),
+		"created_at": numberValue(now.UnixMilli()),
+		"last_used":  numberValue(now.UnixMilli()),
+		"expires_at": numberValue(d.expiresAt(now)),
+	}
+	reverse := d.reverseItem(digest, placeholder, now)
+
+	// Both items are only written if the placeholder is new
+	err = d.call(ctx, "TransactWriteItems", map[string]interface{}{
+		"TransactItems": []map[string]interface{}{
+			{"Put": map[string]interface{}{
+				"TableName":           d.table,
+				"Item":                mapping,
+				"ConditionExpression": "attribute_not_exists(pk)",
+			}},
+			{"Put": map[string]interface{}{"TableName": d.table, "Item": reverse}},
+		},
+	}, nil)
+	if errors.Is(err, errConditionFailed) {
+		return d.storeExisting(ctx, placeholder, secret)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to store mapping: %w", err)
+	}
+	return nil
+}
+
+// storeExisting handles a store of a placeholder that already exists: the
+// same secret refreshes the mapping, a different one is a conflict
+func (d *DynamoDBStore) storeExisting(ctx context.Context, placeholder, secret string) error {
+	item, found, err := d.getItem(ctx, dynamoPlaceholderPrefix+placeholder)
+	if err != nil {
+		return fmt.Errorf("failed to store mapping: %w", err)
+	}
+	if !found {
+		// Deleted since the write; the caller may retry
+		return fmt.Errorf("failed to store mapping: placeholder removed concurrently")
+	}
+	if item.str("secret") != secret {
+		return ErrPlaceholderConflict
+	}
+	if err := d.Touch(ctx, placeholder); err != nil {
+		return fmt.Errorf("failed to store mapping: %w", err)
+	}
+	return d.putItem(ctx, d.reverseItem(item.str("digest"), placeholder, time.Now()))
+}
+
+// Lookup retrieves a secret by its placeholder
+func (d *DynamoDBStore) Lookup(ctx context.Context, placeholder string) (string, bool, error) {
+	item, found, err := d.getLive(ctx, dynamoPlaceholderPrefix+placeholder)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to look up placeholder: %w", err)
+	}
+	if !found {
+		return "", false, nil
+	}
+
+	// Refresh TTL on access; a failure only shortens the mapping's lifetime
+	_ = d.Touch(ctx, placeholder)
+
+	return item.str("secret"), true, nil
+}
+
+// LookupMapping retrieves the full mapping without refreshing its TTL
+func (d *DynamoDBStore) LookupMapping(ctx context.Context, placeholder string) (*Mapping, bool, error) {
+	item, found, err := d.getLive(ctx, dynamoPlaceholderPrefix+placeholder)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to look up mapping: %w", err)
+	}
+	if !found {
+		return nil, false, nil
+	}
+
+	mapping, err := item.mapping()
+	if err != nil {
+		return nil, false, err
+	}
+	return mapping, true, nil
+}
+
+// LookupBySecret retrieves a placeholder by the secret value
+func (d *DynamoDBStore) LookupBySecret(ctx context.Context, secret string) (string, bool, error) {
+	digest := secretDigest(d.indexKey, secret)
+	item, found, err := d.getLive(ctx, dynamoSecretPrefix+digest)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to look up secret: %w", err)
+	}
+	if !found {
+		return "", false, nil
+	}
+
+	// Refresh TTL on access
+	placeholder := item.str("placeholder")
+	_ = d.putItem(ctx, d.reverseItem(digest, placeholder, time.Now()))
+
+	return placeholder, true, nil
+}
+
+// Touch updates the TTL for a mapping
+func (d *DynamoDBStore) Touch(ctx context.Context, placeholder string) error {
+	now := time.Now()
+	err := d.call(ctx, "UpdateItem", map[string]interface{}{
+		"TableName":           d.table,
+		"Key":                 dynamoKey(dynamoPlaceholderPrefix + placeholder),
+		"UpdateExpression":    "SET last_used = :last_used, expires_at = :expires_at",
+		"ConditionExpression": "attribute_exists(pk)",
+		"ExpressionAttributeValues": dynamoItem{
+			":last_used":  numberValue(now.UnixMilli()),
+			":expires_at": numberValue(d.expiresAt(now)),
+		},
+	}, nil)
+	if errors.Is(err, errConditionFailed) {
+		// Touching a missing mapping must not recreate it
+		return nil
+	}
+	return err
+}
+
+// RecordRestore counts a restoration of placeholder
+func (d *DynamoDBStore) RecordRestore(ctx context.Context, placeholder string) error {
+	err := d.call(ctx, "UpdateItem", map[string]interface{}{
+		"TableName":           d.table,
+		"Key":                 dynamoKey(dynamoPlaceholderPrefix + placeholder),
+		"UpdateExpression":    "SET last_restored = :now ADD restore_count :one",
+		"ConditionExpression": "attribute_exists(pk)",
+		"ExpressionAttributeValues": dynamoItem{
+			":now": numberValue(time.Now().UnixMilli()),
+			":one": numberValue(1),
+		},
+	}, nil)
+	if errors.Is(err, errConditionFailed) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to record restore: %w", err)
+	}
+	return nil
+}
+
+// UsageStats aggregates the restoration counts of all live mappings
+func (d *DynamoDBStore) UsageStats(ctx context.Context) (UsageStats, error) {
+	stats := newUsageStats()
+	now := time.Now()
+	err := d.scan(ctx, dynamoPlaceholderPrefix, func(item dynamoItem) error {
+		if !d.live(item, now) {
+			return nil
+		}
+		mapping, err := item.mapping()
+		if err != nil {
+			return err
+		}
+		stats.add(mapping)
+		return nil
+	})
+	if err != nil {
+		return stats, fmt.Errorf("failed to collect usage stats: %w", err)
+	}
+	stats.finish()
+	return stats, nil
+}
+
+// List returns the live mappings selected by filter without their secrets
+func (d *DynamoDBStore) List(ctx context.Context, filter PurgeFilter, limit int) ([]Mapping, error) {
+	now := time.Now()
+	var mappings []Mapping
+	err := d.scan(ctx, dynamoPlaceholderPrefix+filter.placeholderPrefix(), func(item dynamoItem) error {
+		if !d.live(item, now) {
+			return nil
+		}
+		mapping, err := item.mapping()
+		if err != nil {
+			return err
+		}
+		if filter.matches(mapping, now) {
+			mappings = append(mappings, listed(mapping))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list mappings: %w", err)
+	}
+	return newestFirst(mappings, limit), nil
+}
+
+// Cleanup refreshes the mapping count. Expired items themselves are
+// deleted by DynamoDB TTL.
+func (d *DynamoDBStore) Cleanup() error {
+	ctx := context.Background()
+	now := time.Now()
+	count := 0
+	err := d.scan(ctx, dynamoPlaceholderPrefix, func(item dynamoItem) error {
+		if d.live(item, now) {
+			count++
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	d.size.Store(int64(count))
+	return nil
+}
+
+// Size returns the number of live mappings as of the last count
+func (d *DynamoDBStore) Size() int {
+	return int(d.size.Load())
+}
+
+// Purge deletes the mappings selected by filter. It returns the number of
+// deleted placeholder mappings. Data-encryption keys and the index key are
+// kept.
+func (d *DynamoDBStore) Purge(ctx context.Context, filter PurgeFilter) (int, error) {
+	now := time.Now()
+	var keys []string
+	err := d.scan(ctx, dynamoPlaceholderPrefix+filter.placeholderPrefix(), func(item dynamoItem) error {
+		mapping, err := item.mapping()
+		if err != nil {
+			// Undecodable metadata cannot match a filter on it
+			mapping = &Mapping{Placeholder: strings.TrimPrefix(item.str("pk"), dynamoPlaceholderPrefix)}
+		}
+		if filter.matches(mapping, now) {
+			keys = append(keys, item.str("pk"))
+			if digest := item.str("digest"); digest != "" {
+				keys = append(keys, dynamoSecretPrefix+digest)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge mappings: %w", err)
+	}
+
+	deleted := 0
+	for start := 0; start < len(keys); start += dynamoBatchSize {
+		end := min(start+dynamoBatchSize, len(keys))
+		if err := d.deleteBatch(ctx, keys[start:end]); err != nil {
+			return deleted, fmt.Errorf("failed to purge mappings: %w", err)
+		}
+		for _, key := range keys[start:end] {
+			if strings.HasPrefix(key, dynamoPlaceholderPrefix) {
+				deleted++
+			}
+		}
+	}
+
+	d.size.Add(-int64(deleted))
+	if d.size.Load() < 0 {
+		d.size.Store(0)
+	}
+	return deleted, nil
+}
+
+// deleteBatch deletes up to dynamoBatchSize items, retrying unprocessed ones
+func (d *DynamoDBStore) deleteBatch(ctx context.Context, keys []string) error {
+	requests := make([]map[string]interface{}, len(keys))
+	for i, key := range keys {
+		requests[i] = map[string]interface{}{
+			"DeleteRequest": map[string]interface{}{"Key": dynamoKey(key)},
+		}
+	}
+
+	pending := map[string][]map[string]interface{}{d.table: requests}
+	for attempt := 0; len(pending[d.table]) > 0; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(time.Duration(attempt) * 100 * time.Millisecond):
+			}
+		}
+
+		var resp struct {
+			UnprocessedItems map[string][]map[string]interface{} `json:"UnprocessedItems"`
+		}
+		if err := d.call(ctx, "BatchWriteItem", map[string]interface{}{"RequestItems": pending}, &resp); err != nil {
+			return err
+		}
+		pending = resp.UnprocessedItems
+		if pending == nil {
+			return nil
+		}
+	}
+	return nil
+}
+
+// cleanupLoop periodically refreshes the mapping count
+func (d *DynamoDBStore) cleanupLoop() {
+	ticker := time.NewTicker(d.cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := d.Cleanup(); err != nil {
+				// Cleanup errors are not critical, just continue
+				_ = err
+			}
+		case <-d.stopCleanup:
+			return
+		}
+	}
+}
+
+// LoadKeys returns the wrapped data-encryption keys stored in the table
+func (d *DynamoDBStore) LoadKeys(ctx context.Context) ([]kms.WrappedKey, error) {
+	var keys []kms.WrappedKey
+	err := d.scan(ctx, dynamoKeyPrefix, func(item dynamoItem) error {
+		var key kms.WrappedKey
+		if err := json.Unmarshal([]byte(item.str("key")), &key); err != nil {
+			return fmt.Errorf("invalid keyring entry %s: %w", item.str("pk"), err)
+		}
+		keys = append(keys, key)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// SaveKey persists a wrapped data-encryption key in the table. Keys do not
+// expire, as mappings sealed with them may still be live.
+func (d *DynamoDBStore) SaveKey(ctx context.Context, key kms.WrappedKey) error {
+	data, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	return d.putItem(ctx, dynamoItem{
+		"pk":  stringValue(dynamoKeyPrefix + key.ID),
+		"key": stringValue(string(data)),
+	})
+}
+
+// Close stops the cleanup goroutine
+func (d *DynamoDBStore) Close() error {
+	close(d.stopCleanup)
+	return nil
+}
+
+// loadIndexKey returns the shared reverse index key, creating it if needed.
+// A conditional put makes concurrently starting instances agree on a
+// single key.
+func (d *DynamoDBStore) loadIndexKey(ctx context.Context) ([]byte, error) {
+	err := d.call(ctx, "PutItem", map[string]interface{}{
+		"TableName": d.table,
+		"Item": dynamoItem{
+			"pk":  stringValue(dynamoIndexKeyItem),
+			"key": stringValue(base64.StdEncoding.EncodeToString(newIndexKey())),
+		},
+		"ConditionExpression": "attribute_not_exists(pk)",
+	}, nil)
+	if err != nil && !errors.Is(err, errConditionFailed) {
+		return nil, err
+	}
+
+	item, found, err := d.getItem(ctx, dynamoIndexKeyItem)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, fmt.Errorf("index key item missing")
+	}
+	return base64.StdEncoding.DecodeString(item.str("key"))
+}
+
+// expiresAt returns the DynamoDB TTL timestamp for a mapping used at now
+func (d *DynamoDBStore) expiresAt(now time.Time) int64 {
+	return now.Add(d.ttl).Unix()
+}
+
+// reverseItem builds the reverse index item for a secret digest
+func (d *DynamoDBStore) reverseItem(digest, placeholder string, now time.Time) dynamoItem {
+	return dynamoItem{
+		"pk":          stringValue(dynamoSecretPrefix + digest),
+		"placeholder": stringValue(placeholder),
+		"last_used":   numberValue(now.UnixMilli()),
+		"expires_at":  numberValue(d.expiresAt(now)),
+	}
+}
+
+// live reports whether an item has not expired yet
+func (d *DynamoDBStore) live(item dynamoItem, now time.Time) bool {
+	lastUsed := time.UnixMilli(item.num("last_used"))
+	return now.Sub(lastUsed) <= d.ttl
+}
+
+// getLive reads an item, treating expired items not yet deleted by
+// DynamoDB TTL as missing
+func (d *DynamoDBStore) getLive(ctx context.Context, key string) (dynamoItem, bool, error) {
+	item, found, err := d.getItem(ctx, key)
+	if err != nil || !found {
+		return nil, false, err
+	}
+	if !d.live(item, time.Now()) {
+		return nil, false, nil
+	}
+	return item, true, nil
+}
+
+// getItem reads an item with a strongly consistent read
+func (d *DynamoDBStore) getItem(ctx context.Context, key string) (dynamoItem, bool, error) {
+	var resp struct {
+		Item dynamoItem `json:"Item"`
+	}
+	err := d.call(ctx, "GetItem", map[string]interface{}{
+		"TableName":      d.table,
+		"Key":            dynamoKey(key),
+		"ConsistentRead": true,
+	}, &resp)
+	if err != nil {
+		return nil, false, err
+	}
+	if resp.Item == nil {
+		return nil, false, nil
+	}
+	return resp.Item, true, nil
+}
+
+// putItem writes an item
+func (d *DynamoDBStore) putItem(ctx context.Context, item dynamoItem) error {
+	return d.call(ctx, "PutItem", map[string]interface{}{
+		"TableName": d.table,
+		"Item":      item,
+	}, nil)
+}
+
+// scan calls fn for every item whose partition key starts with prefix
+func (d *DynamoDBStore) scan(ctx context.Context, prefix string, fn func(dynamoItem) error) error {
+	var startKey dynamoItem
+	for {
+		request := map[string]interface{}{
+			"TableName":                 d.table,
+			"ConsistentRead":            true,
+			"FilterExpression":          "begins_with(pk, :prefix)",
+			"ExpressionAttributeValues": dynamoItem{":prefix": stringValue(prefix)},
+		}
+		if startKey != nil {
+			request["ExclusiveStartKey"] = startKey
+		}
+
+		var resp struct {
+			Items            []dynamoItem `json:"Items"`
+			LastEvaluatedKey dynamoItem   `json:"LastEvaluatedKey"`
+		}
+		if err := d.call(ctx, "Scan", request, &resp); err != nil {
+			return err
+		}
+		for _, item := range resp.Items {
+			// The filter is also applied here, as it is only an optimization
+			if !strings.HasPrefix(item.str("pk"), prefix) {
+				continue
+			}
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+
+		if len(resp.LastEvaluatedKey) == 0 {
+			return nil
+		}
+		startKey = resp.LastEvaluatedKey
+	}
+}
+
+// call invokes a DynamoDB JSON API action; out may be nil
+func (d *DynamoDBStore) call(ctx context.Context, action string, body map[string]interface{}, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-amz-json-1.0")
+	req.Header.Set("X-Amz-Target", "DynamoDB_20120810."+action)
+	if err := d.signer.Sign(req, payload); err != nil {
+		return fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, dynamoMaxErrorBody))
+		var apiErr struct {
+			Type                string `json:"__type"`
+			Message             string `json:"message"`
+			CancellationReasons []struct {
+				Code string `json:"Code"`
+			} `json:"CancellationReasons"`
+		}
+		if json.Unmarshal(data, &apiErr) == nil {
+			if strings.HasSuffix(apiErr.Type, "#ConditionalCheckFailedException") {
+				return errConditionFailed
+			}
+			// A transaction canceled by a condition reports it per item
+			if strings.HasSuffix(apiErr.Type, "#TransactionCanceledException") {
+				for _, reason := range apiErr.CancellationReasons {
+					if reason.Code == "ConditionalCheckFailed" {
+						return errConditionFailed
+					}
+				}
+			}
+		}
+		return fmt.Errorf("DynamoDB %s failed with status %d: %s", action, resp.StatusCode, data)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode DynamoDB response: %w", err)
+	}
+	return nil
+}
+
+// dynamoItem is a DynamoDB item in the JSON wire format
+type dynamoItem map[string]dynamoValue
+
+// dynamoValue is a DynamoDB attribute value; only strings and numbers are used
+type dynamoValue struct {
+	S *string `json:"S,omitempty"`
+	N *string `json:"N,omitempty"`
+}
+
+func stringValue(s string) dynamoValue {
+	return dynamoValue{S: &s}
+}
+
+func numberValue(n int64) dynamoValue {
+	s := strconv.FormatInt(n, 10)
+	return dynamoValue{N: &s}
+}
+
+// dynamoKey returns the primary key of the item with partition key pk
+func dynamoKey(pk string) dynamoItem {
+	return dynamoItem{"pk": stringValue(pk)}
+}
+
+// str returns a string attribute, or "" if it is not set
+func (i dynamoItem) str(name string) string {
+	if v, ok := i[name]; ok && v.S != nil {
+		return *v.S
+	}
+	return ""
+}
+
+// num returns a number attribute, or 0 if it is not set
+func (i dynamoItem) num(name string) int64 {
+	if v, ok := i[name]; ok && v.N != nil {
+		n, _ := strconv.ParseInt(*v.N, 10, 64)
+		return n
+	}
+	return 0
+}
+
+// mapping decodes a placeholder item
+func (i dynamoItem) mapping() (*Mapping, error) {
+	mapping := &Mapping{
+		Placeholder: strings.TrimPrefix(i.str("pk"), dynamoPlaceholderPrefix),
+		Secret:      i.str("secret"),
+		CreatedAt:   time.UnixMilli(i.num("created_at")),
+		LastUsed:    time.UnixMilli(i.num("last_used")),
+	}
+	if count := i.num("restore_count"); count > 0 {
+		mapping.RestoreCount = count
+		mapping.LastRestored = time.UnixMilli(i.num("last_restored"))
+	}
+	if raw := i.str("metadata"); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &mapping.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to decode metadata: %w", err)
+		}
+	}
+	return mapping, nil
+}
@@ -0,0 +1,313 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/awsauth"
+	"github.com/hfi/llm-secret-interceptor/internal/kms"
+)
+
+// fakeDynamoDB implements the subset of the DynamoDB API used by the store
+type fakeDynamoDB struct {
+	mu    sync.Mutex
+	items map[string]dynamoItem
+	fail  bool
+}
+
+func (f *fakeDynamoDB) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 ") {
+		http.Error(w, "unsigned request", http.StatusForbidden)
+		return
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.fail {
+		http.Error(w, `{"__type":"com.amazonaws.dynamodb.v20120810#InternalServerError"}`, http.StatusInternalServerError)
+		return
+	}
+
+	var req struct {
+		Key                       dynamoItem
+		Item                      dynamoItem
+		ConditionExpression       string
+		UpdateExpression          string
+		ExpressionAttributeValues dynamoItem
+		ExclusiveStartKey         dynamoItem
+		TransactItems             []struct {
+			Put struct {
+				Item                dynamoItem
+				ConditionExpression string
+			}
+		}
+		RequestItems map[string][]struct{ DeleteRequest struct{ Key dynamoItem } }
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := map[string]interface{}{}
+	switch strings.TrimPrefix(r.Header.Get("X-Amz-Target"), "DynamoDB_20120810.") {
+	case "GetItem":
+		if item, ok := f.items[req.Key.str("pk")]; ok {
+			resp["Item"] = item
+		}
+	case "PutItem":
+		pk := req.Item.str("pk")
+		if _, exists := f.items[pk]; exists && req.ConditionExpression == "attribute_not_exists(pk)" {
+			conditionFailed(w)
+			return
+		}
+		f.items[pk] = req.Item
+	case "TransactWriteItems":
+		for _, op := range req.TransactItems {
+			if _, exists := f.items[op.Put.Item.str("pk")]; exists && op.Put.ConditionExpression == "attribute_not_exists(pk)" {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#TransactionCanceledException","CancellationReasons":[{"Code":"ConditionalCheckFailed"},{"Code":"None"}]}`))
+				return
+			}
+		}
+		for _, op := range req.TransactItems {
+			f.items[op.Put.Item.str("pk")] = op.Put.Item
+		}
+	case "UpdateItem":
+		item, ok := f.items[req.Key.str("pk")]
+		if !ok {
+			conditionFailed(w)
+			return
+		}
+		set, add, _ := strings.Cut(strings.TrimPrefix(req.UpdateExpression, "SET "), " ADD ")
+		for _, assignment := range strings.Split(set, ",") {
+			name, value, _ := strings.Cut(assignment, "=")
+			item[strings.TrimSpace(name)] = req.ExpressionAttributeValues[strings.TrimSpace(value)]
+		}
+		if name, value, ok := strings.Cut(add, " "); ok {
+			item[name] = numberValue(item.num(name) + req.ExpressionAttributeValues.num(value))
+		}
+	case "Scan":
+		// Pages of two items exercise pagination
+		prefix := req.ExpressionAttributeValues.str(":prefix")
+		keys := make([]string, 0, len(f.items))
+		for pk := range f.items {
+			if strings.HasPrefix(pk, prefix) && pk > req.ExclusiveStartKey.str("pk") {
+				keys = append(keys, pk)
+			}
+		}
+		sort.Strings(keys)
+		items := []dynamoItem{}
+		for _, pk := range keys {
+			if len(items) == 2 {
+				resp["LastEvaluatedKey"] = dynamoKey(items[1].str("pk"))
+				break
+			}
+			items = append(items, f.items[pk])
+		}
+		resp["Items"] = items
+	case "BatchWriteItem":
+		for _, requests := range req.RequestItems {
+			for _, request := range requests {
+				delete(f.items, request.DeleteRequest.Key.str("pk"))
+			}
+		}
+	default:
+		http.Error(w, "unsupported action", http.StatusBadRequest)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func conditionFailed(w http.ResponseWriter) {
+	w.WriteHeader(http.StatusBadRequest)
+	_, _ = w.Write([]byte(`{"__type":"com.amazonaws.dynamodb.v20120810#ConditionalCheckFailedException","message":"The conditional request failed"}`))
+}
+
+func setupDynamoDBStore(t *testing.T) (*DynamoDBStore, *fakeDynamoDB) {
+	t.Helper()
+	fake := &fakeDynamoDB{items: make(map[string]dynamoItem)}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	store, err := NewDynamoDBStore(DynamoDBOptions{
+		Table:       "mappings",
+		Region:      "eu-central-1",
+		Endpoint:    server.URL,
+		Credentials: awsauth.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"},
+	}, time.Hour)
+	if err != nil {
+		t.Fatalf("NewDynamoDBStore() error: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close() })
+	return store, fake
+}
+
+func TestDynamoDBStore_StoreAndLookup(t *testing.T) {
+	store, fake := setupDynamoDBStore(t)
+	ctx := context.Background()
+
+	meta := Metadata{SecretType: "api_key", RequestID: "req-1"}
+	if err := store.Store(ctx, "__SECRET_12345678__", "mysecretpassword", meta); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+
+	if secret, found, err := store.Lookup(ctx, "__SECRET_12345678__"); err != nil || !found || secret != "mysecretpassword" {
+		t.Errorf("Lookup() = %q, %v, %v", secret, found, err)
+	}
+	if ph, found, err := store.LookupBySecret(ctx, "mysecretpassword"); err != nil || !found || ph != "__SECRET_12345678__" {
+		t.Errorf("LookupBySecret() = %q, %v, %v", ph, found, err)
+	}
+	mapping, found, err := store.LookupMapping(ctx, "__SECRET_12345678__")
+	if err != nil || !found || mapping.Metadata != meta || mapping.CreatedAt.IsZero() {
+		t.Errorf("LookupMapping() = %+v, %v, %v", mapping, found, err)
+	}
+
+	// Secrets never appear in partition keys
+	for pk, item := range fake.items {
+		if strings.Contains(pk, "mysecretpassword") {
+			t.Errorf("secret in partition key %q", pk)
+		}
+		if item.num("expires_at") == 0 && strings.HasPrefix(pk, dynamoPlaceholderPrefix) {
+			t.Errorf("item %q has no TTL attribute", pk)
+		}
+	}
+
+	if _, found, err := store.Lookup(ctx, "__SECRET_nonexist__"); err != nil || found {
+		t.Errorf("Lookup() of missing placeholder = %v, %v", found, err)
+	}
+	if err := store.Touch(ctx, "__SECRET_nonexist__"); err != nil {
+		t.Errorf("Touch() of missing placeholder error: %v", err)
+	}
+	if _, exists := fake.items[dynamoPlaceholderPrefix+"__SECRET_nonexist__"]; exists {
+		t.Error("Touch() recreated a missing mapping")
+	}
+}
+
+func TestDynamoDBStore_ExpiredItems(t *testing.T) {
+	store, fake := setupDynamoDBStore(t)
+	ctx := context.Background()
+
+	if err := store.Store(ctx, "__SECRET_12345678__", "mysecretpassword", Metadata{}); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+
+	// DynamoDB TTL deletes lazily; expired items are still returned
+	stale := numberValue(time.Now().Add(-2 * time.Hour).UnixMilli())
+	for _, item := range fake.items {
+		if _, ok := item["last_used"]; ok {
+			item["last_used"] = stale
+		}
+	}
+
+	if _, found, _ := store.Lookup(ctx, "__SECRET_12345678__"); found {
+		t.Error("Lookup() returned an expired mapping")
+	}
+	if _, found, _ := store.LookupBySecret(ctx, "mysecretpassword"); found {
+		t.Error("LookupBySecret() returned an expired mapping")
+	}
+	if err := store.Cleanup(); err != nil {
+		t.Fatalf("Cleanup() error: %v", err)
+	}
+	if store.Size() != 0 {
+		t.Errorf("Size() = %d, want 0", store.Size())
+	}
+}
+
+func TestDynamoDBStore_SizeAndPurge(t *testing.T) {
+	store, fake := setupDynamoDBStore(t)
+	ctx := context.Background()
+
+	for i, ph := range []string{"a" + namespaceSeparator + "__SECRET_00000001__", "a" + namespaceSeparator + "__SECRET_00000002__", "b" + namespaceSeparator + "__SECRET_00000003__"} {
+		meta := Metadata{SecretType: "api_key"}
+		if i == 1 {
+			meta.SecretType = "password"
+		}
+		if err := store.Store(ctx, ph, "secret-"+ph, meta); err != nil {
+			t.Fatalf("Store() error: %v", err)
+		}
+	}
+	if err := store.Cleanup(); err != nil {
+		t.Fatalf("Cleanup() error: %v", err)
+	}
+	if store.Size() != 3 {
+		t.Errorf("Size() = %d, want 3", store.Size())
+	}
+
+	deleted, err := store.Purge(ctx, PurgeFilter{Namespace: "a", SecretType: "api_key"})
+	if err != nil || deleted != 1 {
+		t.Fatalf("Purge() = %d, %v, want 1", deleted, err)
+	}
+	if _, found, _ := store.LookupBySecret(ctx, "secret-a"+namespaceSeparator+"__SECRET_00000001__"); found {
+		t.Error("reverse index item not purged")
+	}
+
+	deleted, err = store.Purge(ctx, PurgeFilter{})
+	if err != nil || deleted != 2 {
+		t.Fatalf("Purge() all = %d, %v, want 2", deleted, err)
+	}
+	if _, exists := fake.items[dynamoIndexKeyItem]; !exists {
+		t.Error("Purge() deleted the index key")
+	}
+}
+
+func TestDynamoDBStore_SharedIndexKeyAndKeyring(t *testing.T) {
+	store, fake := setupDynamoDBStore(t)
+	ctx := context.Background()
+
+	// A second instance on the same table agrees on the index key
+	server := httptest.NewServer(fake)
+	defer server.Close()
+	other, err := NewDynamoDBStore(DynamoDBOptions{
+		Table:       "mappings",
+		Region:      "eu-central-1",
+		Endpoint:    server.URL,
+		Credentials: awsauth.Credentials{AccessKeyID: "AKID", SecretAccessKey: "secret"},
+	}, time.Hour)
+	if err != nil {
+		t.Fatalf("NewDynamoDBStore() error: %v", err)
+	}
+	defer other.Close()
+	if string(other.indexKey) != string(store.indexKey) {
+		t.Error("instances use different index keys")
+	}
+
+	if err := store.SaveKey(ctx, kms.WrappedKey{ID: "k1", Provider: "local", Wrapped: []byte("wrapped")}); err != nil {
+		t.Fatalf("SaveKey() error: %v", err)
+	}
+	keys, err := other.LoadKeys(ctx)
+	if err != nil || len(keys) != 1 || keys[0].ID != "k1" {
+		t.Errorf("LoadKeys() = %+v, %v", keys, err)
+	}
+}
+
+func TestDynamoDBStore_BackendErrors(t *testing.T) {
+	store, fake := setupDynamoDBStore(t)
+	ctx := context.Background()
+
+	fake.mu.Lock()
+	fake.fail = true
+	fake.mu.Unlock()
+
+	if err := store.Store(ctx, "__SECRET_12345678__", "mysecretpassword", Metadata{}); err == nil {
+		t.Error("Store() should fail")
+	}
+	if _, found, err := store.Lookup(ctx, "__SECRET_12345678__"); err == nil || found {
+		t.Errorf("Lookup() = %v, %v, want error", found, err)
+	}
+	if _, found, err := store.LookupBySecret(ctx, "mysecretpassword"); err == nil || found {
+		t.Errorf("LookupBySecret() = %v, %v, want error", found, err)
+	}
+}
+
+func TestNewDynamoDBStore_RequiresTable(t *testing.T) {
+	if _, err := NewDynamoDBStore(DynamoDBOptions{Region: "eu-central-1"}, time.Hour); err == nil {
+		t.Error("NewDynamoDBStore() without table should fail")
+	}
+}
@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/hfi/llm-secret-interceptor/internal/kms"
+)
+
+// EncryptedStore wraps a MappingStore and seals secrets with a keyring
+// before they reach the underlying store. Secrets are sealed
+// deterministically, so reverse lookups keep working on the sealed values.
+type EncryptedStore struct {
+	inner   MappingStore
+	keyring *kms.Keyring
+}
+
+// NewEncryptedStore creates a store that encrypts secrets stored in inner
+func NewEncryptedStore(inner MappingStore, keyring *kms.Keyring) *EncryptedStore {
+	return &EncryptedStore{
+		inner:   inner,
+		keyring: keyring,
+	}
+}
+
+// Store seals the secret with the active key and saves the mapping
+func (e *EncryptedStore) Store(ctx context.Context, placeholder, secret string, meta Metadata) error {
+	sealed, err := e.keyring.Seal(secret)
+	if err != nil {
+		return err
+	}
+	err = e.inner.Store(ctx, placeholder, sealed, meta)
+	if !errors.Is(err, ErrPlaceholderConflict) {
+		return err
+	}
+
+	// Sealing is only deterministic per data key; a mapping sealed before a
+	// key rotation or by another instance may hold the same secret
+	value, found, lookupErr := e.inner.Lookup(ctx, placeholder)
+	if lookupErr != nil || !found {
+		return err
+	}
+	existing, openErr := e.open(ctx, value)
+	if openErr != nil || existing != secret {
+		return err
+	}
+	return nil
+}
+
+// Lookup retrieves and decrypts a secret by its placeholder
+func (e *EncryptedStore) Lookup(ctx context.Context, placeholder string) (string, bool, error) {
+	value, found, err := e.inner.Lookup(ctx, placeholder)
+	if err != nil || !found {
+		return "", false, err
+	}
+
+	secret, err := e.open(ctx, value)
+	if err != nil {
+		return "", false, err
+	}
+	return secret, true, nil
+}
+
+// LookupMapping retrieves the full mapping with the secret decrypted
+func (e *EncryptedStore) LookupMapping(ctx context.Context, placeholder string) (*Mapping, bool, error) {
+	mapping, found, err := e.inner.LookupMapping(ctx, placeholder)
+	if err != nil || !found {
+		return nil, false, err
+	}
+
+	mapping.Secret, err = e.open(ctx, mapping.Secret)
+	if err != nil {
+		return nil, false, err
+	}
+	return mapping, true, nil
+}
+
+// open decrypts a stored value. Mappings written before encryption was
+// enabled are returned as-is.
+func (e *EncryptedStore) open(ctx context.Context, value string) (string, error) {
+	if !kms.IsSealed(value) {
+		return value, nil
+	}
+
+	secret, err := e.keyring.Open(ctx, value)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt secret: %w", err)
+	}
+	return secret, nil
+}
+
+// LookupBySecret retrieves a placeholder by the secret value, trying the
+// value sealed under every known key so mappings survive key rotation
+func (e *EncryptedStore) LookupBySecret(ctx context.Context, secret string) (string, bool, error) {
+	for _, sealed := range e.keyring.SealAll(secret) {
+		placeholder, found, err := e.inner.LookupBySecret(ctx, sealed)
+		if err != nil || found {
+			return placeholder, found, err
+		}
+	}
+	return e.inner.LookupBySecret(ctx, secret)
+}
+
+// Touch updates the LastUsed timestamp for a mapping
+func (e *EncryptedStore) Touch(ctx context.Context, placeholder string) error {
+	return e.inner.Touch(ctx, placeholder)
+}
+
+// Cleanup removes expired mappings
+func (e *EncryptedStore) Cleanup() error {
+	return e.inner.Cleanup()
+}
+
+// Purge deletes the mappings selected by filter from the underlying store
+func (e *EncryptedStore) Purge(ctx context.Context, filter PurgeFilter) (int, error) {
+	return Purge(ctx, e.inner, filter)
+}
+
+// List returns the mappings of the underlying store selected by filter.
+// Their secrets are never decrypted.
+func (e *EncryptedStore) List(ctx context.Context, filter PurgeFilter, limit int) ([]Mapping, error) {
+	return List(ctx, e.inner, filter, limit)
+}
+
+// Ping checks the connection of the underlying store
+func (e *EncryptedStore) Ping(ctx context.Context) error {
+	return Ping(ctx, e.inner)
+}
+
+// NextSequence increments a placeholder counter of the underlying store
+func (e *EncryptedStore) NextSequence(ctx context.Context, scope string) (int, error) {
+	sequencer, ok := e.inner.(Sequencer)
+	if !ok {
+		return 0, errNoSequencer
+	}
+	return sequencer.NextSequence(ctx, scope)
+}
+
+// RecordRestore counts a restoration of placeholder in the underlying store
+func (e *EncryptedStore) RecordRestore(ctx context.Context, placeholder string) error {
+	return RecordRestore(ctx, e.inner, placeholder)
+}
+
+// UsageStats aggregates the restoration counts of the underlying store
+func (e *EncryptedStore) UsageStats(ctx context.Context) (UsageStats, error) {
+	return CollectUsageStats(ctx, e.inner)
+}
+
+// Size returns the number of stored mappings
+func (e *EncryptedStore) Size() int {
+	return e.inner.Size()
+}
+
+// Close releases the underlying store
+func (e *EncryptedStore) Close() error {
+	return e.inner.Close()
+}
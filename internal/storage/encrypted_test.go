@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/hfi/llm-secret-interceptor/internal/kms"
+)
+
+func newTestEncryptedStore(t *testing.T) (*EncryptedStore, *MockStore, *kms.Keyring) {
+	t.Helper()
+	provider, err := kms.NewLocalProvider(make([]byte, 32))
+	if err != nil {
+		t.Fatalf("NewLocalProvider() error: %v", err)
+	}
+	keyring := kms.NewKeyring(provider, kms.NewMemoryKeyStore())
+	if err := keyring.Init(context.Background()); err != nil {
+		t.Fatalf("Init() error: %v", err)
+	}
+	inner := NewMockStore()
+	return NewEncryptedStore(inner, keyring), inner, keyring
+}
+
+func TestEncryptedStore_StoreAndLookup(t *testing.T) {
+	store, inner, _ := newTestEncryptedStore(t)
+
+	if err := store.Store(context.Background(), "__SECRET_12345678__", "mysecretpassword", Metadata{}); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+
+	// The underlying store never sees the plaintext
+	for placeholder, value := range inner.mappings {
+		if strings.Contains(value, "mysecretpassword") {
+			t.Errorf("inner store holds plaintext for %s", placeholder)
+		}
+	}
+
+	got, found, _ := store.Lookup(context.Background(), "__SECRET_12345678__")
+	if !found || got != "mysecretpassword" {
+		t.Errorf("Lookup() = %q, %v, want %q", got, found, "mysecretpassword")
+	}
+
+	placeholder, found, _ := store.LookupBySecret(context.Background(), "mysecretpassword")
+	if !found || placeholder != "__SECRET_12345678__" {
+		t.Errorf("LookupBySecret() = %q, %v", placeholder, found)
+	}
+}
+
+func TestEncryptedStore_AfterRotation(t *testing.T) {
+	store, _, keyring := newTestEncryptedStore(t)
+
+	if err := store.Store(context.Background(), "__SECRET_12345678__", "mysecretpassword", Metadata{}); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+	if _, err := keyring.Rotate(context.Background()); err != nil {
+		t.Fatalf("Rotate() error: %v", err)
+	}
+
+	got, found, _ := store.Lookup(context.Background(), "__SECRET_12345678__")
+	if !found || got != "mysecretpassword" {
+		t.Errorf("Lookup() after rotation = %q, %v", got, found)
+	}
+	placeholder, found, _ := store.LookupBySecret(context.Background(), "mysecretpassword")
+	if !found || placeholder != "__SECRET_12345678__" {
+		t.Errorf("LookupBySecret() after rotation = %q, %v", placeholder, found)
+	}
+}
+
+func TestEncryptedStore_LegacyPlaintext(t *testing.T) {
+	store, inner, _ := newTestEncryptedStore(t)
+
+	// Mapping written before encryption was enabled
+	if err := inner.Store(context.Background(), "__SECRET_aaaaaaaa__", "legacysecret", Metadata{}); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+
+	got, found, _ := store.Lookup(context.Background(), "__SECRET_aaaaaaaa__")
+	if !found || got != "legacysecret" {
+		t.Errorf("Lookup() = %q, %v for legacy mapping", got, found)
+	}
+	placeholder, found, _ := store.LookupBySecret(context.Background(), "legacysecret")
+	if !found || placeholder != "__SECRET_aaaaaaaa__" {
+		t.Errorf("LookupBySecret() = %q, %v for legacy mapping", placeholder, found)
+	}
+}
@@ -0,0 +1,156 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/metrics"
+)
+
+// InstrumentedStore records Prometheus metrics for every operation of the
+// wrapped store: hits and misses of lookups, errors of writes, and latency.
+// The hit rate of lookup_by_secret is the rate at which existing placeholders
+// are reused for secrets seen before.
+type InstrumentedStore struct {
+	inner   MappingStore
+	backend string
+}
+
+// NewInstrumentedStore wraps a store, labeling its metrics with backend
+func NewInstrumentedStore(inner MappingStore, backend string) *InstrumentedStore {
+	return &InstrumentedStore{
+		inner:   inner,
+		backend: backend,
+	}
+}
+
+// Store saves a new secret-placeholder mapping
+func (i *InstrumentedStore) Store(ctx context.Context, placeholder, secret string, meta Metadata) error {
+	start := time.Now()
+	err := i.inner.Store(ctx, placeholder, secret, meta)
+	result := errorResult(err)
+	if errors.Is(err, ErrPlaceholderConflict) {
+		result = "conflict"
+	}
+	i.record("store", result, start)
+	return err
+}
+
+// Lookup retrieves a secret by its placeholder
+func (i *InstrumentedStore) Lookup(ctx context.Context, placeholder string) (string, bool, error) {
+	start := time.Now()
+	secret, found, err := i.inner.Lookup(ctx, placeholder)
+	i.record("lookup", lookupResult(found, err), start)
+	return secret, found, err
+}
+
+// LookupMapping retrieves the full mapping
+func (i *InstrumentedStore) LookupMapping(ctx context.Context, placeholder string) (*Mapping, bool, error) {
+	start := time.Now()
+	mapping, found, err := i.inner.LookupMapping(ctx, placeholder)
+	i.record("lookup_mapping", lookupResult(found, err), start)
+	return mapping, found, err
+}
+
+// LookupBySecret retrieves a placeholder by the secret value
+func (i *InstrumentedStore) LookupBySecret(ctx context.Context, secret string) (string, bool, error) {
+	start := time.Now()
+	placeholder, found, err := i.inner.LookupBySecret(ctx, secret)
+	i.record("lookup_by_secret", lookupResult(found, err), start)
+	return placeholder, found, err
+}
+
+// Touch updates the LastUsed timestamp for a mapping
+func (i *InstrumentedStore) Touch(ctx context.Context, placeholder string) error {
+	start := time.Now()
+	err := i.inner.Touch(ctx, placeholder)
+	i.record("touch", errorResult(err), start)
+	return err
+}
+
+// Cleanup removes expired mappings
+func (i *InstrumentedStore) Cleanup() error {
+	start := time.Now()
+	err := i.inner.Cleanup()
+	i.record("cleanup", errorResult(err), start)
+	return err
+}
+
+// Purge deletes the mappings selected by filter
+func (i *InstrumentedStore) Purge(ctx context.Context, filter PurgeFilter) (int, error) {
+	start := time.Now()
+	deleted, err := Purge(ctx, i.inner, filter)
+	i.record("purge", errorResult(err), start)
+	return deleted, err
+}
+
+// List returns the mappings selected by filter
+func (i *InstrumentedStore) List(ctx context.Context, filter PurgeFilter, limit int) ([]Mapping, error) {
+	start := time.Now()
+	mappings, err := List(ctx, i.inner, filter, limit)
+	i.record("list", errorResult(err), start)
+	return mappings, err
+}
+
+// Ping checks the connection of the underlying store
+func (i *InstrumentedStore) Ping(ctx context.Context) error {
+	return Ping(ctx, i.inner)
+}
+
+// NextSequence increments a placeholder counter of the underlying store
+func (i *InstrumentedStore) NextSequence(ctx context.Context, scope string) (int, error) {
+	sequencer, ok := i.inner.(Sequencer)
+	if !ok {
+		return 0, errNoSequencer
+	}
+	return sequencer.NextSequence(ctx, scope)
+}
+
+// RecordRestore counts a restoration of placeholder
+func (i *InstrumentedStore) RecordRestore(ctx context.Context, placeholder string) error {
+	start := time.Now()
+	err := RecordRestore(ctx, i.inner, placeholder)
+	i.record("record_restore", errorResult(err), start)
+	return err
+}
+
+// UsageStats aggregates the restoration counts of all mappings
+func (i *InstrumentedStore) UsageStats(ctx context.Context) (UsageStats, error) {
+	start := time.Now()
+	stats, err := CollectUsageStats(ctx, i.inner)
+	i.record("usage_stats", errorResult(err), start)
+	return stats, err
+}
+
+// Size returns the number of stored mappings
+func (i *InstrumentedStore) Size() int {
+	return i.inner.Size()
+}
+
+// Close releases the underlying store
+func (i *InstrumentedStore) Close() error {
+	return i.inner.Close()
+}
+
+func (i *InstrumentedStore) record(operation, result string, start time.Time) {
+	metrics.RecordStoreOperation(i.backend, operation, result, time.Since(start).Seconds())
+}
+
+func lookupResult(found bool, err error) string {
+	switch {
+	case err != nil:
+		return "error"
+	case found:
+		return "hit"
+	default:
+		return "miss"
+	}
+}
+
+func errorResult(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
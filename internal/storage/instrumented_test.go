@@ -0,0 +1,57 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/hfi/llm-secret-interceptor/internal/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestInstrumentedStore_Results(t *testing.T) {
+	inner := NewMockStore()
+	store := NewInstrumentedStore(inner, "test")
+
+	counter := func(operation, result string) float64 {
+		return testutil.ToFloat64(metrics.StoreOperations.WithLabelValues("test", operation, result))
+	}
+	cases := []struct {
+		operation, result string
+		before            float64
+	}{
+		{operation: "store", result: "ok"},
+		{operation: "store", result: "error"},
+		{operation: "lookup", result: "hit"},
+		{operation: "lookup", result: "miss"},
+		{operation: "lookup_by_secret", result: "hit"},
+		{operation: "lookup_by_secret", result: "miss"},
+	}
+	for i := range cases {
+		cases[i].before = counter(cases[i].operation, cases[i].result)
+	}
+
+	if err := store.Store(context.Background(), "__SECRET_1__", "secret1", Metadata{}); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+	_, _, _ = store.Lookup(context.Background(), "__SECRET_1__")
+	_, _, _ = store.Lookup(context.Background(), "__SECRET_missing__")
+	_, _, _ = store.LookupBySecret(context.Background(), "secret1")
+	_, _, _ = store.LookupBySecret(context.Background(), "unknown")
+
+	inner.storeErr = errors.New("backend down")
+	if err := store.Store(context.Background(), "__SECRET_2__", "secret2", Metadata{}); err == nil {
+		t.Error("Store() should pass through the backend error")
+	}
+
+	// Each operation/result combination occurred exactly once
+	for _, c := range cases {
+		if got := counter(c.operation, c.result) - c.before; got != 1 {
+			t.Errorf("%s/%s count = %v, want 1", c.operation, c.result, got)
+		}
+	}
+
+	if n := testutil.CollectAndCount(metrics.StoreOperationDuration); n == 0 {
+		t.Error("no latency observations recorded")
+	}
+}
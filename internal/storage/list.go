@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// Lister is implemented by stores that can enumerate their mappings
+type Lister interface {
+	// List returns up to limit mappings selected by filter, newest first.
+	// Secrets are left empty. A limit of 0 returns all selected mappings.
+	List(ctx context.Context, filter PurgeFilter, limit int) ([]Mapping, error)
+}
+
+// List returns up to limit mappings of store selected by filter, without
+// their secrets
+func List(ctx context.Context, store MappingStore, filter PurgeFilter, limit int) ([]Mapping, error) {
+	lister, ok := store.(Lister)
+	if !ok {
+		return nil, fmt.Errorf("store %T does not support listing", store)
+	}
+	return lister.List(ctx, filter, limit)
+}
+
+// listed returns a copy of mapping without its secret
+func listed(mapping *Mapping) Mapping {
+	entry := *mapping
+	entry.Secret = ""
+	return entry
+}
+
+// newestFirst orders mappings by descending creation time and truncates
+// them to limit if it is positive
+func newestFirst(mappings []Mapping, limit int) []Mapping {
+	sort.Slice(mappings, func(i, j int) bool {
+		a, b := mappings[i], mappings[j]
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.Placeholder < b.Placeholder
+	})
+	if limit > 0 && len(mappings) > limit {
+		mappings = mappings[:limit]
+	}
+	return mappings
+}
@@ -0,0 +1,97 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestList_Backends(t *testing.T) {
+	ctx := context.Background()
+	for name, backend := range consistencyBackends() {
+		t.Run(name, func(t *testing.T) {
+			store, _ := backend(t)
+			for _, m := range []struct {
+				placeholder, secret, secretType string
+			}{
+				{"__SECRET_1__", "sk-one", "api_key"},
+				{"__SECRET_2__", "hunter2", "password"},
+				{"__SECRET_3__", "sk-three", "api_key"},
+			} {
+				if err := store.Store(ctx, m.placeholder, m.secret, Metadata{SecretType: m.secretType}); err != nil {
+					t.Fatalf("Store() error: %v", err)
+				}
+				// Creation times are recorded with millisecond precision
+				time.Sleep(2 * time.Millisecond)
+			}
+
+			all, err := List(ctx, store, PurgeFilter{}, 0)
+			if err != nil {
+				t.Fatalf("List() error: %v", err)
+			}
+			if len(all) != 3 || all[0].Placeholder != "__SECRET_3__" || all[2].Placeholder != "__SECRET_1__" {
+				t.Fatalf("List() = %+v, want all mappings newest first", all)
+			}
+			for _, m := range all {
+				if m.Secret != "" {
+					t.Errorf("List() returned the secret of %s", m.Placeholder)
+				}
+			}
+
+			keys, err := List(ctx, store, PurgeFilter{SecretType: "api_key"}, 1)
+			if err != nil || len(keys) != 1 || keys[0].Placeholder != "__SECRET_3__" {
+				t.Errorf("List(api_key, 1) = %+v, %v, want __SECRET_3__", keys, err)
+			}
+
+			one, err := List(ctx, store, PurgeFilter{Placeholder: "__SECRET_2__"}, 0)
+			if err != nil || len(one) != 1 || one[0].Metadata.SecretType != "password" {
+				t.Errorf("List(__SECRET_2__) = %+v, %v, want the password mapping", one, err)
+			}
+
+			deleted, err := Purge(ctx, store, PurgeFilter{Placeholder: "__SECRET_2__"})
+			if err != nil || deleted != 1 {
+				t.Fatalf("Purge(__SECRET_2__) = %d, %v, want 1", deleted, err)
+			}
+			if _, found, _ := store.Lookup(ctx, "__SECRET_1__"); !found {
+				t.Error("Purge() of one placeholder deleted others")
+			}
+		})
+	}
+}
+
+func TestList_Namespace(t *testing.T) {
+	ctx := context.Background()
+	root := NewMemoryStore(time.Hour)
+	defer func() { _ = root.Close() }()
+
+	alice := WithNamespace(root, "alice")
+	if err := alice.Store(ctx, "__SECRET_1__", "sk-alice", Metadata{}); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+	if err := WithNamespace(root, "bob").Store(ctx, "__SECRET_1__", "sk-bob", Metadata{}); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+
+	mappings, err := List(ctx, alice, PurgeFilter{}, 0)
+	if err != nil || len(mappings) != 1 || mappings[0].Placeholder != "__SECRET_1__" {
+		t.Fatalf("List() = %+v, %v, want alice's mapping only", mappings, err)
+	}
+
+	all, err := List(ctx, root, PurgeFilter{}, 0)
+	if err != nil || len(all) != 2 {
+		t.Fatalf("List() on the shared store = %+v, %v, want 2 mappings", all, err)
+	}
+	namespace, placeholder := SplitNamespace(all[0].Placeholder)
+	if (namespace != "alice" && namespace != "bob") || placeholder != "__SECRET_1__" {
+		t.Errorf("SplitNamespace(%q) = %q, %q", all[0].Placeholder, namespace, placeholder)
+	}
+	if got := NamespacedPlaceholder("alice", "__SECRET_1__"); got != "alice"+namespaceSeparator+"__SECRET_1__" {
+		t.Errorf("NamespacedPlaceholder() = %q", got)
+	}
+}
+
+func TestList_Unsupported(t *testing.T) {
+	if _, err := List(context.Background(), NewMockStore(), PurgeFilter{}, 0); err == nil {
+		t.Error("List() expected error for a store without listing support")
+	}
+}
@@ -0,0 +1,94 @@
+package storage
+
+import "container/list"
+
+// lru is a fixed-capacity least-recently-used map. It is not safe for
+// concurrent use; callers hold their own lock.
+type lru[K comparable, V any] struct {
+	capacity int
+	order    *list.List // front = most recently used
+	items    map[K]*list.Element
+}
+
+type lruItem[K comparable, V any] struct {
+	key   K
+	value V
+}
+
+func newLRU[K comparable, V any](capacity int) *lru[K, V] {
+	return &lru[K, V]{
+		capacity: capacity,
+		order:    list.New(),
+		items:    make(map[K]*list.Element),
+	}
+}
+
+// get returns the value for key and marks it as recently used
+func (c *lru[K, V]) get(key K) (V, bool) {
+	if el, ok := c.items[key]; ok {
+		c.order.MoveToFront(el)
+		return el.Value.(*lruItem[K, V]).value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// add inserts or updates a value and returns the evicted entry, if any
+func (c *lru[K, V]) add(key K, value V) (evictedKey K, evictedValue V, evicted bool) {
+	if el, ok := c.items[key]; ok {
+		el.Value.(*lruItem[K, V]).value = value
+		c.order.MoveToFront(el)
+		return evictedKey, evictedValue, false
+	}
+
+	c.items[key] = c.order.PushFront(&lruItem[K, V]{key: key, value: value})
+	if c.capacity > 0 && c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		item := oldest.Value.(*lruItem[K, V])
+		c.order.Remove(oldest)
+		delete(c.items, item.key)
+		return item.key, item.value, true
+	}
+	return evictedKey, evictedValue, false
+}
+
+// resize changes the capacity and returns the keys evicted to fit it
+func (c *lru[K, V]) resize(capacity int) []K {
+	c.capacity = capacity
+	var evicted []K
+	for capacity > 0 && c.order.Len() > capacity {
+		oldest := c.order.Back()
+		item := oldest.Value.(*lruItem[K, V])
+		c.order.Remove(oldest)
+		delete(c.items, item.key)
+		evicted = append(evicted, item.key)
+	}
+	return evicted
+}
+
+// remove deletes a key
+func (c *lru[K, V]) remove(key K) {
+	if el, ok := c.items[key]; ok {
+		c.order.Remove(el)
+		delete(c.items, key)
+	}
+}
+
+// each calls fn for every entry from least to most recently used
+func (c *lru[K, V]) each(fn func(key K, value V)) {
+	for el := c.order.Back(); el != nil; {
+		prev := el.Prev()
+		item := el.Value.(*lruItem[K, V])
+		fn(item.key, item.value)
+		el = prev
+	}
+}
+
+func (c *lru[K, V]) len() int {
+	return c.order.Len()
+}
+
+func (c *lru[K, V]) clear() {
+	c.order.Init()
+	c.items = make(map[K]*list.Element)
+}
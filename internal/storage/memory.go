@@ -2,15 +2,24 @@
 package storage
 
 import (
+	"context"
+	"sort"
 	"sync"
 	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/metrics"
 )
 
 // MemoryStore is an in-memory implementation of MappingStore
 type MemoryStore struct {
 	mu              sync.RWMutex
 	mappings        map[string]*Mapping // keyed by placeholder
-	secretIndex     map[string]string   // secret -> placeholder reverse lookup
+	secretIndex     map[string]string   // HMAC(secret) -> placeholder reverse lookup
+	indexKey        []byte              // HMAC key for the reverse index
+	indexKeyOnce    sync.Once
+	recency         *lru[string, struct{}] // usage order, only tracked with a size limit
+	snapshot        *snapshotConfig        // nil unless snapshots are enabled
+	snapshotMu      sync.Mutex             // serializes snapshot writes
 	ttl             time.Duration
 	cleanupInterval time.Duration
 	stopCleanup     chan struct{}
@@ -32,65 +41,153 @@ func NewMemoryStore(ttl time.Duration) *MemoryStore {
 	return store
 }
 
+// SetMaxEntries limits the number of stored mappings. When the limit is
+// reached, the least recently used mapping is evicted. 0 disables the limit.
+func (m *MemoryStore) SetMaxEntries(maxEntries int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if maxEntries <= 0 {
+		m.recency = nil
+		return
+	}
+
+	if m.recency == nil {
+		// Seed the usage order from the existing mappings, oldest first
+		existing := make([]*Mapping, 0, len(m.mappings))
+		for _, mapping := range m.mappings {
+			existing = append(existing, mapping)
+		}
+		sort.Slice(existing, func(i, j int) bool {
+			return existing[i].LastUsed.Before(existing[j].LastUsed)
+		})
+		m.recency = newLRU[string, struct{}](0)
+		for _, mapping := range existing {
+			m.recency.add(mapping.Placeholder, struct{}{})
+		}
+	}
+
+	for _, placeholder := range m.recency.resize(maxEntries) {
+		m.evict(placeholder)
+	}
+}
+
 // Store saves a new secret-placeholder mapping
-func (m *MemoryStore) Store(placeholder, secret string) error {
+func (m *MemoryStore) Store(_ context.Context, placeholder, secret string, meta Metadata) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	now := time.Now()
+	if existing, ok := m.mappings[placeholder]; ok {
+		if existing.Secret != secret {
+			return ErrPlaceholderConflict
+		}
+		existing.LastUsed = now
+		m.touchRecency(placeholder)
+		return nil
+	}
+
 	m.mappings[placeholder] = &Mapping{
 		Secret:      secret,
 		Placeholder: placeholder,
 		LastUsed:    now,
 		CreatedAt:   now,
+		Metadata:    meta,
+	}
+	m.secretIndex[m.digest(secret)] = placeholder
+
+	if m.recency != nil {
+		if evicted, _, ok := m.recency.add(placeholder, struct{}{}); ok {
+			m.evict(evicted)
+		}
 	}
-	m.secretIndex[secret] = placeholder
 
 	return nil
 }
 
+// digest returns the reverse index key for a secret
+func (m *MemoryStore) digest(secret string) string {
+	m.indexKeyOnce.Do(func() {
+		m.indexKey = newIndexKey()
+	})
+	return secretDigest(m.indexKey, secret)
+}
+
+// touchRecency marks a mapping as recently used; callers hold the lock
+func (m *MemoryStore) touchRecency(placeholder string) {
+	if m.recency != nil {
+		m.recency.get(placeholder)
+	}
+}
+
+// evict removes a mapping pushed out by the size limit; callers hold the lock
+func (m *MemoryStore) evict(placeholder string) {
+	if mapping, ok := m.mappings[placeholder]; ok {
+		if digest := m.digest(mapping.Secret); m.secretIndex[digest] == placeholder {
+			delete(m.secretIndex, digest)
+		}
+		delete(m.mappings, placeholder)
+		metrics.RecordMappingEvicted()
+	}
+}
+
 // Lookup retrieves a secret by its placeholder
-func (m *MemoryStore) Lookup(placeholder string) (string, bool) {
+func (m *MemoryStore) Lookup(_ context.Context, placeholder string) (string, bool, error) {
 	m.mu.RLock()
 	mapping, ok := m.mappings[placeholder]
 	m.mu.RUnlock()
 
 	if !ok {
-		return "", false
+		return "", false, nil
 	}
 
 	// Update last used time
 	m.mu.Lock()
 	mapping.LastUsed = time.Now()
+	m.touchRecency(placeholder)
 	m.mu.Unlock()
 
-	return mapping.Secret, true
+	return mapping.Secret, true, nil
+}
+
+// LookupMapping retrieves a copy of the full mapping without refreshing it
+func (m *MemoryStore) LookupMapping(_ context.Context, placeholder string) (*Mapping, bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	mapping, ok := m.mappings[placeholder]
+	if !ok {
+		return nil, false, nil
+	}
+	mappingCopy := *mapping
+	return &mappingCopy, true, nil
 }
 
 // LookupBySecret retrieves a placeholder by the secret value
-func (m *MemoryStore) LookupBySecret(secret string) (string, bool) {
+func (m *MemoryStore) LookupBySecret(ctx context.Context, secret string) (string, bool, error) {
 	m.mu.RLock()
-	placeholder, ok := m.secretIndex[secret]
+	placeholder, ok := m.secretIndex[m.digest(secret)]
 	m.mu.RUnlock()
 
 	if ok {
 		// Touch to update last used
-		if err := m.Touch(placeholder); err != nil {
+		if err := m.Touch(ctx, placeholder); err != nil {
 			// Log error but don't fail the lookup
 			_ = err // Touch only updates timestamp, safe to ignore
 		}
 	}
 
-	return placeholder, ok
+	return placeholder, ok, nil
 }
 
 // Touch updates the LastUsed timestamp
-func (m *MemoryStore) Touch(placeholder string) error {
+func (m *MemoryStore) Touch(_ context.Context, placeholder string) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
 	if mapping, ok := m.mappings[placeholder]; ok {
 		mapping.LastUsed = time.Now()
+		m.touchRecency(placeholder)
 	}
 
 	return nil
@@ -104,14 +201,81 @@ func (m *MemoryStore) Cleanup() error {
 	now := time.Now()
 	for placeholder, mapping := range m.mappings {
 		if now.Sub(mapping.LastUsed) > m.ttl {
-			delete(m.secretIndex, mapping.Secret)
+			delete(m.secretIndex, m.digest(mapping.Secret))
 			delete(m.mappings, placeholder)
+			if m.recency != nil {
+				m.recency.remove(placeholder)
+			}
 		}
 	}
 
 	return nil
 }
 
+// Purge deletes the mappings selected by filter
+func (m *MemoryStore) Purge(_ context.Context, filter PurgeFilter) (int, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	deleted := 0
+	for placeholder, mapping := range m.mappings {
+		if !filter.matches(mapping, now) {
+			continue
+		}
+		if digest := m.digest(mapping.Secret); m.secretIndex[digest] == placeholder {
+			delete(m.secretIndex, digest)
+		}
+		delete(m.mappings, placeholder)
+		if m.recency != nil {
+			m.recency.remove(placeholder)
+		}
+		deleted++
+	}
+
+	return deleted, nil
+}
+
+// List returns the mappings selected by filter without their secrets
+func (m *MemoryStore) List(_ context.Context, filter PurgeFilter, limit int) ([]Mapping, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	var mappings []Mapping
+	for _, mapping := range m.mappings {
+		if filter.matches(mapping, now) {
+			mappings = append(mappings, listed(mapping))
+		}
+	}
+	return newestFirst(mappings, limit), nil
+}
+
+// RecordRestore counts a restoration of placeholder
+func (m *MemoryStore) RecordRestore(_ context.Context, placeholder string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if mapping, ok := m.mappings[placeholder]; ok {
+		mapping.RestoreCount++
+		mapping.LastRestored = time.Now()
+	}
+	return nil
+}
+
+// UsageStats aggregates the restoration counts of all mappings
+func (m *MemoryStore) UsageStats(_ context.Context) (UsageStats, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stats := newUsageStats()
+	for _, mapping := range m.mappings {
+		stats.add(mapping)
+	}
+	stats.finish()
+	return stats, nil
+}
+
 // Size returns the number of stored mappings
 func (m *MemoryStore) Size() int {
 	m.mu.RLock()
@@ -119,9 +283,17 @@ func (m *MemoryStore) Size() int {
 	return len(m.mappings)
 }
 
-// Close stops the cleanup goroutine and releases resources
+// Close stops the background goroutines and writes a final snapshot if
+// snapshots are enabled
 func (m *MemoryStore) Close() error {
 	close(m.stopCleanup)
+
+	m.mu.RLock()
+	snapshots := m.snapshot != nil
+	m.mu.RUnlock()
+	if snapshots {
+		return m.SaveSnapshot(context.Background())
+	}
 	return nil
 }
 
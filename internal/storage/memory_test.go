@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"testing"
 	"time"
 )
@@ -13,13 +14,13 @@ func TestMemoryStore_StoreAndLookup(t *testing.T) {
 	secret := "mysecretpassword"
 
 	// Store
-	err := store.Store(placeholder, secret)
+	err := store.Store(context.Background(), placeholder, secret, Metadata{})
 	if err != nil {
 		t.Fatalf("Store() error: %v", err)
 	}
 
 	// Lookup by placeholder
-	got, found := store.Lookup(placeholder)
+	got, found, _ := store.Lookup(context.Background(), placeholder)
 	if !found {
 		t.Error("Lookup() returned not found")
 	}
@@ -36,13 +37,13 @@ func TestMemoryStore_LookupBySecret(t *testing.T) {
 	secret := "mysecretpassword"
 
 	// Store
-	err := store.Store(placeholder, secret)
+	err := store.Store(context.Background(), placeholder, secret, Metadata{})
 	if err != nil {
 		t.Fatalf("Store() error: %v", err)
 	}
 
 	// Lookup by secret
-	got, found := store.LookupBySecret(secret)
+	got, found, _ := store.LookupBySecret(context.Background(), secret)
 	if !found {
 		t.Error("LookupBySecret() returned not found")
 	}
@@ -55,7 +56,7 @@ func TestMemoryStore_LookupNotFound(t *testing.T) {
 	store := NewMemoryStore(time.Hour)
 	defer store.Close()
 
-	_, found := store.Lookup("nonexistent")
+	_, found, _ := store.Lookup(context.Background(), "nonexistent")
 	if found {
 		t.Error("Lookup() should return not found for nonexistent key")
 	}
@@ -69,9 +70,9 @@ func TestMemoryStore_Size(t *testing.T) {
 		t.Errorf("Size() = %d, want 0", store.Size())
 	}
 
-	store.Store("__SECRET_1__", "secret1")
-	store.Store("__SECRET_2__", "secret2")
-	store.Store("__SECRET_3__", "secret3")
+	store.Store(context.Background(), "__SECRET_1__", "secret1", Metadata{})
+	store.Store(context.Background(), "__SECRET_2__", "secret2", Metadata{})
+	store.Store(context.Background(), "__SECRET_3__", "secret3", Metadata{})
 
 	if store.Size() != 3 {
 		t.Errorf("Size() = %d, want 3", store.Size())
@@ -83,10 +84,10 @@ func TestMemoryStore_Cleanup(t *testing.T) {
 	store := NewMemoryStore(50 * time.Millisecond)
 	defer store.Close()
 
-	store.Store("__SECRET_1__", "secret1")
+	store.Store(context.Background(), "__SECRET_1__", "secret1", Metadata{})
 
 	// Verify it's stored
-	_, found := store.Lookup("__SECRET_1__")
+	_, found, _ := store.Lookup(context.Background(), "__SECRET_1__")
 	if !found {
 		t.Fatal("Secret should be found immediately after storing")
 	}
@@ -98,7 +99,7 @@ func TestMemoryStore_Cleanup(t *testing.T) {
 	store.Cleanup()
 
 	// Should be gone
-	_, found = store.Lookup("__SECRET_1__")
+	_, found, _ = store.Lookup(context.Background(), "__SECRET_1__")
 	if found {
 		t.Error("Secret should be cleaned up after TTL")
 	}
@@ -109,20 +110,20 @@ func TestMemoryStore_Touch(t *testing.T) {
 	defer store.Close()
 
 	placeholder := "__SECRET_1__"
-	store.Store(placeholder, "secret1")
+	store.Store(context.Background(), placeholder, "secret1", Metadata{})
 
 	// Wait half the TTL
 	time.Sleep(60 * time.Millisecond)
 
 	// Touch to refresh
-	store.Touch(placeholder)
+	store.Touch(context.Background(), placeholder)
 
 	// Wait another half TTL (would have expired without touch)
 	time.Sleep(60 * time.Millisecond)
 
 	// Should still be there because we touched it
 	store.Cleanup()
-	_, found := store.Lookup(placeholder)
+	_, found, _ := store.Lookup(context.Background(), placeholder)
 	if !found {
 		t.Error("Secret should still exist after touch")
 	}
@@ -140,10 +141,10 @@ func TestMemoryStore_Concurrency(t *testing.T) {
 			placeholder := "__SECRET_" + string(rune('0'+id%10)) + "__"
 			secret := "secret" + string(rune('0'+id%10))
 
-			store.Store(placeholder, secret)
-			store.Lookup(placeholder)
-			store.LookupBySecret(secret)
-			store.Touch(placeholder)
+			store.Store(context.Background(), placeholder, secret, Metadata{})
+			_, _, _ = store.Lookup(context.Background(), placeholder)
+			_, _, _ = store.LookupBySecret(context.Background(), secret)
+			store.Touch(context.Background(), placeholder)
 			store.Size()
 
 			done <- true
@@ -155,3 +156,111 @@ func TestMemoryStore_Concurrency(t *testing.T) {
 		<-done
 	}
 }
+
+func TestMemoryStore_MaxEntries(t *testing.T) {
+	store := NewMemoryStore(time.Hour)
+	defer store.Close()
+	store.SetMaxEntries(2)
+
+	store.Store(context.Background(), "__SECRET_1__", "secret1", Metadata{})
+	store.Store(context.Background(), "__SECRET_2__", "secret2", Metadata{})
+
+	// Using the first mapping makes the second the least recently used
+	_, _, _ = store.Lookup(context.Background(), "__SECRET_1__")
+	store.Store(context.Background(), "__SECRET_3__", "secret3", Metadata{})
+
+	if size := store.Size(); size != 2 {
+		t.Errorf("Size() = %d, want 2", size)
+	}
+	if _, found, _ := store.Lookup(context.Background(), "__SECRET_2__"); found {
+		t.Error("least recently used mapping should have been evicted")
+	}
+	if _, found, _ := store.LookupBySecret(context.Background(), "secret2"); found {
+		t.Error("reverse mapping of evicted entry should be removed")
+	}
+	for _, ph := range []string{"__SECRET_1__", "__SECRET_3__"} {
+		if _, found, _ := store.Lookup(context.Background(), ph); !found {
+			t.Errorf("Lookup(%s) returned not found", ph)
+		}
+	}
+}
+
+func TestMemoryStore_SetMaxEntriesShrinks(t *testing.T) {
+	store := NewMemoryStore(time.Hour)
+	defer store.Close()
+
+	for _, ph := range []string{"__SECRET_1__", "__SECRET_2__", "__SECRET_3__"} {
+		store.Store(context.Background(), ph, "secret"+ph, Metadata{})
+	}
+	store.SetMaxEntries(1)
+
+	if size := store.Size(); size != 1 {
+		t.Errorf("Size() = %d, want 1", size)
+	}
+	if _, found, _ := store.Lookup(context.Background(), "__SECRET_3__"); !found {
+		t.Error("most recently used mapping should be kept")
+	}
+}
+
+func TestMemoryStore_HashedReverseIndex(t *testing.T) {
+	store := NewMemoryStore(time.Hour)
+	defer store.Close()
+
+	store.Store(context.Background(), "__SECRET_12345678__", "mysecretpassword", Metadata{})
+
+	for key := range store.secretIndex {
+		if key == "mysecretpassword" {
+			t.Error("reverse index is keyed by the raw secret")
+		}
+	}
+	if got, found, _ := store.LookupBySecret(context.Background(), "mysecretpassword"); !found || got != "__SECRET_12345678__" {
+		t.Errorf("LookupBySecret() = %q, %v", got, found)
+	}
+}
+
+func TestMemoryStore_LookupMapping(t *testing.T) {
+	store := NewMemoryStore(time.Hour)
+	defer store.Close()
+
+	meta := Metadata{SecretType: "api_key", Interceptor: "pattern", Rule: "openai_key", SourceHost: "api.openai.com", RequestID: "req-1"}
+	if err := store.Store(context.Background(), "__SECRET_1__", "secret1", meta); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+
+	mapping, found, err := store.LookupMapping(context.Background(), "__SECRET_1__")
+	if err != nil || !found {
+		t.Fatalf("LookupMapping() = %v, %v", found, err)
+	}
+	if mapping.Secret != "secret1" || mapping.Metadata != meta || mapping.CreatedAt.IsZero() {
+		t.Errorf("LookupMapping() = %+v", mapping)
+	}
+	if _, found, _ := store.LookupMapping(context.Background(), "__SECRET_missing__"); found {
+		t.Error("LookupMapping() should return not found for nonexistent key")
+	}
+}
+
+func TestMemoryStore_Purge(t *testing.T) {
+	store := NewMemoryStore(time.Hour)
+	defer store.Close()
+	ctx := context.Background()
+
+	_ = store.Store(ctx, "__SECRET_1__", "secret1", Metadata{SecretType: "api_key", RequestID: "req-1"})
+	_ = store.Store(ctx, "__SECRET_2__", "secret2", Metadata{SecretType: "password", RequestID: "req-2"})
+	_ = store.Store(ctx, "__SECRET_3__", "secret3", Metadata{SecretType: "api_key", RequestID: "req-2"})
+
+	if deleted, err := store.Purge(ctx, PurgeFilter{OlderThan: time.Hour}); err != nil || deleted != 0 {
+		t.Errorf("Purge(OlderThan) = %d, %v, want 0", deleted, err)
+	}
+	if deleted, err := store.Purge(ctx, PurgeFilter{SecretType: "api_key", RequestID: "req-2"}); err != nil || deleted != 1 {
+		t.Errorf("Purge(SecretType, RequestID) = %d, %v, want 1", deleted, err)
+	}
+	if _, found, _ := store.LookupBySecret(ctx, "secret3"); found {
+		t.Error("reverse mapping survived Purge()")
+	}
+	if deleted, err := store.Purge(ctx, PurgeFilter{}); err != nil || deleted != 2 {
+		t.Errorf("Purge(all) = %d, %v, want 2", deleted, err)
+	}
+	if size := store.Size(); size != 0 {
+		t.Errorf("Size() = %d after Purge(), want 0", size)
+	}
+}
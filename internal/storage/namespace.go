@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"context"
+	"strings"
+)
+
+// namespaceSeparator separates the namespace from placeholders and secrets
+// in the keys of the underlying store
+const namespaceSeparator = "\x00"
+
+// NamespacedStore scopes a MappingStore to a namespace. Placeholders and
+// secrets of different namespaces never resolve into each other, so users
+// sharing a proxy cannot restore each other's secrets.
+type NamespacedStore struct {
+	inner  MappingStore
+	prefix string
+}
+
+// WithNamespace returns a view of store scoped to namespace. An empty
+// namespace returns store itself, sharing mappings across all clients.
+func WithNamespace(store MappingStore, namespace string) MappingStore {
+	if namespace == "" {
+		return store
+	}
+	return &NamespacedStore{
+		inner:  store,
+		prefix: namespace + namespaceSeparator,
+	}
+}
+
+// NamespaceOf returns the namespace store is scoped to, or "" if it is
+// shared across all clients
+func NamespaceOf(store MappingStore) string {
+	if n, ok := store.(*NamespacedStore); ok {
+		return strings.TrimSuffix(n.prefix, namespaceSeparator)
+	}
+	return ""
+}
+
+// NamespacedPlaceholder returns the key placeholder is stored under in the
+// underlying store of namespace
+func NamespacedPlaceholder(namespace, placeholder string) string {
+	if namespace == "" {
+		return placeholder
+	}
+	return namespace + namespaceSeparator + placeholder
+}
+
+// SplitNamespace splits a placeholder as stored in the underlying store into
+// its namespace and the placeholder seen by clients
+func SplitNamespace(stored string) (namespace, placeholder string) {
+	if namespace, placeholder, ok := strings.Cut(stored, namespaceSeparator); ok {
+		return namespace, placeholder
+	}
+	return "", stored
+}
+
+// Store saves a new secret-placeholder mapping in the namespace
+func (n *NamespacedStore) Store(ctx context.Context, placeholder, secret string, meta Metadata) error {
+	return n.inner.Store(ctx, n.prefix+placeholder, n.prefix+secret, meta)
+}
+
+// Lookup retrieves a secret by its placeholder within the namespace
+func (n *NamespacedStore) Lookup(ctx context.Context, placeholder string) (string, bool, error) {
+	value, found, err := n.inner.Lookup(ctx, n.prefix+placeholder)
+	if err != nil || !found {
+		return "", false, err
+	}
+	secret, ok := strings.CutPrefix(value, n.prefix)
+	return secret, ok, nil
+}
+
+// LookupMapping retrieves the full mapping within the namespace
+func (n *NamespacedStore) LookupMapping(ctx context.Context, placeholder string) (*Mapping, bool, error) {
+	mapping, found, err := n.inner.LookupMapping(ctx, n.prefix+placeholder)
+	if err != nil || !found {
+		return nil, false, err
+	}
+	secret, ok := strings.CutPrefix(mapping.Secret, n.prefix)
+	if !ok {
+		return nil, false, nil
+	}
+	mapping.Secret = secret
+	mapping.Placeholder = placeholder
+	return mapping, true, nil
+}
+
+// LookupBySecret retrieves a placeholder by the secret value within the namespace
+func (n *NamespacedStore) LookupBySecret(ctx context.Context, secret string) (string, bool, error) {
+	value, found, err := n.inner.LookupBySecret(ctx, n.prefix+secret)
+	if err != nil || !found {
+		return "", false, err
+	}
+	placeholder, ok := strings.CutPrefix(value, n.prefix)
+	return placeholder, ok, nil
+}
+
+// Touch updates the LastUsed timestamp for a mapping in the namespace
+func (n *NamespacedStore) Touch(ctx context.Context, placeholder string) error {
+	return n.inner.Touch(ctx, n.prefix+placeholder)
+}
+
+// Cleanup removes expired mappings of all namespaces
+func (n *NamespacedStore) Cleanup() error {
+	return n.inner.Cleanup()
+}
+
+// Purge deletes the mappings of the namespace selected by filter
+func (n *NamespacedStore) Purge(ctx context.Context, filter PurgeFilter) (int, error) {
+	filter.Namespace = strings.TrimSuffix(n.prefix, namespaceSeparator)
+	return Purge(ctx, n.inner, filter)
+}
+
+// List returns the mappings of the namespace selected by filter, with
+// placeholders as seen by clients
+func (n *NamespacedStore) List(ctx context.Context, filter PurgeFilter, limit int) ([]Mapping, error) {
+	filter.Namespace = strings.TrimSuffix(n.prefix, namespaceSeparator)
+	mappings, err := List(ctx, n.inner, filter, limit)
+	for i := range mappings {
+		mappings[i].Placeholder = strings.TrimPrefix(mappings[i].Placeholder, n.prefix)
+	}
+	return mappings, err
+}
+
+// Ping checks the connection of the underlying store
+func (n *NamespacedStore) Ping(ctx context.Context) error {
+	return Ping(ctx, n.inner)
+}
+
+// NextSequence increments a placeholder counter of the namespace
+func (n *NamespacedStore) NextSequence(ctx context.Context, scope string) (int, error) {
+	sequencer, ok := n.inner.(Sequencer)
+	if !ok {
+		return 0, errNoSequencer
+	}
+	return sequencer.NextSequence(ctx, n.prefix+scope)
+}
+
+// RecordRestore counts a restoration of placeholder in the namespace
+func (n *NamespacedStore) RecordRestore(ctx context.Context, placeholder string) error {
+	return RecordRestore(ctx, n.inner, n.prefix+placeholder)
+}
+
+// UsageStats aggregates the restoration counts of all namespaces
+func (n *NamespacedStore) UsageStats(ctx context.Context) (UsageStats, error) {
+	return CollectUsageStats(ctx, n.inner)
+}
+
+// Size returns the number of stored mappings of all namespaces
+func (n *NamespacedStore) Size() int {
+	return n.inner.Size()
+}
+
+// Close is a no-op; the underlying store is shared and closed by its owner
+func (n *NamespacedStore) Close() error {
+	return nil
+}
@@ -0,0 +1,83 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestWithNamespace_Isolation(t *testing.T) {
+	base := NewMemoryStore(time.Hour)
+	defer base.Close()
+
+	alice := WithNamespace(base, "alice")
+	bob := WithNamespace(base, "bob")
+
+	if err := alice.Store(context.Background(), "__SECRET_12345678__", "alicesecret", Metadata{}); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+
+	if got, found, _ := alice.Lookup(context.Background(), "__SECRET_12345678__"); !found || got != "alicesecret" {
+		t.Errorf("Lookup() in own namespace = %q, %v", got, found)
+	}
+	if got, found, _ := alice.LookupBySecret(context.Background(), "alicesecret"); !found || got != "__SECRET_12345678__" {
+		t.Errorf("LookupBySecret() in own namespace = %q, %v", got, found)
+	}
+
+	if _, found, _ := bob.Lookup(context.Background(), "__SECRET_12345678__"); found {
+		t.Error("placeholder resolved in another namespace")
+	}
+	if _, found, _ := bob.LookupBySecret(context.Background(), "alicesecret"); found {
+		t.Error("secret found in another namespace")
+	}
+	if _, found, _ := base.Lookup(context.Background(), "__SECRET_12345678__"); found {
+		t.Error("placeholder resolved in the default namespace")
+	}
+
+	if mapping, found, _ := alice.LookupMapping(context.Background(), "__SECRET_12345678__"); !found ||
+		mapping.Secret != "alicesecret" || mapping.Placeholder != "__SECRET_12345678__" {
+		t.Errorf("LookupMapping() in own namespace = %+v, %v", mapping, found)
+	}
+	if _, found, _ := bob.LookupMapping(context.Background(), "__SECRET_12345678__"); found {
+		t.Error("mapping found in another namespace")
+	}
+}
+
+func TestWithNamespace_Empty(t *testing.T) {
+	base := NewMemoryStore(time.Hour)
+	defer base.Close()
+
+	if store := WithNamespace(base, ""); store != base {
+		t.Error("WithNamespace() with empty namespace should return the store itself")
+	}
+}
+
+func TestWithNamespace_Purge(t *testing.T) {
+	base := NewMemoryStore(time.Hour)
+	defer base.Close()
+	ctx := context.Background()
+
+	alice := WithNamespace(base, "alice")
+	bob := WithNamespace(base, "bob")
+	_ = alice.Store(ctx, "__SECRET_1__", "secret1", Metadata{})
+	_ = bob.Store(ctx, "__SECRET_1__", "secret1", Metadata{})
+
+	if deleted, err := Purge(ctx, alice, PurgeFilter{}); err != nil || deleted != 1 {
+		t.Fatalf("Purge() = %d, %v, want 1", deleted, err)
+	}
+	if _, found, _ := bob.Lookup(ctx, "__SECRET_1__"); !found {
+		t.Error("Purge() deleted a mapping of another namespace")
+	}
+}
+
+func TestNamespaceOf(t *testing.T) {
+	store := NewMemoryStore(0)
+	defer store.Close()
+
+	if ns := NamespaceOf(store); ns != "" {
+		t.Errorf("NamespaceOf(unscoped) = %q, want empty", ns)
+	}
+	if ns := NamespaceOf(WithNamespace(store, "alice")); ns != "alice" {
+		t.Errorf("NamespaceOf() = %q, want alice", ns)
+	}
+}
@@ -0,0 +1,19 @@
+package storage
+
+import "context"
+
+// Pinger is implemented by stores backed by a remote service that can check
+// their connection to it
+type Pinger interface {
+	// Ping checks that the backend answers
+	Ping(ctx context.Context) error
+}
+
+// Ping checks the connection of store to its backend; stores without a
+// remote backend always succeed
+func Ping(ctx context.Context, store MappingStore) error {
+	if pinger, ok := store.(Pinger); ok {
+		return pinger.Ping(ctx)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PurgeFilter selects mappings to purge or list. Every set field must
+// match; the zero filter selects all mappings.
+type PurgeFilter struct {
+	// Namespace selects mappings of a client namespace
+	Namespace string
+	// Placeholder selects the mapping of a placeholder in the namespace
+	Placeholder string
+	// SecretType selects mappings by detected secret type
+	SecretType string
+	// RequestID selects mappings created by a request
+	RequestID string
+	// OlderThan selects mappings created more than this long ago
+	OlderThan time.Duration
+}
+
+// IsEmpty reports whether the filter selects all mappings
+func (f PurgeFilter) IsEmpty() bool {
+	return f == PurgeFilter{}
+}
+
+// Criteria returns the set fields of the filter, e.g. for audit events
+func (f PurgeFilter) Criteria() map[string]string {
+	criteria := make(map[string]string)
+	if f.Namespace != "" {
+		criteria["namespace"] = f.Namespace
+	}
+	if f.Placeholder != "" {
+		criteria["placeholder"] = f.Placeholder
+	}
+	if f.SecretType != "" {
+		criteria["secret_type"] = f.SecretType
+	}
+	if f.RequestID != "" {
+		criteria["request_id"] = f.RequestID
+	}
+	if f.OlderThan > 0 {
+		criteria["older_than"] = f.OlderThan.String()
+	}
+	return criteria
+}
+
+// placeholderPrefix returns the prefix of the stored placeholders selected
+// by the filter's namespace and placeholder
+func (f PurgeFilter) placeholderPrefix() string {
+	return NamespacedPlaceholder(f.Namespace, f.Placeholder)
+}
+
+// matches reports whether a stored mapping is selected. Mappings without a
+// creation time predate metadata and count as old.
+func (f PurgeFilter) matches(mapping *Mapping, now time.Time) bool {
+	if !strings.HasPrefix(mapping.Placeholder, f.placeholderPrefix()) {
+		return false
+	}
+	if f.Placeholder != "" && mapping.Placeholder != f.placeholderPrefix() {
+		return false
+	}
+	if f.SecretType != "" && mapping.Metadata.SecretType != f.SecretType {
+		return false
+	}
+	if f.RequestID != "" && mapping.Metadata.RequestID != f.RequestID {
+		return false
+	}
+	if f.OlderThan > 0 && !mapping.CreatedAt.IsZero() && now.Sub(mapping.CreatedAt) <= f.OlderThan {
+		return false
+	}
+	return true
+}
+
+// Purger is implemented by stores that can delete mappings in bulk
+type Purger interface {
+	// Purge deletes the mappings selected by filter and returns their number
+	Purge(ctx context.Context, filter PurgeFilter) (int, error)
+}
+
+// Purge deletes the mappings of store selected by filter
+func Purge(ctx context.Context, store MappingStore, filter PurgeFilter) (int, error) {
+	purger, ok := store.(Purger)
+	if !ok {
+		return 0, fmt.Errorf("store %T does not support purging", store)
+	}
+	return purger.Purge(ctx, filter)
+}
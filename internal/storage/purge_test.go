@@ -0,0 +1,54 @@
+package storage
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPurgeFilter_Matches(t *testing.T) {
+	now := time.Now()
+	mapping := &Mapping{
+		Placeholder: "alice" + namespaceSeparator + "__SECRET_1__",
+		CreatedAt:   now.Add(-2 * time.Hour),
+		Metadata:    Metadata{SecretType: "api_key", RequestID: "req-1"},
+	}
+
+	tests := []struct {
+		name   string
+		filter PurgeFilter
+		want   bool
+	}{
+		{"empty", PurgeFilter{}, true},
+		{"namespace", PurgeFilter{Namespace: "alice"}, true},
+		{"other namespace", PurgeFilter{Namespace: "bob"}, false},
+		{"placeholder", PurgeFilter{Namespace: "alice", Placeholder: "__SECRET_1__"}, true},
+		{"placeholder prefix", PurgeFilter{Namespace: "alice", Placeholder: "__SECRET_"}, false},
+		{"placeholder outside namespace", PurgeFilter{Placeholder: "__SECRET_1__"}, false},
+		{"secret type", PurgeFilter{SecretType: "api_key"}, true},
+		{"other secret type", PurgeFilter{SecretType: "password"}, false},
+		{"request id", PurgeFilter{RequestID: "req-1"}, true},
+		{"older than", PurgeFilter{OlderThan: time.Hour}, true},
+		{"not older than", PurgeFilter{OlderThan: 3 * time.Hour}, false},
+		{"all criteria", PurgeFilter{Namespace: "alice", SecretType: "api_key", RequestID: "req-1", OlderThan: time.Hour}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.matches(mapping, now); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Mappings without a creation time predate metadata and count as old
+	legacy := &Mapping{Placeholder: "__SECRET_2__"}
+	if !(PurgeFilter{OlderThan: time.Hour}).matches(legacy, now) {
+		t.Error("matches() should select legacy mappings by age")
+	}
+}
+
+func TestPurge_Unsupported(t *testing.T) {
+	if _, err := Purge(context.Background(), NewMockStore(), PurgeFilter{}); err == nil {
+		t.Error("Purge() expected error for a store without purge support")
+	}
+}
@@ -2,121 +2,706 @@ package storage
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/json"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/hfi/llm-secret-interceptor/internal/kms"
 	"github.com/redis/go-redis/v9"
 )
 
-// RedisStore is a Redis-based implementation of MappingStore
+// scanBatchSize is the COUNT hint for SCAN iterations and the batch size for deletes
+const scanBatchSize = 500
+
+// storeScript atomically creates a mapping unless its placeholder exists.
+// An existing mapping of the same secret is refreshed instead; one of a
+// different secret is left alone. It returns 0 when the mapping was
+// created, 1 when it existed and -1 on a conflict.
+//
+// KEYS: placeholder, reverse, metadata and usage key, expiry index
+// ARGV: secret, placeholder, metadata record, TTL in ms, index score
+var storeScript = redis.NewScript(`
+local current = redis.call('GET', KEYS[1])
+if current then
+	if current ~= ARGV[1] then
+		return -1
+	end
+	redis.call('PEXPIRE', KEYS[1], ARGV[4])
+	redis.call('PEXPIRE', KEYS[2], ARGV[4])
+	redis.call('PEXPIRE', KEYS[3], ARGV[4])
+	redis.call('PEXPIRE', KEYS[4], ARGV[4])
+	redis.call('ZADD', KEYS[5], ARGV[5], ARGV[2])
+	return 1
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[4])
+redis.call('SET', KEYS[2], ARGV[2], 'PX', ARGV[4])
+redis.call('SET', KEYS[3], ARGV[3], 'PX', ARGV[4])
+redis.call('ZADD', KEYS[5], ARGV[5], ARGV[2])
+return 0
+`)
+
+// RedisStore is a Redis-based implementation of MappingStore.
+//
+// Reverse index keys are named after an HMAC of the secret, so raw secrets
+// never appear in the keyspace (MONITOR output, keyspace dumps, ...).
+// Mapping metadata is kept as JSON in a separate key per placeholder with
+// the same TTL, as are restore counts in a usage hash.
+// Besides the placeholder and reverse keys, the store maintains a sorted
+// set of placeholders scored by their expiry time. It serves as a
+// server-side counter for Size without walking the keyspace.
 type RedisStore struct {
-	client *redis.Client
-	ttl    time.Duration
-	prefix string
+	client          *redis.Client
+	ttl             time.Duration
+	prefix          string
+	indexKey        []byte
+	cleanupInterval time.Duration
+	stopCleanup     chan struct{}
+}
+
+// RedisOptions contains connection settings for a RedisStore
+type RedisOptions struct {
+	Address  string
+	Username string
+	Password string //#nosec G117 -- Password field is intentional for Redis auth
+	DB       int
+
+	// IndexKey is the HMAC key for the reverse index. If empty, a random key
+	// is created once and shared by all instances through Redis.
+	IndexKey []byte
+
+	// TLS enables TLS when non-nil
+	TLS *tls.Config
+
+	// Pool settings; zero values use the go-redis defaults
+	PoolSize     int
+	MinIdleConns int
+	DialTimeout  time.Duration
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	PoolTimeout  time.Duration
 }
 
 // NewRedisStore creates a new Redis-based mapping store
-func NewRedisStore(address, password string, db int, ttl time.Duration) (*RedisStore, error) {
+func NewRedisStore(opts RedisOptions, ttl time.Duration) (*RedisStore, error) {
 	client := redis.NewClient(&redis.Options{
-		Addr:     address,
-		Password: password,
-		DB:       db,
+		Addr:         opts.Address,
+		Username:     opts.Username,
+		Password:     opts.Password,
+		DB:           opts.DB,
+		TLSConfig:    opts.TLS,
+		PoolSize:     opts.PoolSize,
+		MinIdleConns: opts.MinIdleConns,
+		DialTimeout:  opts.DialTimeout,
+		ReadTimeout:  opts.ReadTimeout,
+		WriteTimeout: opts.WriteTimeout,
+		PoolTimeout:  opts.PoolTimeout,
 	})
 
 	// Test connection
 	ctx := context.Background()
 	if err := client.Ping(ctx).Err(); err != nil {
+		if closeErr := client.Close(); closeErr != nil {
+			return nil, fmt.Errorf("failed to connect to Redis: %w (close: %v)", err, closeErr)
+		}
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
-	return &RedisStore{
-		client: client,
-		ttl:    ttl,
-		prefix: "llm-secret:",
-	}, nil
+	store := &RedisStore{
+		client:          client,
+		ttl:             ttl,
+		prefix:          "llm-secret:",
+		indexKey:        opts.IndexKey,
+		cleanupInterval: time.Minute,
+		stopCleanup:     make(chan struct{}),
+	}
+
+	if len(store.indexKey) == 0 {
+		key, err := store.loadIndexKey(ctx)
+		if err != nil {
+			if closeErr := client.Close(); closeErr != nil {
+				return nil, fmt.Errorf("failed to load index key: %w (close: %v)", err, closeErr)
+			}
+			return nil, fmt.Errorf("failed to load index key: %w", err)
+		}
+		store.indexKey = key
+	}
+
+	// Start background cleanup of the expiry index
+	go store.cleanupLoop()
+
+	return store, nil
 }
 
 // Store saves a new secret-placeholder mapping
-func (r *RedisStore) Store(placeholder, secret string) error {
-	ctx := context.Background()
-
-	// Store placeholder -> secret mapping
-	key := r.prefix + "p:" + placeholder
-	if err := r.client.Set(ctx, key, secret, r.ttl).Err(); err != nil {
-		return err
+func (r *RedisStore) Store(ctx context.Context, placeholder, secret string, meta Metadata) error {
+	record, err := json.Marshal(redisMetadata{Metadata: meta, CreatedAt: time.Now()})
+	if err != nil {
+		return fmt.Errorf("failed to encode metadata: %w", err)
 	}
 
-	// Store secret -> placeholder reverse mapping
-	reverseKey := r.prefix + "s:" + secret
-	if err := r.client.Set(ctx, reverseKey, placeholder, r.ttl).Err(); err != nil {
-		return err
+	entry := r.indexEntry(placeholder)
+	keys := []string{
+		r.placeholderKey(placeholder),
+		r.secretKey(secret),
+		r.metadataKey(placeholder),
+		r.usageKey(placeholder),
+		r.expiryIndexKey(),
+	}
+	result, err := storeScript.Run(ctx, r.client, keys,
+		secret, placeholder, record, r.ttl.Milliseconds(), int64(entry.Score)).Int()
+	if err != nil {
+		return fmt.Errorf("failed to store mapping: %w", err)
+	}
+	if result < 0 {
+		return ErrPlaceholderConflict
 	}
-
 	return nil
 }
 
 // Lookup retrieves a secret by its placeholder
-func (r *RedisStore) Lookup(placeholder string) (string, bool) {
-	ctx := context.Background()
-	key := r.prefix + "p:" + placeholder
-
-	secret, err := r.client.Get(ctx, key).Result()
+func (r *RedisStore) Lookup(ctx context.Context, placeholder string) (string, bool, error) {
+	secret, err := r.client.Get(ctx, r.placeholderKey(placeholder)).Result()
 	if err == redis.Nil {
-		return "", false
+		return "", false, nil
 	}
 	if err != nil {
-		return "", false
+		return "", false, fmt.Errorf("failed to look up placeholder: %w", err)
 	}
 
-	// Refresh TTL on access
-	r.client.Expire(ctx, key, r.ttl)
+	// Refresh TTL on access; a failure only shortens the mapping's lifetime
+	_ = r.Touch(ctx, placeholder)
 
-	return secret, true
+	return secret, true, nil
+}
+
+// LookupMapping retrieves the full mapping without refreshing its TTL.
+// LastUsed is derived from the expiry index.
+func (r *RedisStore) LookupMapping(ctx context.Context, placeholder string) (*Mapping, bool, error) {
+	var secretCmd, metadataCmd *redis.StringCmd
+	var expiryCmd *redis.FloatCmd
+	var usageCmd *redis.MapStringStringCmd
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		secretCmd = pipe.Get(ctx, r.placeholderKey(placeholder))
+		metadataCmd = pipe.Get(ctx, r.metadataKey(placeholder))
+		expiryCmd = pipe.ZScore(ctx, r.expiryIndexKey(), placeholder)
+		usageCmd = pipe.HGetAll(ctx, r.usageKey(placeholder))
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, false, fmt.Errorf("failed to look up mapping: %w", err)
+	}
+
+	secret, err := secretCmd.Result()
+	if err == redis.Nil {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to look up mapping: %w", err)
+	}
+
+	mapping := &Mapping{Secret: secret, Placeholder: placeholder}
+	// Mappings written before metadata was recorded have none
+	if raw, err := metadataCmd.Bytes(); err == nil {
+		var record redisMetadata
+		if err := json.Unmarshal(raw, &record); err != nil {
+			return nil, false, fmt.Errorf("failed to decode metadata: %w", err)
+		}
+		mapping.Metadata = record.Metadata
+		mapping.CreatedAt = record.CreatedAt
+	}
+	if expiry, err := expiryCmd.Result(); err == nil {
+		mapping.LastUsed = time.UnixMilli(int64(expiry)).Add(-r.ttl)
+	}
+	if usage, err := usageCmd.Result(); err == nil {
+		applyRedisUsage(mapping, usage)
+	}
+	return mapping, true, nil
 }
 
 // LookupBySecret retrieves a placeholder by the secret value
-func (r *RedisStore) LookupBySecret(secret string) (string, bool) {
-	ctx := context.Background()
-	key := r.prefix + "s:" + secret
+func (r *RedisStore) LookupBySecret(ctx context.Context, secret string) (string, bool, error) {
+	key := r.secretKey(secret)
 
 	placeholder, err := r.client.Get(ctx, key).Result()
 	if err == redis.Nil {
-		return "", false
+		return "", false, nil
 	}
 	if err != nil {
-		return "", false
+		return "", false, fmt.Errorf("failed to look up secret: %w", err)
 	}
 
 	// Refresh TTL on access
 	r.client.Expire(ctx, key, r.ttl)
 
-	return placeholder, true
+	return placeholder, true, nil
 }
 
 // Touch updates the TTL for a mapping
-func (r *RedisStore) Touch(placeholder string) error {
-	ctx := context.Background()
-	key := r.prefix + "p:" + placeholder
-	return r.client.Expire(ctx, key, r.ttl).Err()
+func (r *RedisStore) Touch(ctx context.Context, placeholder string) error {
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Expire(ctx, r.placeholderKey(placeholder), r.ttl)
+		pipe.Expire(ctx, r.metadataKey(placeholder), r.ttl)
+		pipe.Expire(ctx, r.usageKey(placeholder), r.ttl)
+		pipe.ZAddXX(ctx, r.expiryIndexKey(), r.indexEntry(placeholder))
+		return nil
+	})
+	return err
 }
 
-// Cleanup is a no-op for Redis as TTL handles expiration
+// Cleanup removes expired placeholders from the expiry index. The mapping
+// keys themselves are expired by Redis via TTL.
 func (r *RedisStore) Cleanup() error {
-	// Redis handles expiration automatically via TTL
-	return nil
+	ctx := context.Background()
+	maxScore := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	return r.client.ZRemRangeByScore(ctx, r.expiryIndexKey(), "-inf", maxScore).Err()
 }
 
-// Size returns the approximate number of stored mappings
+// Size returns the number of live mappings according to the expiry index
 func (r *RedisStore) Size() int {
 	ctx := context.Background()
-	keys, err := r.client.Keys(ctx, r.prefix+"p:*").Result()
+	minScore := "(" + strconv.FormatInt(time.Now().UnixMilli(), 10)
+	count, err := r.client.ZCount(ctx, r.expiryIndexKey(), minScore, "+inf").Result()
 	if err != nil {
 		return 0
 	}
-	return len(keys)
+	return int(count)
+}
+
+// Purge deletes the mappings selected by filter using incremental SCAN, so
+// that Redis is never blocked by a single large command. It returns the
+// number of deleted placeholder mappings. Data-encryption keys are kept.
+func (r *RedisStore) Purge(ctx context.Context, filter PurgeFilter) (int, error) {
+	if filter.IsEmpty() {
+		return r.purgeAll(ctx)
+	}
+
+	pattern := r.placeholderKey(escapeGlob(filter.placeholderPrefix())) + "*"
+	now := time.Now()
+	deleted := 0
+	var cursor uint64
+	for {
+		keys, next, err := r.client.Scan(ctx, cursor, pattern, scanBatchSize).Result()
+		if err != nil {
+			return deleted, fmt.Errorf("failed to purge mappings: %w", err)
+		}
+		n, err := r.purgeBatch(ctx, keys, filter, now)
+		deleted += n
+		if err != nil {
+			return deleted, fmt.Errorf("failed to purge mappings: %w", err)
+		}
+		cursor = next
+		if cursor == 0 {
+			return deleted, nil
+		}
+	}
+}
+
+// purgeBatch deletes the mappings of a batch of placeholder keys selected by filter
+func (r *RedisStore) purgeBatch(ctx context.Context, keys []string, filter PurgeFilter, now time.Time) (int, error) {
+	if len(keys) == 0 {
+		return 0, nil
+	}
+
+	secretCmds := make([]*redis.StringCmd, len(keys))
+	metadataCmds := make([]*redis.StringCmd, len(keys))
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			placeholder := strings.TrimPrefix(key, r.prefix+"p:")
+			secretCmds[i] = pipe.Get(ctx, key)
+			metadataCmds[i] = pipe.Get(ctx, r.metadataKey(placeholder))
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return 0, err
+	}
+
+	deleted := 0
+	_, err = r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			value, err := secretCmds[i].Result()
+			if err != nil {
+				// Expired since the scan
+				continue
+			}
+			mapping := &Mapping{Placeholder: strings.TrimPrefix(key, r.prefix+"p:")}
+			if raw, err := metadataCmds[i].Bytes(); err == nil {
+				var record redisMetadata
+				if json.Unmarshal(raw, &record) == nil {
+					mapping.Metadata = record.Metadata
+					mapping.CreatedAt = record.CreatedAt
+				}
+			}
+			if !filter.matches(mapping, now) {
+				continue
+			}
+
+			pipe.Unlink(ctx, key, r.metadataKey(mapping.Placeholder), r.usageKey(mapping.Placeholder), r.secretKey(value))
+			pipe.ZRem(ctx, r.expiryIndexKey(), mapping.Placeholder)
+			deleted++
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return deleted, nil
+}
+
+// purgeAll deletes all mappings of this store
+func (r *RedisStore) purgeAll(ctx context.Context) (int, error) {
+	deleted := 0
+	// s:* holds reverse keys written by versions without a hashed index
+	for _, pattern := range []string{r.prefix + "p:*", r.prefix + "h:*", r.prefix + "m:*", r.prefix + "u:*", r.prefix + "s:*"} {
+		n, err := r.deleteMatching(ctx, pattern)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to purge %s: %w", pattern, err)
+		}
+		if pattern == r.prefix+"p:*" {
+			deleted = n
+		}
+	}
+
+	if err := r.client.Del(ctx, r.expiryIndexKey()).Err(); err != nil {
+		return deleted, fmt.Errorf("failed to purge index: %w", err)
+	}
+	return deleted, nil
+}
+
+// List returns the mappings selected by filter without their secrets using
+// incremental SCAN
+func (r *RedisStore) List(ctx context.Context, filter PurgeFilter, limit int) ([]Mapping, error) {
+	pattern := r.placeholderKey(escapeGlob(filter.placeholderPrefix())) + "*"
+	now := time.Now()
+	var mappings []Mapping
+	var cursor uint64
+	for {
+		keys, next, err := r.client.Scan(ctx, cursor, pattern, scanBatchSize).Result()
+		if err != nil {
+			return nil, fmt.Errorf("failed to list mappings: %w", err)
+		}
+		batch, err := r.listBatch(ctx, keys)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list mappings: %w", err)
+		}
+		for i := range batch {
+			if filter.matches(&batch[i], now) {
+				mappings = append(mappings, batch[i])
+			}
+		}
+		cursor = next
+		if cursor == 0 {
+			return newestFirst(mappings, limit), nil
+		}
+	}
+}
+
+// Ping checks that Redis answers
+func (r *RedisStore) Ping(ctx context.Context) error {
+	if err := r.client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to ping Redis: %w", err)
+	}
+	return nil
+}
+
+// NextSequence increments the placeholder counter of scope with INCR, so
+// all instances sharing the store hand out distinct numbers. The counter
+// expires with the mappings once unused.
+func (r *RedisStore) NextSequence(ctx context.Context, scope string) (int, error) {
+	key := r.sequenceKey(scope)
+	pipe := r.client.TxPipeline()
+	incr := pipe.Incr(ctx, key)
+	if r.ttl > 0 {
+		pipe.PExpire(ctx, key, r.ttl)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to increment placeholder counter: %w", err)
+	}
+	return int(incr.Val()), nil
+}
+
+// listBatch reads the mappings of a batch of placeholder keys without their
+// secrets. Mappings that expired since the scan are left out.
+func (r *RedisStore) listBatch(ctx context.Context, keys []string) ([]Mapping, error) {
+	if len(keys) == 0 {
+		return nil, nil
+	}
+
+	existsCmds := make([]*redis.IntCmd, len(keys))
+	metadataCmds := make([]*redis.StringCmd, len(keys))
+	expiryCmds := make([]*redis.FloatCmd, len(keys))
+	usageCmds := make([]*redis.MapStringStringCmd, len(keys))
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			placeholder := strings.TrimPrefix(key, r.prefix+"p:")
+			existsCmds[i] = pipe.Exists(ctx, key)
+			metadataCmds[i] = pipe.Get(ctx, r.metadataKey(placeholder))
+			expiryCmds[i] = pipe.ZScore(ctx, r.expiryIndexKey(), placeholder)
+			usageCmds[i] = pipe.HGetAll(ctx, r.usageKey(placeholder))
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	mappings := make([]Mapping, 0, len(keys))
+	for i, key := range keys {
+		if n, err := existsCmds[i].Result(); err != nil || n == 0 {
+			continue
+		}
+		mapping := Mapping{Placeholder: strings.TrimPrefix(key, r.prefix+"p:")}
+		if raw, err := metadataCmds[i].Bytes(); err == nil {
+			var record redisMetadata
+			if json.Unmarshal(raw, &record) == nil {
+				mapping.Metadata = record.Metadata
+				mapping.CreatedAt = record.CreatedAt
+			}
+		}
+		if expiry, err := expiryCmds[i].Result(); err == nil {
+			mapping.LastUsed = time.UnixMilli(int64(expiry)).Add(-r.ttl)
+		}
+		if usage, err := usageCmds[i].Result(); err == nil {
+			applyRedisUsage(&mapping, usage)
+		}
+		mappings = append(mappings, mapping)
+	}
+	return mappings, nil
+}
+
+// RecordRestore counts a restoration of placeholder. Counts of mappings
+// that no longer exist are not created.
+func (r *RedisStore) RecordRestore(ctx context.Context, placeholder string) error {
+	exists, err := r.client.Exists(ctx, r.placeholderKey(placeholder)).Result()
+	if err != nil {
+		return fmt.Errorf("failed to record restore: %w", err)
+	}
+	if exists == 0 {
+		return nil
+	}
+
+	key := r.usageKey(placeholder)
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HIncrBy(ctx, key, "count", 1)
+		pipe.HSet(ctx, key, "last", time.Now().UnixMilli())
+		pipe.Expire(ctx, key, r.ttl)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to record restore: %w", err)
+	}
+	return nil
+}
+
+// UsageStats aggregates the restoration counts of all mappings using
+// incremental SCAN
+func (r *RedisStore) UsageStats(ctx context.Context) (UsageStats, error) {
+	stats := newUsageStats()
+	var cursor uint64
+	for {
+		keys, next, err := r.client.Scan(ctx, cursor, r.placeholderKey("*"), scanBatchSize).Result()
+		if err != nil {
+			return stats, fmt.Errorf("failed to collect usage stats: %w", err)
+		}
+		if err := r.usageBatch(ctx, keys, &stats); err != nil {
+			return stats, fmt.Errorf("failed to collect usage stats: %w", err)
+		}
+		cursor = next
+		if cursor == 0 {
+			stats.finish()
+			return stats, nil
+		}
+	}
+}
+
+// usageBatch adds the mappings of a batch of placeholder keys to stats
+func (r *RedisStore) usageBatch(ctx context.Context, keys []string, stats *UsageStats) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	metadataCmds := make([]*redis.StringCmd, len(keys))
+	usageCmds := make([]*redis.MapStringStringCmd, len(keys))
+	_, err := r.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			placeholder := strings.TrimPrefix(key, r.prefix+"p:")
+			metadataCmds[i] = pipe.Get(ctx, r.metadataKey(placeholder))
+			usageCmds[i] = pipe.HGetAll(ctx, r.usageKey(placeholder))
+		}
+		return nil
+	})
+	if err != nil && err != redis.Nil {
+		return err
+	}
+
+	for i, key := range keys {
+		mapping := &Mapping{Placeholder: strings.TrimPrefix(key, r.prefix+"p:")}
+		if raw, err := metadataCmds[i].Bytes(); err == nil {
+			var record redisMetadata
+			if json.Unmarshal(raw, &record) == nil {
+				mapping.Metadata = record.Metadata
+			}
+		}
+		if usage, err := usageCmds[i].Result(); err == nil {
+			applyRedisUsage(mapping, usage)
+		}
+		stats.add(mapping)
+	}
+	return nil
+}
+
+// applyRedisUsage sets the restore counts of a usage hash on mapping
+func applyRedisUsage(mapping *Mapping, usage map[string]string) {
+	if count, err := strconv.ParseInt(usage["count"], 10, 64); err == nil {
+		mapping.RestoreCount = count
+	}
+	if last, err := strconv.ParseInt(usage["last"], 10, 64); err == nil {
+		mapping.LastRestored = time.UnixMilli(last)
+	}
+}
+
+// escapeGlob escapes the special characters of Redis glob patterns
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, c := range s {
+		switch c {
+		case '*', '?', '[', ']', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+// deleteMatching unlinks all keys matching pattern in SCAN-sized batches
+func (r *RedisStore) deleteMatching(ctx context.Context, pattern string) (int, error) {
+	deleted := 0
+	var cursor uint64
+	for {
+		keys, next, err := r.client.Scan(ctx, cursor, pattern, scanBatchSize).Result()
+		if err != nil {
+			return deleted, err
+		}
+		if len(keys) > 0 {
+			n, err := r.client.Unlink(ctx, keys...).Result()
+			if err != nil {
+				return deleted, err
+			}
+			deleted += int(n)
+		}
+		cursor = next
+		if cursor == 0 {
+			return deleted, nil
+		}
+	}
+}
+
+// cleanupLoop periodically trims the expiry index
+func (r *RedisStore) cleanupLoop() {
+	ticker := time.NewTicker(r.cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.Cleanup(); err != nil {
+				// Cleanup errors are not critical, just continue
+				_ = err
+			}
+		case <-r.stopCleanup:
+			return
+		}
+	}
+}
+
+func (r *RedisStore) placeholderKey(placeholder string) string {
+	return r.prefix + "p:" + placeholder
+}
+
+func (r *RedisStore) secretKey(secret string) string {
+	return r.prefix + "h:" + secretDigest(r.indexKey, secret)
+}
+
+func (r *RedisStore) metadataKey(placeholder string) string {
+	return r.prefix + "m:" + placeholder
+}
+
+func (r *RedisStore) usageKey(placeholder string) string {
+	return r.prefix + "u:" + placeholder
+}
+
+func (r *RedisStore) sequenceKey(scope string) string {
+	return r.prefix + "seq:" + scope
+}
+
+// redisMetadata is the JSON record stored under the metadata key
+type redisMetadata struct {
+	Metadata
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// loadIndexKey returns the shared reverse index key, creating it if needed.
+// SETNX makes concurrently starting instances agree on a single key.
+func (r *RedisStore) loadIndexKey(ctx context.Context) ([]byte, error) {
+	name := r.prefix + "index-key"
+	candidate := base64.StdEncoding.EncodeToString(newIndexKey())
+	if err := r.client.SetNX(ctx, name, candidate, 0).Err(); err != nil {
+		return nil, err
+	}
+
+	encoded, err := r.client.Get(ctx, name).Result()
+	if err != nil {
+		return nil, err
+	}
+	return base64.StdEncoding.DecodeString(encoded)
+}
+
+func (r *RedisStore) expiryIndexKey() string {
+	return r.prefix + "index"
+}
+
+// indexEntry returns the index member for a placeholder expiring one TTL from now
+func (r *RedisStore) indexEntry(placeholder string) redis.Z {
+	return redis.Z{
+		Score:  float64(time.Now().Add(r.ttl).UnixMilli()),
+		Member: placeholder,
+	}
+}
+
+// LoadKeys returns the wrapped data-encryption keys stored in Redis
+func (r *RedisStore) LoadKeys(ctx context.Context) ([]kms.WrappedKey, error) {
+	fields, err := r.client.HGetAll(ctx, r.prefix+"keyring").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]kms.WrappedKey, 0, len(fields))
+	for id, data := range fields {
+		var key kms.WrappedKey
+		if err := json.Unmarshal([]byte(data), &key); err != nil {
+			return nil, fmt.Errorf("invalid keyring entry %s: %w", id, err)
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// SaveKey persists a wrapped data-encryption key in Redis. Keys do not
+// expire, as mappings sealed with them may still be live.
+func (r *RedisStore) SaveKey(ctx context.Context, key kms.WrappedKey) error {
+	data, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	return r.client.HSet(ctx, r.prefix+"keyring", key.ID, data).Err()
 }
 
-// Close closes the Redis connection
+// Close stops the cleanup goroutine and closes the Redis connection
 func (r *RedisStore) Close() error {
+	close(r.stopCleanup)
 	return r.client.Close()
 }
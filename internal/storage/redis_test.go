@@ -0,0 +1,261 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/hfi/llm-secret-interceptor/internal/kms"
+)
+
+func newTestRedisStore(t *testing.T) (*RedisStore, *miniredis.Miniredis) {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	store, err := NewRedisStore(RedisOptions{Address: mr.Addr()}, time.Hour)
+	if err != nil {
+		t.Fatalf("NewRedisStore() error: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = store.Close()
+	})
+	return store, mr
+}
+
+func TestRedisStore_StoreAndLookup(t *testing.T) {
+	store, _ := newTestRedisStore(t)
+
+	if err := store.Store(context.Background(), "__SECRET_12345678__", "mysecretpassword", Metadata{}); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+
+	got, found, _ := store.Lookup(context.Background(), "__SECRET_12345678__")
+	if !found || got != "mysecretpassword" {
+		t.Errorf("Lookup() = %q, %v", got, found)
+	}
+	placeholder, found, _ := store.LookupBySecret(context.Background(), "mysecretpassword")
+	if !found || placeholder != "__SECRET_12345678__" {
+		t.Errorf("LookupBySecret() = %q, %v", placeholder, found)
+	}
+	if _, found, _ := store.Lookup(context.Background(), "__SECRET_missing__"); found {
+		t.Error("Lookup() should return not found for nonexistent key")
+	}
+}
+
+func TestRedisStore_SizeAndCleanup(t *testing.T) {
+	store, mr := newTestRedisStore(t)
+
+	for _, ph := range []string{"__SECRET_1__", "__SECRET_2__", "__SECRET_3__"} {
+		if err := store.Store(context.Background(), ph, "secret"+ph, Metadata{}); err != nil {
+			t.Fatalf("Store() error: %v", err)
+		}
+	}
+	if size := store.Size(); size != 3 {
+		t.Errorf("Size() = %d, want 3", size)
+	}
+
+	// Entries whose expiry lies in the past are neither counted nor kept by Cleanup
+	mr.ZAdd(store.expiryIndexKey(), float64(time.Now().Add(-time.Minute).UnixMilli()), "__SECRET_old__")
+	if size := store.Size(); size != 3 {
+		t.Errorf("Size() = %d, want 3 with an expired index entry", size)
+	}
+	if err := store.Cleanup(); err != nil {
+		t.Fatalf("Cleanup() error: %v", err)
+	}
+	members, err := mr.ZMembers(store.expiryIndexKey())
+	if err != nil {
+		t.Fatalf("ZMembers() error: %v", err)
+	}
+	if len(members) != 3 {
+		t.Errorf("index has %d members after Cleanup(), want 3", len(members))
+	}
+}
+
+func TestRedisStore_Purge(t *testing.T) {
+	store, mr := newTestRedisStore(t)
+
+	for _, ph := range []string{"__SECRET_1__", "__SECRET_2__"} {
+		if err := store.Store(context.Background(), ph, "secret"+ph, Metadata{}); err != nil {
+			t.Fatalf("Store() error: %v", err)
+		}
+	}
+	mr.HSet(store.prefix+"keyring", "key1", "{}")
+	// Reverse key written by a version without a hashed index
+	if err := mr.Set(store.prefix+"s:legacysecret", "__SECRET_1__"); err != nil {
+		t.Fatalf("Set() error: %v", err)
+	}
+
+	deleted, err := store.Purge(context.Background(), PurgeFilter{})
+	if err != nil {
+		t.Fatalf("Purge() error: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("Purge() = %d, want 2", deleted)
+	}
+	if size := store.Size(); size != 0 {
+		t.Errorf("Size() = %d after Purge(), want 0", size)
+	}
+	if _, found, _ := store.LookupBySecret(context.Background(), "secret__SECRET_1__"); found {
+		t.Error("reverse mapping survived Purge()")
+	}
+	if mr.Exists(store.prefix + "s:legacysecret") {
+		t.Error("legacy reverse key survived Purge()")
+	}
+	if !mr.Exists(store.prefix + "keyring") {
+		t.Error("Purge() must keep the keyring")
+	}
+}
+
+func TestRedisStore_Keyring(t *testing.T) {
+	store, _ := newTestRedisStore(t)
+	ctx := context.Background()
+
+	keys, err := store.LoadKeys(ctx)
+	if err != nil || len(keys) != 0 {
+		t.Fatalf("LoadKeys() = %v, %v, want empty", keys, err)
+	}
+
+	if err := store.SaveKey(ctx, kms.WrappedKey{ID: "abc", Provider: "local", Wrapped: []byte{1, 2, 3}}); err != nil {
+		t.Fatalf("SaveKey() error: %v", err)
+	}
+	keys, err = store.LoadKeys(ctx)
+	if err != nil || len(keys) != 1 || keys[0].ID != "abc" || len(keys[0].Wrapped) != 3 {
+		t.Errorf("LoadKeys() = %v, %v", keys, err)
+	}
+}
+
+func TestRedisStore_HashedReverseIndex(t *testing.T) {
+	store, mr := newTestRedisStore(t)
+
+	if err := store.Store(context.Background(), "__SECRET_12345678__", "mysecretpassword", Metadata{}); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+	for _, key := range mr.Keys() {
+		if strings.Contains(key, "mysecretpassword") {
+			t.Errorf("key %q contains the raw secret", key)
+		}
+	}
+
+	// A second instance shares the index key and resolves the reverse mapping
+	other, err := NewRedisStore(RedisOptions{Address: mr.Addr()}, time.Hour)
+	if err != nil {
+		t.Fatalf("NewRedisStore() error: %v", err)
+	}
+	defer other.Close()
+
+	placeholder, found, _ := other.LookupBySecret(context.Background(), "mysecretpassword")
+	if !found || placeholder != "__SECRET_12345678__" {
+		t.Errorf("LookupBySecret() on second instance = %q, %v", placeholder, found)
+	}
+}
+
+func TestRedisStore_ConfiguredIndexKey(t *testing.T) {
+	mr := miniredis.RunT(t)
+	store, err := NewRedisStore(RedisOptions{Address: mr.Addr(), IndexKey: []byte("configured")}, time.Hour)
+	if err != nil {
+		t.Fatalf("NewRedisStore() error: %v", err)
+	}
+	defer store.Close()
+
+	if mr.Exists(store.prefix + "index-key") {
+		t.Error("index key should not be generated when configured")
+	}
+	if err := store.Store(context.Background(), "__SECRET_1__", "secret1", Metadata{}); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+	if !mr.Exists(store.prefix + "h:" + secretDigest([]byte("configured"), "secret1")) {
+		t.Error("reverse key not derived from the configured index key")
+	}
+}
+
+func TestRedisStore_BackendErrors(t *testing.T) {
+	store, mr := newTestRedisStore(t)
+	mr.Close()
+
+	if err := store.Store(context.Background(), "__SECRET_1__", "secret1", Metadata{}); err == nil {
+		t.Error("Store() expected error with Redis down")
+	}
+	if _, found, err := store.Lookup(context.Background(), "__SECRET_1__"); err == nil || found {
+		t.Errorf("Lookup() = %v, %v, want error", found, err)
+	}
+	if _, found, err := store.LookupBySecret(context.Background(), "secret1"); err == nil || found {
+		t.Errorf("LookupBySecret() = %v, %v, want error", found, err)
+	}
+}
+
+func TestRedisStore_LookupMapping(t *testing.T) {
+	store, mr := newTestRedisStore(t)
+
+	meta := Metadata{SecretType: "api_key", Interceptor: "pattern", Rule: "openai_key", SourceHost: "api.openai.com", RequestID: "req-1"}
+	if err := store.Store(context.Background(), "__SECRET_1__", "secret1", meta); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+
+	mapping, found, err := store.LookupMapping(context.Background(), "__SECRET_1__")
+	if err != nil || !found {
+		t.Fatalf("LookupMapping() = %v, %v", found, err)
+	}
+	if mapping.Secret != "secret1" || mapping.Metadata != meta || mapping.CreatedAt.IsZero() || mapping.LastUsed.IsZero() {
+		t.Errorf("LookupMapping() = %+v", mapping)
+	}
+	if ttl := mr.TTL("llm-secret:m:__SECRET_1__"); ttl != time.Hour {
+		t.Errorf("metadata TTL = %v, want 1h", ttl)
+	}
+
+	// Mappings written without metadata are still returned
+	mr.Del("llm-secret:m:__SECRET_1__")
+	if mapping, found, err := store.LookupMapping(context.Background(), "__SECRET_1__"); err != nil || !found || mapping.Secret != "secret1" {
+		t.Errorf("LookupMapping() without metadata = %+v, %v, %v", mapping, found, err)
+	}
+	if _, found, err := store.LookupMapping(context.Background(), "__SECRET_missing__"); err != nil || found {
+		t.Errorf("LookupMapping() missing = %v, %v", found, err)
+	}
+}
+
+func TestRedisStore_PurgeFiltered(t *testing.T) {
+	store, mr := newTestRedisStore(t)
+	ctx := context.Background()
+
+	_ = store.Store(ctx, "__SECRET_1__", "secret1", Metadata{SecretType: "api_key", RequestID: "req-1"})
+	_ = store.Store(ctx, "__SECRET_2__", "secret2", Metadata{SecretType: "password", RequestID: "req-1"})
+	_ = store.Store(ctx, "alice\x00__SECRET_3__", "alice\x00secret3", Metadata{SecretType: "api_key"})
+
+	deleted, err := store.Purge(ctx, PurgeFilter{SecretType: "api_key", Namespace: "alice"})
+	if err != nil || deleted != 1 {
+		t.Fatalf("Purge() = %d, %v, want 1", deleted, err)
+	}
+	if _, found, _ := store.Lookup(ctx, "alice\x00__SECRET_3__"); found {
+		t.Error("purged mapping still resolves")
+	}
+	if _, found, _ := store.LookupBySecret(ctx, "alice\x00secret3"); found {
+		t.Error("reverse mapping survived Purge()")
+	}
+	if mr.Exists(store.prefix + "m:alice\x00__SECRET_3__") {
+		t.Error("metadata survived Purge()")
+	}
+
+	deleted, err = store.Purge(ctx, PurgeFilter{RequestID: "req-1"})
+	if err != nil || deleted != 2 {
+		t.Fatalf("Purge() = %d, %v, want 2", deleted, err)
+	}
+	if size := store.Size(); size != 0 {
+		t.Errorf("Size() = %d after Purge(), want 0", size)
+	}
+}
+
+func TestRedisStore_Ping(t *testing.T) {
+	store, mr := newTestRedisStore(t)
+	wrapped := WithTimeout(NewInstrumentedStore(NewCachedStore(store, 10, time.Minute), "redis"), time.Second)
+
+	if err := Ping(context.Background(), wrapped); err != nil {
+		t.Errorf("Ping() error: %v", err)
+	}
+	mr.Close()
+	if err := Ping(context.Background(), wrapped); err == nil {
+		t.Error("Ping() expected error with Redis down")
+	}
+	if err := Ping(context.Background(), NewMemoryStore(time.Hour)); err != nil {
+		t.Errorf("Ping() of memory store error: %v", err)
+	}
+}
@@ -0,0 +1,31 @@
+package storage
+
+import (
+	"context"
+	"errors"
+)
+
+// errNoSequencer is returned by wrappers around stores without counters
+var errNoSequencer = errors.New("store keeps no placeholder counters")
+
+// Sequencer is implemented by stores that keep placeholder counters, so
+// numbers survive restarts and are shared by all instances using the store
+type Sequencer interface {
+	// NextSequence increments the counter of scope and returns its value.
+	// Counters expire with the store's TTL once unused.
+	NextSequence(ctx context.Context, scope string) (int, error)
+}
+
+// NextSequence returns the next placeholder number of store's namespace.
+// It returns false if store keeps no counters.
+func NextSequence(ctx context.Context, store MappingStore) (int, bool, error) {
+	sequencer, ok := store.(Sequencer)
+	if !ok {
+		return 0, false, nil
+	}
+	n, err := sequencer.NextSequence(ctx, "")
+	if errors.Is(err, errNoSequencer) {
+		return 0, false, nil
+	}
+	return n, true, err
+}
@@ -0,0 +1,216 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/kms"
+)
+
+// snapshotVersion is the version of the snapshot file format
+const snapshotVersion = 1
+
+// SnapshotSealer encrypts memory store snapshots; *kms.Keyring implements it
+type SnapshotSealer interface {
+	Seal(plaintext string) (string, error)
+	Open(ctx context.Context, sealed string) (string, error)
+}
+
+// snapshotFile is the on-disk snapshot format. The wrapped data keys are
+// saved next to the data, so a restarted proxy can unwrap them with its key
+// provider and decrypt the snapshot.
+type snapshotFile struct {
+	Version   int              `json:"version"`
+	CreatedAt time.Time        `json:"created_at"`
+	Keys      []kms.WrappedKey `json:"keys"`
+	Data      string           `json:"data"` // sealed JSON array of mappings
+}
+
+// snapshotConfig holds the snapshot settings of a memory store
+type snapshotConfig struct {
+	path     string
+	interval time.Duration
+	sealer   SnapshotSealer
+	keys     kms.KeyStore
+}
+
+// ReadSnapshotKeys returns the wrapped data keys saved in a snapshot file.
+// A missing file yields no keys.
+func ReadSnapshotKeys(path string) ([]kms.WrappedKey, error) {
+	file, err := readSnapshotFile(path)
+	if err != nil || file == nil {
+		return nil, err
+	}
+	return file.Keys, nil
+}
+
+// EnableSnapshots restores the mappings saved at path, if any, and then
+// writes an encrypted snapshot every interval and when the store is closed.
+// It returns the number of restored mappings.
+func (m *MemoryStore) EnableSnapshots(ctx context.Context, path string, interval time.Duration, sealer SnapshotSealer, keys kms.KeyStore) (int, error) {
+	restored, err := m.loadSnapshot(ctx, path, sealer)
+	if err != nil {
+		return 0, err
+	}
+
+	m.mu.Lock()
+	m.snapshot = &snapshotConfig{
+		path:     path,
+		interval: interval,
+		sealer:   sealer,
+		keys:     keys,
+	}
+	m.mu.Unlock()
+
+	if interval > 0 {
+		go m.snapshotLoop(interval)
+	}
+	return restored, nil
+}
+
+// SaveSnapshot writes an encrypted snapshot of all live mappings. The file
+// is replaced atomically, so a crash never leaves a truncated snapshot.
+func (m *MemoryStore) SaveSnapshot(ctx context.Context) error {
+	m.mu.RLock()
+	cfg := m.snapshot
+	mappings := make([]Mapping, 0, len(m.mappings))
+	for _, mapping := range m.mappings {
+		mappings = append(mappings, *mapping)
+	}
+	m.mu.RUnlock()
+
+	if cfg == nil {
+		return fmt.Errorf("snapshots are not enabled")
+	}
+
+	m.snapshotMu.Lock()
+	defer m.snapshotMu.Unlock()
+
+	data, err := json.Marshal(mappings)
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+	sealed, err := cfg.sealer.Seal(string(data))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt snapshot: %w", err)
+	}
+	keys, err := cfg.keys.LoadKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load data keys: %w", err)
+	}
+
+	encoded, err := json.Marshal(snapshotFile{
+		Version:   snapshotVersion,
+		CreatedAt: time.Now(),
+		Keys:      keys,
+		Data:      sealed,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode snapshot: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(cfg.path), filepath.Base(cfg.path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	defer func() {
+		// No-op after the rename
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err := tmp.Write(encoded); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), cfg.path); err != nil {
+		return fmt.Errorf("failed to write snapshot: %w", err)
+	}
+	return nil
+}
+
+// loadSnapshot restores the mappings of a snapshot file that have not expired
+func (m *MemoryStore) loadSnapshot(ctx context.Context, path string, sealer SnapshotSealer) (int, error) {
+	file, err := readSnapshotFile(path)
+	if err != nil || file == nil {
+		return 0, err
+	}
+	if file.Version != snapshotVersion {
+		return 0, fmt.Errorf("unsupported snapshot version %d", file.Version)
+	}
+
+	data, err := sealer.Open(ctx, file.Data)
+	if err != nil {
+		return 0, fmt.Errorf("failed to decrypt snapshot: %w", err)
+	}
+	var mappings []Mapping
+	if err := json.Unmarshal([]byte(data), &mappings); err != nil {
+		return 0, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	restored := 0
+	for i := range mappings {
+		mapping := &mappings[i]
+		if now.Sub(mapping.LastUsed) > m.ttl {
+			continue
+		}
+		m.mappings[mapping.Placeholder] = mapping
+		m.secretIndex[m.digest(mapping.Secret)] = mapping.Placeholder
+		if m.recency != nil {
+			if evicted, _, ok := m.recency.add(mapping.Placeholder, struct{}{}); ok {
+				m.evict(evicted)
+			}
+		}
+		restored++
+	}
+	return restored, nil
+}
+
+// snapshotLoop periodically writes snapshots until the store is closed
+func (m *MemoryStore) snapshotLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := m.SaveSnapshot(context.Background()); err != nil {
+				// The next snapshot or the final one on Close is retried
+				_ = err
+			}
+		case <-m.stopCleanup:
+			return
+		}
+	}
+}
+
+// readSnapshotFile reads a snapshot file, returning nil if it does not exist
+func readSnapshotFile(path string) (*snapshotFile, error) {
+	data, err := os.ReadFile(filepath.Clean(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	var file snapshotFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to decode snapshot: %w", err)
+	}
+	return &file, nil
+}
@@ -0,0 +1,146 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hfi/llm-secret-interceptor/internal/kms"
+)
+
+// newSnapshotKeyring creates a keyring with a fixed local key, persisting
+// wrapped keys in keys
+func newSnapshotKeyring(t *testing.T, keys kms.KeyStore) *kms.Keyring {
+	t.Helper()
+	provider, err := kms.NewLocalProvider(bytes.Repeat([]byte{3}, 32))
+	if err != nil {
+		t.Fatalf("NewLocalProvider() error: %v", err)
+	}
+	keyring := kms.NewKeyring(provider, keys)
+	if err := keyring.Init(context.Background()); err != nil {
+		t.Fatalf("Init() error: %v", err)
+	}
+	return keyring
+}
+
+func TestMemoryStore_SnapshotRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "mappings.snapshot")
+
+	keys := kms.NewMemoryKeyStore()
+	store := NewMemoryStore(time.Hour)
+	if _, err := store.EnableSnapshots(ctx, path, 0, newSnapshotKeyring(t, keys), keys); err != nil {
+		t.Fatalf("EnableSnapshots() error: %v", err)
+	}
+	meta := Metadata{SecretType: "api_key", RequestID: "req-1"}
+	if err := store.Store(ctx, "__SECRET_12345678__", "mysecretpassword", meta); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("snapshot not written: %v", err)
+	}
+	if strings.Contains(string(raw), "mysecretpassword") || strings.Contains(string(raw), "__SECRET_12345678__") {
+		t.Error("snapshot contains plaintext mappings")
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0o600 {
+		t.Errorf("snapshot mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	// A restarted process only has the key provider and the snapshot file
+	restartedKeys := kms.NewMemoryKeyStore()
+	saved, err := ReadSnapshotKeys(path)
+	if err != nil {
+		t.Fatalf("ReadSnapshotKeys() error: %v", err)
+	}
+	for _, key := range saved {
+		if err := restartedKeys.SaveKey(ctx, key); err != nil {
+			t.Fatalf("SaveKey() error: %v", err)
+		}
+	}
+
+	restarted := NewMemoryStore(time.Hour)
+	defer restarted.Close()
+	restored, err := restarted.EnableSnapshots(ctx, path, 0, newSnapshotKeyring(t, restartedKeys), restartedKeys)
+	if err != nil {
+		t.Fatalf("EnableSnapshots() after restart error: %v", err)
+	}
+	if restored != 1 {
+		t.Errorf("restored = %d, want 1", restored)
+	}
+
+	mapping, found, _ := restarted.LookupMapping(ctx, "__SECRET_12345678__")
+	if !found || mapping.Secret != "mysecretpassword" || mapping.Metadata != meta {
+		t.Errorf("LookupMapping() = %+v, %v", mapping, found)
+	}
+	if ph, found, _ := restarted.LookupBySecret(ctx, "mysecretpassword"); !found || ph != "__SECRET_12345678__" {
+		t.Errorf("LookupBySecret() = %q, %v", ph, found)
+	}
+}
+
+func TestMemoryStore_SnapshotSkipsExpired(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "mappings.snapshot")
+
+	keys := kms.NewMemoryKeyStore()
+	keyring := newSnapshotKeyring(t, keys)
+	store := NewMemoryStore(time.Hour)
+	if _, err := store.EnableSnapshots(ctx, path, 0, keyring, keys); err != nil {
+		t.Fatalf("EnableSnapshots() error: %v", err)
+	}
+	if err := store.Store(ctx, "__SECRET_aaaaaaaa__", "fresh", Metadata{}); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+	if err := store.Store(ctx, "__SECRET_bbbbbbbb__", "stale", Metadata{}); err != nil {
+		t.Fatalf("Store() error: %v", err)
+	}
+	store.mu.Lock()
+	store.mappings["__SECRET_bbbbbbbb__"].LastUsed = time.Now().Add(-2 * time.Hour)
+	store.mu.Unlock()
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close() error: %v", err)
+	}
+
+	restarted := NewMemoryStore(time.Hour)
+	defer restarted.Close()
+	restored, err := restarted.EnableSnapshots(ctx, path, 0, keyring, keys)
+	if err != nil {
+		t.Fatalf("EnableSnapshots() error: %v", err)
+	}
+	if restored != 1 || restarted.Size() != 1 {
+		t.Errorf("restored = %d, size = %d, want 1", restored, restarted.Size())
+	}
+}
+
+func TestMemoryStore_SnapshotMissingFile(t *testing.T) {
+	keys := kms.NewMemoryKeyStore()
+	path := filepath.Join(t.TempDir(), "missing.snapshot")
+
+	if saved, err := ReadSnapshotKeys(path); err != nil || saved != nil {
+		t.Errorf("ReadSnapshotKeys() = %v, %v", saved, err)
+	}
+
+	store := NewMemoryStore(time.Hour)
+	defer store.Close()
+	restored, err := store.EnableSnapshots(context.Background(), path, 0, newSnapshotKeyring(t, keys), keys)
+	if err != nil || restored != 0 {
+		t.Errorf("EnableSnapshots() = %d, %v", restored, err)
+	}
+}
+
+func TestMemoryStore_SaveSnapshotDisabled(t *testing.T) {
+	store := NewMemoryStore(time.Hour)
+	defer store.Close()
+
+	if err := store.SaveSnapshot(context.Background()); err == nil {
+		t.Error("SaveSnapshot() without snapshots should fail")
+	}
+}
@@ -1,6 +1,18 @@
 package storage
 
-import "time"
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// ErrPlaceholderConflict is returned by Store when the placeholder already
+// maps to a different secret
+var ErrPlaceholderConflict = errors.New("placeholder already maps to a different secret")
 
 // Mapping represents a secret-to-placeholder mapping with metadata
 type Mapping struct {
@@ -8,21 +20,47 @@ type Mapping struct {
 	Placeholder string
 	LastUsed    time.Time
 	CreatedAt   time.Time
+	Metadata    Metadata
+
+	// RestoreCount and LastRestored account for restorations of the placeholder
+	RestoreCount int64
+	LastRestored time.Time
 }
 
-// MappingStore defines the interface for storing secret mappings
+// Metadata describes where a mapping came from, so that a restored
+// placeholder can be traced back to its original detection. It never
+// contains the secret itself.
+type Metadata struct {
+	SecretType  string `json:"secret_type,omitempty"`
+	Interceptor string `json:"interceptor,omitempty"`
+	Rule        string `json:"rule,omitempty"`
+	SourceHost  string `json:"source_host,omitempty"`
+	RequestID   string `json:"request_id,omitempty"`
+}
+
+// MappingStore defines the interface for storing secret mappings.
+// Lookups report a missing mapping with found == false and a nil error;
+// a non-nil error means the backend could not answer.
 type MappingStore interface {
-	// Store saves a new secret-placeholder mapping
-	Store(placeholder, secret string) error
+	// Store saves a new secret-placeholder mapping with its metadata. It
+	// behaves like SETNX: an existing mapping of the same secret is kept with
+	// its original metadata and refreshed, so concurrent stores of the same
+	// secret from several instances are safe. An existing mapping of a
+	// different secret is never overwritten; ErrPlaceholderConflict is
+	// returned instead.
+	Store(ctx context.Context, placeholder, secret string, meta Metadata) error
 
 	// Lookup retrieves a secret by its placeholder
-	Lookup(placeholder string) (string, bool)
+	Lookup(ctx context.Context, placeholder string) (secret string, found bool, err error)
+
+	// LookupMapping retrieves the full mapping, including its metadata
+	LookupMapping(ctx context.Context, placeholder string) (mapping *Mapping, found bool, err error)
 
 	// LookupBySecret retrieves a placeholder by the secret value
-	LookupBySecret(secret string) (string, bool)
+	LookupBySecret(ctx context.Context, secret string) (placeholder string, found bool, err error)
 
 	// Touch updates the LastUsed timestamp for a mapping
-	Touch(placeholder string) error
+	Touch(ctx context.Context, placeholder string) error
 
 	// Cleanup removes expired mappings
 	Cleanup() error
@@ -33,3 +71,23 @@ type MappingStore interface {
 	// Close releases any resources
 	Close() error
 }
+
+// indexKeySize is the size of the HMAC key for reverse index entries
+const indexKeySize = 32
+
+// newIndexKey generates a random HMAC key for the reverse index
+func newIndexKey() []byte {
+	key := make([]byte, indexKeySize)
+	if _, err := rand.Read(key); err != nil {
+		panic("storage: failed to generate index key: " + err.Error())
+	}
+	return key
+}
+
+// secretDigest returns the keyed hash used in place of a secret as the
+// reverse index key, so raw secrets never appear in map keys or key names
+func secretDigest(key []byte, secret string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(secret))
+	return hex.EncodeToString(mac.Sum(nil))
+}
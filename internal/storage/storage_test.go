@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"context"
 	"testing"
 	"time"
 )
@@ -24,7 +25,7 @@ func NewMockStore() *MockStore {
 	}
 }
 
-func (m *MockStore) Store(placeholder, secret string) error {
+func (m *MockStore) Store(_ context.Context, placeholder, secret string, _ Metadata) error {
 	m.storeCalls++
 	if m.storeErr != nil {
 		return m.storeErr
@@ -34,22 +35,33 @@ func (m *MockStore) Store(placeholder, secret string) error {
 	return nil
 }
 
-func (m *MockStore) Lookup(placeholder string) (string, bool) {
+func (m *MockStore) Lookup(_ context.Context, placeholder string) (string, bool, error) {
 	m.lookupCalls++
 	if m.lookupErr != nil {
-		return "", false
+		return "", false, m.lookupErr
 	}
 	secret, ok := m.mappings[placeholder]
-	return secret, ok
+	return secret, ok, nil
 }
 
-func (m *MockStore) LookupBySecret(secret string) (string, bool) {
+func (m *MockStore) LookupMapping(ctx context.Context, placeholder string) (*Mapping, bool, error) {
+	secret, ok, err := m.Lookup(ctx, placeholder)
+	if !ok {
+		return nil, false, err
+	}
+	return &Mapping{Secret: secret, Placeholder: placeholder}, true, nil
+}
+
+func (m *MockStore) LookupBySecret(_ context.Context, secret string) (string, bool, error) {
 	m.lookupCalls++
+	if m.lookupErr != nil {
+		return "", false, m.lookupErr
+	}
 	placeholder, ok := m.secrets[secret]
-	return placeholder, ok
+	return placeholder, ok, nil
 }
 
-func (m *MockStore) Touch(placeholder string) error {
+func (m *MockStore) Touch(_ context.Context, placeholder string) error {
 	return nil
 }
 
@@ -84,12 +96,12 @@ func TestMockStore_Interface(t *testing.T) {
 func TestMockStore_StoreAndLookup(t *testing.T) {
 	store := NewMockStore()
 
-	err := store.Store("__SECRET_12345678__", "mysecret")
+	err := store.Store(context.Background(), "__SECRET_12345678__", "mysecret", Metadata{})
 	if err != nil {
 		t.Fatalf("Store() error: %v", err)
 	}
 
-	secret, found := store.Lookup("__SECRET_12345678__")
+	secret, found, _ := store.Lookup(context.Background(), "__SECRET_12345678__")
 	if !found {
 		t.Error("Lookup() not found")
 	}
@@ -97,7 +109,7 @@ func TestMockStore_StoreAndLookup(t *testing.T) {
 		t.Errorf("Lookup() = %q, want 'mysecret'", secret)
 	}
 
-	placeholder, found := store.LookupBySecret("mysecret")
+	placeholder, found, _ := store.LookupBySecret(context.Background(), "mysecret")
 	if !found {
 		t.Error("LookupBySecret() not found")
 	}
@@ -132,7 +144,7 @@ func TestMemoryStore_AutoCleanup(t *testing.T) {
 	defer store.Close()
 
 	// Store a value
-	store.Store("__SECRET_1__", "secret1")
+	store.Store(context.Background(), "__SECRET_1__", "secret1", Metadata{})
 
 	// Verify it's stored
 	if store.Size() != 1 {
@@ -161,16 +173,16 @@ func TestMemoryStore_TouchPreventCleanup(t *testing.T) {
 	go store.cleanupLoop()
 	defer store.Close()
 
-	store.Store("__SECRET_1__", "secret1")
+	store.Store(context.Background(), "__SECRET_1__", "secret1", Metadata{})
 
 	// Touch every 40ms to keep it alive
 	for i := 0; i < 3; i++ {
 		time.Sleep(40 * time.Millisecond)
-		store.Touch("__SECRET_1__")
+		store.Touch(context.Background(), "__SECRET_1__")
 	}
 
 	// Should still exist
-	_, found := store.Lookup("__SECRET_1__")
+	_, found, _ := store.Lookup(context.Background(), "__SECRET_1__")
 	if !found {
 		t.Error("Secret should still exist after being touched")
 	}
@@ -183,18 +195,18 @@ func BenchmarkMemoryStore_Store(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		store.Store("__SECRET_test__", "testsecret")
+		store.Store(context.Background(), "__SECRET_test__", "testsecret", Metadata{})
 	}
 }
 
 func BenchmarkMemoryStore_Lookup(b *testing.B) {
 	store := NewMemoryStore(time.Hour)
 	defer store.Close()
-	store.Store("__SECRET_test__", "testsecret")
+	store.Store(context.Background(), "__SECRET_test__", "testsecret", Metadata{})
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		store.Lookup("__SECRET_test__")
+		_, _, _ = store.Lookup(context.Background(), "__SECRET_test__")
 	}
 }
 
@@ -206,8 +218,8 @@ func BenchmarkMemoryStore_Concurrent(b *testing.B) {
 		i := 0
 		for pb.Next() {
 			placeholder := "__SECRET_" + string(rune('a'+i%26)) + "__"
-			store.Store(placeholder, "secret"+string(rune('a'+i%26)))
-			store.Lookup(placeholder)
+			store.Store(context.Background(), placeholder, "secret"+string(rune('a'+i%26)), Metadata{})
+			_, _, _ = store.Lookup(context.Background(), placeholder)
 			i++
 		}
 	})
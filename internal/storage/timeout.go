@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// TimeoutStore bounds every mapping operation of the wrapped store, so that
+// an unresponsive backend cannot stall request processing indefinitely.
+// The deadline is derived from the caller's context; a caller deadline that
+// is shorter wins.
+type TimeoutStore struct {
+	inner   MappingStore
+	timeout time.Duration
+}
+
+// WithTimeout returns store with every operation bounded by timeout.
+// A non-positive timeout returns store itself.
+func WithTimeout(store MappingStore, timeout time.Duration) MappingStore {
+	if timeout <= 0 {
+		return store
+	}
+	return &TimeoutStore{
+		inner:   store,
+		timeout: timeout,
+	}
+}
+
+// Store saves a new secret-placeholder mapping
+func (t *TimeoutStore) Store(ctx context.Context, placeholder, secret string, meta Metadata) error {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	return t.inner.Store(ctx, placeholder, secret, meta)
+}
+
+// Lookup retrieves a secret by its placeholder
+func (t *TimeoutStore) Lookup(ctx context.Context, placeholder string) (string, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	return t.inner.Lookup(ctx, placeholder)
+}
+
+// LookupMapping retrieves the full mapping
+func (t *TimeoutStore) LookupMapping(ctx context.Context, placeholder string) (*Mapping, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	return t.inner.LookupMapping(ctx, placeholder)
+}
+
+// LookupBySecret retrieves a placeholder by the secret value
+func (t *TimeoutStore) LookupBySecret(ctx context.Context, secret string) (string, bool, error) {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	return t.inner.LookupBySecret(ctx, secret)
+}
+
+// Touch updates the LastUsed timestamp for a mapping
+func (t *TimeoutStore) Touch(ctx context.Context, placeholder string) error {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	return t.inner.Touch(ctx, placeholder)
+}
+
+// Cleanup removes expired mappings
+func (t *TimeoutStore) Cleanup() error {
+	return t.inner.Cleanup()
+}
+
+// Purge deletes the mappings selected by filter. Bulk deletes are not
+// bounded by the operation timeout.
+func (t *TimeoutStore) Purge(ctx context.Context, filter PurgeFilter) (int, error) {
+	return Purge(ctx, t.inner, filter)
+}
+
+// List returns the mappings selected by filter. Like bulk deletes, it is
+// not bounded by the operation timeout.
+func (t *TimeoutStore) List(ctx context.Context, filter PurgeFilter, limit int) ([]Mapping, error) {
+	return List(ctx, t.inner, filter, limit)
+}
+
+// Ping checks the connection of the underlying store
+func (t *TimeoutStore) Ping(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	return Ping(ctx, t.inner)
+}
+
+// NextSequence increments a placeholder counter of the underlying store
+func (t *TimeoutStore) NextSequence(ctx context.Context, scope string) (int, error) {
+	sequencer, ok := t.inner.(Sequencer)
+	if !ok {
+		return 0, errNoSequencer
+	}
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	return sequencer.NextSequence(ctx, scope)
+}
+
+// RecordRestore counts a restoration of placeholder
+func (t *TimeoutStore) RecordRestore(ctx context.Context, placeholder string) error {
+	ctx, cancel := context.WithTimeout(ctx, t.timeout)
+	defer cancel()
+	return RecordRestore(ctx, t.inner, placeholder)
+}
+
+// UsageStats aggregates the restoration counts of all mappings. Like bulk
+// deletes, it is not bounded by the operation timeout.
+func (t *TimeoutStore) UsageStats(ctx context.Context) (UsageStats, error) {
+	return CollectUsageStats(ctx, t.inner)
+}
+
+// Size returns the number of stored mappings
+func (t *TimeoutStore) Size() int {
+	return t.inner.Size()
+}
+
+// Close releases the underlying store
+func (t *TimeoutStore) Close() error {
+	return t.inner.Close()
+}
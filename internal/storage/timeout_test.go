@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// blockingStore is a MappingStore whose operations block until the context is done
+type blockingStore struct {
+	MockStore
+}
+
+func (b *blockingStore) Store(ctx context.Context, _, _ string, _ Metadata) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func (b *blockingStore) Lookup(ctx context.Context, _ string) (string, bool, error) {
+	<-ctx.Done()
+	return "", false, ctx.Err()
+}
+
+func TestWithTimeout(t *testing.T) {
+	inner := &blockingStore{MockStore: *NewMockStore()}
+
+	if store := WithTimeout(inner, 0); store != MappingStore(inner) {
+		t.Error("WithTimeout(0) should return the store itself")
+	}
+
+	store := WithTimeout(inner, 10*time.Millisecond)
+	start := time.Now()
+	if err := store.Store(context.Background(), "__SECRET_1__", "secret1", Metadata{}); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Store() error = %v, want deadline exceeded", err)
+	}
+	if _, _, err := store.Lookup(context.Background(), "__SECRET_1__"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Lookup() error = %v, want deadline exceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("operations took %v, timeout not applied", elapsed)
+	}
+}
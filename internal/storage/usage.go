@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// unknownSecretType groups mappings without a recorded secret type
+const unknownSecretType = "unknown"
+
+// UsageTracker is implemented by stores that account for placeholder
+// restorations
+type UsageTracker interface {
+	// RecordRestore counts a restoration of placeholder; unknown
+	// placeholders are ignored
+	RecordRestore(ctx context.Context, placeholder string) error
+
+	// UsageStats aggregates the restoration counts of all live mappings
+	UsageStats(ctx context.Context) (UsageStats, error)
+}
+
+// UsageStats aggregates how often mappings were restored. Secrets that are
+// never echoed back by the model and types that are restored often help to
+// analyze leak patterns.
+type UsageStats struct {
+	Mappings      int                  `json:"mappings"`
+	NeverRestored int                  `json:"never_restored"`
+	Restores      int64                `json:"restores"`
+	ByType        map[string]TypeUsage `json:"by_type"`
+
+	// MostRestoredTypes lists secret types by descending restore count
+	MostRestoredTypes []string `json:"most_restored_types"`
+}
+
+// TypeUsage aggregates the mappings of one secret type
+type TypeUsage struct {
+	Mappings      int   `json:"mappings"`
+	NeverRestored int   `json:"never_restored"`
+	Restores      int64 `json:"restores"`
+}
+
+// newUsageStats creates empty usage stats
+func newUsageStats() UsageStats {
+	return UsageStats{ByType: make(map[string]TypeUsage)}
+}
+
+// add accounts for a mapping
+func (s *UsageStats) add(mapping *Mapping) {
+	secretType := mapping.Metadata.SecretType
+	if secretType == "" {
+		secretType = unknownSecretType
+	}
+
+	usage := s.ByType[secretType]
+	usage.Mappings++
+	usage.Restores += mapping.RestoreCount
+	s.Mappings++
+	s.Restores += mapping.RestoreCount
+	if mapping.RestoreCount == 0 {
+		usage.NeverRestored++
+		s.NeverRestored++
+	}
+	s.ByType[secretType] = usage
+}
+
+// finish ranks the secret types once all mappings are added
+func (s *UsageStats) finish() {
+	s.MostRestoredTypes = s.MostRestoredTypes[:0]
+	for secretType, usage := range s.ByType {
+		if usage.Restores > 0 {
+			s.MostRestoredTypes = append(s.MostRestoredTypes, secretType)
+		}
+	}
+	sort.Slice(s.MostRestoredTypes, func(i, j int) bool {
+		a, b := s.MostRestoredTypes[i], s.MostRestoredTypes[j]
+		if s.ByType[a].Restores != s.ByType[b].Restores {
+			return s.ByType[a].Restores > s.ByType[b].Restores
+		}
+		return a < b
+	})
+}
+
+// RecordRestore counts a restoration of placeholder if store tracks usage
+func RecordRestore(ctx context.Context, store MappingStore, placeholder string) error {
+	tracker, ok := store.(UsageTracker)
+	if !ok {
+		return nil
+	}
+	return tracker.RecordRestore(ctx, placeholder)
+}
+
+// CollectUsageStats aggregates the restoration counts of store
+func CollectUsageStats(ctx context.Context, store MappingStore) (UsageStats, error) {
+	tracker, ok := store.(UsageTracker)
+	if !ok {
+		return UsageStats{}, fmt.Errorf("store %T does not track usage", store)
+	}
+	return tracker.UsageStats(ctx)
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// checkUsageTracking exercises restore accounting on store
+func checkUsageTracking(t *testing.T, store MappingStore) {
+	t.Helper()
+	ctx := context.Background()
+
+	_ = store.Store(ctx, "__SECRET_00000001__", "secret1", Metadata{SecretType: "api_key"})
+	_ = store.Store(ctx, "__SECRET_00000002__", "secret2", Metadata{SecretType: "api_key"})
+	_ = store.Store(ctx, "__SECRET_00000003__", "secret3", Metadata{SecretType: "password"})
+	_ = store.Store(ctx, "__SECRET_00000004__", "secret4", Metadata{})
+
+	for _, ph := range []string{"__SECRET_00000001__", "__SECRET_00000001__", "__SECRET_00000003__", "__SECRET_00000003__", "__SECRET_00000003__"} {
+		if err := RecordRestore(ctx, store, ph); err != nil {
+			t.Fatalf("RecordRestore() error: %v", err)
+		}
+	}
+	// Restores of unknown placeholders are ignored
+	if err := RecordRestore(ctx, store, "__SECRET_nonexist__"); err != nil {
+		t.Fatalf("RecordRestore() of missing placeholder error: %v", err)
+	}
+
+	mapping, found, err := store.LookupMapping(ctx, "__SECRET_00000003__")
+	if err != nil || !found {
+		t.Fatalf("LookupMapping() = %v, %v", found, err)
+	}
+	if mapping.RestoreCount != 3 || time.Since(mapping.LastRestored) > time.Minute {
+		t.Errorf("mapping usage = %d, %v", mapping.RestoreCount, mapping.LastRestored)
+	}
+
+	stats, err := CollectUsageStats(ctx, store)
+	if err != nil {
+		t.Fatalf("CollectUsageStats() error: %v", err)
+	}
+	if stats.Mappings != 4 || stats.NeverRestored != 2 || stats.Restores != 5 {
+		t.Errorf("stats = %+v", stats)
+	}
+	if got := stats.ByType["api_key"]; got != (TypeUsage{Mappings: 2, NeverRestored: 1, Restores: 2}) {
+		t.Errorf("api_key usage = %+v", got)
+	}
+	if got := stats.ByType[unknownSecretType]; got.NeverRestored != 1 {
+		t.Errorf("unknown type usage = %+v", got)
+	}
+	if want := []string{"password", "api_key"}; !reflect.DeepEqual(stats.MostRestoredTypes, want) {
+		t.Errorf("MostRestoredTypes = %v, want %v", stats.MostRestoredTypes, want)
+	}
+}
+
+func TestMemoryStore_UsageTracking(t *testing.T) {
+	store := NewMemoryStore(time.Hour)
+	defer store.Close()
+	checkUsageTracking(t, store)
+}
+
+func TestRedisStore_UsageTracking(t *testing.T) {
+	store, mr := newTestRedisStore(t)
+	checkUsageTracking(t, store)
+
+	if !mr.Exists("llm-secret:u:__SECRET_00000001__") {
+		t.Fatal("usage hash not written")
+	}
+	if _, err := store.Purge(context.Background(), PurgeFilter{}); err != nil {
+		t.Fatalf("Purge() error: %v", err)
+	}
+	if mr.Exists("llm-secret:u:__SECRET_00000001__") {
+		t.Error("usage hash not purged")
+	}
+}
+
+func TestDynamoDBStore_UsageTracking(t *testing.T) {
+	store, _ := setupDynamoDBStore(t)
+	checkUsageTracking(t, store)
+}
+
+func TestDecoratedStore_UsageTracking(t *testing.T) {
+	base := NewMemoryStore(time.Hour)
+	defer base.Close()
+	store := WithTimeout(NewInstrumentedStore(NewCachedStore(base, 10, time.Minute), "memory"), time.Second)
+	checkUsageTracking(t, store)
+
+	// Namespaced restores count for the namespaced mapping
+	ctx := context.Background()
+	scoped := WithNamespace(store, "team-a")
+	_ = scoped.Store(ctx, "__SECRET_00000009__", "secret9", Metadata{})
+	if err := RecordRestore(ctx, scoped, "__SECRET_00000009__"); err != nil {
+		t.Fatalf("RecordRestore() error: %v", err)
+	}
+	if mapping, _, _ := scoped.LookupMapping(ctx, "__SECRET_00000009__"); mapping == nil || mapping.RestoreCount != 1 {
+		t.Errorf("namespaced mapping = %+v", mapping)
+	}
+}
+
+func TestCollectUsageStats_Unsupported(t *testing.T) {
+	if _, err := CollectUsageStats(context.Background(), NewMockStore()); err == nil {
+		t.Error("CollectUsageStats() should fail for stores without usage tracking")
+	}
+	if err := RecordRestore(context.Background(), NewMockStore(), "__SECRET_1__"); err != nil {
+		t.Errorf("RecordRestore() error: %v", err)
+	}
+}
@@ -0,0 +1,2030 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: llmsecret/admin/v1/admin.proto
+
+// Admin and control-plane API of the LLM Secret Interceptor. It mirrors the
+// HTTP admin API of the management server so fleets of proxies can be
+// managed centrally. Calls need "authorization: Bearer <token>" metadata
+// with the admin token. Secrets are never returned.
+
+package adminv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type HealthRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthRequest) Reset() {
+	*x = HealthRequest{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthRequest) ProtoMessage() {}
+
+func (x *HealthRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthRequest.ProtoReflect.Descriptor instead.
+func (*HealthRequest) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{0}
+}
+
+type HealthResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	Mode          string                 `protobuf:"bytes,2,opt,name=mode,proto3" json:"mode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *HealthResponse) Reset() {
+	*x = HealthResponse{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *HealthResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*HealthResponse) ProtoMessage() {}
+
+func (x *HealthResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use HealthResponse.ProtoReflect.Descriptor instead.
+func (*HealthResponse) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *HealthResponse) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *HealthResponse) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+type GetStatusRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatusRequest) Reset() {
+	*x = GetStatusRequest{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatusRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatusRequest) ProtoMessage() {}
+
+func (x *GetStatusRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatusRequest.ProtoReflect.Descriptor instead.
+func (*GetStatusRequest) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{2}
+}
+
+type GetStatusResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        *Status                `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetStatusResponse) Reset() {
+	*x = GetStatusResponse{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetStatusResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetStatusResponse) ProtoMessage() {}
+
+func (x *GetStatusResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetStatusResponse.ProtoReflect.Descriptor instead.
+func (*GetStatusResponse) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetStatusResponse) GetStatus() *Status {
+	if x != nil {
+		return x.Status
+	}
+	return nil
+}
+
+type Status struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Mode is "enforce" or "shadow"
+	Mode string `protobuf:"bytes,1,opt,name=mode,proto3" json:"mode,omitempty"`
+	// StoreType is the mapping store backend
+	StoreType     string                          `protobuf:"bytes,2,opt,name=store_type,json=storeType,proto3" json:"store_type,omitempty"`
+	Mappings      int64                           `protobuf:"varint,3,opt,name=mappings,proto3" json:"mappings,omitempty"`
+	Interceptors  map[string]*InterceptorSettings `protobuf:"bytes,4,rep,name=interceptors,proto3" json:"interceptors,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Status) Reset() {
+	*x = Status{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Status) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Status) ProtoMessage() {}
+
+func (x *Status) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Status.ProtoReflect.Descriptor instead.
+func (*Status) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *Status) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+func (x *Status) GetStoreType() string {
+	if x != nil {
+		return x.StoreType
+	}
+	return ""
+}
+
+func (x *Status) GetMappings() int64 {
+	if x != nil {
+		return x.Mappings
+	}
+	return 0
+}
+
+func (x *Status) GetInterceptors() map[string]*InterceptorSettings {
+	if x != nil {
+		return x.Interceptors
+	}
+	return nil
+}
+
+type SetModeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Mode          string                 `protobuf:"bytes,1,opt,name=mode,proto3" json:"mode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetModeRequest) Reset() {
+	*x = SetModeRequest{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetModeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetModeRequest) ProtoMessage() {}
+
+func (x *SetModeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetModeRequest.ProtoReflect.Descriptor instead.
+func (*SetModeRequest) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SetModeRequest) GetMode() string {
+	if x != nil {
+		return x.Mode
+	}
+	return ""
+}
+
+type SetModeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        *Status                `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetModeResponse) Reset() {
+	*x = SetModeResponse{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetModeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetModeResponse) ProtoMessage() {}
+
+func (x *SetModeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetModeResponse.ProtoReflect.Descriptor instead.
+func (*SetModeResponse) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SetModeResponse) GetStatus() *Status {
+	if x != nil {
+		return x.Status
+	}
+	return nil
+}
+
+// InterceptorSettings are the tunable settings of an interceptor. In
+// updates, unset fields are left unchanged.
+type InterceptorSettings struct {
+	state             protoimpl.MessageState `protogen:"open.v1"`
+	Enabled           *bool                  `protobuf:"varint,1,opt,name=enabled,proto3,oneof" json:"enabled,omitempty"`
+	Threshold         *float64               `protobuf:"fixed64,2,opt,name=threshold,proto3,oneof" json:"threshold,omitempty"`
+	MinLength         *int32                 `protobuf:"varint,3,opt,name=min_length,json=minLength,proto3,oneof" json:"min_length,omitempty"`
+	MaxLength         *int32                 `protobuf:"varint,4,opt,name=max_length,json=maxLength,proto3,oneof" json:"max_length,omitempty"`
+	DisabledRules     []string               `protobuf:"bytes,5,rep,name=disabled_rules,json=disabledRules,proto3" json:"disabled_rules,omitempty"`
+	TrustedConfidence *float64               `protobuf:"fixed64,6,opt,name=trusted_confidence,json=trustedConfidence,proto3,oneof" json:"trusted_confidence,omitempty"`
+	// update_disabled_rules replaces disabled_rules in updates, even with an
+	// empty list
+	UpdateDisabledRules bool `protobuf:"varint,7,opt,name=update_disabled_rules,json=updateDisabledRules,proto3" json:"update_disabled_rules,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *InterceptorSettings) Reset() {
+	*x = InterceptorSettings{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *InterceptorSettings) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*InterceptorSettings) ProtoMessage() {}
+
+func (x *InterceptorSettings) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use InterceptorSettings.ProtoReflect.Descriptor instead.
+func (*InterceptorSettings) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *InterceptorSettings) GetEnabled() bool {
+	if x != nil && x.Enabled != nil {
+		return *x.Enabled
+	}
+	return false
+}
+
+func (x *InterceptorSettings) GetThreshold() float64 {
+	if x != nil && x.Threshold != nil {
+		return *x.Threshold
+	}
+	return 0
+}
+
+func (x *InterceptorSettings) GetMinLength() int32 {
+	if x != nil && x.MinLength != nil {
+		return *x.MinLength
+	}
+	return 0
+}
+
+func (x *InterceptorSettings) GetMaxLength() int32 {
+	if x != nil && x.MaxLength != nil {
+		return *x.MaxLength
+	}
+	return 0
+}
+
+func (x *InterceptorSettings) GetDisabledRules() []string {
+	if x != nil {
+		return x.DisabledRules
+	}
+	return nil
+}
+
+func (x *InterceptorSettings) GetTrustedConfidence() float64 {
+	if x != nil && x.TrustedConfidence != nil {
+		return *x.TrustedConfidence
+	}
+	return 0
+}
+
+func (x *InterceptorSettings) GetUpdateDisabledRules() bool {
+	if x != nil {
+		return x.UpdateDisabledRules
+	}
+	return false
+}
+
+type UpdateInterceptorRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Name is "entropy", "pattern" or "code_context"
+	Name          string               `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Settings      *InterceptorSettings `protobuf:"bytes,2,opt,name=settings,proto3" json:"settings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateInterceptorRequest) Reset() {
+	*x = UpdateInterceptorRequest{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateInterceptorRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateInterceptorRequest) ProtoMessage() {}
+
+func (x *UpdateInterceptorRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateInterceptorRequest.ProtoReflect.Descriptor instead.
+func (*UpdateInterceptorRequest) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *UpdateInterceptorRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UpdateInterceptorRequest) GetSettings() *InterceptorSettings {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+type UpdateInterceptorResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Settings      *InterceptorSettings   `protobuf:"bytes,1,opt,name=settings,proto3" json:"settings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateInterceptorResponse) Reset() {
+	*x = UpdateInterceptorResponse{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateInterceptorResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateInterceptorResponse) ProtoMessage() {}
+
+func (x *UpdateInterceptorResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateInterceptorResponse.ProtoReflect.Descriptor instead.
+func (*UpdateInterceptorResponse) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *UpdateInterceptorResponse) GetSettings() *InterceptorSettings {
+	if x != nil {
+		return x.Settings
+	}
+	return nil
+}
+
+type GetConfigRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetConfigRequest) Reset() {
+	*x = GetConfigRequest{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetConfigRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetConfigRequest) ProtoMessage() {}
+
+func (x *GetConfigRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetConfigRequest.ProtoReflect.Descriptor instead.
+func (*GetConfigRequest) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{10}
+}
+
+type GetConfigResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Yaml is the redacted configuration in YAML
+	Yaml string `protobuf:"bytes,1,opt,name=yaml,proto3" json:"yaml,omitempty"`
+	// Environment lists the configuration environment variables that are set
+	Environment   []string `protobuf:"bytes,2,rep,name=environment,proto3" json:"environment,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetConfigResponse) Reset() {
+	*x = GetConfigResponse{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetConfigResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetConfigResponse) ProtoMessage() {}
+
+func (x *GetConfigResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetConfigResponse.ProtoReflect.Descriptor instead.
+func (*GetConfigResponse) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *GetConfigResponse) GetYaml() string {
+	if x != nil {
+		return x.Yaml
+	}
+	return ""
+}
+
+func (x *GetConfigResponse) GetEnvironment() []string {
+	if x != nil {
+		return x.Environment
+	}
+	return nil
+}
+
+type Rule struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Name        string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Pattern     string                 `protobuf:"bytes,2,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	Type        string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	Confidence  float64                `protobuf:"fixed64,4,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	Description string                 `protobuf:"bytes,5,opt,name=description,proto3" json:"description,omitempty"`
+	// Source is "builtin", "config", "runtime" or a rule file
+	Source        string `protobuf:"bytes,6,opt,name=source,proto3" json:"source,omitempty"`
+	Disabled      bool   `protobuf:"varint,7,opt,name=disabled,proto3" json:"disabled,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Rule) Reset() {
+	*x = Rule{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Rule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rule) ProtoMessage() {}
+
+func (x *Rule) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rule.ProtoReflect.Descriptor instead.
+func (*Rule) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *Rule) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Rule) GetPattern() string {
+	if x != nil {
+		return x.Pattern
+	}
+	return ""
+}
+
+func (x *Rule) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Rule) GetConfidence() float64 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+func (x *Rule) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Rule) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *Rule) GetDisabled() bool {
+	if x != nil {
+		return x.Disabled
+	}
+	return false
+}
+
+type ListRulesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRulesRequest) Reset() {
+	*x = ListRulesRequest{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRulesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRulesRequest) ProtoMessage() {}
+
+func (x *ListRulesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRulesRequest.ProtoReflect.Descriptor instead.
+func (*ListRulesRequest) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{13}
+}
+
+type ListRulesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rules         []*Rule                `protobuf:"bytes,1,rep,name=rules,proto3" json:"rules,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListRulesResponse) Reset() {
+	*x = ListRulesResponse{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListRulesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListRulesResponse) ProtoMessage() {}
+
+func (x *ListRulesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListRulesResponse.ProtoReflect.Descriptor instead.
+func (*ListRulesResponse) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ListRulesResponse) GetRules() []*Rule {
+	if x != nil {
+		return x.Rules
+	}
+	return nil
+}
+
+type AddRuleRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Pattern       string                 `protobuf:"bytes,2,opt,name=pattern,proto3" json:"pattern,omitempty"`
+	Type          string                 `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	Confidence    float64                `protobuf:"fixed64,4,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddRuleRequest) Reset() {
+	*x = AddRuleRequest{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddRuleRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddRuleRequest) ProtoMessage() {}
+
+func (x *AddRuleRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddRuleRequest.ProtoReflect.Descriptor instead.
+func (*AddRuleRequest) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *AddRuleRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *AddRuleRequest) GetPattern() string {
+	if x != nil {
+		return x.Pattern
+	}
+	return ""
+}
+
+func (x *AddRuleRequest) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *AddRuleRequest) GetConfidence() float64 {
+	if x != nil {
+		return x.Confidence
+	}
+	return 0
+}
+
+type AddRuleResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rule          *Rule                  `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AddRuleResponse) Reset() {
+	*x = AddRuleResponse{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AddRuleResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AddRuleResponse) ProtoMessage() {}
+
+func (x *AddRuleResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AddRuleResponse.ProtoReflect.Descriptor instead.
+func (*AddRuleResponse) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *AddRuleResponse) GetRule() *Rule {
+	if x != nil {
+		return x.Rule
+	}
+	return nil
+}
+
+type SetRuleDisabledRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Disabled      bool                   `protobuf:"varint,2,opt,name=disabled,proto3" json:"disabled,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetRuleDisabledRequest) Reset() {
+	*x = SetRuleDisabledRequest{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetRuleDisabledRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetRuleDisabledRequest) ProtoMessage() {}
+
+func (x *SetRuleDisabledRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetRuleDisabledRequest.ProtoReflect.Descriptor instead.
+func (*SetRuleDisabledRequest) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *SetRuleDisabledRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *SetRuleDisabledRequest) GetDisabled() bool {
+	if x != nil {
+		return x.Disabled
+	}
+	return false
+}
+
+type SetRuleDisabledResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Rule          *Rule                  `protobuf:"bytes,1,opt,name=rule,proto3" json:"rule,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *SetRuleDisabledResponse) Reset() {
+	*x = SetRuleDisabledResponse{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetRuleDisabledResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetRuleDisabledResponse) ProtoMessage() {}
+
+func (x *SetRuleDisabledResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetRuleDisabledResponse.ProtoReflect.Descriptor instead.
+func (*SetRuleDisabledResponse) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *SetRuleDisabledResponse) GetRule() *Rule {
+	if x != nil {
+		return x.Rule
+	}
+	return nil
+}
+
+// MappingFilter selects mappings; empty fields select all
+type MappingFilter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Namespace     string                 `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	SecretType    string                 `protobuf:"bytes,2,opt,name=secret_type,json=secretType,proto3" json:"secret_type,omitempty"`
+	RequestId     string                 `protobuf:"bytes,3,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	OlderThan     *durationpb.Duration   `protobuf:"bytes,4,opt,name=older_than,json=olderThan,proto3" json:"older_than,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MappingFilter) Reset() {
+	*x = MappingFilter{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MappingFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MappingFilter) ProtoMessage() {}
+
+func (x *MappingFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MappingFilter.ProtoReflect.Descriptor instead.
+func (*MappingFilter) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *MappingFilter) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *MappingFilter) GetSecretType() string {
+	if x != nil {
+		return x.SecretType
+	}
+	return ""
+}
+
+func (x *MappingFilter) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+func (x *MappingFilter) GetOlderThan() *durationpb.Duration {
+	if x != nil {
+		return x.OlderThan
+	}
+	return nil
+}
+
+type Mapping struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Placeholder   string                 `protobuf:"bytes,1,opt,name=placeholder,proto3" json:"placeholder,omitempty"`
+	Namespace     string                 `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	LastUsed      *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=last_used,json=lastUsed,proto3" json:"last_used,omitempty"`
+	RestoreCount  int64                  `protobuf:"varint,5,opt,name=restore_count,json=restoreCount,proto3" json:"restore_count,omitempty"`
+	LastRestored  *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=last_restored,json=lastRestored,proto3" json:"last_restored,omitempty"`
+	SecretType    string                 `protobuf:"bytes,7,opt,name=secret_type,json=secretType,proto3" json:"secret_type,omitempty"`
+	Interceptor   string                 `protobuf:"bytes,8,opt,name=interceptor,proto3" json:"interceptor,omitempty"`
+	Rule          string                 `protobuf:"bytes,9,opt,name=rule,proto3" json:"rule,omitempty"`
+	SourceHost    string                 `protobuf:"bytes,10,opt,name=source_host,json=sourceHost,proto3" json:"source_host,omitempty"`
+	RequestId     string                 `protobuf:"bytes,11,opt,name=request_id,json=requestId,proto3" json:"request_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Mapping) Reset() {
+	*x = Mapping{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Mapping) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Mapping) ProtoMessage() {}
+
+func (x *Mapping) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Mapping.ProtoReflect.Descriptor instead.
+func (*Mapping) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *Mapping) GetPlaceholder() string {
+	if x != nil {
+		return x.Placeholder
+	}
+	return ""
+}
+
+func (x *Mapping) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *Mapping) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Mapping) GetLastUsed() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastUsed
+	}
+	return nil
+}
+
+func (x *Mapping) GetRestoreCount() int64 {
+	if x != nil {
+		return x.RestoreCount
+	}
+	return 0
+}
+
+func (x *Mapping) GetLastRestored() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastRestored
+	}
+	return nil
+}
+
+func (x *Mapping) GetSecretType() string {
+	if x != nil {
+		return x.SecretType
+	}
+	return ""
+}
+
+func (x *Mapping) GetInterceptor() string {
+	if x != nil {
+		return x.Interceptor
+	}
+	return ""
+}
+
+func (x *Mapping) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *Mapping) GetSourceHost() string {
+	if x != nil {
+		return x.SourceHost
+	}
+	return ""
+}
+
+func (x *Mapping) GetRequestId() string {
+	if x != nil {
+		return x.RequestId
+	}
+	return ""
+}
+
+type ListMappingsRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Filter *MappingFilter         `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	// Limit caps the listing; 0 lists all selected mappings
+	Limit         int32 `protobuf:"varint,2,opt,name=limit,proto3" json:"limit,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListMappingsRequest) Reset() {
+	*x = ListMappingsRequest{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListMappingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMappingsRequest) ProtoMessage() {}
+
+func (x *ListMappingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMappingsRequest.ProtoReflect.Descriptor instead.
+func (*ListMappingsRequest) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *ListMappingsRequest) GetFilter() *MappingFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *ListMappingsRequest) GetLimit() int32 {
+	if x != nil {
+		return x.Limit
+	}
+	return 0
+}
+
+type ListMappingsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Mappings      []*Mapping             `protobuf:"bytes,1,rep,name=mappings,proto3" json:"mappings,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListMappingsResponse) Reset() {
+	*x = ListMappingsResponse{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListMappingsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMappingsResponse) ProtoMessage() {}
+
+func (x *ListMappingsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMappingsResponse.ProtoReflect.Descriptor instead.
+func (*ListMappingsResponse) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{22}
+}
+
+func (x *ListMappingsResponse) GetMappings() []*Mapping {
+	if x != nil {
+		return x.Mappings
+	}
+	return nil
+}
+
+type LookupMappingRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Namespace     string                 `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Placeholder   string                 `protobuf:"bytes,2,opt,name=placeholder,proto3" json:"placeholder,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LookupMappingRequest) Reset() {
+	*x = LookupMappingRequest{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LookupMappingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LookupMappingRequest) ProtoMessage() {}
+
+func (x *LookupMappingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LookupMappingRequest.ProtoReflect.Descriptor instead.
+func (*LookupMappingRequest) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{23}
+}
+
+func (x *LookupMappingRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *LookupMappingRequest) GetPlaceholder() string {
+	if x != nil {
+		return x.Placeholder
+	}
+	return ""
+}
+
+type LookupMappingResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Mapping       *Mapping               `protobuf:"bytes,1,opt,name=mapping,proto3" json:"mapping,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *LookupMappingResponse) Reset() {
+	*x = LookupMappingResponse{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *LookupMappingResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*LookupMappingResponse) ProtoMessage() {}
+
+func (x *LookupMappingResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use LookupMappingResponse.ProtoReflect.Descriptor instead.
+func (*LookupMappingResponse) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *LookupMappingResponse) GetMapping() *Mapping {
+	if x != nil {
+		return x.Mapping
+	}
+	return nil
+}
+
+type DeleteMappingRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Namespace     string                 `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Placeholder   string                 `protobuf:"bytes,2,opt,name=placeholder,proto3" json:"placeholder,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteMappingRequest) Reset() {
+	*x = DeleteMappingRequest{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteMappingRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteMappingRequest) ProtoMessage() {}
+
+func (x *DeleteMappingRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteMappingRequest.ProtoReflect.Descriptor instead.
+func (*DeleteMappingRequest) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *DeleteMappingRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *DeleteMappingRequest) GetPlaceholder() string {
+	if x != nil {
+		return x.Placeholder
+	}
+	return ""
+}
+
+type DeleteMappingResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteMappingResponse) Reset() {
+	*x = DeleteMappingResponse{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[26]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteMappingResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteMappingResponse) ProtoMessage() {}
+
+func (x *DeleteMappingResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[26]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteMappingResponse.ProtoReflect.Descriptor instead.
+func (*DeleteMappingResponse) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{26}
+}
+
+type PurgeMappingsRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	Filter *MappingFilter         `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	// All must be set to purge all mappings with an empty filter
+	All           bool `protobuf:"varint,2,opt,name=all,proto3" json:"all,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PurgeMappingsRequest) Reset() {
+	*x = PurgeMappingsRequest{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[27]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PurgeMappingsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PurgeMappingsRequest) ProtoMessage() {}
+
+func (x *PurgeMappingsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[27]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PurgeMappingsRequest.ProtoReflect.Descriptor instead.
+func (*PurgeMappingsRequest) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{27}
+}
+
+func (x *PurgeMappingsRequest) GetFilter() *MappingFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+func (x *PurgeMappingsRequest) GetAll() bool {
+	if x != nil {
+		return x.All
+	}
+	return false
+}
+
+type PurgeMappingsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Deleted       int64                  `protobuf:"varint,1,opt,name=deleted,proto3" json:"deleted,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PurgeMappingsResponse) Reset() {
+	*x = PurgeMappingsResponse{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[28]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PurgeMappingsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PurgeMappingsResponse) ProtoMessage() {}
+
+func (x *PurgeMappingsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[28]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PurgeMappingsResponse.ProtoReflect.Descriptor instead.
+func (*PurgeMappingsResponse) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{28}
+}
+
+func (x *PurgeMappingsResponse) GetDeleted() int64 {
+	if x != nil {
+		return x.Deleted
+	}
+	return 0
+}
+
+type WatchDetectionsRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchDetectionsRequest) Reset() {
+	*x = WatchDetectionsRequest{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[29]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchDetectionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchDetectionsRequest) ProtoMessage() {}
+
+func (x *WatchDetectionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[29]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchDetectionsRequest.ProtoReflect.Descriptor instead.
+func (*WatchDetectionsRequest) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{29}
+}
+
+type WatchDetectionsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Detection     *Detection             `protobuf:"bytes,1,opt,name=detection,proto3" json:"detection,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchDetectionsResponse) Reset() {
+	*x = WatchDetectionsResponse{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[30]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchDetectionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchDetectionsResponse) ProtoMessage() {}
+
+func (x *WatchDetectionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[30]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchDetectionsResponse.ProtoReflect.Descriptor instead.
+func (*WatchDetectionsResponse) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{30}
+}
+
+func (x *WatchDetectionsResponse) GetDetection() *Detection {
+	if x != nil {
+		return x.Detection
+	}
+	return nil
+}
+
+type Detection struct {
+	state       protoimpl.MessageState `protogen:"open.v1"`
+	Time        *timestamppb.Timestamp `protobuf:"bytes,1,opt,name=time,proto3" json:"time,omitempty"`
+	Host        string                 `protobuf:"bytes,2,opt,name=host,proto3" json:"host,omitempty"`
+	Interceptor string                 `protobuf:"bytes,3,opt,name=interceptor,proto3" json:"interceptor,omitempty"`
+	Rule        string                 `protobuf:"bytes,4,opt,name=rule,proto3" json:"rule,omitempty"`
+	Type        string                 `protobuf:"bytes,5,opt,name=type,proto3" json:"type,omitempty"`
+	// Action is mask, redact, block or shadow
+	Action        string `protobuf:"bytes,6,opt,name=action,proto3" json:"action,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Detection) Reset() {
+	*x = Detection{}
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[31]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Detection) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Detection) ProtoMessage() {}
+
+func (x *Detection) ProtoReflect() protoreflect.Message {
+	mi := &file_llmsecret_admin_v1_admin_proto_msgTypes[31]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Detection.ProtoReflect.Descriptor instead.
+func (*Detection) Descriptor() ([]byte, []int) {
+	return file_llmsecret_admin_v1_admin_proto_rawDescGZIP(), []int{31}
+}
+
+func (x *Detection) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *Detection) GetHost() string {
+	if x != nil {
+		return x.Host
+	}
+	return ""
+}
+
+func (x *Detection) GetInterceptor() string {
+	if x != nil {
+		return x.Interceptor
+	}
+	return ""
+}
+
+func (x *Detection) GetRule() string {
+	if x != nil {
+		return x.Rule
+	}
+	return ""
+}
+
+func (x *Detection) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Detection) GetAction() string {
+	if x != nil {
+		return x.Action
+	}
+	return ""
+}
+
+var File_llmsecret_admin_v1_admin_proto protoreflect.FileDescriptor
+
+const file_llmsecret_admin_v1_admin_proto_rawDesc = "" +
+	"\n" +
+	"\x1ellmsecret/admin/v1/admin.proto\x12\x12llmsecret.admin.v1\x1a\x1egoogle/protobuf/duration.proto\x1a\x1fgoogle/protobuf/timestamp.proto\"\x0f\n" +
+	"\rHealthRequest\"<\n" +
+	"\x0eHealthResponse\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\x12\x12\n" +
+	"\x04mode\x18\x02 \x01(\tR\x04mode\"\x12\n" +
+	"\x10GetStatusRequest\"G\n" +
+	"\x11GetStatusResponse\x122\n" +
+	"\x06status\x18\x01 \x01(\v2\x1a.llmsecret.admin.v1.StatusR\x06status\"\x93\x02\n" +
+	"\x06Status\x12\x12\n" +
+	"\x04mode\x18\x01 \x01(\tR\x04mode\x12\x1d\n" +
+	"\n" +
+	"store_type\x18\x02 \x01(\tR\tstoreType\x12\x1a\n" +
+	"\bmappings\x18\x03 \x01(\x03R\bmappings\x12P\n" +
+	"\finterceptors\x18\x04 \x03(\v2,.llmsecret.admin.v1.Status.InterceptorsEntryR\finterceptors\x1ah\n" +
+	"\x11InterceptorsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12=\n" +
+	"\x05value\x18\x02 \x01(\v2'.llmsecret.admin.v1.InterceptorSettingsR\x05value:\x028\x01\"$\n" +
+	"\x0eSetModeRequest\x12\x12\n" +
+	"\x04mode\x18\x01 \x01(\tR\x04mode\"E\n" +
+	"\x0fSetModeResponse\x122\n" +
+	"\x06status\x18\x01 \x01(\v2\x1a.llmsecret.admin.v1.StatusR\x06status\"\xfd\x02\n" +
+	"\x13InterceptorSettings\x12\x1d\n" +
+	"\aenabled\x18\x01 \x01(\bH\x00R\aenabled\x88\x01\x01\x12!\n" +
+	"\tthreshold\x18\x02 \x01(\x01H\x01R\tthreshold\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"min_length\x18\x03 \x01(\x05H\x02R\tminLength\x88\x01\x01\x12\"\n" +
+	"\n" +
+	"max_length\x18\x04 \x01(\x05H\x03R\tmaxLength\x88\x01\x01\x12%\n" +
+	"\x0edisabled_rules\x18\x05 \x03(\tR\rdisabledRules\x122\n" +
+	"\x12trusted_confidence\x18\x06 \x01(\x01H\x04R\x11trustedConfidence\x88\x01\x01\x122\n" +
+	"\x15update_disabled_rules\x18\a \x01(\bR\x13updateDisabledRulesB\n" +
+	"\n" +
+	"\b_enabledB\f\n" +
+	"\n" +
+	"_thresholdB\r\n" +
+	"\v_min_lengthB\r\n" +
+	"\v_max_lengthB\x15\n" +
+	"\x13_trusted_confidence\"s\n" +
+	"\x18UpdateInterceptorRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12C\n" +
+	"\bsettings\x18\x02 \x01(\v2'.llmsecret.admin.v1.InterceptorSettingsR\bsettings\"`\n" +
+	"\x19UpdateInterceptorResponse\x12C\n" +
+	"\bsettings\x18\x01 \x01(\v2'.llmsecret.admin.v1.InterceptorSettingsR\bsettings\"\x12\n" +
+	"\x10GetConfigRequest\"I\n" +
+	"\x11GetConfigResponse\x12\x12\n" +
+	"\x04yaml\x18\x01 \x01(\tR\x04yaml\x12 \n" +
+	"\venvironment\x18\x02 \x03(\tR\venvironment\"\xbe\x01\n" +
+	"\x04Rule\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\apattern\x18\x02 \x01(\tR\apattern\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12\x1e\n" +
+	"\n" +
+	"confidence\x18\x04 \x01(\x01R\n" +
+	"confidence\x12 \n" +
+	"\vdescription\x18\x05 \x01(\tR\vdescription\x12\x16\n" +
+	"\x06source\x18\x06 \x01(\tR\x06source\x12\x1a\n" +
+	"\bdisabled\x18\a \x01(\bR\bdisabled\"\x12\n" +
+	"\x10ListRulesRequest\"C\n" +
+	"\x11ListRulesResponse\x12.\n" +
+	"\x05rules\x18\x01 \x03(\v2\x18.llmsecret.admin.v1.RuleR\x05rules\"r\n" +
+	"\x0eAddRuleRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\apattern\x18\x02 \x01(\tR\apattern\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12\x1e\n" +
+	"\n" +
+	"confidence\x18\x04 \x01(\x01R\n" +
+	"confidence\"?\n" +
+	"\x0fAddRuleResponse\x12,\n" +
+	"\x04rule\x18\x01 \x01(\v2\x18.llmsecret.admin.v1.RuleR\x04rule\"H\n" +
+	"\x16SetRuleDisabledRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x1a\n" +
+	"\bdisabled\x18\x02 \x01(\bR\bdisabled\"G\n" +
+	"\x17SetRuleDisabledResponse\x12,\n" +
+	"\x04rule\x18\x01 \x01(\v2\x18.llmsecret.admin.v1.RuleR\x04rule\"\xa7\x01\n" +
+	"\rMappingFilter\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x1f\n" +
+	"\vsecret_type\x18\x02 \x01(\tR\n" +
+	"secretType\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\x03 \x01(\tR\trequestId\x128\n" +
+	"\n" +
+	"older_than\x18\x04 \x01(\v2\x19.google.protobuf.DurationR\tolderThan\"\xba\x03\n" +
+	"\aMapping\x12 \n" +
+	"\vplaceholder\x18\x01 \x01(\tR\vplaceholder\x12\x1c\n" +
+	"\tnamespace\x18\x02 \x01(\tR\tnamespace\x129\n" +
+	"\n" +
+	"created_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x127\n" +
+	"\tlast_used\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\blastUsed\x12#\n" +
+	"\rrestore_count\x18\x05 \x01(\x03R\frestoreCount\x12?\n" +
+	"\rlast_restored\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\flastRestored\x12\x1f\n" +
+	"\vsecret_type\x18\a \x01(\tR\n" +
+	"secretType\x12 \n" +
+	"\vinterceptor\x18\b \x01(\tR\vinterceptor\x12\x12\n" +
+	"\x04rule\x18\t \x01(\tR\x04rule\x12\x1f\n" +
+	"\vsource_host\x18\n" +
+	" \x01(\tR\n" +
+	"sourceHost\x12\x1d\n" +
+	"\n" +
+	"request_id\x18\v \x01(\tR\trequestId\"f\n" +
+	"\x13ListMappingsRequest\x129\n" +
+	"\x06filter\x18\x01 \x01(\v2!.llmsecret.admin.v1.MappingFilterR\x06filter\x12\x14\n" +
+	"\x05limit\x18\x02 \x01(\x05R\x05limit\"O\n" +
+	"\x14ListMappingsResponse\x127\n" +
+	"\bmappings\x18\x01 \x03(\v2\x1b.llmsecret.admin.v1.MappingR\bmappings\"V\n" +
+	"\x14LookupMappingRequest\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12 \n" +
+	"\vplaceholder\x18\x02 \x01(\tR\vplaceholder\"N\n" +
+	"\x15LookupMappingResponse\x125\n" +
+	"\amapping\x18\x01 \x01(\v2\x1b.llmsecret.admin.v1.MappingR\amapping\"V\n" +
+	"\x14DeleteMappingRequest\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12 \n" +
+	"\vplaceholder\x18\x02 \x01(\tR\vplaceholder\"\x17\n" +
+	"\x15DeleteMappingResponse\"c\n" +
+	"\x14PurgeMappingsRequest\x129\n" +
+	"\x06filter\x18\x01 \x01(\v2!.llmsecret.admin.v1.MappingFilterR\x06filter\x12\x10\n" +
+	"\x03all\x18\x02 \x01(\bR\x03all\"1\n" +
+	"\x15PurgeMappingsResponse\x12\x18\n" +
+	"\adeleted\x18\x01 \x01(\x03R\adeleted\"\x18\n" +
+	"\x16WatchDetectionsRequest\"V\n" +
+	"\x17WatchDetectionsResponse\x12;\n" +
+	"\tdetection\x18\x01 \x01(\v2\x1d.llmsecret.admin.v1.DetectionR\tdetection\"\xb1\x01\n" +
+	"\tDetection\x12.\n" +
+	"\x04time\x18\x01 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12\x12\n" +
+	"\x04host\x18\x02 \x01(\tR\x04host\x12 \n" +
+	"\vinterceptor\x18\x03 \x01(\tR\vinterceptor\x12\x12\n" +
+	"\x04rule\x18\x04 \x01(\tR\x04rule\x12\x12\n" +
+	"\x04type\x18\x05 \x01(\tR\x04type\x12\x16\n" +
+	"\x06action\x18\x06 \x01(\tR\x06action2\xf6\t\n" +
+	"\fAdminService\x12O\n" +
+	"\x06Health\x12!.llmsecret.admin.v1.HealthRequest\x1a\".llmsecret.admin.v1.HealthResponse\x12X\n" +
+	"\tGetStatus\x12$.llmsecret.admin.v1.GetStatusRequest\x1a%.llmsecret.admin.v1.GetStatusResponse\x12R\n" +
+	"\aSetMode\x12\".llmsecret.admin.v1.SetModeRequest\x1a#.llmsecret.admin.v1.SetModeResponse\x12p\n" +
+	"\x11UpdateInterceptor\x12,.llmsecret.admin.v1.UpdateInterceptorRequest\x1a-.llmsecret.admin.v1.UpdateInterceptorResponse\x12X\n" +
+	"\tGetConfig\x12$.llmsecret.admin.v1.GetConfigRequest\x1a%.llmsecret.admin.v1.GetConfigResponse\x12X\n" +
+	"\tListRules\x12$.llmsecret.admin.v1.ListRulesRequest\x1a%.llmsecret.admin.v1.ListRulesResponse\x12R\n" +
+	"\aAddRule\x12\".llmsecret.admin.v1.AddRuleRequest\x1a#.llmsecret.admin.v1.AddRuleResponse\x12j\n" +
+	"\x0fSetRuleDisabled\x12*.llmsecret.admin.v1.SetRuleDisabledRequest\x1a+.llmsecret.admin.v1.SetRuleDisabledResponse\x12a\n" +
+	"\fListMappings\x12'.llmsecret.admin.v1.ListMappingsRequest\x1a(.llmsecret.admin.v1.ListMappingsResponse\x12d\n" +
+	"\rLookupMapping\x12(.llmsecret.admin.v1.LookupMappingRequest\x1a).llmsecret.admin.v1.LookupMappingResponse\x12d\n" +
+	"\rDeleteMapping\x12(.llmsecret.admin.v1.DeleteMappingRequest\x1a).llmsecret.admin.v1.DeleteMappingResponse\x12d\n" +
+	"\rPurgeMappings\x12(.llmsecret.admin.v1.PurgeMappingsRequest\x1a).llmsecret.admin.v1.PurgeMappingsResponse\x12l\n" +
+	"\x0fWatchDetections\x12*.llmsecret.admin.v1.WatchDetectionsRequest\x1a+.llmsecret.admin.v1.WatchDetectionsResponse0\x01B?Z=github.com/hfi/llm-secret-interceptor/pkg/adminapi/v1;adminv1b\x06proto3"
+
+var (
+	file_llmsecret_admin_v1_admin_proto_rawDescOnce sync.Once
+	file_llmsecret_admin_v1_admin_proto_rawDescData []byte
+)
+
+func file_llmsecret_admin_v1_admin_proto_rawDescGZIP() []byte {
+	file_llmsecret_admin_v1_admin_proto_rawDescOnce.Do(func() {
+		file_llmsecret_admin_v1_admin_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_llmsecret_admin_v1_admin_proto_rawDesc), len(file_llmsecret_admin_v1_admin_proto_rawDesc)))
+	})
+	return file_llmsecret_admin_v1_admin_proto_rawDescData
+}
+
+var file_llmsecret_admin_v1_admin_proto_msgTypes = make([]protoimpl.MessageInfo, 33)
+var file_llmsecret_admin_v1_admin_proto_goTypes = []any{
+	(*HealthRequest)(nil),             // 0: llmsecret.admin.v1.HealthRequest
+	(*HealthResponse)(nil),            // 1: llmsecret.admin.v1.HealthResponse
+	(*GetStatusRequest)(nil),          // 2: llmsecret.admin.v1.GetStatusRequest
+	(*GetStatusResponse)(nil),         // 3: llmsecret.admin.v1.GetStatusResponse
+	(*Status)(nil),                    // 4: llmsecret.admin.v1.Status
+	(*SetModeRequest)(nil),            // 5: llmsecret.admin.v1.SetModeRequest
+	(*SetModeResponse)(nil),           // 6: llmsecret.admin.v1.SetModeResponse
+	(*InterceptorSettings)(nil),       // 7: llmsecret.admin.v1.InterceptorSettings
+	(*UpdateInterceptorRequest)(nil),  // 8: llmsecret.admin.v1.UpdateInterceptorRequest
+	(*UpdateInterceptorResponse)(nil), // 9: llmsecret.admin.v1.UpdateInterceptorResponse
+	(*GetConfigRequest)(nil),          // 10: llmsecret.admin.v1.GetConfigRequest
+	(*GetConfigResponse)(nil),         // 11: llmsecret.admin.v1.GetConfigResponse
+	(*Rule)(nil),                      // 12: llmsecret.admin.v1.Rule
+	(*ListRulesRequest)(nil),          // 13: llmsecret.admin.v1.ListRulesRequest
+	(*ListRulesResponse)(nil),         // 14: llmsecret.admin.v1.ListRulesResponse
+	(*AddRuleRequest)(nil),            // 15: llmsecret.admin.v1.AddRuleRequest
+	(*AddRuleResponse)(nil),           // 16: llmsecret.admin.v1.AddRuleResponse
+	(*SetRuleDisabledRequest)(nil),    // 17: llmsecret.admin.v1.SetRuleDisabledRequest
+	(*SetRuleDisabledResponse)(nil),   // 18: llmsecret.admin.v1.SetRuleDisabledResponse
+	(*MappingFilter)(nil),             // 19: llmsecret.admin.v1.MappingFilter
+	(*Mapping)(nil),                   // 20: llmsecret.admin.v1.Mapping
+	(*ListMappingsRequest)(nil),       // 21: llmsecret.admin.v1.ListMappingsRequest
+	(*ListMappingsResponse)(nil),      // 22: llmsecret.admin.v1.ListMappingsResponse
+	(*LookupMappingRequest)(nil),      // 23: llmsecret.admin.v1.LookupMappingRequest
+	(*LookupMappingResponse)(nil),     // 24: llmsecret.admin.v1.LookupMappingResponse
+	(*DeleteMappingRequest)(nil),      // 25: llmsecret.admin.v1.DeleteMappingRequest
+	(*DeleteMappingResponse)(nil),     // 26: llmsecret.admin.v1.DeleteMappingResponse
+	(*PurgeMappingsRequest)(nil),      // 27: llmsecret.admin.v1.PurgeMappingsRequest
+	(*PurgeMappingsResponse)(nil),     // 28: llmsecret.admin.v1.PurgeMappingsResponse
+	(*WatchDetectionsRequest)(nil),    // 29: llmsecret.admin.v1.WatchDetectionsRequest
+	(*WatchDetectionsResponse)(nil),   // 30: llmsecret.admin.v1.WatchDetectionsResponse
+	(*Detection)(nil),                 // 31: llmsecret.admin.v1.Detection
+	nil,                               // 32: llmsecret.admin.v1.Status.InterceptorsEntry
+	(*durationpb.Duration)(nil),       // 33: google.protobuf.Duration
+	(*timestamppb.Timestamp)(nil),     // 34: google.protobuf.Timestamp
+}
+var file_llmsecret_admin_v1_admin_proto_depIdxs = []int32{
+	4,  // 0: llmsecret.admin.v1.GetStatusResponse.status:type_name -> llmsecret.admin.v1.Status
+	32, // 1: llmsecret.admin.v1.Status.interceptors:type_name -> llmsecret.admin.v1.Status.InterceptorsEntry
+	4,  // 2: llmsecret.admin.v1.SetModeResponse.status:type_name -> llmsecret.admin.v1.Status
+	7,  // 3: llmsecret.admin.v1.UpdateInterceptorRequest.settings:type_name -> llmsecret.admin.v1.InterceptorSettings
+	7,  // 4: llmsecret.admin.v1.UpdateInterceptorResponse.settings:type_name -> llmsecret.admin.v1.InterceptorSettings
+	12, // 5: llmsecret.admin.v1.ListRulesResponse.rules:type_name -> llmsecret.admin.v1.Rule
+	12, // 6: llmsecret.admin.v1.AddRuleResponse.rule:type_name -> llmsecret.admin.v1.Rule
+	12, // 7: llmsecret.admin.v1.SetRuleDisabledResponse.rule:type_name -> llmsecret.admin.v1.Rule
+	33, // 8: llmsecret.admin.v1.MappingFilter.older_than:type_name -> google.protobuf.Duration
+	34, // 9: llmsecret.admin.v1.Mapping.created_at:type_name -> google.protobuf.Timestamp
+	34, // 10: llmsecret.admin.v1.Mapping.last_used:type_name -> google.protobuf.Timestamp
+	34, // 11: llmsecret.admin.v1.Mapping.last_restored:type_name -> google.protobuf.Timestamp
+	19, // 12: llmsecret.admin.v1.ListMappingsRequest.filter:type_name -> llmsecret.admin.v1.MappingFilter
+	20, // 13: llmsecret.admin.v1.ListMappingsResponse.mappings:type_name -> llmsecret.admin.v1.Mapping
+	20, // 14: llmsecret.admin.v1.LookupMappingResponse.mapping:type_name -> llmsecret.admin.v1.Mapping
+	19, // 15: llmsecret.admin.v1.PurgeMappingsRequest.filter:type_name -> llmsecret.admin.v1.MappingFilter
+	31, // 16: llmsecret.admin.v1.WatchDetectionsResponse.detection:type_name -> llmsecret.admin.v1.Detection
+	34, // 17: llmsecret.admin.v1.Detection.time:type_name -> google.protobuf.Timestamp
+	7,  // 18: llmsecret.admin.v1.Status.InterceptorsEntry.value:type_name -> llmsecret.admin.v1.InterceptorSettings
+	0,  // 19: llmsecret.admin.v1.AdminService.Health:input_type -> llmsecret.admin.v1.HealthRequest
+	2,  // 20: llmsecret.admin.v1.AdminService.GetStatus:input_type -> llmsecret.admin.v1.GetStatusRequest
+	5,  // 21: llmsecret.admin.v1.AdminService.SetMode:input_type -> llmsecret.admin.v1.SetModeRequest
+	8,  // 22: llmsecret.admin.v1.AdminService.UpdateInterceptor:input_type -> llmsecret.admin.v1.UpdateInterceptorRequest
+	10, // 23: llmsecret.admin.v1.AdminService.GetConfig:input_type -> llmsecret.admin.v1.GetConfigRequest
+	13, // 24: llmsecret.admin.v1.AdminService.ListRules:input_type -> llmsecret.admin.v1.ListRulesRequest
+	15, // 25: llmsecret.admin.v1.AdminService.AddRule:input_type -> llmsecret.admin.v1.AddRuleRequest
+	17, // 26: llmsecret.admin.v1.AdminService.SetRuleDisabled:input_type -> llmsecret.admin.v1.SetRuleDisabledRequest
+	21, // 27: llmsecret.admin.v1.AdminService.ListMappings:input_type -> llmsecret.admin.v1.ListMappingsRequest
+	23, // 28: llmsecret.admin.v1.AdminService.LookupMapping:input_type -> llmsecret.admin.v1.LookupMappingRequest
+	25, // 29: llmsecret.admin.v1.AdminService.DeleteMapping:input_type -> llmsecret.admin.v1.DeleteMappingRequest
+	27, // 30: llmsecret.admin.v1.AdminService.PurgeMappings:input_type -> llmsecret.admin.v1.PurgeMappingsRequest
+	29, // 31: llmsecret.admin.v1.AdminService.WatchDetections:input_type -> llmsecret.admin.v1.WatchDetectionsRequest
+	1,  // 32: llmsecret.admin.v1.AdminService.Health:output_type -> llmsecret.admin.v1.HealthResponse
+	3,  // 33: llmsecret.admin.v1.AdminService.GetStatus:output_type -> llmsecret.admin.v1.GetStatusResponse
+	6,  // 34: llmsecret.admin.v1.AdminService.SetMode:output_type -> llmsecret.admin.v1.SetModeResponse
+	9,  // 35: llmsecret.admin.v1.AdminService.UpdateInterceptor:output_type -> llmsecret.admin.v1.UpdateInterceptorResponse
+	11, // 36: llmsecret.admin.v1.AdminService.GetConfig:output_type -> llmsecret.admin.v1.GetConfigResponse
+	14, // 37: llmsecret.admin.v1.AdminService.ListRules:output_type -> llmsecret.admin.v1.ListRulesResponse
+	16, // 38: llmsecret.admin.v1.AdminService.AddRule:output_type -> llmsecret.admin.v1.AddRuleResponse
+	18, // 39: llmsecret.admin.v1.AdminService.SetRuleDisabled:output_type -> llmsecret.admin.v1.SetRuleDisabledResponse
+	22, // 40: llmsecret.admin.v1.AdminService.ListMappings:output_type -> llmsecret.admin.v1.ListMappingsResponse
+	24, // 41: llmsecret.admin.v1.AdminService.LookupMapping:output_type -> llmsecret.admin.v1.LookupMappingResponse
+	26, // 42: llmsecret.admin.v1.AdminService.DeleteMapping:output_type -> llmsecret.admin.v1.DeleteMappingResponse
+	28, // 43: llmsecret.admin.v1.AdminService.PurgeMappings:output_type -> llmsecret.admin.v1.PurgeMappingsResponse
+	30, // 44: llmsecret.admin.v1.AdminService.WatchDetections:output_type -> llmsecret.admin.v1.WatchDetectionsResponse
+	32, // [32:45] is the sub-list for method output_type
+	19, // [19:32] is the sub-list for method input_type
+	19, // [19:19] is the sub-list for extension type_name
+	19, // [19:19] is the sub-list for extension extendee
+	0,  // [0:19] is the sub-list for field type_name
+}
+
+func init() { file_llmsecret_admin_v1_admin_proto_init() }
+func file_llmsecret_admin_v1_admin_proto_init() {
+	if File_llmsecret_admin_v1_admin_proto != nil {
+		return
+	}
+	file_llmsecret_admin_v1_admin_proto_msgTypes[7].OneofWrappers = []any{}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_llmsecret_admin_v1_admin_proto_rawDesc), len(file_llmsecret_admin_v1_admin_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   33,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_llmsecret_admin_v1_admin_proto_goTypes,
+		DependencyIndexes: file_llmsecret_admin_v1_admin_proto_depIdxs,
+		MessageInfos:      file_llmsecret_admin_v1_admin_proto_msgTypes,
+	}.Build()
+	File_llmsecret_admin_v1_admin_proto = out.File
+	file_llmsecret_admin_v1_admin_proto_goTypes = nil
+	file_llmsecret_admin_v1_admin_proto_depIdxs = nil
+}
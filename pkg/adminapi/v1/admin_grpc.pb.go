@@ -0,0 +1,618 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: llmsecret/admin/v1/admin.proto
+
+// Admin and control-plane API of the LLM Secret Interceptor. It mirrors the
+// HTTP admin API of the management server so fleets of proxies can be
+// managed centrally. Calls need "authorization: Bearer <token>" metadata
+// with the admin token. Secrets are never returned.
+
+package adminv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	AdminService_Health_FullMethodName            = "/llmsecret.admin.v1.AdminService/Health"
+	AdminService_GetStatus_FullMethodName         = "/llmsecret.admin.v1.AdminService/GetStatus"
+	AdminService_SetMode_FullMethodName           = "/llmsecret.admin.v1.AdminService/SetMode"
+	AdminService_UpdateInterceptor_FullMethodName = "/llmsecret.admin.v1.AdminService/UpdateInterceptor"
+	AdminService_GetConfig_FullMethodName         = "/llmsecret.admin.v1.AdminService/GetConfig"
+	AdminService_ListRules_FullMethodName         = "/llmsecret.admin.v1.AdminService/ListRules"
+	AdminService_AddRule_FullMethodName           = "/llmsecret.admin.v1.AdminService/AddRule"
+	AdminService_SetRuleDisabled_FullMethodName   = "/llmsecret.admin.v1.AdminService/SetRuleDisabled"
+	AdminService_ListMappings_FullMethodName      = "/llmsecret.admin.v1.AdminService/ListMappings"
+	AdminService_LookupMapping_FullMethodName     = "/llmsecret.admin.v1.AdminService/LookupMapping"
+	AdminService_DeleteMapping_FullMethodName     = "/llmsecret.admin.v1.AdminService/DeleteMapping"
+	AdminService_PurgeMappings_FullMethodName     = "/llmsecret.admin.v1.AdminService/PurgeMappings"
+	AdminService_WatchDetections_FullMethodName   = "/llmsecret.admin.v1.AdminService/WatchDetections"
+)
+
+// AdminServiceClient is the client API for AdminService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AdminServiceClient interface {
+	// Health reports whether the proxy is serving and in which mode
+	Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error)
+	// GetStatus returns the mode, mapping store and interceptor settings
+	GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*GetStatusResponse, error)
+	// SetMode switches new requests to enforce or shadow mode until the
+	// configuration is reloaded
+	SetMode(ctx context.Context, in *SetModeRequest, opts ...grpc.CallOption) (*SetModeResponse, error)
+	// UpdateInterceptor switches an interceptor on or off and tunes it until
+	// the configuration is reloaded
+	UpdateInterceptor(ctx context.Context, in *UpdateInterceptorRequest, opts ...grpc.CallOption) (*UpdateInterceptorResponse, error)
+	// GetConfig returns the configuration in effect with credentials redacted
+	GetConfig(ctx context.Context, in *GetConfigRequest, opts ...grpc.CallOption) (*GetConfigResponse, error)
+	// ListRules lists the built-in and configured pattern rules
+	ListRules(ctx context.Context, in *ListRulesRequest, opts ...grpc.CallOption) (*ListRulesResponse, error)
+	// AddRule adds a pattern rule to the runtime rules file
+	AddRule(ctx context.Context, in *AddRuleRequest, opts ...grpc.CallOption) (*AddRuleResponse, error)
+	// SetRuleDisabled disables or enables a pattern rule until the
+	// configuration is reloaded
+	SetRuleDisabled(ctx context.Context, in *SetRuleDisabledRequest, opts ...grpc.CallOption) (*SetRuleDisabledResponse, error)
+	// ListMappings lists mappings selected by a filter, newest first
+	ListMappings(ctx context.Context, in *ListMappingsRequest, opts ...grpc.CallOption) (*ListMappingsResponse, error)
+	// LookupMapping describes the mapping of a placeholder
+	LookupMapping(ctx context.Context, in *LookupMappingRequest, opts ...grpc.CallOption) (*LookupMappingResponse, error)
+	// DeleteMapping deletes the mapping of a placeholder
+	DeleteMapping(ctx context.Context, in *DeleteMappingRequest, opts ...grpc.CallOption) (*DeleteMappingResponse, error)
+	// PurgeMappings deletes the mappings selected by a filter
+	PurgeMappings(ctx context.Context, in *PurgeMappingsRequest, opts ...grpc.CallOption) (*PurgeMappingsResponse, error)
+	// WatchDetections streams detections as they happen
+	WatchDetections(ctx context.Context, in *WatchDetectionsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchDetectionsResponse], error)
+}
+
+type adminServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminServiceClient(cc grpc.ClientConnInterface) AdminServiceClient {
+	return &adminServiceClient{cc}
+}
+
+func (c *adminServiceClient) Health(ctx context.Context, in *HealthRequest, opts ...grpc.CallOption) (*HealthResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(HealthResponse)
+	err := c.cc.Invoke(ctx, AdminService_Health_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) GetStatus(ctx context.Context, in *GetStatusRequest, opts ...grpc.CallOption) (*GetStatusResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetStatusResponse)
+	err := c.cc.Invoke(ctx, AdminService_GetStatus_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) SetMode(ctx context.Context, in *SetModeRequest, opts ...grpc.CallOption) (*SetModeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetModeResponse)
+	err := c.cc.Invoke(ctx, AdminService_SetMode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) UpdateInterceptor(ctx context.Context, in *UpdateInterceptorRequest, opts ...grpc.CallOption) (*UpdateInterceptorResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateInterceptorResponse)
+	err := c.cc.Invoke(ctx, AdminService_UpdateInterceptor_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) GetConfig(ctx context.Context, in *GetConfigRequest, opts ...grpc.CallOption) (*GetConfigResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetConfigResponse)
+	err := c.cc.Invoke(ctx, AdminService_GetConfig_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) ListRules(ctx context.Context, in *ListRulesRequest, opts ...grpc.CallOption) (*ListRulesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListRulesResponse)
+	err := c.cc.Invoke(ctx, AdminService_ListRules_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) AddRule(ctx context.Context, in *AddRuleRequest, opts ...grpc.CallOption) (*AddRuleResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddRuleResponse)
+	err := c.cc.Invoke(ctx, AdminService_AddRule_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) SetRuleDisabled(ctx context.Context, in *SetRuleDisabledRequest, opts ...grpc.CallOption) (*SetRuleDisabledResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetRuleDisabledResponse)
+	err := c.cc.Invoke(ctx, AdminService_SetRuleDisabled_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) ListMappings(ctx context.Context, in *ListMappingsRequest, opts ...grpc.CallOption) (*ListMappingsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListMappingsResponse)
+	err := c.cc.Invoke(ctx, AdminService_ListMappings_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) LookupMapping(ctx context.Context, in *LookupMappingRequest, opts ...grpc.CallOption) (*LookupMappingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(LookupMappingResponse)
+	err := c.cc.Invoke(ctx, AdminService_LookupMapping_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) DeleteMapping(ctx context.Context, in *DeleteMappingRequest, opts ...grpc.CallOption) (*DeleteMappingResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteMappingResponse)
+	err := c.cc.Invoke(ctx, AdminService_DeleteMapping_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) PurgeMappings(ctx context.Context, in *PurgeMappingsRequest, opts ...grpc.CallOption) (*PurgeMappingsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PurgeMappingsResponse)
+	err := c.cc.Invoke(ctx, AdminService_PurgeMappings_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminServiceClient) WatchDetections(ctx context.Context, in *WatchDetectionsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchDetectionsResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &AdminService_ServiceDesc.Streams[0], AdminService_WatchDetections_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchDetectionsRequest, WatchDetectionsResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AdminService_WatchDetectionsClient = grpc.ServerStreamingClient[WatchDetectionsResponse]
+
+// AdminServiceServer is the server API for AdminService service.
+// All implementations must embed UnimplementedAdminServiceServer
+// for forward compatibility.
+type AdminServiceServer interface {
+	// Health reports whether the proxy is serving and in which mode
+	Health(context.Context, *HealthRequest) (*HealthResponse, error)
+	// GetStatus returns the mode, mapping store and interceptor settings
+	GetStatus(context.Context, *GetStatusRequest) (*GetStatusResponse, error)
+	// SetMode switches new requests to enforce or shadow mode until the
+	// configuration is reloaded
+	SetMode(context.Context, *SetModeRequest) (*SetModeResponse, error)
+	// UpdateInterceptor switches an interceptor on or off and tunes it until
+	// the configuration is reloaded
+	UpdateInterceptor(context.Context, *UpdateInterceptorRequest) (*UpdateInterceptorResponse, error)
+	// GetConfig returns the configuration in effect with credentials redacted
+	GetConfig(context.Context, *GetConfigRequest) (*GetConfigResponse, error)
+	// ListRules lists the built-in and configured pattern rules
+	ListRules(context.Context, *ListRulesRequest) (*ListRulesResponse, error)
+	// AddRule adds a pattern rule to the runtime rules file
+	AddRule(context.Context, *AddRuleRequest) (*AddRuleResponse, error)
+	// SetRuleDisabled disables or enables a pattern rule until the
+	// configuration is reloaded
+	SetRuleDisabled(context.Context, *SetRuleDisabledRequest) (*SetRuleDisabledResponse, error)
+	// ListMappings lists mappings selected by a filter, newest first
+	ListMappings(context.Context, *ListMappingsRequest) (*ListMappingsResponse, error)
+	// LookupMapping describes the mapping of a placeholder
+	LookupMapping(context.Context, *LookupMappingRequest) (*LookupMappingResponse, error)
+	// DeleteMapping deletes the mapping of a placeholder
+	DeleteMapping(context.Context, *DeleteMappingRequest) (*DeleteMappingResponse, error)
+	// PurgeMappings deletes the mappings selected by a filter
+	PurgeMappings(context.Context, *PurgeMappingsRequest) (*PurgeMappingsResponse, error)
+	// WatchDetections streams detections as they happen
+	WatchDetections(*WatchDetectionsRequest, grpc.ServerStreamingServer[WatchDetectionsResponse]) error
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+// UnimplementedAdminServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedAdminServiceServer struct{}
+
+func (UnimplementedAdminServiceServer) Health(context.Context, *HealthRequest) (*HealthResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Health not implemented")
+}
+func (UnimplementedAdminServiceServer) GetStatus(context.Context, *GetStatusRequest) (*GetStatusResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
+}
+func (UnimplementedAdminServiceServer) SetMode(context.Context, *SetModeRequest) (*SetModeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetMode not implemented")
+}
+func (UnimplementedAdminServiceServer) UpdateInterceptor(context.Context, *UpdateInterceptorRequest) (*UpdateInterceptorResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateInterceptor not implemented")
+}
+func (UnimplementedAdminServiceServer) GetConfig(context.Context, *GetConfigRequest) (*GetConfigResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetConfig not implemented")
+}
+func (UnimplementedAdminServiceServer) ListRules(context.Context, *ListRulesRequest) (*ListRulesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListRules not implemented")
+}
+func (UnimplementedAdminServiceServer) AddRule(context.Context, *AddRuleRequest) (*AddRuleResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddRule not implemented")
+}
+func (UnimplementedAdminServiceServer) SetRuleDisabled(context.Context, *SetRuleDisabledRequest) (*SetRuleDisabledResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetRuleDisabled not implemented")
+}
+func (UnimplementedAdminServiceServer) ListMappings(context.Context, *ListMappingsRequest) (*ListMappingsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMappings not implemented")
+}
+func (UnimplementedAdminServiceServer) LookupMapping(context.Context, *LookupMappingRequest) (*LookupMappingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LookupMapping not implemented")
+}
+func (UnimplementedAdminServiceServer) DeleteMapping(context.Context, *DeleteMappingRequest) (*DeleteMappingResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteMapping not implemented")
+}
+func (UnimplementedAdminServiceServer) PurgeMappings(context.Context, *PurgeMappingsRequest) (*PurgeMappingsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PurgeMappings not implemented")
+}
+func (UnimplementedAdminServiceServer) WatchDetections(*WatchDetectionsRequest, grpc.ServerStreamingServer[WatchDetectionsResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method WatchDetections not implemented")
+}
+func (UnimplementedAdminServiceServer) mustEmbedUnimplementedAdminServiceServer() {}
+func (UnimplementedAdminServiceServer) testEmbeddedByValue()                      {}
+
+// UnsafeAdminServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdminServiceServer will
+// result in compilation errors.
+type UnsafeAdminServiceServer interface {
+	mustEmbedUnimplementedAdminServiceServer()
+}
+
+func RegisterAdminServiceServer(s grpc.ServiceRegistrar, srv AdminServiceServer) {
+	// If the following call pancis, it indicates UnimplementedAdminServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&AdminService_ServiceDesc, srv)
+}
+
+func _AdminService_Health_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HealthRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).Health(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_Health_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).Health(ctx, req.(*HealthRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetStatusRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_GetStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).GetStatus(ctx, req.(*GetStatusRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_SetMode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetModeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).SetMode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_SetMode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).SetMode(ctx, req.(*SetModeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_UpdateInterceptor_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateInterceptorRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).UpdateInterceptor(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_UpdateInterceptor_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).UpdateInterceptor(ctx, req.(*UpdateInterceptorRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_GetConfig_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetConfigRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).GetConfig(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_GetConfig_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).GetConfig(ctx, req.(*GetConfigRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_ListRules_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListRulesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ListRules(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_ListRules_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ListRules(ctx, req.(*ListRulesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_AddRule_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddRuleRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).AddRule(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_AddRule_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).AddRule(ctx, req.(*AddRuleRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_SetRuleDisabled_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetRuleDisabledRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).SetRuleDisabled(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_SetRuleDisabled_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).SetRuleDisabled(ctx, req.(*SetRuleDisabledRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_ListMappings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListMappingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).ListMappings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_ListMappings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).ListMappings(ctx, req.(*ListMappingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_LookupMapping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LookupMappingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).LookupMapping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_LookupMapping_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).LookupMapping(ctx, req.(*LookupMappingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_DeleteMapping_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteMappingRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).DeleteMapping(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_DeleteMapping_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).DeleteMapping(ctx, req.(*DeleteMappingRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_PurgeMappings_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PurgeMappingsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServiceServer).PurgeMappings(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: AdminService_PurgeMappings_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServiceServer).PurgeMappings(ctx, req.(*PurgeMappingsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _AdminService_WatchDetections_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchDetectionsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AdminServiceServer).WatchDetections(m, &grpc.GenericServerStream[WatchDetectionsRequest, WatchDetectionsResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type AdminService_WatchDetectionsServer = grpc.ServerStreamingServer[WatchDetectionsResponse]
+
+// AdminService_ServiceDesc is the grpc.ServiceDesc for AdminService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var AdminService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "llmsecret.admin.v1.AdminService",
+	HandlerType: (*AdminServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Health",
+			Handler:    _AdminService_Health_Handler,
+		},
+		{
+			MethodName: "GetStatus",
+			Handler:    _AdminService_GetStatus_Handler,
+		},
+		{
+			MethodName: "SetMode",
+			Handler:    _AdminService_SetMode_Handler,
+		},
+		{
+			MethodName: "UpdateInterceptor",
+			Handler:    _AdminService_UpdateInterceptor_Handler,
+		},
+		{
+			MethodName: "GetConfig",
+			Handler:    _AdminService_GetConfig_Handler,
+		},
+		{
+			MethodName: "ListRules",
+			Handler:    _AdminService_ListRules_Handler,
+		},
+		{
+			MethodName: "AddRule",
+			Handler:    _AdminService_AddRule_Handler,
+		},
+		{
+			MethodName: "SetRuleDisabled",
+			Handler:    _AdminService_SetRuleDisabled_Handler,
+		},
+		{
+			MethodName: "ListMappings",
+			Handler:    _AdminService_ListMappings_Handler,
+		},
+		{
+			MethodName: "LookupMapping",
+			Handler:    _AdminService_LookupMapping_Handler,
+		},
+		{
+			MethodName: "DeleteMapping",
+			Handler:    _AdminService_DeleteMapping_Handler,
+		},
+		{
+			MethodName: "PurgeMappings",
+			Handler:    _AdminService_PurgeMappings_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchDetections",
+			Handler:       _AdminService_WatchDetections_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "llmsecret/admin/v1/admin.proto",
+}
@@ -0,0 +1,83 @@
+package placeholder
+
+import (
+	"regexp"
+	"unicode/utf8"
+)
+
+// MaxFuzzyTolerance is the largest configurable fuzzy matching tolerance
+const MaxFuzzyTolerance = 4
+
+// Match is a placeholder found in a text
+type Match struct {
+	// Start and End are the byte offsets of the match in the text
+	Start int
+	End   int
+	// Placeholder is the placeholder as generated, even if the match lost
+	// characters of the prefix or suffix
+	Placeholder string
+}
+
+// WithFuzzyMatching returns a copy of the generator whose FindMatches also
+// finds placeholders that lost up to tolerance characters of their prefix
+// or suffix, e.g. __SECRET_ab12cd34_ when models rewrite markdown around
+// them. The hash must be intact; at least one prefix or suffix character
+// must remain so plain hex strings are not taken for placeholders.
+func (g *Generator) WithFuzzyMatching(tolerance int) *Generator {
+	fuzzy := *g
+	fuzzy.tolerance = max(0, min(tolerance, MaxFuzzyTolerance))
+	fuzzy.compile()
+	return &fuzzy
+}
+
+// FindMatches finds all placeholders in a text, including near misses
+// within the fuzzy matching tolerance. Exact matches take precedence.
+func (g *Generator) FindMatches(text string) []Match {
+	exact := g.pattern.FindAllStringIndex(text, -1)
+	matches := make([]Match, 0, len(exact))
+
+	last := 0
+	for _, idx := range exact {
+		matches = append(matches, g.fuzzyMatches(text, last, idx[0])...)
+		matches = append(matches, Match{Start: idx[0], End: idx[1], Placeholder: text[idx[0]:idx[1]]})
+		last = idx[1]
+	}
+	return append(matches, g.fuzzyMatches(text, last, len(text))...)
+}
+
+// fuzzyMatches finds near-miss placeholders in text[start:end]
+func (g *Generator) fuzzyMatches(text string, start, end int) []Match {
+	if g.fuzzy == nil || start >= end {
+		return nil
+	}
+
+	var matches []Match
+	for _, m := range g.fuzzy.FindAllStringSubmatchIndex(text[start:end], -1) {
+		prefix := text[start+m[2] : start+m[3]]
+		core := text[start+m[4] : start+m[5]]
+		suffix := text[start+m[6] : start+m[7]]
+		if prefix == "" && suffix == "" {
+			continue
+		}
+		dropped := utf8.RuneCountInString(g.prefix) - utf8.RuneCountInString(prefix) +
+			utf8.RuneCountInString(g.suffix) - utf8.RuneCountInString(suffix)
+		if dropped > g.tolerance {
+			continue
+		}
+		matches = append(matches, Match{
+			Start:       start + m[0],
+			End:         start + m[1],
+			Placeholder: g.prefix + core + g.suffix,
+		})
+	}
+	return matches
+}
+
+// optionalChars returns a pattern matching s with any characters dropped
+func optionalChars(s string) string {
+	pattern := ""
+	for _, r := range s {
+		pattern += regexp.QuoteMeta(string(r)) + "?"
+	}
+	return pattern
+}
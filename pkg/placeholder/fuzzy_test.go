@@ -0,0 +1,60 @@
+package placeholder
+
+import "testing"
+
+func TestGenerator_FuzzyMatching(t *testing.T) {
+	g := NewGenerator("__SECRET_", "__").WithFuzzyMatching(2)
+	ph := g.Generate("mysecretpassword")
+	hash := ph[len("__SECRET_") : len(ph)-len("__")]
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"exact", "use " + ph + " now", "use SECRET now"},
+		{"dropped suffix underscore", "use `" + "__SECRET_" + hash + "_` now", "use `SECRET` now"},
+		{"dropped prefix underscores", "use _SECRET" + hash + "__", "use SECRET"},
+		{"beyond tolerance", "use SECRET" + hash + "_", "use SECRET" + hash + "_"},
+		{"bare hash", "commit " + hash + " ok", "commit " + hash + " ok"},
+		{"unknown hash", "use __SECRET_0000ffff_", "use __SECRET_0000ffff_"},
+		{"exact and fuzzy", ph + " and __SECRET_" + hash + "_", "SECRET and SECRET"},
+	}
+
+	lookup := func(placeholder string) (string, bool) {
+		if placeholder == ph {
+			return "SECRET", true
+		}
+		return "", false
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := g.RestorePlaceholders(tt.text, lookup); got != tt.want {
+				t.Errorf("RestorePlaceholders() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerator_FindMatches(t *testing.T) {
+	exact := NewGenerator("__SECRET_", "__")
+	ph := exact.Generate("mysecretpassword")
+	text := "a " + ph + " b " + ph[:len(ph)-1] + " c"
+
+	if got := exact.FindMatches(text); len(got) != 1 || got[0].Placeholder != ph {
+		t.Errorf("FindMatches() without fuzzy matching = %+v", got)
+	}
+
+	got := exact.WithFuzzyMatching(1).FindMatches(text)
+	if len(got) != 2 {
+		t.Fatalf("FindMatches() = %+v, want 2 matches", got)
+	}
+	for _, m := range got {
+		if m.Placeholder != ph {
+			t.Errorf("Placeholder = %q, want %q", m.Placeholder, ph)
+		}
+	}
+	if second := text[got[1].Start:got[1].End]; second != ph[:len(ph)-1] {
+		t.Errorf("second match = %q, want %q", second, ph[:len(ph)-1])
+	}
+}
@@ -1,49 +1,380 @@
 // Package placeholder provides placeholder generation and restoration for secret masking.
+//
+// The package is a stable public API: tools embedding it (CI scanners, log
+// scrubbers) produce placeholders interoperable with the proxy as long as
+// they use the same Options, including the hash function.
 package placeholder
 
 import (
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
+	"math/big"
 	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
-// Generator handles placeholder generation and recognition
+const (
+	// DefaultHashLength is the number of hex characters of the secret's hash
+	// in a placeholder
+	DefaultHashLength = 8
+	// MinHashLength is the shortest configurable hash length
+	MinHashLength = 8
+	// MaxHashLength is the full length of a SHA-256 hash in hex
+	MaxHashLength = 64
+	// MaxTagLength is the longest secret type tag in a placeholder
+	MaxTagLength = 16
+
+	// HexAlphabet encodes hashes of standard placeholders
+	HexAlphabet = "0123456789abcdef"
+	// CompactAlphabet packs more hash bits into each placeholder character
+	CompactAlphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+	// CompactPrefix starts placeholders in the compact format
+	CompactPrefix = "⟦S:"
+	// CompactSuffix ends placeholders in the compact format
+	CompactSuffix = "⟧"
+	// SequentialPrefix starts numbered placeholders
+	SequentialPrefix = "[SECRET_"
+	// SequentialSuffix ends numbered placeholders
+	SequentialSuffix = "]"
+
+	// maxSequenceDigits is the longest number in a numbered placeholder
+	maxSequenceDigits = 10
+
+	// minHashBits is the least number of hash bits a placeholder carries
+	minHashBits = 32
+)
+
+// Generator handles placeholder generation and recognition. Placeholders
+// carry hashLen characters of the secret's hash, hex encoded unless another
+// alphabet is set; longer placeholders up to the full hash, used to resolve
+// collisions, are recognized as well.
 type Generator struct {
 	prefix    string
 	suffix    string
+	alphabet  string
 	hashLen   int
+	fullLen   int
 	maxLength int
 	pattern   *regexp.Regexp
+	random    bool
+	typeTags  bool
+	sequence  *sequence
+	hash      HashFunc
+	legacy    []Scheme
+	tolerance int
+	fuzzy     *regexp.Regexp
 }
 
-// NewGenerator creates a new placeholder generator
+// sequence hands out placeholder numbers per scope. Counters unused for
+// ttl are dropped, as scopes such as per-connection namespaces come and go.
+type sequence struct {
+	mu    sync.Mutex
+	next  map[string]*counter
+	ttl   time.Duration
+	swept time.Time
+}
+
+// counter is the last number handed out in a scope
+type counter struct {
+	n    int
+	used time.Time
+}
+
+// DefaultSequenceTTL is how long unused placeholder counters are kept,
+// matching the default mapping TTL
+const DefaultSequenceTTL = 24 * time.Hour
+
+// NewGenerator creates a new placeholder generator with the default hash length
 func NewGenerator(prefix, suffix string) *Generator {
-	hashLen := 8 // Use first 8 characters of hash
-	maxLength := len(prefix) + hashLen + len(suffix)
+	return NewGeneratorWithHashLength(prefix, suffix, DefaultHashLength)
+}
+
+// NewGeneratorWithHashLength creates a placeholder generator using hashLen
+// hex characters of the hash, clamped to [MinHashLength, MaxHashLength]
+func NewGeneratorWithHashLength(prefix, suffix string, hashLen int) *Generator {
+	g := &Generator{
+		prefix:   prefix,
+		suffix:   suffix,
+		alphabet: HexAlphabet,
+		hashLen:  max(MinHashLength, min(hashLen, MaxHashLength)),
+		fullLen:  MaxHashLength,
+	}
+	g.compile()
+	return g
+}
+
+// WithAlphabet returns a copy of the generator encoding hashes with the
+// given alphabet of distinct ASCII letters and digits. Larger alphabets
+// carry more bits per character, so hashLen, counted in characters of the
+// alphabet, can be shorter; it is clamped to the range of HashLengthRange.
+func (g *Generator) WithAlphabet(alphabet string, hashLen int) (*Generator, error) {
+	if len(alphabet) < 2 {
+		return nil, fmt.Errorf("placeholder alphabet needs at least 2 characters")
+	}
+	seen := make(map[rune]bool, len(alphabet))
+	for _, r := range alphabet {
+		if !(r >= '0' && r <= '9') && !(r >= 'A' && r <= 'Z') && !(r >= 'a' && r <= 'z') {
+			return nil, fmt.Errorf("placeholder alphabet must only contain ASCII letters and digits, got %q", r)
+		}
+		if seen[r] {
+			return nil, fmt.Errorf("placeholder alphabet contains %q twice", r)
+		}
+		seen[r] = true
+	}
+
+	encoded := *g
+	encoded.alphabet = alphabet
+	encoded.fullLen = digitsFor(len(alphabet), sha256.Size*8)
+	minLen, maxLen := encoded.HashLengthRange()
+	encoded.hashLen = max(minLen, min(hashLen, maxLen))
+	encoded.compile()
+	return &encoded, nil
+}
 
-	// Build regex pattern for matching placeholders
-	escapedPrefix := regexp.QuoteMeta(prefix)
-	escapedSuffix := regexp.QuoteMeta(suffix)
-	pattern := regexp.MustCompile(escapedPrefix + `[a-f0-9]{` + fmt.Sprintf("%d", hashLen) + `}` + escapedSuffix)
+// digitsFor returns the number of digits in base needed to encode bits
+func digitsFor(base, bits int) int {
+	limit := new(big.Int).Lsh(big.NewInt(1), uint(bits))
+	value := big.NewInt(1)
+	b := big.NewInt(int64(base))
+	digits := 0
+	for value.Cmp(limit) < 0 {
+		value.Mul(value, b)
+		digits++
+	}
+	return digits
+}
+
+// WithTypeTags returns a copy of the generator whose placeholders encode
+// the secret type, e.g. __SECRET_APIKEY_ab12cd34__, giving the model a
+// semantic hint. Untagged placeholders are still recognized.
+func (g *Generator) WithTypeTags() *Generator {
+	tagged := *g
+	tagged.typeTags = true
+	tagged.compile()
+	return &tagged
+}
+
+// compile builds the pattern recognizing the generator's placeholders
+func (g *Generator) compile() {
+	g.maxLength = len(g.prefix) + g.fullLen + len(g.suffix)
+	tag := ""
+	if g.typeTags {
+		g.maxLength += MaxTagLength + 1
+		tag = fmt.Sprintf(`(?:[A-Z0-9]{1,%d}_)?`, MaxTagLength)
+	}
+
+	body := fmt.Sprintf(`[%s]{%d,%d}`, g.alphabet, g.hashLen, g.fullLen)
+	if g.sequence != nil {
+		g.maxLength += maxSequenceDigits - g.fullLen
+		body = fmt.Sprintf(`[1-9][0-9]{0,%d}`, maxSequenceDigits-1)
+	}
+
+	pattern := regexp.QuoteMeta(g.prefix) + tag + body + regexp.QuoteMeta(g.suffix)
+	g.fuzzy = nil
+	if g.tolerance > 0 {
+		g.fuzzy = regexp.MustCompile("(" + optionalChars(g.prefix) + ")(" + tag + body + ")(" + optionalChars(g.suffix) + ")")
+	}
+	if len(g.legacy) > 0 {
+		pattern = "(?:" + pattern + ")"
+		for _, legacy := range g.legacy {
+			pattern += "|(?:" + legacy.Pattern().String() + ")"
+			g.maxLength = max(g.maxLength, legacy.MaxLength())
+		}
+	}
+	g.pattern = regexp.MustCompile(pattern)
+}
+
+// WithLegacy returns a copy of the generator that also recognizes the
+// placeholders of legacy schemes, so placeholders issued under old
+// settings are still restored during a configuration migration. New
+// placeholders are always generated by g.
+func (g *Generator) WithLegacy(legacy ...Scheme) *Generator {
+	combined := *g
+	combined.legacy = append(append([]Scheme(nil), g.legacy...), legacy...)
+	combined.compile()
+	return &combined
+}
+
+// NewRandomGenerator creates a placeholder generator whose placeholders are
+// random instead of derived from the secret, so observers cannot confirm
+// whether a known secret was sent. Callers must reuse the placeholder of an
+// already stored secret to keep placeholders stable.
+func NewRandomGenerator(prefix, suffix string, hashLen int) *Generator {
+	g := NewGeneratorWithHashLength(prefix, suffix, hashLen)
+	g.random = true
+	return g
+}
+
+// NewKeyedGenerator creates a placeholder generator deriving hashes with
+// HMAC-SHA-256 under a deployment key instead of bare SHA-256, so whoever
+// sees placeholders cannot brute-force low-entropy secrets offline. All
+// instances sharing mappings must use the same key.
+func NewKeyedGenerator(prefix, suffix string, hashLen int, key []byte) *Generator {
+	return NewGeneratorWithHashLength(prefix, suffix, hashLen).WithHash(HMACSHA256(key))
+}
+
+// NewSequentialGenerator creates a generator numbering placeholders per
+// scope, e.g. [SECRET_1], [SECRET_2], which models handle more gracefully
+// in explanations. Numbers are handed out by NextSequence; Generate still
+// returns a hash-derived placeholder that callers must replace.
+func NewSequentialGenerator(prefix, suffix string) *Generator {
+	g := NewGenerator(prefix, suffix)
+	g.sequence = &sequence{next: make(map[string]*counter), ttl: DefaultSequenceTTL}
+	g.compile()
+	return g
+}
 
-	return &Generator{
-		prefix:    prefix,
-		suffix:    suffix,
-		hashLen:   hashLen,
-		maxLength: maxLength,
-		pattern:   pattern,
+// WithSequenceTTL returns a copy of a sequential generator that drops the
+// counter of a scope once unused for ttl, which should match the TTL of the
+// numbered mappings (0 = never). Other generators are returned unchanged.
+func (g *Generator) WithSequenceTTL(ttl time.Duration) *Generator {
+	if g.sequence == nil {
+		return g
 	}
+	combined := *g
+	combined.sequence = &sequence{next: make(map[string]*counter), ttl: ttl}
+	return &combined
+}
+
+// Sequential reports whether the generator numbers placeholders
+func (g *Generator) Sequential() bool {
+	return g.sequence != nil
+}
+
+// NextSequence returns the next unused placeholder number of scope,
+// starting at 1. It returns 0 if the generator is not sequential.
+func (g *Generator) NextSequence(scope string) int {
+	if g.sequence == nil {
+		return 0
+	}
+	return g.sequence.advance(scope, time.Now())
+}
+
+// advance returns the next number of scope, dropping counters unused for
+// the sequence TTL at most once per TTL
+func (s *sequence) advance(scope string, now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ttl > 0 && now.Sub(s.swept) >= s.ttl {
+		for name, c := range s.next {
+			if now.Sub(c.used) >= s.ttl {
+				delete(s.next, name)
+			}
+		}
+		s.swept = now
+	}
+	c, ok := s.next[scope]
+	if !ok {
+		c = &counter{}
+		s.next[scope] = c
+	}
+	c.n++
+	c.used = now
+	return c.n
+}
+
+// GenerateSequence creates the numbered placeholder n, tagged with
+// secretType if the generator uses type tags
+func (g *Generator) GenerateSequence(n int, secretType string) string {
+	number := strconv.Itoa(n)
+	if tag := g.tag(secretType); tag != "" {
+		number = tag + "_" + number
+	}
+	return g.prefix + number + g.suffix
+}
+
+// Deterministic reports whether a secret always yields the same placeholder
+func (g *Generator) Deterministic() bool {
+	return !g.random && g.sequence == nil
 }
 
 // Generate creates a placeholder for a given secret
 func (g *Generator) Generate(secret string) string {
-	hash := sha256.Sum256([]byte(secret))
-	hashStr := hex.EncodeToString(hash[:])[:g.hashLen]
+	return g.GenerateLength(secret, g.hashLen)
+}
+
+// GenerateLength creates a placeholder with hashLen hex characters of the
+// hash, clamped to the generator's hash length and MaxHashLength. A longer
+// placeholder resolves a collision with another secret's placeholder.
+func (g *Generator) GenerateLength(secret string, hashLen int) string {
+	return g.GenerateTyped(secret, "", hashLen)
+}
+
+// GenerateTyped creates a placeholder like GenerateLength that is tagged
+// with secretType if the generator uses type tags
+func (g *Generator) GenerateTyped(secret, secretType string, hashLen int) string {
+	hashLen = max(g.hashLen, min(hashLen, g.fullLen))
+	var hash [sha256.Size]byte
+	if g.hash != nil {
+		copy(hash[:], g.hash(secret))
+	} else {
+		hash = sha256.Sum256([]byte(secret))
+	}
+	if g.random {
+		// crypto/rand.Read never returns an error
+		_, _ = rand.Read(hash[:])
+	}
+	hashStr := g.encode(hash[:])[:hashLen]
+	if tag := g.tag(secretType); tag != "" {
+		hashStr = tag + "_" + hashStr
+	}
 	return g.prefix + hashStr + g.suffix
 }
 
+// encode encodes hash with the generator's alphabet, most significant digit
+// first, padded to the full hash length
+func (g *Generator) encode(hash []byte) string {
+	if g.alphabet == HexAlphabet {
+		return hex.EncodeToString(hash)
+	}
+
+	n := new(big.Int).SetBytes(hash)
+	base := big.NewInt(int64(len(g.alphabet)))
+	digit := new(big.Int)
+	digits := make([]byte, g.fullLen)
+	for i := len(digits) - 1; i >= 0; i-- {
+		n.DivMod(n, base, digit)
+		digits[i] = g.alphabet[digit.Int64()]
+	}
+	return string(digits)
+}
+
+// tag normalizes secretType to a placeholder tag: "api_key" becomes APIKEY
+func (g *Generator) tag(secretType string) string {
+	if !g.typeTags {
+		return ""
+	}
+	var b strings.Builder
+	for _, r := range strings.ToUpper(secretType) {
+		if b.Len() == MaxTagLength {
+			break
+		}
+		if (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') {
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// HashLength returns the number of hash characters in generated placeholders
+func (g *Generator) HashLength() int {
+	return g.hashLen
+}
+
+// HashLengthRange returns the shortest and longest hash length of the
+// generator's alphabet: at least 32 bits and at most the full hash
+func (g *Generator) HashLengthRange() (int, int) {
+	if g.alphabet == HexAlphabet {
+		return MinHashLength, MaxHashLength
+	}
+	return digitsFor(len(g.alphabet), minHashBits), g.fullLen
+}
+
 // MaxLength returns the maximum length of a placeholder
 func (g *Generator) MaxLength() int {
 	return g.maxLength
@@ -54,12 +385,12 @@ func (g *Generator) IsPlaceholder(s string) bool {
 	return g.pattern.MatchString(s)
 }
 
-// FindAll finds all placeholders in a text
+// FindAll finds all exactly matching placeholders in a text
 func (g *Generator) FindAll(text string) []string {
 	return g.pattern.FindAllString(text, -1)
 }
 
-// FindAllIndex finds all placeholders and their positions
+// FindAllIndex finds all exactly matching placeholders and their positions
 func (g *Generator) FindAllIndex(text string) [][]int {
 	return g.pattern.FindAllStringIndex(text, -1)
 }
@@ -81,10 +412,26 @@ func (g *Generator) ReplaceSecrets(text string, secrets []string) (string, map[s
 
 // RestorePlaceholders replaces all placeholders with their original secrets
 func (g *Generator) RestorePlaceholders(text string, lookup func(placeholder string) (string, bool)) string {
-	return g.pattern.ReplaceAllStringFunc(text, func(placeholder string) string {
-		if secret, ok := lookup(placeholder); ok {
-			return secret
+	if g.fuzzy == nil {
+		return g.pattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+			if secret, ok := lookup(placeholder); ok {
+				return secret
+			}
+			return placeholder // Keep placeholder if not found
+		})
+	}
+
+	var b strings.Builder
+	last := 0
+	for _, m := range g.FindMatches(text) {
+		b.WriteString(text[last:m.Start])
+		if secret, ok := lookup(m.Placeholder); ok {
+			b.WriteString(secret)
+		} else {
+			b.WriteString(text[m.Start:m.End]) // Keep placeholder if not found
 		}
-		return placeholder // Keep placeholder if not found
-	})
+		last = m.End
+	}
+	b.WriteString(text[last:])
+	return b.String()
 }
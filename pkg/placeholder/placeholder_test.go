@@ -1,7 +1,9 @@
 package placeholder
 
 import (
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestGenerator_Generate(t *testing.T) {
@@ -181,14 +183,46 @@ func TestGenerator_MaxLength(t *testing.T) {
 	g := NewGenerator("__SECRET_", "__")
 
 	maxLen := g.MaxLength()
-	// __SECRET_ (9) + 8 hex chars + __ (2) = 19
-	expected := 19
+	// __SECRET_ (9) + up to 64 hex chars of lengthened placeholders + __ (2) = 75
+	expected := 75
 
 	if maxLen != expected {
 		t.Errorf("MaxLength() = %d, want %d", maxLen, expected)
 	}
 }
 
+func TestGenerator_HashLength(t *testing.T) {
+	g := NewGeneratorWithHashLength("__SECRET_", "__", 16)
+	secret := "my-secret-value"
+
+	ph := g.Generate(secret)
+	if len(ph) != len("__SECRET_")+16+len("__") || g.HashLength() != 16 {
+		t.Errorf("Generate() = %q, want 16 hash characters", ph)
+	}
+	if !g.IsPlaceholder(ph) {
+		t.Errorf("IsPlaceholder(%q) = false", ph)
+	}
+
+	// Lengthened placeholders extend the same hash and are recognized
+	long := g.GenerateLength(secret, 20)
+	if long[:len(ph)-2] != ph[:len(ph)-2] || len(long) != len(ph)+4 || !g.IsPlaceholder(long) {
+		t.Errorf("GenerateLength() = %q, want %q extended by 4 characters", long, ph)
+	}
+	if got := g.GenerateLength(secret, 1000); len(got) != len("__SECRET_")+MaxHashLength+len("__") {
+		t.Errorf("GenerateLength() beyond the hash = %q", got)
+	}
+
+	// Shorter placeholders than configured are not recognized
+	if g.IsPlaceholder(NewGenerator("__SECRET_", "__").Generate(secret)) {
+		t.Error("8-character placeholder recognized by a 16-character generator")
+	}
+
+	// Lengths are clamped
+	if NewGeneratorWithHashLength("__SECRET_", "__", 2).HashLength() != MinHashLength {
+		t.Error("hash length below the minimum not clamped")
+	}
+}
+
 // Helper functions
 
 func contains(s, substr string) bool {
@@ -212,3 +246,228 @@ func containsAny(s string, substrs []string) bool {
 	}
 	return false
 }
+
+func TestRandomGenerator(t *testing.T) {
+	g := NewRandomGenerator("__SECRET_", "__", DefaultHashLength)
+	if g.Deterministic() {
+		t.Error("Deterministic() = true for random generator")
+	}
+	if !NewGenerator("__SECRET_", "__").Deterministic() {
+		t.Error("Deterministic() = false for default generator")
+	}
+
+	secret := "mysecretpassword"
+	placeholder1 := g.Generate(secret)
+	placeholder2 := g.Generate(secret)
+	if placeholder1 == placeholder2 {
+		t.Errorf("random placeholders are equal: %q", placeholder1)
+	}
+	if placeholder1 == NewGenerator("__SECRET_", "__").Generate(secret) {
+		t.Error("random placeholder equals the hash-derived placeholder")
+	}
+	for _, ph := range []string{placeholder1, placeholder2, g.GenerateLength(secret, 16)} {
+		if !g.IsPlaceholder(ph) {
+			t.Errorf("IsPlaceholder(%q) = false, want true", ph)
+		}
+	}
+	if got := len(g.GenerateLength(secret, 16)); got != 9+16+2 {
+		t.Errorf("GenerateLength(16) length = %d, want %d", got, 9+16+2)
+	}
+}
+
+func TestGenerator_TypeTags(t *testing.T) {
+	plain := NewGenerator("__SECRET_", "__")
+	g := plain.WithTypeTags()
+
+	secret := "sk-a8Kd9fJ2mN4pQ7xR3yZ5"
+	untagged := plain.Generate(secret)
+	tagged := g.GenerateTyped(secret, "api_key", g.HashLength())
+	if want := "__SECRET_APIKEY_" + untagged[len("__SECRET_"):]; tagged != want {
+		t.Errorf("GenerateTyped() = %q, want %q", tagged, want)
+	}
+	if got := plain.GenerateTyped(secret, "api_key", plain.HashLength()); got != untagged {
+		t.Errorf("GenerateTyped() without type tags = %q, want %q", got, untagged)
+	}
+	if got := g.GenerateTyped(secret, "", g.HashLength()); got != untagged {
+		t.Errorf("GenerateTyped() without type = %q, want %q", got, untagged)
+	}
+
+	for _, ph := range []string{tagged, untagged, g.GenerateTyped(secret, "a-very-long-connection_string", 16)} {
+		if !g.IsPlaceholder(ph) {
+			t.Errorf("IsPlaceholder(%q) = false, want true", ph)
+		}
+		if len(ph) > g.MaxLength() {
+			t.Errorf("len(%q) = %d exceeds MaxLength() %d", ph, len(ph), g.MaxLength())
+		}
+	}
+	if plain.IsPlaceholder(tagged) {
+		t.Errorf("generator without type tags recognizes %q", tagged)
+	}
+
+	text := "Use " + tagged + " as your key"
+	restored := g.RestorePlaceholders(text, func(ph string) (string, bool) {
+		return secret, ph == tagged
+	})
+	if restored != "Use "+secret+" as your key" {
+		t.Errorf("RestorePlaceholders() = %q", restored)
+	}
+}
+
+func TestGenerator_WithAlphabet(t *testing.T) {
+	hexGen := NewGenerator(CompactPrefix, CompactSuffix)
+	g, err := hexGen.WithAlphabet(CompactAlphabet, 6)
+	if err != nil {
+		t.Fatalf("WithAlphabet() error: %v", err)
+	}
+	if minLen, maxLen := g.HashLengthRange(); minLen != 6 || maxLen != 43 {
+		t.Errorf("HashLengthRange() = %d, %d, want 6, 43", minLen, maxLen)
+	}
+
+	secret := "sk-a8Kd9fJ2mN4pQ7xR3yZ5"
+	ph := g.Generate(secret)
+	if !strings.HasPrefix(ph, CompactPrefix) || len([]rune(ph)) != 3+6+1 {
+		t.Errorf("Generate() = %q, want 6 base62 characters", ph)
+	}
+	if ph != g.Generate(secret) {
+		t.Error("compact placeholders are not deterministic")
+	}
+	full := g.GenerateLength(secret, 100)
+	if !strings.HasPrefix(full, ph[:len(ph)-len(CompactSuffix)]) {
+		t.Errorf("lengthened placeholder %q does not extend %q", full, ph)
+	}
+	for _, p := range []string{ph, full} {
+		if !g.IsPlaceholder(p) {
+			t.Errorf("IsPlaceholder(%q) = false, want true", p)
+		}
+		if len(p) > g.MaxLength() {
+			t.Errorf("len(%q) = %d exceeds MaxLength() %d", p, len(p), g.MaxLength())
+		}
+	}
+
+	// Hex alphabet keeps the standard encoding
+	same, err := hexGen.WithAlphabet(HexAlphabet, 8)
+	if err != nil {
+		t.Fatalf("WithAlphabet() error: %v", err)
+	}
+	if same.Generate(secret) != hexGen.Generate(secret) {
+		t.Error("hex alphabet changed the encoding")
+	}
+
+	for _, alphabet := range []string{"", "a", "abca", "ab_c", "äb"} {
+		if _, err := hexGen.WithAlphabet(alphabet, 8); err == nil {
+			t.Errorf("WithAlphabet(%q) should fail", alphabet)
+		}
+	}
+}
+
+func TestSequentialGenerator(t *testing.T) {
+	g := NewSequentialGenerator(SequentialPrefix, SequentialSuffix)
+	if !g.Sequential() || g.Deterministic() {
+		t.Error("sequential generator should be sequential and not deterministic")
+	}
+	if NewGenerator("__SECRET_", "__").NextSequence("") != 0 {
+		t.Error("NextSequence() of a hash generator should be 0")
+	}
+
+	if n := g.NextSequence("alice"); n != 1 {
+		t.Errorf("NextSequence(alice) = %d, want 1", n)
+	}
+	if n := g.NextSequence("alice"); n != 2 {
+		t.Errorf("NextSequence(alice) = %d, want 2", n)
+	}
+	if n := g.NextSequence("bob"); n != 1 {
+		t.Errorf("NextSequence(bob) = %d, want 1", n)
+	}
+
+	if ph := g.GenerateSequence(2, "api_key"); ph != "[SECRET_2]" {
+		t.Errorf("GenerateSequence() = %q, want [SECRET_2]", ph)
+	}
+	tagged := g.WithTypeTags()
+	if ph := tagged.GenerateSequence(12, "api_key"); ph != "[SECRET_APIKEY_12]" {
+		t.Errorf("GenerateSequence() with type tags = %q, want [SECRET_APIKEY_12]", ph)
+	}
+
+	text := "Use [SECRET_1] and [SECRET_APIKEY_12], not [SECRET_0] or [SECRET_x]"
+	if got := tagged.FindAll(text); len(got) != 2 || got[0] != "[SECRET_1]" || got[1] != "[SECRET_APIKEY_12]" {
+		t.Errorf("FindAll() = %v", got)
+	}
+	if len("[SECRET_APIKEY_1234567890]") > tagged.MaxLength() {
+		t.Errorf("MaxLength() = %d too short", tagged.MaxLength())
+	}
+}
+
+func TestKeyedGenerator(t *testing.T) {
+	plain := NewGenerator("__SECRET_", "__")
+	g := NewKeyedGenerator("__SECRET_", "__", DefaultHashLength, []byte("0123456789abcdef"))
+	other := NewKeyedGenerator("__SECRET_", "__", DefaultHashLength, []byte("fedcba9876543210"))
+
+	secret := "hunter2"
+	ph := g.Generate(secret)
+	if !g.Deterministic() || ph != g.Generate(secret) {
+		t.Error("keyed placeholders should be deterministic")
+	}
+	if ph == plain.Generate(secret) {
+		t.Error("keyed placeholder equals the bare SHA-256 placeholder")
+	}
+	if ph == other.Generate(secret) {
+		t.Error("placeholders under different keys are equal")
+	}
+	if !g.IsPlaceholder(ph) {
+		t.Errorf("IsPlaceholder(%q) = false, want true", ph)
+	}
+	if long := g.GenerateLength(secret, 16); long[:len(ph)-2] != ph[:len(ph)-2] {
+		t.Errorf("lengthened placeholder %q does not extend %q", long, ph)
+	}
+}
+
+func TestGenerator_WithLegacy(t *testing.T) {
+	legacy := NewGenerator("__SECRET_", "__")
+	g := NewGenerator("<<S_", ">>").WithLegacy(legacy, NewSequentialGenerator(SequentialPrefix, SequentialSuffix))
+
+	secret := "sk-a8Kd9fJ2mN4pQ7xR3yZ5"
+	current := g.Generate(secret)
+	old := legacy.Generate(secret)
+	if current == old || current[:4] != "<<S_" {
+		t.Errorf("Generate() = %q, want the current scheme", current)
+	}
+
+	text := "new " + current + " old " + old + " numbered [SECRET_3]"
+	if got := g.FindAll(text); len(got) != 3 || got[0] != current || got[1] != old || got[2] != "[SECRET_3]" {
+		t.Errorf("FindAll() = %v", got)
+	}
+	if legacyOnly := legacy.FindAll(text); len(legacyOnly) != 1 {
+		t.Errorf("legacy generator changed: FindAll() = %v", legacyOnly)
+	}
+
+	restored := g.RestorePlaceholders(text, func(ph string) (string, bool) {
+		return secret, ph == current || ph == old
+	})
+	if restored != "new "+secret+" old "+secret+" numbered [SECRET_3]" {
+		t.Errorf("RestorePlaceholders() = %q", restored)
+	}
+
+	tagged := NewGenerator("__SECRET_", "__").WithTypeTags()
+	if g2 := NewGenerator("<<S_", ">>").WithLegacy(tagged); g2.MaxLength() != tagged.MaxLength() {
+		t.Errorf("MaxLength() = %d, want the longest scheme %d", g2.MaxLength(), tagged.MaxLength())
+	}
+}
+
+func TestSequentialGenerator_DropsUnusedCounters(t *testing.T) {
+	g := NewSequentialGenerator(SequentialPrefix, SequentialSuffix).WithSequenceTTL(time.Hour)
+	start := time.Now()
+
+	g.sequence.advance("conn-1", start)
+	g.sequence.advance("conn-2", start)
+	if n := g.sequence.advance("conn-2", start.Add(30*time.Minute)); n != 2 {
+		t.Errorf("advance(conn-2) = %d, want 2", n)
+	}
+	if n := g.sequence.advance("conn-3", start.Add(time.Hour)); n != 1 {
+		t.Errorf("advance(conn-3) = %d, want 1", n)
+	}
+	if _, ok := g.sequence.next["conn-1"]; ok {
+		t.Error("counter unused for the TTL was kept")
+	}
+	if len(g.sequence.next) != 2 {
+		t.Errorf("counters = %d, want conn-2 and conn-3", len(g.sequence.next))
+	}
+}
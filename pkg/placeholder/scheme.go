@@ -0,0 +1,106 @@
+package placeholder
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+)
+
+// Scheme produces and recognizes placeholders. Generator is the reference
+// implementation; custom schemes can be recognized alongside it via
+// Generator.WithLegacy.
+type Scheme interface {
+	// Generate creates the placeholder of secret
+	Generate(secret string) string
+	// Pattern matches the scheme's placeholders
+	Pattern() *regexp.Regexp
+	// MaxLength returns the maximum length of a placeholder in bytes
+	MaxLength() int
+}
+
+var _ Scheme = (*Generator)(nil)
+
+// HashFunc derives the hash a placeholder is built from. It must return at
+// least 32 bytes; only the first 32 are used.
+type HashFunc func(secret string) []byte
+
+// SHA256 hashes secrets with bare SHA-256, the default
+func SHA256(secret string) []byte {
+	hash := sha256.Sum256([]byte(secret))
+	return hash[:]
+}
+
+// HMACSHA256 returns a hash function computing HMAC-SHA-256 under key
+func HMACSHA256(key []byte) HashFunc {
+	key = append([]byte(nil), key...)
+	return func(secret string) []byte {
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(secret))
+		return mac.Sum(nil)
+	}
+}
+
+// WithHash returns a copy of the generator deriving placeholders with hash
+func (g *Generator) WithHash(hash HashFunc) *Generator {
+	hashed := *g
+	hashed.hash = hash
+	return &hashed
+}
+
+// Pattern returns the regular expression matching the generator's placeholders
+func (g *Generator) Pattern() *regexp.Regexp {
+	return g.pattern
+}
+
+// Options configures a Generator built by New
+type Options struct {
+	// Prefix and Suffix enclose the hash
+	Prefix string
+	Suffix string
+	// HashLength is the number of hash characters; 0 uses DefaultHashLength
+	// for hex and the shortest length carrying 32 bits for other alphabets
+	HashLength int
+	// Alphabet encodes the hash; empty uses HexAlphabet
+	Alphabet string
+	// Hash derives the hash; nil uses SHA256
+	Hash HashFunc
+	// TypeTags encodes the secret type in placeholders
+	TypeTags bool
+	// Legacy schemes are recognized in addition to the generated placeholders
+	Legacy []Scheme
+}
+
+// New creates a generator from opts. Unlike the other constructors, it
+// rejects invalid settings instead of clamping them, so embedders notice
+// when they would produce placeholders the proxy does not recognize.
+func New(opts Options) (*Generator, error) {
+	g := NewGenerator(opts.Prefix, opts.Suffix)
+	if opts.Alphabet != "" && opts.Alphabet != HexAlphabet {
+		var err error
+		if g, err = g.WithAlphabet(opts.Alphabet, opts.HashLength); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.HashLength != 0 {
+		minLen, maxLen := g.HashLengthRange()
+		if opts.HashLength < minLen || opts.HashLength > maxLen {
+			return nil, fmt.Errorf("placeholder hash length must be between %d and %d, got %d",
+				minLen, maxLen, opts.HashLength)
+		}
+		g.hashLen = opts.HashLength
+		g.compile()
+	}
+
+	if opts.Hash != nil {
+		g = g.WithHash(opts.Hash)
+	}
+	if opts.TypeTags {
+		g = g.WithTypeTags()
+	}
+	if len(opts.Legacy) > 0 {
+		g = g.WithLegacy(opts.Legacy...)
+	}
+	return g, nil
+}
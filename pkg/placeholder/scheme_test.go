@@ -0,0 +1,86 @@
+package placeholder
+
+import (
+	"crypto/sha512"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestNew(t *testing.T) {
+	g, err := New(Options{Prefix: "__SECRET_", Suffix: "__"})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	secret := "sk-a8Kd9fJ2mN4pQ7xR3yZ5"
+	if got, want := g.Generate(secret), NewGenerator("__SECRET_", "__").Generate(secret); got != want {
+		t.Errorf("Generate() = %q, want %q", got, want)
+	}
+
+	key := []byte("0123456789abcdef")
+	keyed, err := New(Options{Prefix: "__SECRET_", Suffix: "__", Hash: HMACSHA256(key), TypeTags: true})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	want := NewKeyedGenerator("__SECRET_", "__", DefaultHashLength, key).WithTypeTags().GenerateTyped(secret, "token", DefaultHashLength)
+	if got := keyed.GenerateTyped(secret, "token", DefaultHashLength); got != want {
+		t.Errorf("GenerateTyped() = %q, want %q", got, want)
+	}
+
+	compact, err := New(Options{Prefix: CompactPrefix, Suffix: CompactSuffix, Alphabet: CompactAlphabet})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	if compact.HashLength() != 6 {
+		t.Errorf("HashLength() = %d, want the shortest base62 length 6", compact.HashLength())
+	}
+
+	for _, opts := range []Options{
+		{Prefix: "<", Suffix: ">", HashLength: 4},
+		{Prefix: "<", Suffix: ">", HashLength: 65},
+		{Prefix: "<", Suffix: ">", Alphabet: "ab-c"},
+		{Prefix: "<", Suffix: ">", Alphabet: CompactAlphabet, HashLength: 44},
+	} {
+		if _, err := New(opts); err == nil {
+			t.Errorf("New(%+v) should fail", opts)
+		}
+	}
+}
+
+func TestGenerator_WithHash(t *testing.T) {
+	sha512Hash := func(secret string) []byte {
+		hash := sha512.Sum512([]byte(secret))
+		return hash[:]
+	}
+	g := NewGenerator("__SECRET_", "__").WithHash(sha512Hash)
+
+	secret := "mysecretpassword"
+	ph := g.Generate(secret)
+	if ph == NewGenerator("__SECRET_", "__").Generate(secret) {
+		t.Error("custom hash function ignored")
+	}
+	if ph != g.Generate(secret) || !g.IsPlaceholder(ph) {
+		t.Errorf("Generate() = %q not stable or not recognized", ph)
+	}
+	if got := NewGenerator("__SECRET_", "__").WithHash(SHA256).Generate(secret); got != NewGenerator("__SECRET_", "__").Generate(secret) {
+		t.Errorf("SHA256 hash = %q differs from the default", got)
+	}
+}
+
+// upperScheme is a custom scheme recognized next to a Generator
+type upperScheme struct{}
+
+func (upperScheme) Generate(secret string) string { return "{{" + strings.ToUpper(secret) + "}}" }
+func (upperScheme) Pattern() *regexp.Regexp       { return regexp.MustCompile(`\{\{[A-Z]+\}\}`) }
+func (upperScheme) MaxLength() int                { return 100 }
+
+func TestGenerator_WithLegacyScheme(t *testing.T) {
+	g := NewGenerator("__SECRET_", "__").WithLegacy(upperScheme{})
+	ph := upperScheme{}.Generate("abc")
+	if !g.IsPlaceholder(ph) {
+		t.Errorf("IsPlaceholder(%q) = false, want true", ph)
+	}
+	if g.MaxLength() != 100 {
+		t.Errorf("MaxLength() = %d, want 100", g.MaxLength())
+	}
+}